@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"bot-jual/internal/backup"
+	"bot-jual/internal/config"
+	"bot-jual/internal/logging"
+	"bot-jual/internal/metrics"
+	"bot-jual/internal/objstore"
+	"bot-jual/internal/repo"
+
+	"github.com/joho/godotenv"
+)
+
+// newBackupRunner builds the backup runner for the configured database.
+// repository takes SQLite snapshots and may be nil when only restoring.
+func newBackupRunner(cfg *config.Config, repository repo.Repository, metricRegistry *metrics.Metrics, logger *slog.Logger, transport http.RoundTripper) (*backup.Runner, error) {
+	var source backup.Source
+	if cfg.IsSQLite {
+		snapshotter, _ := repository.(backup.Snapshotter)
+		source = backup.SQLite{DB: snapshotter, Path: cfg.DatabaseURL}
+	} else if cfg.IsMySQL {
+		source = backup.MySQL{
+			URL:       cfg.DatabaseURL,
+			MySQLDump: cfg.BackupMySQLDump,
+			MySQL:     cfg.BackupMySQLClient,
+		}
+	} else {
+		source = backup.Postgres{
+			URL:       cfg.DatabaseURL,
+			Schema:    cfg.SupabaseSchema,
+			PgDump:    cfg.BackupPgDump,
+			PgRestore: cfg.BackupPgRestore,
+		}
+	}
+	runner := backup.New(source, metricRegistry, logger, backup.Config{
+		Dir:      cfg.BackupDir,
+		Keep:     cfg.BackupKeep,
+		At:       cfg.BackupAt,
+		Location: repo.DefaultZone,
+	})
+	if cfg.BackupS3Bucket != "" {
+		// Backups share the catalog export's storage account.
+		store, err := objstore.New(objstore.Config{
+			Endpoint:  cfg.CatalogS3Endpoint,
+			Region:    cfg.CatalogS3Region,
+			Bucket:    cfg.BackupS3Bucket,
+			AccessKey: cfg.CatalogS3AccessKey,
+			SecretKey: cfg.CatalogS3SecretKey,
+			PathStyle: cfg.CatalogS3PathStyle,
+		}, transport)
+		if err != nil {
+			return nil, fmt.Errorf("backup storage: %w", err)
+		}
+		runner.SetUploader(store, cfg.BackupS3Prefix)
+	}
+	return runner, nil
+}
+
+// runBackupNow takes one backup outside the nightly schedule:
+//
+//	app backup
+func runBackupNow(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cfg, logger, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+
+	var repository repo.Repository
+	if cfg.IsSQLite {
+		repository, err = repo.NewSQLite(ctx, cfg.DatabaseURL, logger)
+		if err != nil {
+			return fmt.Errorf("init repository: %w", err)
+		}
+		defer repository.Close()
+	}
+	runner, err := newBackupRunner(cfg, repository, nil, logger, nil)
+	if err != nil {
+		return err
+	}
+	file, err := runner.Backup(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(file)
+	return nil
+}
+
+// runRestore replaces the database with a backup, the newest one in
+// BACKUP_DIR unless -file names another. Stop the app first:
+//
+//	app restore -yes
+//	app restore -file data/backups/bot-jual-20260101T200000Z.db -yes
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := fs.String("file", "", "backup to restore (default: the newest in BACKUP_DIR)")
+	confirm := fs.Bool("yes", false, "confirm that the current database may be overwritten")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cfg, logger, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+
+	path := *file
+	if path == "" {
+		if path, err = backup.Latest(cfg.BackupDir); err != nil {
+			return err
+		}
+	}
+	if !*confirm {
+		return fmt.Errorf("restoring %s overwrites the current database; stop the app and rerun with -yes", path)
+	}
+	runner, err := newBackupRunner(cfg, nil, nil, logger, nil)
+	if err != nil {
+		return err
+	}
+	if err := runner.Restore(ctx, path); err != nil {
+		return err
+	}
+	fmt.Printf("restored %s\n", path)
+	return nil
+}
+
+func loadCommandConfig() (*config.Config, *slog.Logger, error) {
+	_ = godotenv.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	logger := logging.New(logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
+	return cfg, logger, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/convo"
+	"bot-jual/internal/loadtest"
+	"bot-jual/internal/logging"
+	"bot-jual/internal/metrics"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/persona"
+	"bot-jual/internal/repo"
+	"bot-jual/migrations"
+)
+
+// runLoadTest drives synthetic conversations through a real conversation
+// engine backed by a scratch SQLite database and fake WhatsApp, Atlantic and
+// LLM endpoints, then prints throughput and latency percentiles:
+//
+//	app loadtest -rate 20 -duration 1m -llm-latency 800ms
+func runLoadTest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	rate := fs.Float64("rate", 5, "conversations started per second")
+	duration := fs.Duration("duration", 30*time.Second, "how long new conversations keep starting")
+	think := fs.Duration("think", 500*time.Millisecond, "pause between a reply and the next user message")
+	dbPath := fs.String("db", "", "SQLite database to use (default: a scratch file)")
+	llmLatency := fs.Duration("llm-latency", 600*time.Millisecond, "simulated LLM response time")
+	supplierLatency := fs.Duration("supplier-latency", 150*time.Millisecond, "simulated Atlantic response time")
+	sendLatency := fs.Duration("send-latency", 50*time.Millisecond, "simulated WhatsApp send time")
+	maxConcurrent := fs.Int("llm-concurrency", 4, "concurrent LLM calls, as GEMINI_MAX_CONCURRENT")
+	queueSize := fs.Int("llm-queue", 64, "queued LLM calls, as GEMINI_QUEUE_SIZE")
+	logLevel := fs.String("log-level", "error", "engine log level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger := logging.NewLogger(*logLevel)
+
+	path := *dbPath
+	if path == "" {
+		dir, err := os.MkdirTemp("", "bot-jual-loadtest-")
+		if err != nil {
+			return fmt.Errorf("create scratch dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "loadtest.db")
+	}
+	repository, err := repo.NewSQLite(ctx, path, logger)
+	if err != nil {
+		return fmt.Errorf("init repository: %w", err)
+	}
+	defer repository.Close()
+	if err := repository.RunMigrations(ctx, migrations.Files); err != nil {
+		return fmt.Errorf("run migrations: %w", err)
+	}
+
+	supplier := loadtest.NewSupplier(*supplierLatency)
+	defer supplier.Close()
+	llm := loadtest.NewLLM(*llmLatency, loadtest.DefaultScript)
+	defer llm.Close()
+	gateway := &loadtest.Gateway{Latency: *sendLatency}
+
+	metricRegistry := metrics.New(metrics.Options{Namespace: "loadtest"})
+	replyPersona := persona.Persona{ShopName: "Load Test", Tone: "casual"}
+	nluClient := nlu.New(repository, logger, metricRegistry, nlu.Config{
+		Timeout:       30 * time.Second,
+		Persona:       replyPersona,
+		MaxConcurrent: *maxConcurrent,
+		QueueSize:     *queueSize,
+		Providers:     []string{nlu.ProviderOpenAI},
+		OpenAI:        nlu.OpenAIConfig{BaseURL: llm.URL(), Model: "loadtest"},
+	})
+	atlClient := atl.New(atl.Config{BaseURL: supplier.URL(), APIKey: "loadtest"}, logger, metricRegistry, nil)
+	engine := convo.New(repository, nluClient, atlClient, gateway, nil, metricRegistry, logger, convo.EngineConfig{
+		Persona:          replyPersona,
+		ReceiptDocuments: true,
+	})
+
+	fmt.Printf("load test: %.1f conversations/s for %s, %d messages each\n", *rate, *duration, len(loadtest.DefaultScript))
+	report := loadtest.Run(ctx, engine, loadtest.Config{
+		Rate:       *rate,
+		Duration:   *duration,
+		Think:      *think,
+		Script:     loadtest.DefaultScript,
+		FirstPhone: 628990000000 + uint64(time.Now().Unix()%100000)*1000,
+	})
+	fmt.Println()
+	fmt.Print(report.String())
+	texts, images, docs := gateway.Sent()
+	fmt.Printf("\nreplies: %d text, %d image, %d document; supplier calls: %d; llm calls: %d\n", texts, images, docs, supplier.Calls(), llm.Calls())
+	return nil
+}
@@ -3,30 +3,62 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // users.timezone must resolve in images without zoneinfo
 
+	"bot-jual/internal/activity"
 	"bot-jual/internal/atl"
+	"bot-jual/internal/broadcast"
 	"bot-jual/internal/cache"
+	"bot-jual/internal/chaos"
 	"bot-jual/internal/config"
 	"bot-jual/internal/convo"
+	"bot-jual/internal/digiflazz"
 	"bot-jual/internal/handlers"
 	"bot-jual/internal/httpserver"
+	"bot-jual/internal/httpx"
+	"bot-jual/internal/jobs"
 	"bot-jual/internal/logging"
 	"bot-jual/internal/metrics"
+	"bot-jual/internal/midtrans"
 	"bot-jual/internal/nlu"
+	"bot-jual/internal/notify"
+	"bot-jual/internal/objstore"
+	"bot-jual/internal/persona"
+	"bot-jual/internal/receipt"
 	"bot-jual/internal/repo"
+	"bot-jual/internal/reports"
+	"bot-jual/internal/supplier"
 	"bot-jual/internal/wa"
+	"bot-jual/internal/xendit"
 	"bot-jual/migrations"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	switch command {
+	case "loadtest":
+		err = runLoadTest(os.Args[2:])
+	case "backup":
+		err = runBackupNow(os.Args[2:])
+	case "restore":
+		err = runRestore(os.Args[2:])
+	default:
+		err = run()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
 		os.Exit(1)
 	}
@@ -40,7 +72,16 @@ func run() error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	logger := logging.NewLogger(cfg.LogLevel)
+	var logRing *logging.Ring
+	if cfg.LogRingSize > 0 {
+		logRing = logging.NewRing(cfg.LogRingSize)
+	}
+	logger := logging.New(logging.Config{
+		Level:           cfg.LogLevel,
+		Format:          cfg.LogFormat,
+		ComponentLevels: cfg.LogComponentLevels,
+		Ring:            logRing,
+	})
 	logger.Info("starting wa-sales-bot", "env", cfg.AppEnv)
 
 	if cfg.PublicBaseURL != "" {
@@ -51,13 +92,20 @@ func run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	metricRegistry := metrics.Registry(cfg.MetricsNamespace)
+	metricRegistry := metrics.New(metrics.Options{
+		Namespace: cfg.MetricsNamespace,
+		Env:       cfg.AppEnv,
+		Instance:  cfg.MetricsInstance,
+	})
 
 	var repository repo.Repository
 
 	if cfg.IsSQLite {
 		logger.Info("using sqlite database", "path", cfg.DatabaseURL)
 		repository, err = repo.NewSQLite(ctx, cfg.DatabaseURL, logger)
+	} else if cfg.IsMySQL {
+		logger.Info("using mysql database")
+		repository, err = repo.NewMySQL(ctx, cfg.DatabaseURL, logger)
 	} else {
 		logger.Info("using postgres database")
 		repository, err = repo.NewPostgres(ctx, cfg.DatabaseURL, cfg.SupabaseSchema, logger)
@@ -70,18 +118,37 @@ func run() error {
 	if err := repository.RunMigrations(ctx, migrations.Files); err != nil {
 		return fmt.Errorf("run migrations: %w", err)
 	}
+	// Order, deposit and message writes go through tracked so /admin/events
+	// sees them; backups keep the raw repository for its snapshot support.
+	activityBus := activity.NewBus()
+	tracked := activity.Track(repository, activityBus)
 	logger.Info("database migrated")
 
 	if err := repository.SyncGeminiKeys(ctx, cfg.GeminiAPIKeys); err != nil {
 		return fmt.Errorf("sync gemini keys: %w", err)
 	}
 
+	// chaosInjector stays nil unless CHAOS_ENABLED; a nil injector never fails.
+	var chaosInjector *chaos.Injector
+	if cfg.ChaosEnabled {
+		chaosInjector = chaos.New()
+		for fault, rate := range cfg.ChaosFaults {
+			if err := chaosInjector.Set(fault, rate, 0); err != nil {
+				return fmt.Errorf("chaos faults: %w", err)
+			}
+		}
+		logger.Warn("failure injection enabled, dependencies may fail on purpose", "faults", chaosInjector.Active())
+	}
+
 	redisClient := cache.New(cache.Config{
 		Addr:     cfg.RedisAddr,
 		Password: cfg.RedisPassword,
 		DB:       cfg.RedisDB,
 		UseTLS:   cfg.RedisTLS,
 	}, logger)
+	if chaosInjector != nil {
+		redisClient.Client().AddHook(chaosInjector.RedisHook())
+	}
 	defer func() {
 		if err := redisClient.Close(); err != nil {
 			logger.Warn("failed closing redis", "error", err)
@@ -90,48 +157,277 @@ func run() error {
 	if err := redisClient.Ping(ctx); err != nil {
 		logger.Warn("redis ping failed", "error", err)
 	}
+	metricRegistry.RedisUp.Set(1)
+	go redisClient.Monitor(ctx, 15*time.Second, func(up bool) {
+		if up {
+			metricRegistry.RedisUp.Set(1)
+		} else {
+			metricRegistry.RedisUp.Set(0)
+		}
+	})
+
+	replyPersona := persona.Persona{
+		ShopName:  cfg.ShopName,
+		Tone:      cfg.ReplyTone,
+		NoEmoji:   !cfg.ReplyEmoji,
+		Signature: cfg.ReplySignature,
+	}
+	// Receipt links need both a signing secret and a public URL.
+	receiptLinks := receipt.New(cfg.ReceiptSecret, cfg.PublicBaseURL)
+
+	transportCfg := httpx.TransportConfig{
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.HTTPIdleConnTimeout,
+		DialTimeout:         cfg.HTTPDialTimeout,
+		KeepAlive:           cfg.HTTPKeepAlive,
+		TLSHandshakeTimeout: cfg.HTTPTLSHandshakeTimeout,
+	}
+	atlTransportCfg, nluTransportCfg := transportCfg, transportCfg
+	atlTransportCfg.Proxy = cfg.AtlanticProxyURL
+	nluTransportCfg.Proxy = cfg.GeminiProxyURL
+	atlTransport := httpx.NewTransport(atlTransportCfg)
+	nluTransport := atlTransport
+	if !cfg.HTTPShareTransport || !sameProxy(cfg.AtlanticProxyURL, cfg.GeminiProxyURL) {
+		nluTransport = httpx.NewTransport(nluTransportCfg)
+	}
+	var atlRoundTripper, nluRoundTripper http.RoundTripper = atlTransport, nluTransport
+	if chaosInjector != nil {
+		atlRoundTripper = chaosInjector.Transport(chaos.Atlantic, atlTransport)
+		nluRoundTripper = chaosInjector.Transport(chaos.Gemini, nluTransport)
+	}
+
+	var proxyProbes []*httpx.ProxyProbe
+	if cfg.AtlanticProxyURL != nil {
+		logger.Info("atlantic calls use proxy", "proxy", cfg.AtlanticProxyURL.Redacted())
+		proxyProbes = append(proxyProbes, httpx.NewProxyProbe("atlantic", cfg.AtlanticProxyURL, logger, metricRegistry, cfg.ProxyProbeInterval))
+	}
+	if cfg.GeminiProxyURL != nil {
+		logger.Info("gemini calls use proxy", "proxy", cfg.GeminiProxyURL.Redacted())
+		proxyProbes = append(proxyProbes, httpx.NewProxyProbe("gemini", cfg.GeminiProxyURL, logger, metricRegistry, cfg.ProxyProbeInterval))
+	}
+	for _, probe := range proxyProbes {
+		go probe.Run(ctx)
+	}
 
 	nluClient := nlu.New(repository, logger, metricRegistry, nlu.Config{
-		Model:    cfg.GeminiModel,
-		Timeout:  cfg.GeminiTimeout,
-		Cooldown: cfg.GeminiCooldown,
+		Model:         cfg.GeminiModel,
+		Timeout:       cfg.GeminiTimeout,
+		Cooldown:      cfg.GeminiCooldown,
+		Persona:       replyPersona,
+		Redis:         redisClient,
+		MaxConcurrent: cfg.GeminiMaxConcurrent,
+		QueueSize:     cfg.GeminiQueueSize,
+		BusyThreshold: cfg.GeminiBusyThreshold,
+		Transport:     nluRoundTripper,
+		Whisper: nlu.WhisperConfig{
+			URL:      cfg.WhisperURL,
+			APIKey:   cfg.WhisperAPIKey,
+			Model:    cfg.WhisperModel,
+			Language: cfg.WhisperLanguage,
+		},
+		Providers: cfg.NLUProviders,
+		OpenAI: nlu.OpenAIConfig{
+			BaseURL: cfg.OpenAIBaseURL,
+			APIKey:  cfg.OpenAIAPIKey,
+			Model:   cfg.OpenAIModel,
+		},
+		Ollama: nlu.OllamaConfig{
+			BaseURL: cfg.OllamaURL,
+			Model:   cfg.OllamaModel,
+		},
 	})
 
 	atlClient := atl.New(atl.Config{
-		BaseURL: cfg.AtlanticBaseURL,
-		APIKey:  cfg.AtlanticAPIKey,
-		Timeout: cfg.AtlanticTimeout,
+		BaseURL:   cfg.AtlanticBaseURL,
+		APIKey:    cfg.AtlanticAPIKey,
+		Timeout:   cfg.AtlanticTimeout,
+		Transport: atlRoundTripper,
+
+		BreakerThreshold: cfg.AtlanticBreakerThreshold,
+		BreakerCooldown:  cfg.AtlanticBreakerCooldown,
 	}, logger, metricRegistry, redisClient)
 
+	if cfg.CatalogS3Bucket != "" {
+		store, err := objstore.New(objstore.Config{
+			Endpoint:  cfg.CatalogS3Endpoint,
+			Region:    cfg.CatalogS3Region,
+			Bucket:    cfg.CatalogS3Bucket,
+			AccessKey: cfg.CatalogS3AccessKey,
+			SecretKey: cfg.CatalogS3SecretKey,
+			PathStyle: cfg.CatalogS3PathStyle,
+		}, httpx.NewTransport(transportCfg))
+		if err != nil {
+			return fmt.Errorf("catalog export storage: %w", err)
+		}
+		atlClient.SetCatalogExport(store, cfg.CatalogS3Prefix)
+		if cfg.CatalogExportInterval > 0 {
+			go atlClient.RunCatalogExport(ctx, cfg.CatalogExportInterval, atl.DefaultCatalogPath)
+		}
+		logger.Info("catalog export enabled", "bucket", cfg.CatalogS3Bucket, "prefix", cfg.CatalogS3Prefix, "interval", cfg.CatalogExportInterval)
+	}
+
+	atlProbe := atl.NewHealthProbe(atlClient, logger, metricRegistry, cfg.AtlanticProbeInterval)
+	go atlProbe.Run(ctx)
+
+	if cfg.AdminAlertWebhookURL != "" {
+		// WhatsApp alerts go out of band: the admins' usual channel is the
+		// one that is down.
+		go activity.Forward(ctx, activityBus, cfg.AdminAlertWebhookURL, []string{"whatsapp."}, nil, logger)
+	}
 	waClient, err := wa.New(ctx, wa.Config{
-		StorePath: cfg.WhatsAppStorePath,
-		LogLevel:  cfg.WhatsAppLogLevel,
-		Metrics:   metricRegistry,
+		StorePath:          cfg.WhatsAppStorePath,
+		LogLevel:           cfg.WhatsAppLogLevel,
+		Metrics:            metricRegistry,
+		TypingDelayPerChar: cfg.TypingDelayPerChar,
+		TypingDelayMax:     cfg.TypingDelayMax,
+		ResendAfter:        cfg.WhatsAppResendAfter,
+		MaxResends:         cfg.WhatsAppMaxResends,
+		PairPhone:          cfg.WhatsAppPairPhone,
+		Events:             activityBus,
 	}, logger)
 	if err != nil {
 		return fmt.Errorf("init whatsapp client: %w", err)
 	}
 	defer waClient.Close()
 
-	convoEngine := convo.New(repository, nluClient, atlClient, waClient, redisClient, metricRegistry, logger, convo.EngineConfig{
-		DefaultDepositMethod: cfg.AtlanticDepositMethod,
-		DefaultDepositType:   cfg.AtlanticDepositType,
-		DepositFeeFixed:      cfg.AtlanticDepositFeeFixed,
-		DepositFeePercent:    cfg.AtlanticDepositFeePercent,
+	convoEngine := convo.New(tracked, nluClient, atlClient, waClient, redisClient, metricRegistry, logger, convo.EngineConfig{
+		DefaultDepositMethod:      cfg.AtlanticDepositMethod,
+		DefaultDepositType:        cfg.AtlanticDepositType,
+		DepositFeeFixed:           cfg.AtlanticDepositFeeFixed,
+		DepositFeePercent:         cfg.AtlanticDepositFeePercent,
+		WithdrawMinAmount:         cfg.WithdrawMinAmount,
+		WithdrawFee:               cfg.WithdrawFee,
+		WithdrawDailyLimit:        cfg.WithdrawDailyLimit,
+		WithdrawApprovalThreshold: cfg.WithdrawApprovalThreshold,
+		ApprovalTTL:               cfg.ApprovalTTL,
+		AdminJIDs:                 cfg.AdminJIDs,
+		Receipts:                  receiptLinks,
+		MinMargin:                 cfg.MinMargin,
+		LowSuccessRate:            cfg.LowSuccessRate,
+		LowSuccessMinSamples:      cfg.LowSuccessMinSamples,
+		Persona:                   replyPersona,
+		MemorySummaryInterval:     cfg.MemorySummaryInterval,
+		MemoryMinMessages:         cfg.MemoryMinMessages,
+		CampaignAttributionWindow: cfg.CampaignAttributionWindow,
+		CurrencySymbolSpace:       cfg.CurrencySymbolSpace,
+		InteractiveMenus:          cfg.WhatsAppInteractive,
+		ReceiptDocuments:          cfg.ReceiptDocuments,
+		PriceCacheTTL:             cfg.PriceCacheTTL,
+		TrustLevels:               trustLevels(cfg.TrustLevels),
+		TrustProductMinOrders:     cfg.TrustProductMinOrders,
+		DepositReminderAfter:      cfg.DepositReminderAfter,
+		DepositExpireAfter:        cfg.DepositExpireAfter,
+		PLNInquiryCode:            cfg.PLNInquiryCode,
 	})
+	convoEngine.SetAtlanticProbe(atlProbe)
+	suppliers := []supplier.Supplier{supplier.NewAtlantic(atlClient, atlProbe)}
+	if cfg.DigiflazzUsername != "" {
+		suppliers = append(suppliers, digiflazz.New(digiflazz.Config{
+			Username:  cfg.DigiflazzUsername,
+			APIKey:    cfg.DigiflazzAPIKey,
+			SKUs:      cfg.DigiflazzSKUs,
+			Transport: httpx.NewTransport(transportCfg),
+		}, logger))
+		logger.Info("digiflazz enabled as an additional supplier", "mapped_skus", len(cfg.DigiflazzSKUs))
+	}
+	supplierRouter := supplier.NewRouter(logger, suppliers...)
+	convoEngine.SetSupplierRouter(supplierRouter)
+	convoEngine.SetContactChecker(waClient)
+	var midtransClient *midtrans.Client
+	if cfg.PaymentProvider == "midtrans" {
+		midtransClient = midtrans.New(midtrans.Config{
+			ServerKey:  cfg.MidtransServerKey,
+			Production: cfg.MidtransProduction,
+			Transport:  httpx.NewTransport(transportCfg),
+		}, logger)
+		convoEngine.SetPaymentProvider(cfg.PaymentProvider, midtransClient)
+		logger.Info("deposits are taken through midtrans", "production", cfg.MidtransProduction)
+	}
+	var xenditClient *xendit.Client
+	if cfg.PaymentProvider == "xendit" {
+		xenditClient = xendit.New(xendit.Config{
+			SecretKey: cfg.XenditSecretKey,
+			Expiry:    cfg.DepositExpireAfter,
+			Transport: httpx.NewTransport(transportCfg),
+		}, logger)
+		convoEngine.SetPaymentProvider(cfg.PaymentProvider, xenditClient)
+		logger.Info("deposits are taken through xendit")
+	}
+	go reloadOnSIGHUP(ctx, logger, cfg, convoEngine, nluClient)
 	waClient.SetMessageProcessor(convoEngine)
+	waClient.SetOutboundStore(repository)
+	waClient.SetMessageLog(tracked)
+	if chaosInjector != nil {
+		waClient.SetSendFault(chaosInjector.SendFault)
+	}
+
+	merchantEvents := notify.New(repository, logger, notify.Config{
+		Timeout:      cfg.MerchantWebhookTimeout,
+		MaxAttempts:  cfg.MerchantWebhookMaxAttempts,
+		PollInterval: cfg.MerchantWebhookPollInterval,
+	})
+	convoEngine.SetEventNotifier(merchantEvents)
+	go merchantEvents.Run(ctx)
 
 	// Fetch and save product catalog in background on startup.
 	go func() {
 		catalogCtx, catalogCancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer catalogCancel()
-		if err := atlClient.FetchAndSaveAllProducts(catalogCtx, "data/products.json"); err != nil {
+		if err := atlClient.FetchAndSaveAllProducts(catalogCtx, atl.DefaultCatalogPath); err != nil {
 			logger.Warn("failed to fetch product catalog on startup", "error", err)
 		}
 	}()
 
-	webhookProcessor := handlers.NewAtlanticWebhookProcessor(repository, waClient, metricRegistry, logger, atlClient)
-	webhookHandler := atl.NewWebhookHandler(logger, metricRegistry, cfg.AtlanticWebhookSecretMD5Username, cfg.AtlanticWebhookSecretMD5Password, webhookProcessor)
+	webhookProcessor := handlers.NewAtlanticWebhookProcessor(tracked, waClient, metricRegistry, logger, atlClient, handlers.ProcessorConfig{
+		AdminJIDs:                 cfg.AdminJIDs,
+		LowSuccessRate:            cfg.LowSuccessRate,
+		LowSuccessMinSamples:      cfg.LowSuccessMinSamples,
+		Persona:                   replyPersona,
+		CampaignAttributionWindow: cfg.CampaignAttributionWindow,
+		Receipts:                  receiptLinks,
+		OrderSLA:                  cfg.OrderSLA,
+		CurrencySymbolSpace:       cfg.CurrencySymbolSpace,
+		ReceiptDocuments:          cfg.ReceiptDocuments,
+		NotificationMaxAttempts:   cfg.NotificationMaxAttempts,
+	})
+	webhookProcessor.SetPriceCacheInvalidator(convoEngine)
+	webhookProcessor.SetSupplierRouter(supplierRouter)
+	webhookProcessor.SetDedupCache(redisClient)
+	webhookProcessor.SetEventNotifier(merchantEvents)
+	convoEngine.SetDepositSettler(webhookProcessor)
+	// Webhooks are acknowledged on receipt and processed by a worker pool.
+	webhookQueue := atl.NewWebhookQueue(webhookProcessor, logger, metricRegistry, atl.WebhookQueueConfig{
+		Workers:     cfg.WebhookWorkers,
+		QueueSize:   cfg.WebhookQueueSize,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+	})
+	webhookQueue.SetDeadLetterStore(webhookProcessor)
+	// The queue outlives the signal context: it stops only after the HTTP
+	// server has stopped accepting webhooks, so nothing is enqueued after it
+	// has dead-lettered its backlog.
+	queueCtx, queueCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer queueCancel()
+	queueDone := make(chan struct{})
+	go func() {
+		defer close(queueDone)
+		webhookQueue.Run(queueCtx)
+	}()
+	webhookCredentials := []atl.WebhookCredential{{UsernameMD5: cfg.AtlanticWebhookSecretMD5Username, PasswordMD5: cfg.AtlanticWebhookSecretMD5Password}}
+	for _, c := range cfg.AtlanticWebhookOldCredentials {
+		webhookCredentials = append(webhookCredentials, atl.WebhookCredential{UsernameMD5: c.UsernameMD5, PasswordMD5: c.PasswordMD5})
+	}
+	webhookHandler := atl.NewWebhookHandler(logger, metricRegistry, webhookCredentials, webhookQueue)
+	webhookHandler.SetHMACSecrets(cfg.AtlanticWebhookHMACSecrets)
+	webhookHandler.SetReplayWindow(cfg.AtlanticWebhookReplayWindow)
+	webhookHandler.SetDeadLetterStore(webhookProcessor)
+	var midtransWebhook http.Handler
+	if midtransClient != nil {
+		midtransWebhook = midtrans.NewWebhookHandler(logger, metricRegistry, cfg.MidtransServerKey, webhookProcessor)
+	}
+	var xenditWebhook http.Handler
+	if xenditClient != nil {
+		xenditWebhook = xendit.NewWebhookHandler(logger, metricRegistry, cfg.XenditCallbackToken, webhookProcessor)
+	}
 
 	waCtx, waCancel := context.WithCancel(ctx)
 	defer waCancel()
@@ -142,14 +438,63 @@ func run() error {
 		}
 	}()
 
+	campaignScheduler := broadcast.NewScheduler(repository, broadcast.New(waClient, logger), logger, broadcast.SchedulerConfig{
+		PollInterval: cfg.CampaignPollInterval,
+		Persona:      replyPersona,
+	})
+	campaignScheduler.SetCategoryResolver(atlClient)
+	go campaignScheduler.Run(waCtx)
+	go convoEngine.RunMemorySummaries(waCtx)
+	go convoEngine.RunApprovalExpiry(waCtx)
+	go convoEngine.RunScheduledOrders(waCtx)
+	go convoEngine.RunProductAlerts(waCtx)
+	go convoEngine.RunStaleDeposits(waCtx)
+	go webhookProcessor.RunOrderSLA(waCtx)
+	go webhookProcessor.RunNotificationRetries(waCtx)
+	pendingPoller := jobs.NewPendingPoller(tracked, supplierRouter, webhookProcessor, metricRegistry, logger, jobs.PendingPollerConfig{
+		Interval: cfg.PendingPollInterval,
+		MinAge:   cfg.PendingPollMinAge,
+		MaxAge:   cfg.PendingPollMaxAge,
+	})
+	go pendingPoller.Run(waCtx)
+	backupRunner, err := newBackupRunner(cfg, repository, metricRegistry, logger, httpx.NewTransport(transportCfg))
+	if err != nil {
+		return err
+	}
+	backupRunner.SetNotifier(waClient, cfg.AdminJIDs)
+	go backupRunner.Run(waCtx)
+	salesSummary := reports.NewDailySummary(repository, waClient, cfg.AdminJIDs, logger, reports.Config{
+		At:       cfg.SalesSummaryAt,
+		Location: repo.DefaultZone,
+	})
+	go salesSummary.Run(waCtx)
+	go waClient.RunResends(waCtx)
+
 	httpSrv := httpserver.New(cfg.HTTPListenAddr, logger, metricRegistry, httpserver.Handlers{
 		AtlanticWebhook: webhookHandler,
+		MidtransWebhook: midtransWebhook,
+		XenditWebhook:   xenditWebhook,
 	}, cfg.PublicBasePath)
 	httpSrv.SetDependencies(httpserver.Dependencies{
-		Repository: repository,
-		Redis:      redisClient,
-		NLU:        nluClient,
-		Atlantic:   atlClient,
+		Repository:    tracked,
+		Redis:         redisClient,
+		NLU:           nluClient,
+		Atlantic:      atlClient,
+		DeadLetters:   webhookProcessor,
+		AtlanticProbe: atlProbe,
+		ProxyProbes:   proxyProbes,
+		Receipts:      receiptLinks,
+		ShopName:      cfg.ShopName,
+		WhatsApp:      waClient,
+		Logs:          logRing,
+		Events:        activityBus,
+		Chaos:         chaosInjector,
+	})
+	httpSrv.SetAdminAuth(httpserver.AdminAuth{
+		APIKeys:   cfg.AdminAPIKeys,
+		JWTSecret: cfg.AdminJWTSecret,
+		JWTIssuer: cfg.AdminJWTIssuer,
+		JWTRole:   cfg.AdminJWTRole,
 	})
 
 	errCh := make(chan error, 1)
@@ -173,6 +518,21 @@ func run() error {
 	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("http server shutdown error", "error", err)
 	}
+	queueCancel()
+	select {
+	case <-queueDone:
+	case <-time.After(30 * time.Second):
+		logger.Warn("webhook queue did not stop in time")
+	}
 
 	return nil
 }
+
+// sameProxy reports whether two clients are configured with the same proxy, so
+// they can share one transport.
+func sameProxy(a, b *url.URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"bot-jual/internal/config"
+	"bot-jual/internal/convo"
+	"bot-jual/internal/nlu"
+)
+
+// reloadOnSIGHUP re-reads the environment and CONFIG_FILE on SIGHUP and
+// applies fees, limits, cooldowns and the price cache TTL without touching
+// the WhatsApp session. Other settings still need a restart.
+func reloadOnSIGHUP(ctx context.Context, logger *slog.Logger, current *config.Config, engine *convo.Engine, nluClient *nlu.Client) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		next, err := config.Load()
+		if err != nil {
+			logger.Error("config reload failed, keeping current settings", "error", err)
+			continue
+		}
+		engine.UpdateTunables(engineTunables(next))
+		nluClient.SetCooldown(next.GeminiCooldown)
+		if changed := restartOnlySettings(current, next); len(changed) > 0 {
+			logger.Warn("config reload ignored settings that need a restart", "settings", changed)
+		}
+		logger.Info("config reloaded", "file", next.ConfigFile)
+	}
+}
+
+func engineTunables(cfg *config.Config) convo.Tunables {
+	return convo.Tunables{
+		DepositFeeFixed:           cfg.AtlanticDepositFeeFixed,
+		DepositFeePercent:         cfg.AtlanticDepositFeePercent,
+		WithdrawMinAmount:         cfg.WithdrawMinAmount,
+		WithdrawFee:               cfg.WithdrawFee,
+		WithdrawDailyLimit:        cfg.WithdrawDailyLimit,
+		WithdrawApprovalThreshold: cfg.WithdrawApprovalThreshold,
+		ApprovalTTL:               cfg.ApprovalTTL,
+		MinMargin:                 cfg.MinMargin,
+		PriceCacheTTL:             cfg.PriceCacheTTL,
+		TrustLevels:               trustLevels(cfg.TrustLevels),
+		TrustProductMinOrders:     cfg.TrustProductMinOrders,
+	}
+}
+
+func trustLevels(levels []config.TrustLevel) []convo.TrustLevel {
+	out := make([]convo.TrustLevel, 0, len(levels))
+	for _, level := range levels {
+		out = append(out, convo.TrustLevel{MinOrders: level.MinOrders, MaxAmount: level.MaxAmount})
+	}
+	return out
+}
+
+// restartOnlySettings names the structural settings that differ between
+// the running and the reloaded configuration.
+func restartOnlySettings(prev, next *config.Config) []string {
+	var changed []string
+	for _, s := range []struct {
+		name       string
+		prev, next string
+	}{
+		{"DATABASE_URL", prev.DatabaseURL, next.DatabaseURL},
+		{"HTTP_LISTEN_ADDR", prev.HTTPListenAddr, next.HTTPListenAddr},
+		{"WHATSAPP_STORE_PATH", prev.WhatsAppStorePath, next.WhatsAppStorePath},
+		{"WHATSAPP_DEVICE_JID", prev.WhatsAppDeviceJID, next.WhatsAppDeviceJID},
+		{"REDIS_ADDR", prev.RedisAddr, next.RedisAddr},
+		{"ATL_API_KEY", prev.AtlanticAPIKey, next.AtlanticAPIKey},
+		{"ATL_BASE_URL", prev.AtlanticBaseURL, next.AtlanticBaseURL},
+		{"GEMINI_MODEL_FLASH_LITE", prev.GeminiModel, next.GeminiModel},
+		{"LOG_LEVEL", prev.LogLevel, next.LogLevel},
+	} {
+		if s.prev != s.next {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
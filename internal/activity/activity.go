@@ -0,0 +1,95 @@
+// Package activity publishes order, deposit and message events as the
+// repository records them, so operators can watch the shop live, along with
+// WhatsApp session alerts, which can also be forwarded to a webhook.
+package activity
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	OrderCreated    = "order.created"
+	OrderUpdated    = "order.updated"
+	DepositCreated  = "deposit.created"
+	DepositUpdated  = "deposit.updated"
+	MessageIncoming = "message.incoming"
+	MessageOutgoing = "message.outgoing"
+	// WhatsAppLoggedOut means WhatsApp revoked the bot's session, so admins
+	// cannot be told over WhatsApp and the bot is down until it is re-paired.
+	WhatsAppLoggedOut = "whatsapp.logged_out"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before it starts missing them.
+const subscriberBuffer = 256
+
+// Event is something that just happened.
+type Event struct {
+	Type string    `json:"type"`
+	At   time.Time `json:"at"`
+	Data any       `json:"data"`
+}
+
+// Bus fans events out to subscribers. Publishing never blocks: a
+// subscriber whose buffer is full misses the event.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event][]string
+	now  func() time.Time
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: map[chan Event][]string{}, now: time.Now}
+}
+
+// Publish delivers an event to every subscriber interested in its type.
+func (b *Bus) Publish(eventType string, data any) {
+	ev := Event{Type: eventType, At: b.now().UTC(), Data: data}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, prefixes := range b.subs {
+		if !wants(prefixes, eventType) {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe delivers events whose type starts with one of prefixes, e.g.
+// "order" or "message.incoming", or every event without prefixes, until
+// cancel is called, which also closes the channel.
+func (b *Bus) Subscribe(prefixes []string) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = prefixes
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func wants(prefixes []string, eventType string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
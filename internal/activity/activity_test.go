@@ -0,0 +1,120 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type fakeRepo struct {
+	repo.Repository
+}
+
+func (fakeRepo) InsertOrder(_ context.Context, order repo.Order) (*repo.Order, error) {
+	return &order, nil
+}
+
+func (fakeRepo) UpdateDepositStatus(context.Context, string, string, map[string]any) error {
+	return nil
+}
+
+func (fakeRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+func TestTrackPublishesWrites(t *testing.T) {
+	bus := NewBus()
+	orders, cancelOrders := bus.Subscribe([]string{"order"})
+	defer cancelOrders()
+	all, cancelAll := bus.Subscribe(nil)
+
+	r := Track(fakeRepo{}, bus)
+	ctx := context.Background()
+	if _, err := r.InsertOrder(ctx, repo.Order{OrderRef: "ORD1", ProductCode: "ML86", Amount: 20000, Status: "pending"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateDepositStatus(ctx, "DEP1", "success", nil); err != nil {
+		t.Fatal(err)
+	}
+	text := "halo"
+	if err := r.InsertMessage(ctx, repo.MessageRecord{UserID: "u1", Direction: "incoming", Type: "text", Content: &text}); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := <-orders
+	if ev.Type != OrderCreated || ev.Data.(OrderEvent).OrderRef != "ORD1" {
+		t.Fatalf("order event = %+v", ev)
+	}
+	if len(orders) != 0 {
+		t.Fatalf("order subscriber got %d unrelated events", len(orders))
+	}
+
+	var types []string
+	for len(all) > 0 {
+		types = append(types, (<-all).Type)
+	}
+	want := []string{OrderCreated, DepositUpdated, MessageIncoming}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] || types[2] != want[2] {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+
+	cancelAll()
+	if _, open := <-all; open {
+		t.Fatal("channel still open after cancel")
+	}
+}
+
+func TestForwardPostsMatchingEvents(t *testing.T) {
+	received := make(chan Event, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	bus := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Forward(ctx, bus, srv.URL, []string{"whatsapp."}, srv.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+		close(done)
+	}()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		bus.mu.Lock()
+		subscribed := len(bus.subs) == 1
+		bus.mu.Unlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("forwarder never subscribed")
+		}
+	}
+
+	bus.Publish(OrderCreated, OrderEvent{OrderRef: "ORD1"})
+	bus.Publish(WhatsAppLoggedOut, WhatsAppEvent{Reason: "device removed"})
+	select {
+	case ev := <-received:
+		data, _ := ev.Data.(map[string]any)
+		if ev.Type != WhatsAppLoggedOut || data["reason"] != "device removed" {
+			t.Fatalf("forwarded %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("logout alert not forwarded")
+	}
+	cancel()
+	<-done
+	if len(received) != 0 {
+		t.Fatalf("forwarded an event outside the prefixes: %+v", <-received)
+	}
+}
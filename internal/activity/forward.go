@@ -0,0 +1,66 @@
+package activity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// forwardTimeout bounds one webhook post.
+const forwardTimeout = 10 * time.Second
+
+// WhatsAppEvent describes a change in the WhatsApp session.
+type WhatsAppEvent struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// Forward posts every event whose type starts with one of prefixes to url as
+// JSON, until ctx ends. It carries alerts admins must get when nobody is
+// watching the dashboard and WhatsApp itself may be what broke. A failed
+// post is logged and dropped.
+func Forward(ctx context.Context, bus *Bus, url string, prefixes []string, client *http.Client, logger *slog.Logger) {
+	if client == nil {
+		client = &http.Client{Timeout: forwardTimeout}
+	}
+	events, cancel := bus.Subscribe(prefixes)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			if err := post(ctx, client, url, ev); err != nil {
+				logger.Error("failed forwarding admin alert", "error", err, "type", ev.Type)
+			}
+		}
+	}
+}
+
+func post(ctx context.Context, client *http.Client, url string, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
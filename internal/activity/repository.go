@@ -0,0 +1,102 @@
+package activity
+
+import (
+	"context"
+
+	"bot-jual/internal/repo"
+)
+
+// OrderEvent describes a new order or, with only OrderRef and Status set, a
+// status change.
+type OrderEvent struct {
+	OrderRef    string `json:"order_ref"`
+	UserID      string `json:"user_id,omitempty"`
+	ProductCode string `json:"product_code,omitempty"`
+	Amount      int64  `json:"amount,omitempty"`
+	Status      string `json:"status"`
+}
+
+// DepositEvent describes a new deposit or, with only DepositRef and Status
+// set, a status change.
+type DepositEvent struct {
+	DepositRef string `json:"deposit_ref"`
+	UserID     string `json:"user_id,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Amount     int64  `json:"amount,omitempty"`
+	Status     string `json:"status"`
+}
+
+// MessageEvent describes a logged WhatsApp message.
+type MessageEvent struct {
+	UserID  string `json:"user_id"`
+	Type    string `json:"type"`
+	Content string `json:"content,omitempty"`
+}
+
+// trackedRepository publishes an event after each successful write it
+// watches and passes everything else straight through.
+type trackedRepository struct {
+	repo.Repository
+	bus *Bus
+}
+
+// Track returns r publishing order, deposit and message writes to bus.
+func Track(r repo.Repository, bus *Bus) repo.Repository {
+	return &trackedRepository{Repository: r, bus: bus}
+}
+
+func (t *trackedRepository) InsertOrder(ctx context.Context, order repo.Order) (*repo.Order, error) {
+	saved, err := t.Repository.InsertOrder(ctx, order)
+	if err == nil {
+		t.bus.Publish(OrderCreated, OrderEvent{OrderRef: saved.OrderRef, UserID: saved.UserID, ProductCode: saved.ProductCode, Amount: saved.Amount, Status: saved.Status})
+	}
+	return saved, err
+}
+
+func (t *trackedRepository) UpdateOrderStatus(ctx context.Context, orderRef, status string, metadata map[string]any) error {
+	err := t.Repository.UpdateOrderStatus(ctx, orderRef, status, metadata)
+	if err == nil {
+		t.bus.Publish(OrderUpdated, OrderEvent{OrderRef: orderRef, Status: status})
+	}
+	return err
+}
+
+func (t *trackedRepository) MarkOrderSucceeded(ctx context.Context, orderRef string, metadata map[string]any) (bool, error) {
+	changed, err := t.Repository.MarkOrderSucceeded(ctx, orderRef, metadata)
+	if err == nil && changed {
+		t.bus.Publish(OrderUpdated, OrderEvent{OrderRef: orderRef, Status: "success"})
+	}
+	return changed, err
+}
+
+func (t *trackedRepository) InsertDeposit(ctx context.Context, dep repo.Deposit) (*repo.Deposit, error) {
+	saved, err := t.Repository.InsertDeposit(ctx, dep)
+	if err == nil {
+		t.bus.Publish(DepositCreated, DepositEvent{DepositRef: saved.DepositRef, UserID: saved.UserID, Method: saved.Method, Amount: saved.Amount, Status: saved.Status})
+	}
+	return saved, err
+}
+
+func (t *trackedRepository) UpdateDepositStatus(ctx context.Context, ref, status string, metadata map[string]any) error {
+	err := t.Repository.UpdateDepositStatus(ctx, ref, status, metadata)
+	if err == nil {
+		t.bus.Publish(DepositUpdated, DepositEvent{DepositRef: ref, Status: status})
+	}
+	return err
+}
+
+func (t *trackedRepository) InsertMessage(ctx context.Context, msg repo.MessageRecord) error {
+	err := t.Repository.InsertMessage(ctx, msg)
+	if err == nil {
+		eventType := MessageOutgoing
+		if msg.Direction == "incoming" {
+			eventType = MessageIncoming
+		}
+		ev := MessageEvent{UserID: msg.UserID, Type: msg.Type}
+		if msg.Content != nil {
+			ev.Content = *msg.Content
+		}
+		t.bus.Publish(eventType, ev)
+	}
+	return err
+}
@@ -0,0 +1,182 @@
+package atl
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// anomalySampleLimit caps the payload excerpt logged with an anomaly.
+const anomalySampleLimit = 512
+
+// Anomaly kinds reported when Atlantic payloads need heuristic parsing.
+const (
+	anomalyUnknownKey    = "unknown_key"
+	anomalyStringStatus  = "string_status"
+	anomalyStringCode    = "string_code"
+	anomalyMissingStatus = "missing_status"
+	anomalyFieldAlias    = "field_alias"
+	anomalyStringNumber  = "string_number"
+	anomalyNumericStatus = "numeric_status"
+)
+
+// payloadAnomaly is one sign of supplier schema drift, such as an unknown
+// key or a boolean sent as a string.
+type payloadAnomaly struct {
+	Kind   string
+	Detail string
+}
+
+var envelopeKeys = map[string]bool{"status": true, "message": true, "code": true, "data": true}
+
+// priceListFields are the keys PriceListItem.UnmarshalJSON reads; the first
+// entry of each field is the documented one, the rest are fallbacks.
+var priceListFields = [][]string{
+	{"code", "kode", "product_code"},
+	{"name", "layanan", "product_name"},
+	{"category", "kategori"},
+	{"provider", "operator"},
+	{"nominal", "nilai", "denom"},
+	{"price", "harga", "sell_price", "amount"},
+	{"status", "status_text", "status_id", "status_code"},
+	{"description", "keterangan", "note"},
+}
+
+var priceListKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	for _, field := range priceListFields {
+		for _, key := range field {
+			keys[key] = true
+		}
+	}
+	return keys
+}()
+
+// envelopeAnomalies inspects the raw envelope fields decoded by
+// responseEnvelope.UnmarshalJSON.
+func envelopeAnomalies(fields map[string]json.RawMessage) []payloadAnomaly {
+	var res []payloadAnomaly
+	for _, key := range sortedKeys(fields) {
+		if !envelopeKeys[key] {
+			res = append(res, payloadAnomaly{Kind: anomalyUnknownKey, Detail: key})
+		}
+	}
+	status, ok := fields["status"]
+	switch {
+	case !ok:
+		res = append(res, payloadAnomaly{Kind: anomalyMissingStatus})
+	case !isJSONBool(status):
+		res = append(res, payloadAnomaly{Kind: anomalyStringStatus, Detail: truncate(string(status), 32)})
+	}
+	if code, ok := fields["code"]; ok && !isJSONNumber(code) && string(code) != "null" {
+		res = append(res, payloadAnomaly{Kind: anomalyStringCode, Detail: truncate(string(code), 32)})
+	}
+	return res
+}
+
+// anomalies reports how far a decoded price list item strayed from the
+// documented shape.
+func (p PriceListItem) anomalies() []payloadAnomaly {
+	var res []payloadAnomaly
+	keys := make([]string, 0, len(p.Raw))
+	for key := range p.Raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !priceListKeys[key] {
+			res = append(res, payloadAnomaly{Kind: anomalyUnknownKey, Detail: key})
+		}
+	}
+	for _, field := range priceListFields {
+		if _, ok := p.Raw[field[0]]; ok {
+			continue
+		}
+		for _, alias := range field[1:] {
+			if _, ok := p.Raw[alias]; ok {
+				res = append(res, payloadAnomaly{Kind: anomalyFieldAlias, Detail: field[0] + "<-" + alias})
+				break
+			}
+		}
+	}
+	if _, ok := p.Raw["price"].(string); ok {
+		res = append(res, payloadAnomaly{Kind: anomalyStringNumber, Detail: "price"})
+	}
+	if _, ok := p.Raw["status"].(float64); ok {
+		res = append(res, payloadAnomaly{Kind: anomalyNumericStatus, Detail: "status"})
+	}
+	return res
+}
+
+// reportAnomalies counts each anomaly kind once per response and logs every
+// distinct anomaly at warn level the first time it is seen, with a payload
+// sample; repeats are logged at debug level so drift does not flood the logs.
+func (c *Client) reportAnomalies(endpoint string, anomalies []payloadAnomaly, sample []byte) {
+	if len(anomalies) == 0 {
+		return
+	}
+	counted := map[string]bool{}
+	for _, a := range anomalies {
+		if !counted[a.Kind] {
+			counted[a.Kind] = true
+			if c.metrics != nil {
+				c.metrics.AtlanticAnomalies.WithLabelValues(endpoint, a.Kind).Inc()
+			}
+		}
+		args := []any{"endpoint", endpoint, "kind", a.Kind, "detail", a.Detail}
+		if _, seen := c.anomaliesSeen.LoadOrStore(endpoint+"|"+a.Kind+"|"+a.Detail, struct{}{}); seen {
+			c.logger.Debug("atlantic payload anomaly", args...)
+			continue
+		}
+		c.logger.Warn("atlantic payload anomaly", append(args, "sample", truncate(string(sample), anomalySampleLimit))...)
+	}
+}
+
+// reportPriceListAnomalies reports anomalies across a price list, sampling
+// the first item that showed each one.
+func (c *Client) reportPriceListAnomalies(endpoint string, items []PriceListItem) {
+	var (
+		all    []payloadAnomaly
+		sample []byte
+		seen   = map[payloadAnomaly]bool{}
+	)
+	for _, item := range items {
+		for _, a := range item.anomalies() {
+			if seen[a] {
+				continue
+			}
+			seen[a] = true
+			all = append(all, a)
+			if sample == nil {
+				sample, _ = json.Marshal(item.Raw)
+			}
+		}
+	}
+	c.reportAnomalies(endpoint, all, sample)
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isJSONBool(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return s == "true" || s == "false"
+}
+
+func isJSONNumber(raw json.RawMessage) bool {
+	var n json.Number
+	return json.Unmarshal(raw, &n) == nil && !strings.HasPrefix(strings.TrimSpace(string(raw)), `"`)
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "…"
+}
@@ -0,0 +1,65 @@
+package atl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnvelopeAnomalies(t *testing.T) {
+	var clean responseEnvelope
+	if err := json.Unmarshal([]byte(`{"status":true,"message":"ok","code":200,"data":[]}`), &clean); err != nil {
+		t.Fatal(err)
+	}
+	if len(clean.anomalies) != 0 || clean.sample != nil {
+		t.Fatalf("documented envelope flagged: %v", clean.anomalies)
+	}
+
+	var drifted responseEnvelope
+	if err := json.Unmarshal([]byte(`{"status":"true","code":"200","data":[],"meta":{}}`), &drifted); err != nil {
+		t.Fatal(err)
+	}
+	if !drifted.Status || drifted.Code != 200 {
+		t.Fatalf("heuristic parse changed: %+v", drifted)
+	}
+	want := map[string]bool{anomalyUnknownKey: true, anomalyStringStatus: true, anomalyStringCode: true}
+	if len(drifted.anomalies) != len(want) {
+		t.Fatalf("anomalies = %v", drifted.anomalies)
+	}
+	for _, a := range drifted.anomalies {
+		if !want[a.Kind] {
+			t.Fatalf("unexpected anomaly %v", a)
+		}
+	}
+	if len(drifted.sample) == 0 {
+		t.Fatalf("drifted envelope kept no sample")
+	}
+}
+
+func TestPriceListItemAnomalies(t *testing.T) {
+	var item PriceListItem
+	if err := json.Unmarshal([]byte(`{"kode":"PLN20","name":"PLN 20K","price":"20500","status":1,"img":"x.png"}`), &item); err != nil {
+		t.Fatal(err)
+	}
+	got := map[payloadAnomaly]bool{}
+	for _, a := range item.anomalies() {
+		got[a] = true
+	}
+	for _, want := range []payloadAnomaly{
+		{Kind: anomalyUnknownKey, Detail: "img"},
+		{Kind: anomalyFieldAlias, Detail: "code<-kode"},
+		{Kind: anomalyStringNumber, Detail: "price"},
+		{Kind: anomalyNumericStatus, Detail: "status"},
+	} {
+		if !got[want] {
+			t.Errorf("missing anomaly %v in %v", want, item.anomalies())
+		}
+	}
+
+	var clean PriceListItem
+	if err := json.Unmarshal([]byte(`{"code":"PLN20","name":"PLN 20K","price":20500,"status":"available"}`), &clean); err != nil {
+		t.Fatal(err)
+	}
+	if a := clean.anomalies(); len(a) != 0 {
+		t.Fatalf("documented item flagged: %v", a)
+	}
+}
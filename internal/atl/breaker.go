@@ -0,0 +1,179 @@
+package atl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bot-jual/internal/metrics"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is matched by errors returned while an endpoint's breaker is open.
+var ErrCircuitOpen = errors.New("atlantic circuit open")
+
+// CircuitOpenError reports a call short-circuited by an open breaker.
+type CircuitOpenError struct {
+	Endpoint string
+	RetryAt  time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("atlantic %s circuit open until %s", e.Endpoint, e.RetryAt.Format(time.RFC3339))
+}
+
+// Unwrap lets errors.Is match ErrCircuitOpen.
+func (e *CircuitOpenError) Unwrap() error { return ErrCircuitOpen }
+
+// Breaker states, also used as the value of the breaker state gauge.
+const (
+	BreakerClosed   = "closed"
+	BreakerHalfOpen = "half_open"
+	BreakerOpen     = "open"
+)
+
+var breakerGauge = map[string]float64{BreakerClosed: 0, BreakerHalfOpen: 1, BreakerOpen: 2}
+
+// BreakerState describes one endpoint's breaker for the debug endpoint.
+type BreakerState struct {
+	Endpoint            string    `json:"endpoint"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+type endpointBreaker struct {
+	failures int
+	openedAt time.Time
+	// probing is set while the single half-open trial call is in flight.
+	probing bool
+}
+
+// breakers keeps a circuit breaker per endpoint. A breaker opens after
+// threshold consecutive failures and rejects calls for cooldown; the first
+// call after that is let through as a trial, closing the breaker on success
+// and reopening it on failure.
+type breakers struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointBreaker
+	threshold int
+	cooldown  time.Duration
+	metrics   *metrics.Metrics
+	now       func() time.Time
+}
+
+func newBreakers(threshold int, cooldown time.Duration, metrics *metrics.Metrics) *breakers {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &breakers{
+		endpoints: map[string]*endpointBreaker{},
+		threshold: threshold,
+		cooldown:  cooldown,
+		metrics:   metrics,
+		now:       time.Now,
+	}
+}
+
+// allow returns a *CircuitOpenError when endpoint must not be called now.
+func (b *breakers) allow(endpoint string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	eb := b.endpoints[endpoint]
+	if eb == nil || eb.openedAt.IsZero() {
+		return nil
+	}
+	retryAt := eb.openedAt.Add(b.cooldown)
+	if eb.probing || b.now().Before(retryAt) {
+		return &CircuitOpenError{Endpoint: endpoint, RetryAt: retryAt}
+	}
+	eb.probing = true
+	b.setGauge(endpoint, BreakerHalfOpen)
+	return nil
+}
+
+// record updates endpoint's breaker with the outcome of a call that allow let through.
+func (b *breakers) record(endpoint string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	eb := b.endpoints[endpoint]
+	if eb == nil {
+		if !failed {
+			return
+		}
+		eb = &endpointBreaker{}
+		b.endpoints[endpoint] = eb
+	}
+	if !failed {
+		if !eb.openedAt.IsZero() || eb.failures > 0 {
+			*eb = endpointBreaker{}
+			b.setGauge(endpoint, BreakerClosed)
+		}
+		return
+	}
+	eb.failures++
+	if eb.probing || (eb.openedAt.IsZero() && eb.failures >= b.threshold) {
+		eb.openedAt = b.now()
+		eb.probing = false
+		b.setGauge(endpoint, BreakerOpen)
+	}
+}
+
+func (b *breakers) state(endpoint string, eb *endpointBreaker) BreakerState {
+	st := BreakerState{Endpoint: endpoint, State: BreakerClosed, ConsecutiveFailures: eb.failures}
+	if eb.openedAt.IsZero() {
+		return st
+	}
+	st.OpenedAt = eb.openedAt
+	st.RetryAt = eb.openedAt.Add(b.cooldown)
+	st.State = BreakerOpen
+	if eb.probing {
+		st.State = BreakerHalfOpen
+	}
+	return st
+}
+
+// states lists every endpoint that has failed at least once, by endpoint.
+func (b *breakers) states() []BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res := make([]BreakerState, 0, len(b.endpoints))
+	for endpoint, eb := range b.endpoints {
+		res = append(res, b.state(endpoint, eb))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Endpoint < res[j].Endpoint })
+	return res
+}
+
+func (b *breakers) open(endpoint string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	eb := b.endpoints[endpoint]
+	return eb != nil && !eb.openedAt.IsZero()
+}
+
+func (b *breakers) setGauge(endpoint, state string) {
+	if b.metrics != nil {
+		b.metrics.AtlanticCircuit.WithLabelValues(endpoint).Set(breakerGauge[state])
+	}
+}
+
+// BreakerStates reports the circuit breaker of every endpoint that has failed.
+func (c *Client) BreakerStates() []BreakerState {
+	return c.breakers.states()
+}
+
+// BreakerOpen reports whether calls to endpoint are currently short-circuited.
+func (c *Client) BreakerOpen(endpoint string) bool {
+	return c.breakers.open(endpoint)
+}
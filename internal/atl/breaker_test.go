@@ -0,0 +1,68 @@
+package atl
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	b := newBreakers(3, time.Minute, nil)
+	b.now = func() time.Time { return now }
+
+	const endpoint = "/transaksi/create"
+	for i := 0; i < 3; i++ {
+		if err := b.allow(endpoint); err != nil {
+			t.Fatalf("call %d rejected before threshold: %v", i, err)
+		}
+		b.record(endpoint, true)
+	}
+	err := b.allow(endpoint)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow after threshold = %v, want ErrCircuitOpen", err)
+	}
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("open error = %#v", err)
+	}
+	if b.allow("/layanan/price_list") != nil {
+		t.Fatalf("breaker leaked to another endpoint")
+	}
+
+	// After the cooldown one trial is let through; a failure reopens.
+	now = now.Add(time.Minute)
+	if err := b.allow(endpoint); err != nil {
+		t.Fatalf("trial call rejected: %v", err)
+	}
+	if b.allow(endpoint) == nil {
+		t.Fatalf("second call allowed while trial in flight")
+	}
+	b.record(endpoint, true)
+	if st := b.states()[0]; st.State != BreakerOpen || !st.OpenedAt.Equal(now) {
+		t.Fatalf("after failed trial state = %+v", st)
+	}
+
+	// A successful trial closes the breaker.
+	now = now.Add(time.Minute)
+	if err := b.allow(endpoint); err != nil {
+		t.Fatalf("trial call rejected: %v", err)
+	}
+	b.record(endpoint, false)
+	if b.open(endpoint) || b.allow(endpoint) != nil {
+		t.Fatalf("breaker still open after successful trial")
+	}
+	if st := b.states()[0]; st.State != BreakerClosed || st.ConsecutiveFailures != 0 {
+		t.Fatalf("after recovery state = %+v", st)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := newBreakers(2, time.Minute, nil)
+	b.record("/x", true)
+	b.record("/x", false)
+	b.record("/x", true)
+	if b.open("/x") {
+		t.Fatalf("non-consecutive failures opened the breaker")
+	}
+}
@@ -0,0 +1,87 @@
+package atl
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CatalogStore receives catalog snapshots, typically S3-compatible object storage.
+type CatalogStore interface {
+	PutObject(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// SetCatalogExport uploads every catalog saved by FetchAndSaveAllProducts to
+// store under prefix, with timestamped keys so older copies are kept.
+func (c *Client) SetCatalogExport(store CatalogStore, prefix string) {
+	c.catalogStore = store
+	c.catalogPrefix = strings.Trim(prefix, "/")
+}
+
+// RunCatalogExport refreshes and exports the catalog every interval until ctx
+// is cancelled. The first run happens after one interval; startup already
+// saves a snapshot.
+func (c *Client) RunCatalogExport(ctx context.Context, interval time.Duration, outputPath string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.FetchAndSaveAllProducts(ctx, outputPath); err != nil && ctx.Err() == nil {
+			c.logger.Warn("scheduled catalog export failed", "error", err)
+			if c.metrics != nil {
+				c.metrics.Errors.WithLabelValues("catalog_export").Inc()
+			}
+		}
+	}
+}
+
+func (c *Client) exportCatalog(ctx context.Context, jsonData []byte, entries []catalogEntry, at time.Time) error {
+	csvData, err := catalogCSV(entries)
+	if err != nil {
+		return err
+	}
+	base := catalogExportKey(c.catalogPrefix, at)
+	if err := c.catalogStore.PutObject(ctx, base+".json", "application/json", jsonData); err != nil {
+		return err
+	}
+	if err := c.catalogStore.PutObject(ctx, base+".csv", "text/csv", csvData); err != nil {
+		return err
+	}
+	c.logger.Info("product catalog exported", "key", base, "total_products", len(entries))
+	return nil
+}
+
+// catalogExportKey returns the object key, without extension, for a snapshot taken at.
+func catalogExportKey(prefix string, at time.Time) string {
+	name := "products-" + at.UTC().Format("20060102T150405Z")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+func catalogCSV(entries []catalogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"code", "name", "category", "provider", "nominal", "price", "status", "type"})
+	for _, e := range entries {
+		_ = w.Write([]string{
+			e.Code, e.Name, e.Category, e.Provider, e.Nominal,
+			strconv.FormatFloat(e.Price.Float(), 'f', -1, 64),
+			e.Status, e.Type,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("encode catalog csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
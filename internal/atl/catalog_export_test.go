@@ -0,0 +1,33 @@
+package atl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/money"
+)
+
+func TestCatalogExportFormats(t *testing.T) {
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("WIB", 7*3600))
+	if got := catalogExportKey("catalog", at); got != "catalog/products-20260303T220607Z" {
+		t.Fatalf("catalogExportKey = %q", got)
+	}
+	if got := catalogExportKey("", at); got != "products-20260303T220607Z" {
+		t.Fatalf("catalogExportKey without prefix = %q", got)
+	}
+
+	data, err := catalogCSV([]catalogEntry{
+		{Code: "TSEL5", Name: "Telkomsel 5K, promo", Category: "Pulsa", Provider: "Telkomsel", Price: money.FromRupiah(5750), Status: "available", Type: "prabayar"},
+	})
+	if err != nil {
+		t.Fatalf("catalogCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != "code,name,category,provider,nominal,price,status,type" {
+		t.Fatalf("unexpected csv:\n%s", data)
+	}
+	if lines[1] != `TSEL5,"Telkomsel 5K, promo",Pulsa,Telkomsel,,5750,available,prabayar` {
+		t.Fatalf("row = %q", lines[1])
+	}
+}
@@ -0,0 +1,50 @@
+package atl
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// categoryAvailability identifies one catalog_products series of a product type.
+type categoryAvailability struct {
+	category     string
+	availability string
+}
+
+// countCategoryAvailability counts products per category and availability.
+// Products without a status are counted as available, as they are still
+// offered. Both series are reported for every category, so a category going
+// fully down reads as available=0 instead of a series disappearing.
+func countCategoryAvailability(items []PriceListItem) map[categoryAvailability]int {
+	counts := make(map[categoryAvailability]int)
+	for _, item := range items {
+		category := strings.TrimSpace(item.Category)
+		if category == "" {
+			category = "unknown"
+		}
+		available := categoryAvailability{category: category, availability: "available"}
+		unavailable := categoryAvailability{category: category, availability: "unavailable"}
+		if item.Status == "" || item.Status == "available" {
+			counts[available]++
+			counts[unavailable] += 0
+		} else {
+			counts[unavailable]++
+			counts[available] += 0
+		}
+	}
+	return counts
+}
+
+// recordCatalogAvailability replaces the catalog_products gauges of
+// productType with a freshly fetched price list, dropping categories no
+// longer offered.
+func (c *Client) recordCatalogAvailability(productType string, items []PriceListItem) {
+	if c.metrics == nil || c.metrics.CatalogProducts == nil {
+		return
+	}
+	c.metrics.CatalogProducts.DeletePartialMatch(prometheus.Labels{"type": productType})
+	for key, n := range countCategoryAvailability(items) {
+		c.metrics.CatalogProducts.WithLabelValues(productType, key.category, key.availability).Set(float64(n))
+	}
+}
@@ -0,0 +1,30 @@
+package atl
+
+import "testing"
+
+func TestCountCategoryAvailability(t *testing.T) {
+	counts := countCategoryAvailability([]PriceListItem{
+		{Code: "TSEL10", Category: "Pulsa", Status: "available"},
+		{Code: "TSEL25", Category: "Pulsa", Status: "unavailable"},
+		{Code: "ISAT10", Category: "Pulsa"},
+		{Code: "PLN20", Category: "PLN", Status: "unavailable"},
+		{Code: "PLN50", Category: " PLN ", Status: "unavailable"},
+		{Code: "VOUCHER", Status: "available"},
+	})
+	want := map[categoryAvailability]int{
+		{"Pulsa", "available"}:     2,
+		{"Pulsa", "unavailable"}:   1,
+		{"PLN", "available"}:       0,
+		{"PLN", "unavailable"}:     2,
+		{"unknown", "available"}:   1,
+		{"unknown", "unavailable"}: 0,
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("counts = %v, want %v", counts, want)
+	}
+	for key, n := range want {
+		if got, ok := counts[key]; !ok || got != n {
+			t.Errorf("%v = %d (present %v), want %d", key, got, ok, n)
+		}
+	}
+}
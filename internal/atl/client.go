@@ -11,10 +11,13 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"bot-jual/internal/cache"
+	"bot-jual/internal/httpx"
 	"bot-jual/internal/metrics"
+	"bot-jual/internal/money"
 
 	"log/slog"
 )
@@ -27,8 +30,19 @@ const (
 var (
 	// ErrInvalidCredential indicates Atlantic rejected the provided credentials.
 	ErrInvalidCredential = errors.New("atlantic invalid credential")
+	// ErrRejected is matched by errors where Atlantic answered and refused
+	// the request, so nothing was carried out. Timeouts, network errors and
+	// 5xx answers do not match: the request may have gone through.
+	ErrRejected = errors.New("atlantic rejected the request")
 )
 
+// rejectedError marks err as a refusal from Atlantic.
+type rejectedError struct{ err error }
+
+func (e *rejectedError) Error() string        { return e.err.Error() }
+func (e *rejectedError) Unwrap() error        { return e.err }
+func (e *rejectedError) Is(target error) bool { return target == ErrRejected }
+
 // Client provides typed access to Atlantic H2H API.
 type Client struct {
 	logger   *slog.Logger
@@ -39,6 +53,12 @@ type Client struct {
 	metrics  *metrics.Metrics
 	cache    *cache.Redis
 	priceTTL time.Duration
+	// catalogStore, when set, receives a copy of every saved catalog snapshot.
+	catalogStore  CatalogStore
+	catalogPrefix string
+	// anomaliesSeen remembers payload anomalies already logged at warn level.
+	anomaliesSeen sync.Map
+	breakers      *breakers
 }
 
 // Config holds Atlantic client configuration.
@@ -46,6 +66,12 @@ type Config struct {
 	BaseURL string
 	APIKey  string
 	Timeout time.Duration
+	// Transport carries supplier calls; nil builds a dedicated pooled transport.
+	Transport http.RoundTripper
+	// BreakerThreshold consecutive failures open an endpoint's circuit for
+	// BreakerCooldown. Zero values use the defaults.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 // responseEnvelope mirrors Atlantic's standard response shape.
@@ -54,6 +80,10 @@ type responseEnvelope struct {
 	Message string
 	Code    int
 	Data    json.RawMessage
+	// anomalies lists heuristic parsing the envelope needed; sample holds
+	// the start of the payload when there were any.
+	anomalies []payloadAnomaly
+	sample    []byte
 }
 
 func (r *responseEnvelope) UnmarshalJSON(data []byte) error {
@@ -67,6 +97,13 @@ func (r *responseEnvelope) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &a); err != nil {
 		return err
 	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err == nil {
+		r.anomalies = envelopeAnomalies(fields)
+	}
+	if len(r.anomalies) > 0 {
+		r.sample = []byte(truncate(string(data), anomalySampleLimit))
+	}
 	r.Message = strings.TrimSpace(stringTrimQuotes(a.Message))
 	r.Data = a.Data
 	if len(a.Status) != 0 {
@@ -102,15 +139,20 @@ func New(cfg Config, logger *slog.Logger, metrics *metrics.Metrics, redis *cache
 	if timeout <= 0 {
 		timeout = 15 * time.Second
 	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = httpx.NewTransport(httpx.TransportConfig{})
+	}
 	return &Client{
 		logger:   logger.With("component", "atlantic"),
 		baseURL:  base,
 		apiKey:   cfg.APIKey,
 		timeout:  timeout,
-		http:     &http.Client{Timeout: timeout},
+		http:     &http.Client{Timeout: timeout, Transport: transport},
 		metrics:  metrics,
 		cache:    redis,
 		priceTTL: defaultPriceCacheTTL,
+		breakers: newBreakers(cfg.BreakerThreshold, cfg.BreakerCooldown, metrics),
 	}
 }
 
@@ -121,7 +163,7 @@ type PriceListItem struct {
 	Category    string         `json:"category"`
 	Provider    string         `json:"provider"`
 	Nominal     string         `json:"nominal"`
-	Price       float64        `json:"price"`
+	Price       money.Money    `json:"price"`
 	Status      string         `json:"status"`
 	Description string         `json:"description"`
 	Raw         map[string]any `json:"-"`
@@ -154,8 +196,7 @@ func (p *PriceListItem) UnmarshalJSON(data []byte) error {
 		p.Nominal = readStringRaw(tmp, "denom")
 	}
 
-	price := readFloatRaw(tmp, "price", "harga", "sell_price", "amount")
-	p.Price = price
+	p.Price = money.FromFloat(readFloatRaw(tmp, "price", "harga", "sell_price", "amount"))
 
 	status := readStringRaw(tmp, "status", "status_text")
 	if status == "" {
@@ -194,6 +235,8 @@ func (c *Client) PriceList(ctx context.Context, productType string, forceRefresh
 	if err != nil {
 		return nil, fmt.Errorf("parse price list: %w", err)
 	}
+	c.reportPriceListAnomalies("/layanan/price_list", items)
+	c.recordCatalogAvailability(productType, items)
 
 	if c.cache != nil {
 		if err := c.cache.SetJSON(ctx, cacheKey, items, c.priceTTL); err != nil {
@@ -203,7 +246,65 @@ func (c *Client) PriceList(ctx context.Context, productType string, forceRefresh
 	return items, nil
 }
 
-// FetchAndSaveAllProducts fetches all products (prabayar + pascabayar) and saves to a JSON file.
+// InvalidatePriceList drops cached price lists so the next lookup hits Atlantic.
+func (c *Client) InvalidatePriceList(ctx context.Context, productTypes ...string) error {
+	if c.cache == nil || len(productTypes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(productTypes))
+	for _, productType := range productTypes {
+		keys = append(keys, fmt.Sprintf("atlantic:pricelist:%s", normalizeProductType(productType)))
+	}
+	if err := c.cache.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("invalidate price list: %w", err)
+	}
+	return nil
+}
+
+// ProductCodesInCategory returns the codes of catalog products whose category
+// matches, ignoring case.
+func (c *Client) ProductCodesInCategory(ctx context.Context, category string) ([]string, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, nil
+	}
+	prabayar, err := c.PriceList(ctx, "prabayar", false)
+	if err != nil {
+		return nil, fmt.Errorf("fetch prabayar: %w", err)
+	}
+	pascabayar, err := c.PriceList(ctx, "pascabayar", false)
+	if err != nil {
+		c.logger.Warn("pascabayar price list fetch failed, matching prabayar only", "error", err)
+	}
+
+	var codes []string
+	for _, items := range [][]PriceListItem{prabayar, pascabayar} {
+		for _, item := range items {
+			if item.Code != "" && strings.EqualFold(strings.TrimSpace(item.Category), category) {
+				codes = append(codes, item.Code)
+			}
+		}
+	}
+	return codes, nil
+}
+
+// DefaultCatalogPath is where the product catalog snapshot is written.
+const DefaultCatalogPath = "data/products.json"
+
+type catalogEntry struct {
+	Code     string      `json:"code"`
+	Name     string      `json:"name"`
+	Category string      `json:"category"`
+	Provider string      `json:"provider"`
+	Nominal  string      `json:"nominal,omitempty"`
+	Price    money.Money `json:"price"`
+	Status   string      `json:"status"`
+	Type     string      `json:"type"`
+}
+
+// FetchAndSaveAllProducts fetches all products (prabayar + pascabayar) and
+// saves them to a JSON file, then uploads JSON and CSV copies when a catalog
+// store is configured.
 func (c *Client) FetchAndSaveAllProducts(ctx context.Context, outputPath string) error {
 	prabayar, err := c.PriceList(ctx, "prabayar", true)
 	if err != nil {
@@ -214,17 +315,6 @@ func (c *Client) FetchAndSaveAllProducts(ctx context.Context, outputPath string)
 		c.logger.Warn("pascabayar price list fetch failed, continuing with prabayar only", "error", err)
 	}
 
-	type catalogEntry struct {
-		Code     string  `json:"code"`
-		Name     string  `json:"name"`
-		Category string  `json:"category"`
-		Provider string  `json:"provider"`
-		Nominal  string  `json:"nominal,omitempty"`
-		Price    float64 `json:"price"`
-		Status   string  `json:"status"`
-		Type     string  `json:"type"`
-	}
-
 	entries := make([]catalogEntry, 0, len(prabayar)+len(pascabayar))
 	for _, p := range prabayar {
 		entries = append(entries, catalogEntry{
@@ -251,6 +341,12 @@ func (c *Client) FetchAndSaveAllProducts(ctx context.Context, outputPath string)
 	}
 
 	c.logger.Info("product catalog saved", "path", outputPath, "total_products", len(entries))
+
+	if c.catalogStore != nil {
+		if err := c.exportCatalog(ctx, data, entries, time.Now()); err != nil {
+			return fmt.Errorf("export catalog: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -275,7 +371,7 @@ type ProfileResponse struct {
 	Username string         `json:"username"`
 	Email    string         `json:"email"`
 	Phone    string         `json:"phone"`
-	Balance  float64        `json:"balance"`
+	Balance  money.Money    `json:"balance"`
 	Status   string         `json:"status"`
 	Raw      map[string]any `json:"raw"`
 }
@@ -296,13 +392,13 @@ func (c *Client) GetProfile(ctx context.Context) (*ProfileResponse, error) {
 		Email:    firstString(data, "email"),
 		Phone:    firstString(data, "phone"),
 		Status:   firstString(data, "status"),
-		Balance:  toFloat(data["balance"]),
+		Balance:  money.FromFloat(toFloat(data["balance"])),
 		Raw:      data,
 	}
 	if resp.Balance == 0 {
 		if balStr := firstString(data, "balance"); balStr != "" {
 			if parsed, err := strconv.ParseFloat(strings.ReplaceAll(balStr, ",", ""), 64); err == nil {
-				resp.Balance = parsed
+				resp.Balance = money.FromFloat(parsed)
 			}
 		}
 	}
@@ -320,6 +416,29 @@ type CreatePrepaidRequest struct {
 	Note        string `json:"note,omitempty"`
 }
 
+// maxTransactionNoteLength keeps notes within what the supplier accepts.
+const maxTransactionNoteLength = 100
+
+// FormatTransactionNote combines a customer reference and free-form note into
+// the single note field sent along with a transaction.
+func FormatTransactionNote(customerRef, note string) string {
+	customerRef = strings.TrimSpace(customerRef)
+	note = strings.TrimSpace(note)
+	var combined string
+	switch {
+	case customerRef != "" && note != "":
+		combined = fmt.Sprintf("Ref %s - %s", customerRef, note)
+	case customerRef != "":
+		combined = "Ref " + customerRef
+	default:
+		combined = note
+	}
+	if runes := []rune(combined); len(runes) > maxTransactionNoteLength {
+		combined = string(runes[:maxTransactionNoteLength])
+	}
+	return combined
+}
+
 // TransactionResponse captures Atlantic transaction response.
 type TransactionResponse struct {
 	RefID   string         `json:"ref_id"`
@@ -327,6 +446,9 @@ type TransactionResponse struct {
 	Message string         `json:"message"`
 	SN      string         `json:"sn,omitempty"`
 	Raw     map[string]any `json:"raw"`
+	// Supplier names who took the transaction when it was placed through
+	// the supplier router; empty means Atlantic.
+	Supplier string `json:"supplier,omitempty"`
 }
 
 // CreatePrepaidTransaction triggers Atlantic transaction creation.
@@ -362,6 +484,12 @@ type TransactionStatusRequest struct {
 	RefID string `json:"ref_id"`
 	ID    string `json:"id"`
 	Type  string `json:"type"`
+	// Supplier, ProductCode and CustomerID send the check to the supplier
+	// that took the transaction, for suppliers that look it up by what it
+	// was placed with. Atlantic ignores them.
+	Supplier    string `json:"supplier,omitempty"`
+	ProductCode string `json:"product_code,omitempty"`
+	CustomerID  string `json:"customer_id,omitempty"`
 }
 
 // TransactionStatusResponse details transaction status.
@@ -421,8 +549,8 @@ type BillInquiryResponse struct {
 	RefID    string         `json:"ref_id"`
 	Status   string         `json:"status"`
 	Message  string         `json:"message"`
-	Amount   float64        `json:"amount"`
-	Fee      float64        `json:"fee"`
+	Amount   money.Money    `json:"amount"`
+	Fee      money.Money    `json:"fee"`
 	BillInfo map[string]any `json:"bill_info"`
 	Raw      map[string]any `json:"raw"`
 }
@@ -449,8 +577,8 @@ func (c *Client) BillInquiry(ctx context.Context, req BillInquiryRequest) (*Bill
 		RefID:    firstString(data, "reff_id", "ref_id", "reference"),
 		Status:   normalizeTransactionStatus(firstString(data, "status", "state")),
 		Message:  firstString(data, "message", "info", "description"),
-		Amount:   firstFloat(data, "amount", "total", "tagihan"),
-		Fee:      firstFloat(data, "fee", "admin"),
+		Amount:   firstMoney(data, "amount", "total", "tagihan"),
+		Fee:      firstMoney(data, "fee", "admin"),
 		BillInfo: extractNested(data, "bill_info", "detail", "data"),
 		Raw:      data,
 	}
@@ -514,14 +642,15 @@ func (c *Client) BillPayment(ctx context.Context, req BillPaymentRequest) (*Bill
 
 // DepositRequest holds deposit parameters.
 type DepositRequest struct {
-	Method string  `json:"method"`
-	Amount float64 `json:"amount"`
-	RefID  string  `json:"ref_id"`
-	Type   string  `json:"type,omitempty"`
+	Method string      `json:"method"`
+	Amount money.Money `json:"amount"`
+	RefID  string      `json:"ref_id"`
+	Type   string      `json:"type,omitempty"`
 }
 
 // DepositResponse contains deposit status.
 type DepositResponse struct {
+	ID        string         `json:"id"`
 	RefID     string         `json:"ref_id"`
 	Status    string         `json:"status"`
 	Message   string         `json:"message"`
@@ -529,9 +658,9 @@ type DepositResponse struct {
 	QRString  string         `json:"qr_string"`
 	QRImage   string         `json:"qr_image"`
 	ExpiredAt string         `json:"expired_at"`
-	Amount    float64        `json:"amount"`
-	Fee       float64        `json:"fee"`
-	NetAmount float64        `json:"net_amount"`
+	Amount    money.Money    `json:"amount"`
+	Fee       money.Money    `json:"fee"`
+	NetAmount money.Money    `json:"net_amount"`
 	Raw       map[string]any `json:"raw"`
 }
 
@@ -539,7 +668,7 @@ type DepositResponse struct {
 func (c *Client) CreateDeposit(ctx context.Context, req DepositRequest) (*DepositResponse, error) {
 	form := url.Values{}
 	form.Set("reff_id", req.RefID)
-	form.Set("nominal", strconv.FormatFloat(req.Amount, 'f', 0, 64))
+	form.Set("nominal", strconv.FormatInt(req.Amount.Rupiah(), 10))
 	form.Set("metode", req.Method)
 	if req.Type != "" {
 		form.Set("type", req.Type)
@@ -552,16 +681,17 @@ func (c *Client) CreateDeposit(ctx context.Context, req DepositRequest) (*Deposi
 	if err != nil {
 		return nil, err
 	}
-	fee := firstFloat(data, "fee", "admin_fee", "admin")
-	net := firstFloat(data, "get_balance", "net_amount", "saldo_masuk", "balance_masuk")
+	fee := firstMoney(data, "fee", "admin_fee", "admin")
+	net := firstMoney(data, "get_balance", "net_amount", "saldo_masuk", "balance_masuk")
 	resp := &DepositResponse{
+		ID:        firstString(data, "id"),
 		RefID:     firstString(data, "reff_id", "ref_id", "reference"),
 		Status:    normalizeTransactionStatus(firstString(data, "status", "state")),
 		Message:   firstString(data, "message", "info", "description"),
 		QRString:  firstString(data, "qr_string", "qr"),
 		QRImage:   firstString(data, "qr_image", "image"),
 		ExpiredAt: firstString(data, "expired_at", "expire_at"),
-		Amount:    firstFloat(data, "nominal", "amount"),
+		Amount:    firstMoney(data, "nominal", "amount"),
 		Checkout:  extractNested(data, "checkout"),
 		Raw:       data,
 	}
@@ -586,13 +716,13 @@ func (c *Client) CreateDeposit(ctx context.Context, req DepositRequest) (*Deposi
 		resp.Checkout["expired_at"] = resp.ExpiredAt
 	}
 	if resp.Amount > 0 {
-		resp.Checkout["nominal"] = resp.Amount
+		resp.Checkout["nominal"] = resp.Amount.Float()
 	}
 	if resp.Fee > 0 {
-		resp.Checkout["fee"] = resp.Fee
+		resp.Checkout["fee"] = resp.Fee.Float()
 	}
 	if resp.NetAmount > 0 {
-		resp.Checkout["net_amount"] = resp.NetAmount
+		resp.Checkout["net_amount"] = resp.NetAmount.Float()
 	}
 	// Populate bank/VA info if provider returns it at top-level.
 	if firstString(resp.Checkout, "bank") == "" {
@@ -634,9 +764,9 @@ type DepositMethod struct {
 	Method     string         `json:"method"`
 	Type       string         `json:"type"`
 	Name       string         `json:"name"`
-	Min        float64        `json:"min"`
-	Max        float64        `json:"max"`
-	Fee        float64        `json:"fee"`
+	Min        money.Money    `json:"min"`
+	Max        money.Money    `json:"max"`
+	Fee        money.Money    `json:"fee"`
 	FeePercent float64        `json:"fee_percent"`
 	Status     string         `json:"status"`
 	ImgURL     string         `json:"img_url"`
@@ -669,9 +799,9 @@ func (c *Client) DepositMethods(ctx context.Context, req DepositMethodRequest) (
 			Method:     firstString(row, "metode", "method", "code"),
 			Type:       firstString(row, "type"),
 			Name:       firstString(row, "name", "nama"),
-			Min:        firstFloat(row, "min"),
-			Max:        firstFloat(row, "max"),
-			Fee:        firstFloat(row, "fee"),
+			Min:        firstMoney(row, "min"),
+			Max:        firstMoney(row, "max"),
+			Fee:        firstMoney(row, "fee"),
 			FeePercent: firstFloat(row, "fee_persen", "fee_percent"),
 			Status:     normalizeAvailabilityStatus(firstString(row, "status")),
 			ImgURL:     firstString(row, "img_url", "image"),
@@ -687,9 +817,9 @@ type DepositStatusResponse struct {
 	RefID     string         `json:"ref_id"`
 	Status    string         `json:"status"`
 	Method    string         `json:"method"`
-	Amount    float64        `json:"amount"`
-	Fee       float64        `json:"fee"`
-	NetAmount float64        `json:"net_amount"`
+	Amount    money.Money    `json:"amount"`
+	Fee       money.Money    `json:"fee"`
+	NetAmount money.Money    `json:"net_amount"`
 	CreatedAt string         `json:"created_at"`
 	Raw       map[string]any `json:"raw"`
 }
@@ -711,9 +841,9 @@ func (c *Client) DepositStatus(ctx context.Context, depositID string) (*DepositS
 		RefID:     firstString(data, "reff_id", "ref_id"),
 		Status:    normalizeTransactionStatus(firstString(data, "status", "state")),
 		Method:    firstString(data, "metode", "method"),
-		Amount:    firstFloat(data, "nominal", "amount"),
-		Fee:       firstFloat(data, "fee", "admin_fee"),
-		NetAmount: firstFloat(data, "get_balance", "net_amount", "saldo_masuk"),
+		Amount:    firstMoney(data, "nominal", "amount"),
+		Fee:       firstMoney(data, "fee", "admin_fee"),
+		NetAmount: firstMoney(data, "get_balance", "net_amount", "saldo_masuk"),
 		CreatedAt: firstString(data, "created_at"),
 		Raw:       data,
 	}
@@ -754,10 +884,10 @@ type DepositInstantResponse struct {
 	ID            string         `json:"id"`
 	RefID         string         `json:"ref_id"`
 	Status        string         `json:"status"`
-	Amount        float64        `json:"amount"`
-	HandlingFee   float64        `json:"handling_fee"`
-	TotalFee      float64        `json:"total_fee"`
-	TotalReceived float64        `json:"total_received"`
+	Amount        money.Money    `json:"amount"`
+	HandlingFee   money.Money    `json:"handling_fee"`
+	TotalFee      money.Money    `json:"total_fee"`
+	TotalReceived money.Money    `json:"total_received"`
 	CreatedAt     string         `json:"created_at"`
 	Raw           map[string]any `json:"raw"`
 }
@@ -779,10 +909,10 @@ func (c *Client) DepositInstant(ctx context.Context, depositID string, action bo
 		ID:            firstString(data, "id"),
 		RefID:         firstString(data, "reff_id", "ref_id"),
 		Status:        normalizeTransactionStatus(firstString(data, "status", "state")),
-		Amount:        firstFloat(data, "nominal", "amount"),
-		HandlingFee:   firstFloat(data, "penanganan", "handling_fee"),
-		TotalFee:      firstFloat(data, "total_fee", "fee"),
-		TotalReceived: firstFloat(data, "total_diterima", "total_received"),
+		Amount:        firstMoney(data, "nominal", "amount"),
+		HandlingFee:   firstMoney(data, "penanganan", "handling_fee"),
+		TotalFee:      firstMoney(data, "total_fee", "fee"),
+		TotalReceived: firstMoney(data, "total_diterima", "total_received"),
 		CreatedAt:     firstString(data, "created_at"),
 		Raw:           data,
 	}
@@ -791,14 +921,14 @@ func (c *Client) DepositInstant(ctx context.Context, depositID string, action bo
 
 // TransferRequest holds transfer parameters.
 type TransferRequest struct {
-	BankCode    string  `json:"bank_code"`
-	AccountName string  `json:"account_name"`
-	AccountNo   string  `json:"account_no"`
-	Amount      float64 `json:"amount"`
-	RefID       string  `json:"ref_id"`
-	Description string  `json:"description,omitempty"`
-	Email       string  `json:"email,omitempty"`
-	Phone       string  `json:"phone,omitempty"`
+	BankCode    string      `json:"bank_code"`
+	AccountName string      `json:"account_name"`
+	AccountNo   string      `json:"account_no"`
+	Amount      money.Money `json:"amount"`
+	RefID       string      `json:"ref_id"`
+	Description string      `json:"description,omitempty"`
+	Email       string      `json:"email,omitempty"`
+	Phone       string      `json:"phone,omitempty"`
 }
 
 // TransferResponse contains transfer status.
@@ -816,7 +946,7 @@ func (c *Client) CreateTransfer(ctx context.Context, req TransferRequest) (*Tran
 	form.Set("kode_bank", req.BankCode)
 	form.Set("nomor_akun", req.AccountNo)
 	form.Set("nama_penerima", req.AccountName)
-	form.Set("nominal", strconv.FormatFloat(req.Amount, 'f', -1, 64))
+	form.Set("nominal", strconv.FormatInt(req.Amount.Rupiah(), 10))
 	if req.Description != "" {
 		form.Set("catatan", req.Description)
 	}
@@ -956,15 +1086,16 @@ func (c *Client) call(ctx context.Context, method, endpoint string, body io.Read
 	if err := c.do(ctx, method, endpoint, body, contentType, &env); err != nil {
 		return nil, err
 	}
+	c.reportAnomalies(endpoint, env.anomalies, env.sample)
 	if !env.Status {
 		message := strings.TrimSpace(env.Message)
 		if message == "" {
 			message = "atlantic operation failed"
 		}
 		if env.Code != 0 {
-			return nil, fmt.Errorf("atlantic %s error: %s (code=%d)", endpoint, message, env.Code)
+			return nil, &rejectedError{fmt.Errorf("atlantic %s error: %s (code=%d)", endpoint, message, env.Code)}
 		}
-		return nil, fmt.Errorf("atlantic %s error: %s", endpoint, message)
+		return nil, &rejectedError{fmt.Errorf("atlantic %s error: %s", endpoint, message)}
 	}
 	return &env, nil
 }
@@ -999,15 +1130,25 @@ func (c *Client) do(ctx context.Context, method, endpoint string, body io.Reader
 		req.Header.Set("X-Client-Action", "create_deposit_qris")
 	}
 
+	if err := c.breakers.allow(endpoint); err != nil {
+		if c.metrics != nil {
+			c.metrics.AtlanticRequests.WithLabelValues(endpoint, "circuit_open").Inc()
+		}
+		return err
+	}
+
 	start := time.Now()
 	res, err := c.http.Do(req)
 	if err != nil {
+		c.breakers.record(endpoint, true)
 		if c.metrics != nil {
 			c.metrics.AtlanticRequests.WithLabelValues(endpoint, "error").Inc()
 		}
 		return fmt.Errorf("atlantic request: %w", err)
 	}
 	defer res.Body.Close()
+	// Only outages count against the breaker; 4xx answers mean Atlantic is up.
+	c.breakers.record(endpoint, res.StatusCode >= 500)
 
 	duration := time.Since(start).Seconds()
 	statusLabel := fmt.Sprintf("%d", res.StatusCode)
@@ -1022,7 +1163,13 @@ func (c *Client) do(ctx context.Context, method, endpoint string, body io.Reader
 	}
 
 	if res.StatusCode >= 400 {
-		return classifyHTTPError(res.StatusCode, string(bodyBytes))
+		err := classifyHTTPError(res.StatusCode, string(bodyBytes))
+		// A 4xx answer means the request was refused, except a timeout or
+		// throttling, which leave its fate unknown.
+		if res.StatusCode < 500 && res.StatusCode != http.StatusRequestTimeout && res.StatusCode != http.StatusTooManyRequests {
+			return &rejectedError{err}
+		}
+		return err
 	}
 
 	if dest == nil {
@@ -1191,6 +1338,11 @@ func firstFloat(data map[string]any, keys ...string) float64 {
 	return 0
 }
 
+// firstMoney reads a rupiah amount from the first non-zero key.
+func firstMoney(data map[string]any, keys ...string) money.Money {
+	return money.FromFloat(firstFloat(data, keys...))
+}
+
 func readStringRaw(raw map[string]json.RawMessage, keys ...string) string {
 	for _, key := range keys {
 		if val, ok := raw[key]; ok {
@@ -0,0 +1,156 @@
+package atl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Payloads under testdata/payloads are sanitized Atlantic responses and
+// webhook bodies. Each one is parsed the way production code parses it and
+// the typed result is compared with testdata/golden/<name>.json. After an
+// intended mapping change, refresh the goldens with:
+//
+//	go test ./internal/atl -run TestPayloadGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files under testdata/golden")
+
+// goldenCalls maps an API fixture name prefix to the client call that
+// consumes it.
+var goldenCalls = map[string]func(ctx context.Context, c *Client) (any, error){
+	"price_list": func(ctx context.Context, c *Client) (any, error) {
+		items, err := c.PriceList(ctx, "prabayar", true)
+		for i := range items {
+			items[i].Raw = nil
+		}
+		// Grouped lists come back in map order.
+		sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+		return items, err
+	},
+	"deposit_create": func(ctx context.Context, c *Client) (any, error) {
+		resp, err := c.CreateDeposit(ctx, DepositRequest{RefID: "dep", Method: "QRIS"})
+		if resp != nil {
+			resp.Raw = nil
+		}
+		return resp, err
+	},
+	"deposit_status": func(ctx context.Context, c *Client) (any, error) {
+		resp, err := c.DepositStatus(ctx, "DEP")
+		if resp != nil {
+			resp.Raw = nil
+		}
+		return resp, err
+	},
+	"transaksi_create": func(ctx context.Context, c *Client) (any, error) {
+		resp, err := c.CreatePrepaidTransaction(ctx, CreatePrepaidRequest{ProductCode: "TSEL5", CustomerID: "0812"})
+		if resp != nil {
+			resp.Raw = nil
+		}
+		return resp, err
+	},
+	"transaksi_status": func(ctx context.Context, c *Client) (any, error) {
+		resp, err := c.TransactionStatus(ctx, TransactionStatusRequest{RefID: "trx"})
+		if resp != nil {
+			resp.Raw = nil
+		}
+		return resp, err
+	},
+}
+
+func TestPayloadGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "payloads", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no payload fixtures: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got any
+			if strings.HasPrefix(name, "webhook_") {
+				got = parseWebhookFixture(t, body)
+			} else {
+				got = callWithFixture(t, name, body)
+			}
+			compareGolden(t, filepath.Join("testdata", "golden", name+".json"), got)
+		})
+	}
+}
+
+func parseWebhookFixture(t *testing.T, body []byte) any {
+	t.Helper()
+	eventType := detectEventType(http.Header{}, body)
+	event, err := NewWebhookEvent(eventType, nil, body, time.Time{})
+	if err != nil {
+		t.Fatalf("NewWebhookEvent: %v", err)
+	}
+	// The decoded payload echoes the fixture; the golden covers the mapping.
+	if update := event.Update(); update != nil {
+		update.Payload = nil
+	}
+	return struct {
+		Type        string
+		Deposit     *DepositUpdate     `json:",omitempty"`
+		Transaction *TransactionUpdate `json:",omitempty"`
+		Transfer    *TransferUpdate    `json:",omitempty"`
+	}{event.Type, event.Deposit, event.Transaction, event.Transfer}
+}
+
+func callWithFixture(t *testing.T, name string, body []byte) any {
+	t.Helper()
+	var call func(context.Context, *Client) (any, error)
+	for prefix, fn := range goldenCalls {
+		if strings.HasPrefix(name, prefix) {
+			call = fn
+		}
+	}
+	if call == nil {
+		t.Fatalf("no client call registered for fixture %q", name)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	client := New(Config{BaseURL: srv.URL, APIKey: "test"}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil)
+	got, err := call(context.Background(), client)
+	if err != nil {
+		t.Fatalf("client call: %v", err)
+	}
+	return got
+}
+
+func compareGolden(t *testing.T, path string, got any) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, '\n')
+	if *updateGolden {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if !bytes.Equal(want, data) {
+		t.Errorf("%s mismatch\n--- want\n%s\n--- got\n%s", path, want, data)
+	}
+}
@@ -0,0 +1,111 @@
+package atl
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bot-jual/internal/metrics"
+)
+
+const defaultProbeInterval = time.Minute
+
+// ProbeStatus is the latest result of the supplier health probe.
+type ProbeStatus struct {
+	Up        bool
+	Error     string
+	CheckedAt time.Time
+}
+
+// HealthProbe periodically calls a cheap Atlantic endpoint so supplier
+// outages show up in metrics and readiness separately from bot errors.
+type HealthProbe struct {
+	ping     func(ctx context.Context) error
+	logger   *slog.Logger
+	metrics  *metrics.Metrics
+	interval time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	status ProbeStatus
+}
+
+// NewHealthProbe builds a probe that pings Atlantic via GetProfile at most once per interval.
+func NewHealthProbe(client *Client, logger *slog.Logger, metrics *metrics.Metrics, interval time.Duration) *HealthProbe {
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	return &HealthProbe{
+		ping: func(ctx context.Context) error {
+			_, err := client.GetProfile(ctx)
+			return err
+		},
+		logger:   logger.With("component", "atlantic_probe"),
+		metrics:  metrics,
+		interval: interval,
+		timeout:  client.timeout,
+	}
+}
+
+// Run probes immediately and then on every interval until ctx is cancelled.
+func (p *HealthProbe) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings Atlantic unless the last probe is more recent than the
+// interval, in which case the cached status is returned.
+func (p *HealthProbe) Check(ctx context.Context) ProbeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.status.CheckedAt.IsZero() && time.Since(p.status.CheckedAt) < p.interval {
+		return p.status
+	}
+
+	pingCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	err := p.ping(pingCtx)
+	if ctx.Err() != nil {
+		// Shutting down; keep the previous status rather than reporting an outage.
+		return p.status
+	}
+
+	wasUp := p.status.Up || p.status.CheckedAt.IsZero()
+	p.status = ProbeStatus{Up: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		p.status.Error = err.Error()
+		if wasUp {
+			p.logger.Warn("atlantic probe failed", "error", err)
+		}
+	} else if !wasUp {
+		p.logger.Info("atlantic probe recovered")
+	}
+	if p.metrics != nil {
+		up := 0.0
+		if p.status.Up {
+			up = 1
+		}
+		p.metrics.AtlanticUp.Set(up)
+	}
+	return p.status
+}
+
+// Status returns the latest probe result without contacting Atlantic.
+func (p *HealthProbe) Status() ProbeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
@@ -0,0 +1,38 @@
+package atl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHealthProbeCachesWithinInterval(t *testing.T) {
+	calls := 0
+	pingErr := errors.New("connection refused")
+	p := &HealthProbe{
+		ping: func(context.Context) error {
+			calls++
+			return pingErr
+		},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		interval: time.Hour,
+	}
+
+	st := p.Check(context.Background())
+	if st.Up || st.Error != pingErr.Error() {
+		t.Fatalf("first check = %+v, want down with error", st)
+	}
+	p.Check(context.Background())
+	if calls != 1 {
+		t.Fatalf("ping called %d times, want 1 within the interval", calls)
+	}
+
+	p.status.CheckedAt = time.Now().Add(-2 * time.Hour)
+	pingErr = nil
+	if st := p.Check(context.Background()); !st.Up || calls != 2 {
+		t.Errorf("check after interval = %+v (calls %d), want up after a second ping", st, calls)
+	}
+}
@@ -2,12 +2,16 @@ package atl
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,12 +20,15 @@ import (
 	"log/slog"
 )
 
-// WebhookEvent contains metadata and payload from Atlantic webhook.
+// WebhookEvent contains metadata, the raw payload and the typed update parsed from an Atlantic webhook.
 type WebhookEvent struct {
-	Type       string
-	Headers    map[string]string
-	Payload    json.RawMessage
-	ReceivedAt time.Time
+	Type        string
+	Headers     map[string]string
+	Payload     json.RawMessage
+	ReceivedAt  time.Time
+	Deposit     *DepositUpdate
+	Transaction *TransactionUpdate
+	Transfer    *TransferUpdate
 }
 
 // WebhookProcessor defines handler interface for Atlantic events.
@@ -29,26 +36,66 @@ type WebhookProcessor interface {
 	HandleAtlanticEvent(ctx context.Context, event WebhookEvent) error
 }
 
+// DeadLetterStore keeps events the processor failed on so they can be replayed later.
+type DeadLetterStore interface {
+	StoreDeadLetter(ctx context.Context, event WebhookEvent, procErr error) error
+}
+
+// WebhookCredential is an MD5-hashed basic auth pair Atlantic signs
+// callbacks with.
+type WebhookCredential struct {
+	UsernameMD5 string
+	PasswordMD5 string
+}
+
 // WebhookHandler verifies Atlantic webhook signature and forwards events.
 type WebhookHandler struct {
-	logger      *slog.Logger
-	metrics     *metrics.Metrics
-	usernameMD5 string
-	passwordMD5 string
+	logger  *slog.Logger
+	metrics *metrics.Metrics
+	// credentials are all accepted; the first is the current one and the
+	// rest stay valid while a rotation is rolled out.
+	credentials []WebhookCredential
+	hmacSecrets [][]byte
 	processor   WebhookProcessor
+	deadLetters DeadLetterStore
+	// replayWindow bounds how old a timestamped callback may be.
+	replayWindow time.Duration
 }
 
-// NewWebhookHandler creates a new webhook handler.
-func NewWebhookHandler(logger *slog.Logger, metrics *metrics.Metrics, usernameMD5, passwordMD5 string, processor WebhookProcessor) *WebhookHandler {
+// NewWebhookHandler creates a new webhook handler accepting any of credentials.
+func NewWebhookHandler(logger *slog.Logger, metrics *metrics.Metrics, credentials []WebhookCredential, processor WebhookProcessor) *WebhookHandler {
+	normalized := make([]WebhookCredential, 0, len(credentials))
+	for _, c := range credentials {
+		normalized = append(normalized, WebhookCredential{
+			UsernameMD5: strings.ToLower(strings.TrimSpace(c.UsernameMD5)),
+			PasswordMD5: strings.ToLower(strings.TrimSpace(c.PasswordMD5)),
+		})
+	}
 	return &WebhookHandler{
 		logger:      logger.With("component", "atlantic_webhook"),
 		metrics:     metrics,
-		usernameMD5: strings.ToLower(usernameMD5),
-		passwordMD5: strings.ToLower(passwordMD5),
+		credentials: normalized,
 		processor:   processor,
 	}
 }
 
+// SetHMACSecrets additionally accepts signature headers carrying the hex
+// HMAC-SHA256 of the body under any of secrets, so old and new secrets both
+// verify during a rotation.
+func (h *WebhookHandler) SetHMACSecrets(secrets []string) {
+	h.hmacSecrets = h.hmacSecrets[:0]
+	for _, secret := range secrets {
+		if secret != "" {
+			h.hmacSecrets = append(h.hmacSecrets, []byte(secret))
+		}
+	}
+}
+
+// SetDeadLetterStore enables dead-lettering of events that fail processing.
+func (h *WebhookHandler) SetDeadLetterStore(store DeadLetterStore) {
+	h.deadLetters = store
+}
+
 // ServeHTTP satisfies http.Handler.
 func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -56,12 +103,7 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.validateAuth(r); err != nil {
-		h.metrics.Errors.WithLabelValues("atlantic_webhook_auth").Inc()
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
-	}
-
+	// The body is read first: HMAC signatures are computed over it.
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		h.metrics.Errors.WithLabelValues("atlantic_webhook").Inc()
@@ -70,53 +112,86 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	eventType := detectEventType(r.Header, body)
-	headers := map[string]string{}
-	for key, vals := range r.Header {
-		if len(vals) > 0 {
-			headers[key] = vals[0]
-		}
+	if err := h.validateAuth(r, body); err != nil {
+		h.metrics.Errors.WithLabelValues("atlantic_webhook_auth").Inc()
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.checkFreshness(r.Header, body, time.Now()); err != nil {
+		h.logger.Warn("rejected stale webhook", "error", err)
+		h.metrics.Errors.WithLabelValues("atlantic_webhook_replay").Inc()
+		http.Error(w, "stale webhook", http.StatusUnauthorized)
+		return
 	}
 
-	event := WebhookEvent{
-		Type:       eventType,
-		Headers:    headers,
-		Payload:    body,
-		ReceivedAt: time.Now(),
+	eventType := detectEventType(r.Header, body)
+	event, err := NewWebhookEvent(eventType, WebhookHeaders(r.Header), body, time.Now())
+	if err != nil {
+		h.logger.Warn("invalid webhook payload", "error", err, "event", eventType)
+		h.metrics.Errors.WithLabelValues("atlantic_webhook_decode").Inc()
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
 	}
 
 	if h.processor != nil {
 		if err := h.processor.HandleAtlanticEvent(r.Context(), event); err != nil {
 			h.logger.Error("failed processing webhook", "error", err, "event", eventType)
 			h.metrics.Errors.WithLabelValues("atlantic_webhook_process").Inc()
-			http.Error(w, "failed to process", http.StatusInternalServerError)
-			return
+			// Once the event is dead-lettered it is acknowledged; replay happens from the admin API.
+			if h.deadLetters == nil {
+				http.Error(w, "failed to process", http.StatusInternalServerError)
+				return
+			}
+			if dlErr := h.deadLetters.StoreDeadLetter(r.Context(), event, err); dlErr != nil {
+				h.logger.Error("failed storing webhook dead letter", "error", dlErr, "event", eventType)
+				http.Error(w, "failed to process", http.StatusInternalServerError)
+				return
+			}
 		}
 	}
 
+	WriteWebhookOK(w)
+}
+
+// WebhookHeaders flattens request headers to their first value for storing
+// with a WebhookEvent. Headers named in omit, such as shared secrets, are
+// left out.
+func WebhookHeaders(header http.Header, omit ...string) map[string]string {
+	headers := map[string]string{}
+	for key, vals := range header {
+		if len(vals) > 0 && !slices.Contains(omit, key) {
+			headers[key] = vals[0]
+		}
+	}
+	return headers
+}
+
+// WriteWebhookOK acknowledges a webhook so the provider stops retrying it.
+func WriteWebhookOK(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(`{"status":"ok"}`))
 }
 
-func (h *WebhookHandler) validateAuth(r *http.Request) error {
+func (h *WebhookHandler) validateAuth(r *http.Request, body []byte) error {
 	username, password, ok := r.BasicAuth()
 	if !ok {
-		if h.validateSignatureHeader(r) {
+		if h.validateSignatureHeader(r, body) {
 			return nil
 		}
 		return fmt.Errorf("missing basic auth")
 	}
 
-	if md5Hex(username) != h.usernameMD5 {
-		return fmt.Errorf("invalid username hash")
-	}
-	if md5Hex(password) != h.passwordMD5 {
-		return fmt.Errorf("invalid password hash")
+	userHash, passHash := md5Hex(username), md5Hex(password)
+	for i, c := range h.credentials {
+		if hashEqual(userHash, c.UsernameMD5) && hashEqual(passHash, c.PasswordMD5) {
+			h.notePreviousCredential(i)
+			return nil
+		}
 	}
-	return nil
+	return fmt.Errorf("invalid basic auth")
 }
 
-func (h *WebhookHandler) validateSignatureHeader(r *http.Request) bool {
+func (h *WebhookHandler) validateSignatureHeader(r *http.Request, body []byte) bool {
 	signature := strings.TrimSpace(r.Header.Get("X-Atl-Signature"))
 	if signature == "" {
 		signature = strings.TrimSpace(r.Header.Get("X-Atlantic-Signature"))
@@ -127,8 +202,34 @@ func (h *WebhookHandler) validateSignatureHeader(r *http.Request) bool {
 	if signature == "" {
 		return false
 	}
-	signature = strings.ToLower(signature)
-	return signature == h.usernameMD5 || signature == h.passwordMD5
+	signature = strings.TrimPrefix(strings.ToLower(signature), "sha256=")
+	for i, c := range h.credentials {
+		if hashEqual(signature, c.UsernameMD5) || hashEqual(signature, c.PasswordMD5) {
+			h.notePreviousCredential(i)
+			return true
+		}
+	}
+	for i, secret := range h.hmacSecrets {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		if hashEqual(signature, hex.EncodeToString(mac.Sum(nil))) {
+			h.notePreviousCredential(i)
+			return true
+		}
+	}
+	return false
+}
+
+// notePreviousCredential logs callbacks still signed with a credential other
+// than the current one, so operators know when the old one can be retired.
+func (h *WebhookHandler) notePreviousCredential(index int) {
+	if index > 0 {
+		h.logger.Info("webhook authenticated with previous credential", "index", index)
+	}
+}
+
+func hashEqual(got, want string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
 }
 
 func md5Hex(val string) string {
@@ -136,7 +237,18 @@ func md5Hex(val string) string {
 	return strings.ToLower(hex.EncodeToString(sum[:]))
 }
 
+// EventPriceUpdate is the normalised event type for product or price change notifications.
+const EventPriceUpdate = "price_update"
+
 func detectEventType(header http.Header, body []byte) string {
+	eventType := rawEventType(header, body)
+	if isPriceUpdateHint(eventType, body) {
+		return EventPriceUpdate
+	}
+	return eventType
+}
+
+func rawEventType(header http.Header, body []byte) string {
 	for _, key := range []string{"X-Atlantic-Event", "X-Event-Type", "X-Event"} {
 		if val := header.Get(key); val != "" {
 			return val
@@ -161,3 +273,55 @@ func detectEventType(header http.Header, body []byte) string {
 	}
 	return "unknown"
 }
+
+// isPriceUpdateHint recognises product/price change notifications, either by
+// event name or, for untyped callbacks, by a price list in the payload.
+func isPriceUpdateHint(eventType string, body []byte) bool {
+	etype := strings.ToLower(eventType)
+	for _, skip := range []string{"transaksi", "transaction", "deposit", "transfer"} {
+		if strings.Contains(etype, skip) {
+			return false
+		}
+	}
+	for _, hint := range []string{"price", "harga", "product", "produk", "layanan"} {
+		if strings.Contains(etype, hint) {
+			return true
+		}
+	}
+	if etype != "unknown" {
+		return false
+	}
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	for _, key := range []string{"price_list", "pricelist", "products"} {
+		if _, ok := payload[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceUpdateTypes returns the product types named in a price update payload.
+// An empty result means the hint did not say, so every type should be refreshed.
+func PriceUpdateTypes(body []byte) []string {
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	if data, ok := payload["data"].(map[string]any); ok {
+		payload = data
+	}
+	for _, key := range []string{"product_type", "type", "jenis"} {
+		raw, ok := payload[key].(string)
+		if !ok {
+			continue
+		}
+		switch productType := normalizeProductType(raw); productType {
+		case "prabayar", "pascabayar":
+			return []string{productType}
+		}
+	}
+	return nil
+}
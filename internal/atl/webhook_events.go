@@ -0,0 +1,135 @@
+package atl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusUpdate carries the fields shared by every Atlantic status callback.
+type StatusUpdate struct {
+	Ref       string
+	RawStatus string
+	Status    string
+	Message   string
+	Amount    int64
+	// Payload is the decoded body, kept for auditing in order/deposit metadata.
+	Payload map[string]any
+}
+
+// DepositUpdate is a parsed deposit status callback.
+type DepositUpdate struct {
+	StatusUpdate
+	Method    string
+	Fee       int64
+	NetAmount int64
+}
+
+// TransactionUpdate is a parsed prepaid/postpaid transaction callback.
+type TransactionUpdate struct {
+	StatusUpdate
+	SN          string
+	ProductCode string
+	Target      string
+	Price       int64
+}
+
+// TransferUpdate is a parsed bank transfer (withdrawal) callback.
+type TransferUpdate struct {
+	StatusUpdate
+	Fee       int64
+	BankCode  string
+	AccountNo string
+}
+
+// NewWebhookEvent decodes a webhook body into a typed event. Exactly one of
+// Deposit, Transaction or Transfer is set, except for price update hints.
+func NewWebhookEvent(eventType string, headers map[string]string, body []byte, receivedAt time.Time) (WebhookEvent, error) {
+	event := WebhookEvent{
+		Type:       eventType,
+		Headers:    headers,
+		Payload:    body,
+		ReceivedAt: receivedAt,
+	}
+	if eventType == EventPriceUpdate {
+		return event, nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, fmt.Errorf("decode payload: %w", err)
+	}
+	flat := flattenWebhookPayload(payload)
+	base := StatusUpdate{
+		Ref:       firstString(flat, "ref_id", "reff_id", "reference", "transaction_ref", "order_ref", "deposit_ref"),
+		RawStatus: firstString(flat, "status", "state"),
+		Message:   firstString(flat, "message", "info", "description"),
+		Amount:    firstMoney(flat, "amount", "nominal", "jumlah").Rupiah(),
+		Payload:   payload,
+	}
+	base.Status = NormalizeTransactionStatus(base.RawStatus)
+
+	etype := strings.ToLower(eventType)
+	switch {
+	case strings.Contains(etype, "deposit"):
+		event.Deposit = &DepositUpdate{
+			StatusUpdate: base,
+			Method:       firstString(flat, "metode", "method"),
+			Fee:          firstMoney(flat, "fee", "admin_fee").Rupiah(),
+			NetAmount:    firstMoney(flat, "get_balance", "net_amount", "saldo_masuk").Rupiah(),
+		}
+	case strings.Contains(etype, "transfer"):
+		event.Transfer = &TransferUpdate{
+			StatusUpdate: base,
+			Fee:          firstMoney(flat, "fee", "admin_fee").Rupiah(),
+			BankCode:     firstString(flat, "bank_code", "kode_bank"),
+			AccountNo:    firstString(flat, "nomor_akun", "account_no", "account_number"),
+		}
+	default:
+		event.Transaction = &TransactionUpdate{
+			StatusUpdate: base,
+			SN:           firstString(flat, "sn", "serial_number"),
+			ProductCode:  firstString(flat, "code", "product_code", "layanan"),
+			Target:       firstString(flat, "target", "customer_no", "tujuan"),
+			Price:        firstMoney(flat, "price", "harga").Rupiah(),
+		}
+	}
+	return event, nil
+}
+
+// Update returns the common status fields of whichever typed update the event holds.
+func (e WebhookEvent) Update() *StatusUpdate {
+	switch {
+	case e.Deposit != nil:
+		return &e.Deposit.StatusUpdate
+	case e.Transaction != nil:
+		return &e.Transaction.StatusUpdate
+	case e.Transfer != nil:
+		return &e.Transfer.StatusUpdate
+	default:
+		return nil
+	}
+}
+
+// flattenWebhookPayload lifts fields nested under "data" (object or JSON
+// string) to the top level without overriding top-level keys.
+func flattenWebhookPayload(payload map[string]any) map[string]any {
+	flat := make(map[string]any, len(payload)+8)
+	for k, v := range payload {
+		flat[k] = v
+	}
+	var nested map[string]any
+	switch data := payload["data"].(type) {
+	case map[string]any:
+		nested = data
+	case string:
+		_ = json.Unmarshal([]byte(data), &nested)
+	}
+	for k, v := range nested {
+		if _, exists := flat[k]; !exists {
+			flat[k] = v
+		}
+	}
+	return flat
+}
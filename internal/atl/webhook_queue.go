@@ -0,0 +1,185 @@
+package atl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bot-jual/internal/metrics"
+)
+
+const (
+	defaultWebhookWorkers      = 4
+	defaultWebhookQueueSize    = 256
+	defaultWebhookMaxAttempts  = 3
+	defaultWebhookRetryBackoff = 2 * time.Second
+	// webhookDrainTimeout bounds dead-lettering the backlog on shutdown.
+	webhookDrainTimeout = 10 * time.Second
+)
+
+// ErrWebhookQueueFull is returned when an event arrives while every queue slot is taken.
+var ErrWebhookQueueFull = errors.New("webhook queue full")
+
+var errShutdownBeforeProcessing = errors.New("shutdown before processing")
+
+// WebhookQueueConfig sizes the webhook worker pool. Zero fields use defaults.
+type WebhookQueueConfig struct {
+	Workers      int
+	QueueSize    int
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// WebhookQueue decouples webhook acknowledgement from processing. It
+// satisfies WebhookProcessor by enqueueing, so the HTTP handler can answer
+// Atlantic immediately, and a bounded pool of workers runs the wrapped
+// processor with retries. Events that keep failing are dead-lettered.
+type WebhookQueue struct {
+	processor    WebhookProcessor
+	deadLetters  DeadLetterStore
+	logger       *slog.Logger
+	metrics      *metrics.Metrics
+	events       chan WebhookEvent
+	workers      int
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// NewWebhookQueue wraps processor in a worker pool started by Run.
+func NewWebhookQueue(processor WebhookProcessor, logger *slog.Logger, metrics *metrics.Metrics, cfg WebhookQueueConfig) *WebhookQueue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWebhookWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultWebhookQueueSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultWebhookRetryBackoff
+	}
+	return &WebhookQueue{
+		processor:    processor,
+		logger:       logger.With("component", "atlantic_webhook_queue"),
+		metrics:      metrics,
+		events:       make(chan WebhookEvent, cfg.QueueSize),
+		workers:      cfg.Workers,
+		maxAttempts:  cfg.MaxAttempts,
+		retryBackoff: cfg.RetryBackoff,
+	}
+}
+
+// SetDeadLetterStore keeps events that exhaust their retries for replay.
+func (q *WebhookQueue) SetDeadLetterStore(store DeadLetterStore) {
+	q.deadLetters = store
+}
+
+// HandleAtlanticEvent enqueues the event without waiting for it to be processed.
+func (q *WebhookQueue) HandleAtlanticEvent(_ context.Context, event WebhookEvent) error {
+	select {
+	case q.events <- event:
+		q.observe("queued")
+		return nil
+	default:
+		q.observe("rejected")
+		return ErrWebhookQueueFull
+	}
+}
+
+// Run processes queued events until ctx is cancelled. Events still queued at
+// shutdown are dead-lettered so they can be replayed after restart.
+func (q *WebhookQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-q.events:
+					q.setDepth()
+					q.process(ctx, event)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	q.drain()
+}
+
+// process runs one event, retrying with linear backoff before dead-lettering
+// it. An attempt already running is allowed to finish during shutdown.
+func (q *WebhookQueue) process(ctx context.Context, event WebhookEvent) {
+	procCtx := context.WithoutCancel(ctx)
+	var err error
+	for attempt := 1; attempt <= q.maxAttempts; attempt++ {
+		if err = q.processor.HandleAtlanticEvent(procCtx, event); err == nil {
+			q.observe("processed")
+			return
+		}
+		if attempt == q.maxAttempts {
+			break
+		}
+		q.observe("retried")
+		q.logger.Warn("webhook processing failed, retrying", "error", err, "event", event.Type, "attempt", attempt)
+		select {
+		case <-ctx.Done():
+			q.deadLetter(procCtx, event, err)
+			return
+		case <-time.After(q.retryBackoff * time.Duration(attempt)):
+		}
+	}
+	q.logger.Error("failed processing webhook", "error", err, "event", event.Type, "attempts", q.maxAttempts)
+	if q.metrics != nil {
+		q.metrics.Errors.WithLabelValues("atlantic_webhook_process").Inc()
+	}
+	q.deadLetter(procCtx, event, err)
+}
+
+func (q *WebhookQueue) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookDrainTimeout)
+	defer cancel()
+	for {
+		select {
+		case event := <-q.events:
+			q.deadLetter(ctx, event, errShutdownBeforeProcessing)
+		default:
+			q.setDepth()
+			return
+		}
+	}
+}
+
+func (q *WebhookQueue) deadLetter(ctx context.Context, event WebhookEvent, procErr error) {
+	if q.deadLetters == nil {
+		q.observe("dropped")
+		return
+	}
+	if err := q.deadLetters.StoreDeadLetter(ctx, event, procErr); err != nil {
+		q.logger.Error("failed storing webhook dead letter", "error", err, "event", event.Type)
+		q.observe("dropped")
+		return
+	}
+	q.observe("dead_lettered")
+}
+
+func (q *WebhookQueue) observe(outcome string) {
+	if q.metrics == nil {
+		return
+	}
+	q.metrics.WebhookEvents.WithLabelValues(outcome).Inc()
+	if outcome == "queued" {
+		q.setDepth()
+	}
+}
+
+func (q *WebhookQueue) setDepth() {
+	if q.metrics != nil {
+		q.metrics.WebhookQueueDepth.Set(float64(len(q.events)))
+	}
+}
@@ -0,0 +1,74 @@
+package atl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type flakyProcessor struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	done     chan struct{}
+}
+
+func (p *flakyProcessor) HandleAtlanticEvent(context.Context, WebhookEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("temporary failure")
+	}
+	close(p.done)
+	return nil
+}
+
+type recordingDeadLetters struct {
+	mu     sync.Mutex
+	events []WebhookEvent
+}
+
+func (d *recordingDeadLetters) StoreDeadLetter(_ context.Context, event WebhookEvent, _ error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+	return nil
+}
+
+func TestWebhookQueueRetries(t *testing.T) {
+	proc := &flakyProcessor{failures: 1, done: make(chan struct{})}
+	q := NewWebhookQueue(proc, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, WebhookQueueConfig{
+		Workers: 1, QueueSize: 1, MaxAttempts: 2, RetryBackoff: time.Millisecond,
+	})
+	dead := &recordingDeadLetters{}
+	q.SetDeadLetterStore(dead)
+
+	if err := q.HandleAtlanticEvent(context.Background(), WebhookEvent{Type: "deposit"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.HandleAtlanticEvent(context.Background(), WebhookEvent{Type: "deposit"}); !errors.Is(err, ErrWebhookQueueFull) {
+		t.Fatalf("second enqueue into a full queue = %v, want ErrWebhookQueueFull", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+	select {
+	case <-proc.done:
+	case <-time.After(time.Second):
+		t.Fatalf("event was not retried to success")
+	}
+	cancel()
+	<-stopped
+	if proc.calls != 2 || len(dead.events) != 0 {
+		t.Fatalf("calls = %d, dead letters = %d", proc.calls, len(dead.events))
+	}
+}
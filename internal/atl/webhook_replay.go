@@ -0,0 +1,89 @@
+package atl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// atlanticZone is the zone of timestamps Atlantic sends without an offset.
+var atlanticZone = time.FixedZone("WIB", 7*3600)
+
+// SetReplayWindow rejects callbacks whose timestamp, when they carry one, is
+// further than window from now, so a captured callback cannot be replayed
+// once its ref has left the dedup window. Zero disables the check.
+func (h *WebhookHandler) SetReplayWindow(window time.Duration) {
+	h.replayWindow = window
+}
+
+// checkFreshness returns an error for a callback outside the replay window.
+// Callbacks without a timestamp pass; the idempotency layer still applies.
+func (h *WebhookHandler) checkFreshness(header http.Header, body []byte, now time.Time) error {
+	if h.replayWindow <= 0 {
+		return nil
+	}
+	sent, ok := webhookTimestamp(header, body)
+	if !ok {
+		return nil
+	}
+	age := now.Sub(sent)
+	if age > h.replayWindow {
+		return fmt.Errorf("timestamp %s is %s old", sent.Format(time.RFC3339), age.Round(time.Second))
+	}
+	if -age > h.replayWindow {
+		return fmt.Errorf("timestamp %s is %s ahead", sent.Format(time.RFC3339), (-age).Round(time.Second))
+	}
+	return nil
+}
+
+// webhookTimestamp finds when a callback was sent, from a timestamp header
+// or a timestamp field of the body or its data object.
+func webhookTimestamp(header http.Header, body []byte) (time.Time, bool) {
+	for _, key := range []string{"X-Atl-Timestamp", "X-Atlantic-Timestamp", "X-Webhook-Timestamp", "X-Timestamp"} {
+		if val := strings.TrimSpace(header.Get(key)); val != "" {
+			return parseWebhookTimestamp(val)
+		}
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return time.Time{}, false
+	}
+	flat := flattenWebhookPayload(payload)
+	for _, key := range []string{"timestamp", "ts", "sent_at"} {
+		switch v := flat[key].(type) {
+		case string:
+			if strings.TrimSpace(v) != "" {
+				return parseWebhookTimestamp(v)
+			}
+		case float64:
+			return unixTimestamp(int64(v)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseWebhookTimestamp accepts unix seconds or milliseconds, RFC 3339, and
+// "2006-01-02 15:04:05" in WIB. An unparseable value counts as absent.
+func parseWebhookTimestamp(val string) (time.Time, bool) {
+	val = strings.TrimSpace(val)
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return unixTimestamp(n), true
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(time.DateTime, val, atlanticZone); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func unixTimestamp(n int64) time.Time {
+	if n > 1e12 {
+		return time.UnixMilli(n)
+	}
+	return time.Unix(n, 0)
+}
@@ -0,0 +1,154 @@
+package atl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectEventTypePriceUpdate(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		body   string
+		want   string
+	}{
+		{"header hint", "price.updated", `{}`, EventPriceUpdate},
+		{"body type", "", `{"type":"product_update","data":{"type":"prabayar"}}`, EventPriceUpdate},
+		{"untyped price list", "", `{"price_list":[{"code":"TSEL10"}]}`, EventPriceUpdate},
+		{"transaction", "", `{"event":"transaksi","data":{"ref_id":"abc"}}`, "transaksi"},
+		{"deposit", "deposit", `{"products":[]}`, "deposit"},
+	}
+	for _, tc := range cases {
+		header := http.Header{}
+		if tc.header != "" {
+			header.Set("X-Atlantic-Event", tc.header)
+		}
+		if got := detectEventType(header, []byte(tc.body)); got != tc.want {
+			t.Errorf("%s: detectEventType = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPriceUpdateTypes(t *testing.T) {
+	got := PriceUpdateTypes([]byte(`{"type":"product_update","data":{"type":"postpaid"}}`))
+	if len(got) != 1 || got[0] != "pascabayar" {
+		t.Fatalf("PriceUpdateTypes = %v, want [pascabayar]", got)
+	}
+	if got := PriceUpdateTypes([]byte(`{"type":"price_update"}`)); len(got) != 0 {
+		t.Fatalf("PriceUpdateTypes without type = %v, want empty", got)
+	}
+}
+
+func TestNewWebhookEventTyped(t *testing.T) {
+	body := []byte(`{"event":"deposit","data":{"reff_id":"dep-1","status":"success","nominal":"50000","fee":700,"get_balance":49300}}`)
+	event, err := NewWebhookEvent("deposit", nil, body, time.Now())
+	if err != nil {
+		t.Fatalf("NewWebhookEvent: %v", err)
+	}
+	if event.Deposit == nil || event.Transaction != nil || event.Transfer != nil {
+		t.Fatalf("expected only a deposit update, got %+v", event)
+	}
+	dep := event.Deposit
+	if dep.Ref != "dep-1" || dep.Status != "success" || dep.Amount != 50000 || dep.Fee != 700 || dep.NetAmount != 49300 {
+		t.Fatalf("unexpected deposit update %+v", dep)
+	}
+
+	body = []byte(`{"event":"transaksi","data":"{\"ref_id\":\"trx-1\",\"status\":\"Sukses\",\"sn\":\"SN123\",\"code\":\"TSEL10\"}"}`)
+	event, err = NewWebhookEvent("transaksi", nil, body, time.Now())
+	if err != nil {
+		t.Fatalf("NewWebhookEvent: %v", err)
+	}
+	if event.Transaction == nil || event.Transaction.Ref != "trx-1" || event.Transaction.SN != "SN123" || event.Transaction.ProductCode != "TSEL10" {
+		t.Fatalf("unexpected transaction update %+v", event.Transaction)
+	}
+	if event.Update() != &event.Transaction.StatusUpdate {
+		t.Fatalf("Update should return the transaction status fields")
+	}
+
+	if _, err := NewWebhookEvent("transaksi", nil, []byte(`not json`), time.Now()); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
+
+func TestWebhookAuthAcceptsRotatedCredentials(t *testing.T) {
+	h := NewWebhookHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, []WebhookCredential{
+		{UsernameMD5: md5Hex("new-user"), PasswordMD5: md5Hex("new-pass")},
+		{UsernameMD5: strings.ToUpper(md5Hex("old-user")), PasswordMD5: md5Hex("old-pass")},
+	}, nil)
+	h.SetHMACSecrets([]string{"new-secret", "old-secret"})
+	body := []byte(`{"event":"deposit"}`)
+
+	request := func(user, pass string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/webhook/atlantic", nil)
+		r.SetBasicAuth(user, pass)
+		return r
+	}
+	for _, pair := range [][2]string{{"new-user", "new-pass"}, {"old-user", "old-pass"}} {
+		if err := h.validateAuth(request(pair[0], pair[1]), body); err != nil {
+			t.Fatalf("basic auth %s rejected: %v", pair[0], err)
+		}
+	}
+	if err := h.validateAuth(request("new-user", "old-pass"), body); err == nil {
+		t.Fatalf("mixed credential pair accepted")
+	}
+
+	signed := func(secret string) *http.Request {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		r := httptest.NewRequest(http.MethodPost, "/webhook/atlantic", nil)
+		r.Header.Set("X-Atl-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return r
+	}
+	if err := h.validateAuth(signed("old-secret"), body); err != nil {
+		t.Fatalf("hmac with old secret rejected: %v", err)
+	}
+	if err := h.validateAuth(signed("wrong"), body); err == nil {
+		t.Fatalf("hmac with unknown secret accepted")
+	}
+}
+
+func TestWebhookReplayWindow(t *testing.T) {
+	h := NewWebhookHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, nil)
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	withHeader := func(val string) http.Header {
+		header := http.Header{}
+		header.Set("X-Atl-Timestamp", val)
+		return header
+	}
+	old := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	if err := h.checkFreshness(withHeader(old), nil, now); err != nil {
+		t.Fatalf("check without a window rejected: %v", err)
+	}
+
+	h.SetReplayWindow(10 * time.Minute)
+	cases := []struct {
+		name   string
+		header http.Header
+		body   string
+		fresh  bool
+	}{
+		{"no timestamp", http.Header{}, `{"data":{"reff_id":"dep-1"}}`, true},
+		{"recent header", withHeader(strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)), `{}`, true},
+		{"old header", withHeader(old), `{}`, false},
+		{"old millis", withHeader(strconv.FormatInt(now.Add(-time.Hour).UnixMilli(), 10)), `{}`, false},
+		{"far future", withHeader(now.Add(time.Hour).Format(time.RFC3339)), `{}`, false},
+		{"recent body wib", http.Header{}, `{"data":{"timestamp":"2026-03-04 18:58:00"}}`, true},
+		{"old body", http.Header{}, `{"timestamp":` + old + `}`, false},
+	}
+	for _, tc := range cases {
+		err := h.checkFreshness(tc.header, []byte(tc.body), now)
+		if (err == nil) != tc.fresh {
+			t.Errorf("%s: checkFreshness = %v, want fresh %v", tc.name, err, tc.fresh)
+		}
+	}
+}
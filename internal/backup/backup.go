@@ -0,0 +1,282 @@
+// Package backup copies the database to local files on a nightly schedule,
+// keeps the newest copies, optionally uploads them to object storage and
+// restores a copy on request.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bot-jual/internal/metrics"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// filePrefix starts the name of every backup file, so rotation never
+// touches anything else in the directory.
+const filePrefix = "bot-jual-"
+
+// partialSuffix marks a dump still being written.
+const partialSuffix = ".partial"
+
+// Source copies the database to a file and back.
+type Source interface {
+	// Dump writes a consistent copy of the database to path, which does
+	// not exist yet.
+	Dump(ctx context.Context, path string) error
+	// Restore replaces the database contents with the copy at path.
+	Restore(ctx context.Context, path string) error
+	// Ext is the extension of dump files, including the dot.
+	Ext() string
+}
+
+// Uploader stores a finished backup off the host; objstore.Client fits.
+type Uploader interface {
+	PutObject(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// Notifier sends alerts to admins over WhatsApp.
+type Notifier interface {
+	SendText(ctx context.Context, to types.JID, text string) error
+}
+
+// Config groups knobs for scheduled backups.
+type Config struct {
+	// Dir holds the backup files.
+	Dir string
+	// Keep is how many backups stay in Dir; older ones are deleted.
+	Keep int
+	// At is the time of day backups run, as an offset from midnight in
+	// Location. A negative value disables the schedule.
+	At       time.Duration
+	Location *time.Location
+}
+
+// Runner takes backups.
+type Runner struct {
+	source    Source
+	uploader  Uploader
+	prefix    string
+	notifier  Notifier
+	adminJIDs []string
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
+	cfg       Config
+	now       func() time.Time
+}
+
+// New constructs a backup runner. metrics may be nil.
+func New(source Source, metrics *metrics.Metrics, logger *slog.Logger, cfg Config) *Runner {
+	if cfg.Keep <= 0 {
+		cfg.Keep = 7
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &Runner{
+		source:  source,
+		metrics: metrics,
+		logger:  logger.With("component", "backup"),
+		cfg:     cfg,
+		now:     time.Now,
+	}
+}
+
+// SetUploader uploads every backup under prefix as well. Old uploads are
+// not deleted; use a bucket lifecycle rule for that.
+func (r *Runner) SetUploader(uploader Uploader, prefix string) {
+	r.uploader = uploader
+	r.prefix = strings.Trim(prefix, "/")
+}
+
+// SetNotifier alerts the admins after every scheduled backup.
+func (r *Runner) SetNotifier(notifier Notifier, adminJIDs []string) {
+	r.notifier = notifier
+	r.adminJIDs = adminJIDs
+}
+
+// Run takes a backup every day at the configured time until ctx is
+// cancelled, alerting admins of the outcome.
+func (r *Runner) Run(ctx context.Context) {
+	if r.cfg.At < 0 {
+		return
+	}
+	for {
+		next := nextRun(r.now(), r.cfg.At, r.cfg.Location)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		file, err := r.Backup(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		r.alert(ctx, file, err)
+	}
+}
+
+// nextRun returns the first time after now that falls at offset at past
+// midnight in loc.
+func nextRun(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	next := midnight.Add(at)
+	if !next.After(now) {
+		next = midnight.AddDate(0, 0, 1).Add(at)
+	}
+	return next
+}
+
+// Backup dumps the database into Dir, uploads the dump when an uploader is
+// set and deletes backups beyond Keep. It returns the new file's path.
+func (r *Runner) Backup(ctx context.Context) (string, error) {
+	started := r.now()
+	file, err := r.backup(ctx)
+	if err != nil {
+		r.observe("error")
+		r.logger.Error("backup failed", "error", err)
+		return file, err
+	}
+	r.observe("success")
+	if r.metrics != nil {
+		r.metrics.BackupLastSuccess.SetToCurrentTime()
+	}
+	r.logger.Info("backup completed", "file", file, "duration", r.now().Sub(started).Round(time.Millisecond))
+	return file, nil
+}
+
+func (r *Runner) backup(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(r.cfg.Dir, 0o700); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+	name := filePrefix + r.now().UTC().Format("20060102T150405Z") + r.source.Ext()
+	file := filepath.Join(r.cfg.Dir, name)
+	partial := file + partialSuffix
+	_ = os.Remove(partial)
+	if err := r.source.Dump(ctx, partial); err != nil {
+		_ = os.Remove(partial)
+		return "", fmt.Errorf("dump database: %w", err)
+	}
+	if err := os.Rename(partial, file); err != nil {
+		return "", fmt.Errorf("finish backup file: %w", err)
+	}
+
+	if r.uploader != nil {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return file, fmt.Errorf("read backup for upload: %w", err)
+		}
+		if err := r.uploader.PutObject(ctx, path.Join(r.prefix, name), "application/octet-stream", data); err != nil {
+			return file, fmt.Errorf("upload backup: %w", err)
+		}
+	}
+
+	if err := r.rotate(); err != nil {
+		// The new backup is safe; a full disk shows up in the next run.
+		r.logger.Warn("failed deleting old backups", "error", err)
+	}
+	return file, nil
+}
+
+// rotate deletes all but the newest Keep backups, along with dumps left
+// partial by a crash.
+func (r *Runner) rotate() error {
+	files, err := List(r.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if len(files) > r.cfg.Keep {
+		for _, file := range files[:len(files)-r.cfg.Keep] {
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("remove old backup: %w", err)
+			}
+		}
+	}
+	partials, _ := filepath.Glob(filepath.Join(r.cfg.Dir, filePrefix+"*"+partialSuffix))
+	for _, partial := range partials {
+		_ = os.Remove(partial)
+	}
+	return nil
+}
+
+// List returns the finished backups in dir, oldest first.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || strings.HasSuffix(name, partialSuffix) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	// Names embed a UTC timestamp, so they sort chronologically.
+	sort.Strings(files)
+	return files, nil
+}
+
+// Latest returns the newest finished backup in dir.
+func Latest(dir string) (string, error) {
+	files, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no backups in %s", dir)
+	}
+	return files[len(files)-1], nil
+}
+
+// Restore replaces the database with the backup at file.
+func (r *Runner) Restore(ctx context.Context, file string) error {
+	if _, err := os.Stat(file); err != nil {
+		return fmt.Errorf("backup file: %w", err)
+	}
+	if err := r.source.Restore(ctx, file); err != nil {
+		return fmt.Errorf("restore %s: %w", filepath.Base(file), err)
+	}
+	r.logger.Info("database restored", "file", file)
+	return nil
+}
+
+func (r *Runner) alert(ctx context.Context, file string, backupErr error) {
+	if r.notifier == nil {
+		return
+	}
+	text := fmt.Sprintf("Backup database berhasil: %s.", filepath.Base(file))
+	if backupErr != nil {
+		text = fmt.Sprintf("Backup database GAGAL: %v. Cek log server dan ruang disk.", backupErr)
+	} else if r.uploader != nil {
+		text = fmt.Sprintf("Backup database berhasil: %s, sudah diunggah ke object storage.", filepath.Base(file))
+	}
+	for _, raw := range r.adminJIDs {
+		jid, err := wa.ParseAdminJID(raw)
+		if err != nil {
+			r.logger.Warn("invalid admin jid", "error", err, "jid", raw)
+			continue
+		}
+		if err := r.notifier.SendText(ctx, jid, text); err != nil {
+			r.logger.Warn("failed sending backup alert", "error", err, "jid", raw)
+		}
+	}
+}
+
+func (r *Runner) observe(outcome string) {
+	if r.metrics != nil {
+		r.metrics.Backups.WithLabelValues(outcome).Inc()
+	}
+}
@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+type fakeSource struct {
+	err      error
+	restored string
+}
+
+func (s *fakeSource) Ext() string { return ".db" }
+
+func (s *fakeSource) Dump(_ context.Context, path string) error {
+	if s.err != nil {
+		return s.err
+	}
+	return os.WriteFile(path, []byte("dump"), 0o600)
+}
+
+func (s *fakeSource) Restore(_ context.Context, path string) error {
+	s.restored = path
+	return nil
+}
+
+type fakeUploader struct{ keys []string }
+
+func (u *fakeUploader) PutObject(_ context.Context, key, _ string, _ []byte) error {
+	u.keys = append(u.keys, key)
+	return nil
+}
+
+type fakeNotifier struct{ texts []string }
+
+func (n *fakeNotifier) SendText(_ context.Context, _ types.JID, text string) error {
+	n.texts = append(n.texts, text)
+	return nil
+}
+
+func newTestRunner(t *testing.T, source Source, keep int) (*Runner, *time.Time) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := New(source, nil, logger, Config{Dir: t.TempDir(), Keep: keep})
+	now := time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestBackupRotatesAndUploads(t *testing.T) {
+	r, now := newTestRunner(t, &fakeSource{}, 2)
+	uploader := &fakeUploader{}
+	r.SetUploader(uploader, "/backups/")
+	unrelated := filepath.Join(r.cfg.Dir, "notes.txt")
+	if err := os.WriteFile(unrelated, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(r.cfg.Dir, filePrefix+"20250101T000000Z.db"+partialSuffix)
+	if err := os.WriteFile(stale, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for i := 0; i < 3; i++ {
+		file, err := r.Backup(context.Background())
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		files = append(files, file)
+		*now = now.Add(24 * time.Hour)
+	}
+
+	kept, err := List(r.cfg.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 2 || kept[0] != files[1] || kept[1] != files[2] {
+		t.Fatalf("kept %v, want the last two of %v", kept, files)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Fatalf("unrelated file removed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("partial dump not cleaned up: %v", err)
+	}
+	if len(uploader.keys) != 3 || uploader.keys[0] != "backups/bot-jual-20260101T200000Z.db" {
+		t.Fatalf("unexpected uploads %v", uploader.keys)
+	}
+	if latest, _ := Latest(r.cfg.Dir); latest != files[2] {
+		t.Fatalf("latest = %s, want %s", latest, files[2])
+	}
+}
+
+func TestBackupFailureLeavesNoFile(t *testing.T) {
+	r, _ := newTestRunner(t, &fakeSource{err: errors.New("pg_dump: connection refused")}, 3)
+	notifier := &fakeNotifier{}
+	r.SetNotifier(notifier, []string{"628111"})
+
+	file, err := r.Backup(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	r.alert(context.Background(), file, err)
+	if files, _ := List(r.cfg.Dir); len(files) != 0 {
+		t.Fatalf("failed backup left files %v", files)
+	}
+	if len(notifier.texts) != 1 || !strings.Contains(notifier.texts[0], "GAGAL") || !strings.Contains(notifier.texts[0], "connection refused") {
+		t.Fatalf("unexpected alerts %q", notifier.texts)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	at := 3 * time.Hour
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 1, 1, 1, 0, 0, 0, wib), time.Date(2026, 1, 1, 3, 0, 0, 0, wib)},
+		{time.Date(2026, 1, 1, 3, 0, 0, 0, wib), time.Date(2026, 1, 2, 3, 0, 0, 0, wib)},
+		// 21:00 UTC is already 04:00 the next day in WIB.
+		{time.Date(2026, 1, 1, 21, 0, 0, 0, time.UTC), time.Date(2026, 1, 3, 3, 0, 0, 0, wib)},
+	}
+	for _, tc := range cases {
+		if got := nextRun(tc.now, at, wib); !got.Equal(tc.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestSQLiteRestoreKeepsCurrentDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "bot.db")
+	backupPath := filepath.Join(dir, "bot-jual-20260101T200000Z.db")
+	for path, content := range map[string]string{dbPath: "current", dbPath + "-wal": "wal", backupPath: "backup"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := SQLite{Path: "file:" + dbPath + "?_pragma=busy_timeout=10000"}
+	if err := source.Restore(context.Background(), backupPath); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dbPath); string(got) != "backup" {
+		t.Fatalf("database = %q, want the backup", got)
+	}
+	if got, _ := os.ReadFile(dbPath + ".pre-restore"); string(got) != "current" {
+		t.Fatalf("previous database = %q, want it kept", got)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Fatalf("stale WAL not removed: %v", err)
+	}
+}
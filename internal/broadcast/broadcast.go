@@ -0,0 +1,81 @@
+// Package broadcast fans out WhatsApp messages to many users at a throttled rate.
+package broadcast
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Sender delivers a text message over WhatsApp.
+type Sender interface {
+	SendText(ctx context.Context, to types.JID, text string) error
+}
+
+// Message is a single outgoing broadcast message.
+type Message struct {
+	UserID string
+	JID    types.JID
+	Text   string
+}
+
+// Result reports the outcome of sending one Message.
+type Result struct {
+	Message Message
+	Err     error
+}
+
+// Broadcaster sends messages sequentially so bulk sends stay under WhatsApp rate limits.
+type Broadcaster struct {
+	sender Sender
+	logger *slog.Logger
+}
+
+// New constructs a Broadcaster.
+func New(sender Sender, logger *slog.Logger) *Broadcaster {
+	return &Broadcaster{
+		sender: sender,
+		logger: logger.With("component", "broadcast"),
+	}
+}
+
+// Send delivers msgs at most perMinute per minute, calling onResult after each
+// attempt. It stops early when ctx is done or onResult returns false.
+func (b *Broadcaster) Send(ctx context.Context, msgs []Message, perMinute int, onResult func(Result) bool) error {
+	interval := throttleInterval(perMinute)
+	for i, msg := range msgs {
+		if i > 0 && interval > 0 {
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sendCtx := wa.WithOrigin(ctx, wa.Origin{Trigger: wa.TriggerBroadcast, UserID: msg.UserID})
+		err := b.sender.SendText(sendCtx, msg.JID, msg.Text)
+		if err != nil {
+			b.logger.Warn("failed sending broadcast message", "error", err, "user_id", msg.UserID)
+		}
+		if onResult != nil && !onResult(Result{Message: msg, Err: err}) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// throttleInterval is the pause between two sends; zero or negative rates send without pausing.
+func throttleInterval(perMinute int) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+	return time.Minute / time.Duration(perMinute)
+}
@@ -0,0 +1,110 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+type fakeSender struct {
+	sent []string
+	fail map[string]bool
+}
+
+func (f *fakeSender) SendText(_ context.Context, to types.JID, text string) error {
+	if f.fail[to.User] {
+		return errors.New("boom")
+	}
+	f.sent = append(f.sent, to.User)
+	return nil
+}
+
+func TestThrottleInterval(t *testing.T) {
+	if got := throttleInterval(30); got != 2*time.Second {
+		t.Errorf("throttleInterval(30) = %v, want 2s", got)
+	}
+	if got := throttleInterval(0); got != 0 {
+		t.Errorf("throttleInterval(0) = %v, want 0", got)
+	}
+}
+
+func TestSendReportsResultsAndStops(t *testing.T) {
+	sender := &fakeSender{fail: map[string]bool{"2": true}}
+	b := New(sender, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	msgs := []Message{
+		{UserID: "a", JID: types.NewJID("1", types.DefaultUserServer)},
+		{UserID: "b", JID: types.NewJID("2", types.DefaultUserServer)},
+		{UserID: "c", JID: types.NewJID("3", types.DefaultUserServer)},
+	}
+
+	var failed []string
+	err := b.Send(context.Background(), msgs, 0, func(res Result) bool {
+		if res.Err != nil {
+			failed = append(failed, res.Message.UserID)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != "1" {
+		t.Errorf("sent = %v, want [1]", sender.sent)
+	}
+	if len(failed) != 1 || failed[0] != "b" {
+		t.Errorf("failed = %v, want [b]", failed)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	name := "Budi"
+	if got := renderTemplate("Halo {name}, promo pulsa!", repo.User{DisplayName: &name}); got != "Halo Budi, promo pulsa!" {
+		t.Errorf("renderTemplate with name = %q", got)
+	}
+	if got := renderTemplate("Halo {name}", repo.User{}); got != "Halo Kak" {
+		t.Errorf("renderTemplate without name = %q", got)
+	}
+}
+
+func TestFlashSaleTemplate(t *testing.T) {
+	sale := repo.FlashSale{ProductCode: "tsel25", Price: 23500, EndsAt: time.Date(2026, 5, 1, 13, 0, 0, 0, time.UTC)}
+	got := FlashSaleTemplate("Halo {name}, {code} jadi {price} sampai {until}!", sale)
+	if want := "Halo {name}, TSEL25 jadi Rp23.500 sampai {until:2026-05-01T13:00:00Z}!"; got != want {
+		t.Errorf("FlashSaleTemplate = %q, want %q", got, want)
+	}
+	name := "Budi"
+	if got, want := renderTemplate(got, repo.User{DisplayName: &name}), "Halo Budi, TSEL25 jadi Rp23.500 sampai 01 May 20:00 WIB!"; got != want {
+		t.Errorf("rendered for default zone = %q, want %q", got, want)
+	}
+	if got, want := renderTemplate(got, repo.User{Timezone: "Asia/Jayapura"}), "Halo Kak, TSEL25 jadi Rp23.500 sampai 01 May 22:00 WIT!"; got != want {
+		t.Errorf("rendered for Asia/Jayapura = %q, want %q", got, want)
+	}
+	if got := FlashSaleTemplate(" ", sale); !strings.Contains(got, "/beli TSEL25") {
+		t.Errorf("default template = %q", got)
+	}
+}
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) ProductCodesInCategory(_ context.Context, category string) ([]string, error) {
+	return f[category], nil
+}
+
+func TestResolveSegment(t *testing.T) {
+	resolver := fakeResolver{"Pulsa": {"TSEL10", "XL10"}}
+	seg, err := ResolveSegment(context.Background(), resolver, repo.Segment{BoughtCategory: "Pulsa", MinSpent: 50000})
+	if err != nil {
+		t.Fatalf("ResolveSegment returned error: %v", err)
+	}
+	if len(seg.ProductCodes) != 2 || seg.MinSpent != 50000 {
+		t.Errorf("ResolveSegment = %+v, want two product codes and min spent kept", seg)
+	}
+}
@@ -0,0 +1,179 @@
+package broadcast
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"bot-jual/internal/persona"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SchedulerConfig groups knobs for the campaign scheduler.
+type SchedulerConfig struct {
+	PollInterval time.Duration
+	// ClaimLease is how long a running campaign may send nothing before
+	// another poll takes it over, e.g. after a crash mid-send.
+	ClaimLease time.Duration
+	Persona    persona.Persona
+}
+
+// CategoryResolver maps a catalog category to the product codes it contains.
+type CategoryResolver interface {
+	ProductCodesInCategory(ctx context.Context, category string) ([]string, error)
+}
+
+// Scheduler starts due campaigns and sends them through the Broadcaster.
+type Scheduler struct {
+	repo        repo.Repository
+	broadcaster *Broadcaster
+	logger      *slog.Logger
+	cfg         SchedulerConfig
+	categories  CategoryResolver
+}
+
+// NewScheduler constructs a campaign scheduler.
+func NewScheduler(repository repo.Repository, broadcaster *Broadcaster, logger *slog.Logger, cfg SchedulerConfig) *Scheduler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Minute
+	}
+	if cfg.ClaimLease <= 0 {
+		cfg.ClaimLease = 15 * time.Minute
+	}
+	return &Scheduler{
+		repo:        repository,
+		broadcaster: broadcaster,
+		logger:      logger.With("component", "campaign_scheduler"),
+		cfg:         cfg,
+	}
+}
+
+// SetCategoryResolver registers the catalog lookup used by bought-category segments.
+func (s *Scheduler) SetCategoryResolver(resolver CategoryResolver) {
+	s.categories = resolver
+}
+
+// Run polls for due campaigns until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runDue(ctx context.Context) {
+	due, err := s.repo.ClaimDueCampaigns(ctx, time.Now(), s.cfg.ClaimLease)
+	if err != nil {
+		s.logger.Error("failed claiming due campaigns", "error", err)
+		return
+	}
+	for _, c := range due {
+		s.runCampaign(ctx, c)
+	}
+}
+
+func (s *Scheduler) runCampaign(ctx context.Context, c repo.Campaign) {
+	logger := s.logger.With("campaign_id", c.ID, "campaign", c.Name)
+	seg, err := ResolveSegment(ctx, s.categories, c.Segment)
+	if err != nil {
+		logger.Error("failed resolving campaign segment", "error", err)
+		return
+	}
+	users, err := s.repo.ListCampaignRecipients(ctx, c.ID, seg)
+	if err != nil {
+		// Leave it running; deliveries already recorded are skipped on the next attempt.
+		logger.Error("failed listing campaign recipients", "error", err)
+		return
+	}
+	logger.Info("campaign started", "recipients", len(users))
+
+	msgs := make([]Message, 0, len(users))
+	for _, u := range users {
+		jid, err := types.ParseJID(*u.WAJID)
+		if err != nil {
+			logger.Warn("invalid recipient jid", "error", err, "user_id", u.ID)
+			continue
+		}
+		msgs = append(msgs, Message{
+			UserID: u.ID,
+			JID:    jid,
+			Text:   s.cfg.Persona.Sign(renderTemplate(c.Template, u)),
+		})
+	}
+
+	cancelled := false
+	sendErr := s.broadcaster.Send(wa.WithOrigin(ctx, wa.Origin{CorrelationID: c.ID}), msgs, c.ThrottlePerMinute, func(res Result) bool {
+		delivery := repo.CampaignDelivery{CampaignID: c.ID, UserID: res.Message.UserID, Status: repo.DeliverySent}
+		if res.Err != nil {
+			errMsg := res.Err.Error()
+			delivery.Status, delivery.Error = repo.DeliveryFailed, &errMsg
+		}
+		if err := s.repo.RecordCampaignDelivery(ctx, delivery); err != nil {
+			logger.Warn("failed recording campaign delivery", "error", err, "user_id", res.Message.UserID)
+		}
+		current, err := s.repo.GetCampaign(ctx, c.ID)
+		if err == nil && current.Status == repo.CampaignCancelled {
+			cancelled = true
+			return false
+		}
+		return true
+	})
+	if sendErr != nil {
+		logger.Warn("campaign interrupted", "error", sendErr)
+		return
+	}
+	if cancelled {
+		logger.Info("campaign cancelled while sending")
+		return
+	}
+	if err := s.repo.UpdateCampaignStatus(ctx, c.ID, repo.CampaignCompleted); err != nil {
+		logger.Error("failed completing campaign", "error", err)
+		return
+	}
+	logger.Info("campaign completed")
+}
+
+// untilPattern matches the {until:<RFC 3339>} markers of flash sale templates.
+var untilPattern = regexp.MustCompile(`\{until:([^}]+)\}`)
+
+// renderTemplate fills the {name} placeholder with the recipient's display
+// name and {until:...} markers with that time in the recipient's zone.
+func renderTemplate(tmpl string, u repo.User) string {
+	name := "Kak"
+	if u.DisplayName != nil && strings.TrimSpace(*u.DisplayName) != "" {
+		name = strings.TrimSpace(*u.DisplayName)
+	}
+	tmpl = untilPattern.ReplaceAllStringFunc(tmpl, func(marker string) string {
+		at, err := time.Parse(time.RFC3339, untilPattern.FindStringSubmatch(marker)[1])
+		if err != nil {
+			return marker
+		}
+		return at.In(u.Location()).Format("02 Jan 15:04 MST")
+	})
+	return strings.ReplaceAll(tmpl, "{name}", name)
+}
+
+// ResolveSegment fills in the product codes of a bought-category segment.
+func ResolveSegment(ctx context.Context, resolver CategoryResolver, seg repo.Segment) (repo.Segment, error) {
+	if seg.BoughtCategory == "" || len(seg.ProductCodes) > 0 || resolver == nil {
+		return seg, nil
+	}
+	codes, err := resolver.ProductCodesInCategory(ctx, seg.BoughtCategory)
+	if err != nil {
+		return seg, fmt.Errorf("resolve category %q: %w", seg.BoughtCategory, err)
+	}
+	seg.ProductCodes = codes
+	return seg, nil
+}
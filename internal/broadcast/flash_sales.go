@@ -0,0 +1,28 @@
+package broadcast
+
+import (
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+// DefaultFlashSaleTemplate announces a flash sale when the admin gives no
+// template of their own.
+const DefaultFlashSaleTemplate = "Halo {name}! ⚡ Flash sale {code} cuma {price} sampai {until}. Ketik \"/beli {code}\" buat ambil sebelum harganya balik normal ya."
+
+// FlashSaleTemplate fills the {code} and {price} placeholders of an
+// announcement. {name} is left for the scheduler to fill per recipient, and
+// {until} becomes a {until:<RFC 3339>} marker it renders in the recipient's
+// time zone.
+func FlashSaleTemplate(tmpl string, sale repo.FlashSale) string {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultFlashSaleTemplate
+	}
+	return strings.NewReplacer(
+		"{code}", strings.ToUpper(sale.ProductCode),
+		"{price}", money.FromRupiah(sale.Price).String(),
+		"{until}", "{until:"+sale.EndsAt.UTC().Format(time.RFC3339)+"}",
+	).Replace(tmpl)
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLocked is returned by Lock while another holder owns the key.
+	ErrLocked = errors.New("redis lock held")
+	// ErrUnavailable is returned by Lock while Monitor reports Redis down,
+	// so callers can fall back to a local lock instead of waiting on timeouts.
+	ErrUnavailable = errors.New("redis unavailable")
+)
+
+// releaseScript deletes the lock only if it still holds our token, so a
+// holder that outlived its TTL cannot free someone else's lock.
+var releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Lock is a held distributed lock; see Redis.Lock.
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// Lock takes key for ttl with SET NX. The lock expires on its own after ttl,
+// so a crashed holder cannot block others for longer; Release frees it early.
+func (r *Redis) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if !r.Available() {
+		return nil, ErrUnavailable
+	}
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, fmt.Errorf("lock token: %w", err)
+	}
+	token := hex.EncodeToString(buf[:])
+	ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis lock %s: %w", key, err)
+	}
+	if !ok {
+		return nil, ErrLocked
+	}
+	return &Lock{client: r.client, key: key, token: token}, nil
+}
+
+// Release frees the lock if it has not expired and been taken over.
+func (l *Lock) Release(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("redis unlock %s: %w", l.key, err)
+	}
+	return nil
+}
@@ -6,15 +6,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// Redis wraps a go-redis client with logging helpers.
+// Redis wraps a go-redis client with logging helpers. While Monitor reports
+// Redis down, the JSON helpers skip it instead of waiting on timeouts: reads
+// miss and writes are dropped.
 type Redis struct {
 	client *redis.Client
 	logger *slog.Logger
+	down   atomic.Bool
 }
 
 // Config defines connection parameters for Redis.
@@ -55,8 +59,45 @@ func (r *Redis) Ping(ctx context.Context) error {
 	return nil
 }
 
+// Available reports whether the last health check reached Redis.
+func (r *Redis) Available() bool {
+	return !r.down.Load()
+}
+
+// Monitor pings Redis every interval until ctx is cancelled and calls
+// onChange, which may be nil, whenever availability flips.
+func (r *Redis) Monitor(ctx context.Context, interval time.Duration, onChange func(up bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := r.Ping(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		up := err == nil
+		if wasDown := r.down.Swap(!up); wasDown == up {
+			if up {
+				r.logger.Info("redis recovered, caching resumed")
+			} else {
+				r.logger.Warn("redis unavailable, caching skipped", "error", err)
+			}
+			if onChange != nil {
+				onChange(up)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // SetJSON caches a value as JSON with the provided TTL.
 func (r *Redis) SetJSON(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if !r.Available() {
+		return nil
+	}
 	data, err := jsonMarshal(value)
 	if err != nil {
 		return err
@@ -66,6 +107,9 @@ func (r *Redis) SetJSON(ctx context.Context, key string, value any, ttl time.Dur
 
 // GetJSON retrieves JSON value and unmarshals into dest.
 func (r *Redis) GetJSON(ctx context.Context, key string, dest any) (bool, error) {
+	if !r.Available() {
+		return false, nil
+	}
 	res, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
@@ -79,6 +123,17 @@ func (r *Redis) GetJSON(ctx context.Context, key string, dest any) (bool, error)
 	return true, nil
 }
 
+// Delete removes the given keys.
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 || !r.Available() {
+		return nil
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("redis del: %w", err)
+	}
+	return nil
+}
+
 // Close releases Redis resources.
 func (r *Redis) Close() error {
 	return r.client.Close()
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestMonitorSkipsUnavailableRedis(t *testing.T) {
+	r := New(Config{Addr: "127.0.0.1:1"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan bool, 1)
+	go r.Monitor(ctx, time.Hour, func(up bool) { changes <- up })
+
+	select {
+	case up := <-changes:
+		if up {
+			t.Fatalf("unreachable redis reported up")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("monitor did not report redis down")
+	}
+	if r.Available() {
+		t.Fatalf("Available() = true after failed health check")
+	}
+	var dest map[string]string
+	if ok, err := r.GetJSON(ctx, "k", &dest); ok || err != nil {
+		t.Fatalf("GetJSON while down = %v, %v; want a silent miss", ok, err)
+	}
+	if err := r.SetJSON(ctx, "k", map[string]string{"a": "b"}, time.Minute); err != nil {
+		t.Fatalf("SetJSON while down = %v; want it skipped", err)
+	}
+	if _, err := r.Lock(ctx, "lock:k", time.Minute); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Lock while down = %v; want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package chaos injects dependency failures on demand so degradation and
+// retry paths can be exercised in staging. It is wired in only when
+// CHAOS_ENABLED is set and never in production.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Faults that can be injected.
+const (
+	// Redis fails every Redis command as if the server were unreachable.
+	Redis = "redis"
+	// Atlantic answers supplier calls with HTTP 500.
+	Atlantic = "atlantic"
+	// Gemini answers Gemini calls with HTTP 429, as when a key's quota is spent.
+	Gemini = "gemini"
+	// WhatsAppSend fails outgoing WhatsApp messages.
+	WhatsAppSend = "wa_send"
+)
+
+// Faults lists every injectable fault.
+var Faults = []string{Redis, Atlantic, Gemini, WhatsAppSend}
+
+// ErrInjected marks a failure produced by the injector rather than the
+// dependency itself.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Status describes one active fault.
+type Status struct {
+	Fault string `json:"fault"`
+	// Rate is the share of calls that fail, in (0, 1].
+	Rate float64 `json:"rate"`
+	// Until is when the fault clears itself; zero means it stays until
+	// cleared.
+	Until time.Time `json:"until,omitzero"`
+}
+
+// Injector decides, call by call, whether a dependency should fail.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]Status
+	now    func() time.Time
+	roll   func() float64
+}
+
+// New returns an injector with no active faults.
+func New() *Injector {
+	return &Injector{faults: map[string]Status{}, now: time.Now, roll: rand.Float64}
+}
+
+// Set makes rate of the calls to fault fail, for d or until cleared when d
+// is zero.
+func (i *Injector) Set(fault string, rate float64, d time.Duration) error {
+	if !known(fault) {
+		return fmt.Errorf("unknown fault %q", fault)
+	}
+	if rate <= 0 || rate > 1 {
+		return fmt.Errorf("fault %s: rate must be in (0, 1]", fault)
+	}
+	if d < 0 {
+		return fmt.Errorf("fault %s: duration must not be negative", fault)
+	}
+	status := Status{Fault: fault, Rate: rate}
+	if d > 0 {
+		status.Until = i.now().Add(d).UTC()
+	}
+	i.mu.Lock()
+	i.faults[fault] = status
+	i.mu.Unlock()
+	return nil
+}
+
+// Clear stops injecting fault.
+func (i *Injector) Clear(fault string) {
+	i.mu.Lock()
+	delete(i.faults, fault)
+	i.mu.Unlock()
+}
+
+// Active returns the faults currently injected, by name.
+func (i *Injector) Active() []Status {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	res := make([]Status, 0, len(i.faults))
+	for name, status := range i.faults {
+		if i.expired(status) {
+			delete(i.faults, name)
+			continue
+		}
+		res = append(res, status)
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].Fault < res[b].Fault })
+	return res
+}
+
+// Fail reports whether this call to fault should fail. A nil injector
+// never fails, so callers need not check whether chaos is enabled.
+func (i *Injector) Fail(fault string) bool {
+	if i == nil {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	status, ok := i.faults[fault]
+	if !ok {
+		return false
+	}
+	if i.expired(status) {
+		delete(i.faults, fault)
+		return false
+	}
+	return status.Rate >= 1 || i.roll() < status.Rate
+}
+
+func (i *Injector) expired(status Status) bool {
+	return !status.Until.IsZero() && !i.now().Before(status.Until)
+}
+
+func known(fault string) bool {
+	for _, f := range Faults {
+		if f == fault {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInjectorRateAndExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	inj := New()
+	inj.now = func() time.Time { return now }
+	inj.roll = func() float64 { return 0.4 }
+
+	if err := inj.Set("disk", 1, 0); err == nil {
+		t.Fatal("expected an error for an unknown fault")
+	}
+	if err := inj.Set(Atlantic, 0.5, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := inj.Set(Gemini, 0.3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !inj.Fail(Atlantic) || inj.Fail(Gemini) || inj.Fail(Redis) {
+		t.Fatal("faults fired against their rates")
+	}
+
+	now = now.Add(time.Minute)
+	if inj.Fail(Atlantic) {
+		t.Fatal("expired fault still fails calls")
+	}
+	if active := inj.Active(); len(active) != 1 || active[0].Fault != Gemini {
+		t.Fatalf("active = %+v, want only gemini", active)
+	}
+
+	var nilInjector *Injector
+	if nilInjector.Fail(Redis) || nilInjector.SendFault() != nil {
+		t.Fatal("nil injector failed a call")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	inj := New()
+	client := &http.Client{Transport: inj.Transport(Gemini, http.DefaultTransport)}
+	get := func() int {
+		t.Helper()
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("status = %d before injection", code)
+	}
+	if err := inj.Set(Gemini, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", code)
+	}
+	inj.Clear(Gemini)
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("status = %d after clearing", code)
+	}
+
+	if err := inj.Set(WhatsAppSend, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := inj.SendFault(); !errors.Is(err, ErrInjected) {
+		t.Fatalf("SendFault = %v, want ErrInjected", err)
+	}
+}
@@ -0,0 +1,104 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Transport wraps next so calls fail with the response fault implies while
+// it is active: HTTP 500 for Atlantic and HTTP 429 for Gemini.
+func (i *Injector) Transport(fault string, next http.RoundTripper) http.RoundTripper {
+	return &transport{injector: i, fault: fault, next: next}
+}
+
+type transport struct {
+	injector *Injector
+	fault    string
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.injector.Fail(t.fault) {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	status, body := http.StatusInternalServerError, `{"status":false,"message":"chaos: injected server error"}`
+	if t.fault == Gemini {
+		status, body = http.StatusTooManyRequests, `{"error":{"code":429,"message":"chaos: injected quota exhaustion","status":"RESOURCE_EXHAUSTED"}}`
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// RedisHook fails Redis dials and commands while the Redis fault is active,
+// so health checks see Redis down and callers take their fallback paths.
+func (i *Injector) RedisHook() redis.Hook {
+	return redisHook{injector: i}
+}
+
+type redisHook struct {
+	injector *Injector
+}
+
+func (h redisHook) err() error {
+	return fmt.Errorf("redis unavailable: %w", ErrInjected)
+}
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if h.injector.Fail(Redis) {
+			return nil, h.err()
+		}
+		return next(ctx, network, addr)
+	}
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.injector.Fail(Redis) {
+			err := h.err()
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if h.injector.Fail(Redis) {
+			err := h.err()
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// SendFault fails a WhatsApp send while the WhatsApp send fault is active;
+// it fits wa.Client.SetSendFault.
+func (i *Injector) SendFault() error {
+	if i.Fail(WhatsAppSend) {
+		return fmt.Errorf("whatsapp send: %w", ErrInjected)
+	}
+	return nil
+}
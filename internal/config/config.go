@@ -7,28 +7,54 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"bot-jual/internal/httpx"
 )
 
-// Config holds the application configuration loaded from environment variables.
+// Config holds the application configuration loaded from environment
+// variables and the optional CONFIG_FILE.
 type Config struct {
+	// ConfigFile is the YAML file settings were read from, if any.
+	ConfigFile                       string
 	AppEnv                           string
 	LogLevel                         string
+	LogFormat                        string
+	LogComponentLevels               map[string]string
+	LogRingSize                      int
 	HTTPListenAddr                   string
 	DatabaseURL                      string
 	IsSQLite                         bool
+	IsMySQL                          bool
 	SupabaseSchema                   string
 	WhatsAppStorePath                string
 	WhatsAppDeviceJID                string
 	WhatsAppLogLevel                 string
+	WhatsAppPairPhone                string
+	AdminAlertWebhookURL             string
+	WhatsAppInteractive              bool
 	AtlanticAPIKey                   string
 	AtlanticBaseURL                  string
 	AtlanticTimeout                  time.Duration
 	AtlanticWebhookSecretMD5Username string
 	AtlanticWebhookSecretMD5Password string
+	AtlanticWebhookOldCredentials    []WebhookCredential
+	AtlanticWebhookHMACSecrets       []string
+	AtlanticWebhookReplayWindow      time.Duration
 	GeminiAPIKeys                    []string
 	GeminiModel                      string
+	WhisperURL                       string
+	WhisperAPIKey                    string
+	WhisperModel                     string
+	WhisperLanguage                  string
+	NLUProviders                     []string
+	OpenAIBaseURL                    string
+	OpenAIAPIKey                     string
+	OpenAIModel                      string
+	OllamaURL                        string
+	OllamaModel                      string
 	GeminiTimeout                    time.Duration
 	MetricsNamespace                 string
+	MetricsInstance                  string
 	GeminiCooldown                   time.Duration
 	RedisAddr                        string
 	RedisPassword                    string
@@ -40,31 +66,173 @@ type Config struct {
 	AtlanticDepositMethod            string
 	AtlanticDepositFeeFixed          int64
 	AtlanticDepositFeePercent        float64
+	PaymentProvider                  string
+	MidtransServerKey                string
+	MidtransProduction               bool
+	XenditSecretKey                  string
+	XenditCallbackToken              string
+	DigiflazzUsername                string
+	DigiflazzAPIKey                  string
+	DigiflazzSKUs                    map[string]string
+	WithdrawMinAmount                int64
+	WithdrawFee                      int64
+	WithdrawDailyLimit               int64
+	WithdrawApprovalThreshold        int64
+	ApprovalTTL                      time.Duration
+	OrderSLA                         time.Duration
+	MinMargin                        int64
+	PriceCacheTTL                    time.Duration
+	TrustLevels                      []TrustLevel
+	TrustProductMinOrders            map[string]int
+	AdminJIDs                        []string
+	LowSuccessRate                   float64
+	LowSuccessMinSamples             int64
+	ShopName                         string
+	ReceiptSecret                    string
+	ReplyTone                        string
+	ReplyEmoji                       bool
+	ReplySignature                   string
+	CurrencySymbolSpace              bool
+	ReceiptDocuments                 bool
+	TypingDelayPerChar               time.Duration
+	TypingDelayMax                   time.Duration
+	CampaignPollInterval             time.Duration
+	AtlanticProbeInterval            time.Duration
+	CampaignAttributionWindow        time.Duration
+	GeminiMaxConcurrent              int
+	GeminiQueueSize                  int
+	GeminiBusyThreshold              int
+	MemorySummaryInterval            time.Duration
+	MemoryMinMessages                int
+	WhatsAppResendAfter              time.Duration
+	WhatsAppMaxResends               int
+	HTTPMaxIdleConnsPerHost          int
+	HTTPIdleConnTimeout              time.Duration
+	HTTPDialTimeout                  time.Duration
+	HTTPKeepAlive                    time.Duration
+	HTTPTLSHandshakeTimeout          time.Duration
+	HTTPShareTransport               bool
+	AtlanticProxyURL                 *url.URL
+	GeminiProxyURL                   *url.URL
+	ProxyProbeInterval               time.Duration
+	WebhookWorkers                   int
+	WebhookQueueSize                 int
+	WebhookMaxAttempts               int
+	MerchantWebhookTimeout           time.Duration
+	MerchantWebhookMaxAttempts       int
+	MerchantWebhookPollInterval      time.Duration
+	NotificationMaxAttempts          int
+	CatalogS3Endpoint                string
+	CatalogS3Region                  string
+	CatalogS3Bucket                  string
+	CatalogS3AccessKey               string
+	CatalogS3SecretKey               string
+	CatalogS3Prefix                  string
+	CatalogS3PathStyle               bool
+	CatalogExportInterval            time.Duration
+	BackupDir                        string
+	BackupAt                         time.Duration
+	SalesSummaryAt                   time.Duration
+	BackupKeep                       int
+	BackupS3Bucket                   string
+	BackupS3Prefix                   string
+	BackupPgDump                     string
+	BackupPgRestore                  string
+	BackupMySQLDump                  string
+	BackupMySQLClient                string
+	AtlanticBreakerThreshold         int
+	AtlanticBreakerCooldown          time.Duration
+	PendingPollInterval              time.Duration
+	PendingPollMinAge                time.Duration
+	PendingPollMaxAge                time.Duration
+	DepositReminderAfter             time.Duration
+	DepositExpireAfter               time.Duration
+	PLNInquiryCode                   string
+	AdminAPIKeys                     []string
+	AdminJWTSecret                   string
+	AdminJWTIssuer                   string
+	AdminJWTRole                     string
+	ChaosEnabled                     bool
+	ChaosFaults                      map[string]float64
 }
 
-// Load returns configuration populated from environment variables with fallbacks.
+// Load returns configuration populated from environment variables, then the
+// YAML file named by CONFIG_FILE, with fallbacks. It can be called again
+// to pick up edits to the file.
 func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	fileValues = nil
+	path := trimmedEnv("CONFIG_FILE")
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fileValues = values
+	}
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConfigFile = path
+	return cfg, nil
+}
+
+func load() (*Config, error) {
 	cfg := &Config{
 		AppEnv:                           getenvDefault("APP_ENV", "development"),
 		LogLevel:                         getenvDefault("LOG_LEVEL", "info"),
+		LogFormat:                        strings.ToLower(getenvDefault("LOG_FORMAT", "text")),
 		HTTPListenAddr:                   getenvDefault("HTTP_LISTEN_ADDR", ":8080"),
 		DatabaseURL:                      trimmedEnv("DATABASE_URL"),
 		SupabaseSchema:                   getenvDefault("SUPABASE_SCHEMA", "public"),
 		WhatsAppStorePath:                getenvDefault("WHATSAPP_STORE_PATH", "data/wa-store.db"),
 		WhatsAppDeviceJID:                trimmedEnv("WHATSAPP_DEVICE_JID"),
 		WhatsAppLogLevel:                 getenvDefault("WHATSAPP_LOG_LEVEL", "INFO"),
+		WhatsAppPairPhone:                strings.TrimPrefix(trimmedEnv("WHATSAPP_PAIR_PHONE"), "+"),
+		AdminAlertWebhookURL:             trimmedEnv("ADMIN_ALERT_WEBHOOK_URL"),
 		AtlanticAPIKey:                   trimmedEnv("ATL_API_KEY"),
 		AtlanticBaseURL:                  getenvDefault("ATL_BASE_URL", "https://atlantich2h.com"),
 		AtlanticWebhookSecretMD5Username: trimmedEnv("ATL_WEBHOOK_SECRET_MD5_USERNAME"),
 		AtlanticWebhookSecretMD5Password: trimmedEnv("ATL_WEBHOOK_SECRET_MD5_PASSWORD"),
+		AtlanticWebhookHMACSecrets:       splitAndTrim(trimmedEnv("ATL_WEBHOOK_HMAC_SECRETS")),
 		GeminiAPIKeys:                    splitAndTrim(trimmedEnv("GEMINI_KEYS")),
 		GeminiModel:                      getenvDefault("GEMINI_MODEL_FLASH_LITE", "gemini-2.5-flash-lite"),
+		WhisperURL:                       trimmedEnv("WHISPER_URL"),
+		WhisperAPIKey:                    trimmedEnv("WHISPER_API_KEY"),
+		WhisperModel:                     getenvDefault("WHISPER_MODEL", "whisper-1"),
+		WhisperLanguage:                  getenvDefault("WHISPER_LANGUAGE", "id"),
+		NLUProviders:                     splitAndTrim(strings.ToLower(getenvDefault("NLU_PROVIDERS", "gemini"))),
+		OpenAIBaseURL:                    strings.TrimRight(getenvDefault("OPENAI_BASE_URL", "https://api.openai.com/v1"), "/"),
+		OpenAIAPIKey:                     trimmedEnv("OPENAI_API_KEY"),
+		OpenAIModel:                      getenvDefault("OPENAI_MODEL", "gpt-4o-mini"),
+		OllamaURL:                        strings.TrimRight(getenvDefault("OLLAMA_URL", "http://localhost:11434"), "/"),
+		OllamaModel:                      getenvDefault("OLLAMA_MODEL", "llama3.1"),
 		MetricsNamespace:                 getenvDefault("METRICS_NAMESPACE", "bot_jual"),
+		MetricsInstance:                  trimmedEnv("METRICS_INSTANCE"),
 		RedisAddr:                        getenvDefault("REDIS_ADDR", "localhost:6379"),
 		RedisPassword:                    trimmedEnv("REDIS_PASSWORD"),
 		PublicBaseURL:                    getenvDefault("PUBLIC_BASE_URL", ""),
 		AtlanticDepositType:              getenvDefault("ATL_DEPOSIT_TYPE", "ewallet"),
 		AtlanticDepositMethod:            getenvDefault("ATL_DEPOSIT_METHOD", "qris"),
+		PaymentProvider:                  strings.ToLower(getenvDefault("PAYMENT_PROVIDER", "atlantic")),
+		MidtransServerKey:                trimmedEnv("MIDTRANS_SERVER_KEY"),
+		XenditSecretKey:                  trimmedEnv("XENDIT_SECRET_KEY"),
+		XenditCallbackToken:              trimmedEnv("XENDIT_CALLBACK_TOKEN"),
+		DigiflazzUsername:                trimmedEnv("DIGIFLAZZ_USERNAME"),
+		DigiflazzAPIKey:                  trimmedEnv("DIGIFLAZZ_API_KEY"),
+		AdminJIDs:                        splitAndTrim(trimmedEnv("ADMIN_JIDS")),
+		ShopName:                         trimmedEnv("SHOP_NAME"),
+		PLNInquiryCode:                   trimmedEnv("PLN_INQUIRY_CODE"),
+		ReceiptSecret:                    trimmedEnv("RECEIPT_SECRET"),
+		ReplyTone:                        strings.ToLower(getenvDefault("REPLY_TONE", "casual")),
+		ReplySignature:                   trimmedEnv("REPLY_SIGNATURE"),
+		AdminAPIKeys:                     splitAndTrim(trimmedEnv("ADMIN_API_KEYS")),
+		AdminJWTSecret:                   trimmedEnv("ADMIN_JWT_SECRET"),
+		AdminJWTIssuer:                   trimmedEnv("ADMIN_JWT_ISSUER"),
+		AdminJWTRole:                     getenvDefault("ADMIN_JWT_ROLE", "admin"),
 	}
 
 	cooldown := getenvDefault("GEMINI_COOLDOWN", "24h")
@@ -79,11 +247,54 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid ATL_TIMEOUT duration: %w", err)
 	}
 
+	if thresholdStr := getenvDefault("ATL_BREAKER_THRESHOLD", "5"); thresholdStr != "" {
+		threshold, convErr := strconv.Atoi(strings.TrimSpace(thresholdStr))
+		if convErr != nil || threshold < 1 {
+			return nil, fmt.Errorf("invalid ATL_BREAKER_THRESHOLD value %q: must be a positive integer", thresholdStr)
+		}
+		cfg.AtlanticBreakerThreshold = threshold
+	}
+
+	breakerCooldownStr := getenvDefault("ATL_BREAKER_COOLDOWN", "30s")
+	if cfg.AtlanticBreakerCooldown, err = time.ParseDuration(breakerCooldownStr); err != nil {
+		return nil, fmt.Errorf("invalid ATL_BREAKER_COOLDOWN duration: %w", err)
+	}
+
+	replayWindowStr := getenvDefault("ATL_WEBHOOK_REPLAY_WINDOW", "10m")
+	if cfg.AtlanticWebhookReplayWindow, err = time.ParseDuration(replayWindowStr); err != nil {
+		return nil, fmt.Errorf("invalid ATL_WEBHOOK_REPLAY_WINDOW duration: %w", err)
+	}
+
+	probeIntervalStr := getenvDefault("ATL_PROBE_INTERVAL", "1m")
+	if cfg.AtlanticProbeInterval, err = time.ParseDuration(probeIntervalStr); err != nil {
+		return nil, fmt.Errorf("invalid ATL_PROBE_INTERVAL duration: %w", err)
+	}
+
 	geminiTimeoutStr := getenvDefault("GEMINI_TIMEOUT", "20s")
 	if cfg.GeminiTimeout, err = time.ParseDuration(geminiTimeoutStr); err != nil {
 		return nil, fmt.Errorf("invalid GEMINI_TIMEOUT duration: %w", err)
 	}
 
+	typingPerCharStr := getenvDefault("TYPING_DELAY_PER_CHAR", "0s")
+	if cfg.TypingDelayPerChar, err = time.ParseDuration(typingPerCharStr); err != nil {
+		return nil, fmt.Errorf("invalid TYPING_DELAY_PER_CHAR duration: %w", err)
+	}
+
+	typingMaxStr := getenvDefault("TYPING_DELAY_MAX", "4s")
+	if cfg.TypingDelayMax, err = time.ParseDuration(typingMaxStr); err != nil {
+		return nil, fmt.Errorf("invalid TYPING_DELAY_MAX duration: %w", err)
+	}
+
+	campaignPollStr := getenvDefault("CAMPAIGN_POLL_INTERVAL", "1m")
+	if cfg.CampaignPollInterval, err = time.ParseDuration(campaignPollStr); err != nil {
+		return nil, fmt.Errorf("invalid CAMPAIGN_POLL_INTERVAL duration: %w", err)
+	}
+
+	attributionStr := getenvDefault("CAMPAIGN_ATTRIBUTION_WINDOW", "72h")
+	if cfg.CampaignAttributionWindow, err = time.ParseDuration(attributionStr); err != nil {
+		return nil, fmt.Errorf("invalid CAMPAIGN_ATTRIBUTION_WINDOW duration: %w", err)
+	}
+
 	if fixedStr := getenvDefault("ATL_DEPOSIT_FEE_FIXED", "0"); fixedStr != "" {
 		fixedVal, convErr := strconv.ParseInt(strings.TrimSpace(fixedStr), 10, 64)
 		if convErr != nil {
@@ -110,6 +321,339 @@ func Load() (*Config, error) {
 		cfg.AtlanticDepositFeePercent = percentVal
 	}
 
+	if minStr := getenvDefault("WITHDRAW_MIN_AMOUNT", "10000"); minStr != "" {
+		minVal, convErr := strconv.ParseInt(strings.TrimSpace(minStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid WITHDRAW_MIN_AMOUNT value: %w", convErr)
+		}
+		if minVal < 0 {
+			minVal = 0
+		}
+		cfg.WithdrawMinAmount = minVal
+	}
+
+	if feeStr := getenvDefault("WITHDRAW_FEE", "2500"); feeStr != "" {
+		feeVal, convErr := strconv.ParseInt(strings.TrimSpace(feeStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid WITHDRAW_FEE value: %w", convErr)
+		}
+		if feeVal < 0 {
+			feeVal = 0
+		}
+		cfg.WithdrawFee = feeVal
+	}
+
+	if limitStr := getenvDefault("WITHDRAW_DAILY_LIMIT", "2000000"); limitStr != "" {
+		limitVal, convErr := strconv.ParseInt(strings.TrimSpace(limitStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid WITHDRAW_DAILY_LIMIT value: %w", convErr)
+		}
+		if limitVal < 0 {
+			limitVal = 0
+		}
+		cfg.WithdrawDailyLimit = limitVal
+	}
+
+	if thresholdStr := getenvDefault("WITHDRAW_APPROVAL_THRESHOLD", "0"); thresholdStr != "" {
+		thresholdVal, convErr := strconv.ParseInt(strings.TrimSpace(thresholdStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid WITHDRAW_APPROVAL_THRESHOLD value: %w", convErr)
+		}
+		if thresholdVal < 0 {
+			thresholdVal = 0
+		}
+		cfg.WithdrawApprovalThreshold = thresholdVal
+	}
+
+	approvalTTLStr := getenvDefault("APPROVAL_TTL", "30m")
+	if cfg.ApprovalTTL, err = time.ParseDuration(approvalTTLStr); err != nil {
+		return nil, fmt.Errorf("invalid APPROVAL_TTL duration: %w", err)
+	}
+
+	orderSLAStr := getenvDefault("ORDER_SLA", "15m")
+	if cfg.OrderSLA, err = time.ParseDuration(orderSLAStr); err != nil {
+		return nil, fmt.Errorf("invalid ORDER_SLA duration: %w", err)
+	}
+
+	pollIntervalStr := getenvDefault("PENDING_POLL_INTERVAL", "2m")
+	if cfg.PendingPollInterval, err = time.ParseDuration(pollIntervalStr); err != nil {
+		return nil, fmt.Errorf("invalid PENDING_POLL_INTERVAL duration: %w", err)
+	}
+
+	pollMinAgeStr := getenvDefault("PENDING_POLL_MIN_AGE", "2m")
+	if cfg.PendingPollMinAge, err = time.ParseDuration(pollMinAgeStr); err != nil {
+		return nil, fmt.Errorf("invalid PENDING_POLL_MIN_AGE duration: %w", err)
+	}
+
+	pollMaxAgeStr := getenvDefault("PENDING_POLL_MAX_AGE", "24h")
+	if cfg.PendingPollMaxAge, err = time.ParseDuration(pollMaxAgeStr); err != nil {
+		return nil, fmt.Errorf("invalid PENDING_POLL_MAX_AGE duration: %w", err)
+	}
+
+	depositReminderStr := getenvDefault("DEPOSIT_REMINDER_AFTER", "15m")
+	if cfg.DepositReminderAfter, err = time.ParseDuration(depositReminderStr); err != nil {
+		return nil, fmt.Errorf("invalid DEPOSIT_REMINDER_AFTER duration: %w", err)
+	}
+
+	depositExpireStr := getenvDefault("DEPOSIT_EXPIRE_AFTER", "1h")
+	if cfg.DepositExpireAfter, err = time.ParseDuration(depositExpireStr); err != nil {
+		return nil, fmt.Errorf("invalid DEPOSIT_EXPIRE_AFTER duration: %w", err)
+	}
+
+	priceTTLStr := getenvDefault("PRICE_CACHE_TTL", "5m")
+	if cfg.PriceCacheTTL, err = time.ParseDuration(priceTTLStr); err != nil {
+		return nil, fmt.Errorf("invalid PRICE_CACHE_TTL duration: %w", err)
+	}
+
+	if marginStr := getenvDefault("MIN_MARGIN", "0"); marginStr != "" {
+		marginVal, convErr := strconv.ParseInt(strings.TrimSpace(marginStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid MIN_MARGIN value: %w", convErr)
+		}
+		cfg.MinMargin = marginVal
+	}
+
+	// Order caps by trust, e.g. "0=100000,3=500000,10=0": a user with at
+	// least 3 successful orders may order up to 500000, from 10 without cap.
+	for _, pair := range splitAndTrim(trimmedEnv("TRUST_LEVELS")) {
+		ordersStr, amountStr, ok := strings.Cut(pair, "=")
+		orders, ordersErr := strconv.Atoi(strings.TrimSpace(ordersStr))
+		amount, amountErr := strconv.ParseInt(strings.TrimSpace(amountStr), 10, 64)
+		if !ok || ordersErr != nil || amountErr != nil || orders < 0 || amount < 0 {
+			return nil, fmt.Errorf("invalid TRUST_LEVELS entry %q: must be successful_orders=max_amount", pair)
+		}
+		cfg.TrustLevels = append(cfg.TrustLevels, TrustLevel{MinOrders: orders, MaxAmount: amount})
+	}
+	// Products held back from new users, e.g. "ewallet=3,GOPAY100=5", keyed
+	// by product type or code.
+	for _, pair := range splitAndTrim(trimmedEnv("TRUST_PRODUCT_MIN_ORDERS")) {
+		product, ordersStr, ok := strings.Cut(pair, "=")
+		product = strings.ToLower(strings.TrimSpace(product))
+		orders, convErr := strconv.Atoi(strings.TrimSpace(ordersStr))
+		if !ok || product == "" || convErr != nil || orders < 0 {
+			return nil, fmt.Errorf("invalid TRUST_PRODUCT_MIN_ORDERS entry %q: must be product=successful_orders", pair)
+		}
+		if cfg.TrustProductMinOrders == nil {
+			cfg.TrustProductMinOrders = map[string]int{}
+		}
+		cfg.TrustProductMinOrders[product] = orders
+	}
+
+	if rateStr := getenvDefault("PRODUCT_LOW_SUCCESS_RATE", "0.7"); rateStr != "" {
+		rateVal, convErr := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid PRODUCT_LOW_SUCCESS_RATE value: %w", convErr)
+		}
+		// Accept both 0.7 and 70 for seventy percent.
+		if rateVal > 1 {
+			rateVal = rateVal / 100
+		}
+		if rateVal < 0 {
+			rateVal = 0
+		}
+		cfg.LowSuccessRate = rateVal
+	}
+
+	if samplesStr := getenvDefault("PRODUCT_LOW_SUCCESS_MIN_SAMPLES", "5"); samplesStr != "" {
+		samplesVal, convErr := strconv.ParseInt(strings.TrimSpace(samplesStr), 10, 64)
+		if convErr != nil {
+			return nil, fmt.Errorf("invalid PRODUCT_LOW_SUCCESS_MIN_SAMPLES value: %w", convErr)
+		}
+		if samplesVal < 1 {
+			samplesVal = 1
+		}
+		cfg.LowSuccessMinSamples = samplesVal
+	}
+
+	if concurrentStr := getenvDefault("GEMINI_MAX_CONCURRENT", "4"); concurrentStr != "" {
+		concurrent, convErr := strconv.Atoi(strings.TrimSpace(concurrentStr))
+		if convErr != nil || concurrent < 1 {
+			return nil, fmt.Errorf("invalid GEMINI_MAX_CONCURRENT value %q: must be a positive integer", concurrentStr)
+		}
+		cfg.GeminiMaxConcurrent = concurrent
+	}
+
+	if queueStr := getenvDefault("GEMINI_QUEUE_SIZE", "32"); queueStr != "" {
+		queueSize, convErr := strconv.Atoi(strings.TrimSpace(queueStr))
+		if convErr != nil || queueSize < 1 {
+			return nil, fmt.Errorf("invalid GEMINI_QUEUE_SIZE value %q: must be a positive integer", queueStr)
+		}
+		cfg.GeminiQueueSize = queueSize
+	}
+
+	if busyStr := getenvDefault("GEMINI_BUSY_THRESHOLD", "8"); busyStr != "" {
+		busy, convErr := strconv.Atoi(strings.TrimSpace(busyStr))
+		if convErr != nil || busy < 0 {
+			return nil, fmt.Errorf("invalid GEMINI_BUSY_THRESHOLD value %q: must be zero or a positive integer", busyStr)
+		}
+		cfg.GeminiBusyThreshold = busy
+	}
+
+	resendAfterStr := getenvDefault("WA_RESEND_AFTER", "10m")
+	if cfg.WhatsAppResendAfter, err = time.ParseDuration(resendAfterStr); err != nil {
+		return nil, fmt.Errorf("invalid WA_RESEND_AFTER duration: %w", err)
+	}
+
+	if maxResendsStr := getenvDefault("WA_MAX_RESENDS", "1"); maxResendsStr != "" {
+		maxResends, convErr := strconv.Atoi(strings.TrimSpace(maxResendsStr))
+		if convErr != nil || maxResends < 0 {
+			return nil, fmt.Errorf("invalid WA_MAX_RESENDS value %q: must be a non-negative integer", maxResendsStr)
+		}
+		cfg.WhatsAppMaxResends = maxResends
+	}
+
+	memoryIntervalStr := getenvDefault("MEMORY_SUMMARY_INTERVAL", "30m")
+	if cfg.MemorySummaryInterval, err = time.ParseDuration(memoryIntervalStr); err != nil {
+		return nil, fmt.Errorf("invalid MEMORY_SUMMARY_INTERVAL duration: %w", err)
+	}
+
+	if memoryMinStr := getenvDefault("MEMORY_MIN_MESSAGES", "20"); memoryMinStr != "" {
+		minMessages, convErr := strconv.Atoi(strings.TrimSpace(memoryMinStr))
+		if convErr != nil || minMessages < 1 {
+			return nil, fmt.Errorf("invalid MEMORY_MIN_MESSAGES value %q: must be a positive integer", memoryMinStr)
+		}
+		cfg.MemoryMinMessages = minMessages
+	}
+
+	if idleStr := getenvDefault("HTTP_MAX_IDLE_CONNS_PER_HOST", "16"); idleStr != "" {
+		idle, convErr := strconv.Atoi(strings.TrimSpace(idleStr))
+		if convErr != nil || idle < 1 {
+			return nil, fmt.Errorf("invalid HTTP_MAX_IDLE_CONNS_PER_HOST value %q: must be a positive integer", idleStr)
+		}
+		cfg.HTTPMaxIdleConnsPerHost = idle
+	}
+
+	idleTimeoutStr := getenvDefault("HTTP_IDLE_CONN_TIMEOUT", "90s")
+	if cfg.HTTPIdleConnTimeout, err = time.ParseDuration(idleTimeoutStr); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_IDLE_CONN_TIMEOUT duration: %w", err)
+	}
+
+	dialTimeoutStr := getenvDefault("HTTP_DIAL_TIMEOUT", "5s")
+	if cfg.HTTPDialTimeout, err = time.ParseDuration(dialTimeoutStr); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_DIAL_TIMEOUT duration: %w", err)
+	}
+
+	keepAliveStr := getenvDefault("HTTP_KEEP_ALIVE", "30s")
+	if cfg.HTTPKeepAlive, err = time.ParseDuration(keepAliveStr); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_KEEP_ALIVE duration: %w", err)
+	}
+
+	tlsTimeoutStr := getenvDefault("HTTP_TLS_HANDSHAKE_TIMEOUT", "10s")
+	if cfg.HTTPTLSHandshakeTimeout, err = time.ParseDuration(tlsTimeoutStr); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_TLS_HANDSHAKE_TIMEOUT duration: %w", err)
+	}
+
+	cfg.HTTPShareTransport = strings.EqualFold(getenvDefault("HTTP_SHARE_TRANSPORT", "true"), "true")
+
+	if cfg.AtlanticProxyURL, err = httpx.ParseProxyURL(trimmedEnv("ATL_PROXY_URL")); err != nil {
+		return nil, fmt.Errorf("invalid ATL_PROXY_URL: %w", err)
+	}
+	if cfg.GeminiProxyURL, err = httpx.ParseProxyURL(trimmedEnv("GEMINI_PROXY_URL")); err != nil {
+		return nil, fmt.Errorf("invalid GEMINI_PROXY_URL: %w", err)
+	}
+
+	proxyProbeStr := getenvDefault("PROXY_PROBE_INTERVAL", "1m")
+	if cfg.ProxyProbeInterval, err = time.ParseDuration(proxyProbeStr); err != nil {
+		return nil, fmt.Errorf("invalid PROXY_PROBE_INTERVAL duration: %w", err)
+	}
+
+	if workersStr := getenvDefault("WEBHOOK_WORKERS", "4"); workersStr != "" {
+		workers, convErr := strconv.Atoi(strings.TrimSpace(workersStr))
+		if convErr != nil || workers < 1 {
+			return nil, fmt.Errorf("invalid WEBHOOK_WORKERS value %q: must be a positive integer", workersStr)
+		}
+		cfg.WebhookWorkers = workers
+	}
+
+	if webhookQueueStr := getenvDefault("WEBHOOK_QUEUE_SIZE", "256"); webhookQueueStr != "" {
+		queueSize, convErr := strconv.Atoi(strings.TrimSpace(webhookQueueStr))
+		if convErr != nil || queueSize < 1 {
+			return nil, fmt.Errorf("invalid WEBHOOK_QUEUE_SIZE value %q: must be a positive integer", webhookQueueStr)
+		}
+		cfg.WebhookQueueSize = queueSize
+	}
+
+	if attemptsStr := getenvDefault("WEBHOOK_MAX_ATTEMPTS", "3"); attemptsStr != "" {
+		attempts, convErr := strconv.Atoi(strings.TrimSpace(attemptsStr))
+		if convErr != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS value %q: must be a positive integer", attemptsStr)
+		}
+		cfg.WebhookMaxAttempts = attempts
+	}
+
+	merchantTimeoutStr := getenvDefault("MERCHANT_WEBHOOK_TIMEOUT", "10s")
+	if cfg.MerchantWebhookTimeout, err = time.ParseDuration(merchantTimeoutStr); err != nil {
+		return nil, fmt.Errorf("invalid MERCHANT_WEBHOOK_TIMEOUT duration: %w", err)
+	}
+	if attemptsStr := getenvDefault("MERCHANT_WEBHOOK_MAX_ATTEMPTS", "6"); attemptsStr != "" {
+		attempts, convErr := strconv.Atoi(strings.TrimSpace(attemptsStr))
+		if convErr != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid MERCHANT_WEBHOOK_MAX_ATTEMPTS value %q: must be a positive integer", attemptsStr)
+		}
+		cfg.MerchantWebhookMaxAttempts = attempts
+	}
+	if attemptsStr := getenvDefault("NOTIFICATION_MAX_ATTEMPTS", "10"); attemptsStr != "" {
+		attempts, convErr := strconv.Atoi(strings.TrimSpace(attemptsStr))
+		if convErr != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid NOTIFICATION_MAX_ATTEMPTS value %q: must be a positive integer", attemptsStr)
+		}
+		cfg.NotificationMaxAttempts = attempts
+	}
+	merchantPollStr := getenvDefault("MERCHANT_WEBHOOK_POLL_INTERVAL", "15s")
+	if cfg.MerchantWebhookPollInterval, err = time.ParseDuration(merchantPollStr); err != nil {
+		return nil, fmt.Errorf("invalid MERCHANT_WEBHOOK_POLL_INTERVAL duration: %w", err)
+	}
+
+	cfg.CatalogS3Endpoint = trimmedEnv("CATALOG_S3_ENDPOINT")
+	cfg.CatalogS3Region = getenvDefault("CATALOG_S3_REGION", "us-east-1")
+	cfg.CatalogS3Bucket = trimmedEnv("CATALOG_S3_BUCKET")
+	cfg.CatalogS3AccessKey = trimmedEnv("CATALOG_S3_ACCESS_KEY")
+	cfg.CatalogS3SecretKey = trimmedEnv("CATALOG_S3_SECRET_KEY")
+	cfg.CatalogS3Prefix = getenvDefault("CATALOG_S3_PREFIX", "catalog")
+	cfg.CatalogS3PathStyle = strings.EqualFold(getenvDefault("CATALOG_S3_PATH_STYLE", "true"), "true")
+
+	exportIntervalStr := getenvDefault("CATALOG_EXPORT_INTERVAL", "6h")
+	if cfg.CatalogExportInterval, err = time.ParseDuration(exportIntervalStr); err != nil {
+		return nil, fmt.Errorf("invalid CATALOG_EXPORT_INTERVAL duration: %w", err)
+	}
+
+	cfg.BackupDir = getenvDefault("BACKUP_DIR", "data/backups")
+	cfg.BackupS3Bucket = trimmedEnv("BACKUP_S3_BUCKET")
+	cfg.BackupS3Prefix = getenvDefault("BACKUP_S3_PREFIX", "backups")
+	cfg.BackupPgDump = getenvDefault("BACKUP_PG_DUMP", "pg_dump")
+	cfg.BackupPgRestore = getenvDefault("BACKUP_PG_RESTORE", "pg_restore")
+	cfg.BackupMySQLDump = getenvDefault("BACKUP_MYSQLDUMP", "mysqldump")
+	cfg.BackupMySQLClient = getenvDefault("BACKUP_MYSQL", "mysql")
+	// BACKUP_TIME is the WIB time of day of the nightly backup; BackupAt
+	// holds it as an offset from midnight, negative when off.
+	if backupTime := strings.ToLower(getenvDefault("BACKUP_TIME", "03:00")); backupTime == "off" {
+		cfg.BackupAt = -1
+	} else {
+		at, parseErr := time.Parse("15:04", backupTime)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid BACKUP_TIME %q: must be HH:MM or off", backupTime)
+		}
+		cfg.BackupAt = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+	// SALES_SUMMARY_TIME is the WIB time of day the sales summary reaches
+	// the admins, held like BackupAt.
+	if summaryTime := strings.ToLower(getenvDefault("SALES_SUMMARY_TIME", "22:00")); summaryTime == "off" {
+		cfg.SalesSummaryAt = -1
+	} else {
+		at, parseErr := time.Parse("15:04", summaryTime)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid SALES_SUMMARY_TIME %q: must be HH:MM or off", summaryTime)
+		}
+		cfg.SalesSummaryAt = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+	if keepStr := getenvDefault("BACKUP_KEEP", "7"); keepStr != "" {
+		keep, convErr := strconv.Atoi(strings.TrimSpace(keepStr))
+		if convErr != nil || keep < 1 {
+			return nil, fmt.Errorf("invalid BACKUP_KEEP value %q: must be a positive integer", keepStr)
+		}
+		cfg.BackupKeep = keep
+	}
+
 	if redisDBStr := getenvDefault("REDIS_DB", "0"); redisDBStr != "" {
 		db, convErr := strconv.Atoi(redisDBStr)
 		if convErr != nil {
@@ -119,6 +663,62 @@ func Load() (*Config, error) {
 	}
 
 	cfg.RedisTLS = strings.EqualFold(getenvDefault("REDIS_TLS", "false"), "true")
+	cfg.ReplyEmoji = strings.EqualFold(getenvDefault("REPLY_EMOJI", "true"), "true")
+	cfg.CurrencySymbolSpace = strings.EqualFold(getenvDefault("CURRENCY_SYMBOL_SPACE", "false"), "true")
+	// Lists and buttons often do not render for regular (non-Business API)
+	// accounts, so they stay opt-in.
+	cfg.WhatsAppInteractive = strings.EqualFold(getenvDefault("WHATSAPP_INTERACTIVE", "false"), "true")
+	cfg.ReceiptDocuments = strings.EqualFold(getenvDefault("RECEIPT_DOCUMENTS", "true"), "true")
+	cfg.MidtransProduction = strings.EqualFold(getenvDefault("MIDTRANS_PRODUCTION", "false"), "true")
+
+	if cfg.MetricsInstance == "" {
+		cfg.MetricsInstance, _ = os.Hostname()
+	}
+
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q: must be text or json", cfg.LogFormat)
+	}
+	// Per-component levels, e.g. "convo=debug,atlantic_webhook=warn".
+	for _, pair := range splitAndTrim(trimmedEnv("LOG_LEVELS")) {
+		component, level, ok := strings.Cut(pair, "=")
+		component, level = strings.TrimSpace(component), strings.ToLower(strings.TrimSpace(level))
+		if !ok || component == "" || !validLogLevel(level) {
+			return nil, fmt.Errorf("invalid LOG_LEVELS entry %q: must be component=debug|info|warn|error", pair)
+		}
+		if cfg.LogComponentLevels == nil {
+			cfg.LogComponentLevels = map[string]string{}
+		}
+		cfg.LogComponentLevels[component] = level
+	}
+	// Recent entries kept in memory for /admin/logs; 0 turns the buffer off.
+	if ringStr := getenvDefault("LOG_RING_SIZE", "1000"); ringStr != "" {
+		size, convErr := strconv.Atoi(ringStr)
+		if convErr != nil || size < 0 {
+			return nil, fmt.Errorf("invalid LOG_RING_SIZE value %q: must be a non-negative integer", ringStr)
+		}
+		cfg.LogRingSize = size
+	}
+	// Failure injection for staging drills; faults start from CHAOS_FAULTS,
+	// e.g. "atlantic=0.5,gemini=1", and can be changed at runtime.
+	cfg.ChaosEnabled = strings.EqualFold(getenvDefault("CHAOS_ENABLED", "false"), "true")
+	if cfg.ChaosEnabled && strings.EqualFold(cfg.AppEnv, "production") {
+		return nil, fmt.Errorf("CHAOS_ENABLED must not be set when APP_ENV is production")
+	}
+	for _, pair := range splitAndTrim(trimmedEnv("CHAOS_FAULTS")) {
+		fault, rateStr, ok := strings.Cut(pair, "=")
+		rate, convErr := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if !ok || convErr != nil || rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid CHAOS_FAULTS entry %q: must be fault=rate with rate in (0, 1]", pair)
+		}
+		if cfg.ChaosFaults == nil {
+			cfg.ChaosFaults = map[string]float64{}
+		}
+		cfg.ChaosFaults[strings.TrimSpace(fault)] = rate
+	}
+
+	if cfg.ReplyTone != "casual" && cfg.ReplyTone != "formal" {
+		return nil, fmt.Errorf("invalid REPLY_TONE %q: must be casual or formal", cfg.ReplyTone)
+	}
 
 	if cfg.PublicBaseURL != "" {
 		parsed, err := url.Parse(cfg.PublicBaseURL)
@@ -132,6 +732,54 @@ func Load() (*Config, error) {
 		cfg.PublicBasePath = basePath
 	}
 
+	if cfg.WhisperURL != "" {
+		parsed, err := url.Parse(cfg.WhisperURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid WHISPER_URL %q: must be an http(s) URL such as https://api.openai.com/v1/audio/transcriptions", cfg.WhisperURL)
+		}
+	}
+
+	seenProviders := make(map[string]bool, len(cfg.NLUProviders))
+	for _, name := range cfg.NLUProviders {
+		switch name {
+		case "gemini", "openai", "ollama":
+		default:
+			return nil, fmt.Errorf("invalid NLU_PROVIDERS entry %q: must be gemini, openai or ollama", name)
+		}
+		if seenProviders[name] {
+			return nil, fmt.Errorf("invalid NLU_PROVIDERS: %q listed twice", name)
+		}
+		seenProviders[name] = true
+	}
+	if seenProviders["openai"] {
+		parsed, err := url.Parse(cfg.OpenAIBaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid OPENAI_BASE_URL %q: must be an http(s) URL such as https://api.openai.com/v1", cfg.OpenAIBaseURL)
+		}
+		if parsed.Host == "api.openai.com" && cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required when NLU_PROVIDERS includes openai")
+		}
+	}
+	if seenProviders["ollama"] {
+		parsed, err := url.Parse(cfg.OllamaURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid OLLAMA_URL %q: must be an http(s) URL such as http://localhost:11434", cfg.OllamaURL)
+		}
+	}
+
+	if cfg.AdminAlertWebhookURL != "" {
+		parsed, err := url.Parse(cfg.AdminAlertWebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid ADMIN_ALERT_WEBHOOK_URL %q: must be an http(s) URL", cfg.AdminAlertWebhookURL)
+		}
+	}
+
+	if phone := cfg.WhatsAppPairPhone; phone != "" {
+		if _, convErr := strconv.ParseUint(phone, 10, 64); convErr != nil || strings.HasPrefix(phone, "0") {
+			return nil, fmt.Errorf("invalid WHATSAPP_PAIR_PHONE %q: must be an international number such as 6281234567890", phone)
+		}
+	}
+
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
@@ -141,8 +789,52 @@ func Load() (*Config, error) {
 	if cfg.AtlanticAPIKey == "" {
 		return nil, fmt.Errorf("ATL_API_KEY is required")
 	}
-	if cfg.AtlanticWebhookSecretMD5Username == "" || cfg.AtlanticWebhookSecretMD5Password == "" {
-		return nil, fmt.Errorf("ATL_WEBHOOK_SECRET_MD5_USERNAME and ATL_WEBHOOK_SECRET_MD5_PASSWORD are required")
+	switch cfg.PaymentProvider {
+	case "atlantic":
+	case "midtrans":
+		if cfg.MidtransServerKey == "" {
+			return nil, fmt.Errorf("MIDTRANS_SERVER_KEY is required when PAYMENT_PROVIDER is midtrans")
+		}
+	case "xendit":
+		if cfg.XenditSecretKey == "" || cfg.XenditCallbackToken == "" {
+			return nil, fmt.Errorf("XENDIT_SECRET_KEY and XENDIT_CALLBACK_TOKEN are required when PAYMENT_PROVIDER is xendit")
+		}
+	default:
+		return nil, fmt.Errorf("invalid PAYMENT_PROVIDER %q: must be atlantic, midtrans or xendit", cfg.PaymentProvider)
+	}
+	if (cfg.DigiflazzUsername == "") != (cfg.DigiflazzAPIKey == "") {
+		return nil, fmt.Errorf("DIGIFLAZZ_USERNAME and DIGIFLAZZ_API_KEY must be set together")
+	}
+	// Atlantic product codes whose Digiflazz buyer SKU differs, e.g.
+	// "TSEL10=tsel10k,XL5=xl5".
+	for _, pair := range splitAndTrim(trimmedEnv("DIGIFLAZZ_SKU_MAP")) {
+		code, sku, ok := strings.Cut(pair, "=")
+		code, sku = strings.TrimSpace(code), strings.TrimSpace(sku)
+		if !ok || code == "" || sku == "" {
+			return nil, fmt.Errorf("invalid DIGIFLAZZ_SKU_MAP entry %q: must be product_code=sku", pair)
+		}
+		if cfg.DigiflazzSKUs == nil {
+			cfg.DigiflazzSKUs = map[string]string{}
+		}
+		cfg.DigiflazzSKUs[code] = sku
+	}
+	// Old credential pairs stay valid next to the current one while a
+	// rotation is rolled out on Atlantic's side.
+	for _, pair := range splitAndTrim(trimmedEnv("ATL_WEBHOOK_OLD_CREDENTIALS")) {
+		username, password, ok := strings.Cut(pair, ":")
+		username, password = strings.TrimSpace(username), strings.TrimSpace(password)
+		if !ok || username == "" || password == "" {
+			return nil, fmt.Errorf("invalid ATL_WEBHOOK_OLD_CREDENTIALS entry %q: must be username_md5:password_md5", pair)
+		}
+		cfg.AtlanticWebhookOldCredentials = append(cfg.AtlanticWebhookOldCredentials, WebhookCredential{UsernameMD5: username, PasswordMD5: password})
+	}
+	if (cfg.AtlanticWebhookSecretMD5Username == "" || cfg.AtlanticWebhookSecretMD5Password == "") && len(cfg.AtlanticWebhookHMACSecrets) == 0 {
+		return nil, fmt.Errorf("ATL_WEBHOOK_SECRET_MD5_USERNAME and ATL_WEBHOOK_SECRET_MD5_PASSWORD are required unless ATL_WEBHOOK_HMAC_SECRETS is set")
+	}
+
+	// HS256 keys shorter than the hash output are brute-forceable offline.
+	if cfg.AdminJWTSecret != "" && len(cfg.AdminJWTSecret) < 32 {
+		return nil, fmt.Errorf("ADMIN_JWT_SECRET must be at least 32 characters")
 	}
 
 	cfg.AtlanticBaseURL = strings.TrimRight(cfg.AtlanticBaseURL, "/")
@@ -151,12 +843,15 @@ func Load() (*Config, error) {
 	if strings.HasPrefix(cfg.DatabaseURL, "file:") || strings.HasSuffix(cfg.DatabaseURL, ".db") {
 		cfg.IsSQLite = true
 	}
+	if lower := strings.ToLower(cfg.DatabaseURL); strings.HasPrefix(lower, "mysql://") || strings.HasPrefix(lower, "mariadb://") {
+		cfg.IsMySQL = true
+	}
 
 	return cfg, nil
 }
 
 func getenvDefault(key, fallback string) string {
-	if val, ok := os.LookupEnv(key); ok {
+	if val, ok := lookupEnv(key); ok {
 		if trimmed := strings.TrimSpace(val); trimmed != "" {
 			return trimmed
 		}
@@ -164,6 +859,20 @@ func getenvDefault(key, fallback string) string {
 	return fallback
 }
 
+// WebhookCredential is an MD5-hashed username/password pair accepted on
+// Atlantic callbacks.
+type WebhookCredential struct {
+	UsernameMD5 string
+	PasswordMD5 string
+}
+
+// TrustLevel caps the amount of a single order for users with at least
+// MinOrders successful orders; a zero MaxAmount means no cap.
+type TrustLevel struct {
+	MinOrders int
+	MaxAmount int64
+}
+
 func splitAndTrim(val string) []string {
 	if val == "" {
 		return nil
@@ -179,8 +888,16 @@ func splitAndTrim(val string) []string {
 }
 
 func trimmedEnv(key string) string {
-	if val, ok := os.LookupEnv(key); ok {
+	if val, ok := lookupEnv(key); ok {
 		return strings.TrimSpace(val)
 	}
 	return ""
 }
+
+func validLogLevel(level string) bool {
+	switch level {
+	case "debug", "info", "warn", "warning", "error":
+		return true
+	}
+	return false
+}
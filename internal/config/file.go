@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// loadMu serialises Load, as a reload runs next to the live process;
+// fileValues holds the settings read from CONFIG_FILE during it.
+var (
+	loadMu     sync.Mutex
+	fileValues map[string]string
+)
+
+// readConfigFile parses a YAML file of settings keyed by their environment
+// variable names, e.g.
+//
+//	ATL_DEPOSIT_FEE_FIXED: 500
+//	GEMINI_COOLDOWN: 12h
+//	ADMIN_JIDS: [628111, 628222]
+//
+// Keys are case-insensitive and lists are joined with commas, so every value
+// parses exactly like its environment variable.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	values := make(map[string]string, len(raw))
+	for key, val := range raw {
+		name := strings.ToUpper(strings.TrimSpace(key))
+		switch v := val.(type) {
+		case nil:
+			continue
+		case []any:
+			parts := make([]string, 0, len(v))
+			for _, item := range v {
+				parts = append(parts, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(parts, ",")
+		case map[any]any:
+			return nil, fmt.Errorf("parse config file %s: %s must be a value or a list", path, key)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// lookupEnv returns key from the environment, falling back to the config
+// file. The environment wins so a deploy can still override one setting.
+func lookupEnv(key string) (string, bool) {
+	if val, ok := os.LookupEnv(key); ok && strings.TrimSpace(val) != "" {
+		return val, true
+	}
+	val, ok := fileValues[key]
+	return val, ok
+}
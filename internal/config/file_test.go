@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.yaml")
+	body := "ATL_DEPOSIT_FEE_FIXED: 500\natl_deposit_fee_percent: 0.7\nGEMINI_COOLDOWN: 12h\nADMIN_JIDS: [628111, 628222]\nREPLY_EMOJI: false\nSHOP_NAME:\n"
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("readConfigFile: %v", err)
+	}
+	want := map[string]string{
+		"ATL_DEPOSIT_FEE_FIXED":   "500",
+		"ATL_DEPOSIT_FEE_PERCENT": "0.7",
+		"GEMINI_COOLDOWN":         "12h",
+		"ADMIN_JIDS":              "628111,628222",
+		"REPLY_EMOJI":             "false",
+	}
+	if len(values) != len(want) {
+		t.Fatalf("values = %v, want %v", values, want)
+	}
+	for key, val := range want {
+		if values[key] != val {
+			t.Errorf("%s = %q, want %q", key, values[key], val)
+		}
+	}
+}
+
+func TestLookupEnvPrefersEnvironment(t *testing.T) {
+	fileValues = map[string]string{"WITHDRAW_FEE": "3000", "WITHDRAW_MIN_AMOUNT": "20000"}
+	t.Cleanup(func() { fileValues = nil })
+	t.Setenv("WITHDRAW_FEE", "1000")
+
+	if got := getenvDefault("WITHDRAW_FEE", "2500"); got != "1000" {
+		t.Errorf("WITHDRAW_FEE = %q, want the environment's 1000", got)
+	}
+	if got := getenvDefault("WITHDRAW_MIN_AMOUNT", "10000"); got != "20000" {
+		t.Errorf("WITHDRAW_MIN_AMOUNT = %q, want the file's 20000", got)
+	}
+	if got := getenvDefault("WITHDRAW_DAILY_LIMIT", "2000000"); got != "2000000" {
+		t.Errorf("WITHDRAW_DAILY_LIMIT = %q, want the default", got)
+	}
+}
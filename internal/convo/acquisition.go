@@ -0,0 +1,85 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+// Acquisition tags are written into the prefilled text of shared wa.me links,
+// e.g. "Halo kak (referral: BUDI10)" or "Halo kak (src: instagram)".
+var (
+	referralTagRegex = regexp.MustCompile(`(?i)[(\[]?\s*\b(?:kode\s+)?referral\s*:\s*([a-z0-9_-]{3,32})\s*[)\]]?`)
+	channelTagRegex  = regexp.MustCompile(`(?i)[(\[]?\s*\bsrc\s*:\s*([a-z0-9_-]{2,32})\s*[)\]]?`)
+)
+
+// extractAcquisitionTag reports the source named by a referral or channel tag
+// in text (organic when there is none) and returns text without the tags.
+func extractAcquisitionTag(text string) (repo.Acquisition, string) {
+	acq := repo.Acquisition{Source: repo.AcquisitionOrganic}
+	if m := channelTagRegex.FindStringSubmatch(text); m != nil {
+		acq = repo.Acquisition{Source: repo.AcquisitionChannel, Detail: strings.ToLower(m[1])}
+	}
+	if m := referralTagRegex.FindStringSubmatch(text); m != nil {
+		acq = repo.Acquisition{Source: repo.AcquisitionReferral, Detail: strings.ToUpper(m[1])}
+	}
+	stripped := referralTagRegex.ReplaceAllString(text, " ")
+	stripped = channelTagRegex.ReplaceAllString(stripped, " ")
+	return acq, strings.Join(strings.Fields(stripped), " ")
+}
+
+// recordAcquisition stores how a new user arrived. It must run before the
+// user's first message is logged; later calls are no-ops in the repository.
+func (e *Engine) recordAcquisition(ctx context.Context, userID string, acq repo.Acquisition) {
+	recorded, err := e.repo.RecordUserAcquisition(ctx, userID, acq)
+	if err != nil {
+		e.logger.Warn("failed recording user acquisition", "error", err, "user_id", userID)
+		return
+	}
+	if recorded {
+		e.logger.Info("user acquired", "user_id", userID, "source", acq.Source, "detail", acq.Detail)
+	}
+}
+
+// tagAcquisition marks a new order with where its buyer came from: the last
+// campaign message inside the attribution window, else the user's own source.
+func (e *Engine) tagAcquisition(ctx context.Context, userID string, meta map[string]any) {
+	acq := repo.Acquisition{Source: repo.AcquisitionUnknown}
+	if window := e.cfg.CampaignAttributionWindow; window > 0 {
+		campaignID, err := e.repo.LatestCampaignDelivery(ctx, userID, time.Now().Add(-window))
+		if err == nil {
+			acq = repo.Acquisition{Source: repo.AcquisitionCampaign, Detail: campaignID}
+		} else if !errors.Is(err, repo.ErrNotFound) {
+			e.logger.Warn("failed looking up campaign delivery", "error", err, "user_id", userID)
+		}
+	}
+	if acq.Source == repo.AcquisitionUnknown {
+		if userAcq, err := e.repo.GetUserAcquisition(ctx, userID); err != nil {
+			e.logger.Warn("failed loading user acquisition", "error", err, "user_id", userID)
+		} else if userAcq.Source != "" {
+			acq = *userAcq
+		}
+	}
+	meta["acquisition_source"] = acq.Source
+	if acq.Detail != "" {
+		meta["acquisition_detail"] = acq.Detail
+	}
+}
+
+// carryAcquisition copies the stored order's acquisition tag into meta,
+// because status updates replace the order metadata wholesale.
+func (e *Engine) carryAcquisition(ctx context.Context, orderRef string, meta map[string]any) {
+	order, err := e.repo.GetOrderByRef(ctx, orderRef)
+	if err != nil {
+		return
+	}
+	for _, key := range []string{"acquisition_source", "acquisition_detail"} {
+		if val, ok := order.Metadata[key].(string); ok && val != "" {
+			meta[key] = val
+		}
+	}
+}
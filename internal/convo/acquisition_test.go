@@ -0,0 +1,29 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+)
+
+func TestExtractAcquisitionTag(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     repo.Acquisition
+		stripped string
+	}{
+		{"halo kak mau beli pulsa", repo.Acquisition{Source: repo.AcquisitionOrganic}, "halo kak mau beli pulsa"},
+		{"Halo kak (referral: budi10)", repo.Acquisition{Source: repo.AcquisitionReferral, Detail: "BUDI10"}, "Halo kak"},
+		{"kode referral:ANI_22 mau tanya", repo.Acquisition{Source: repo.AcquisitionReferral, Detail: "ANI_22"}, "mau tanya"},
+		{"Halo kak [src:Instagram]", repo.Acquisition{Source: repo.AcquisitionChannel, Detail: "instagram"}, "Halo kak"},
+		{"halo (src: tiktok) (referral: X99)", repo.Acquisition{Source: repo.AcquisitionReferral, Detail: "X99"}, "halo"},
+		// "ref:" belongs to order annotations, not referrals.
+		{"beli pulsa ref: INV-1", repo.Acquisition{Source: repo.AcquisitionOrganic}, "beli pulsa ref: INV-1"},
+	}
+	for _, tc := range tests {
+		got, stripped := extractAcquisitionTag(tc.in)
+		if got != tc.want || stripped != tc.stripped {
+			t.Errorf("extractAcquisitionTag(%q) = %+v, %q; want %+v, %q", tc.in, got, stripped, tc.want, tc.stripped)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package convo
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Amount is a rupiah amount parsed from user text. Single values have
+// Min == Max; ranges such as "antara 20-30rb" keep both bounds.
+type Amount struct {
+	Min int64
+	Max int64
+}
+
+// IsRange reports whether the user gave two different bounds.
+func (a Amount) IsRange() bool {
+	return a.Min != a.Max
+}
+
+const amountNumber = `(\d+(?:[.,]\d+)*)`
+
+// amountUnit matches unit suffixes only as whole words so "20 kirim" or
+// "10 mobile" don't pick up a multiplier.
+const amountUnit = `(?:\s*(k|rb|ribu|jt|juta|miliar|milyar)\b)?`
+
+var (
+	amountRegex      = regexp.MustCompile(amountNumber + amountUnit)
+	amountRangeRegex = regexp.MustCompile(`(?:(antara)\s+)?` + amountNumber + amountUnit + `\s*(-|–|~|sampai|sampe|hingga|s/d|sd|dan)\s*` + amountNumber + amountUnit)
+	// budgetWordRegex marks text that talks about a price range, so bare
+	// numbers joined by a dash there are bounds rather than a phone number
+	// such as "0812-3456-7890".
+	budgetWordRegex = regexp.MustCompile(`\b(antara|budget|bujet|kisaran|range)\b`)
+	// dashedPhoneRegex matches Indonesian mobile numbers written in dashed
+	// groups, which are never amounts.
+	dashedPhoneRegex = regexp.MustCompile(`(?:\+?62-?|\b0)8\d{1,3}(?:-\d{3,5}){1,3}\b`)
+)
+
+var amountMultipliers = map[string]int64{
+	"k": 1_000, "rb": 1_000, "ribu": 1_000,
+	"jt": 1_000_000, "juta": 1_000_000,
+	"miliar": 1_000_000_000, "milyar": 1_000_000_000,
+}
+
+// parseAmount reads the first amount or amount range in text. It accepts
+// grouped digits ("20.000"), unit suffixes with decimals ("1,5jt", "2.5k"),
+// Indonesian number words ("seratus ribu") and ranges ("20-30rb",
+// "antara 20 dan 30 ribu"). A unit written on only one bound of a range
+// applies to both. Bounds without any unit only make a range next to a
+// budget word, and dashed phone numbers are skipped.
+func parseAmount(text string) (Amount, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return Amount{}, fmt.Errorf("empty amount")
+	}
+	text = normalizeNumberWords(dashedPhoneRegex.ReplaceAllString(text, " "))
+
+	if m := amountRangeRegex.FindStringSubmatch(text); m != nil && (m[4] != "dan" || m[1] != "") &&
+		(m[3] != "" || m[6] != "" || budgetWordRegex.MatchString(text)) {
+		lowUnit, highUnit := m[3], m[6]
+		high, highOK := parseAmountNumber(m[5], highUnit)
+		low, lowOK := parseAmountNumber(m[2], lowUnit)
+		if lowUnit == "" && highUnit != "" {
+			if scaled, ok := parseAmountNumber(m[2], highUnit); ok && scaled <= high {
+				low = scaled
+			}
+		}
+		if highUnit == "" && lowUnit != "" {
+			if scaled, ok := parseAmountNumber(m[5], lowUnit); ok && scaled >= low {
+				high = scaled
+			}
+		}
+		if lowOK && highOK {
+			if low > high {
+				low, high = high, low
+			}
+			return Amount{Min: low, Max: high}, nil
+		}
+	}
+
+	m := amountRegex.FindStringSubmatch(text)
+	if m == nil {
+		return Amount{}, fmt.Errorf("no numeric value")
+	}
+	val, ok := parseAmountNumber(m[1], m[2])
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", m[0])
+	}
+	return Amount{Min: val, Max: val}, nil
+}
+
+// parseExactAmount is parseAmount for places that need one figure, such as
+// deposits and withdrawals, where a range is as unclear as no amount at all.
+func parseExactAmount(text string) (int64, error) {
+	amount, err := parseAmount(text)
+	if err != nil {
+		return 0, err
+	}
+	if amount.IsRange() {
+		return 0, fmt.Errorf("amount %d-%d is a range", amount.Min, amount.Max)
+	}
+	return amount.Max, nil
+}
+
+// parseAmountNumber converts digits with "." or "," separators and an
+// optional unit to rupiah. A separator followed by exactly three digits
+// groups thousands; any other separator, or the only separator before a
+// unit ("1.5jt"), is a decimal point.
+func parseAmountNumber(num, unit string) (int64, bool) {
+	groups := strings.FieldsFunc(num, func(r rune) bool { return r == '.' || r == ',' })
+	if len(groups) == 0 {
+		return 0, false
+	}
+	intGroups, frac := groups, ""
+	if len(groups) > 1 {
+		last := groups[len(groups)-1]
+		if (unit != "" && len(groups) == 2) || len(last) != 3 {
+			intGroups, frac = groups[:len(groups)-1], last
+		}
+	}
+	whole, err := strconv.ParseInt(strings.Join(intGroups, ""), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	mult := int64(1)
+	if unit != "" {
+		mult = amountMultipliers[unit]
+	}
+	if whole > math.MaxInt64/mult {
+		return 0, false
+	}
+	val := whole * mult
+	if frac != "" {
+		f, err := strconv.ParseFloat("0."+frac, 64)
+		if err != nil {
+			return 0, false
+		}
+		val += int64(math.Round(f * float64(mult)))
+	}
+	return val, true
+}
+
+var (
+	numberWordDigits = map[string]float64{
+		"nol": 0, "satu": 1, "dua": 2, "tiga": 3, "empat": 4, "lima": 5,
+		"enam": 6, "tujuh": 7, "delapan": 8, "sembilan": 9, "setengah": 0.5,
+	}
+	// numberWordFixed are "se-" words that stand for a whole value.
+	numberWordFixed = map[string]float64{"sepuluh": 10, "sebelas": 11, "seratus": 100}
+	numberWordScale = map[string]float64{"ribu": 1e3, "juta": 1e6, "miliar": 1e9, "milyar": 1e9}
+	// numberWordSeScale are the "se-" forms of scale words ("seribu" = 1 ribu).
+	numberWordSeScale = map[string]string{"seribu": "ribu", "sejuta": "juta", "semiliar": "miliar", "semilyar": "milyar"}
+)
+
+// normalizeNumberWords rewrites runs of Indonesian number words into digits
+// so the numeric parser can read them. A run ending in a single scale word
+// keeps it as a unit ("dua puluh ribu" becomes "20 ribu") so ranges can
+// share the unit; bare digit words like "dua" are left alone because they
+// usually count items rather than money.
+func normalizeNumberWords(text string) string {
+	fields := strings.Fields(text)
+	out := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); {
+		j := i
+		for j < len(fields) && isNumberWord(fields[j], j == i) {
+			j++
+		}
+		if j == i {
+			out = append(out, fields[i])
+			i++
+			continue
+		}
+		if converted, ok := convertNumberWords(fields[i:j]); ok {
+			out = append(out, converted)
+		} else {
+			out = append(out, fields[i:j]...)
+		}
+		i = j
+	}
+	return strings.Join(out, " ")
+}
+
+// isNumberWord reports whether word can continue (or, when first is set,
+// start) a run of number words. Multipliers never start a run so "20 ribu"
+// stays as written.
+func isNumberWord(word string, first bool) bool {
+	if _, ok := numberWordDigits[word]; ok {
+		return true
+	}
+	if _, ok := numberWordFixed[word]; ok {
+		return true
+	}
+	if _, ok := numberWordSeScale[word]; ok {
+		return true
+	}
+	if first {
+		return false
+	}
+	if _, ok := numberWordScale[word]; ok {
+		return true
+	}
+	return word == "belas" || word == "puluh" || word == "ratus"
+}
+
+func convertNumberWords(words []string) (string, bool) {
+	var total, group, n float64
+	scales := 0
+	multiplied := false
+	for _, w := range words {
+		if d, ok := numberWordDigits[w]; ok {
+			n += d
+			continue
+		}
+		if v, ok := numberWordFixed[w]; ok {
+			group += v
+			multiplied = true
+			continue
+		}
+		if base, ok := numberWordSeScale[w]; ok {
+			w, n = base, n+1
+		}
+		multiplied = true
+		switch w {
+		case "belas":
+			group += n + 10
+		case "puluh":
+			group += n * 10
+		case "ratus":
+			group += n * 100
+		default:
+			scale := numberWordScale[w]
+			g := group + n
+			if g == 0 {
+				g = 1
+			}
+			total += g * scale
+			group = 0
+			scales++
+		}
+		n = 0
+	}
+	if !multiplied {
+		return "", false
+	}
+	total += group + n
+
+	last := words[len(words)-1]
+	if base, ok := numberWordSeScale[last]; ok {
+		last = base
+	}
+	if scale, ok := numberWordScale[last]; ok && scales == 1 {
+		return strconv.FormatFloat(total/scale, 'f', -1, 64) + " " + last, true
+	}
+	return strconv.FormatFloat(math.Round(total), 'f', -1, 64), true
+}
@@ -0,0 +1,100 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{"5000", Amount{5000, 5000}},
+		{"Rp 20.000", Amount{20000, 20000}},
+		{"1.000.000", Amount{1000000, 1000000}},
+		{"20,000", Amount{20000, 20000}},
+		{"20k", Amount{20000, 20000}},
+		{"2.5k", Amount{2500, 2500}},
+		{"50rb", Amount{50000, 50000}},
+		{"50 ribu", Amount{50000, 50000}},
+		{"1,5jt", Amount{1500000, 1500000}},
+		{"1.5 juta", Amount{1500000, 1500000}},
+		{"2jt", Amount{2000000, 2000000}},
+		{"pulsa telkomsel 20k", Amount{20000, 20000}},
+		{"10 mobile legends", Amount{10, 10}},
+		{"tarik saldo 50rb ke bca", Amount{50000, 50000}},
+		{"seratus ribu", Amount{100000, 100000}},
+		{"seribu", Amount{1000, 1000}},
+		{"sejuta", Amount{1000000, 1000000}},
+		{"dua puluh lima ribu", Amount{25000, 25000}},
+		{"lima belas ribu", Amount{15000, 15000}},
+		{"dua ratus lima puluh ribu", Amount{250000, 250000}},
+		{"setengah juta", Amount{500000, 500000}},
+		{"satu setengah juta", Amount{1500000, 1500000}},
+		{"satu juta dua ratus ribu", Amount{1200000, 1200000}},
+		{"budget seratus ribu aja", Amount{100000, 100000}},
+		{"20-30rb", Amount{20000, 30000}},
+		{"antara 20-30rb", Amount{20000, 30000}},
+		{"20rb - 30rb", Amount{20000, 30000}},
+		{"20rb-30", Amount{20000, 30000}},
+		{"15000-30rb", Amount{15000, 30000}},
+		{"antara 20 dan 30 ribu", Amount{20000, 30000}},
+		{"10rb sampai 25rb", Amount{10000, 25000}},
+		{"1 s/d 2jt", Amount{1000000, 2000000}},
+		{"antara dua puluh sampai tiga puluh ribu", Amount{20000, 30000}},
+		{"30rb-20rb", Amount{20000, 30000}},
+		{"pulsa 10rb dan 20rb", Amount{10000, 10000}},
+		{"budget 20000-30000", Amount{20000, 30000}},
+		{"pulsa 20rb ke 0812-3456-7890", Amount{20000, 20000}},
+		{"isi 0812-3456-7890 50rb", Amount{50000, 50000}},
+		{"+62-812-3456-7890 25rb", Amount{25000, 25000}},
+		{"2m", Amount{2, 2}},
+	}
+	for _, tc := range tests {
+		got, err := parseAmount(tc.in)
+		if err != nil {
+			t.Errorf("parseAmount(%q) error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseAmount(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseAmountRejects(t *testing.T) {
+	for _, in := range []string{"", "   ", "halo kak", "beli dua", "setengah jam", "0812-3456-7890"} {
+		if got, err := parseAmount(in); err == nil {
+			t.Errorf("parseAmount(%q) = %+v, want error", in, got)
+		}
+	}
+}
+
+func TestParseExactAmountRejectsRanges(t *testing.T) {
+	if got, err := parseExactAmount("50rb"); err != nil || got != 50000 {
+		t.Fatalf("parseExactAmount(50rb) = %d, %v", got, err)
+	}
+	if _, err := parseExactAmount("20-30rb"); err == nil {
+		t.Fatalf("expected range to be rejected")
+	}
+}
+
+func TestFilterByBudgetRange(t *testing.T) {
+	items := []atl.PriceListItem{
+		{Code: "A", Price: money.FromRupiah(10000), Status: "available"},
+		{Code: "B", Price: money.FromRupiah(25000), Status: "available"},
+		{Code: "C", Price: money.FromRupiah(35000), Status: "available"},
+		{Code: "D", Price: money.FromRupiah(22000), Status: "empty"},
+	}
+	capped := filterByBudget(items, Amount{Min: 30000, Max: 30000})
+	if len(capped) != 2 || capped[0].Code != "A" || capped[1].Code != "B" {
+		t.Fatalf("single budget = %+v", capped)
+	}
+	ranged := filterByBudget(items, Amount{Min: 20000, Max: 30000})
+	if len(ranged) != 1 || ranged[0].Code != "B" {
+		t.Fatalf("range budget = %+v", ranged)
+	}
+}
@@ -0,0 +1,268 @@
+package convo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	defaultApprovalTTL    = 30 * time.Minute
+	approvalCheckInterval = time.Minute
+)
+
+// Admins decide by replying "SETUJU <kode>" or "TOLAK <kode>". WhatsApp
+// interactive buttons are not delivered reliably to regular accounts, so the
+// request message spells the replies out instead.
+var approvalReplyPattern = regexp.MustCompile(`(?i)^(setuju|approve|acc|tolak|reject)\s+([a-z0-9]{6})$`)
+
+// needsWithdrawApproval reports whether a withdrawal must wait for an admin.
+// Approvals need at least one admin to answer them.
+func (e *Engine) needsWithdrawApproval(amount int64) bool {
+	threshold := e.tunables().WithdrawApprovalThreshold
+	return threshold > 0 && amount >= threshold && len(e.cfg.AdminJIDs) > 0
+}
+
+// requestWithdrawApproval records the withdrawal as awaiting approval and asks
+// the admins to decide. The amount stays reserved until the decision.
+func (e *Engine) requestWithdrawApproval(ctx context.Context, evt *events.Message, user *repo.User, wd repo.Withdrawal) error {
+	wd.Status = "awaiting_approval"
+	if _, err := e.repo.InsertWithdrawal(ctx, wd); err != nil {
+		return fmt.Errorf("insert withdrawal: %w", err)
+	}
+	ttl := e.tunables().ApprovalTTL
+	if ttl <= 0 {
+		ttl = defaultApprovalTTL
+	}
+	approval, err := e.repo.InsertAdminApproval(ctx, repo.AdminApproval{
+		Code:       newApprovalCode(),
+		Kind:       repo.ApprovalWithdrawal,
+		UserID:     user.ID,
+		UserJID:    evt.Info.Sender.String(),
+		SubjectRef: wd.WithdrawalRef,
+		Summary:    withdrawalApprovalSummary(wd, userPhone(evt.Info.MessageSource, user), e.adminCurrencyLocale()),
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		if updErr := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, "failed", wd.Metadata); updErr != nil {
+			e.logger.Warn("failed marking withdrawal failed", "error", updErr, "ref_id", wd.WithdrawalRef)
+		}
+		return fmt.Errorf("insert admin approval: %w", err)
+	}
+	e.logger.Info("admin approval requested", "code", approval.Code, "kind", approval.Kind, "subject_ref", approval.SubjectRef, "user_id", user.ID)
+	e.notifyAdmins(ctx, fmt.Sprintf("Butuh persetujuan (%s):\n%s\n\nBalas SETUJU %s atau TOLAK %s dalam %d menit.",
+		approval.Code, approval.Summary, approval.Code, approval.Code, int(ttl.Minutes())))
+
+	reply := fmt.Sprintf("%s %s sebesar %s perlu persetujuan admin dulu ya. Kamu akan dikabari paling lambat %d menit lagi.",
+		withdrawalNoun(wd), wd.WithdrawalRef, formatCurrency(e.currencyLocale(user), money.FromRupiah(wd.Amount)), int(ttl.Minutes()))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "withdraw_awaiting_approval")
+}
+
+func withdrawalApprovalSummary(wd repo.Withdrawal, phone string, locale money.Locale) string {
+	ownerName := ""
+	if wd.AccountName != nil {
+		ownerName = *wd.AccountName
+	}
+	if phone == "" {
+		phone = "nomor tidak diketahui"
+	}
+	return fmt.Sprintf("%s %s (+biaya %s) ke %s %s a.n %s oleh %s.",
+		withdrawalDescription(wd), formatCurrency(locale, money.FromRupiah(wd.Amount)), formatCurrency(locale, money.FromRupiah(wd.Fee)),
+		strings.ToUpper(wd.BankCode), wd.AccountNo, ownerName, phone)
+}
+
+// parseApprovalReply recognises an admin's decision on a pending approval.
+func (e *Engine) parseApprovalReply(sender types.MessageSource, text string) (*nlu.IntentResult, bool) {
+	m := approvalReplyPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil || !e.isAdmin(sender) {
+		return nil, false
+	}
+	decision := repo.ApprovalApproved
+	switch strings.ToLower(m[1]) {
+	case "tolak", "reject":
+		decision = repo.ApprovalRejected
+	}
+	return &nlu.IntentResult{
+		Intent:     "admin_decision",
+		Confidence: 1,
+		Entities:   map[string]string{"decision": decision, "code": strings.ToUpper(m[2])},
+	}, true
+}
+
+// isAdmin reports whether the message comes from a configured admin. Admins
+// are usually configured by phone number while WhatsApp may address them by
+// LID, so the phone-number JID a LID message carries as SenderAlt counts too.
+func (e *Engine) isAdmin(src types.MessageSource) bool {
+	sender, alt := src.Sender.ToNonAD(), src.SenderAlt.ToNonAD()
+	for _, raw := range e.cfg.AdminJIDs {
+		jid, err := wa.ParseAdminJID(raw)
+		if err != nil {
+			continue
+		}
+		jid = jid.ToNonAD()
+		if (!sender.IsEmpty() && jid == sender) || (!alt.IsEmpty() && jid == alt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) handleAdminDecision(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	code := intent.Entities["code"]
+	decision := intent.Entities["decision"]
+	approval, err := e.repo.DecideAdminApproval(ctx, code, decision, evt.Info.Sender.String(), time.Now())
+	if errors.Is(err, repo.ErrNotFound) {
+		reply := fmt.Sprintf("Kode %s tidak ditemukan, sudah diputuskan, atau sudah kedaluwarsa.", code)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "admin_decision_unknown")
+	}
+	if err != nil {
+		return fmt.Errorf("decide admin approval: %w", err)
+	}
+	e.logger.Info("admin approval decided", "code", approval.Code, "kind", approval.Kind, "subject_ref", approval.SubjectRef, "status", approval.Status, "decided_by", evt.Info.Sender.String())
+
+	var userMsg, adminMsg string
+	switch approval.Kind {
+	case repo.ApprovalWithdrawal:
+		userMsg, adminMsg = e.resolveWithdrawalApproval(ctx, approval)
+	default:
+		adminMsg = fmt.Sprintf("Keputusan %s dicatat, tapi jenis %q belum didukung.", approval.Code, approval.Kind)
+	}
+	if userMsg != "" {
+		e.notifyApprovalUser(ctx, approval, userMsg, "withdraw_approval_"+approval.Status)
+	}
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, adminMsg, "admin_decision")
+}
+
+// resolveWithdrawalApproval carries out a decided withdrawal and returns the
+// messages for the user and the deciding admin.
+func (e *Engine) resolveWithdrawalApproval(ctx context.Context, approval *repo.AdminApproval) (string, string) {
+	wd, err := e.repo.GetWithdrawalByRef(ctx, approval.SubjectRef)
+	if err != nil {
+		e.logger.Error("failed loading withdrawal for approval", "error", err, "ref_id", approval.SubjectRef)
+		return "", fmt.Sprintf("Keputusan %s dicatat, tapi permintaan %s tidak bisa dimuat.", approval.Code, approval.SubjectRef)
+	}
+	wd.Metadata = withApproval(wd.Metadata, approval)
+	locale := e.userCurrencyLocale(ctx, approval.UserID)
+	amount := formatCurrency(locale, money.FromRupiah(wd.Amount))
+
+	if approval.Status != repo.ApprovalApproved {
+		if err := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, "failed", wd.Metadata); err != nil {
+			e.logger.Warn("failed marking withdrawal rejected", "error", err, "ref_id", wd.WithdrawalRef)
+		}
+		return fmt.Sprintf("%s %s sebesar %s ditolak admin. Saldo kamu tidak terpotong.", withdrawalNoun(*wd), wd.WithdrawalRef, amount),
+			fmt.Sprintf("Ditolak (%s): %s", approval.Code, approval.Summary)
+	}
+
+	status, err := e.submitWithdrawal(ctx, *wd)
+	if err != nil {
+		e.logger.Error("approved withdrawal failed", "error", err, "ref_id", wd.WithdrawalRef)
+		return fmt.Sprintf("%s %s sebesar %s sudah disetujui, tapi gagal diproses. Saldo kamu tidak terpotong, coba lagi nanti ya.", withdrawalNoun(*wd), wd.WithdrawalRef, amount),
+			fmt.Sprintf("Disetujui (%s), tapi transfer gagal: %v", approval.Code, err)
+	}
+	return withdrawalProcessingMessage(*wd, status, locale),
+		fmt.Sprintf("Disetujui (%s): %s Status transfer: %s.", approval.Code, approval.Summary, strings.ToUpper(status))
+}
+
+// RunApprovalExpiry closes approvals nobody answered in time until ctx is cancelled.
+func (e *Engine) RunApprovalExpiry(ctx context.Context) {
+	ticker := time.NewTicker(approvalCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.expireApprovals(ctx)
+		}
+	}
+}
+
+func (e *Engine) expireApprovals(ctx context.Context) {
+	expired, err := e.repo.ExpireAdminApprovals(ctx, time.Now())
+	if err != nil {
+		e.logger.Warn("failed expiring admin approvals", "error", err)
+		return
+	}
+	for i := range expired {
+		approval := &expired[i]
+		e.logger.Info("admin approval expired", "code", approval.Code, "kind", approval.Kind, "subject_ref", approval.SubjectRef)
+		if approval.Kind != repo.ApprovalWithdrawal {
+			continue
+		}
+		wd, err := e.repo.GetWithdrawalByRef(ctx, approval.SubjectRef)
+		if err != nil {
+			e.logger.Warn("failed loading expired withdrawal", "error", err, "ref_id", approval.SubjectRef)
+			continue
+		}
+		if err := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, "failed", withApproval(wd.Metadata, approval)); err != nil {
+			e.logger.Warn("failed marking withdrawal expired", "error", err, "ref_id", wd.WithdrawalRef)
+			continue
+		}
+		msg := fmt.Sprintf("%s %s sebesar %s dibatalkan karena belum ada persetujuan admin. Saldo kamu tidak terpotong, silakan ajukan lagi ya.",
+			withdrawalNoun(*wd), wd.WithdrawalRef, formatCurrency(e.userCurrencyLocale(ctx, approval.UserID), money.FromRupiah(wd.Amount)))
+		e.notifyApprovalUser(ctx, approval, msg, "withdraw_approval_expired")
+		e.notifyAdmins(ctx, fmt.Sprintf("Kedaluwarsa (%s): %s", approval.Code, approval.Summary))
+	}
+}
+
+func (e *Engine) notifyApprovalUser(ctx context.Context, approval *repo.AdminApproval, text, category string) {
+	jid, err := types.ParseJID(approval.UserJID)
+	if err != nil {
+		e.logger.Warn("invalid approval user jid", "error", err, "jid", approval.UserJID)
+		return
+	}
+	if err := e.respondAndLog(ctx, jid, approval.UserID, text, category); err != nil {
+		e.logger.Warn("failed notifying user of approval", "error", err, "code", approval.Code)
+	}
+}
+
+func (e *Engine) notifyAdmins(ctx context.Context, text string) {
+	for _, raw := range e.cfg.AdminJIDs {
+		jid, err := wa.ParseAdminJID(raw)
+		if err != nil {
+			e.logger.Warn("invalid admin jid", "error", err, "jid", raw)
+			continue
+		}
+		if err := e.gateway.SendText(ctx, jid, text); err != nil {
+			e.logger.Warn("failed notifying admin", "error", err, "jid", jid.String())
+		}
+	}
+}
+
+// withApproval copies metadata and records the approval decision in it.
+func withApproval(meta map[string]any, approval *repo.AdminApproval) map[string]any {
+	out := cloneMeta(meta)
+	decision := map[string]any{"code": approval.Code, "status": approval.Status}
+	if approval.DecidedBy != nil {
+		decision["decided_by"] = *approval.DecidedBy
+	}
+	out["approval"] = decision
+	return out
+}
+
+func cloneMeta(meta map[string]any) map[string]any {
+	out := make(map[string]any, len(meta)+2)
+	for k, v := range meta {
+		out[k] = v
+	}
+	return out
+}
+
+func newApprovalCode() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b) // never fails since Go 1.24
+	return strings.ToUpper(hex.EncodeToString(b))
+}
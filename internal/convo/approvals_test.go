@@ -0,0 +1,90 @@
+package convo
+
+import (
+	"strings"
+	"testing"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseApprovalReply(t *testing.T) {
+	e := &Engine{cfg: EngineConfig{AdminJIDs: []string{"+6281234567890"}}}
+	admin := types.MessageSource{Sender: types.NewJID("6281234567890", types.DefaultUserServer)}
+	stranger := types.MessageSource{Sender: types.NewJID("6289999999999", types.DefaultUserServer)}
+
+	intent, ok := e.parseApprovalReply(admin, "setuju a1b2c3")
+	if !ok || intent.Intent != "admin_decision" || intent.Entities["decision"] != repo.ApprovalApproved || intent.Entities["code"] != "A1B2C3" {
+		t.Fatalf("parseApprovalReply(setuju) = %+v, %v", intent, ok)
+	}
+	if intent, ok := e.parseApprovalReply(admin, "TOLAK A1B2C3"); !ok || intent.Entities["decision"] != repo.ApprovalRejected {
+		t.Fatalf("parseApprovalReply(TOLAK) = %+v, %v", intent, ok)
+	}
+	if _, ok := e.parseApprovalReply(stranger, "SETUJU A1B2C3"); ok {
+		t.Fatalf("non-admin decision was accepted")
+	}
+	if _, ok := e.parseApprovalReply(admin, "setuju dong"); ok {
+		t.Fatalf("ordinary text parsed as a decision")
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	e := &Engine{cfg: EngineConfig{AdminJIDs: []string{"+6281234567890", "99887766@lid"}}}
+	for _, tc := range []struct {
+		name string
+		src  types.MessageSource
+		want bool
+	}{
+		{"phone number", types.MessageSource{Sender: types.NewADJID("6281234567890", 0, 12)}, true},
+		{"lid with phone number alt", types.MessageSource{Sender: types.NewJID("123456789", types.HiddenUserServer), SenderAlt: types.NewJID("6281234567890", types.DefaultUserServer)}, true},
+		{"configured lid", types.MessageSource{Sender: types.NewJID("99887766", types.HiddenUserServer)}, true},
+		{"lid without alt", types.MessageSource{Sender: types.NewJID("123456789", types.HiddenUserServer)}, false},
+		{"same user on another server", types.MessageSource{Sender: types.NewJID("6281234567890", types.HiddenUserServer)}, false},
+		{"stranger", types.MessageSource{Sender: types.NewJID("6289999999999", types.DefaultUserServer)}, false},
+	} {
+		if got := e.isAdmin(tc.src); got != tc.want {
+			t.Errorf("%s: isAdmin = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNeedsWithdrawApproval(t *testing.T) {
+	e := &Engine{cfg: EngineConfig{WithdrawApprovalThreshold: 1000000}}
+	if e.needsWithdrawApproval(2000000) {
+		t.Fatalf("approval required without any admin to answer it")
+	}
+	e.cfg.AdminJIDs = []string{"6281234567890"}
+	if !e.needsWithdrawApproval(1000000) || e.needsWithdrawApproval(999999) {
+		t.Fatalf("threshold not applied at %d", e.cfg.WithdrawApprovalThreshold)
+	}
+}
+
+func TestWithdrawalApprovalSummaryNamesTransfers(t *testing.T) {
+	name := "BUDI"
+	wd := repo.Withdrawal{WithdrawalRef: "tf1", BankCode: "bca", AccountNo: "123", AccountName: &name, Amount: 2000000, Fee: 2500,
+		Metadata: map[string]any{"kind": withdrawalKindTransfer}}
+	if got := withdrawalApprovalSummary(wd, "6281111", money.Indonesian); !strings.HasPrefix(got, "Transfer tf1 ") {
+		t.Fatalf("transfer summary = %q", got)
+	}
+	wd.WithdrawalRef, wd.Metadata = "wd1", nil
+	if got := withdrawalApprovalSummary(wd, "6281111", money.Indonesian); !strings.HasPrefix(got, "Tarik saldo wd1 ") {
+		t.Fatalf("withdrawal summary = %q", got)
+	}
+}
+
+func TestUserPhonePrefersPhoneNumberOverLID(t *testing.T) {
+	lid := types.NewJID("123456789", types.HiddenUserServer)
+	stored := "+6281111"
+	user := &repo.User{PhoneNumber: &stored}
+	if got := userPhone(types.MessageSource{Sender: lid, SenderAlt: types.NewJID("6282222", types.DefaultUserServer)}, user); got != "6282222" {
+		t.Fatalf("alt phone = %q", got)
+	}
+	if got := userPhone(types.MessageSource{Sender: lid}, user); got != "6281111" {
+		t.Fatalf("stored phone = %q", got)
+	}
+	if got := userPhone(types.MessageSource{Sender: lid}, &repo.User{}); got != "" {
+		t.Fatalf("unknown phone = %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var knownBankCodes = []string{
+	"bca", "bri", "bni", "mandiri", "bsi", "btn", "cimb", "permata", "danamon", "jago", "seabank",
+	"dana", "ovo", "gopay", "shopeepay", "linkaja",
+}
+
+func (e *Engine) handleCheckAccount(ctx context.Context, evt *events.Message, user *repo.User, rawText string, intent *nlu.IntentResult) error {
+	parsedBank, parsedAccount := parseBankAccount(strings.ToLower(rawText))
+	bank := strings.ToLower(strings.TrimSpace(intent.Entities["bank_code"]))
+	if bank == "" {
+		bank = parsedBank
+	}
+	account := strings.TrimSpace(intent.Entities["account_no"])
+	if account == "" {
+		account = parsedAccount
+	}
+	if bank == "" || account == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Sebutkan bank dan nomor rekeningnya ya. Contoh: \"cek rekening BCA 1234567890\".", "check_account_missing_fields")
+	}
+
+	check, err := e.atl.TransferCheckAccount(ctx, bank, account)
+	if err != nil {
+		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "check_account")
+	}
+	ownerName := strings.TrimSpace(check.OwnerName)
+	if ownerName == "" {
+		reply := fmt.Sprintf("Rekening %s %s tidak ditemukan. Cek lagi nomornya ya.", strings.ToUpper(bank), account)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_account_not_found")
+	}
+	reply := fmt.Sprintf("Rekening %s %s terdaftar atas nama *%s*.", strings.ToUpper(bank), account, ownerName)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_account")
+}
+
+func looksLikeAccountCheck(text string) bool {
+	return strings.Contains(text, "cek rekening") ||
+		strings.Contains(text, "cek rek ") ||
+		strings.Contains(text, "cek nama rekening") ||
+		strings.Contains(text, "cek akun bank") ||
+		strings.Contains(text, "cek ewallet")
+}
+
+// parseBankAccount picks the bank/e-wallet code and the longest digit run
+// (at least 6 digits) from lowercase text such as "cek rekening bca 1234567890".
+func parseBankAccount(lowered string) (string, string) {
+	fields := strings.Fields(lowered)
+
+	account := ""
+	for _, field := range fields {
+		digits := strings.Trim(field, ".,;:-")
+		if len(digits) < 6 || strings.Trim(digits, "0123456789") != "" {
+			continue
+		}
+		if len(digits) > len(account) {
+			account = digits
+		}
+	}
+
+	bank := ""
+	for _, candidate := range knownBankCodes {
+		if containsWord(lowered, candidate) {
+			bank = candidate
+			break
+		}
+	}
+	if bank == "" {
+		for i, field := range fields {
+			if field == "ke" && i+1 < len(fields) && !hasDigit(fields[i+1]) {
+				bank = fields[i+1]
+				break
+			}
+		}
+	}
+	return bank, account
+}
+
+func hasDigit(value string) bool {
+	return strings.ContainsAny(value, "0123456789")
+}
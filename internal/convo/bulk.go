@@ -0,0 +1,214 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxBulkTargets caps how many numbers one message may top up at once.
+const maxBulkTargets = 10
+
+var msisdnPattern = regexp.MustCompile(`^(?:\+?62|0)8\d{7,11}$`)
+
+// extractMSISDNs returns the distinct Indonesian mobile numbers in text, in
+// local form and in the order written, as in "isi pulsa 10k ke 0812...,
+// 0813..., 0857...".
+func extractMSISDNs(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';' || r == '/' || r == '&'
+	})
+	seen := map[string]bool{}
+	var numbers []string
+	for _, field := range fields {
+		field = strings.ReplaceAll(strings.Trim(field, ".:()"), "-", "")
+		if !msisdnPattern.MatchString(field) {
+			continue
+		}
+		local := field
+		if !strings.HasPrefix(local, "0") {
+			local = localPhoneNumber(strings.TrimPrefix(local, "+"))
+		}
+		if seen[local] {
+			continue
+		}
+		seen[local] = true
+		numbers = append(numbers, local)
+	}
+	return numbers
+}
+
+// bulkTargets returns the numbers a purchase goes to: those a pending draft
+// carried over, else every number in the message.
+func bulkTargets(entities map[string]string, text string) []string {
+	if carried := strings.TrimSpace(entities["targets"]); carried != "" {
+		return strings.Split(carried, ",")
+	}
+	return extractMSISDNs(text)
+}
+
+// handleBulkPrepaid takes a purchase of item for several numbers. Bulk
+// orders are paid from the balance, which covers every number up front, so
+// any other payment method is asked to switch to saldo.
+func (e *Engine) handleBulkPrepaid(ctx context.Context, evt *events.Message, user *repo.User, item *atl.PriceListItem, productType, paymentMethod, batchRef string, targets []string, annotations orderAnnotations) error {
+	if len(targets) > maxBulkTargets {
+		reply := fmt.Sprintf("Maksimal %d nomor sekali pesan ya. Kirim ulang dengan nomor yang lebih sedikit.", maxBulkTargets)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "prepaid_bulk_too_many")
+	}
+	draft := newDraftOrder(item, productType, targets[0], "", annotations)
+	draft.Targets = targets
+	if paymentMethod != "deposit" && paymentMethod != "saldo" {
+		e.storeDraft(ctx, user.ID, draft, flowConfirm)
+		prompt := draft.summary(user, e.currencyLocale(user)) + "\n\nPesanan ke beberapa nomor dibayar pakai saldo ya. Balas *saldo* untuk lanjut, atau \"batal\"."
+		if paymentMethod != "" {
+			prompt += "\nKalau saldo belum cukup, top up dulu dengan \"deposit [jumlah]\"."
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, prompt, "prepaid_bulk_confirm")
+	}
+	if e.purchasesHeld() {
+		e.degraded(dependencyAtlantic)
+		e.storeDraft(ctx, user.ID, draft, flowConfirm)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseHeld), "prepaid_held_supplier_down")
+	}
+	e.storeDraft(ctx, user.ID, nil, flowIdle)
+
+	item, proceed, err := e.verifyMargin(ctx, evt, user, item, productType)
+	if !proceed {
+		return err
+	}
+	return e.executePrepaidBulkWithBalance(ctx, evt, user, item, productType, batchRef, targets, annotations)
+}
+
+// executePrepaidBulkWithBalance buys item once for every target from the
+// user's balance. The orders, one per target, are created together or not
+// at all, then bought in turn; a failure for one number does not stop the
+// others unless the supplier is down. Each order settles on its own, and the
+// user gets a progress note and one combined result.
+func (e *Engine) executePrepaidBulkWithBalance(ctx context.Context, evt *events.Message, user *repo.User, item *atl.PriceListItem, productType, batchRef string, targets []string, annotations orderAnnotations) error {
+	size := len(targets)
+	unitPrice := item.Price.Rupiah()
+	total := unitPrice * int64(size)
+	if proceed, err := e.checkTrustTotal(ctx, evt, user, money.FromRupiah(total)); !proceed {
+		return err
+	}
+	locale := e.currencyLocale(user)
+	ub, err := e.repo.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		e.logger.Error("failed to check balance", "error", err, "user", user.ID)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgBalanceCheckFailed), "balance_check_failed")
+	}
+	if ub == nil || ub.SaldoConfirmed < total {
+		currentBalance := int64(0)
+		if ub != nil {
+			currentBalance = ub.SaldoConfirmed
+		}
+		reply := fmt.Sprintf("Saldo kamu tidak mencukupi.\n\n💰 Saldo: %s\n🏷️ Harga: %d nomor × %s = %s\n\nSilakan deposit dulu.\nKetik: \"deposit [jumlah]\" untuk top up saldo.", formatCurrency(locale, money.FromRupiah(currentBalance)), size, formatCurrency(locale, item.Price), formatCurrency(locale, money.FromRupiah(total)))
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "insufficient_balance")
+	}
+
+	batchRef = strings.TrimSpace(batchRef)
+	if batchRef == "" {
+		batchRef = generateRefID("trx")
+	}
+	instructions := e.productInstructions(ctx, *item)
+	refs := batchRefs(batchRef, size)
+	orders := make([]repo.Order, size)
+	for i, ref := range refs {
+		preMeta := map[string]any{
+			"customer_id": targets[i],
+			"precreate":   true,
+		}
+		if strings.TrimSpace(productType) != "" {
+			preMeta["product_type"] = productType
+		}
+		recordBatch(preMeta, batchRef, size)
+		annotations.applyTo(preMeta)
+		recordInstructions(preMeta, instructions)
+		recordReplyTarget(preMeta, evt)
+		e.tagAcquisition(ctx, user.ID, preMeta)
+		orders[i] = repo.Order{
+			UserID:      user.ID,
+			OrderRef:    ref,
+			ProductCode: item.Code,
+			Amount:      unitPrice,
+			Status:      "processing",
+			Metadata:    preMeta,
+		}
+	}
+	// All or nothing, so the balance is never charged for part of a list the
+	// user cannot see.
+	inserted, err := e.repo.InsertOrders(ctx, orders)
+	if err != nil {
+		e.logger.Error("failed creating bulk orders", "error", err, "batch_ref", batchRef, "user_id", user.ID)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Pesanan belum bisa dibuat, saldo kamu tidak terpotong. Coba lagi sebentar ya.", "create_prepaid_bulk_failed")
+	}
+	for i := range inserted {
+		e.orderCreated(ctx, &inserted[i])
+		e.recordOrderQuote(ctx, inserted[i].OrderRef, productType, item)
+	}
+
+	progress := fmt.Sprintf("Sip, %s (%s) ke %d nomor sedang kuproses, total %s. Hasilnya kukirim sebentar lagi ya.", item.Name, item.Code, size, formatCurrency(locale, money.FromRupiah(total)))
+	if err := e.respondAndLog(ctx, evt.Info.Sender, user.ID, progress, "create_prepaid_bulk_progress"); err != nil {
+		e.logger.Warn("failed sending bulk progress", "error", err, "batch_ref", batchRef)
+	}
+
+	lines := make([]string, 0, size)
+	var (
+		down                    bool
+		succeeded, open, failed int
+	)
+	for i, ref := range refs {
+		if down {
+			e.cancelBatchUnit(ctx, ref, targets[i], "", batchRef, size, "supplier unavailable")
+			lines = append(lines, fmt.Sprintf("%d. %s — dibatalkan", i+1, targets[i]))
+			failed++
+			continue
+		}
+		candidates := generateTargetCandidates(targets[i], "", targets[i])
+		result, outcome, err := e.buyBatchUnit(ctx, evt, user, item, ref, candidates, targets[i], "", batchRef, size, annotations, instructions)
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, targets[i], result))
+		switch outcome {
+		case unitSucceeded:
+			succeeded++
+		case unitOpen:
+			open++
+		case unitFailed:
+			failed++
+			// Other numbers would meet the same closed circuit.
+			down = errors.Is(err, atl.ErrCircuitOpen)
+		}
+	}
+
+	reply := fmt.Sprintf("Hasil %s (%s) ke %d nomor, ref %s:\n%s\n\nSukses %d, diproses %d, gagal %d.", item.Name, item.Code, size, batchRef, strings.Join(lines, "\n"), succeeded, open, failed)
+	if open > 0 {
+		reply += "\nYang masih diproses akan ku kabari begitu ada update."
+	}
+	if failed > 0 {
+		reply += "\nNomor yang gagal tidak memotong saldo."
+	}
+	reply += annotations.replySuffix()
+	reply += e.receiptLine(user, refs[0])
+	if succeeded > 0 {
+		reply = withInstructions(user, reply, instructions)
+	}
+	event := "create_prepaid_bulk"
+	if succeeded == 0 && open == 0 {
+		event = "create_prepaid_bulk_failed"
+	}
+	if err := e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, event); err != nil {
+		return err
+	}
+	if succeeded > 0 && open == 0 {
+		e.sendReceiptDocument(ctx, evt.Info.Sender, user.ID, refs[0])
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExtractMSISDNs(t *testing.T) {
+	got := extractMSISDNs("isi pulsa 10k ke 081234567890, 0813-1234-5678, +6285712345678 dan 081234567890.")
+	want := []string{"081234567890", "081312345678", "085712345678"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractMSISDNs = %q, want %q", got, want)
+	}
+	if got := extractMSISDNs("beli ML 86 diamond 12345678(1234)"); len(got) != 0 {
+		t.Fatalf("game ID read as a number: %q", got)
+	}
+}
+
+func TestDraftCarriesTargets(t *testing.T) {
+	d := &draftOrder{ProductCode: "TSEL10", ProductName: "Telkomsel 10.000", CustomerID: "081234567890", Price: 10500, Targets: []string{"081234567890", "081312345678"}}
+	entities := map[string]string{"payment_method": "deposit"}
+	d.fill(entities)
+	if got := bulkTargets(entities, "saldo"); !reflect.DeepEqual(got, d.Targets) {
+		t.Fatalf("carried targets = %q", got)
+	}
+	summary := d.summary(nil, money.LocaleFor("id", false))
+	if !strings.Contains(summary, "Tujuan (2 nomor): 081234567890, 081312345678") || !strings.Contains(summary, "Total: Rp21.000") {
+		t.Fatalf("summary = %q", summary)
+	}
+
+	// A new number replaces the list.
+	entities = map[string]string{"customer_id": "085712345678"}
+	d.fill(entities)
+	if entities["targets"] != "" {
+		t.Fatalf("targets kept after the number changed: %q", entities["targets"])
+	}
+}
+
+type bulkRepo struct {
+	repo.Repository
+	balance  int64
+	failNext bool
+	orders   map[string]*repo.Order
+}
+
+func (r *bulkRepo) GetUserBalance(context.Context, string) (*repo.UserBalance, error) {
+	return &repo.UserBalance{SaldoConfirmed: r.balance}, nil
+}
+
+func (r *bulkRepo) InsertOrders(_ context.Context, orders []repo.Order) ([]repo.Order, error) {
+	if r.failNext {
+		return nil, errors.New("constraint violation")
+	}
+	for i := range orders {
+		order := orders[i]
+		r.orders[order.OrderRef] = &order
+	}
+	return orders, nil
+}
+
+func (r *bulkRepo) GetOrderByRef(_ context.Context, ref string) (*repo.Order, error) {
+	if order, ok := r.orders[ref]; ok {
+		return order, nil
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r *bulkRepo) UpdateOrderStatus(_ context.Context, ref, status string, meta map[string]any) error {
+	r.orders[ref].Status = status
+	r.orders[ref].Metadata = meta
+	return nil
+}
+
+func (r *bulkRepo) CountSuccessfulOrders(context.Context, string) (int, error) {
+	return 0, nil
+}
+
+func (r *bulkRepo) GetUserAcquisition(context.Context, string) (*repo.Acquisition, error) {
+	return &repo.Acquisition{}, nil
+}
+
+func (r *bulkRepo) ListCuratedProducts(context.Context) ([]repo.CuratedProduct, error) {
+	return nil, nil
+}
+
+func (r *bulkRepo) InsertMessage(_ context.Context, msg repo.MessageRecord) error {
+	return nil
+}
+
+func TestExecutePrepaidBulkWithBalance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("target") == "081312345678" {
+			_, _ = io.WriteString(w, `{"status":false,"message":"Nomor tujuan tidak valid"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status":true,"data":{"id":"X","reff_id":"`+r.Form.Get("reff_id")+`","status":"success","sn":"SN-`+r.Form.Get("target")+`"}}`)
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := &bulkRepo{balance: 50000, orders: map[string]*repo.Order{}}
+	gw := &textGateway{}
+	e := &Engine{
+		repo:    r,
+		gateway: gw,
+		logger:  logger,
+		atl:     atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil),
+	}
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{
+		Info:    types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}},
+		Message: &waProto.Message{Conversation: proto.String("isi pulsa 10k ke 081234567890, 081312345678, 085712345678 pakai saldo")},
+	}
+	item := &atl.PriceListItem{Code: "TSEL10", Name: "Telkomsel 10.000", Price: money.FromRupiah(10500)}
+	targets := []string{"081234567890", "081312345678", "085712345678"}
+
+	if err := e.executePrepaidBulkWithBalance(ctx, evt, user, item, "prabayar", "trx-bulk", targets, orderAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"trx-bulk-1": "success", "trx-bulk-2": "failed", "trx-bulk-3": "success"}
+	for ref, status := range want {
+		order := r.orders[ref]
+		if order == nil || order.Status != status {
+			t.Fatalf("order %s = %+v, want %s", ref, order, status)
+		}
+		if order.Metadata["batch_ref"] != "trx-bulk" {
+			t.Fatalf("order %s not tied to its batch: %v", ref, order.Metadata)
+		}
+	}
+	if got := r.orders["trx-bulk-3"].Metadata["customer_id"]; got != "085712345678" {
+		t.Fatalf("third order target = %v", got)
+	}
+	if len(gw.sent) != 2 || !strings.Contains(gw.sent[0], "ke 3 nomor sedang kuproses") {
+		t.Fatalf("messages = %q", gw.sent)
+	}
+	result := gw.sent[1]
+	for _, line := range []string{"1. 081234567890 — sukses, SN: SN-081234567890", "2. 081312345678 — gagal", "3. 085712345678 — sukses", "Sukses 2, diproses 0, gagal 1."} {
+		if !strings.Contains(result, line) {
+			t.Fatalf("result lacks %q:\n%s", line, result)
+		}
+	}
+
+	// Not enough balance for every number creates nothing.
+	r.orders = map[string]*repo.Order{}
+	r.balance = 20000
+	if err := e.executePrepaidBulkWithBalance(ctx, evt, user, item, "prabayar", "trx-poor", targets, orderAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.orders) != 0 || !strings.Contains(gw.sent[len(gw.sent)-1], "3 nomor × ") {
+		t.Fatalf("orders %v, reply %q", r.orders, gw.sent[len(gw.sent)-1])
+	}
+
+	// A failed insert leaves no order behind and charges nothing.
+	r.balance, r.failNext = 50000, true
+	if err := e.executePrepaidBulkWithBalance(ctx, evt, user, item, "prabayar", "trx-fail", targets, orderAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.orders) != 0 || !strings.Contains(gw.sent[len(gw.sent)-1], "tidak terpotong") {
+		t.Fatalf("orders %v, reply %q", r.orders, gw.sent[len(gw.sent)-1])
+	}
+
+	// The trust cap is per order, so it covers every number together.
+	r.failNext = false
+	e.cfg.TrustLevels = []TrustLevel{{MinOrders: 0, MaxAmount: 25000}}
+	if err := e.executePrepaidBulkWithBalance(ctx, evt, user, item, "prabayar", "trx-cap", targets, orderAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.orders) != 0 || !strings.Contains(gw.sent[len(gw.sent)-1], "total pesanan Rp31.500 melebihi limit") {
+		t.Fatalf("orders %v, reply %q", r.orders, gw.sent[len(gw.sent)-1])
+	}
+}
@@ -0,0 +1,26 @@
+package convo
+
+import (
+	"context"
+
+	"bot-jual/internal/nlu"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const busyNotice = "Sebentar ya, antrian lagi ramai. Pesanmu sudah kuterima dan segera kubalas."
+
+// withBusyNotice acknowledges a message right away when its intent detection
+// has to wait behind a saturated NLU queue; the real reply follows once a slot
+// frees. Groups get no notice. The acknowledgement is not logged, so it never
+// becomes the "last bot message" fed back into the prompt.
+func (e *Engine) withBusyNotice(ctx context.Context, evt *events.Message) context.Context {
+	if evt.Info.IsGroup {
+		return ctx
+	}
+	return nlu.WithBusyNotice(ctx, func() {
+		if err := e.gateway.SendText(ctx, evt.Info.Sender, e.cfg.Persona.Apply(busyNotice)); err != nil {
+			e.logger.Warn("failed sending busy notice", "error", err)
+		}
+	})
+}
@@ -0,0 +1,494 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxCartItems caps a cart so its checkout stays one readable message and
+// one deposit of sensible size.
+const maxCartItems = 10
+
+// cartCheckoutHint closes every cart reply.
+const cartCheckoutHint = "Ketik /checkout saldo, /checkout qris atau /checkout bri untuk bayar semuanya sekaligus. Hapus item dengan /keranjang hapus NOMOR, atau kosongkan dengan /keranjang kosongkan."
+
+// cartItem is one purchase waiting in the user's cart. Price is the quoted
+// price in whole rupiah; checkout re-checks it before charging.
+type cartItem struct {
+	ProductCode  string `json:"product_code"`
+	ProductName  string `json:"product_name,omitempty"`
+	ProductType  string `json:"product_type,omitempty"`
+	CustomerID   string `json:"customer_id"`
+	CustomerZone string `json:"customer_zone,omitempty"`
+	Price        int64  `json:"price"`
+}
+
+func (c cartItem) target() string {
+	if c.CustomerZone != "" && !strings.Contains(c.CustomerID, "(") {
+		return fmt.Sprintf("%s(%s)", c.CustomerID, c.CustomerZone)
+	}
+	return c.CustomerID
+}
+
+// cartAddPattern matches one "tambah <produk> ke <tujuan>" request.
+var cartAddPattern = regexp.MustCompile(`(?i)^tambah(?:kan)?\s+(.+?)\s+(?:ke|buat|untuk)\s+(.+)$`)
+
+// cartSeparator splits a message adding several products at once.
+var cartSeparator = regexp.MustCompile(`\s*(?:[,;\n]+|\s+dan\s+)\s*`)
+
+// cartRequest is a product and target the user asked to add to the cart.
+type cartRequest struct {
+	Query  string
+	Target string
+}
+
+// cartRequestsFromText reads "tambah pulsa 10k ke 0812..., tambah token PLN
+// 20k ke 1234..." into its requests. It returns nil unless every part of the
+// message is such a request, so ordinary sentences starting with "tambah"
+// still reach the NLU. Topping up the balance is a deposit, not a product.
+func cartRequestsFromText(text string) []cartRequest {
+	var reqs []cartRequest
+	for _, part := range cartSeparator.Split(strings.TrimSpace(text), -1) {
+		if part == "" {
+			continue
+		}
+		m := cartAddPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil
+		}
+		query, target := strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		lower := strings.ToLower(query)
+		if strings.Contains(lower, "saldo") || strings.Contains(lower, "deposit") || strings.Contains(lower, "keranjang") {
+			return nil
+		}
+		if !strings.ContainsAny(target, "0123456789") && !refersToOwnNumber(target) {
+			return nil
+		}
+		reqs = append(reqs, cartRequest{Query: query, Target: target})
+	}
+	return reqs
+}
+
+// parseCartAdd recognises products being added to the cart, so a list of
+// several items needs no Gemini call.
+func parseCartAdd(text string) (*nlu.IntentResult, bool) {
+	if len(cartRequestsFromText(text)) == 0 {
+		return nil, false
+	}
+	return &nlu.IntentResult{Intent: "add_to_cart", Confidence: 1, Entities: map[string]string{}}, true
+}
+
+func (e *Engine) loadCart(ctx context.Context, userID string) []cartItem {
+	return e.loadSession(ctx, userID).Cart
+}
+
+// storeCart saves the cart in the user's session; an empty cart clears it.
+func (e *Engine) storeCart(ctx context.Context, userID string, cart []cartItem) {
+	s := e.loadSession(ctx, userID)
+	if len(s.Cart) == 0 && len(cart) == 0 {
+		return
+	}
+	s.Cart = cart
+	e.saveSession(ctx, userID, s)
+}
+
+// handleAddToCart adds the products named in text, or in the intent's
+// entities when text lists none, to the user's cart.
+func (e *Engine) handleAddToCart(ctx context.Context, evt *events.Message, user *repo.User, text string, intent *nlu.IntentResult) error {
+	reqs := cartRequestsFromText(text)
+	if len(reqs) == 0 {
+		query := strings.TrimSpace(intent.Entities["product_code"])
+		if query == "" {
+			query = strings.TrimSpace(intent.Entities["product_query"])
+		}
+		target := strings.TrimSpace(intent.Entities["customer_id"])
+		if zone := strings.TrimSpace(intent.Entities["customer_zone"]); zone != "" && target != "" {
+			target = fmt.Sprintf("%s(%s)", target, zone)
+		}
+		if query == "" || target == "" {
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Sebutkan produk dan tujuannya ya, contoh: \"tambah pulsa telkomsel 10k ke 081234567890\".", "cart_add_missing")
+		}
+		reqs = []cartRequest{{Query: query, Target: target}}
+	}
+
+	cart := e.loadCart(ctx, user.ID)
+	locale := e.currencyLocale(user)
+	var added, notes []string
+	for _, req := range reqs {
+		if len(cart) >= maxCartItems {
+			notes = append(notes, fmt.Sprintf("Keranjang maksimal %d item, jadi \"%s\" belum kumasukkan.", maxCartItems, req.Query))
+			continue
+		}
+		item, note := e.resolveCartItem(ctx, evt, user, req)
+		if item == nil {
+			if note != "" {
+				notes = append(notes, note)
+			}
+			continue
+		}
+		cart = append(cart, *item)
+		added = append(added, fmt.Sprintf("• %s (%s) → %s — %s", item.ProductName, item.ProductCode, item.target(), formatCurrency(locale, money.FromRupiah(item.Price))))
+	}
+	if len(added) == 0 {
+		if len(notes) == 0 {
+			// Every item was refused by a check that already replied.
+			return nil
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, strings.Join(notes, "\n"), "cart_add_failed")
+	}
+	e.storeCart(ctx, user.ID, cart)
+
+	var sb strings.Builder
+	sb.WriteString("Masuk keranjang:\n")
+	sb.WriteString(strings.Join(added, "\n"))
+	if len(notes) > 0 {
+		sb.WriteString("\n\n" + strings.Join(notes, "\n"))
+	}
+	sb.WriteString("\n\n" + e.cartSummary(cart, locale))
+	sb.WriteString("\n\n" + cartCheckoutHint)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, sb.String(), "cart_add")
+}
+
+// resolveCartItem finds the product and target of req. A nil item comes
+// with a note for the user, or none when a check already replied.
+func (e *Engine) resolveCartItem(ctx context.Context, evt *events.Message, user *repo.User, req cartRequest) (*cartItem, string) {
+	code := ""
+	if !strings.Contains(req.Query, " ") {
+		code = strings.ToUpper(req.Query)
+	}
+	item, productType, err := e.resolveProductFromQuery(ctx, code, "prabayar", req.Query, "")
+	if err != nil {
+		e.logger.Warn("cart product lookup failed", "error", err, "query", req.Query)
+		return nil, fmt.Sprintf("\"%s\": daftar harga lagi tidak bisa dicek, coba lagi sebentar ya.", req.Query)
+	}
+	if item == nil {
+		return nil, fmt.Sprintf("\"%s\": produknya belum ketemu. Sebutkan nama atau kode produk lain ya.", req.Query)
+	}
+	target := req.Target
+	if refersToOwnNumber(target) {
+		target = ownPhoneNumber(user, evt)
+		if target == "" || productRequiresZone(item) {
+			return nil, fmt.Sprintf("%s (%s): nomor WhatsApp kamu belum kelihatan dari sini, tulis nomor tujuannya ya.", item.Name, item.Code)
+		}
+	}
+	customerID, customerZone := normalizeCustomerTarget(target, "")
+	if customerID == "" {
+		return nil, fmt.Sprintf("%s (%s): tujuannya belum jelas, tulis nomor/ID tujuan ya.", item.Name, item.Code)
+	}
+	if productRequiresZone(item) && customerZone == "" {
+		return nil, fmt.Sprintf("%s (%s) butuh ID plus Server, formatkan seperti 12345678(1234) ya.", item.Name, item.Code)
+	}
+	if proceed, err := e.checkTrust(ctx, evt, user, item, productType); !proceed {
+		if err != nil {
+			e.logger.Warn("failed replying to held cart item", "error", err, "product_code", item.Code)
+		}
+		return nil, ""
+	}
+	return &cartItem{
+		ProductCode:  item.Code,
+		ProductName:  item.Name,
+		ProductType:  productType,
+		CustomerID:   customerID,
+		CustomerZone: customerZone,
+		Price:        item.Price.Rupiah(),
+	}, ""
+}
+
+// cartTotal sums the quoted prices of the cart.
+func cartTotal(cart []cartItem) int64 {
+	var total int64
+	for _, item := range cart {
+		total += item.Price
+	}
+	return total
+}
+
+// cartSummary lists the cart with its total and what each payment method
+// costs: the balance pays the total as is, QRIS and bank transfer add the
+// deposit fee.
+func (e *Engine) cartSummary(cart []cartItem, locale money.Locale) string {
+	var sb strings.Builder
+	sb.WriteString("🛒 Keranjang kamu:")
+	for i, item := range cart {
+		sb.WriteString(fmt.Sprintf("\n%d. %s (%s) → %s — %s", i+1, item.ProductName, item.ProductCode, item.target(), formatCurrency(locale, money.FromRupiah(item.Price))))
+	}
+	total := cartTotal(cart)
+	sb.WriteString(fmt.Sprintf("\n\nTotal: %s", formatCurrency(locale, money.FromRupiah(total))))
+	if gross := e.requiredDepositGross(total); gross > total {
+		sb.WriteString(fmt.Sprintf("\n• Pakai saldo: %s", formatCurrency(locale, money.FromRupiah(total))))
+		sb.WriteString(fmt.Sprintf("\n• Pakai QRIS/BRI: %s (biaya %s)", formatCurrency(locale, money.FromRupiah(gross)), formatCurrency(locale, money.FromRupiah(gross-total))))
+	}
+	return sb.String()
+}
+
+func (e *Engine) handleViewCart(ctx context.Context, evt *events.Message, user *repo.User) error {
+	cart := e.loadCart(ctx, user.ID)
+	if len(cart) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Keranjang kamu masih kosong. Tambah produk dengan \"tambah pulsa telkomsel 10k ke 081234567890\".", "cart_view_empty")
+	}
+	reply := e.cartSummary(cart, e.currencyLocale(user)) + "\n\n" + cartCheckoutHint
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_view")
+}
+
+// handleRemoveCartItem drops the numbered item from the cart.
+func (e *Engine) handleRemoveCartItem(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	cart := e.loadCart(ctx, user.ID)
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(intent.Entities["index"]), "#"))
+	if err != nil || n < 1 || n > len(cart) {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nomor item itu tidak ada di keranjang. Cek dulu dengan /keranjang ya.", "cart_remove_invalid")
+	}
+	removed := cart[n-1]
+	cart = append(cart[:n-1], cart[n:]...)
+	e.storeCart(ctx, user.ID, cart)
+	reply := fmt.Sprintf("%s (%s) ke %s dihapus dari keranjang.", removed.ProductName, removed.ProductCode, removed.target())
+	if len(cart) > 0 {
+		reply += "\n\n" + e.cartSummary(cart, e.currencyLocale(user))
+	}
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_remove")
+}
+
+func (e *Engine) handleClearCart(ctx context.Context, evt *events.Message, user *repo.User) error {
+	e.storeCart(ctx, user.ID, nil)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Oke, keranjang sudah kosong.", "cart_clear")
+}
+
+// handleCheckoutCart pays for the whole cart. Every item is re-quoted first;
+// a price that moved stops the checkout with the cart updated, so the user
+// confirms the new total. The balance buys the items one by one, while QRIS
+// and bank transfer create one deposit that pays for all of them.
+func (e *Engine) handleCheckoutCart(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	cart := e.loadCart(ctx, user.ID)
+	if len(cart) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Keranjang kamu masih kosong. Tambah produk dulu ya, contoh: \"tambah pulsa telkomsel 10k ke 081234567890\".", "cart_checkout_empty")
+	}
+	method := commandPaymentMethod(intent.Entities["payment_method"])
+	if method == "" {
+		reply := e.cartSummary(cart, e.currencyLocale(user)) + "\n\nMau bayar pakai apa? Balas /checkout saldo, /checkout qris atau /checkout bri."
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_checkout_ask_method")
+	}
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseBusy), "purchase_in_progress")
+	}
+	defer release()
+	if e.purchasesHeld() {
+		e.degraded(dependencyAtlantic)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseHeld), "cart_held_supplier_down")
+	}
+
+	items := make([]*atl.PriceListItem, len(cart))
+	for i, entry := range cart {
+		item, _, err := e.resolveProductFromQuery(ctx, entry.ProductCode, entry.ProductType, "", "")
+		if err != nil {
+			return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "cart_checkout_fetch")
+		}
+		if item == nil {
+			reply := fmt.Sprintf("Maaf, %s (%s) sudah tidak dijual. Hapus dulu dengan /keranjang hapus %d lalu checkout lagi ya.", entry.ProductName, entry.ProductCode, i+1)
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_checkout_unavailable")
+		}
+		quoted := *item
+		quoted.Price = money.FromRupiah(entry.Price)
+		offer, proceed, err := e.verifyMargin(ctx, evt, user, &quoted, entry.ProductType)
+		if !proceed {
+			// verifyMargin told the user why; keep the cart at the new price.
+			if offer != nil && offer.Price.Rupiah() != entry.Price {
+				cart[i].Price = offer.Price.Rupiah()
+				e.storeCart(ctx, user.ID, cart)
+			}
+			return err
+		}
+		items[i] = offer
+	}
+	// Each item passed checkTrust when it was added, but the cap is per order
+	// and the cart is paid as one.
+	var total int64
+	for _, item := range items {
+		total += item.Price.Rupiah()
+	}
+	if proceed, err := e.checkTrustTotal(ctx, evt, user, money.FromRupiah(total)); !proceed {
+		return err
+	}
+
+	e.storeCart(ctx, user.ID, nil)
+	if method == "deposit" {
+		return e.checkoutCartWithBalance(ctx, evt, user, cart, items)
+	}
+	return e.checkoutCartWithDeposit(ctx, evt, user, cart, items, method)
+}
+
+// checkoutCartWithBalance buys every item from the user's balance after
+// checking it covers the whole cart, so the cart is not left half bought
+// for want of funds.
+func (e *Engine) checkoutCartWithBalance(ctx context.Context, evt *events.Message, user *repo.User, cart []cartItem, items []*atl.PriceListItem) error {
+	var total int64
+	for _, item := range items {
+		total += item.Price.Rupiah()
+	}
+	locale := e.currencyLocale(user)
+	ub, err := e.repo.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		e.logger.Error("failed to check balance", "error", err, "user", user.ID)
+		e.storeCart(ctx, user.ID, cart)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Gagal mengecek saldo. Coba lagi nanti ya.", "balance_check_failed")
+	}
+	if ub == nil || ub.SaldoConfirmed < total {
+		currentBalance := int64(0)
+		if ub != nil {
+			currentBalance = ub.SaldoConfirmed
+		}
+		e.storeCart(ctx, user.ID, cart)
+		reply := fmt.Sprintf("Saldo kamu tidak mencukupi untuk isi keranjang.\n\n💰 Saldo: %s\n🛒 Total: %s\n\nKeranjang tetap kusimpan. Deposit dulu atau checkout pakai QRIS/BRI ya.", formatCurrency(locale, money.FromRupiah(currentBalance)), formatCurrency(locale, money.FromRupiah(total)))
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "insufficient_balance")
+	}
+	var firstErr error
+	for i, entry := range cart {
+		if err := e.executePrepaidWithBalance(ctx, evt, user, entry.ProductCode, entry.CustomerID, entry.CustomerZone, entry.CustomerID, "", items[i], entry.ProductType, orderAnnotations{}); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// checkoutCartWithDeposit creates one deposit for the whole cart and an
+// order per item waiting on it; paying the deposit fulfils every order.
+func (e *Engine) checkoutCartWithDeposit(ctx context.Context, evt *events.Message, user *repo.User, cart []cartItem, items []*atl.PriceListItem, method string) error {
+	var total int64
+	for _, item := range items {
+		total += item.Price.Rupiah()
+	}
+	grossAmount := e.requiredDepositGross(total)
+	depositType := e.cfg.DefaultDepositType
+	if method == "bri" {
+		depositType = "bank"
+	}
+	if method == "qris" && e.defaultDepositMethod() != "" && !strings.EqualFold(e.defaultDepositMethod(), "qris") {
+		method = e.defaultDepositMethod()
+	}
+	depositRef := generateRefID("dep")
+	depResp, provider, err := e.createDeposit(ctx, atl.DepositRequest{
+		Method: method,
+		Amount: money.FromRupiah(grossAmount),
+		RefID:  depositRef,
+		Type:   depositType,
+	})
+	if err != nil {
+		e.logger.Warn("cart deposit request failed", "error", err, "user_id", user.ID, "method", method)
+		e.storeCart(ctx, user.ID, cart)
+		feedback := friendlyAtlanticError(err)
+		if feedback == "" {
+			feedback = "Belum bisa menyiapkan deposit untuk keranjang kamu. Keranjang tetap kusimpan, coba metode lain atau ulangi sebentar lagi."
+		} else {
+			feedback = fmt.Sprintf("Belum bisa menyiapkan deposit: %s. Keranjang tetap kusimpan.", feedback)
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, feedback, "cart_checkout_failed")
+	}
+
+	feeAmount := depResp.Fee.Rupiah()
+	netAmount := deriveNetAmount(total, feeAmount, depResp.NetAmount.Rupiah())
+	depStatus, forced := forceSuccessIfPending(depResp.Status)
+	codes := make([]string, len(items))
+	for i, item := range items {
+		codes[i] = item.Code
+	}
+	metadata := map[string]any{
+		"checkout":          depResp.Checkout,
+		"payment_provider":  provider,
+		"cart_products":     codes,
+		"gross_amount":      grossAmount,
+		"requested_amount":  grossAmount,
+		"target_net_amount": total,
+	}
+	if depResp.ID != "" {
+		metadata["provider_id"] = depResp.ID
+	}
+	if forced {
+		metadata["forced_success"] = true
+		metadata["original_status"] = depResp.Status
+	}
+	if providerAmount := depResp.Amount.Rupiah(); providerAmount > 0 {
+		metadata["provider_amount"] = providerAmount
+	}
+	if feeAmount > 0 {
+		metadata["fee"] = feeAmount
+	}
+	if netAmount > 0 {
+		metadata["net_amount"] = netAmount
+	}
+	depositAmount := grossAmount
+	if netAmount > 0 {
+		depositAmount = netAmount
+	}
+	if _, err := e.repo.InsertDeposit(ctx, repo.Deposit{
+		UserID:     user.ID,
+		DepositRef: depositRef,
+		Method:     method,
+		Amount:     depositAmount,
+		Status:     depStatus,
+		Metadata:   metadata,
+	}); err != nil {
+		e.logger.Warn("failed storing deposit", "error", err)
+	}
+
+	locale := e.currencyLocale(user)
+	lines := make([]string, len(cart))
+	for i, entry := range cart {
+		item := items[i]
+		orderRef := generateRefID("trx")
+		orderMetadata := map[string]any{
+			"customer_id":  entry.CustomerID,
+			"deposit_ref":  depositRef,
+			"product":      item.Name,
+			"product_type": entry.ProductType,
+		}
+		if candidates := generateTargetCandidates(entry.CustomerID, entry.CustomerZone, entry.CustomerID); len(candidates) > 0 {
+			orderMetadata["target_candidates"] = candidates
+		}
+		if entry.CustomerZone != "" {
+			orderMetadata["customer_zone"] = entry.CustomerZone
+		}
+		recordInstructions(orderMetadata, e.productInstructions(ctx, *item))
+		recordReplyTarget(orderMetadata, evt)
+		e.tagAcquisition(ctx, user.ID, orderMetadata)
+		if order, err := e.repo.InsertOrder(ctx, repo.Order{
+			UserID:      user.ID,
+			OrderRef:    orderRef,
+			ProductCode: item.Code,
+			Amount:      item.Price.Rupiah(),
+			Status:      "awaiting_payment",
+			Metadata:    orderMetadata,
+		}); err != nil {
+			e.logger.Warn("failed storing pending order", "error", err, "order_ref", orderRef)
+		} else {
+			e.orderCreated(ctx, order)
+		}
+		e.recordOrderQuote(ctx, orderRef, entry.ProductType, item)
+		lines[i] = fmt.Sprintf("%d. %s (%s) → %s — %s, ref %s", i+1, item.Name, item.Code, entry.target(), formatCurrency(locale, item.Price), orderRef)
+	}
+
+	summaryLine := summarizeDepositAmounts(user, grossAmount, feeAmount, netAmount, locale)
+	header := fmt.Sprintf("Sip, sudah kubuatin deposit via %s sebesar %s buat %d item keranjang:\n%s\nRef deposit: %s", strings.ToUpper(method), formatCurrency(locale, money.FromRupiah(grossAmount)), len(cart), strings.Join(lines, "\n"), depositRef)
+	if depositType == "bank" {
+		reply := header + "\n" + formatBankTransferInfo(user, depResp.Checkout, locale, user.Location())
+		if summaryLine != "" {
+			reply = fmt.Sprintf("%s\n%s", reply, summaryLine)
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_checkout")
+	}
+	qrCaption := fmt.Sprintf("Deposit %s via %s untuk %d item keranjang.", depositRef, strings.ToUpper(method), len(cart))
+	if summaryLine != "" {
+		qrCaption = fmt.Sprintf("%s\n%s", qrCaption, summaryLine)
+	}
+	qrSent := e.sendCheckoutQRImage(ctx, evt.Info.Sender, user.ID, depResp.Checkout, qrCaption, "cart_checkout")
+	reply := header + "\n" + formatCheckoutInfo(user, depResp.Checkout, qrSent, locale, user.Location())
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cart_checkout")
+}
@@ -0,0 +1,170 @@
+package convo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestCartRequestsFromText(t *testing.T) {
+	reqs := cartRequestsFromText("tambah pulsa telkomsel 10k ke 081234567890, tambahkan token pln 20rb buat 12345678901 dan tambah ML86 untuk 69827740(2126)")
+	want := []cartRequest{
+		{Query: "pulsa telkomsel 10k", Target: "081234567890"},
+		{Query: "token pln 20rb", Target: "12345678901"},
+		{Query: "ML86", Target: "69827740(2126)"},
+	}
+	if len(reqs) != len(want) {
+		t.Fatalf("got %d requests, want %d: %+v", len(reqs), len(want), reqs)
+	}
+	for i := range want {
+		if reqs[i] != want[i] {
+			t.Fatalf("request %d = %+v, want %+v", i, reqs[i], want[i])
+		}
+	}
+
+	if reqs := cartRequestsFromText("tambah pulsa 5rb ke nomorku"); len(reqs) != 1 || reqs[0].Target != "nomorku" {
+		t.Fatalf("own number target = %+v", reqs)
+	}
+
+	for _, text := range []string{
+		"tambah saldo 50rb ke bca 1234567890",
+		"tambah pulsa ke teman saya",
+		"tambah pulsa 10k ke 0812, terus cek harga token",
+		"tambahan dong",
+	} {
+		if reqs := cartRequestsFromText(text); reqs != nil {
+			t.Fatalf("%q parsed as %+v", text, reqs)
+		}
+		if _, ok := parseCartAdd(text); ok {
+			t.Fatalf("%q recognised as a cart add", text)
+		}
+	}
+	if intent, ok := parseCartAdd("Tambah TSEL10 ke 081234567890"); !ok || intent.Intent != "add_to_cart" {
+		t.Fatalf("parseCartAdd = %+v, %v", intent, ok)
+	}
+}
+
+func TestCartItemTarget(t *testing.T) {
+	if got := (cartItem{CustomerID: "69827740", CustomerZone: "2126"}).target(); got != "69827740(2126)" {
+		t.Fatalf("target = %q", got)
+	}
+	if got := (cartItem{CustomerID: "081234567890"}).target(); got != "081234567890" {
+		t.Fatalf("target = %q", got)
+	}
+}
+
+// catalogRepo serves a catalog with no curation, renames, pricing rules,
+// flash sales or pinned snapshots, for tests that quote and re-quote
+// products against a fake Atlantic price list.
+type catalogRepo struct {
+	*stateRepo
+	successful int
+}
+
+func (r *catalogRepo) ListCuratedProducts(context.Context) ([]repo.CuratedProduct, error) {
+	return nil, nil
+}
+
+func (r *catalogRepo) ListProductNameRules(context.Context) ([]repo.ProductNameRule, error) {
+	return nil, nil
+}
+
+func (r *catalogRepo) ListPricingRules(context.Context) ([]repo.PricingRule, error) {
+	return nil, nil
+}
+
+func (r *catalogRepo) ListFlashSales(context.Context, time.Time) ([]repo.FlashSale, error) {
+	return nil, nil
+}
+
+func (r *catalogRepo) GetPinnedCatalogSnapshot(context.Context, string) (*repo.CatalogSnapshot, error) {
+	return nil, repo.ErrNotFound
+}
+
+func (r *catalogRepo) GetLatestCatalogSnapshot(context.Context, string) (*repo.CatalogSnapshot, error) {
+	return nil, repo.ErrNotFound
+}
+
+func (r *catalogRepo) InsertCatalogSnapshot(_ context.Context, snap repo.CatalogSnapshot) (*repo.CatalogSnapshot, error) {
+	return &snap, nil
+}
+
+func (r *catalogRepo) CountSuccessfulOrders(context.Context, string) (int, error) {
+	return r.successful, nil
+}
+
+func (r *catalogRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+// newCatalogEngine returns an engine whose Atlantic price list is *items at
+// the time of each request.
+func newCatalogEngine(t *testing.T, r repo.Repository, items *[]atl.PriceListItem) (*Engine, *textGateway) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/layanan/price_list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := json.Marshal(*items)
+		_, _ = io.WriteString(w, `{"status":true,"data":`+string(data)+`}`)
+	}))
+	t.Cleanup(srv.Close)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gw := &textGateway{}
+	e := &Engine{
+		repo:            r,
+		gateway:         gw,
+		logger:          logger,
+		atl:             atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil),
+		priceCache:      make(map[string]priceCacheEntry),
+		catalogPins:     make(map[string]catalogPin),
+		catalogVersions: make(map[string]catalogVersion),
+		sessions:        make(map[string]sessionEntry),
+		priceCacheTTL:   time.Minute,
+	}
+	return e, gw
+}
+
+func TestCheckoutCartAboveTrustCap(t *testing.T) {
+	items := []atl.PriceListItem{
+		{Code: "GPLAY50", Name: "Google Play 50.000", Price: money.FromRupiah(55000), Status: "available"},
+		{Code: "DANA50", Name: "DANA 50.000", Price: money.FromRupiah(51000), Status: "available"},
+	}
+	r := &catalogRepo{stateRepo: &stateRepo{states: map[string]repo.ConversationState{}}}
+	e, gw := newCatalogEngine(t, r, &items)
+	e.cfg.TrustLevels = []TrustLevel{{MinOrders: 0, MaxAmount: 100000}}
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+
+	// Either item alone is within the cap; together they are not.
+	cart := []cartItem{
+		{ProductCode: "GPLAY50", ProductName: "Google Play 50.000", ProductType: "prabayar", CustomerID: "081234567890", Price: 55000},
+		{ProductCode: "DANA50", ProductName: "DANA 50.000", ProductType: "prabayar", CustomerID: "081234567890", Price: 51000},
+	}
+	e.storeCart(ctx, user.ID, cart)
+	if err := e.handleCheckoutCart(ctx, evt, user, &nlu.IntentResult{Entities: map[string]string{"payment_method": "saldo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(gw.sent[len(gw.sent)-1], "total pesanan Rp106.000 melebihi limit") {
+		t.Fatalf("messages = %q", gw.sent)
+	}
+	if len(e.loadCart(ctx, user.ID)) != 2 {
+		t.Fatalf("cart not kept after the refused checkout")
+	}
+}
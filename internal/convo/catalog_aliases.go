@@ -0,0 +1,90 @@
+package convo
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// catalogAliasTTL bounds how long admin edits take to reach the matcher.
+const catalogAliasTTL = time.Minute
+
+type catalogAlias struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+type catalogAliasCache struct {
+	rules   []catalogAlias
+	expires time.Time
+}
+
+// expandCatalogQuery rewrites customer phrases (e.g. "ml") into catalog wording
+// using the operator-managed alias table before the query is scored.
+func (e *Engine) expandCatalogQuery(ctx context.Context, query string) string {
+	if strings.TrimSpace(query) == "" {
+		return query
+	}
+	return expandAliases(query, e.catalogAliasRules(ctx))
+}
+
+func (e *Engine) catalogAliasRules(ctx context.Context) []catalogAlias {
+	e.mu.RLock()
+	cached := e.aliasCache
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.rules
+	}
+
+	aliases, err := e.repo.ListCatalogAliases(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading catalog aliases", "error", err)
+		return cached.rules
+	}
+	mapping := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		mapping[a.Alias] = a.Replacement
+	}
+	rules := compileAliases(mapping)
+
+	e.mu.Lock()
+	e.aliasCache = catalogAliasCache{rules: rules, expires: time.Now().Add(catalogAliasTTL)}
+	e.mu.Unlock()
+	return rules
+}
+
+// compileAliases builds whole-word matchers, longest alias first so
+// "token listrik" wins over "token".
+func compileAliases(mapping map[string]string) []catalogAlias {
+	keys := make([]string, 0, len(mapping))
+	for alias := range mapping {
+		if strings.TrimSpace(alias) != "" {
+			keys = append(keys, alias)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) == len(keys[j]) {
+			return keys[i] < keys[j]
+		}
+		return len(keys[i]) > len(keys[j])
+	})
+
+	rules := make([]catalogAlias, 0, len(keys))
+	for _, alias := range keys {
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(strings.TrimSpace(alias)) + `\b`)
+		rules = append(rules, catalogAlias{pattern: pattern, replacement: mapping[alias]})
+	}
+	return rules
+}
+
+func expandAliases(query string, rules []catalogAlias) string {
+	expanded := query
+	for _, rule := range rules {
+		if rule.pattern.MatchString(expanded) {
+			expanded = rule.pattern.ReplaceAllLiteralString(expanded, rule.replacement)
+		}
+	}
+	return expanded
+}
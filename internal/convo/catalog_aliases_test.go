@@ -0,0 +1,21 @@
+package convo
+
+import "testing"
+
+func TestExpandAliasesPrefersLongestWholeWord(t *testing.T) {
+	rules := compileAliases(map[string]string{
+		"ml":            "mobile legends",
+		"token":         "voucher",
+		"token listrik": "pln prabayar",
+	})
+
+	if got := expandAliases("diamond ml 86", rules); got != "diamond mobile legends 86" {
+		t.Fatalf("unexpected expansion %q", got)
+	}
+	if got := expandAliases("token listrik 50k", rules); got != "pln prabayar 50k" {
+		t.Fatalf("unexpected expansion %q", got)
+	}
+	if got := expandAliases("html", rules); got != "html" {
+		t.Fatalf("alias should not match inside words, got %q", got)
+	}
+}
@@ -0,0 +1,143 @@
+package convo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+// catalogPinTTL bounds how long an admin pin or rollback takes to reach replies.
+const catalogPinTTL = time.Minute
+
+type catalogVersion struct {
+	version  int
+	checksum string
+}
+
+type catalogPin struct {
+	// version is zero when no snapshot is pinned.
+	version int
+	items   []atl.PriceListItem
+	expires time.Time
+}
+
+// pinnedPriceList returns the admin-pinned snapshot of a product type, if any.
+func (e *Engine) pinnedPriceList(ctx context.Context, productType string) ([]atl.PriceListItem, bool) {
+	e.mu.RLock()
+	cached, ok := e.catalogPins[productType]
+	e.mu.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.items, cached.version > 0
+	}
+
+	pin := catalogPin{expires: time.Now().Add(catalogPinTTL)}
+	snap, err := e.repo.GetPinnedCatalogSnapshot(ctx, productType)
+	switch {
+	case err == nil:
+		var items []atl.PriceListItem
+		if err := json.Unmarshal(snap.Items, &items); err != nil {
+			e.logger.Warn("failed decoding pinned catalog", "error", err, "type", productType, "version", snap.Version)
+			return nil, false
+		}
+		pin.version, pin.items = snap.Version, items
+	case errors.Is(err, repo.ErrNotFound):
+	default:
+		e.logger.Warn("failed loading pinned catalog", "error", err, "type", productType)
+		return cached.items, cached.version > 0
+	}
+
+	e.mu.Lock()
+	e.catalogPins[productType] = pin
+	e.mu.Unlock()
+	return pin.items, pin.version > 0
+}
+
+// recordCatalogSnapshot stores a new catalog version when the supplier price list changed.
+func (e *Engine) recordCatalogSnapshot(ctx context.Context, productType string, items []atl.PriceListItem) {
+	sum := catalogChecksum(items)
+	e.mu.RLock()
+	current := e.catalogVersions[productType]
+	e.mu.RUnlock()
+	if current.checksum == sum {
+		return
+	}
+
+	if latest, err := e.repo.GetLatestCatalogSnapshot(ctx, productType); err == nil && latest.Checksum == sum {
+		e.setCatalogVersion(productType, catalogVersion{version: latest.Version, checksum: sum})
+		return
+	} else if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		e.logger.Warn("failed loading latest catalog snapshot", "error", err, "type", productType)
+		return
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		e.logger.Warn("failed encoding catalog snapshot", "error", err, "type", productType)
+		return
+	}
+	snap, err := e.repo.InsertCatalogSnapshot(ctx, repo.CatalogSnapshot{
+		ProductType: productType,
+		Checksum:    sum,
+		ItemCount:   len(items),
+		Items:       data,
+	})
+	if err != nil {
+		e.logger.Warn("failed storing catalog snapshot", "error", err, "type", productType)
+		return
+	}
+	e.logger.Info("catalog snapshot recorded", "type", productType, "version", snap.Version, "items", snap.ItemCount)
+	e.setCatalogVersion(productType, catalogVersion{version: snap.Version, checksum: sum})
+}
+
+func (e *Engine) setCatalogVersion(productType string, v catalogVersion) {
+	e.mu.Lock()
+	e.catalogVersions[productType] = v
+	e.mu.Unlock()
+}
+
+// catalogVersionFor returns the version prices of productType are currently quoted from, or zero if unknown.
+func (e *Engine) catalogVersionFor(productType string) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if pin, ok := e.catalogPins[productType]; ok && pin.version > 0 && time.Now().Before(pin.expires) {
+		return pin.version
+	}
+	return e.catalogVersions[productType].version
+}
+
+// recordOrderQuote remembers which catalog version an order's price came from.
+func (e *Engine) recordOrderQuote(ctx context.Context, orderRef, productType string, item *atl.PriceListItem) {
+	productType = defaultProductType(productType)
+	version := e.catalogVersionFor(productType)
+	if version == 0 || item == nil {
+		return
+	}
+	if err := e.repo.RecordOrderCatalogVersion(ctx, repo.OrderCatalogVersion{
+		OrderRef:       orderRef,
+		ProductType:    productType,
+		CatalogVersion: version,
+		QuotedPrice:    item.Price.Rupiah(),
+	}); err != nil {
+		e.logger.Warn("failed recording order catalog version", "error", err, "order_ref", orderRef)
+	}
+}
+
+// catalogChecksum fingerprints the fields that matter for quoting, independent of item order.
+func catalogChecksum(items []atl.PriceListItem) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%s|%s|%d|%s", strings.ToUpper(item.Code), item.Name, item.Price, item.Status))
+	}
+	sort.Strings(lines)
+	h := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(h[:])
+}
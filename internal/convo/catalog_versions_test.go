@@ -0,0 +1,37 @@
+package convo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func TestCatalogChecksum(t *testing.T) {
+	items := []atl.PriceListItem{
+		{Code: "TSEL10", Name: "Telkomsel 10K", Price: money.FromRupiah(10500), Status: "available"},
+		{Code: "XL10", Name: "XL 10K", Price: money.FromRupiah(10700), Status: "available"},
+	}
+	reordered := []atl.PriceListItem{items[1], items[0]}
+	if catalogChecksum(items) != catalogChecksum(reordered) {
+		t.Fatalf("checksum depends on item order")
+	}
+
+	repriced := []atl.PriceListItem{items[0], {Code: "XL10", Name: "XL 10K", Price: money.FromRupiah(1070), Status: "available"}}
+	if catalogChecksum(items) == catalogChecksum(repriced) {
+		t.Fatalf("checksum ignores price changes")
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded []atl.PriceListItem
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if catalogChecksum(decoded) != catalogChecksum(items) {
+		t.Fatalf("stored snapshot does not round-trip")
+	}
+}
@@ -0,0 +1,217 @@
+package convo
+
+import (
+	"strings"
+
+	"bot-jual/internal/nlu"
+)
+
+// commandHelp documents the slash commands handled without NLU.
+const commandHelp = "Perintah cepat:\n" +
+	"• /harga [produk] - cek harga, contoh: /harga pulsa telkomsel\n" +
+	"• /beli KODE TUJUAN [SERVER] [saldo|qris|bri] - contoh: /beli TSEL25 081234567890 qris\n" +
+	"• /tambah KODE TUJUAN - masukkan produk ke keranjang, contoh: /tambah TSEL10 081234567890\n" +
+	"• /keranjang [hapus NOMOR|kosongkan] - lihat atau ubah keranjang\n" +
+	"• /checkout saldo|qris|bri - bayar semua isi keranjang\n" +
+	"• /langganan [KODE TUJUAN tiap tanggal N|batal NOMOR] - beli rutin dari saldo, contoh: /langganan TSEL25 081234567890 tiap tanggal 1\n" +
+	"• /pantau [KODE [HARGA]|batal NOMOR] - kabari saat produk ready lagi atau harganya turun\n" +
+	"• /saldo - cek saldo\n" +
+	"• /transfer [BANK NOMOR NOMINAL] - kirim saldo ke rekening bank atau e-wallet, contoh: /transfer dana 081234567890 50rb\n" +
+	"• /status REF - cek status transaksi\n" +
+	"• /bayarulang [REF] - buat QR baru untuk pesanan yang QR-nya kedaluwarsa\n" +
+	"• /riwayat [halaman] - lihat transaksi terakhir\n" +
+	"• /reset - mulai obrolan baru dari awal\n" +
+	"• /operator - minta dibantu admin\n" +
+	"• /format id|en|auto - pilih format angka, contoh: /format en untuk Rp1,250,000\n" +
+	"• /english - reply in English\n" +
+	"• #nomor TUJUAN - beli produk dari daftar harga terakhir, contoh: #2 081234567890\n" +
+	"• /bantuan - tampilkan bantuan"
+
+// commandHelpEnglish is commandHelp for users who chose English replies.
+const commandHelpEnglish = "Quick commands:\n" +
+	"• /harga [product] - check prices, e.g. /harga pulsa telkomsel\n" +
+	"• /beli CODE TARGET [SERVER] [saldo|qris|bri] - e.g. /beli TSEL25 081234567890 qris\n" +
+	"• /tambah CODE TARGET - add a product to your cart, e.g. /tambah TSEL10 081234567890\n" +
+	"• /keranjang [hapus NUMBER|kosongkan] - view or edit your cart\n" +
+	"• /checkout saldo|qris|bri - pay for everything in your cart\n" +
+	"• /langganan [CODE TARGET every month on the Nth|batal NUMBER] - buy regularly from your balance, e.g. /langganan TSEL25 081234567890 tiap tanggal 1\n" +
+	"• /pantau [CODE [PRICE]|batal NUMBER] - get told when a product is back in stock or cheaper\n" +
+	"• /saldo - check your balance\n" +
+	"• /transfer [BANK NUMBER AMOUNT] - send balance to a bank account or e-wallet, e.g. /transfer dana 081234567890 50rb\n" +
+	"• /status REF - check a transaction\n" +
+	"• /bayarulang [REF] - get a new QR for an order whose QR expired\n" +
+	"• /riwayat [page] - list recent transactions\n" +
+	"• /reset - start the conversation over\n" +
+	"• /operator - ask an admin for help\n" +
+	"• /format id|en|auto - pick the number format, e.g. /format en for Rp1,250,000\n" +
+	"• /bahasa - balas dalam bahasa Indonesia\n" +
+	"• #number TARGET - buy from the last price list, e.g. #2 081234567890\n" +
+	"• /help - show this help"
+
+// parseCommand maps a slash command to an intent so power users get
+// deterministic handling without a Gemini round trip. ok is false for text
+// that is not a command; unknown commands resolve to the help intent.
+func parseCommand(text string) (intent *nlu.IntentResult, ok bool) {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 || len(fields[0]) < 2 || fields[0][0] != '/' {
+		return nil, false
+	}
+	name := strings.ToLower(fields[0][1:])
+	args := fields[1:]
+	intent = &nlu.IntentResult{Confidence: 1, Entities: map[string]string{}}
+
+	switch name {
+	case "harga", "price":
+		if len(args) == 0 {
+			intent.Intent = "catalog_all"
+			break
+		}
+		query := strings.Join(args, " ")
+		intent.Intent = "price_lookup"
+		intent.Entities["product_query"] = query
+		intent.Entities["product_type"] = defaultProductType(query)
+	case "beli", "buy":
+		intent.Intent = "create_prepaid"
+		if len(args) > 0 {
+			intent.Entities["product_code"] = strings.ToUpper(args[0])
+		}
+		if len(args) > 1 {
+			intent.Entities["customer_id"] = args[1]
+		}
+		for _, arg := range args[min(len(args), 2):] {
+			if method := commandPaymentMethod(arg); method != "" {
+				intent.Entities["payment_method"] = method
+			} else if zone := strings.Trim(arg, "()"); isDigits(zone) {
+				intent.Entities["customer_zone"] = zone
+			}
+		}
+	case "tambah", "add":
+		intent.Intent = "add_to_cart"
+		if len(args) > 0 {
+			intent.Entities["product_code"] = strings.ToUpper(args[0])
+		}
+		if len(args) > 1 {
+			intent.Entities["customer_id"] = args[1]
+		}
+		if len(args) > 2 {
+			if zone := strings.Trim(args[2], "()"); isDigits(zone) {
+				intent.Entities["customer_zone"] = zone
+			}
+		}
+	case "keranjang", "cart":
+		intent.Intent = "view_cart"
+		if len(args) > 0 {
+			switch strings.ToLower(args[0]) {
+			case "hapus", "remove":
+				intent.Intent = "remove_cart_item"
+				if len(args) > 1 {
+					intent.Entities["index"] = args[1]
+				}
+			case "kosongkan", "kosong", "clear":
+				intent.Intent = "clear_cart"
+			}
+		}
+	case "checkout":
+		intent.Intent = "checkout_cart"
+		if len(args) > 0 {
+			intent.Entities["payment_method"] = strings.ToLower(args[0])
+		}
+	case "langganan", "subscription":
+		intent.Intent = "list_schedules"
+		switch {
+		case len(args) > 0 && (strings.EqualFold(args[0], "batal") || strings.EqualFold(args[0], "cancel")):
+			intent.Intent = "cancel_schedule"
+			if len(args) > 1 {
+				intent.Entities["index"] = args[1]
+			}
+		case len(args) > 1:
+			intent.Intent = "schedule_order"
+			intent.Entities["product_code"] = strings.ToUpper(args[0])
+			intent.Entities["customer_id"] = args[1]
+			intent.Entities["schedule"] = strings.Join(args[2:], " ")
+		}
+	case "pantau", "watch":
+		intent.Intent = "list_product_alerts"
+		switch {
+		case len(args) > 0 && (strings.EqualFold(args[0], "batal") || strings.EqualFold(args[0], "cancel")):
+			intent.Intent = "cancel_product_alert"
+			if len(args) > 1 {
+				intent.Entities["index"] = args[1]
+			}
+		case len(args) > 0:
+			intent.Intent = "product_alert"
+			intent.Entities["product_code"] = strings.ToUpper(args[0])
+			if len(args) > 1 {
+				intent.Entities["target_price"] = args[1]
+			}
+		}
+	case "bayarulang", "repay":
+		intent.Intent = "regenerate_deposit"
+		if len(args) > 0 {
+			intent.Entities["ref_id"] = args[0]
+		}
+	case "riwayat", "history":
+		intent.Intent = "order_history"
+		if len(args) > 0 {
+			intent.Entities["page"] = args[0]
+		}
+	case "reset":
+		intent.Intent = "reset_context"
+	case "operator", "cs":
+		intent.Intent = "talk_to_human"
+	case "bahasa", "indonesia":
+		intent.Intent = "set_language"
+		intent.Entities["language"] = "id"
+		if len(args) > 0 {
+			intent.Entities["language"] = strings.ToLower(args[0])
+		}
+	case "english", "language":
+		intent.Intent = "set_language"
+		intent.Entities["language"] = "en"
+		if len(args) > 0 {
+			intent.Entities["language"] = strings.ToLower(args[0])
+		}
+	case "format":
+		intent.Intent = "currency_format"
+		if len(args) > 0 {
+			intent.Entities["currency_locale"] = strings.ToLower(args[0])
+		}
+	case "saldo", "balance":
+		intent.Intent = "check_balance"
+	case "transfer", "kirim":
+		intent.Intent = "create_transfer"
+	case "status":
+		intent.Intent = "check_status"
+		if len(args) > 0 {
+			intent.Entities["ref_id"] = args[0]
+		}
+	default:
+		intent.Intent = "help"
+	}
+	return intent, true
+}
+
+func commandPaymentMethod(arg string) string {
+	switch strings.ToLower(arg) {
+	case "saldo", "deposit":
+		return "deposit"
+	case "qris", "qr":
+		return "qris"
+	case "bri", "bank":
+		return "bri"
+	default:
+		return ""
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
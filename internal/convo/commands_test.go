@@ -0,0 +1,62 @@
+package convo
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		ok       bool
+		intent   string
+		entities map[string]string
+	}{
+		{text: "harga pulsa", ok: false},
+		{text: "/", ok: false},
+		{text: "/harga", ok: true, intent: "catalog_all"},
+		{text: "/harga pulsa telkomsel", ok: true, intent: "price_lookup", entities: map[string]string{"product_query": "pulsa telkomsel"}},
+		{text: "/beli tsel25 081234567890 qris", ok: true, intent: "create_prepaid", entities: map[string]string{
+			"product_code": "TSEL25", "customer_id": "081234567890", "payment_method": "qris",
+		}},
+		{text: "/BELI ML3 69827740 (2126) saldo", ok: true, intent: "create_prepaid", entities: map[string]string{
+			"product_code": "ML3", "customer_id": "69827740", "customer_zone": "2126", "payment_method": "deposit",
+		}},
+		{text: "/tambah tsel10 081234567890", ok: true, intent: "add_to_cart", entities: map[string]string{"product_code": "TSEL10", "customer_id": "081234567890"}},
+		{text: "/keranjang", ok: true, intent: "view_cart"},
+		{text: "/keranjang hapus 2", ok: true, intent: "remove_cart_item", entities: map[string]string{"index": "2"}},
+		{text: "/keranjang kosongkan", ok: true, intent: "clear_cart"},
+		{text: "/checkout QRIS", ok: true, intent: "checkout_cart", entities: map[string]string{"payment_method": "qris"}},
+		{text: "/langganan", ok: true, intent: "list_schedules"},
+		{text: "/langganan batal 2", ok: true, intent: "cancel_schedule", entities: map[string]string{"index": "2"}},
+		{text: "/langganan tsel25 081234567890 tiap tanggal 1", ok: true, intent: "schedule_order", entities: map[string]string{"product_code": "TSEL25", "customer_id": "081234567890", "schedule": "tiap tanggal 1"}},
+		{text: "/pantau", ok: true, intent: "list_product_alerts"},
+		{text: "/pantau ml86 20rb", ok: true, intent: "product_alert", entities: map[string]string{"product_code": "ML86", "target_price": "20rb"}},
+		{text: "/pantau batal 1", ok: true, intent: "cancel_product_alert", entities: map[string]string{"index": "1"}},
+		{text: "/saldo", ok: true, intent: "check_balance"},
+		{text: "/reset", ok: true, intent: "reset_context"},
+		{text: "/operator", ok: true, intent: "talk_to_human"},
+		{text: "/riwayat 2", ok: true, intent: "order_history", entities: map[string]string{"page": "2"}},
+		{text: "/format EN", ok: true, intent: "currency_format", entities: map[string]string{"currency_locale": "en"}},
+		{text: "/english", ok: true, intent: "set_language", entities: map[string]string{"language": "en"}},
+		{text: "/bahasa", ok: true, intent: "set_language", entities: map[string]string{"language": "id"}},
+		{text: "/language ID", ok: true, intent: "set_language", entities: map[string]string{"language": "id"}},
+		{text: " /status INV123 ", ok: true, intent: "check_status", entities: map[string]string{"ref_id": "INV123"}},
+		{text: "/bayarulang trx-0123456789abcdef", ok: true, intent: "regenerate_deposit", entities: map[string]string{"ref_id": "trx-0123456789abcdef"}},
+		{text: "/apaini", ok: true, intent: "help"},
+	}
+	for _, tt := range tests {
+		intent, ok := parseCommand(tt.text)
+		if ok != tt.ok {
+			t.Fatalf("parseCommand(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		if intent.Intent != tt.intent {
+			t.Fatalf("parseCommand(%q) intent = %q, want %q", tt.text, intent.Intent, tt.intent)
+		}
+		for k, want := range tt.entities {
+			if got := intent.Entities[k]; got != want {
+				t.Fatalf("parseCommand(%q) %s = %q, want %q", tt.text, k, got, want)
+			}
+		}
+	}
+}
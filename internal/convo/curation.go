@@ -0,0 +1,129 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+// curationTTL bounds how long admin edits take to reach catalog output.
+const curationTTL = time.Minute
+
+type curationRules struct {
+	hiddenCodes      map[string]bool
+	hiddenCategories map[string]bool
+	// codeInstructions and categoryInstructions hold purchase instructions;
+	// a product's own entry wins over its category's.
+	codeInstructions     map[string]string
+	categoryInstructions map[string]string
+}
+
+type curationCache struct {
+	rules   curationRules
+	expires time.Time
+}
+
+func (e *Engine) curationRules(ctx context.Context) curationRules {
+	e.mu.RLock()
+	cached := e.curation
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.rules
+	}
+
+	entries, err := e.repo.ListCuratedProducts(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading product curation", "error", err)
+		return cached.rules
+	}
+	rules := buildCurationRules(entries)
+
+	e.mu.Lock()
+	e.curation = curationCache{rules: rules, expires: time.Now().Add(curationTTL)}
+	e.mu.Unlock()
+	return rules
+}
+
+func buildCurationRules(entries []repo.CuratedProduct) curationRules {
+	rules := curationRules{
+		hiddenCodes:          map[string]bool{},
+		hiddenCategories:     map[string]bool{},
+		codeInstructions:     map[string]string{},
+		categoryInstructions: map[string]string{},
+	}
+	for _, entry := range entries {
+		instructions := ""
+		if entry.Instructions != nil {
+			instructions = strings.TrimSpace(*entry.Instructions)
+		}
+		switch entry.Scope {
+		case repo.CurationScopeProduct:
+			code := strings.ToUpper(strings.TrimSpace(entry.Key))
+			if entry.Hidden {
+				rules.hiddenCodes[code] = true
+			}
+			if instructions != "" {
+				rules.codeInstructions[code] = instructions
+			}
+		case repo.CurationScopeCategory:
+			category := strings.ToLower(strings.TrimSpace(entry.Key))
+			if entry.Hidden {
+				rules.hiddenCategories[category] = true
+			}
+			if instructions != "" {
+				rules.categoryInstructions[category] = instructions
+			}
+		}
+	}
+	return rules
+}
+
+// instructionsFor returns the purchase instructions configured for item.
+func (r curationRules) instructionsFor(item atl.PriceListItem) string {
+	if text, ok := r.codeInstructions[strings.ToUpper(strings.TrimSpace(item.Code))]; ok {
+		return text
+	}
+	return r.categoryInstructions[strings.ToLower(strings.TrimSpace(item.Category))]
+}
+
+// productInstructions returns the purchase instructions for item, if any.
+func (e *Engine) productInstructions(ctx context.Context, item atl.PriceListItem) string {
+	return e.curationRules(ctx).instructionsFor(item)
+}
+
+// recordInstructions keeps instructions in order metadata so later status
+// updates, including webhooks, can append them as well.
+func recordInstructions(meta map[string]any, instructions string) {
+	if instructions != "" {
+		meta["instructions"] = instructions
+	}
+}
+
+// withInstructions appends purchase instructions to a success message.
+func withInstructions(user *repo.User, msg, instructions string) string {
+	if instructions == "" {
+		return msg
+	}
+	return msg + "\n\n" + localize(user, msgProductInfo) + "\n" + instructions
+}
+
+// applyCuration drops products the operator has hidden, either by code or by category.
+func applyCuration(items []atl.PriceListItem, rules curationRules) []atl.PriceListItem {
+	if len(rules.hiddenCodes) == 0 && len(rules.hiddenCategories) == 0 {
+		return items
+	}
+	res := make([]atl.PriceListItem, 0, len(items))
+	for _, item := range items {
+		if rules.hiddenCodes[strings.ToUpper(strings.TrimSpace(item.Code))] {
+			continue
+		}
+		if rules.hiddenCategories[strings.ToLower(strings.TrimSpace(item.Category))] {
+			continue
+		}
+		res = append(res, item)
+	}
+	return res
+}
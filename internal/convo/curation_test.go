@@ -0,0 +1,32 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+func TestCurationInstructions(t *testing.T) {
+	str := func(s string) *string { return &s }
+	rules := buildCurationRules([]repo.CuratedProduct{
+		{Scope: repo.CurationScopeCategory, Key: "PLN", Instructions: str("Token masuk maksimal 1x24 jam.")},
+		{Scope: repo.CurationScopeProduct, Key: "pln20", Instructions: str("Masukkan token di meteran.")},
+		{Scope: repo.CurationScopeProduct, Key: "GP10", Hidden: true},
+	})
+
+	if got := rules.instructionsFor(atl.PriceListItem{Code: "PLN20", Category: "pln"}); got != "Masukkan token di meteran." {
+		t.Fatalf("product instructions = %q", got)
+	}
+	if got := rules.instructionsFor(atl.PriceListItem{Code: "PLN50", Category: "PLN"}); got != "Token masuk maksimal 1x24 jam." {
+		t.Fatalf("category instructions = %q", got)
+	}
+	if got := rules.instructionsFor(atl.PriceListItem{Code: "ML86", Category: "games"}); got != "" {
+		t.Fatalf("unexpected instructions %q", got)
+	}
+
+	items := applyCuration([]atl.PriceListItem{{Code: "PLN20", Category: "pln"}, {Code: "GP10"}}, rules)
+	if len(items) != 1 || items[0].Code != "PLN20" {
+		t.Fatalf("instructions-only entries must not hide products: %v", items)
+	}
+}
@@ -0,0 +1,70 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// currencyLocale picks how amounts are written for the user: their /format
+// choice, else their language preference. A nil user gets the shop default.
+func (e *Engine) currencyLocale(user *repo.User) money.Locale {
+	tag := ""
+	if user != nil {
+		tag = user.NumberLocale()
+	}
+	return money.LocaleFor(tag, e.cfg.CurrencySymbolSpace)
+}
+
+// adminCurrencyLocale is how amounts are written in admin alerts, which are
+// always in Indonesian whatever the customer's preference.
+func (e *Engine) adminCurrencyLocale() money.Locale {
+	return money.LocaleFor("id", e.cfg.CurrencySymbolSpace)
+}
+
+// userCurrencyLocale is currencyLocale for notifications that only carry the
+// user's ID.
+func (e *Engine) userCurrencyLocale(ctx context.Context, userID string) money.Locale {
+	if userID == "" {
+		return e.currencyLocale(nil)
+	}
+	user, err := e.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		e.logger.Warn("failed loading user currency locale", "error", err, "user_id", userID)
+		return e.currencyLocale(nil)
+	}
+	return e.currencyLocale(user)
+}
+
+func formatCurrency(locale money.Locale, value money.Money) string {
+	return value.Format(locale)
+}
+
+// handleCurrencyFormat stores the user's preferred number format; "auto"
+// goes back to following their language.
+func (e *Engine) handleCurrencyFormat(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	choice := strings.ToLower(strings.TrimSpace(intent.Entities["currency_locale"]))
+	var locale *string
+	switch choice {
+	case "id", "en":
+		locale = &choice
+	case "auto":
+	default:
+		example := money.FromRupiah(1250000)
+		reply := localize(user, msgCurrencyFormatUsage,
+			formatCurrency(money.LocaleFor("id", e.cfg.CurrencySymbolSpace), example), formatCurrency(money.LocaleFor("en", e.cfg.CurrencySymbolSpace), example))
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "currency_format")
+	}
+	if err := e.repo.SetUserCurrencyLocale(ctx, user.ID, locale); err != nil {
+		return fmt.Errorf("set currency locale: %w", err)
+	}
+	user.CurrencyLocale = locale
+	reply := localize(user, msgCurrencyFormatSet, formatCurrency(e.currencyLocale(user), money.FromRupiah(1250000)))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "currency_format")
+}
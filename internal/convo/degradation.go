@@ -0,0 +1,74 @@
+package convo
+
+import (
+	"bot-jual/internal/atl"
+)
+
+// Dependencies the engine degrades around, as used in metric labels.
+const (
+	dependencyNLU      = "nlu"
+	dependencyAtlantic = "atlantic"
+	dependencyRedis    = "redis"
+)
+
+// Degradation policy: each outage keeps the bot answering with a reduced but
+// honest service instead of generic errors.
+//   - NLU down: rule-based intents; free text that no rule understands gets
+//     the slash command list.
+//   - Atlantic down: price lists come from the last known data with a notice
+//     and purchases are held, keeping the draft so the user can resume,
+//     unless the supplier router has another supplier to send them to.
+//   - Redis down: caching is skipped and sessions are kept in the database,
+//     or process memory if that fails too; users are not told because
+//     nothing they see changes.
+const (
+	nluUnavailableMessage  = "Maaf, asisten pintarku sedang gangguan jadi pesan bebas belum bisa kupahami. Sementara pakai perintah cepat ya:\n\n" + commandHelp
+	staleCatalogNotice     = "⚠️ Supplier sedang gangguan. Ini harga dari data terakhir, harga mungkin berubah.\n\n"
+	cachedCatalogNotice    = "Data harga sementara (cache):\n"
+	purchaseHeldMessage    = "Maaf, supplier sedang gangguan jadi pembelian ditahan sementara. Pesananmu sudah kusimpan, coba balas lagi beberapa menit lagi ya."
+	billPaymentHeldMessage = "Maaf, supplier sedang gangguan jadi pembayaran tagihan ditahan sementara. Coba lagi beberapa menit lagi ya."
+	providerDownMessage    = "Maaf, provider sedang gangguan. Coba lagi beberapa menit lagi ya."
+)
+
+// SetAtlanticProbe lets the engine hold purchases while the supplier health
+// probe reports Atlantic down.
+func (e *Engine) SetAtlanticProbe(probe *atl.HealthProbe) {
+	e.atlProbe = probe
+}
+
+// atlanticDown reports whether the latest supplier probe failed or the
+// transaction endpoint's circuit breaker is open.
+func (e *Engine) atlanticDown() bool {
+	if e.atl != nil && e.atl.BreakerOpen("/transaksi/create") {
+		return true
+	}
+	if e.atlProbe == nil {
+		return false
+	}
+	st := e.atlProbe.Status()
+	return !st.CheckedAt.IsZero() && !st.Up
+}
+
+// cacheAvailable reports whether Redis can be used right now.
+func (e *Engine) cacheAvailable() bool {
+	return e.cache != nil && e.cache.Available()
+}
+
+// degraded counts a reply served in degraded mode because dependency is down.
+func (e *Engine) degraded(dependency string) {
+	if e.metrics != nil {
+		e.metrics.DegradedResponses.WithLabelValues(dependency).Inc()
+	}
+}
+
+// priceListNotice prefixes price replies that may not reflect live supplier prices.
+func (e *Engine) priceListNotice(cached bool) string {
+	if e.atlanticDown() {
+		e.degraded(dependencyAtlantic)
+		return staleCatalogNotice
+	}
+	if cached {
+		return cachedCatalogNotice
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// draftOrder is a purchase being assembled over several messages. Follow-ups
+// such as "ganti nomornya" or "pakai kode promo" edit it until the user picks
+// a payment method, which creates the real order.
+type draftOrder struct {
+	ProductCode  string `json:"product_code"`
+	ProductName  string `json:"product_name,omitempty"`
+	ProductType  string `json:"product_type,omitempty"`
+	CustomerID   string `json:"customer_id,omitempty"`
+	CustomerZone string `json:"customer_zone,omitempty"`
+	Quantity     int    `json:"quantity,omitempty"`
+	// Price is the quoted price in whole rupiah; the order re-checks it before charging.
+	Price       int64  `json:"price,omitempty"`
+	Voucher     string `json:"voucher,omitempty"`
+	CustomerRef string `json:"customer_ref,omitempty"`
+	Note        string `json:"note,omitempty"`
+	// PLN is the customer a PLN token's meter number resolved to.
+	PLN plnCustomer `json:"pln"`
+	// Targets lists every number of an order for several numbers;
+	// CustomerID is the first of them.
+	Targets []string `json:"targets,omitempty"`
+}
+
+func newDraftOrder(item *atl.PriceListItem, productType, customerID, customerZone string, ann orderAnnotations) *draftOrder {
+	return &draftOrder{
+		ProductCode:  item.Code,
+		ProductName:  item.Name,
+		ProductType:  productType,
+		CustomerID:   customerID,
+		CustomerZone: customerZone,
+		Quantity:     1,
+		Price:        item.Price.Rupiah(),
+		Voucher:      ann.Voucher,
+		CustomerRef:  ann.CustomerRef,
+		Note:         ann.Note,
+		PLN:          ann.PLN,
+	}
+}
+
+// replacedBy reports whether entities name a different product than the draft.
+func (d *draftOrder) replacedBy(entities map[string]string) bool {
+	if code := strings.TrimSpace(entities["product_code"]); code != "" {
+		return !strings.EqualFold(code, d.ProductCode)
+	}
+	return strings.TrimSpace(entities["product_query"]) != ""
+}
+
+// fill copies draft fields into entities the current message left empty.
+func (d *draftOrder) fill(entities map[string]string) {
+	set := func(key, value string) {
+		if value != "" && strings.TrimSpace(entities[key]) == "" {
+			entities[key] = value
+		}
+	}
+	if strings.TrimSpace(entities["product_query"]) == "" {
+		set("product_code", d.ProductCode)
+		set("product_type", d.ProductType)
+	}
+	if strings.TrimSpace(entities["customer_id"]) == "" {
+		// A new target brings its own zone; only reuse the zone with the old target.
+		set("customer_id", d.CustomerID)
+		set("customer_zone", d.CustomerZone)
+		if len(d.Targets) > 1 {
+			set("targets", strings.Join(d.Targets, ","))
+		}
+	}
+	if d.Quantity > 1 {
+		set("quantity", strconv.Itoa(d.Quantity))
+	}
+	set("voucher_code", d.Voucher)
+	set("customer_ref", d.CustomerRef)
+	set("order_note", d.Note)
+	d.PLN.toEntities(entities)
+}
+
+// summary renders the draft for the confirmation prompt.
+func (d *draftOrder) summary(user *repo.User, locale money.Locale) string {
+	var sb strings.Builder
+	sb.WriteString(localize(user, msgDraftHeader) + "\n")
+	sb.WriteString(localize(user, msgDraftProduct, d.ProductName, d.ProductCode) + "\n")
+	target := d.CustomerID
+	if d.CustomerZone != "" && !strings.Contains(target, "(") {
+		target = fmt.Sprintf("%s(%s)", target, d.CustomerZone)
+	}
+	if len(d.Targets) > 1 {
+		sb.WriteString(localize(user, msgDraftTargets, len(d.Targets), strings.Join(d.Targets, ", ")) + "\n")
+	} else {
+		sb.WriteString(localize(user, msgDraftTarget, target) + "\n")
+	}
+	if label := d.PLN.label(); label != "" && d.PLN.Meter == d.CustomerID {
+		sb.WriteString(localize(user, msgDraftCustomerName, label) + "\n")
+	}
+	switch {
+	case len(d.Targets) > 1:
+		sb.WriteString(localize(user, msgDraftUnitPrice, len(d.Targets), formatCurrency(locale, money.FromRupiah(d.Price))) + "\n")
+		sb.WriteString(localize(user, msgDraftTotal, formatCurrency(locale, money.FromRupiah(d.Price*int64(len(d.Targets))))))
+	case d.Quantity > 1:
+		sb.WriteString(localize(user, msgDraftQuantity, d.Quantity, formatCurrency(locale, money.FromRupiah(d.Price))) + "\n")
+		sb.WriteString(localize(user, msgDraftTotal, formatCurrency(locale, money.FromRupiah(d.Price*int64(d.Quantity)))))
+	default:
+		sb.WriteString(localize(user, msgDraftPrice, formatCurrency(locale, money.FromRupiah(d.Price))))
+	}
+	if d.Voucher != "" {
+		// The shop has no promo codes yet, so say so rather than imply a discount.
+		sb.WriteString("\n" + localize(user, msgDraftVoucher, d.Voucher))
+	}
+	if d.CustomerRef != "" {
+		sb.WriteString("\n" + localize(user, msgDraftRef, d.CustomerRef))
+	}
+	if d.Note != "" {
+		sb.WriteString("\n" + localize(user, msgDraftNote, d.Note))
+	}
+	return sb.String()
+}
+
+// draftQuantity reads the requested quantity, defaulting to one.
+func draftQuantity(entities map[string]string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(entities["quantity"]))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// applyDraft merges the user's pending draft into a purchase intent. A
+// create_prepaid for another product starts over, while update_draft always
+// edits the draft. ok is false when update_draft finds nothing to edit.
+func (e *Engine) applyDraft(ctx context.Context, userID string, intent *nlu.IntentResult) (ok bool) {
+	if intent.Entities == nil {
+		intent.Entities = map[string]string{}
+	}
+	draft := e.loadSession(ctx, userID).Draft
+	if draft == nil {
+		return intent.Intent != "update_draft"
+	}
+	if intent.Intent != "update_draft" && draft.replacedBy(intent.Entities) {
+		return true
+	}
+	draft.fill(intent.Entities)
+	return true
+}
+
+// storeDraft saves the draft in the user's session at step, the flow step
+// waiting on the user's next reply; a nil draft returns the flow to idle.
+func (e *Engine) storeDraft(ctx context.Context, userID string, draft *draftOrder, step flowStep) {
+	s := e.loadSession(ctx, userID)
+	if s.Draft == nil && draft == nil {
+		return
+	}
+	if draft == nil {
+		step = flowIdle
+	}
+	if !s.advance(step) {
+		e.logger.Warn("unexpected purchase flow step", "user_id", userID, "from", s.Step, "to", step)
+		s.Step = step
+	}
+	s.Draft = draft
+	e.saveSession(ctx, userID, s)
+}
+
+// parseDraftReply recognises a bare payment method or "batal" sent while a
+// draft is pending, so the final step of an order needs no Gemini call.
+func (e *Engine) parseDraftReply(ctx context.Context, userID, text string) (*nlu.IntentResult, bool) {
+	word := strings.ToLower(strings.Trim(strings.TrimSpace(text), ".!"))
+	method := commandPaymentMethod(word)
+	cancel := word == "batal" || word == "cancel" || word == "gak jadi" || word == "ga jadi"
+	if method == "" && !cancel {
+		return nil, false
+	}
+	if e.loadSession(ctx, userID).Draft == nil {
+		return nil, false
+	}
+	if cancel {
+		return &nlu.IntentResult{Intent: "cancel_order", Confidence: 1, Entities: map[string]string{}}, true
+	}
+	return &nlu.IntentResult{Intent: "update_draft", Confidence: 1, Entities: map[string]string{"payment_method": method}}, true
+}
+
+func (e *Engine) handleCancelOrder(ctx context.Context, evt *events.Message, user *repo.User) error {
+	draft := e.loadSession(ctx, user.ID).Draft
+	if draft == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgCancelOrderNone), "cancel_order_none")
+	}
+	e.storeDraft(ctx, user.ID, nil, flowIdle)
+	reply := localize(user, msgCancelOrder, draft.ProductName, draft.ProductCode)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "cancel_order")
+}
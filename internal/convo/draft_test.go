@@ -0,0 +1,56 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+)
+
+func TestDraftOrderEdits(t *testing.T) {
+	e := &Engine{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), sessions: map[string]sessionEntry{}}
+	ctx := context.Background()
+	e.storeDraft(ctx, "u1", &draftOrder{ProductCode: "ML86", ProductName: "MLBB 86 Diamonds", CustomerID: "12345678", CustomerZone: "1234", Quantity: 1}, flowConfirm)
+
+	change := &nlu.IntentResult{Intent: "update_draft", Entities: map[string]string{"customer_id": "87654321(4321)", "voucher_code": "HEMAT"}}
+	if !e.applyDraft(ctx, "u1", change) {
+		t.Fatalf("update_draft did not find the draft")
+	}
+	if got := change.Entities; got["product_code"] != "ML86" || got["customer_id"] != "87654321(4321)" || got["customer_zone"] != "" || got["voucher_code"] != "HEMAT" {
+		t.Fatalf("merged entities = %v", got)
+	}
+
+	other := &nlu.IntentResult{Intent: "create_prepaid", Entities: map[string]string{"product_code": "FF70"}}
+	e.applyDraft(ctx, "u1", other)
+	if other.Entities["customer_id"] != "" {
+		t.Fatalf("a new product inherited the old draft: %v", other.Entities)
+	}
+
+	if intent, ok := e.parseDraftReply(ctx, "u1", "QRIS"); !ok || intent.Intent != "update_draft" || intent.Entities["payment_method"] != "qris" {
+		t.Fatalf("parseDraftReply(QRIS) = %+v, %v", intent, ok)
+	}
+	if intent, ok := e.parseDraftReply(ctx, "u1", "batal"); !ok || intent.Intent != "cancel_order" {
+		t.Fatalf("parseDraftReply(batal) = %+v, %v", intent, ok)
+	}
+	if _, ok := e.parseDraftReply(ctx, "u2", "qris"); ok {
+		t.Fatalf("payment reply resolved without a draft")
+	}
+	if e.applyDraft(ctx, "u2", &nlu.IntentResult{Intent: "update_draft", Entities: map[string]string{}}) {
+		t.Fatalf("update_draft without a draft should fail")
+	}
+}
+
+func TestDraftSummarySaysPromoCodeNotApplied(t *testing.T) {
+	d := &draftOrder{ProductCode: "ML86", ProductName: "MLBB 86 Diamonds", CustomerID: "12345678", Price: 20000, Voucher: "HEMAT"}
+	got := d.summary(nil, money.Indonesian)
+	if !strings.Contains(got, "HEMAT belum bisa dipakai") || strings.Contains(got, "Kode promo: HEMAT") {
+		t.Fatalf("summary = %q", got)
+	}
+	if suffix := (orderAnnotations{Voucher: "HEMAT"}).replySuffix(); suffix != "" {
+		t.Fatalf("replySuffix = %q, want the promo code left out", suffix)
+	}
+}
@@ -1,6 +1,7 @@
 package convo
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -21,8 +22,12 @@ import (
 	"bot-jual/internal/atl"
 	"bot-jual/internal/cache"
 	"bot-jual/internal/metrics"
+	"bot-jual/internal/money"
 	"bot-jual/internal/nlu"
+	"bot-jual/internal/persona"
+	"bot-jual/internal/receipt"
 	"bot-jual/internal/repo"
+	"bot-jual/internal/supplier"
 	"bot-jual/internal/wa"
 
 	"github.com/google/uuid"
@@ -44,6 +49,9 @@ type Engine struct {
 	repo          repo.Repository
 	nlu           *nlu.Client
 	atl           *atl.Client
+	payments      PaymentProvider
+	paymentName   string
+	suppliers     *supplier.Router
 	gateway       WhatsAppGateway
 	cache         *cache.Redis
 	metrics       *metrics.Metrics
@@ -52,6 +60,34 @@ type Engine struct {
 	mu            sync.RWMutex
 	priceCache    map[string]priceCacheEntry
 	priceCacheTTL time.Duration
+	// supplierQuotes holds the price lists the margin check forced fresh,
+	// so back-to-back purchases share one supplier round trip.
+	supplierQuotes map[string]priceCacheEntry
+	aliasCache     catalogAliasCache
+	curation       curationCache
+	pricing        pricingCache
+	naming         namingCache
+	productStats   productStatsCache
+	// flashSaleCache holds running and upcoming flash sales.
+	flashSaleCache flashSaleCache
+	// catalogPins and catalogVersions track admin-pinned snapshots and the
+	// latest recorded snapshot per product type.
+	catalogPins     map[string]catalogPin
+	catalogVersions map[string]catalogVersion
+	// sessions backs loadSession when Redis is not configured or down.
+	sessions map[string]sessionEntry
+	// purchaseLocks backs lockPurchase when Redis is not configured or down.
+	purchaseLocks map[string]time.Time
+	atlProbe      *atl.HealthProbe
+	// contacts backs the contacts-only sender policy.
+	contacts          ContactChecker
+	senderPolicyCache senderPolicyCache
+	// events publishes order events to merchant webhooks.
+	events EventNotifier
+	// depositSettler applies deposits the expiry check finds paid.
+	depositSettler atl.WebhookProcessor
+	// bankList caches the banks and e-wallets transfers can go to.
+	bankList transferBankCache
 }
 
 // EngineConfig groups optional knobs for conversation logic.
@@ -60,21 +96,73 @@ type EngineConfig struct {
 	DefaultDepositType   string
 	DepositFeeFixed      int64
 	DepositFeePercent    float64
+	WithdrawMinAmount    int64
+	WithdrawFee          int64
+	WithdrawDailyLimit   int64
+	// WithdrawApprovalThreshold holds withdrawals of at least this amount
+	// for an admin decision; zero disables approvals.
+	WithdrawApprovalThreshold int64
+	ApprovalTTL               time.Duration
+	AdminJIDs                 []string
+	MinMargin                 int64
+	Persona                   persona.Persona
+	LowSuccessRate            float64
+	LowSuccessMinSamples      int64
+	// MemorySummaryInterval is how often user profiles are re-summarised
+	// once a user has MemoryMinMessages new messages; zero disables it.
+	MemorySummaryInterval time.Duration
+	MemoryMinMessages     int
+	// Receipts builds public receipt links for order confirmations; nil omits them.
+	Receipts *receipt.Links
+	// CampaignAttributionWindow tags orders placed this soon after a campaign
+	// message with that campaign; zero tags them by the user's source only.
+	CampaignAttributionWindow time.Duration
+	// CurrencySymbolSpace writes amounts as "Rp 10.000" instead of "Rp10.000".
+	CurrencySymbolSpace bool
+	// InteractiveMenus sends product and payment choices as tappable lists
+	// and buttons when the gateway supports them. Off unless
+	// WHATSAPP_INTERACTIVE=true.
+	InteractiveMenus bool
+	// ReceiptDocuments follows successful purchase replies with a PDF receipt
+	// when the gateway can send documents.
+	ReceiptDocuments bool
+	// PriceCacheTTL is how long fetched price lists are reused; zero means
+	// five minutes.
+	PriceCacheTTL time.Duration
+	// TrustLevels cap single orders by the user's successful order count;
+	// empty disables the caps.
+	TrustLevels []TrustLevel
+	// TrustProductMinOrders holds products back, keyed by lowercase product
+	// code or type, until a user has this many successful orders.
+	TrustProductMinOrders map[string]int
+	// DepositReminderAfter is how long a deposit may wait for payment before
+	// the user is reminded of it; zero sends no reminders.
+	DepositReminderAfter time.Duration
+	// DepositExpireAfter is when an unpaid deposit is cancelled and the
+	// orders waiting on it are released; zero leaves deposits open.
+	DepositExpireAfter time.Duration
+	// PLNInquiryCode is the bill inquiry code that resolves a PLN meter
+	// number to its customer; empty inquires with the token's own code.
+	PLNInquiryCode string
 }
 
 // New creates a conversation engine instance.
 func New(repository repo.Repository, nluClient *nlu.Client, atlClient *atl.Client, gateway WhatsAppGateway, cache *cache.Redis, metrics *metrics.Metrics, logger *slog.Logger, cfg EngineConfig) *Engine {
 	return &Engine{
-		repo:          repository,
-		nlu:           nluClient,
-		atl:           atlClient,
-		gateway:       gateway,
-		cache:         cache,
-		metrics:       metrics,
-		logger:        logger.With("component", "convo"),
-		cfg:           cfg,
-		priceCache:    make(map[string]priceCacheEntry),
-		priceCacheTTL: 5 * time.Minute,
+		repo:            repository,
+		nlu:             nluClient,
+		atl:             atlClient,
+		gateway:         gateway,
+		cache:           cache,
+		metrics:         metrics,
+		logger:          logger.With("component", "convo"),
+		cfg:             cfg,
+		priceCache:      make(map[string]priceCacheEntry),
+		catalogPins:     make(map[string]catalogPin),
+		catalogVersions: make(map[string]catalogVersion),
+		sessions:        make(map[string]sessionEntry),
+		purchaseLocks:   make(map[string]time.Time),
+		priceCacheTTL:   cmp.Or(cfg.PriceCacheTTL, 5*time.Minute),
 	}
 }
 
@@ -96,12 +184,18 @@ func (e *Engine) ProcessMessage(ctx context.Context, evt *events.Message) {
 
 	senderJID := evt.Info.Sender.ToNonAD() // Strip device part (e.g. :38) to avoid "no device part" errors
 	evt.Info.Sender = senderJID            // Ensure all downstream handlers use the clean JID
+	if reason := e.blockedSender(ctx, evt); reason != "" {
+		e.metrics.SenderBlocked.WithLabelValues(reason).Inc()
+		e.logger.Info("ignoring message refused by sender policy", "from", senderJID.String(), "reason", reason)
+		return
+	}
 	text := extractText(evt)
 	pushName := strings.TrimSpace(evt.Info.PushName)
 	userProfile := repo.UserProfile{
-		WAID:        senderJID.String(),
+		WAID:        e.resolveWAID(ctx, evt),
 		WAJID:       toPtr(senderJID.String()),
 		DisplayName: optionalString(pushName),
+		PhoneNumber: optionalString(senderPhone(evt.Info.MessageSource)),
 	}
 
 	user, err := e.repo.UpsertUserByWA(ctx, userProfile)
@@ -110,47 +204,75 @@ func (e *Engine) ProcessMessage(ctx context.Context, evt *events.Message) {
 		return
 	}
 
+	// Voice notes go through the same pipeline as typed text once transcribed,
+	// so commands, selections and draft replies work when spoken too.
+	voiceNote := msgType == "audio"
+	if voiceNote {
+		text = e.transcribeVoiceNote(ctx, evt, user)
+	}
+
 	contextSummary, lastBot := e.buildConversationContext(ctx, user.ID)
+	userMemory := e.userMemory(ctx, user.ID)
+
+	acquisition, untagged := extractAcquisitionTag(text)
+	e.recordAcquisition(ctx, user.ID, acquisition)
+
+	// Everything sent while handling the message is audited against it.
+	ctx = wa.WithOrigin(ctx, wa.Origin{Trigger: wa.TriggerConvo, CorrelationID: string(evt.Info.ID)})
 
 	if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
 		UserID:    user.ID,
 		Direction: "incoming",
 		Type:      msgType,
-		Content:   optionalString(text),
+		Content:   optionalString(e.redactPIN(ctx, user.ID, text)),
 	}); err != nil {
 		e.logger.Warn("failed logging incoming message", "error", err)
 	}
+	if e.handedOff(ctx, evt, user) {
+		// An operator is handling this user; the message is logged for them.
+		e.logger.Debug("conversation handed to operator, not replying", "user_id", user.ID)
+		return
+	}
+	e.expireStaleFlow(ctx, evt, user)
+	if untagged != "" {
+		text = untagged
+	}
 
 	if text == "" {
-		e.handleNonText(ctx, evt, user)
+		if !voiceNote {
+			e.handleNonText(ctx, evt, user)
+		}
 		return
 	}
 
-	intent, err := e.nlu.DetectIntent(ctx, nlu.IntentInput{
-		UserMessage:       text,
-		Channel:           "whatsapp",
-		UserLocale:        user.LanguagePreference,
-		ContextSummary:    contextSummary,
-		LastBotMessage:    lastBot,
-		ConversationState: contextSummary,
-	})
-	if err != nil {
-		// Fallback: continue with heuristic parsing so critical flows (e.g., "Beli ML3 69827740 (2126)") still run.
-		e.logger.Warn("nlu intent detection failed, using heuristic fallback", "error", err)
-		intent = &nlu.IntentResult{
-			Entities: map[string]string{},
-		}
-		e.enrichIntentFromText(text, intent)
-		// Default to deposit when creating prepaid if method is not specified.
-		if strings.TrimSpace(strings.ToLower(intent.Intent)) == "create_prepaid" {
-			if strings.TrimSpace(intent.Entities["payment_method"]) == "" {
-				intent.Entities["payment_method"] = "deposit"
-			}
+	intent, isCommand := parseCommand(text)
+	if !isCommand {
+		intent, isCommand = e.parseTransferReply(ctx, user.ID, text)
+	}
+	if !isCommand {
+		intent, isCommand = e.parseSelection(ctx, user.ID, text)
+	}
+	if !isCommand {
+		intent, isCommand = parseCartAdd(text)
+	}
+	if !isCommand {
+		intent, isCommand = e.parseDraftReply(ctx, user.ID, text)
+	}
+	if !isCommand {
+		intent, isCommand = e.parseApprovalReply(evt.Info.MessageSource, text)
+	}
+	if !isCommand {
+		intent, isCommand = e.parseResumeReply(evt.Info.MessageSource, text)
+	}
+	if !isCommand {
+		quote := parseQuotedReference(evt, text)
+		channel := "whatsapp"
+		if voiceNote {
+			channel = "whatsapp_audio"
 		}
+		intent = e.detectTextIntent(e.withBusyNotice(ctx, evt), user, channel, text, contextSummary, lastBot, userMemory, quote.text)
+		applyQuotedReference(intent, quote)
 	}
-
-	e.mergeToolCallArguments(intent)
-	e.enrichIntentFromText(text, intent)
 	e.logger.Debug("resolved intent", "intent", intent.Intent, "entities", intent.Entities, "tool_call", intent.ToolCall)
 
 	// Group chat policy: only respond in group for sales-related intents.
@@ -163,6 +285,8 @@ func (e *Engine) ProcessMessage(ctx context.Context, evt *events.Message) {
 			"price_lookup":    true,
 			"budget_filter":   true,
 			"create_prepaid":  true,
+			"add_to_cart":     true,
+			"checkout_cart":   true,
 			"check_bill":      true,
 			"pay_bill":        true,
 			"check_status":    true,
@@ -209,11 +333,11 @@ func (e *Engine) ProcessMessage(ctx context.Context, evt *events.Message) {
 			}
 		}
 
-		stub := fmt.Sprintf("Saya menjual %s kak. Cek PM ya 🙏", topic)
+		stub := e.cfg.Persona.Apply(fmt.Sprintf("Saya menjual %s kak. Cek PM ya 🙏", topic))
 
-		if err := e.gateway.SendText(ctx, evt.Info.Chat, stub); err != nil {
+		if err := e.gateway.SendText(wa.WithOrigin(ctx, wa.Origin{UserID: user.ID, Category: "group_stub"}), evt.Info.Chat, stub); err != nil {
 			e.logger.Warn("failed sending group stub", "error", err)
-		} else {
+		} else if !e.outboundAudited() {
 			if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
 				UserID:    user.ID,
 				Direction: "outgoing",
@@ -247,30 +371,110 @@ func (e *Engine) ProcessMessage(ctx context.Context, evt *events.Message) {
 	}
 }
 
+// detectTextIntent resolves a text message through Gemini, falling back to
+// keyword heuristics when the call fails.
+func (e *Engine) detectTextIntent(ctx context.Context, user *repo.User, channel, text, contextSummary, lastBot, userMemory, quoted string) *nlu.IntentResult {
+	ctx = nlu.WithPriority(ctx, nluPriority(text, lastBot))
+	intent, err := e.nlu.DetectIntent(ctx, nlu.IntentInput{
+		UserMessage:       text,
+		Channel:           channel,
+		UserLocale:        user.LanguagePreference,
+		ContextSummary:    contextSummary,
+		LastBotMessage:    lastBot,
+		ConversationState: contextSummary,
+		UserProfile:       userMemory,
+		QuotedMessage:     truncateForPrompt(quoted, 300),
+	})
+	if err != nil {
+		// Fallback: continue with heuristic parsing so critical flows (e.g., "Beli ML3 69827740 (2126)") still run.
+		e.logger.Warn("nlu intent detection failed, using heuristic fallback", "error", err)
+		e.degraded(dependencyNLU)
+		intent = &nlu.IntentResult{
+			Entities: map[string]string{},
+		}
+		e.enrichIntentFromText(text, intent)
+		if intent.Intent == "fallback" {
+			intent.Intent = "nlu_unavailable"
+		}
+		// Default to deposit when creating prepaid if method is not specified.
+		if strings.TrimSpace(strings.ToLower(intent.Intent)) == "create_prepaid" {
+			if strings.TrimSpace(intent.Entities["payment_method"]) == "" {
+				intent.Entities["payment_method"] = "deposit"
+			}
+		}
+	}
+
+	e.mergeToolCallArguments(intent)
+	e.enrichIntentFromText(text, intent)
+	return intent
+}
+
 func (e *Engine) routeIntent(ctx context.Context, evt *events.Message, user *repo.User, text string, intent *nlu.IntentResult) error {
 	switch intent.Intent {
 	case "smalltalk_greeting", "smalltalk":
 		reply := intent.Reply
 		if reply == "" {
-			reply = "Halo! Aku menyediakan berbagai layanan digital:\n\n📱 *Pulsa & Paket Data* - Telkomsel, Indosat, XL, Tri, Smartfren\n🎮 *Top Up Game* - Mobile Legends, Free Fire, PUBG, dll\n⚡ *Token Listrik* - Prabayar & Pascabayar\n💳 *Bayar Tagihan* - PLN, PDAM, BPJS, dll\n💰 *Deposit & Transfer* - QRIS, Bank Transfer, E-wallet\n\nKetik nama produk yang kamu cari, contoh: \"pulsa telkomsel 20k\" atau \"top up ML\""
+			reply = localize(user, msgSmalltalk, e.greeting(user))
 		}
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "smalltalk")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, e.cfg.Persona.Sign(reply), "smalltalk")
 	case "price_lookup":
 		return e.handlePriceLookup(ctx, evt, user, text, intent)
 	case "budget_filter":
 		return e.handleBudgetFilter(ctx, evt, user, text, intent)
-	case "create_prepaid":
+	case "create_prepaid", "update_draft":
+		if intent.Intent == "create_prepaid" && hasSchedule(text) {
+			// "isi pulsa 25k tiap tanggal 1" is a subscription, not a purchase now.
+			return e.handleScheduleOrder(ctx, evt, user, text, intent)
+		}
 		return e.handleCreatePrepaid(ctx, evt, user, intent)
+	case "schedule_order":
+		return e.handleScheduleOrder(ctx, evt, user, text, intent)
+	case "list_schedules":
+		return e.handleListSchedules(ctx, evt, user)
+	case "cancel_schedule":
+		return e.handleCancelSchedule(ctx, evt, user, intent)
+	case "product_alert":
+		return e.handleProductAlert(ctx, evt, user, text, intent)
+	case "list_product_alerts":
+		return e.handleListProductAlerts(ctx, evt, user)
+	case "cancel_product_alert":
+		return e.handleCancelProductAlert(ctx, evt, user, intent)
+	case "cancel_order":
+		return e.handleCancelOrder(ctx, evt, user)
+	case "add_to_cart":
+		return e.handleAddToCart(ctx, evt, user, text, intent)
+	case "view_cart":
+		return e.handleViewCart(ctx, evt, user)
+	case "remove_cart_item":
+		return e.handleRemoveCartItem(ctx, evt, user, intent)
+	case "clear_cart":
+		return e.handleClearCart(ctx, evt, user)
+	case "checkout_cart":
+		return e.handleCheckoutCart(ctx, evt, user, intent)
+	case "admin_decision":
+		return e.handleAdminDecision(ctx, evt, user, intent)
+	case "talk_to_human":
+		return e.handleTalkToHuman(ctx, evt, user, text)
+	case "resume_bot":
+		return e.handleResumeBot(ctx, evt, user, intent)
 	case "check_bill":
 		return e.handleCheckBill(ctx, evt, user, intent)
 	case "pay_bill":
 		return e.handlePayBill(ctx, evt, user, intent)
 	case "check_status":
 		return e.handleCheckStatus(ctx, evt, user, intent)
+	case "regenerate_deposit":
+		return e.handleRegenerateDeposit(ctx, evt, user, intent)
 	case "create_deposit":
 		return e.handleCreateDeposit(ctx, evt, user, intent)
 	case "create_transfer":
-		return e.handleCreateTransfer(ctx, evt, user, intent)
+		return e.handleCreateTransfer(ctx, evt, user, text, intent)
+	case "transfer_reply":
+		return e.handleTransferReply(ctx, evt, user, intent)
+	case "withdraw_balance":
+		return e.handleWithdrawBalance(ctx, evt, user, text, intent)
+	case "check_account":
+		return e.handleCheckAccount(ctx, evt, user, text, intent)
 	case "catalog_all":
 		if strings.TrimSpace(intent.Entities["provider"]) != "" || strings.TrimSpace(intent.Entities["product_query"]) != "" {
 			return e.handlePriceLookup(ctx, evt, user, text, intent)
@@ -278,15 +482,27 @@ func (e *Engine) routeIntent(ctx context.Context, evt *events.Message, user *rep
 		return e.handleCatalogAll(ctx, evt, user)
 	case "check_balance":
 		return e.handleCheckBalance(ctx, evt, user)
+	case "order_history":
+		return e.handleOrderHistory(ctx, evt, user, intent)
 	case "payment_info":
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, paymentInfoMessage(), "payment_info")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPaymentInfo), "payment_info")
 	case "help":
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, helpMessage(), "help")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, e.cfg.Persona.Sign(localize(user, msgHelp)), "help")
+	case "reset_context":
+		return e.handleResetContext(ctx, evt, user)
+	case "currency_format":
+		return e.handleCurrencyFormat(ctx, evt, user, intent)
+	case "set_language":
+		return e.handleSetLanguage(ctx, evt, user, intent)
+	case "nlu_unavailable":
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgNLUUnavailable), "nlu_unavailable")
+	case "selection_expired":
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgSelectionExpired), "selection_expired")
 	default:
 		if intent.Reply != "" {
 			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, intent.Reply, "nlu_reply")
 		}
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Maaf, aku belum paham permintaanmu. Bisa jelaskan lagi?", "fallback")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgFallback), "fallback")
 	}
 }
 
@@ -309,15 +525,16 @@ func (e *Engine) handlePriceLookup(ctx context.Context, evt *events.Message, use
 	if err != nil {
 		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "price_lookup_fetch")
 	}
-	matches := filterByQuery(items, query, provider, fullRequest)
+	matches := filterByQuery(items, e.expandCatalogQuery(ctx, query), provider, fullRequest)
 	if len(matches) == 0 {
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Belum ketemu produk yang cocok. Coba sebutkan nama layanan lain ya.", "price_lookup_not_found")
 	}
-	reply := formatPriceList(matches, fullRequest)
-	if cached {
-		reply = "Data harga sementara (cache):\n" + reply
-	}
-	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "price_lookup")
+	locale := e.currencyLocale(user)
+	reply, codes := formatPriceList(matches, fullRequest, locale)
+	e.rememberSelections(ctx, user.ID, codes)
+	reply = e.priceListNotice(cached) + reply
+	body := e.priceListNotice(cached) + listPrompt
+	return e.respondWithList(ctx, evt.Info.Sender, user.ID, reply, body, priceListSections(matches, codes, locale), "price_lookup")
 }
 
 func (e *Engine) handleBudgetFilter(ctx context.Context, evt *events.Message, user *repo.User, rawText string, intent *nlu.IntentResult) error {
@@ -334,8 +551,8 @@ func (e *Engine) handleBudgetFilter(ctx context.Context, evt *events.Message, us
 	if productType == "" {
 		productType = "prabayar"
 	}
-	maxBudget, err := parseAmount(budgetStr)
-	if err != nil || maxBudget <= 0 {
+	budget, err := parseAmount(budgetStr)
+	if err != nil || budget.Max <= 0 {
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Angka budgetnya belum jelas. Tulis nominal seperti 5000 atau 20k ya.", "budget_invalid_amount")
 	}
 
@@ -346,17 +563,18 @@ func (e *Engine) handleBudgetFilter(ctx context.Context, evt *events.Message, us
 
 	// First filter by product query if provided, then apply budget cap
 	if query != "" {
-		items = filterByQuery(items, query, provider, true)
+		items = filterByQuery(items, e.expandCatalogQuery(ctx, query), provider, true)
 	}
-	matches := filterByBudget(items, maxBudget)
+	matches := filterByBudget(items, budget)
 	if len(matches) == 0 {
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Belum ada produk yang cocok dengan budget kamu. Coba tambah sedikit nominalnya ya.", "budget_not_found")
 	}
-	reply := formatPriceList(matches, false)
-	if cached {
-		reply = "Data harga sementara (cache):\n" + reply
-	}
-	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "budget_filter")
+	locale := e.currencyLocale(user)
+	reply, codes := formatPriceList(matches, false, locale)
+	e.rememberSelections(ctx, user.ID, codes)
+	reply = e.priceListNotice(cached) + reply
+	body := e.priceListNotice(cached) + listPrompt
+	return e.respondWithList(ctx, evt.Info.Sender, user.ID, reply, body, priceListSections(matches, codes, locale), "budget_filter")
 }
 
 func (e *Engine) handleCatalogAll(ctx context.Context, evt *events.Message, user *repo.User) error {
@@ -369,14 +587,22 @@ func (e *Engine) handleCatalogAll(ctx context.Context, evt *events.Message, user
 		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "catalog_all_pascabayar")
 	}
 	combined := append(prabayar, pascabayar...)
-	reply := formatCatalogSummary(combined)
-	if prabayarCached || pascaCached {
-		reply = "Data harga sementara (cache):\n" + reply
-	}
+	reply, codes := formatCatalogSummary(combined, e.currencyLocale(user))
+	e.rememberSelections(ctx, user.ID, codes)
+	reply = e.priceListNotice(prabayarCached || pascaCached) + reply
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "catalog_all")
 }
 
 func (e *Engine) handleCreatePrepaid(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	// Held from the draft lookup on, so a double-sent reply cannot consume the same draft twice.
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseBusy), "purchase_in_progress")
+	}
+	defer release()
+	if !e.applyDraft(ctx, user.ID, intent) {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgDraftMissing), "draft_missing")
+	}
 	productCode := strings.TrimSpace(intent.Entities["product_code"])
 	rawCustomerID := strings.TrimSpace(intent.Entities["customer_id"])
 	customerID := rawCustomerID
@@ -412,13 +638,18 @@ func (e *Engine) handleCreatePrepaid(ctx context.Context, evt *events.Message, u
 	if refID == "" {
 		refID = strings.TrimSpace(intent.Entities["reff_id"])
 	}
+	annotations := annotationsFromEntities(intent.Entities)
+	if annotations.CustomerRef != "" && strings.EqualFold(refID, annotations.CustomerRef) {
+		// The user's own reference is not unique across customers; keep our generated ref.
+		refID = ""
+	}
 
 	item, resolvedType, err := e.resolveProductFromQuery(ctx, productCode, productType, productQuery, provider)
 	if err != nil {
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, err.Error(), "prepaid_missing_product")
 	}
 	if item == nil {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Produk belum ditemukan. Sebutkan kode atau nama produk yang kamu inginkan ya.", "prepaid_missing_product")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgProductNotFound), "prepaid_missing_product")
 	}
 	if productCode == "" {
 		productCode = item.Code
@@ -426,32 +657,98 @@ func (e *Engine) handleCreatePrepaid(ctx context.Context, evt *events.Message, u
 	if productType == "" {
 		productType = resolvedType
 	}
+	// "isi pulsa buat nomorku": the bot already knows the number, so fill it
+	// in and have the user confirm it instead of retyping it.
+	ownNumber, ownNumberUnknown := false, false
+	if (customerID == "" && refersToOwnNumber(rawLower)) || refersToOwnNumber(customerID) {
+		if phone := ownPhoneNumber(user, evt); phone != "" && !productRequiresZone(item) {
+			customerID, rawCustomerID, ownNumber = phone, phone, true
+		} else {
+			customerID, rawCustomerID, ownNumberUnknown = "", "", true
+		}
+	}
 	customerID, customerZone = normalizeCustomerTarget(customerID, customerZone)
 	if customerZone != "" {
 		intent.Entities["customer_zone"] = customerZone
 	}
 	intent.Entities["customer_id"] = customerID
+	if proceed, err := e.checkTrust(ctx, evt, user, item, productType); !proceed {
+		return err
+	}
+	if targets := bulkTargets(intent.Entities, rawLower); len(targets) > 1 && !ownNumber && !productRequiresZone(item) && !isPLNToken(item, productType) {
+		return e.handleBulkPrepaid(ctx, evt, user, item, productType, paymentMethod, refID, targets, annotations)
+	}
+	draft := newDraftOrder(item, productType, customerID, customerZone, annotations)
+	quantity, quantityNote := orderQuantity(item, draftQuantity(intent.Entities))
+	draft.Quantity = quantity
 	if customerID == "" {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, fmt.Sprintf("Kamu mau beli %s (%s). Kirim nomor/ID tujuan ya.", item.Name, item.Code), "prepaid_missing_customer")
+		e.storeDraft(ctx, user.ID, draft, flowEnterTarget)
+		if ownNumberUnknown {
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgAskTargetOwnUnknown, item.Name, item.Code), "prepaid_own_number_unknown")
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgAskTarget, item.Name, item.Code), "prepaid_missing_customer")
 	}
 	if productRequiresZone(item) && customerZone == "" {
-		hint := fmt.Sprintf("Untuk %s, butuh ID plus Server. Formatkan seperti 12345678(1234) ya.", item.Name)
+		e.storeDraft(ctx, user.ID, draft, flowEnterTarget)
+		hint := localize(user, msgAskZone, item.Name)
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, hint, "prepaid_missing_customer_zone")
 	}
 
-	// If no payment method was determined, prompt user to choose.
+	if isPLNToken(item, productType) {
+		fresh, proceed, err := e.resolvePLNMeter(ctx, evt, user, draft, &annotations)
+		if !proceed {
+			return err
+		}
+		if fresh {
+			// Let the user check the meter's owner before paying.
+			paymentMethod = ""
+		}
+	}
+
+	if ownNumber {
+		// Never charge a number the user did not type without asking first.
+		paymentMethod = ""
+	}
+
+	// If no payment method was determined, confirm the draft and prompt user to choose.
 	if paymentMethod == "" {
-		prompt := fmt.Sprintf("Mau beli %s (%s) — %s\nHarga: %s\n\nMau bayar pakai apa?\n🏦 *BRI* — Transfer Bank BRI\n📱 *QRIS* — Scan QR\n💰 *Saldo* — Pakai saldo deposit\n\nBalas: bri / qris / saldo", item.Name, item.Code, formatCurrency(item.Price), formatCurrency(item.Price))
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, prompt, "prepaid_ask_payment_method")
+		e.storeDraft(ctx, user.ID, draft, flowConfirm)
+		summary := draft.summary(user, e.currencyLocale(user))
+		if ownNumber {
+			summary = localize(user, msgOwnNumberTarget, customerID, summary)
+		}
+		body := summary + "\n\n" + localize(user, msgAskPayment)
+		prompt := body + "\n" + localize(user, msgPaymentOptions)
+		if quantityNote != "" {
+			prompt += "\n\n" + quantityNote
+			body += "\n\n" + quantityNote
+		}
+		changeHint := localize(user, msgDraftChangeHint)
+		prompt += "\n" + changeHint
+		return e.respondWithButtons(ctx, evt.Info.Sender, user.ID, prompt, body, changeHint, paymentButtons, "prepaid_ask_payment_method")
+	}
+	if e.purchasesHeld() {
+		e.degraded(dependencyAtlantic)
+		e.storeDraft(ctx, user.ID, draft, flowConfirm)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseHeld), "prepaid_held_supplier_down")
+	}
+	e.storeDraft(ctx, user.ID, nil, flowIdle)
+
+	item, proceed, err := e.verifyMargin(ctx, evt, user, item, productType)
+	if !proceed {
+		return err
 	}
 
 	switch paymentMethod {
 	case "deposit", "saldo":
-		return e.executePrepaidWithBalance(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, productType)
+		if quantity > 1 {
+			return e.executePrepaidBatchWithBalance(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, productType, quantity, annotations)
+		}
+		return e.executePrepaidWithBalance(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, productType, annotations)
 	case "bri":
-		return e.executePrepaidWithCheckout(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, "BRI", productType)
+		return e.executePrepaidWithCheckout(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, "BRI", productType, quantity, annotations)
 	default:
-		return e.executePrepaidWithCheckout(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, paymentMethod, productType)
+		return e.executePrepaidWithCheckout(ctx, evt, user, productCode, customerID, customerZone, rawCustomerID, refID, item, paymentMethod, productType, quantity, annotations)
 	}
 }
 
@@ -459,7 +756,7 @@ func (e *Engine) handleCheckBill(ctx context.Context, evt *events.Message, user
 	productCode := intent.Entities["product_code"]
 	customerID := intent.Entities["customer_id"]
 	if productCode == "" || customerID == "" {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Butuh kode produk dan ID pelanggan ya. Contoh: \"cek tagihan PLN 123456\".", "bill_missing_fields")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgBillMissingFields), "bill_missing_fields")
 	}
 	refID := generateRefID("bill")
 	resp, err := e.atl.BillInquiry(ctx, atl.BillInquiryRequest{
@@ -471,30 +768,43 @@ func (e *Engine) handleCheckBill(ctx context.Context, evt *events.Message, user
 		return fmt.Errorf("bill inquiry: %w", err)
 	}
 
+	billMeta := map[string]any{
+		"customer_id":  customerID,
+		"amount":       resp.Amount,
+		"fee":          resp.Fee,
+		"bill_info":    resp.BillInfo,
+		"product_type": "pascabayar",
+	}
+	recordReplyTarget(billMeta, evt)
+	e.tagAcquisition(ctx, user.ID, billMeta)
 	if _, err := e.repo.InsertOrder(ctx, repo.Order{
 		UserID:      user.ID,
 		OrderRef:    resp.RefID,
 		ProductCode: productCode,
 		Status:      resp.Status,
-		Metadata: map[string]any{
-			"customer_id":  customerID,
-			"amount":       resp.Amount,
-			"fee":          resp.Fee,
-			"bill_info":    resp.BillInfo,
-			"product_type": "pascabayar",
-		},
+		Metadata:    billMeta,
 	}); err != nil {
 		e.logger.Warn("failed storing bill inquiry", "error", err)
 	}
 
-	reply := fmt.Sprintf("Tagihan %s atas %s total %.0f + fee %.0f. Kalau mau langsung bayar, ketik: bayar %s.", productCode, customerID, resp.Amount, resp.Fee, resp.RefID)
+	locale := e.currencyLocale(user)
+	reply := localize(user, msgBillInquiry, productCode, customerID, formatCurrency(locale, resp.Amount), formatCurrency(locale, resp.Fee), resp.RefID)
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_bill")
 }
 
 func (e *Engine) handlePayBill(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
 	refID := intent.Entities["ref_id"]
 	if refID == "" {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Butuh kode ref transaksi tagihan yang mau dibayar.", "pay_bill_missing_ref")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPayBillMissingRef), "pay_bill_missing_ref")
+	}
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseBusy), "purchase_in_progress")
+	}
+	defer release()
+	if e.atlanticDown() {
+		e.degraded(dependencyAtlantic)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgBillPaymentHeld), "pay_bill_held_supplier_down")
 	}
 	productCode := strings.TrimSpace(intent.Entities["product_code"])
 	customerID := strings.TrimSpace(intent.Entities["customer_id"])
@@ -517,13 +827,15 @@ func (e *Engine) handlePayBill(ctx context.Context, evt *events.Message, user *r
 		return fmt.Errorf("bill payment: %w", err)
 	}
 
-	if err := e.repo.UpdateOrderStatus(ctx, refID, resp.Status, map[string]any{
-		"message": resp.Message,
-	}); err != nil {
+	payMeta := map[string]any{"message": resp.Message}
+	e.carryAcquisition(ctx, refID, payMeta)
+	if err := e.repo.UpdateOrderStatus(ctx, refID, resp.Status, payMeta); err != nil {
 		e.logger.Warn("failed update order status", "error", err)
+	} else if isSuccessStatus(resp.Status) {
+		e.orderSucceeded(ctx, refID, "")
 	}
 
-	reply := fmt.Sprintf("Pembayaran %s status: %s. Pesan: %s", refID, resp.Status, resp.Message)
+	reply := localize(user, msgPayBillStatus, refID, resp.Status, resp.Message)
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "pay_bill")
 }
 
@@ -548,21 +860,28 @@ func (e *Engine) handleCheckStatus(ctx context.Context, evt *events.Message, use
 	}
 	productType := strings.TrimSpace(intent.Entities["product_type"])
 	var order *repo.Order
-	if productType == "" && e.repo != nil {
+	if refID != "" && e.repo != nil {
 		if found, err := e.repo.GetOrderByRef(ctx, refID); err == nil && found != nil {
 			order = found
-			productType = strings.TrimSpace(stringValue(found.Metadata, "product_type"))
+			if productType == "" {
+				productType = strings.TrimSpace(stringValue(found.Metadata, "product_type"))
+			}
 		}
 	}
 	if productType == "" {
 		productType = "prabayar"
 	}
 
-	resp, err := e.atl.TransactionStatus(ctx, atl.TransactionStatusRequest{
+	req := atl.TransactionStatusRequest{
 		RefID: refID,
 		ID:    id,
 		Type:  productType,
-	})
+	}
+	if order != nil {
+		placed := supplier.StatusRequest(order)
+		req.Supplier, req.ProductCode, req.CustomerID = placed.Supplier, placed.ProductCode, placed.CustomerID
+	}
+	resp, err := e.fulfiller().TransactionStatus(ctx, req)
 	if err != nil {
 		if order != nil && isNotFoundAtlanticError(err) {
 			statusText := strings.ToUpper(strings.TrimSpace(order.Status))
@@ -589,6 +908,9 @@ func (e *Engine) handleCheckStatus(ctx context.Context, evt *events.Message, use
 	if resp.SN != "" {
 		reply = fmt.Sprintf("%s SN: %s.", reply, resp.SN)
 	}
+	if order != nil {
+		reply += annotationsFromMetadata(order.Metadata).replySuffix()
+	}
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_status")
 }
 
@@ -606,16 +928,20 @@ func (e *Engine) handleCreateDeposit(ctx context.Context, evt *events.Message, u
 	}
 	amountStr := strings.TrimSpace(intent.Entities["amount"])
 	if amountStr == "" {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nominal deposit belum jelas. Coba tulis angka seperti 50000.", "deposit_invalid_amount")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgDepositInvalidAmount), "deposit_invalid_amount")
 	}
 	if method == "" {
-		hint := "Mau deposit via apa?\n🏦 *BRI* — Transfer Bank BRI\n📱 *QRIS* — Scan QR\n\nContoh: \"deposit qris 50000\" atau \"deposit bri 100000\""
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, hint, "deposit_missing_fields")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgDepositAskMethod), "deposit_missing_fields")
 	}
-	amount, err := parseAmount(amountStr)
+	amount, err := parseExactAmount(amountStr)
 	if err != nil || amount <= 0 {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nominal deposit belum jelas. Coba tulis angka seperti 50000.", "deposit_invalid_amount")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgDepositInvalidAmount), "deposit_invalid_amount")
+	}
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseBusy), "purchase_in_progress")
 	}
+	defer release()
 	refID := strings.TrimSpace(intent.Entities["ref_id"])
 	if refID == "" {
 		refID = generateRefID("dep")
@@ -629,9 +955,9 @@ func (e *Engine) handleCreateDeposit(ctx context.Context, evt *events.Message, u
 	if method == "bri" {
 		depositType = "bank"
 	}
-	resp, err := e.atl.CreateDeposit(ctx, atl.DepositRequest{
+	resp, provider, err := e.createDeposit(ctx, atl.DepositRequest{
 		Method: method,
-		Amount: float64(grossAmount),
+		Amount: money.FromRupiah(grossAmount),
 		RefID:  refID,
 		Type:   depositType,
 	})
@@ -639,16 +965,16 @@ func (e *Engine) handleCreateDeposit(ctx context.Context, evt *events.Message, u
 		e.logger.Warn("create deposit request failed", "error", err, "user_id", user.ID, "method", method, "amount", amount)
 		feedback := friendlyAtlanticError(err)
 		if feedback == "" {
-			feedback = "Deposit belum bisa diproses sekarang. Coba lagi dalam beberapa saat ya."
+			feedback = localize(user, msgDepositUnavailable)
 		} else {
-			feedback = fmt.Sprintf("Deposit belum bisa diproses: %s", feedback)
+			feedback = localize(user, msgDepositFailed, feedback)
 		}
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, feedback, "create_deposit_failed")
 	}
 
-	feeAmount := priceToAmount(resp.Fee)
-	netFromProvider := priceToAmount(resp.NetAmount)
-	providerAmount := priceToAmount(resp.Amount)
+	feeAmount := resp.Fee.Rupiah()
+	netFromProvider := resp.NetAmount.Rupiah()
+	providerAmount := resp.Amount.Rupiah()
 	depStatus, forced := forceSuccessIfPending(resp.Status)
 
 	// Prefer net reported by provider; otherwise derive from gross and fee
@@ -665,10 +991,14 @@ func (e *Engine) handleCreateDeposit(ctx context.Context, evt *events.Message, u
 
 	metadata := map[string]any{
 		"checkout":         resp.Checkout,
+		"payment_provider": provider,
 		"message":          resp.Message,
 		"gross_amount":     displayGross,
 		"requested_amount": amount,
 	}
+	if resp.ID != "" {
+		metadata["provider_id"] = resp.ID
+	}
 	if forced {
 		metadata["forced_success"] = true
 		metadata["original_status"] = resp.Status
@@ -704,32 +1034,34 @@ func (e *Engine) handleCreateDeposit(ctx context.Context, evt *events.Message, u
 			computedFee = altFee
 		}
 	}
-	summaryLine := summarizeDepositAmounts(displayGross, computedFee, netAmount)
+	locale := e.currencyLocale(user)
+	summaryLine := summarizeDepositAmounts(user, displayGross, computedFee, netAmount, locale)
 
 	// Check if this is a bank transfer deposit (BRI) — show transfer info instead of QR.
 	if method == "bri" || depositType == "bank" {
-		bankInfo := formatBankTransferInfo(resp.Checkout)
-		reply := fmt.Sprintf("Sip, deposit %s via BRI sebesar %s sudah siap.\n%s", refID, formatCurrency(float64(displayGross)), bankInfo)
+		bankInfo := formatBankTransferInfo(user, resp.Checkout, locale, user.Location())
+		reply := localize(user, msgDepositReady, refID, "BRI", formatCurrency(locale, money.FromRupiah(displayGross)), bankInfo)
 		if summaryLine != "" {
 			reply = fmt.Sprintf("%s\n%s", reply, summaryLine)
 		}
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_deposit")
 	}
 
-	qrCaption := fmt.Sprintf("Deposit %s via %s senilai %s.", refID, strings.ToUpper(method), formatCurrency(float64(displayGross)))
+	qrCaption := localize(user, msgDepositQRCaption, refID, strings.ToUpper(method), formatCurrency(locale, money.FromRupiah(displayGross)))
 	if summaryLine != "" {
 		qrCaption = fmt.Sprintf("%s\n%s", qrCaption, summaryLine)
 	}
 	qrSent := e.sendCheckoutQRImage(ctx, evt.Info.Sender, user.ID, resp.Checkout, qrCaption, "create_deposit")
 
-	reply := fmt.Sprintf("Sip, deposit %s via %s sebesar %s sudah siap.\n%s", refID, strings.ToUpper(method), formatCurrency(float64(displayGross)), formatCheckoutInfo(resp.Checkout, qrSent))
+	reply := localize(user, msgDepositReady, refID, strings.ToUpper(method), formatCurrency(locale, money.FromRupiah(displayGross)), formatCheckoutInfo(user, resp.Checkout, qrSent, locale, user.Location()))
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_deposit")
 }
 
 func (e *Engine) handleCheckBalance(ctx context.Context, evt *events.Message, user *repo.User) error {
+	locale := e.currencyLocale(user)
 	// Prefer per-JID balance from Postgres (computed via triggers/views).
 	if ub, err := e.repo.GetUserBalance(ctx, user.ID); err == nil && ub != nil {
-		reply := fmt.Sprintf("Saldo kamu sekitar %s.", formatCurrency(float64(ub.SaldoConfirmed)))
+		reply := localize(user, msgBalance, formatCurrency(locale, money.FromRupiah(ub.SaldoConfirmed)))
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_balance")
 	}
 
@@ -738,53 +1070,12 @@ func (e *Engine) handleCheckBalance(ctx context.Context, evt *events.Message, us
 	if err != nil {
 		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "check_balance")
 	}
-	reply := fmt.Sprintf("Saldo Atlantic kamu sekitar %s. Status akun: %s.", formatCurrency(profile.Balance), strings.ToUpper(profile.Status))
+	reply := localize(user, msgAtlanticBalance, formatCurrency(locale, profile.Balance), strings.ToUpper(profile.Status))
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "check_balance")
 }
 
-func (e *Engine) handleCreateTransfer(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
-	bank := intent.Entities["bank_code"]
-	account := intent.Entities["account_no"]
-	accountName := intent.Entities["account_name"]
-	amountStr := intent.Entities["amount"]
-	if bank == "" || account == "" || amountStr == "" {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Format transfer belum lengkap. Contoh: \"transfer 100000 ke dana 08123 a.n Budi\".", "transfer_missing_fields")
-	}
-	amount, err := parseAmount(amountStr)
-	if err != nil {
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nominal transfer belum jelas. Tulis angka seperti 100000 ya.", "transfer_invalid_amount")
-	}
-	refID := generateRefID("tf")
-
-	resp, err := e.atl.CreateTransfer(ctx, atl.TransferRequest{
-		BankCode:    bank,
-		AccountNo:   account,
-		AccountName: accountName,
-		Amount:      float64(amount),
-		RefID:       refID,
-	})
-	if err != nil {
-		return fmt.Errorf("create transfer: %w", err)
-	}
-
-	if err := e.repo.UpdateOrderStatus(ctx, refID, resp.Status, map[string]any{
-		"bank_code":    bank,
-		"account_no":   account,
-		"account_name": accountName,
-		"amount":       amount,
-		"message":      resp.Message,
-	}); err != nil {
-		e.logger.Warn("failed update transfer record", "error", err)
-	}
-
-	reply := fmt.Sprintf("Transfer %s status: %s. Pesan: %s", refID, resp.Status, resp.Message)
-	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_transfer")
-}
-
 func (e *Engine) handleNonText(ctx context.Context, evt *events.Message, user *repo.User) {
 	switch detectMessageType(evt) {
-	case "audio":
-		e.handleAudioMessage(ctx, evt, user)
 	case "image":
 		e.handleImageMessage(ctx, evt, user)
 	case "video", "document":
@@ -798,45 +1089,34 @@ func (e *Engine) handleNonText(ctx context.Context, evt *events.Message, user *r
 	}
 }
 
-func (e *Engine) handleAudioMessage(ctx context.Context, evt *events.Message, user *repo.User) {
+// transcribeVoiceNote turns a voice note into text for the intent pipeline.
+// It replies to the user itself and returns "" when there is nothing to act on.
+func (e *Engine) transcribeVoiceNote(ctx context.Context, evt *events.Message, user *repo.User) string {
 	if !e.allowMediaRequest(ctx, user.ID, "audio") {
 		_ = e.respond(ctx, evt.Info.Sender, "Permintaan voice note kamu lagi dibatasi sebentar ya. Coba lagi beberapa menit lagi.")
-		return
+		return ""
 	}
 
 	data, mime, err := e.gateway.DownloadMedia(ctx, evt.Message)
 	if err != nil {
 		e.logger.Error("download audio failed", "error", err)
 		_ = e.respond(ctx, evt.Info.Sender, "Maaf, voice note-nya gagal kuambil. Bisa kirim ulang atau ketik manual ya.")
-		return
+		return ""
 	}
 
 	transcript, err := e.nlu.TranscribeAudio(ctx, data, mime)
 	if err != nil {
 		e.logger.Error("transcribe audio failed", "error", err)
+		e.degraded(dependencyNLU)
 		_ = e.respond(ctx, evt.Info.Sender, "Voice note-nya belum bisa kubaca. Coba ketik manual dulu ya.")
-		return
+		return ""
 	}
-
 	if transcript == "" {
 		_ = e.respond(ctx, evt.Info.Sender, "Voice note-nya kurang jelas. Ketik manual aja ya.")
-		return
-	}
-
-	intent, err := e.nlu.DetectIntent(ctx, nlu.IntentInput{
-		UserMessage: fmt.Sprintf("Hasil transkrip voice note: %s", transcript),
-		Channel:     "whatsapp_audio",
-		UserLocale:  user.LanguagePreference,
-	})
-	if err != nil {
-		e.logger.Error("intent from audio failed", "error", err)
-		_ = e.respond(ctx, evt.Info.Sender, "Maaf, aku belum paham isi voice note-nya. Coba jelaskan dengan teks ya.")
-		return
-	}
-
-	if err := e.routeIntent(ctx, evt, user, transcript, intent); err != nil {
-		e.logger.Error("audio intent handling failed", "error", err)
+		return ""
 	}
+	e.logger.Info("voice note transcribed", "user_id", user.ID, "chars", len(transcript))
+	return transcript
 }
 
 func (e *Engine) handleImageMessage(ctx context.Context, evt *events.Message, user *repo.User) {
@@ -868,6 +1148,7 @@ func (e *Engine) handleImageMessage(ctx context.Context, evt *events.Message, us
 		UserMessage: fmt.Sprintf("Hasil analisa gambar: %s", intentInput),
 		Channel:     "whatsapp_image",
 		UserLocale:  user.LanguagePreference,
+		UserProfile: e.userMemory(ctx, user.ID),
 	})
 	if err != nil {
 		e.logger.Error("intent from image failed", "error", err)
@@ -881,22 +1162,16 @@ func (e *Engine) handleImageMessage(ctx context.Context, evt *events.Message, us
 }
 
 func (e *Engine) respondAndLog(ctx context.Context, to types.JID, userID string, text string, category string) error {
-	if err := e.respond(ctx, to, text); err != nil {
+	text = e.cfg.Persona.Apply(text)
+	if err := e.gateway.SendText(wa.WithOrigin(ctx, wa.Origin{UserID: userID, Category: category}), to, text); err != nil {
 		return err
 	}
-	if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
-		UserID:    userID,
-		Direction: "outgoing",
-		Type:      category,
-		Content:   &text,
-	}); err != nil {
-		e.logger.Warn("failed logging outgoing message", "error", err)
-	}
+	e.logOutgoing(ctx, userID, text, category)
 	return nil
 }
 
 func (e *Engine) respond(ctx context.Context, to types.JID, text string) error {
-	return e.gateway.SendText(ctx, to, text)
+	return e.gateway.SendText(ctx, to, e.cfg.Persona.Apply(text))
 }
 
 func (e *Engine) handleAtlanticFailure(ctx context.Context, to types.JID, userID string, err error, category string) error {
@@ -904,6 +1179,11 @@ func (e *Engine) handleAtlanticFailure(ctx context.Context, to types.JID, userID
 		e.logger.Error("atlantic credential rejected", "error", err)
 		return e.respondAndLog(ctx, to, userID, "Koneksi Atlantic menolak API key yang dipakai. Mohon cek kembali kredensial di konfigurasi.", category)
 	}
+	if errors.Is(err, atl.ErrCircuitOpen) {
+		e.degraded(dependencyAtlantic)
+		e.logger.Warn("atlantic call short-circuited", "error", err, "category", category)
+		return e.respondAndLog(ctx, to, userID, providerDownMessage, category)
+	}
 	e.logger.Error("atlantic operation failed", "error", err)
 	return e.respondAndLog(ctx, to, userID, "Maaf, harga layanan belum bisa kuambil dari Atlantic. Coba lagi dalam beberapa saat ya.", category)
 }
@@ -1039,11 +1319,32 @@ func (e *Engine) enrichIntentFromText(text string, intent *nlu.IntentResult) {
 		intent.Intent = "fallback"
 	}
 
+	// Strip "ref: X" / "catatan: Y" from purchase messages so they don't leak into target parsing.
+	if containsPurchaseKeyword(lowered) && !looksLikeStatusQuery(lowered) {
+		if annotations, stripped := extractOrderAnnotations(trimmed); !annotations.empty() {
+			annotations.toEntities(intent.Entities)
+			trimmed = stripped
+			lowered = strings.ToLower(trimmed)
+		}
+	}
+
+	if looksLikeRepayRequest(lowered) {
+		intent.Intent = "regenerate_deposit"
+		if ref := quotedOrderRefPattern.FindString(lowered); ref != "" && intent.Entities["ref_id"] == "" {
+			intent.Entities["ref_id"] = ref
+		}
+		return
+	}
+
 	// Detect payment method questions early — before product query check
 	if looksLikePaymentQuery(lowered) {
 		intent.Intent = "payment_info"
 		return
 	}
+	if looksLikeHistoryQuery(lowered) {
+		intent.Intent = "order_history"
+		return
+	}
 	if looksLikeStatusQuery(lowered) {
 		intent.Intent = "check_status"
 	}
@@ -1068,7 +1369,10 @@ func (e *Engine) enrichIntentFromText(text string, intent *nlu.IntentResult) {
 	}
 	if intent.Entities["budget"] == "" {
 		if amount, err := parseAmount(trimmed); err == nil && looksLikeBudget(lowered) {
-			intent.Entities["budget"] = fmt.Sprintf("%d", amount)
+			intent.Entities["budget"] = fmt.Sprintf("%d", amount.Max)
+			if amount.IsRange() {
+				intent.Entities["budget"] = fmt.Sprintf("%d-%d", amount.Min, amount.Max)
+			}
 			if intent.Intent == "fallback" || intent.Intent == "" || intent.Intent == "price_lookup" {
 				intent.Intent = "budget_filter"
 			}
@@ -1094,6 +1398,12 @@ func (e *Engine) enrichIntentFromText(text string, intent *nlu.IntentResult) {
 	if looksLikeBalanceQuery(lowered) {
 		intent.Intent = "check_balance"
 	}
+	if looksLikeWithdrawRequest(lowered) {
+		intent.Intent = "withdraw_balance"
+	}
+	if looksLikeAccountCheck(lowered) {
+		intent.Intent = "check_account"
+	}
 	if method := detectPaymentMethod(lowered); method != "" {
 		intent.Entities["payment_method"] = method
 		if intent.Intent == "price_lookup" {
@@ -1220,7 +1530,7 @@ func looksLikeBudget(text string) bool {
 	budgetWords := []string{
 		"budget", "modal", "cuma", "punya",
 		"dibawah", "di bawah", "under", "kurang dari",
-		"maksimal", "max", "murah",
+		"maksimal", "max", "murah", "antara", "kisaran",
 		"dibawah", "ga lebih", "gak lebih", "tidak lebih",
 	}
 	for _, w := range budgetWords {
@@ -1605,7 +1915,7 @@ func generateTargetCandidates(normalizedID, zone, raw string) []string {
 	return ordered
 }
 
-func (e *Engine) createPrepaidWithVariants(ctx context.Context, productCode, refID string, candidates []string, userID string) (*atl.TransactionResponse, string, error) {
+func (e *Engine) createPrepaidWithVariants(ctx context.Context, productCode, refID, note string, candidates []string, userID string) (*atl.TransactionResponse, string, error) {
 	var lastResp *atl.TransactionResponse
 	var lastTarget string
 
@@ -1615,10 +1925,11 @@ func (e *Engine) createPrepaidWithVariants(ctx context.Context, productCode, ref
 			continue
 		}
 		e.logger.Debug("atlantic prepaid attempt", "product_code", productCode, "target", target, "attempt", idx+1, "user_id", userID)
-		resp, err := e.atl.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{
+		resp, err := e.fulfiller().CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{
 			ProductCode: productCode,
 			CustomerID:  target,
 			RefID:       refID,
+			Note:        note,
 		})
 		if err != nil {
 			if shouldRetryTargetError(err) && idx+1 < len(candidates) {
@@ -1741,7 +2052,7 @@ func productRequiresZone(item *atl.PriceListItem) bool {
 }
 
 // retryPrepaidAsync keeps retrying a prepaid transaction on temporary server errors and notifies the user of the outcome.
-func (e *Engine) retryPrepaidAsync(ctx context.Context, userID string, to types.JID, productName string, productCode string, refID string, candidates []string, customerZone string) {
+func (e *Engine) retryPrepaidAsync(ctx context.Context, user *repo.User, to types.JID, productName string, productCode string, refID string, note string, candidates []string, customerZone string, instructions string) {
 	// Backoff schedule
 	backoffs := []time.Duration{5 * time.Second, 15 * time.Second, 30 * time.Second}
 
@@ -1752,7 +2063,7 @@ func (e *Engine) retryPrepaidAsync(ctx context.Context, userID string, to types.
 	)
 
 	for i, d := range backoffs {
-		r, u, err := e.createPrepaidWithVariants(ctx, productCode, refID, candidates, userID)
+		r, u, err := e.createPrepaidWithVariants(ctx, productCode, refID, note, candidates, user.ID)
 		if err != nil {
 			lastErr = err
 			if isTemporaryServerError(err, "") && i+1 < len(backoffs) {
@@ -1778,6 +2089,10 @@ func (e *Engine) retryPrepaidAsync(ctx context.Context, userID string, to types.
 		if customerZone != "" {
 			meta["customer_zone"] = customerZone
 		}
+		recordInstructions(meta, instructions)
+		recordSupplier(meta, resp)
+		e.carryAcquisition(ctx, refID, meta)
+		e.carryBatch(ctx, refID, meta)
 		if err := e.repo.UpdateOrderStatus(ctx, refID, resp.Status, meta); err != nil {
 			e.logger.Warn("retry: update order status failed", "error", err, "order_ref", refID)
 		}
@@ -1785,30 +2100,36 @@ func (e *Engine) retryPrepaidAsync(ctx context.Context, userID string, to types.
 		status := strings.ToLower(strings.TrimSpace(resp.Status))
 		switch status {
 		case "", "pending", "processing", "process":
-			msg := fmt.Sprintf("Update: transaksi %s (%s) lagi diproses. Ref: %s.", productName, productCode, refID)
+			msg := localize(user, msgRetryProcessing, productName, productCode, refID)
 			if strings.TrimSpace(resp.Message) != "" {
 				msg = fmt.Sprintf("%s %s", msg, strings.TrimSpace(resp.Message))
 			}
-			_ = e.respondAndLog(context.Background(), to, userID, msg, "create_prepaid_retry_pending")
+			msg += e.receiptLine(user, refID)
+			_ = e.respondAndLog(context.Background(), to, user.ID, msg, "create_prepaid_retry_pending")
 		case "success", "completed", "ok", "available":
-			msg := fmt.Sprintf("Sukses: transaksi %s (%s) berhasil! Ref: %s.", productName, productCode, refID)
+			msg := localize(user, msgRetrySuccess, productName, productCode, refID)
 			if resp.SN != "" {
 				msg = fmt.Sprintf("%s SN: %s.", msg, resp.SN)
 			}
 			if strings.TrimSpace(resp.Message) != "" {
 				msg = fmt.Sprintf("%s %s", msg, strings.TrimSpace(resp.Message))
 			}
-			_ = e.respondAndLog(context.Background(), to, userID, msg, "create_prepaid_retry_success")
+			msg += e.receiptLine(user, refID)
+			msg = withInstructions(user, msg, instructions)
+			e.orderSucceeded(ctx, refID, resp.SN)
+			_ = e.respondAndLog(context.Background(), to, user.ID, msg, "create_prepaid_retry_success")
+			e.sendReceiptDocument(context.Background(), to, user.ID, refID)
 		default:
 			fail := strings.TrimSpace(resp.Message)
 			if fail == "" {
-				fail = "Transaksi gagal. Saldo deposit sepertinya belum cukup."
+				fail = localize(user, msgPrepaidRejected)
 			}
-			_ = e.repo.UpdateOrderStatus(ctx, refID, "failed", map[string]any{
-				"message": fail,
-			})
-			msg := fmt.Sprintf("Maaf, transaksi %s (%s) belum berhasil. %s", productName, productCode, fail)
-			_ = e.respondAndLog(context.Background(), to, userID, msg, "create_prepaid_retry_failed")
+			failMeta := map[string]any{"message": fail}
+			e.carryAcquisition(ctx, refID, failMeta)
+			e.carryBatch(ctx, refID, failMeta)
+			_ = e.repo.UpdateOrderStatus(ctx, refID, "failed", failMeta)
+			msg := localize(user, msgRetryFailed, productName, productCode, fail)
+			_ = e.respondAndLog(context.Background(), to, user.ID, msg, "create_prepaid_retry_failed")
 		}
 		return
 	}
@@ -1816,22 +2137,23 @@ func (e *Engine) retryPrepaidAsync(ctx context.Context, userID string, to types.
 	// All retries exhausted without a response
 	if isTemporaryServerError(lastErr, "") {
 		// Keep the order in processing and inform the user it's still queued due to server issues.
-		queuedMsg := fmt.Sprintf("Masih ada gangguan server. Transaksimu %s (%s) tetap ku antre. Ref: %s.", productName, productCode, refID)
-		_ = e.respondAndLog(context.Background(), to, userID, queuedMsg, "create_prepaid_retry_queued")
+		queuedMsg := localize(user, msgRetryQueued, productName, productCode, refID)
+		_ = e.respondAndLog(context.Background(), to, user.ID, queuedMsg, "create_prepaid_retry_queued")
 		// Do not mark order failed; allow future retries or manual recovery.
 		return
 	}
 	friendly := friendlyAtlanticError(lastErr)
 	if friendly == "" {
-		friendly = "Transaksi belum bisa diproses."
+		friendly = localize(user, msgTransactionUnprocessed)
 	}
-	if err := e.repo.UpdateOrderStatus(ctx, refID, "failed", map[string]any{
-		"error": strings.TrimSpace(lastErr.Error()),
-	}); err != nil {
+	failMeta := map[string]any{"error": strings.TrimSpace(lastErr.Error())}
+	e.carryAcquisition(ctx, refID, failMeta)
+	e.carryBatch(ctx, refID, failMeta)
+	if err := e.repo.UpdateOrderStatus(ctx, refID, "failed", failMeta); err != nil {
 		e.logger.Warn("retry: update order after failure", "error", err, "order_ref", refID)
 	}
-	msg := fmt.Sprintf("Maaf, transaksi %s (%s) belum bisa diproses. %s", productName, productCode, friendly)
-	_ = e.respondAndLog(context.Background(), to, userID, msg, "create_prepaid_retry_failed")
+	msg := localize(user, msgRetryUnprocessed, productName, productCode, friendly)
+	_ = e.respondAndLog(context.Background(), to, user.ID, msg, "create_prepaid_retry_failed")
 }
 
 func (e *Engine) defaultDepositMethod() string {
@@ -1850,7 +2172,7 @@ func (e *Engine) resolveProductFromQuery(ctx context.Context, productCode, produ
 	} else {
 		searchTypes = append(searchTypes, "prabayar", "pascabayar")
 	}
-	query = strings.TrimSpace(query)
+	query = e.expandCatalogQuery(ctx, strings.TrimSpace(query))
 
 	e.logger.Debug("resolveProductFromQuery", "product_code", productCode, "product_type", productType, "query", query, "provider", provider)
 
@@ -1870,7 +2192,7 @@ func (e *Engine) resolveProductFromQuery(ctx context.Context, productCode, produ
 			e.logger.Debug("product code not found in type", "code", productCode, "type", t, "total_items", len(items))
 		}
 		if query != "" {
-			matches := filterByQuery(items, query, provider, false)
+			matches := deprioritizeFlaky(filterByQuery(items, query, provider, false), e.flakyProducts(ctx))
 			e.logger.Debug("filterByQuery result", "query", query, "provider", provider, "matches", len(matches))
 			if len(matches) > 0 {
 				item := matches[0]
@@ -1878,7 +2200,7 @@ func (e *Engine) resolveProductFromQuery(ctx context.Context, productCode, produ
 			}
 		}
 		if provider != "" {
-			matches := filterByQuery(items, provider, provider, false)
+			matches := deprioritizeFlaky(filterByQuery(items, provider, provider, false), e.flakyProducts(ctx))
 			if len(matches) > 0 {
 				item := matches[0]
 				return &item, t, nil
@@ -1888,20 +2210,38 @@ func (e *Engine) resolveProductFromQuery(ctx context.Context, productCode, produ
 	return nil, "", nil
 }
 
-func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Message, user *repo.User, productCode, customerID, customerZone, rawCustomerID, orderRef string, item *atl.PriceListItem, productType string) error {
+// createPrepaidWithRetry places a prepaid transaction, retrying a few times
+// in quick succession while the supplier reports a transient server error.
+func (e *Engine) createPrepaidWithRetry(ctx context.Context, productCode, refID, note string, candidates []string, userID string) (*atl.TransactionResponse, string, error) {
+	const maxAttempts = 4
+	for attempt := 1; ; attempt++ {
+		resp, usedTarget, err := e.createPrepaidWithVariants(ctx, productCode, refID, note, candidates, userID)
+		if err == nil {
+			return resp, usedTarget, nil
+		}
+		// Retry on transient server errors (e.g., 500 / "gangguan server").
+		if !isTemporaryServerError(err, "") || attempt == maxAttempts {
+			return nil, "", err
+		}
+		time.Sleep(800 * time.Millisecond)
+	}
+}
+
+func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Message, user *repo.User, productCode, customerID, customerZone, rawCustomerID, orderRef string, item *atl.PriceListItem, productType string, annotations orderAnnotations) error {
 	// Check balance BEFORE processing the transaction
-	amount := priceToAmount(item.Price)
+	amount := item.Price.Rupiah()
+	locale := e.currencyLocale(user)
 	ub, err := e.repo.GetUserBalance(ctx, user.ID)
 	if err != nil {
 		e.logger.Error("failed to check balance", "error", err, "user", user.ID)
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Gagal mengecek saldo. Coba lagi nanti ya.", "balance_check_failed")
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgBalanceCheckFailed), "balance_check_failed")
 	}
 	if ub == nil || ub.SaldoConfirmed < amount {
 		currentBalance := int64(0)
 		if ub != nil {
 			currentBalance = ub.SaldoConfirmed
 		}
-		reply := fmt.Sprintf("Saldo kamu tidak mencukupi.\n\n💰 Saldo: %s\n🏷️ Harga: %s\n\nSilakan deposit dulu atau gunakan metode pembayaran lain (BRI/QRIS).\nKetik: \"deposit [jumlah]\" untuk top up saldo.", formatCurrency(float64(currentBalance)), formatCurrency(item.Price))
+		reply := localize(user, msgInsufficientBalance, formatCurrency(locale, money.FromRupiah(currentBalance)), formatCurrency(locale, item.Price))
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "insufficient_balance")
 	}
 
@@ -1909,6 +2249,7 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 	if refID == "" {
 		refID = generateRefID("trx")
 	}
+	instructions := e.productInstructions(ctx, *item)
 	// Pre-create order so we can update status even if Atlantic returns an error.
 	// (amount already computed above for balance check)
 	preMeta := map[string]any{
@@ -1921,7 +2262,11 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 		preMeta["product_type"] = productType
 	}
 	preMeta["precreate"] = true
-	if _, err := e.repo.InsertOrder(ctx, repo.Order{
+	annotations.applyTo(preMeta)
+	recordInstructions(preMeta, instructions)
+	recordReplyTarget(preMeta, evt)
+	e.tagAcquisition(ctx, user.ID, preMeta)
+	if order, err := e.repo.InsertOrder(ctx, repo.Order{
 		UserID:      user.ID,
 		OrderRef:    refID,
 		ProductCode: productCode,
@@ -1930,42 +2275,22 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 		Metadata:    preMeta,
 	}); err != nil {
 		e.logger.Warn("failed precreate order", "error", err, "order_ref", refID)
+	} else {
+		e.orderCreated(ctx, order)
 	}
+	e.recordOrderQuote(ctx, refID, productType, item)
 
 	candidates := generateTargetCandidates(customerID, customerZone, rawCustomerID)
-
-	const maxAttempts = 4
-	var (
-		resp       *atl.TransactionResponse
-		usedTarget string
-		lastErr    error
-	)
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		r, u, err := e.createPrepaidWithVariants(ctx, productCode, refID, candidates, user.ID)
-		if err != nil {
-			// Retry on transient server errors (e.g., 500 / "gangguan server").
-			if isTemporaryServerError(err, "") && attempt < maxAttempts {
-				time.Sleep(800 * time.Millisecond)
-				continue
-			}
-			lastErr = err
-			break
-		}
-		resp = r
-		usedTarget = u
-		break
-	}
-
+	resp, usedTarget, lastErr := e.createPrepaidWithRetry(ctx, productCode, refID, annotations.atlanticNote(), candidates, user.ID)
 	if resp == nil {
 		// If it's a temporary backend error (e.g., 500 / "gangguan server"), keep order as processing,
 		// queue background retries, and inform user that the transaction is queued.
 		if isTemporaryServerError(lastErr, "") {
-			queuedMsg := fmt.Sprintf("Lagi ada gangguan server. Transaksimu %s (%s) sudah ku antre ya. Ref: %s. Akan ku kabari begitu ada update.", item.Name, item.Code, refID)
+			queuedMsg := localize(user, msgPrepaidQueued, item.Name, item.Code, refID)
 			_ = e.respondAndLog(ctx, evt.Info.Sender, user.ID, queuedMsg, "create_prepaid_queued")
 
 			// Continue attempts in background with backoff.
-			go e.retryPrepaidAsync(context.Background(), user.ID, evt.Info.Sender, item.Name, productCode, refID, candidates, customerZone)
+			go e.retryPrepaidAsync(context.Background(), user, evt.Info.Sender, item.Name, productCode, refID, annotations.atlanticNote(), candidates, customerZone, instructions)
 
 			// Keep user flow clean; do not mark as failed now.
 			return nil
@@ -1974,7 +2299,7 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 		// Non-temporary failure after retries.
 		friendly := friendlyAtlanticError(lastErr)
 		if friendly == "" {
-			friendly = "Transaksi belum bisa diproses karena gangguan sistem. Coba sebentar lagi ya."
+			friendly = localize(user, msgPrepaidSystemError)
 		}
 		failMeta := map[string]any{
 			"customer_id": customerID,
@@ -1983,10 +2308,11 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 		if customerZone != "" {
 			failMeta["customer_zone"] = customerZone
 		}
+		e.carryAcquisition(ctx, refID, failMeta)
 		if err := e.repo.UpdateOrderStatus(ctx, refID, "failed", failMeta); err != nil {
 			e.logger.Warn("update order after failure", "error", err, "order_ref", refID)
 		}
-		message := fmt.Sprintf("Transaksi %s (%s) gagal diproses. %s", item.Name, item.Code, friendly)
+		message := localize(user, msgPrepaidFailed, item.Name, item.Code, friendly)
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, message, "create_prepaid_failed")
 	}
 
@@ -2002,6 +2328,10 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 	if customerZone != "" {
 		metadata["customer_zone"] = customerZone
 	}
+	annotations.applyTo(metadata)
+	recordInstructions(metadata, instructions)
+	recordSupplier(metadata, resp)
+	e.carryAcquisition(ctx, refID, metadata)
 	if err := e.repo.UpdateOrderStatus(ctx, refID, resp.Status, metadata); err != nil {
 		e.logger.Warn("failed updating order after success", "error", err, "order_ref", refID)
 	}
@@ -2009,40 +2339,56 @@ func (e *Engine) executePrepaidWithBalance(ctx context.Context, evt *events.Mess
 	status := strings.ToLower(strings.TrimSpace(resp.Status))
 	switch status {
 	case "", "pending", "processing", "process":
-		reply := fmt.Sprintf("Sip, transaksi %s (%s) lagi diproses. Ref: %s.", item.Name, item.Code, refID)
+		reply := localize(user, msgPrepaidProcessing, item.Name, item.Code, refID)
 		if txt := strings.TrimSpace(resp.Message); txt != "" {
 			reply = fmt.Sprintf("%s %s", reply, txt)
 		}
+		reply += annotations.replySuffix()
+		reply += e.receiptLine(user, refID)
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid")
 	case "success", "completed", "ok", "available":
-		reply := fmt.Sprintf("Mantap, transaksi %s (%s) sukses! Ref: %s.", item.Name, item.Code, refID)
+		reply := localize(user, msgPrepaidSuccess, item.Name, item.Code, refID)
 		if resp.SN != "" {
 			reply = fmt.Sprintf("%s SN: %s.", reply, resp.SN)
 		}
 		if txt := strings.TrimSpace(resp.Message); txt != "" {
 			reply = fmt.Sprintf("%s %s", reply, txt)
 		}
-		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid_success")
+		reply += annotations.replySuffix()
+		reply += e.receiptLine(user, refID)
+		reply = withInstructions(user, reply, instructions)
+		e.orderSucceeded(ctx, refID, resp.SN)
+		if err := e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid_success"); err != nil {
+			return err
+		}
+		e.sendReceiptDocument(ctx, evt.Info.Sender, user.ID, refID)
+		return nil
 	default:
 		failure := strings.TrimSpace(resp.Message)
 		if failure == "" {
-			failure = "Transaksi gagal. Saldo deposit sepertinya belum cukup."
+			failure = localize(user, msgPrepaidRejected)
 		}
 		if ub, err := e.repo.GetUserBalance(ctx, user.ID); err == nil && ub != nil {
-			failure = fmt.Sprintf("%s Saldo kamu sekitar %s.", failure, formatCurrency(float64(ub.SaldoConfirmed)))
+			failure = failure + " " + localize(user, msgBalance, formatCurrency(locale, money.FromRupiah(ub.SaldoConfirmed)))
 		}
-		reply := fmt.Sprintf("Waduh, transaksi %s (%s) belum berhasil. %s", item.Name, item.Code, failure)
+		reply := localize(user, msgPrepaidNotSucceeded, item.Name, item.Code, failure)
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid_failed")
 	}
 }
 
-func (e *Engine) executePrepaidWithCheckout(ctx context.Context, evt *events.Message, user *repo.User, productCode, customerID, customerZone, rawCustomerID, orderRef string, item *atl.PriceListItem, method, productType string) error {
+func (e *Engine) executePrepaidWithCheckout(ctx context.Context, evt *events.Message, user *repo.User, productCode, customerID, customerZone, rawCustomerID, orderRef string, item *atl.PriceListItem, method, productType string, quantity int, annotations orderAnnotations) error {
 	depositRef := generateRefID("dep")
 	orderRef = strings.TrimSpace(orderRef)
 	if orderRef == "" {
 		orderRef = generateRefID("trx")
 	}
-	amountInt := priceToAmount(item.Price)
+	// One deposit pays for every unit of a batch.
+	amountInt := item.Price.Rupiah() * int64(quantity)
+	if quantity > 1 {
+		if proceed, err := e.checkTrustTotal(ctx, evt, user, money.FromRupiah(amountInt)); !proceed {
+			return err
+		}
+	}
 	grossAmount := e.requiredDepositGross(amountInt)
 	// Override deposit type to "bank" for BRI method.
 	depositType := e.cfg.DefaultDepositType
@@ -2052,9 +2398,9 @@ func (e *Engine) executePrepaidWithCheckout(ctx context.Context, evt *events.Mes
 	if strings.EqualFold(method, "qris") && e.defaultDepositMethod() != "" && !strings.EqualFold(e.defaultDepositMethod(), "qris") {
 		method = e.defaultDepositMethod()
 	}
-	depResp, err := e.atl.CreateDeposit(ctx, atl.DepositRequest{
+	depResp, provider, err := e.createDeposit(ctx, atl.DepositRequest{
 		Method: method,
-		Amount: float64(grossAmount),
+		Amount: money.FromRupiah(grossAmount),
 		RefID:  depositRef,
 		Type:   depositType,
 	})
@@ -2062,27 +2408,34 @@ func (e *Engine) executePrepaidWithCheckout(ctx context.Context, evt *events.Mes
 		e.logger.Warn("checkout deposit request failed", "error", err, "user_id", user.ID, "method", method, "product_code", productCode)
 		feedback := friendlyAtlanticError(err)
 		if feedback == "" {
-			feedback = "Belum bisa menyiapkan deposit untuk transaksi ini. Coba pilih metode lain atau ulangi sebentar lagi."
+			feedback = localize(user, msgCheckoutUnavailable)
 		} else {
-			feedback = fmt.Sprintf("Belum bisa menyiapkan deposit: %s", feedback)
+			feedback = localize(user, msgCheckoutFailed, feedback)
 		}
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, feedback, "create_prepaid_checkout_failed")
 	}
 
-	feeAmount := priceToAmount(depResp.Fee)
-	netFromProvider := priceToAmount(depResp.NetAmount)
-	providerAmount := priceToAmount(depResp.Amount)
+	feeAmount := depResp.Fee.Rupiah()
+	netFromProvider := depResp.NetAmount.Rupiah()
+	providerAmount := depResp.Amount.Rupiah()
 	netAmount := deriveNetAmount(amountInt, feeAmount, netFromProvider)
 	depStatus, forced := forceSuccessIfPending(depResp.Status)
 
 	metadata := map[string]any{
 		"checkout":          depResp.Checkout,
+		"payment_provider":  provider,
 		"product":           item.Name,
 		"product_code":      item.Code,
 		"gross_amount":      grossAmount,
 		"requested_amount":  grossAmount,
 		"target_net_amount": amountInt,
 	}
+	if depResp.ID != "" {
+		metadata["provider_id"] = depResp.ID
+	}
+	if quantity > 1 {
+		metadata["quantity"] = quantity
+	}
 	if forced {
 		metadata["forced_success"] = true
 		metadata["original_status"] = depResp.Status
@@ -2134,42 +2487,63 @@ func (e *Engine) executePrepaidWithCheckout(ctx context.Context, evt *events.Mes
 	if customerZone != "" {
 		orderMetadata["customer_zone"] = customerZone
 	}
-	if _, err := e.repo.InsertOrder(ctx, repo.Order{
-		UserID:      user.ID,
-		OrderRef:    orderRef,
-		ProductCode: productCode,
-		Amount:      amountInt,
-		Status:      "awaiting_payment",
-		Metadata:    orderMetadata,
-	}); err != nil {
-		e.logger.Warn("failed storing pending order", "error", err)
+	annotations.applyTo(orderMetadata)
+	recordInstructions(orderMetadata, e.productInstructions(ctx, *item))
+	recordReplyTarget(orderMetadata, evt)
+	e.tagAcquisition(ctx, user.ID, orderMetadata)
+	orderRefs := batchRefs(orderRef, quantity)
+	for _, ref := range orderRefs {
+		unitMetadata := orderMetadata
+		if quantity > 1 {
+			unitMetadata = cloneMeta(orderMetadata)
+			recordBatch(unitMetadata, orderRef, quantity)
+		}
+		if order, err := e.repo.InsertOrder(ctx, repo.Order{
+			UserID:      user.ID,
+			OrderRef:    ref,
+			ProductCode: productCode,
+			Amount:      item.Price.Rupiah(),
+			Status:      "awaiting_payment",
+			Metadata:    unitMetadata,
+		}); err != nil {
+			e.logger.Warn("failed storing pending order", "error", err, "order_ref", ref)
+		} else {
+			e.orderCreated(ctx, order)
+		}
+		e.recordOrderQuote(ctx, ref, productType, item)
 	}
+	product := fmt.Sprintf("%s (%s)", item.Name, item.Code)
+	if quantity > 1 {
+		product = fmt.Sprintf("%d× %s", quantity, product)
+	}
+	orderLabel := strings.Join(orderRefs, ", ")
 
-	summaryLine := summarizeDepositAmounts(grossAmount, feeAmount, netAmount)
+	locale := e.currencyLocale(user)
+	summaryLine := summarizeDepositAmounts(user, grossAmount, feeAmount, netAmount, locale)
 
 	// If method is BRI/bank, show bank transfer info instead of QR.
 	isBankMethod := strings.EqualFold(method, "BRI") || strings.EqualFold(method, "bri") || depositType == "bank"
 	if isBankMethod {
-		bankInfo := formatBankTransferInfo(depResp.Checkout)
-		reply := fmt.Sprintf("Sip, sudah kubuatin deposit via BRI sebesar %s buat %s (%s).\nRef deposit: %s\nRef order: %s\n%s", formatCurrency(float64(grossAmount)), item.Name, item.Code, depositRef, orderRef, bankInfo)
+		bankInfo := formatBankTransferInfo(user, depResp.Checkout, locale, user.Location())
+		reply := localize(user, msgCheckoutReady, "BRI", formatCurrency(locale, money.FromRupiah(grossAmount)), product, depositRef, orderLabel, annotations.replySuffix(), bankInfo)
 		if summaryLine != "" {
 			reply = fmt.Sprintf("%s\n%s", reply, summaryLine)
 		}
 		if shortfall > 0 {
-			reply = fmt.Sprintf("%s\nSaldo masuk masih kurang %s dari harga produk. Tambah deposit ya supaya bisa ku proses.", reply, formatCurrency(float64(shortfall)))
+			reply += "\n" + localize(user, msgCheckoutShortfall, formatCurrency(locale, money.FromRupiah(shortfall)))
 		}
 		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid_checkout")
 	}
 
-	qrCaption := fmt.Sprintf("Deposit %s via %s untuk %s (%s).", depositRef, strings.ToUpper(method), item.Name, item.Code)
+	qrCaption := localize(user, msgCheckoutQRCaption, depositRef, strings.ToUpper(method), product)
 	if summaryLine != "" {
 		qrCaption = fmt.Sprintf("%s\n%s", qrCaption, summaryLine)
 	}
 	qrSent := e.sendCheckoutQRImage(ctx, evt.Info.Sender, user.ID, depResp.Checkout, qrCaption, "create_prepaid_checkout")
 
-	reply := fmt.Sprintf("Sip, sudah kubuatin deposit via %s sebesar %s buat %s (%s).\nRef deposit: %s\nRef order: %s\n%s", strings.ToUpper(method), formatCurrency(float64(grossAmount)), item.Name, item.Code, depositRef, orderRef, formatCheckoutInfo(depResp.Checkout, qrSent))
+	reply := localize(user, msgCheckoutReady, strings.ToUpper(method), formatCurrency(locale, money.FromRupiah(grossAmount)), product, depositRef, orderLabel, annotations.replySuffix(), formatCheckoutInfo(user, depResp.Checkout, qrSent, locale, user.Location()))
 	if shortfall > 0 {
-		reply = fmt.Sprintf("%s\nSaldo masuk masih kurang %s dari harga produk. Tambah deposit ya supaya bisa ku proses.", reply, formatCurrency(float64(shortfall)))
+		reply += "\n" + localize(user, msgCheckoutShortfall, formatCurrency(locale, money.FromRupiah(shortfall)))
 	}
 	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_prepaid_checkout")
 }
@@ -2252,14 +2626,6 @@ func extractAtlanticJSONMessage(raw string) string {
 	return ""
 }
 
-func priceToAmount(price float64) int64 {
-	return int64(price + 0.5)
-}
-
-func formatCurrency(value float64) string {
-	return fmt.Sprintf("Rp%d", priceToAmount(value))
-}
-
 func forceSuccessIfPending(status string) (string, bool) {
 	normalized := strings.ToLower(strings.TrimSpace(status))
 	switch normalized {
@@ -2273,6 +2639,7 @@ func forceSuccessIfPending(status string) (string, bool) {
 func (e *Engine) sendCheckoutQRImage(ctx context.Context, to types.JID, userID string, checkout map[string]any, caption, category string) bool {
 	imageURL := firstStringMap(checkout, "qr_image")
 	qrString := firstStringMap(checkout, "qr_string")
+	sendCtx := wa.WithOrigin(ctx, wa.Origin{UserID: userID, Category: category + "_qr_image"})
 
 	if imageURL != "" {
 		data, mimeType, err := fetchQRImageData(ctx, imageURL)
@@ -2283,15 +2650,8 @@ func (e *Engine) sendCheckoutQRImage(ctx context.Context, to types.JID, userID s
 					mimeType = "image/png"
 				}
 			}
-			if err := e.gateway.SendImage(ctx, to, data, mimeType, caption); err == nil {
-				if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
-					UserID:    userID,
-					Direction: "outgoing",
-					Type:      category + "_qr_image",
-					MediaURL:  optionalString(imageURL),
-				}); err != nil {
-					e.logger.Warn("failed logging outgoing qr image", "error", err)
-				}
+			if err := e.gateway.SendImage(sendCtx, to, data, mimeType, e.cfg.Persona.Apply(caption)); err == nil {
+				e.logOutgoingQR(ctx, userID, imageURL, category)
 				return true
 			}
 			e.logger.Warn("failed sending qr image", "error", err)
@@ -2308,10 +2668,18 @@ func (e *Engine) sendCheckoutQRImage(ctx context.Context, to types.JID, userID s
 		e.logger.Warn("failed generating qr image", "error", err)
 		return false
 	}
-	if err := e.gateway.SendImage(ctx, to, data, "image/png", caption); err != nil {
+	if err := e.gateway.SendImage(sendCtx, to, data, "image/png", e.cfg.Persona.Apply(caption)); err != nil {
 		e.logger.Warn("failed sending qr image", "error", err)
 		return false
 	}
+	e.logOutgoingQR(ctx, userID, imageURL, category)
+	return true
+}
+
+func (e *Engine) logOutgoingQR(ctx context.Context, userID, imageURL, category string) {
+	if e.outboundAudited() {
+		return
+	}
 	if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
 		UserID:    userID,
 		Direction: "outgoing",
@@ -2320,7 +2688,6 @@ func (e *Engine) sendCheckoutQRImage(ctx context.Context, to types.JID, userID s
 	}); err != nil {
 		e.logger.Warn("failed logging outgoing qr image", "error", err)
 	}
-	return true
 }
 
 func fetchQRImageData(ctx context.Context, src string) ([]byte, string, error) {
@@ -2427,9 +2794,9 @@ func decodeBase64(value string) ([]byte, error) {
 	return nil, fmt.Errorf("invalid base64 data")
 }
 
-func formatCheckoutInfo(checkout map[string]any, qrImageSent bool) string {
+func formatCheckoutInfo(user *repo.User, checkout map[string]any, qrImageSent bool, locale money.Locale, loc *time.Location) string {
 	if len(checkout) == 0 {
-		return "Instruksi pembayaran akan dikirim setelah checkout tersedia."
+		return localize(user, msgCheckoutPending)
 	}
 	grossVal := parseAmountString(firstStringMap(checkout, "gross_amount"))
 	if grossVal == 0 {
@@ -2452,10 +2819,10 @@ func formatCheckoutInfo(checkout map[string]any, qrImageSent bool) string {
 	if netVal == 0 {
 		netVal = parseAmountString(firstStringMap(checkout, "saldo_masuk"))
 	}
-	summary := summarizeDepositAmounts(grossVal, feeVal, netVal)
+	summary := summarizeDepositAmounts(user, grossVal, feeVal, netVal, locale)
 	qrImage := firstStringMap(checkout, "qr_image")
 	qrString := firstStringMap(checkout, "qr_string")
-	expired := firstStringMap(checkout, "expired_at")
+	expired := formatExpiry(firstStringMap(checkout, "expired_at"), loc)
 	var builder strings.Builder
 	if summary != "" {
 		builder.WriteString(summary)
@@ -2463,16 +2830,19 @@ func formatCheckoutInfo(checkout map[string]any, qrImageSent bool) string {
 	}
 	if qrImage != "" {
 		if qrImageSent {
-			builder.WriteString("QR sudah kukirim sebagai gambar terpisah.\n")
+			builder.WriteString(localize(user, msgQRSent) + "\n")
 		} else {
-			builder.WriteString(fmt.Sprintf("Scan QR berikut: %s\n", qrImage))
+			builder.WriteString(localize(user, msgQRScan, qrImage) + "\n")
 		}
 	}
 	if qrString != "" && !qrImageSent {
 		builder.WriteString(fmt.Sprintf("QR String: %s\n", qrString))
 	}
+	if payURL := firstStringMap(checkout, "redirect_url"); payURL != "" {
+		builder.WriteString(localize(user, msgPayURL, payURL) + "\n")
+	}
 	if expired != "" {
-		builder.WriteString(fmt.Sprintf("Berlaku sampai: %s\n", expired))
+		builder.WriteString(localize(user, msgValidUntil, expired) + "\n")
 	}
 	if builder.Len() == 0 {
 		data, err := json.MarshalIndent(checkout, "", "  ")
@@ -2485,9 +2855,9 @@ func formatCheckoutInfo(checkout map[string]any, qrImageSent bool) string {
 }
 
 // formatBankTransferInfo formats bank transfer details (BRI, etc.) from Atlantic checkout response.
-func formatBankTransferInfo(checkout map[string]any) string {
+func formatBankTransferInfo(user *repo.User, checkout map[string]any, locale money.Locale, loc *time.Location) string {
 	if len(checkout) == 0 {
-		return "Instruksi transfer akan dikirim setelah tersedia."
+		return localize(user, msgBankTransferPending)
 	}
 	bank := firstStringMap(checkout, "bank")
 	if bank == "" {
@@ -2548,29 +2918,31 @@ func formatBankTransferInfo(checkout map[string]any) string {
 	if tambahan == "" {
 		tambahan = firstStringMap(checkout, "unique_code")
 	}
-	expired := firstStringMap(checkout, "expired_at")
+	expired := formatExpiry(firstStringMap(checkout, "expired_at"), loc)
 
 	var sb strings.Builder
-	sb.WriteString("\n🏦 *TRANSFER BANK*\n")
+	sb.WriteString("\n" + localize(user, msgBankTransferHeader) + "\n")
 	if bank != "" {
 		sb.WriteString(fmt.Sprintf("Bank: *%s*\n", strings.ToUpper(bank)))
 	}
 	if tujuan != "" {
-		sb.WriteString(fmt.Sprintf("No. Rekening: *%s*\n", tujuan))
+		sb.WriteString(localize(user, msgBankAccountNo, tujuan) + "\n")
 	}
 	if atasNama != "" {
-		sb.WriteString(fmt.Sprintf("Atas Nama: *%s*\n", atasNama))
+		sb.WriteString(localize(user, msgBankAccountName, atasNama) + "\n")
 	}
-	if nominal != "" {
-		sb.WriteString(fmt.Sprintf("Nominal: *Rp %s*\n", nominal))
+	if amount := parseAmountString(nominal); amount > 0 {
+		sb.WriteString(localize(user, msgBankAmount, formatCurrency(locale, money.FromRupiah(amount))) + "\n")
+	} else if nominal != "" {
+		sb.WriteString(localize(user, msgBankAmount, "Rp "+nominal) + "\n")
 	}
 	if tambahan != "" {
-		sb.WriteString(fmt.Sprintf("Kode Unik: *%s*\n", tambahan))
+		sb.WriteString(localize(user, msgBankUniqueCode, tambahan) + "\n")
 	}
 	if expired != "" {
-		sb.WriteString(fmt.Sprintf("Berlaku sampai: %s\n", expired))
+		sb.WriteString(localize(user, msgValidUntil, expired) + "\n")
 	}
-	sb.WriteString("\n⚠️ Transfer *tepat* sesuai nominal agar deposit otomatis terverifikasi.")
+	sb.WriteString("\n" + localize(user, msgBankExactAmount))
 	return strings.TrimSpace(sb.String())
 }
 
@@ -2630,7 +3002,7 @@ func stringValue(meta map[string]any, key string) string {
 }
 
 func (e *Engine) allowMediaRequest(ctx context.Context, userID, mediaType string) bool {
-	if e.cache == nil {
+	if !e.cacheAvailable() {
 		return true
 	}
 	key := fmt.Sprintf("rl:media:%s:%s", mediaType, userID)
@@ -2661,6 +3033,8 @@ func detectMessageType(evt *events.Message) string {
 		return "audio"
 	case msg.DocumentMessage != nil:
 		return "document"
+	case wa.InteractiveSelection(msg) != "":
+		return "interactive_reply"
 	default:
 		return "unknown"
 	}
@@ -2668,6 +3042,10 @@ func detectMessageType(evt *events.Message) string {
 
 func extractText(evt *events.Message) string {
 	msg := evt.Message
+	// A tapped list row or button carries the command it stands for.
+	if selection := wa.InteractiveSelection(msg); selection != "" {
+		return selection
+	}
 	switch {
 	case msg.GetConversation() != "":
 		return strings.TrimSpace(msg.GetConversation())
@@ -2696,6 +3074,7 @@ func (e *Engine) buildConversationContext(ctx context.Context, userID string) (s
 		e.logger.Debug("failed fetching message history", "error", err)
 		return "", ""
 	}
+	messages = sinceContextReset(messages)
 	if len(messages) == 0 {
 		return "", ""
 	}
@@ -2737,12 +3116,12 @@ func generateRefID(prefix string) string {
 	return fmt.Sprintf("%s-%s", prefix, strings.ReplaceAll(uuid.NewString(), "-", "")[:16])
 }
 
-func helpMessage() string {
-	return "Aku menyediakan berbagai layanan digital:\n\n📱 *Pulsa & Paket Data* - Telkomsel, Indosat, XL, Tri, Smartfren\n🎮 *Top Up Game* - Mobile Legends, Free Fire, PUBG, dll\n⚡ *Token Listrik* - Prabayar & Pascabayar\n💳 *Bayar Tagihan* - PLN, PDAM, BPJS, dll\n💰 *Deposit & Transfer* - QRIS, Bank Transfer, E-wallet\n\nContoh penggunaan:\n• \"pulsa telkomsel 20k\" - cek harga pulsa\n• \"budget 5000\" - tampilkan produk ≤5000\n• \"top up ML 12345\" - beli diamond Mobile Legends\n• \"cek tagihan PLN 123456\" - cek tagihan listrik"
-}
-
-func paymentInfoMessage() string {
-	return "Metode pembayaran yang tersedia:\n\n🏦 *BRI* — Transfer via Bank BRI\n📱 *QRIS* — Scan QR Code (bisa pakai e-wallet apapun)\n💰 *Saldo Deposit* — Pakai saldo yang sudah didepositkan\n\nCara bayar:\n• Ketik \"beli [kode produk] [ID tujuan]\" lalu pilih metode\n• Contoh: \"beli ML3 12345678(2126) via qris\"\n• Atau beli dulu, nanti akan ditanya mau bayar pakai apa"
+// greeting opens template replies, naming the shop when one is configured.
+func (e *Engine) greeting(user *repo.User) string {
+	if name := strings.TrimSpace(e.cfg.Persona.ShopName); name != "" {
+		return localize(user, msgGreetingShop, name)
+	}
+	return localize(user, msgGreeting)
 }
 
 func looksLikePaymentQuery(text string) bool {
@@ -2764,12 +3143,13 @@ func (e *Engine) depositFeeAmount(gross int64) int64 {
 	if gross <= 0 {
 		return 0
 	}
-	fee := e.cfg.DepositFeeFixed
+	t := e.tunables()
+	fee := t.DepositFeeFixed
 	if fee < 0 {
 		fee = 0
 	}
-	if percent := e.cfg.DepositFeePercent; percent > 0 {
-		fee += int64(math.Ceil(float64(gross) * percent))
+	if percent := t.DepositFeePercent; percent > 0 {
+		fee += money.FromRupiah(gross).MulRateCeil(percent).Rupiah()
 	}
 	if fee < 0 {
 		fee = 0
@@ -2784,11 +3164,12 @@ func (e *Engine) requiredDepositGross(targetNet int64) int64 {
 	if targetNet <= 0 {
 		return targetNet
 	}
-	if e.cfg.DepositFeePercent <= 0 && e.cfg.DepositFeeFixed <= 0 {
+	t := e.tunables()
+	if t.DepositFeePercent <= 0 && t.DepositFeeFixed <= 0 {
 		return targetNet
 	}
-	percent := e.cfg.DepositFeePercent
-	fixed := e.cfg.DepositFeeFixed
+	percent := t.DepositFeePercent
+	fixed := t.DepositFeeFixed
 	gross := targetNet
 	if percent > 0 && percent < 0.99 {
 		estimate := (float64(targetNet) + float64(fixed)) / (1 - percent)
@@ -2812,10 +3193,29 @@ func (e *Engine) requiredDepositGross(targetNet int64) int64 {
 	return gross
 }
 
+// fetchPriceList returns the curated price list at selling prices: supplier
+// cost marked up by the pricing rules, or the flash sale price while one
+// runs. Products carry their display names. It falls back to the local cache
+// on supplier errors.
 func (e *Engine) fetchPriceList(ctx context.Context, productType string) ([]atl.PriceListItem, bool, error) {
+	items, cached, err := e.fetchRawPriceList(ctx, productType)
+	if err != nil {
+		return nil, false, err
+	}
+	items = applyCuration(items, e.curationRules(ctx))
+	items = e.productNames(ctx).Apply(items)
+	items = e.sellingRules(ctx).Apply(items)
+	return applyFlashSales(items, e.flashSales(ctx), time.Now()), cached, nil
+}
+
+func (e *Engine) fetchRawPriceList(ctx context.Context, productType string) ([]atl.PriceListItem, bool, error) {
+	if pinned, ok := e.pinnedPriceList(ctx, productType); ok {
+		return pinned, false, nil
+	}
 	items, err := e.atl.PriceList(ctx, productType, false)
 	if err == nil && len(items) > 0 {
 		e.storePriceCache(productType, items)
+		e.recordCatalogSnapshot(ctx, productType, items)
 		return items, false, nil
 	}
 	if cached, ok := e.getPriceCache(productType); ok && len(cached) > 0 {
@@ -2841,6 +3241,15 @@ func (e *Engine) storePriceCache(productType string, items []atl.PriceListItem)
 	}
 }
 
+// InvalidatePriceCache drops the in-process price lists for the given product types.
+func (e *Engine) InvalidatePriceCache(productTypes ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, productType := range productTypes {
+		delete(e.priceCache, productType)
+	}
+}
+
 func (e *Engine) getPriceCache(productType string) ([]atl.PriceListItem, bool) {
 	e.mu.RLock()
 	entry, ok := e.priceCache[productType]
@@ -2858,7 +3267,7 @@ func (e *Engine) getPriceCache(productType string) ([]atl.PriceListItem, bool) {
 	copy(clone, entry.items)
 	return clone, true
 }
-func summarizeDepositAmounts(gross, fee, net int64) string {
+func summarizeDepositAmounts(user *repo.User, gross, fee, net int64, locale money.Locale) string {
 	if gross <= 0 && net <= 0 {
 		return ""
 	}
@@ -2871,13 +3280,13 @@ func summarizeDepositAmounts(gross, fee, net int64) string {
 	}
 	parts := make([]string, 0, 3)
 	if gross > 0 {
-		parts = append(parts, fmt.Sprintf("Tagihan: %s", formatCurrency(float64(gross))))
+		parts = append(parts, localize(user, msgAmountBilled, formatCurrency(locale, money.FromRupiah(gross))))
 	}
 	if fee > 0 {
-		parts = append(parts, fmt.Sprintf("Biaya: %s", formatCurrency(float64(fee))))
+		parts = append(parts, localize(user, msgAmountFee, formatCurrency(locale, money.FromRupiah(fee))))
 	}
 	if net > 0 {
-		parts = append(parts, fmt.Sprintf("Saldo masuk: %s", formatCurrency(float64(net))))
+		parts = append(parts, localize(user, msgAmountCredited, formatCurrency(locale, money.FromRupiah(net))))
 	}
 	if len(parts) == 0 {
 		return ""
@@ -2890,7 +3299,7 @@ func parseAmountString(value string) int64 {
 	if trimmed == "" {
 		return 0
 	}
-	if amt, err := parseAmount(trimmed); err == nil {
+	if amt, err := parseExactAmount(trimmed); err == nil {
 		return amt
 	}
 	clean := strings.ReplaceAll(trimmed, ".", "")
@@ -0,0 +1,69 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+// flashSaleTTL bounds how long a newly scheduled sale takes to be picked up.
+// Windows themselves are checked against the clock on every lookup, so a
+// sale starts and ends on time regardless of the cache.
+const flashSaleTTL = time.Minute
+
+type flashSaleCache struct {
+	sales   []repo.FlashSale
+	expires time.Time
+}
+
+func (e *Engine) flashSales(ctx context.Context) []repo.FlashSale {
+	e.mu.RLock()
+	cached := e.flashSaleCache
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.sales
+	}
+
+	sales, err := e.repo.ListFlashSales(ctx, time.Now())
+	if err != nil {
+		e.logger.Warn("failed loading flash sales", "error", err)
+		return cached.sales
+	}
+
+	e.mu.Lock()
+	e.flashSaleCache = flashSaleCache{sales: sales, expires: time.Now().Add(flashSaleTTL)}
+	e.mu.Unlock()
+	return sales
+}
+
+// flashPrice returns the sale price for code when a flash sale is running at now.
+func flashPrice(sales []repo.FlashSale, code string, now time.Time) (money.Money, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, sale := range sales {
+		if sale.Active(now) && strings.EqualFold(sale.ProductCode, code) {
+			return money.FromRupiah(sale.Price), true
+		}
+	}
+	return 0, false
+}
+
+// applyFlashSales swaps in the sale price of every product with a running
+// flash sale. Items are copied, so cached price lists keep supplier prices
+// and revert as soon as the window ends.
+func applyFlashSales(items []atl.PriceListItem, sales []repo.FlashSale, now time.Time) []atl.PriceListItem {
+	if len(sales) == 0 {
+		return items
+	}
+	res := make([]atl.PriceListItem, len(items))
+	copy(res, items)
+	for i := range res {
+		if price, ok := flashPrice(sales, res[i].Code, now); ok {
+			res[i].Price = price
+		}
+	}
+	return res
+}
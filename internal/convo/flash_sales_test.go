@@ -0,0 +1,38 @@
+package convo
+
+import (
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestApplyFlashSales(t *testing.T) {
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	sales := []repo.FlashSale{
+		{ProductCode: "tsel25", Price: 23500, StartsAt: start, EndsAt: start.Add(time.Hour)},
+		{ProductCode: "ML86", Price: 18000, StartsAt: start.Add(2 * time.Hour), EndsAt: start.Add(3 * time.Hour)},
+	}
+	items := []atl.PriceListItem{
+		{Code: "TSEL25", Price: money.FromRupiah(25500)},
+		{Code: "ML86", Price: money.FromRupiah(21000)},
+	}
+
+	during := applyFlashSales(items, sales, start.Add(30*time.Minute))
+	if during[0].Price != money.FromRupiah(23500) || during[1].Price != money.FromRupiah(21000) {
+		t.Fatalf("prices during first sale = %v, %v", during[0].Price, during[1].Price)
+	}
+	if items[0].Price != money.FromRupiah(25500) {
+		t.Fatalf("applyFlashSales modified its input")
+	}
+
+	after := applyFlashSales(items, sales, start.Add(time.Hour))
+	if after[0].Price != money.FromRupiah(25500) {
+		t.Fatalf("price after window = %v, want supplier price", after[0].Price)
+	}
+	if _, ok := flashPrice(sales, "ml86", start.Add(2*time.Hour)); !ok {
+		t.Fatalf("second sale not active at its start")
+	}
+}
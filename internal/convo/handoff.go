@@ -0,0 +1,161 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Admins give a conversation back to the bot by replying "LANJUT <nomor>",
+// spelled out for the same reason as approval replies.
+var resumeReplyPattern = regexp.MustCompile(`(?i)^(lanjut|resume)\s+(\+?[0-9][0-9 -]{4,20}[0-9])$`)
+
+// handedOff reports whether a human operator is handling the user, in which
+// case the bot must not answer them. Admins are never handed off. A lookup
+// failure lets the bot answer rather than go silent.
+func (e *Engine) handedOff(ctx context.Context, evt *events.Message, user *repo.User) bool {
+	if e.isAdmin(evt.Info.MessageSource) {
+		return false
+	}
+	_, err := e.repo.GetHandoff(ctx, user.ID)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		e.logger.Warn("failed checking operator handoff", "error", err, "user_id", user.ID)
+	}
+	return err == nil
+}
+
+// handleTalkToHuman hands the user's conversation to the admins. Sent by an
+// admin, it lists the conversations operators are handling instead.
+func (e *Engine) handleTalkToHuman(ctx context.Context, evt *events.Message, user *repo.User, text string) error {
+	if e.isAdmin(evt.Info.MessageSource) {
+		return e.handleListHandoffs(ctx, evt, user)
+	}
+	if len(e.cfg.AdminJIDs) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgHandoffUnavailable), "handoff_unavailable")
+	}
+	phone := userPhone(evt.Info.MessageSource, user)
+	handoff, err := e.repo.StartHandoff(ctx, repo.Handoff{
+		UserID:  user.ID,
+		ChatJID: evt.Info.Sender.String(),
+		Phone:   optionalString(phone),
+		Reason:  optionalString(truncateForPrompt(text, 300)),
+	})
+	if err != nil {
+		return fmt.Errorf("start handoff: %w", err)
+	}
+	e.logger.Info("conversation handed to operator", "user_id", user.ID, "chat_jid", handoff.ChatJID)
+
+	name := evt.Info.Sender.User
+	if user.DisplayName != nil && *user.DisplayName != "" {
+		name = fmt.Sprintf("%s (%s)", *user.DisplayName, evt.Info.Sender.User)
+	}
+	key := handoffKey(*handoff)
+	e.notifyAdmins(ctx, fmt.Sprintf("Permintaan bantuan operator dari %s:\n\"%s\"\n\nBot berhenti membalas user ini. Balas dari akun WhatsApp bot, lalu kirim LANJUT %s untuk mengaktifkan bot lagi.",
+		name, truncateForPrompt(text, 300), key))
+
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgHandoffStarted), "handoff_started")
+}
+
+func (e *Engine) handleListHandoffs(ctx context.Context, evt *events.Message, user *repo.User) error {
+	handoffs, err := e.repo.ListHandoffs(ctx)
+	if err != nil {
+		return fmt.Errorf("list handoffs: %w", err)
+	}
+	if len(handoffs) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Tidak ada percakapan yang sedang ditangani operator.", "handoff_list")
+	}
+	loc := e.userZone(ctx, user.ID)
+	var sb strings.Builder
+	sb.WriteString("Percakapan yang ditangani operator:")
+	for i, h := range handoffs {
+		sb.WriteString(fmt.Sprintf("\n%d. %s sejak %s", i+1, handoffKey(h), h.StartedAt.In(loc).Format("02 Jan 15:04")))
+		if h.Reason != nil && *h.Reason != "" {
+			sb.WriteString(fmt.Sprintf(" — \"%s\"", truncateForPrompt(*h.Reason, 60)))
+		}
+	}
+	sb.WriteString("\n\nKirim LANJUT <nomor> untuk mengaktifkan bot lagi.")
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, sb.String(), "handoff_list")
+}
+
+// parseResumeReply recognises an admin giving a conversation back to the bot.
+func (e *Engine) parseResumeReply(sender types.MessageSource, text string) (*nlu.IntentResult, bool) {
+	m := resumeReplyPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil || !e.isAdmin(sender) {
+		return nil, false
+	}
+	return &nlu.IntentResult{
+		Intent:     "resume_bot",
+		Confidence: 1,
+		Entities:   map[string]string{"number": m[2]},
+	}, true
+}
+
+func (e *Engine) handleResumeBot(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	number := intent.Entities["number"]
+	handoffs, err := e.repo.ListHandoffs(ctx)
+	if err != nil {
+		return fmt.Errorf("list handoffs: %w", err)
+	}
+	var match *repo.Handoff
+	for i := range handoffs {
+		if handoffMatches(handoffs[i], number) {
+			match = &handoffs[i]
+			break
+		}
+	}
+	if match == nil {
+		reply := fmt.Sprintf("Tidak ada percakapan operator untuk %s. Kirim /operator untuk melihat daftarnya.", number)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "handoff_resume_unknown")
+	}
+	if err := e.repo.EndHandoff(ctx, match.UserID); err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("end handoff: %w", err)
+	}
+	e.logger.Info("conversation resumed by bot", "user_id", match.UserID, "resumed_by", evt.Info.Sender.String())
+
+	if jid, err := types.ParseJID(match.ChatJID); err == nil {
+		var handedBack *repo.User
+		if u, err := e.repo.GetUserByID(ctx, match.UserID); err == nil {
+			handedBack = u
+		}
+		notice := localize(handedBack, msgHandoffResumed)
+		if err := e.respondAndLog(ctx, jid, match.UserID, notice, "handoff_resumed"); err != nil {
+			e.logger.Warn("failed notifying user of resumed bot", "error", err, "user_id", match.UserID)
+		}
+	}
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, fmt.Sprintf("Bot aktif lagi untuk %s.", handoffKey(*match)), "handoff_resume")
+}
+
+// handoffKey is how admins name a handed-off conversation: the user's phone
+// number, or the chat address when WhatsApp hides the number behind a LID.
+func handoffKey(h repo.Handoff) string {
+	if h.Phone != nil && *h.Phone != "" {
+		return *h.Phone
+	}
+	if jid, err := types.ParseJID(h.ChatJID); err == nil {
+		return jid.User
+	}
+	return h.ChatJID
+}
+
+// handoffMatches reports whether number, as an admin typed it, names h.
+// Local numbers (08...) match their international form (628...).
+func handoffMatches(h repo.Handoff, number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + digits[1:]
+	}
+	return digits != "" && (digits == handoffKey(h) || strings.HasPrefix(h.ChatJID, digits+"@"))
+}
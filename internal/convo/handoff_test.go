@@ -0,0 +1,42 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseResumeReply(t *testing.T) {
+	e := &Engine{cfg: EngineConfig{AdminJIDs: []string{"+6281234567890"}}}
+	admin := types.MessageSource{Sender: types.NewJID("6281234567890", types.DefaultUserServer)}
+	stranger := types.MessageSource{Sender: types.NewJID("6289999999999", types.DefaultUserServer)}
+
+	intent, ok := e.parseResumeReply(admin, "lanjut 0812-3456-7890")
+	if !ok || intent.Intent != "resume_bot" || intent.Entities["number"] != "0812-3456-7890" {
+		t.Fatalf("parseResumeReply = %+v, %v", intent, ok)
+	}
+	if _, ok := e.parseResumeReply(stranger, "LANJUT 081234567890"); ok {
+		t.Fatalf("non-admin resume was accepted")
+	}
+	if _, ok := e.parseResumeReply(admin, "lanjut aja"); ok {
+		t.Fatalf("ordinary text parsed as a resume")
+	}
+}
+
+func TestHandoffMatches(t *testing.T) {
+	phone := "6281234567890"
+	byPhone := repo.Handoff{ChatJID: "123456789@lid", Phone: &phone}
+	byLID := repo.Handoff{ChatJID: "123456789@lid"}
+
+	if !handoffMatches(byPhone, "0812-3456-7890") || !handoffMatches(byPhone, "+62 812 3456 7890") {
+		t.Fatalf("phone number did not match")
+	}
+	if handoffKey(byLID) != "123456789" || !handoffMatches(byLID, "123456789") {
+		t.Fatalf("LID conversation did not match its chat address")
+	}
+	if handoffMatches(byPhone, "0899") {
+		t.Fatalf("unrelated number matched")
+	}
+}
@@ -0,0 +1,100 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// historyPageSize is how many orders one "riwayat" reply lists.
+const historyPageSize = 5
+
+// handleOrderHistory lists the user's latest orders, one page at a time.
+func (e *Engine) handleOrderHistory(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	page, err := strconv.Atoi(strings.TrimSpace(intent.Entities["page"]))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	// One extra row tells whether there is a next page.
+	orders, err := e.repo.ListOrdersByUser(ctx, user.ID, historyPageSize+1, (page-1)*historyPageSize)
+	if err != nil {
+		return fmt.Errorf("list order history: %w", err)
+	}
+	reply := formatOrderHistory(orders, page, e.currencyLocale(user), user.Location())
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "order_history")
+}
+
+func formatOrderHistory(orders []repo.Order, page int, locale money.Locale, loc *time.Location) string {
+	if len(orders) == 0 {
+		if page > 1 {
+			return "Tidak ada transaksi lagi di halaman itu. Ketik /riwayat untuk melihat yang terbaru."
+		}
+		return "Belum ada transaksi. Ketik nama produk yang kamu cari, contoh: \"pulsa telkomsel 20k\"."
+	}
+	more := len(orders) > historyPageSize
+	if more {
+		orders = orders[:historyPageSize]
+	}
+
+	var sb strings.Builder
+	if page > 1 {
+		fmt.Fprintf(&sb, "Riwayat transaksi kamu (halaman %d):\n", page)
+	} else {
+		sb.WriteString("Riwayat transaksi terakhir kamu:\n")
+	}
+	for i, order := range orders {
+		fmt.Fprintf(&sb, "\n%d. %s · %s", (page-1)*historyPageSize+i+1, order.CreatedAt.In(loc).Format("02/01 15:04"), order.ProductCode)
+		if order.Amount > 0 {
+			fmt.Fprintf(&sb, " · %s", formatCurrency(locale, money.FromRupiah(order.Amount)))
+		}
+		fmt.Fprintf(&sb, " · *%s*", orderStatusLabel(order.Status))
+		details := []string{"Ref " + order.OrderRef}
+		if target := stringValue(order.Metadata, "customer_id"); target != "" {
+			details = append(details, "Tujuan "+target)
+		}
+		if sn := stringValue(order.Metadata, "sn"); sn != "" {
+			details = append(details, "SN "+sn)
+		}
+		sb.WriteString("\n   " + strings.Join(details, " · "))
+	}
+	if more {
+		fmt.Fprintf(&sb, "\n\nKetik /riwayat %d untuk transaksi sebelumnya.", page+1)
+	}
+	sb.WriteString("\nCek detail satu transaksi dengan /status REF.")
+	return sb.String()
+}
+
+func orderStatusLabel(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "success":
+		return "SUKSES"
+	case "failed":
+		return "GAGAL"
+	case "awaiting_payment":
+		return "MENUNGGU PEMBAYARAN"
+	case "pending", "process", "processing":
+		return "DIPROSES"
+	case "":
+		return "UNKNOWN"
+	default:
+		return strings.ToUpper(status)
+	}
+}
+
+func looksLikeHistoryQuery(text string) bool {
+	return text == "riwayat" ||
+		strings.Contains(text, "riwayat transaksi") ||
+		strings.Contains(text, "riwayat order") ||
+		strings.Contains(text, "riwayat pesanan") ||
+		strings.Contains(text, "riwayat pembelian") ||
+		strings.Contains(text, "histori transaksi") ||
+		strings.Contains(text, "history transaksi")
+}
@@ -0,0 +1,154 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/handlers"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestFormatOrderHistory(t *testing.T) {
+	created := time.Date(2026, 10, 16, 7, 5, 0, 0, time.UTC)
+	orders := make([]repo.Order, historyPageSize+1)
+	for i := range orders {
+		orders[i] = repo.Order{OrderRef: fmt.Sprintf("trx-%d", i), ProductCode: "TSEL25", Amount: 25500, Status: "processing", CreatedAt: created}
+	}
+	orders[0].Status = "success"
+	orders[0].Metadata = map[string]any{"customer_id": "081234567890", "sn": "SN123"}
+
+	got := formatOrderHistory(orders, 2, money.LocaleFor("id", false), repo.DefaultZone)
+	for _, want := range []string{
+		"(halaman 2)",
+		"6. 16/10 14:05 · TSEL25 · Rp25.500 · *SUKSES*\n   Ref trx-0 · Tujuan 081234567890 · SN SN123",
+		"7. 16/10 14:05 · TSEL25 · Rp25.500 · *DIPROSES*",
+		"/riwayat 3",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("history missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "trx-5") {
+		t.Fatalf("lookahead row listed:\n%s", got)
+	}
+
+	if got := formatOrderHistory(orders[:2], 1, money.LocaleFor("id", false), repo.DefaultZone); strings.Contains(got, "/riwayat 2") {
+		t.Fatalf("last page offers a next page:\n%s", got)
+	}
+}
+
+func TestLooksLikeHistoryQuery(t *testing.T) {
+	for text, want := range map[string]bool{
+		"riwayat":                       true,
+		"mau lihat riwayat transaksi":   true,
+		"history transaksi bulan ini":   true,
+		"riwayat chat dihapus aja":      false,
+		"cek status transaksi trx-1234": false,
+	} {
+		if got := looksLikeHistoryQuery(text); got != want {
+			t.Fatalf("looksLikeHistoryQuery(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+// historyRepo keeps orders the way the database does: UpdateOrderStatus
+// replaces the metadata.
+type historyRepo struct {
+	repo.Repository
+	orders map[string]repo.Order
+}
+
+func (r *historyRepo) GetOrderByRef(_ context.Context, ref string) (*repo.Order, error) {
+	order, ok := r.orders[ref]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return &order, nil
+}
+
+func (r *historyRepo) UpdateOrderStatus(_ context.Context, ref, status string, meta map[string]any) error {
+	order := r.orders[ref]
+	order.Status, order.Metadata = status, meta
+	r.orders[ref] = order
+	return nil
+}
+
+func (r *historyRepo) MarkOrderSucceeded(ctx context.Context, ref string, meta map[string]any) (bool, error) {
+	if r.orders[ref].Status == "success" {
+		return false, nil
+	}
+	return true, r.UpdateOrderStatus(ctx, ref, "success", meta)
+}
+
+func (r *historyRepo) ListOrdersByUser(context.Context, string, int, int) ([]repo.Order, error) {
+	var orders []repo.Order
+	for _, order := range r.orders {
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func (r *historyRepo) RecordProductOutcome(_ context.Context, code, _ string, success bool) (*repo.ProductStat, error) {
+	return &repo.ProductStat{ProductCode: code}, nil
+}
+
+func (r *historyRepo) ClaimWebhookEvent(context.Context, string, string, string) (bool, error) {
+	return true, nil
+}
+
+func (r *historyRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "6281111@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+func (r *historyRepo) InsertNotification(_ context.Context, n repo.Notification) (*repo.Notification, error) {
+	return &n, nil
+}
+
+func (r *historyRepo) UpdateNotification(context.Context, repo.Notification) error {
+	return nil
+}
+
+func (r *historyRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+func TestOrderHistoryAfterWebhook(t *testing.T) {
+	r := &historyRepo{orders: map[string]repo.Order{
+		"trx-1": {OrderRef: "trx-1", UserID: "u1", ProductCode: "TSEL25", Amount: 25500, Status: "pending", Metadata: map[string]any{"customer_id": "081234567890", "product_type": "pulsa"}},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gw := &textGateway{}
+	p := handlers.NewAtlanticWebhookProcessor(r, gw, nil, logger, nil, handlers.ProcessorConfig{})
+	ctx := context.Background()
+	err := p.HandleAtlanticEvent(ctx, atl.WebhookEvent{Type: "transaksi", Transaction: &atl.TransactionUpdate{
+		StatusUpdate: atl.StatusUpdate{Ref: "trx-1", RawStatus: "success", Status: "success"},
+		SN:           "SN123",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stringValue(r.orders["trx-1"].Metadata, "product_type"); got != "pulsa" {
+		t.Fatalf("product type after webhook = %q", got)
+	}
+
+	e := &Engine{repo: r, gateway: gw, logger: logger}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	if err := e.handleOrderHistory(ctx, evt, &repo.User{ID: "u1"}, &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := gw.sent[len(gw.sent)-1]; !strings.Contains(got, "Ref trx-1 · Tujuan 081234567890 · SN SN123") {
+		t.Fatalf("history after webhook:\n%s", got)
+	}
+}
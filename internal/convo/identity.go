@@ -0,0 +1,38 @@
+package convo
+
+import (
+	"context"
+	"errors"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// resolveWAID returns the WA ID the sender's user is keyed by. WhatsApp is
+// moving contacts from phone-number JIDs to LIDs, which also survive a number
+// change, so users are keyed by LID as soon as a message reveals one. A user
+// still keyed by the phone number is moved over, folding in any duplicate
+// created under the LID meanwhile, so balance and history carry across.
+func (e *Engine) resolveWAID(ctx context.Context, evt *events.Message) string {
+	sender := evt.Info.Sender.ToNonAD()
+	lid, pn := sender, evt.Info.SenderAlt.ToNonAD()
+	if sender.Server != types.HiddenUserServer {
+		lid, pn = pn, sender
+	}
+	if lid.Server != types.HiddenUserServer || lid.User == "" || pn.Server != types.DefaultUserServer || pn.User == "" {
+		return sender.String()
+	}
+
+	user, err := e.repo.MigrateUserWAID(ctx, pn.String(), lid.String())
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+	case err != nil:
+		// The next message retries; until then the LID user stands alone.
+		e.logger.Warn("failed migrating user to lid", "error", err, "pn", pn.String(), "lid", lid.String())
+	default:
+		e.logger.Info("migrated user to lid", "user_id", user.ID, "pn", pn.String(), "lid", lid.String())
+	}
+	return lid.String()
+}
@@ -0,0 +1,51 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type migrationRepo struct {
+	repo.Repository
+	calls [][2]string
+}
+
+func (r *migrationRepo) MigrateUserWAID(ctx context.Context, fromWAID, toWAID string) (*repo.User, error) {
+	r.calls = append(r.calls, [2]string{fromWAID, toWAID})
+	return &repo.User{ID: "u1", WAID: toWAID}, nil
+}
+
+func TestResolveWAIDPrefersLID(t *testing.T) {
+	pn := types.NewJID("6281234567890", types.DefaultUserServer)
+	lid := types.NewJID("123456789012345", types.HiddenUserServer)
+	store := &migrationRepo{}
+	e := &Engine{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), repo: store}
+
+	message := func(sender, alt types.JID) *events.Message {
+		return &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: sender, SenderAlt: alt}}}
+	}
+
+	if got := e.resolveWAID(context.Background(), message(pn, types.EmptyJID)); got != pn.String() {
+		t.Fatalf("without alt = %q, want phone number", got)
+	}
+	if len(store.calls) != 0 {
+		t.Fatalf("migrated without a lid: %v", store.calls)
+	}
+
+	for _, evt := range []*events.Message{message(lid, pn), message(pn, lid)} {
+		if got := e.resolveWAID(context.Background(), evt); got != lid.String() {
+			t.Fatalf("resolved %q, want lid", got)
+		}
+	}
+	want := [2]string{pn.String(), lid.String()}
+	if len(store.calls) != 2 || store.calls[0] != want || store.calls[1] != want {
+		t.Fatalf("migrations = %v, want phone number to lid", store.calls)
+	}
+}
@@ -0,0 +1,137 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxListRows is how many products fit in one WhatsApp list; longer lists
+// stay text.
+const maxListRows = 10
+
+// listPrompt heads a product list sent as a tappable menu.
+const listPrompt = "Pilih produknya dari daftar ya, nanti kutanyakan nomor tujuannya."
+
+// interactiveGateway is implemented by gateways that can send tappable
+// menus. Selections come back as the row or button ID, which is command text
+// ProcessMessage already understands.
+type interactiveGateway interface {
+	SendListMessage(ctx context.Context, to types.JID, title, body, buttonText string, sections []wa.ListSection) error
+	SendButtons(ctx context.Context, to types.JID, body, footer string, buttons []wa.Button) error
+}
+
+// paymentButtons answer the payment method prompt with the words
+// parseDraftReply accepts.
+var paymentButtons = []wa.Button{
+	{ID: "bri", Text: "🏦 BRI"},
+	{ID: "qris", Text: "📱 QRIS"},
+	{ID: "saldo", Text: "💰 Saldo"},
+}
+
+// auditingGateway is implemented by gateways that log every message they
+// send in the conversation log themselves.
+type auditingGateway interface {
+	AuditsOutbound() bool
+}
+
+func (e *Engine) outboundAudited() bool {
+	gw, ok := e.gateway.(auditingGateway)
+	return ok && gw.AuditsOutbound()
+}
+
+func (e *Engine) menus() (interactiveGateway, bool) {
+	if !e.cfg.InteractiveMenus {
+		return nil, false
+	}
+	gw, ok := e.gateway.(interactiveGateway)
+	return gw, ok
+}
+
+// respondWithList offers sections as a tappable list, falling back to the
+// text reply when lists are off, do not fit or fail to send. The text reply
+// is what gets logged either way.
+func (e *Engine) respondWithList(ctx context.Context, to types.JID, userID, text, body string, sections []wa.ListSection, category string) error {
+	gw, ok := e.menus()
+	if !ok || len(sections) == 0 {
+		return e.respondAndLog(ctx, to, userID, text, category)
+	}
+	sendCtx := wa.WithOrigin(ctx, wa.Origin{UserID: userID, Category: category, Transcript: e.cfg.Persona.Apply(text)})
+	if err := gw.SendListMessage(sendCtx, to, "", e.cfg.Persona.Apply(body), "Pilih produk", sections); err != nil {
+		e.logger.Warn("failed sending list message, falling back to text", "error", err, "category", category)
+		return e.respondAndLog(ctx, to, userID, text, category)
+	}
+	e.logOutgoing(ctx, userID, e.cfg.Persona.Apply(text), category)
+	return nil
+}
+
+// respondWithButtons is respondWithList for a short set of buttons.
+func (e *Engine) respondWithButtons(ctx context.Context, to types.JID, userID, text, body, footer string, buttons []wa.Button, category string) error {
+	gw, ok := e.menus()
+	if !ok {
+		return e.respondAndLog(ctx, to, userID, text, category)
+	}
+	sendCtx := wa.WithOrigin(ctx, wa.Origin{UserID: userID, Category: category, Transcript: e.cfg.Persona.Apply(text)})
+	if err := gw.SendButtons(sendCtx, to, e.cfg.Persona.Apply(body), footer, buttons); err != nil {
+		e.logger.Warn("failed sending buttons, falling back to text", "error", err, "category", category)
+		return e.respondAndLog(ctx, to, userID, text, category)
+	}
+	e.logOutgoing(ctx, userID, e.cfg.Persona.Apply(text), category)
+	return nil
+}
+
+// logOutgoing records a reply in the conversation log, unless the gateway
+// already logs everything it sends.
+func (e *Engine) logOutgoing(ctx context.Context, userID, text, category string) {
+	if e.outboundAudited() {
+		return
+	}
+	if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
+		UserID:    userID,
+		Direction: "outgoing",
+		Type:      category,
+		Content:   &text,
+	}); err != nil {
+		e.logger.Warn("failed logging outgoing message", "error", err)
+	}
+}
+
+// priceListSections turns the codes of a formatted price list into list rows
+// in the same order and numbering. Tapping a row sends "/beli CODE". It
+// returns nil when the list is too long for one message.
+func priceListSections(items []atl.PriceListItem, codes []string, locale money.Locale) []wa.ListSection {
+	if len(codes) == 0 || len(codes) > maxListRows {
+		return nil
+	}
+	byCode := make(map[string]atl.PriceListItem, len(items))
+	for _, item := range items {
+		if _, ok := byCode[item.Code]; !ok {
+			byCode[item.Code] = item
+		}
+	}
+	var sections []wa.ListSection
+	for i, code := range codes {
+		item := byCode[code]
+		category := strings.TrimSpace(item.Category)
+		if category == "" {
+			category = "Lainnya"
+		}
+		if len(sections) == 0 || sections[len(sections)-1].Title != category {
+			sections = append(sections, wa.ListSection{Title: category})
+		}
+		last := &sections[len(sections)-1]
+		last.Rows = append(last.Rows, wa.ListRow{
+			ID:          "/beli " + code,
+			Title:       fmt.Sprintf("#%d %s", i+1, item.Name),
+			Description: fmt.Sprintf("%s · %s", code, formatCurrency(locale, item.Price)),
+		})
+	}
+	return sections
+}
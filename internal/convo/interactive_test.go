@@ -0,0 +1,33 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func TestPriceListSections(t *testing.T) {
+	items := []atl.PriceListItem{
+		{Code: "TSEL25", Name: "Telkomsel 25K", Category: "Pulsa", Price: money.FromRupiah(25500)},
+		{Code: "TSEL50", Name: "Telkomsel 50K", Category: "Pulsa", Price: money.FromRupiah(50200)},
+		{Code: "ML86", Name: "86 Diamonds", Category: "Games", Price: money.FromRupiah(21000)},
+	}
+	_, codes := formatPriceList(items, false, money.Indonesian)
+	sections := priceListSections(items, codes, money.Indonesian)
+	if len(sections) != 2 || sections[0].Title != "Pulsa" || len(sections[0].Rows) != 2 {
+		t.Fatalf("sections = %+v", sections)
+	}
+	row := sections[0].Rows[1]
+	if row.ID != "/beli TSEL50" || row.Title != "#2 Telkomsel 50K" || row.Description != "TSEL50 · Rp50.200" {
+		t.Fatalf("row = %+v", row)
+	}
+	if intent, ok := parseCommand(row.ID); !ok || intent.Entities["product_code"] != "TSEL50" {
+		t.Fatalf("row id does not parse as a purchase: %+v", intent)
+	}
+
+	long := make([]string, maxListRows+1)
+	if got := priceListSections(items, long, money.Indonesian); got != nil {
+		t.Fatalf("long list got %d sections", len(got))
+	}
+}
@@ -0,0 +1,106 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// supplierQuoteTTL is how long a forced supplier price list answers margin
+// checks before the next purchase refreshes it again.
+const supplierQuoteTTL = 30 * time.Second
+
+// verifyMargin re-reads the supplier cost right before a purchase. While the
+// quoted price still covers cost plus the configured minimum margin, the
+// purchase goes ahead at the quoted price the user confirmed. Otherwise the
+// user is re-quoted with the fresh selling price (cost plus markup, never
+// less than cost plus the minimum margin) and the purchase is not executed.
+// A running flash sale skips the check: the operator chose that price, and
+// the user pays it unless it is above the quote. Once the window ends, an
+// order quoted at the sale price is re-quoted.
+// It returns the item to charge and whether the purchase may proceed.
+func (e *Engine) verifyMargin(ctx context.Context, evt *events.Message, user *repo.User, item *atl.PriceListItem, productType string) (*atl.PriceListItem, bool, error) {
+	fresh, err := e.supplierQuote(ctx, productType)
+	if err != nil {
+		// Without a fresh quote we cannot do better than the cached one; the
+		// supplier call that follows will surface real outages.
+		e.logger.Warn("margin check skipped, price refresh failed", "error", err, "product_code", item.Code)
+		return item, true, nil
+	}
+
+	var current *atl.PriceListItem
+	for i := range fresh {
+		if strings.EqualFold(fresh[i].Code, item.Code) {
+			current = &fresh[i]
+			break
+		}
+	}
+	if current == nil || (current.Status != "" && !strings.EqualFold(current.Status, "available")) {
+		reply := localize(user, msgProductUnavailable, item.Name, item.Code)
+		return item, false, e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "margin_guard_unavailable")
+	}
+
+	offer := *current
+	offer.Name = e.productNames(ctx).Name(*current)
+	offer.Price = e.sellingRules(ctx).Price(*current)
+	if price, ok := flashPrice(e.flashSales(ctx), current.Code, time.Now()); ok {
+		// The operator set this price for the sale window, even below cost.
+		offer.Price = price
+		if price < current.Price {
+			e.logger.Info("flash sale priced below supplier cost", "product_code", current.Code, "price", price.Rupiah(), "cost", current.Price.Rupiah())
+		}
+		if offer.Price <= item.Price {
+			return &offer, true, nil
+		}
+		return &offer, false, e.requote(ctx, evt, user, item, &offer)
+	}
+
+	sellPrice := item.Price
+	cost := current.Price
+	minMargin := e.tunables().MinMargin
+	if sellPrice-cost >= money.FromRupiah(minMargin) {
+		return item, true, nil
+	}
+
+	e.logger.Info("supplier price moved, re-quoting", "product_code", item.Code, "quoted", sellPrice.Rupiah(), "cost", cost.Rupiah(), "min_margin", minMargin)
+	return &offer, false, e.requote(ctx, evt, user, item, &offer)
+}
+
+// requote tells the user the price they were quoted for item changed to the
+// offer's and that the purchase was not made.
+func (e *Engine) requote(ctx context.Context, evt *events.Message, user *repo.User, item, offer *atl.PriceListItem) error {
+	locale := e.currencyLocale(user)
+	reply := localize(user, msgPriceChanged, item.Name, item.Code, formatCurrency(locale, item.Price), formatCurrency(locale, offer.Price))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "margin_guard_requote")
+}
+
+// supplierQuote returns the supplier price list, forcing a refresh unless
+// one was fetched within supplierQuoteTTL.
+func (e *Engine) supplierQuote(ctx context.Context, productType string) ([]atl.PriceListItem, error) {
+	e.mu.RLock()
+	entry, ok := e.supplierQuotes[productType]
+	e.mu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.items, nil
+	}
+
+	fresh, err := e.atl.PriceList(ctx, productType, true)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	if e.supplierQuotes == nil {
+		e.supplierQuotes = make(map[string]priceCacheEntry)
+	}
+	e.supplierQuotes[productType] = priceCacheEntry{items: fresh, expires: time.Now().Add(supplierQuoteTTL)}
+	e.mu.Unlock()
+	e.storePriceCache(productType, fresh)
+	e.recordCatalogSnapshot(ctx, productType, fresh)
+	return fresh, nil
+}
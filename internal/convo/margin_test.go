@@ -0,0 +1,72 @@
+package convo
+
+import (
+	"context"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestVerifyMarginChargesQuotedPrice(t *testing.T) {
+	items := []atl.PriceListItem{{Code: "TSEL10", Name: "Telkomsel 10.000", Price: money.FromRupiah(10000), Status: "available"}}
+	r := &catalogRepo{stateRepo: &stateRepo{states: map[string]repo.ConversationState{}}}
+	e, gw := newCatalogEngine(t, r, &items)
+	e.cfg.MinMargin = 500
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	quoted := &atl.PriceListItem{Code: "TSEL10", Name: "Telkomsel 10.000", Price: money.FromRupiah(11500)}
+
+	// The fresh selling price is only cost plus the minimum margin, but the
+	// quote still covers it, so the user pays what they confirmed.
+	item, proceed, err := e.verifyMargin(ctx, evt, user, quoted, "prabayar")
+	if err != nil || !proceed {
+		t.Fatalf("verifyMargin = %v, %v", proceed, err)
+	}
+	if item.Price.Rupiah() != 11500 {
+		t.Fatalf("charged %d, want the quoted 11500", item.Price.Rupiah())
+	}
+	if len(gw.sent) != 0 {
+		t.Fatalf("user told about a price change: %q", gw.sent)
+	}
+}
+
+func TestVerifyMarginRequoteClearsMinMargin(t *testing.T) {
+	items := []atl.PriceListItem{{Code: "TSEL10", Name: "Telkomsel 10.000", Price: money.FromRupiah(10000), Status: "available"}}
+	r := &catalogRepo{stateRepo: &stateRepo{states: map[string]repo.ConversationState{}}}
+	e, gw := newCatalogEngine(t, r, &items)
+	e.cfg.MinMargin = 500
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+
+	// Quoted at cost with no markup rule: the re-quote must add the margin,
+	// or confirming it would be refused again.
+	quoted := &atl.PriceListItem{Code: "TSEL10", Name: "Telkomsel 10.000", Price: money.FromRupiah(10000)}
+	offer, proceed, err := e.verifyMargin(ctx, evt, user, quoted, "prabayar")
+	if err != nil || proceed {
+		t.Fatalf("verifyMargin = %v, %v; want a re-quote", proceed, err)
+	}
+	if offer.Price.Rupiah() != 10500 {
+		t.Fatalf("re-quoted %d, want cost plus margin 10500", offer.Price.Rupiah())
+	}
+	if len(gw.sent) != 1 {
+		t.Fatalf("sent %d messages, want the re-quote", len(gw.sent))
+	}
+
+	// The supplier moves again, but the confirmation lands within the quote
+	// TTL and is checked against the list already fetched.
+	items[0].Price = money.FromRupiah(10400)
+	item, proceed, err := e.verifyMargin(ctx, evt, user, offer, "prabayar")
+	if err != nil || !proceed {
+		t.Fatalf("confirming the re-quote = %v, %v", proceed, err)
+	}
+	if item.Price.Rupiah() != 10500 {
+		t.Fatalf("charged %d, want 10500", item.Price.Rupiah())
+	}
+}
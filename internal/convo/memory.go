@@ -0,0 +1,117 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+)
+
+const (
+	defaultMemoryMinMessages = 20
+	// memoryTranscriptMessages bounds how much history one summary run reads.
+	memoryTranscriptMessages = 60
+	memoryBatchSize          = 20
+)
+
+// RunMemorySummaries periodically folds new conversation history into each
+// user's stored profile until ctx is cancelled. It does nothing when
+// MemorySummaryInterval is not set.
+func (e *Engine) RunMemorySummaries(ctx context.Context) {
+	if e.cfg.MemorySummaryInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(e.cfg.MemorySummaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.summariseMemories(ctx)
+		}
+	}
+}
+
+func (e *Engine) summariseMemories(ctx context.Context) {
+	minMessages := e.cfg.MemoryMinMessages
+	if minMessages <= 0 {
+		minMessages = defaultMemoryMinMessages
+	}
+	candidates, err := e.repo.ListMemoryCandidates(ctx, minMessages, memoryBatchSize)
+	if err != nil {
+		e.logger.Warn("failed listing memory candidates", "error", err)
+		return
+	}
+	// Summaries are background work; let the queue shed them before live chats.
+	ctx = nlu.WithPriority(ctx, nlu.PriorityLow)
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := e.summariseMemory(ctx, c); err != nil {
+			e.logger.Warn("failed summarising user memory", "error", err, "user_id", c.UserID)
+		}
+	}
+}
+
+func (e *Engine) summariseMemory(ctx context.Context, c repo.MemoryCandidate) error {
+	messages, err := e.repo.ListRecentMessages(ctx, c.UserID, memoryTranscriptMessages)
+	if err != nil {
+		return fmt.Errorf("load history: %w", err)
+	}
+	transcript := memoryTranscript(sinceContextReset(messages))
+	if transcript == "" {
+		return nil
+	}
+	summary, err := e.nlu.SummarizeConversation(ctx, c.PreviousSummary, transcript)
+	if err != nil {
+		return fmt.Errorf("summarise: %w", err)
+	}
+	if err := e.repo.UpsertUserMemory(ctx, repo.UserMemory{
+		UserID:       c.UserID,
+		Summary:      summary,
+		MessageCount: c.MessageCount,
+	}); err != nil {
+		return err
+	}
+	e.logger.Debug("user memory updated", "user_id", c.UserID, "messages", c.MessageCount)
+	return nil
+}
+
+// userMemory returns the stored profile of a user, or "" when there is none.
+func (e *Engine) userMemory(ctx context.Context, userID string) string {
+	mem, err := e.repo.GetUserMemory(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) {
+			e.logger.Debug("failed loading user memory", "error", err)
+		}
+		return ""
+	}
+	return mem.Summary
+}
+
+// memoryTranscript renders newest-first history as a chronological transcript.
+func memoryTranscript(messages []repo.MessageRecord) string {
+	lines := make([]string, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Content == nil {
+			continue
+		}
+		text := strings.TrimSpace(*msg.Content)
+		if text == "" {
+			continue
+		}
+		role := "User"
+		if msg.Direction == "outgoing" {
+			role = "Bot"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", role, truncateForPrompt(text, 200)))
+	}
+	return strings.Join(lines, "\n")
+}
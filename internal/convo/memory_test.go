@@ -0,0 +1,42 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+)
+
+func TestMemoryTranscript(t *testing.T) {
+	text := func(s string) *string { return &s }
+	// ListRecentMessages returns newest first.
+	messages := []repo.MessageRecord{
+		{Direction: "outgoing", Content: text("Pembayaran QRIS diterima, TSEL25 diproses.")},
+		{Direction: "incoming", Content: text("  ")},
+		{Direction: "incoming", Content: nil},
+		{Direction: "incoming", Content: text("beli tsel 25k ke 0812 via qris")},
+	}
+	got := memoryTranscript(messages)
+	want := "User: beli tsel 25k ke 0812 via qris\nBot: Pembayaran QRIS diterima, TSEL25 diproses."
+	if got != want {
+		t.Fatalf("memoryTranscript() = %q, want %q", got, want)
+	}
+	if memoryTranscript(nil) != "" {
+		t.Fatalf("empty history should give empty transcript")
+	}
+}
+
+func TestSinceContextReset(t *testing.T) {
+	text := func(s string) *string { return &s }
+	messages := []repo.MessageRecord{
+		{Direction: "incoming", Type: "text", Content: text("pulsa xl")},
+		{Direction: "outgoing", Type: contextResetType},
+		{Direction: "incoming", Type: "text", Content: text("beli tsel 25k")},
+	}
+	got := sinceContextReset(messages)
+	if len(got) != 1 || *got[0].Content != "pulsa xl" {
+		t.Fatalf("sinceContextReset kept %d messages, want only the one after the reset", len(got))
+	}
+	if memoryTranscript(sinceContextReset(messages[1:])) != "" {
+		t.Fatalf("history before the reset leaked into the transcript")
+	}
+}
@@ -0,0 +1,50 @@
+package convo
+
+import (
+	"context"
+	"strings"
+
+	"bot-jual/internal/repo"
+)
+
+// EventNotifier publishes order events to merchant integrations.
+type EventNotifier interface {
+	OrderCreated(ctx context.Context, order repo.Order)
+	OrderSucceeded(ctx context.Context, order repo.Order, sn string)
+}
+
+// SetEventNotifier publishes order.created and order.success for orders the
+// engine creates and completes synchronously.
+func (e *Engine) SetEventNotifier(events EventNotifier) {
+	e.events = events
+}
+
+func (e *Engine) orderCreated(ctx context.Context, order *repo.Order) {
+	if e.events == nil || order == nil {
+		return
+	}
+	e.events.OrderCreated(ctx, *order)
+}
+
+// orderSucceeded publishes order.success for ref, reloading the order so the
+// event carries its final status and metadata.
+func (e *Engine) orderSucceeded(ctx context.Context, ref, sn string) {
+	if e.events == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	order, err := e.repo.GetOrderByRef(ctx, ref)
+	if err != nil {
+		e.logger.Warn("failed loading order for merchant event", "error", err, "order_ref", ref)
+		return
+	}
+	e.events.OrderSucceeded(ctx, *order, sn)
+}
+
+func isSuccessStatus(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "success", "completed", "ok", "available":
+		return true
+	}
+	return false
+}
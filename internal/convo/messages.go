@@ -0,0 +1,426 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Reply languages. Users default to Indonesian; the catalog falls back to it
+// for any string without an English version yet.
+const (
+	langIndonesian = "id"
+	langEnglish    = "en"
+)
+
+// languageTags are what language_preference stores for each reply language.
+var languageTags = map[string]string{
+	langIndonesian: "id-ID",
+	langEnglish:    "en-US",
+}
+
+// msgKey names a user-facing reply in the message catalog.
+type msgKey int
+
+const (
+	msgGreeting msgKey = iota
+	msgGreetingShop
+	msgSmalltalk
+	msgHelp
+	msgPaymentInfo
+	msgFallback
+	msgNLUUnavailable
+	msgSelectionExpired
+	msgPurchaseBusy
+	msgPurchaseHeld
+	msgBillPaymentHeld
+	msgCancelOrderNone
+	msgCancelOrder
+	msgBalance
+	msgAtlanticBalance
+	msgResetContext
+	msgCurrencyFormatUsage
+	msgCurrencyFormatSet
+	msgHandoffUnavailable
+	msgHandoffStarted
+	msgHandoffResumed
+	msgLanguageUsage
+	msgLanguageSet
+	msgFlowExpired
+	msgCartExpired
+	msgTransferExpired
+	msgDraftMissing
+	msgProductNotFound
+	msgAskTarget
+	msgAskTargetOwnUnknown
+	msgAskZone
+	msgOwnNumberTarget
+	msgAskPayment
+	msgPaymentOptions
+	msgDraftChangeHint
+	msgDraftHeader
+	msgDraftProduct
+	msgDraftTarget
+	msgDraftTargets
+	msgDraftCustomerName
+	msgDraftUnitPrice
+	msgDraftQuantity
+	msgDraftTotal
+	msgDraftPrice
+	msgDraftVoucher
+	msgDraftRef
+	msgDraftNote
+	msgBalanceCheckFailed
+	msgInsufficientBalance
+	msgPrepaidQueued
+	msgPrepaidSystemError
+	msgPrepaidFailed
+	msgPrepaidProcessing
+	msgPrepaidSuccess
+	msgPrepaidRejected
+	msgPrepaidNotSucceeded
+	msgRetryProcessing
+	msgRetrySuccess
+	msgRetryFailed
+	msgRetryQueued
+	msgRetryUnprocessed
+	msgTransactionUnprocessed
+	msgCheckoutUnavailable
+	msgCheckoutFailed
+	msgCheckoutReady
+	msgCheckoutQRCaption
+	msgCheckoutShortfall
+	msgReceiptLine
+	msgProductInfo
+	msgBillMissingFields
+	msgBillInquiry
+	msgPayBillMissingRef
+	msgPayBillStatus
+	msgDepositInvalidAmount
+	msgDepositAskMethod
+	msgDepositUnavailable
+	msgDepositFailed
+	msgDepositReady
+	msgDepositQRCaption
+	msgDepositReminder
+	msgDepositAutoCancel
+	msgDepositExpired
+	msgDepositExpiredOrders
+	msgAmountBilled
+	msgAmountFee
+	msgAmountCredited
+	msgCheckoutPending
+	msgQRSent
+	msgQRScan
+	msgPayURL
+	msgValidUntil
+	msgBankTransferPending
+	msgBankTransferHeader
+	msgBankAccountNo
+	msgBankAccountName
+	msgBankAmount
+	msgBankUniqueCode
+	msgBankExactAmount
+	msgInsufficientBalanceQuantity
+	msgProductUnavailable
+	msgPriceChanged
+	msgPLNMeterNotFound
+	msgRepayUnavailable
+	msgRepayLookupFailed
+	msgRepayNone
+	msgRepayAlreadyPaid
+	msgRepayQRUnavailable
+	msgRepayQRFailed
+	msgRepayReadyBank
+	msgRepayQRCaption
+	msgRepayReady
+)
+
+const servicesEnglish = "📱 *Airtime & Data* - Telkomsel, Indosat, XL, Tri, Smartfren\n🎮 *Game Top Up* - Mobile Legends, Free Fire, PUBG, etc\n⚡ *Electricity Tokens* - Prepaid & Postpaid\n💳 *Bill Payments* - PLN, PDAM, BPJS, etc\n💰 *Deposit & Transfer* - QRIS, Bank Transfer, E-wallet"
+
+const servicesIndonesian = "📱 *Pulsa & Paket Data* - Telkomsel, Indosat, XL, Tri, Smartfren\n🎮 *Top Up Game* - Mobile Legends, Free Fire, PUBG, dll\n⚡ *Token Listrik* - Prabayar & Pascabayar\n💳 *Bayar Tagihan* - PLN, PDAM, BPJS, dll\n💰 *Deposit & Transfer* - QRIS, Bank Transfer, E-wallet"
+
+// messageCatalog holds every localized reply by language. Entries are
+// fmt.Sprintf formats when the reply carries values.
+var messageCatalog = map[string]map[msgKey]string{
+	langIndonesian: {
+		msgGreeting:                    "Halo!",
+		msgGreetingShop:                "Halo, selamat datang di %s!",
+		msgSmalltalk:                   "%s Aku menyediakan berbagai layanan digital:\n\n" + servicesIndonesian + "\n\nKetik nama produk yang kamu cari, contoh: \"pulsa telkomsel 20k\" atau \"top up ML\"",
+		msgHelp:                        "Aku menyediakan berbagai layanan digital:\n\n" + servicesIndonesian + "\n\nContoh penggunaan:\n• \"pulsa telkomsel 20k\" - cek harga pulsa\n• \"budget 5000\" - tampilkan produk ≤5000\n• \"top up ML 12345\" - beli diamond Mobile Legends\n• \"cek tagihan PLN 123456\" - cek tagihan listrik\n• \"tarik saldo 50rb ke bca 1234567890\" - tarik saldo ke rekening\n• \"cek rekening BCA 1234567890\" - cek nama pemilik rekening\n\n" + commandHelp,
+		msgPaymentInfo:                 "Metode pembayaran yang tersedia:\n\n🏦 *BRI* — Transfer via Bank BRI\n📱 *QRIS* — Scan QR Code (bisa pakai e-wallet apapun)\n💰 *Saldo Deposit* — Pakai saldo yang sudah didepositkan\n\nCara bayar:\n• Ketik \"beli [kode produk] [ID tujuan]\" lalu pilih metode\n• Contoh: \"beli ML3 12345678(2126) via qris\"\n• Atau beli dulu, nanti akan ditanya mau bayar pakai apa",
+		msgFallback:                    "Maaf, aku belum paham permintaanmu. Bisa jelaskan lagi?",
+		msgNLUUnavailable:              nluUnavailableMessage,
+		msgSelectionExpired:            "Nomor pilihan itu sudah tidak berlaku. Cek harga lagi ya, lalu balas #nomor dari daftar terbaru.",
+		msgPurchaseBusy:                purchaseBusyMessage,
+		msgPurchaseHeld:                purchaseHeldMessage,
+		msgBillPaymentHeld:             billPaymentHeldMessage,
+		msgCancelOrderNone:             "Tidak ada pesanan yang sedang disiapkan.",
+		msgCancelOrder:                 "Oke, pesanan %s (%s) dibatalkan. Kabari aja kalau mau beli yang lain ya.",
+		msgBalance:                     "Saldo kamu sekitar %s.",
+		msgAtlanticBalance:             "Saldo Atlantic kamu sekitar %s. Status akun: %s.",
+		msgResetContext:                "Oke, obrolan sebelumnya sudah kulupakan. Riwayat transaksi dan saldo kamu tetap aman. Mau cari apa sekarang?",
+		msgCurrencyFormatUsage:         "Pilih format angka: /format id (%s) atau /format en (%s). Ketik /format auto untuk mengikuti bahasa kamu.",
+		msgCurrencyFormatSet:           "Oke, nominal akan kutulis seperti %s.",
+		msgHandoffUnavailable:          "Maaf, admin belum bisa dihubungi lewat chat ini. Aku tetap bantu sebisanya ya, ceritakan kendalanya.",
+		msgHandoffStarted:              "Oke, aku sambungkan ke admin ya. Mulai sekarang admin yang akan membalas chat kamu, mohon ditunggu 🙏",
+		msgHandoffResumed:              "Bot aktif lagi ya. Kalau butuh admin lagi, ketik /operator.",
+		msgLanguageUsage:               "Pilih bahasa balasan: /bahasa untuk bahasa Indonesia atau /english untuk bahasa Inggris.",
+		msgLanguageSet:                 "Oke, mulai sekarang aku balas pakai bahasa Indonesia. Ketik /english to switch to English.",
+		msgFlowExpired:                 "Pesanan %s (%s) yang tadi belum selesai sudah kedaluwarsa karena lama tidak ada balasan, jadi aku batalkan. Kirim ulang pesanannya kalau masih mau beli ya.",
+		msgCartExpired:                 "Keranjang kamu (%d item) sudah kedaluwarsa karena lama tidak ada balasan, jadi aku kosongkan. Tambah lagi produknya kalau masih mau beli ya.",
+		msgTransferExpired:             "Transfer yang tadi belum selesai sudah kedaluwarsa karena lama tidak ada balasan, jadi aku batalkan. Saldo kamu tidak terpotong.",
+		msgDraftMissing:                "Belum ada pesanan yang sedang disiapkan. Mau beli apa?",
+		msgProductNotFound:             "Produk belum ditemukan. Sebutkan kode atau nama produk yang kamu inginkan ya.",
+		msgAskTarget:                   "Kamu mau beli %s (%s). Kirim nomor/ID tujuan ya.",
+		msgAskTargetOwnUnknown:         "Kamu mau beli %s (%s), tapi nomor WhatsApp kamu belum kelihatan dari sini. Kirim nomor/ID tujuannya ya.",
+		msgAskZone:                     "Untuk %s, butuh ID plus Server. Formatkan seperti 12345678(1234) ya.",
+		msgOwnNumberTarget:             "Nomor tujuan aku ambil dari nomor WhatsApp kamu: %s. Pastikan sudah benar ya.\n\n%s",
+		msgAskPayment:                  "Mau bayar pakai apa?",
+		msgPaymentOptions:              "🏦 *BRI* — Transfer Bank BRI\n📱 *QRIS* — Scan QR\n💰 *Saldo* — Pakai saldo deposit\n\nBalas: bri / qris / saldo",
+		msgDraftChangeHint:             "Mau ubah? Kirim misal \"ganti nomornya 0813...\", atau \"batal\".",
+		msgDraftHeader:                 "Pesanan kamu:",
+		msgDraftProduct:                "• Produk: %s (%s)",
+		msgDraftTarget:                 "• Tujuan: %s",
+		msgDraftTargets:                "• Tujuan (%d nomor): %s",
+		msgDraftCustomerName:           "• Nama pelanggan: %s",
+		msgDraftUnitPrice:              "• Harga: %d × %s",
+		msgDraftQuantity:               "• Jumlah: %d × %s",
+		msgDraftTotal:                  "• Total: %s",
+		msgDraftPrice:                  "• Harga: %s",
+		msgDraftVoucher:                "• Kode promo %s belum bisa dipakai, harga di atas tanpa potongan.",
+		msgDraftRef:                    "• Ref kamu: %s",
+		msgDraftNote:                   "• Catatan: %s",
+		msgBalanceCheckFailed:          "Gagal mengecek saldo. Coba lagi nanti ya.",
+		msgInsufficientBalance:         "Saldo kamu tidak mencukupi.\n\n💰 Saldo: %s\n🏷️ Harga: %s\n\nSilakan deposit dulu atau gunakan metode pembayaran lain (BRI/QRIS).\nKetik: \"deposit [jumlah]\" untuk top up saldo.",
+		msgPrepaidQueued:               "Lagi ada gangguan server. Transaksimu %s (%s) sudah ku antre ya. Ref: %s. Akan ku kabari begitu ada update.",
+		msgPrepaidSystemError:          "Transaksi belum bisa diproses karena gangguan sistem. Coba sebentar lagi ya.",
+		msgPrepaidFailed:               "Transaksi %s (%s) gagal diproses. %s",
+		msgPrepaidProcessing:           "Sip, transaksi %s (%s) lagi diproses. Ref: %s.",
+		msgPrepaidSuccess:              "Mantap, transaksi %s (%s) sukses! Ref: %s.",
+		msgPrepaidRejected:             "Transaksi gagal. Saldo deposit sepertinya belum cukup.",
+		msgPrepaidNotSucceeded:         "Waduh, transaksi %s (%s) belum berhasil. %s",
+		msgRetryProcessing:             "Update: transaksi %s (%s) lagi diproses. Ref: %s.",
+		msgRetrySuccess:                "Sukses: transaksi %s (%s) berhasil! Ref: %s.",
+		msgRetryFailed:                 "Maaf, transaksi %s (%s) belum berhasil. %s",
+		msgRetryQueued:                 "Masih ada gangguan server. Transaksimu %s (%s) tetap ku antre. Ref: %s.",
+		msgRetryUnprocessed:            "Maaf, transaksi %s (%s) belum bisa diproses. %s",
+		msgTransactionUnprocessed:      "Transaksi belum bisa diproses.",
+		msgCheckoutUnavailable:         "Belum bisa menyiapkan deposit untuk transaksi ini. Coba pilih metode lain atau ulangi sebentar lagi.",
+		msgCheckoutFailed:              "Belum bisa menyiapkan deposit: %s",
+		msgCheckoutReady:               "Sip, sudah kubuatin deposit via %s sebesar %s buat %s.\nRef deposit: %s\nRef order: %s%s\n%s",
+		msgCheckoutQRCaption:           "Deposit %s via %s untuk %s.",
+		msgCheckoutShortfall:           "Saldo masuk masih kurang %s dari harga produk. Tambah deposit ya supaya bisa ku proses.",
+		msgReceiptLine:                 "Bukti transaksi: %s",
+		msgProductInfo:                 "Info produk:",
+		msgBillMissingFields:           "Butuh kode produk dan ID pelanggan ya. Contoh: \"cek tagihan PLN 123456\".",
+		msgBillInquiry:                 "Tagihan %s atas %s total %s + fee %s. Kalau mau langsung bayar, ketik: bayar %s.",
+		msgPayBillMissingRef:           "Butuh kode ref transaksi tagihan yang mau dibayar.",
+		msgPayBillStatus:               "Pembayaran %s status: %s. Pesan: %s",
+		msgDepositInvalidAmount:        "Nominal deposit belum jelas. Coba tulis angka seperti 50000.",
+		msgDepositAskMethod:            "Mau deposit via apa?\n🏦 *BRI* — Transfer Bank BRI\n📱 *QRIS* — Scan QR\n\nContoh: \"deposit qris 50000\" atau \"deposit bri 100000\"",
+		msgDepositUnavailable:          "Deposit belum bisa diproses sekarang. Coba lagi dalam beberapa saat ya.",
+		msgDepositFailed:               "Deposit belum bisa diproses: %s",
+		msgDepositReady:                "Sip, deposit %s via %s sebesar %s sudah siap.\n%s",
+		msgDepositQRCaption:            "Deposit %s via %s senilai %s.",
+		msgDepositReminder:             "⏳ Deposit %s via %s sebesar %s belum kami terima pembayarannya.\n%s",
+		msgDepositAutoCancel:           "Kalau belum dibayar sampai %s, deposit ini otomatis kubatalkan.",
+		msgDepositExpired:              "Deposit %s sebesar %s kubatalkan karena belum dibayar sampai batas waktunya.",
+		msgDepositExpiredOrders:        "Pesanan %s ikut dibatalkan. Balas \"bayar ulang\" untuk QR baru dengan harga yang sama, atau buat pesanan baru.",
+		msgAmountBilled:                "Tagihan: %s",
+		msgAmountFee:                   "Biaya: %s",
+		msgAmountCredited:              "Saldo masuk: %s",
+		msgCheckoutPending:             "Instruksi pembayaran akan dikirim setelah checkout tersedia.",
+		msgQRSent:                      "QR sudah kukirim sebagai gambar terpisah.",
+		msgQRScan:                      "Scan QR berikut: %s",
+		msgPayURL:                      "Bayar lewat halaman ini: %s",
+		msgValidUntil:                  "Berlaku sampai: %s",
+		msgBankTransferPending:         "Instruksi transfer akan dikirim setelah tersedia.",
+		msgBankTransferHeader:          "🏦 *TRANSFER BANK*",
+		msgBankAccountNo:               "No. Rekening: *%s*",
+		msgBankAccountName:             "Atas Nama: *%s*",
+		msgBankAmount:                  "Nominal: *%s*",
+		msgBankUniqueCode:              "Kode Unik: *%s*",
+		msgBankExactAmount:             "⚠️ Transfer *tepat* sesuai nominal agar deposit otomatis terverifikasi.",
+		msgInsufficientBalanceQuantity: "Saldo kamu tidak mencukupi.\n\n💰 Saldo: %s\n🏷️ Harga: %d × %s = %s\n\nSilakan deposit dulu atau gunakan metode pembayaran lain (BRI/QRIS).\nKetik: \"deposit [jumlah]\" untuk top up saldo.",
+		msgProductUnavailable:          "Maaf, %s (%s) lagi tidak tersedia dari supplier. Coba produk lain ya.",
+		msgPriceChanged:                "Harga %s (%s) barusan berubah dari %s jadi %s.\nKirim ulang perintah belinya kalau mau lanjut dengan harga baru ya.",
+		msgPLNMeterNotFound:            "Nomor meter %s tidak ditemukan di PLN. Cek lagi nomor meter/ID pelanggannya lalu kirim ulang ya.",
+		msgRepayUnavailable:            "Layanan pembayaran sedang tidak tersedia. Coba lagi sebentar lagi ya.",
+		msgRepayLookupFailed:           "Lagi ada kendala cek pesanan kamu. Coba lagi sebentar ya.",
+		msgRepayNone:                   "Tidak ada pesanan yang menunggu pembayaran. Kalau mau beli lagi, sebutkan produk dan nomor tujuannya ya.",
+		msgRepayAlreadyPaid:            "Pembayaran deposit %s sudah kami terima, pesanan %s sedang diproses. Tidak perlu bayar ulang ya.",
+		msgRepayQRUnavailable:          "Belum bisa membuat QR baru. Coba lagi sebentar lagi ya.",
+		msgRepayQRFailed:               "Belum bisa membuat QR baru: %s",
+		msgRepayReadyBank:              "Sip, deposit %s sudah kuganti dengan yang baru via BRI sebesar %s buat %s (%s).\nRef deposit: %s\nRef order: %s%s\n%s",
+		msgRepayQRCaption:              "QR baru deposit %s via %s untuk %s (%s).",
+		msgRepayReady:                  "Sip, QR lama (%s) sudah kubatalkan dan kubuatin yang baru via %s sebesar %s buat %s (%s).\nRef deposit: %s\nRef order: %s%s\n%s",
+	},
+	langEnglish: {
+		msgGreeting:                    "Hi!",
+		msgGreetingShop:                "Hi, welcome to %s!",
+		msgSmalltalk:                   "%s I offer a range of digital services:\n\n" + servicesEnglish + "\n\nType the product you're looking for, e.g. \"pulsa telkomsel 20k\" or \"top up ML\"",
+		msgHelp:                        "I offer a range of digital services:\n\n" + servicesEnglish + "\n\nExamples:\n• \"pulsa telkomsel 20k\" - check airtime prices\n• \"budget 5000\" - show products up to 5000\n• \"top up ML 12345\" - buy Mobile Legends diamonds\n• \"cek tagihan PLN 123456\" - check an electricity bill\n• \"withdraw 50k to bca 1234567890\" - withdraw your balance to a bank account\n• \"check account BCA 1234567890\" - look up an account holder's name\n\n" + commandHelpEnglish,
+		msgPaymentInfo:                 "Available payment methods:\n\n🏦 *BRI* — BRI bank transfer\n📱 *QRIS* — Scan a QR code (works with any e-wallet)\n💰 *Balance* — Pay from your deposited balance\n\nHow to pay:\n• Type \"beli [product code] [target ID]\" and then pick a method\n• Example: \"beli ML3 12345678(2126) via qris\"\n• Or order first and I'll ask how you'd like to pay",
+		msgFallback:                    "Sorry, I didn't quite get that. Could you say it another way?",
+		msgNLUUnavailable:              "Sorry, my assistant is having trouble so I can't understand free-form messages right now. Please use the quick commands for now:\n\n" + commandHelpEnglish,
+		msgSelectionExpired:            "That list number has expired. Please check the prices again and reply with a #number from the latest list.",
+		msgPurchaseBusy:                "Your previous order is still being processed. Hang on a moment, I'll let you know how it goes.",
+		msgPurchaseHeld:                "Sorry, our supplier is having trouble so purchases are on hold for now. I've kept your order, please reply again in a few minutes.",
+		msgBillPaymentHeld:             "Sorry, our supplier is having trouble so bill payments are on hold for now. Please try again in a few minutes.",
+		msgCancelOrderNone:             "There's no order being prepared.",
+		msgCancelOrder:                 "Okay, order %s (%s) is cancelled. Let me know if you'd like something else.",
+		msgBalance:                     "Your balance is about %s.",
+		msgAtlanticBalance:             "Your Atlantic balance is about %s. Account status: %s.",
+		msgResetContext:                "Okay, I've forgotten our earlier conversation. Your transaction history and balance are safe. What are you looking for now?",
+		msgCurrencyFormatUsage:         "Pick a number format: /format id (%s) or /format en (%s). Type /format auto to follow your language.",
+		msgCurrencyFormatSet:           "Okay, I'll write amounts like %s.",
+		msgHandoffUnavailable:          "Sorry, admins can't be reached through this chat yet. I'll still help as best I can, tell me what's wrong.",
+		msgHandoffStarted:              "Okay, I'm connecting you to an admin. From now on an admin will reply to your chat, please hold on 🙏",
+		msgHandoffResumed:              "The bot is back. If you need an admin again, type /operator.",
+		msgLanguageUsage:               "Pick a reply language: /english for English or /bahasa for Indonesian.",
+		msgLanguageSet:                 "Okay, I'll reply in English from now on. Carts, bulk orders, transfers, schedules and a few other features still reply in Indonesian. Ketik /bahasa untuk kembali ke bahasa Indonesia.",
+		msgFlowExpired:                 "Your unfinished order for %s (%s) expired after a while without a reply, so I've cancelled it. Just send the order again if you still want it.",
+		msgCartExpired:                 "Your cart (%d items) expired after a while without a reply, so I've emptied it. Add the products again if you still want them.",
+		msgTransferExpired:             "Your unfinished transfer expired after a while without a reply, so I've cancelled it. Your balance was not charged.",
+		msgDraftMissing:                "There's no order in progress. What would you like to buy?",
+		msgProductNotFound:             "I couldn't find that product. Tell me the code or name of the product you want.",
+		msgAskTarget:                   "You want to buy %s (%s). Please send the destination number/ID.",
+		msgAskTargetOwnUnknown:         "You want to buy %s (%s), but I can't see your WhatsApp number from here. Please send the destination number/ID.",
+		msgAskZone:                     "%s needs an ID plus Server. Write it like 12345678(1234).",
+		msgOwnNumberTarget:             "I took the destination from your WhatsApp number: %s. Please make sure it's right.\n\n%s",
+		msgAskPayment:                  "How would you like to pay?",
+		msgPaymentOptions:              "🏦 *BRI* — BRI bank transfer\n📱 *QRIS* — Scan a QR code\n💰 *Saldo* — Use your deposit balance\n\nReply: bri / qris / saldo",
+		msgDraftChangeHint:             "Want to change it? Send e.g. \"change the number to 0813...\", or \"cancel\".",
+		msgDraftHeader:                 "Your order:",
+		msgDraftProduct:                "• Product: %s (%s)",
+		msgDraftTarget:                 "• Destination: %s",
+		msgDraftTargets:                "• Destinations (%d numbers): %s",
+		msgDraftCustomerName:           "• Customer name: %s",
+		msgDraftUnitPrice:              "• Price: %d × %s",
+		msgDraftQuantity:               "• Quantity: %d × %s",
+		msgDraftTotal:                  "• Total: %s",
+		msgDraftPrice:                  "• Price: %s",
+		msgDraftVoucher:                "• Promo code %s can't be used yet; the price above has no discount.",
+		msgDraftRef:                    "• Your ref: %s",
+		msgDraftNote:                   "• Note: %s",
+		msgBalanceCheckFailed:          "I couldn't check your balance. Please try again later.",
+		msgInsufficientBalance:         "Your balance isn't enough.\n\n💰 Balance: %s\n🏷️ Price: %s\n\nPlease deposit first or use another payment method (BRI/QRIS).\nType \"deposit [amount]\" to top up.",
+		msgPrepaidQueued:               "The server is having trouble, so your %s (%s) transaction is queued. Ref: %s. I'll let you know as soon as there's an update.",
+		msgPrepaidSystemError:          "The transaction can't be processed because of a system problem. Please try again shortly.",
+		msgPrepaidFailed:               "The %s (%s) transaction failed. %s",
+		msgPrepaidProcessing:           "Done, your %s (%s) transaction is being processed. Ref: %s.",
+		msgPrepaidSuccess:              "Great, your %s (%s) transaction succeeded! Ref: %s.",
+		msgPrepaidRejected:             "The transaction failed. The deposit balance seems to be insufficient.",
+		msgPrepaidNotSucceeded:         "Oops, your %s (%s) transaction didn't go through. %s",
+		msgRetryProcessing:             "Update: your %s (%s) transaction is being processed. Ref: %s.",
+		msgRetrySuccess:                "Success: your %s (%s) transaction went through! Ref: %s.",
+		msgRetryFailed:                 "Sorry, your %s (%s) transaction didn't go through. %s",
+		msgRetryQueued:                 "The server is still having trouble, so your %s (%s) transaction stays queued. Ref: %s.",
+		msgRetryUnprocessed:            "Sorry, your %s (%s) transaction can't be processed. %s",
+		msgTransactionUnprocessed:      "The transaction can't be processed.",
+		msgCheckoutUnavailable:         "I couldn't set up a deposit for this purchase. Try another method or try again in a moment.",
+		msgCheckoutFailed:              "I couldn't set up the deposit: %s",
+		msgCheckoutReady:               "Done, I've created a %s deposit of %s for %s.\nDeposit ref: %s\nOrder ref: %s%s\n%s",
+		msgCheckoutQRCaption:           "Deposit %s via %s for %s.",
+		msgCheckoutShortfall:           "The credited amount is still %s short of the product price. Please add a deposit so I can process it.",
+		msgReceiptLine:                 "Receipt: %s",
+		msgProductInfo:                 "Product info:",
+		msgBillMissingFields:           "I need the product code and customer ID. Example: \"cek tagihan PLN 123456\".",
+		msgBillInquiry:                 "The %s bill for %s is %s + a %s fee. To pay it now, type: bayar %s.",
+		msgPayBillMissingRef:           "I need the ref code of the bill you want to pay.",
+		msgPayBillStatus:               "Payment %s status: %s. Message: %s",
+		msgDepositInvalidAmount:        "The deposit amount isn't clear. Try a number like 50000.",
+		msgDepositAskMethod:            "How would you like to deposit?\n🏦 *BRI* — BRI bank transfer\n📱 *QRIS* — Scan a QR code\n\nExample: \"deposit qris 50000\" or \"deposit bri 100000\"",
+		msgDepositUnavailable:          "The deposit can't be processed right now. Please try again in a moment.",
+		msgDepositFailed:               "The deposit can't be processed: %s",
+		msgDepositReady:                "Done, deposit %s via %s for %s is ready.\n%s",
+		msgDepositQRCaption:            "Deposit %s via %s for %s.",
+		msgDepositReminder:             "⏳ We haven't received the payment for deposit %s via %s of %s yet.\n%s",
+		msgDepositAutoCancel:           "If it isn't paid by %s, I'll cancel this deposit automatically.",
+		msgDepositExpired:              "I've cancelled deposit %s of %s because it wasn't paid in time.",
+		msgDepositExpiredOrders:        "Order %s was cancelled with it. Reply \"bayar ulang\" for a new QR at the same price, or place a new order.",
+		msgAmountBilled:                "Amount due: %s",
+		msgAmountFee:                   "Fee: %s",
+		msgAmountCredited:              "Credited: %s",
+		msgCheckoutPending:             "Payment instructions will follow once checkout is available.",
+		msgQRSent:                      "I've sent the QR as a separate image.",
+		msgQRScan:                      "Scan this QR: %s",
+		msgPayURL:                      "Pay on this page: %s",
+		msgValidUntil:                  "Valid until: %s",
+		msgBankTransferPending:         "Transfer instructions will follow once available.",
+		msgBankTransferHeader:          "🏦 *BANK TRANSFER*",
+		msgBankAccountNo:               "Account no.: *%s*",
+		msgBankAccountName:             "Account name: *%s*",
+		msgBankAmount:                  "Amount: *%s*",
+		msgBankUniqueCode:              "Unique code: *%s*",
+		msgBankExactAmount:             "⚠️ Transfer the *exact* amount so the deposit is verified automatically.",
+		msgInsufficientBalanceQuantity: "Your balance isn't enough.\n\n💰 Balance: %s\n🏷️ Price: %d × %s = %s\n\nPlease deposit first or use another payment method (BRI/QRIS).\nType \"deposit [amount]\" to top up.",
+		msgProductUnavailable:          "Sorry, %s (%s) isn't available from the supplier right now. Please try another product.",
+		msgPriceChanged:                "The price of %s (%s) just changed from %s to %s.\nSend the purchase again if you'd like to continue at the new price.",
+		msgPLNMeterNotFound:            "Meter number %s wasn't found at PLN. Please check the meter/customer ID and send it again.",
+		msgRepayUnavailable:            "The payment service isn't available right now. Please try again shortly.",
+		msgRepayLookupFailed:           "I'm having trouble checking your orders. Please try again shortly.",
+		msgRepayNone:                   "No orders are waiting for payment. If you'd like to buy again, tell me the product and destination number.",
+		msgRepayAlreadyPaid:            "We've received the payment for deposit %s and order %s is being processed. No need to pay again.",
+		msgRepayQRUnavailable:          "I couldn't create a new QR. Please try again shortly.",
+		msgRepayQRFailed:               "I couldn't create a new QR: %s",
+		msgRepayReadyBank:              "Done, I've replaced deposit %s with a new BRI deposit of %s for %s (%s).\nDeposit ref: %s\nOrder ref: %s%s\n%s",
+		msgRepayQRCaption:              "New QR for deposit %s via %s for %s (%s).",
+		msgRepayReady:                  "Done, I've cancelled the old QR (%s) and created a new %s one of %s for %s (%s).\nDeposit ref: %s\nOrder ref: %s%s\n%s",
+	},
+}
+
+// userLanguage is the reply language for user, from their language
+// preference. A nil user gets Indonesian.
+func userLanguage(user *repo.User) string {
+	if user == nil {
+		return langIndonesian
+	}
+	return user.Language()
+}
+
+// localize returns the reply key in the user's language, formatted with
+// args when given.
+func localize(user *repo.User, key msgKey, args ...any) string {
+	format, ok := messageCatalog[userLanguage(user)][key]
+	if !ok {
+		format = messageCatalog[langIndonesian][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// parseLanguage maps what users type after /bahasa or /english to a reply
+// language, or "" when it names neither.
+func parseLanguage(choice string) string {
+	switch strings.ToLower(strings.TrimSpace(choice)) {
+	case "id", "indonesia", "indonesian", "bahasa", "indo":
+		return langIndonesian
+	case "en", "english", "inggris":
+		return langEnglish
+	default:
+		return ""
+	}
+}
+
+// handleSetLanguage switches the language the bot replies to the user in.
+func (e *Engine) handleSetLanguage(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	lang := parseLanguage(intent.Entities["language"])
+	if lang == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgLanguageUsage), "set_language")
+	}
+	tag := languageTags[lang]
+	if err := e.repo.SetUserLanguage(ctx, user.ID, tag); err != nil {
+		return fmt.Errorf("set user language: %w", err)
+	}
+	user.LanguagePreference = tag
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgLanguageSet), "set_language")
+}
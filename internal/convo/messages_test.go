@@ -0,0 +1,59 @@
+package convo
+
+import (
+	"strings"
+	"testing"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestLocalizeFollowsLanguagePreference(t *testing.T) {
+	english := &repo.User{LanguagePreference: "en-US"}
+	indonesian := &repo.User{LanguagePreference: "id-ID"}
+
+	if got := localize(english, msgBalance, "Rp10,000"); got != "Your balance is about Rp10,000." {
+		t.Fatalf("english balance = %q", got)
+	}
+	if got := localize(indonesian, msgBalance, "Rp10.000"); got != "Saldo kamu sekitar Rp10.000." {
+		t.Fatalf("indonesian balance = %q", got)
+	}
+	if got := localize(nil, msgFallback); got != messageCatalog[langIndonesian][msgFallback] {
+		t.Fatalf("nil user fallback = %q", got)
+	}
+	if got := localize(&repo.User{LanguagePreference: "fr-FR"}, msgGreeting); got != "Halo!" {
+		t.Fatalf("unsupported language greeting = %q", got)
+	}
+}
+
+func TestMessageCatalogComplete(t *testing.T) {
+	for key, id := range messageCatalog[langIndonesian] {
+		en, ok := messageCatalog[langEnglish][key]
+		if !ok {
+			t.Errorf("message %d has no English version", key)
+			continue
+		}
+		if strings.Count(id, "%") != strings.Count(en, "%") {
+			t.Errorf("message %d takes different arguments: %q vs %q", key, id, en)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := map[string]string{"EN": langEnglish, "english": langEnglish, "indonesia": langIndonesian, "id": langIndonesian, "jawa": ""}
+	for choice, want := range tests {
+		if got := parseLanguage(choice); got != want {
+			t.Errorf("parseLanguage(%q) = %q, want %q", choice, got, want)
+		}
+	}
+}
+
+func TestDraftSummaryFollowsLanguage(t *testing.T) {
+	d := &draftOrder{ProductCode: "TSEL10", ProductName: "Telkomsel 10.000", CustomerID: "081234567890", Quantity: 1, Price: 10500}
+	if got := d.summary(&repo.User{LanguagePreference: "en-US"}, money.English); !strings.HasPrefix(got, "Your order:\n• Product: Telkomsel 10.000 (TSEL10)\n• Destination: 081234567890") {
+		t.Fatalf("english summary = %q", got)
+	}
+	if got := d.summary(nil, money.Indonesian); !strings.HasPrefix(got, "Pesanan kamu:\n• Produk: ") {
+		t.Fatalf("indonesian summary = %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+package convo
+
+import (
+	"context"
+	"time"
+
+	"bot-jual/internal/naming"
+)
+
+// namingTTL bounds how long admin edits to product names take to reach replies.
+const namingTTL = time.Minute
+
+type namingCache struct {
+	chain   naming.Chain
+	expires time.Time
+}
+
+// productNames returns the translators that turn supplier product names into
+// display names.
+func (e *Engine) productNames(ctx context.Context) naming.Chain {
+	e.mu.RLock()
+	cached := e.naming
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.chain
+	}
+
+	rules, err := e.repo.ListProductNameRules(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading product name rules", "error", err)
+		if cached.chain == nil {
+			return naming.Chain{naming.Tidy{}}
+		}
+		return cached.chain
+	}
+	chain, err := naming.New(rules)
+	if err != nil {
+		e.logger.Warn("skipping invalid product name rules", "error", err)
+	}
+
+	e.mu.Lock()
+	e.naming = namingCache{chain: chain, expires: time.Now().Add(namingTTL)}
+	e.mu.Unlock()
+	return chain
+}
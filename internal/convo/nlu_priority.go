@@ -0,0 +1,49 @@
+package convo
+
+import (
+	"strings"
+
+	"bot-jual/internal/nlu"
+)
+
+// purchasePromptMarkers appear in bot replies that wait for the user to
+// complete a purchase, so the answer must not be shed.
+var purchasePromptMarkers = []string{
+	"mau bayar pakai apa",
+	"kirim nomor/id tujuan",
+	"butuh id plus server",
+	"kirim ulang perintah belinya",
+	"ketik: bayar",
+}
+
+var purchaseWords = []string{"beli", "bayar", "checkout", "order", "qris", "bri", "saldo", "deposit", "topup", "top up", "isi"}
+
+var commerceWords = []string{"harga", "pulsa", "paket", "data", "token", "pln", "tagihan", "diamond", "voucher", "status", "cek", "produk", "menu", "katalog", "tarik"}
+
+// nluPriority classifies a message for the Gemini queue: purchase flows
+// first, product questions next, casual chat last.
+func nluPriority(text, lastBot string) nlu.Priority {
+	lastBot = strings.ToLower(lastBot)
+	for _, marker := range purchasePromptMarkers {
+		if strings.Contains(lastBot, marker) {
+			return nlu.PriorityHigh
+		}
+	}
+	text = strings.ToLower(text)
+	if containsAnyWord(text, purchaseWords) {
+		return nlu.PriorityHigh
+	}
+	if containsAnyWord(text, commerceWords) {
+		return nlu.PriorityNormal
+	}
+	return nlu.PriorityLow
+}
+
+func containsAnyWord(text string, words []string) bool {
+	for _, w := range words {
+		if containsWord(text, w) {
+			return true
+		}
+	}
+	return false
+}
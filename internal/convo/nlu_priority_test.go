@@ -0,0 +1,26 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/nlu"
+)
+
+func TestNLUPriority(t *testing.T) {
+	cases := []struct {
+		text, lastBot string
+		want          nlu.Priority
+	}{
+		{"qris", "Mau beli Telkomsel 10K (TSEL10) — Rp10.500\nMau bayar pakai apa?", nlu.PriorityHigh},
+		{"08123456789", "Kamu mau beli Telkomsel 10K (TSEL10). Kirim nomor/ID tujuan ya.", nlu.PriorityHigh},
+		{"beli tsel10 08123456789", "", nlu.PriorityHigh},
+		{"harga pulsa xl berapa", "", nlu.PriorityNormal},
+		{"halo kak apa kabar", "", nlu.PriorityLow},
+		{"isian formulir", "", nlu.PriorityLow},
+	}
+	for _, tc := range cases {
+		if got := nluPriority(tc.text, tc.lastBot); got != tc.want {
+			t.Errorf("nluPriority(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
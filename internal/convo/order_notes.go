@@ -0,0 +1,108 @@
+package convo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bot-jual/internal/atl"
+)
+
+var (
+	customerRefPattern = regexp.MustCompile(`(?i)\b(?:ref|referensi|invoice|inv)\s*[:=]\s*([0-9A-Za-z][0-9A-Za-z._/-]{0,63})`)
+	orderNotePattern   = regexp.MustCompile(`(?i)\b(?:note|catatan|keterangan|ket)\s*[:=]\s*(.+)$`)
+)
+
+// orderAnnotations carries the user's own reference, note and promo code for
+// an order, and for PLN tokens the customer the meter number resolved to.
+// The promo code is only recorded; no discount is applied for it.
+type orderAnnotations struct {
+	CustomerRef string
+	Note        string
+	Voucher     string
+	PLN         plnCustomer
+}
+
+// extractOrderAnnotations pulls "ref: X" and "catatan: Y" fragments out of a
+// purchase message and returns the remaining text without them.
+func extractOrderAnnotations(text string) (orderAnnotations, string) {
+	var ann orderAnnotations
+	remaining := text
+	if match := customerRefPattern.FindStringSubmatchIndex(remaining); match != nil {
+		ann.CustomerRef = strings.TrimSpace(remaining[match[2]:match[3]])
+		remaining = remaining[:match[0]] + " " + remaining[match[1]:]
+	}
+	if match := orderNotePattern.FindStringSubmatchIndex(remaining); match != nil {
+		ann.Note = strings.TrimSpace(remaining[match[2]:match[3]])
+		remaining = remaining[:match[0]]
+	}
+	return ann, strings.Join(strings.Fields(remaining), " ")
+}
+
+func annotationsFromEntities(entities map[string]string) orderAnnotations {
+	return orderAnnotations{
+		CustomerRef: strings.TrimSpace(entities["customer_ref"]),
+		Note:        strings.TrimSpace(entities["order_note"]),
+		Voucher:     strings.ToUpper(strings.TrimSpace(entities["voucher_code"])),
+		PLN:         plnCustomerFromEntities(entities),
+	}
+}
+
+func (a orderAnnotations) empty() bool {
+	return a.CustomerRef == "" && a.Note == "" && a.Voucher == ""
+}
+
+func (a orderAnnotations) toEntities(entities map[string]string) {
+	if a.CustomerRef != "" {
+		entities["customer_ref"] = a.CustomerRef
+	}
+	if a.Note != "" {
+		entities["order_note"] = a.Note
+	}
+	if a.Voucher != "" {
+		entities["voucher_code"] = a.Voucher
+	}
+}
+
+// applyTo stores the annotations in order metadata.
+func (a orderAnnotations) applyTo(meta map[string]any) {
+	if a.CustomerRef != "" {
+		meta["customer_ref"] = a.CustomerRef
+	}
+	if a.Note != "" {
+		meta["note"] = a.Note
+	}
+	if a.Voucher != "" {
+		meta["voucher_code"] = a.Voucher
+	}
+	a.PLN.applyTo(meta)
+}
+
+func (a orderAnnotations) atlanticNote() string {
+	return atl.FormatTransactionNote(a.CustomerRef, a.Note)
+}
+
+// replySuffix renders the annotations for confirmation messages. The promo
+// code is left out so the reply does not suggest it was applied.
+func (a orderAnnotations) replySuffix() string {
+	var sb strings.Builder
+	if a.CustomerRef != "" {
+		sb.WriteString(fmt.Sprintf("\nRef kamu: %s", a.CustomerRef))
+	}
+	if a.Note != "" {
+		sb.WriteString(fmt.Sprintf("\nCatatan: %s", a.Note))
+	}
+	if label := a.PLN.label(); label != "" {
+		sb.WriteString(fmt.Sprintf("\nNama pelanggan PLN: %s", label))
+	}
+	return sb.String()
+}
+
+func annotationsFromMetadata(meta map[string]any) orderAnnotations {
+	return orderAnnotations{
+		CustomerRef: stringValue(meta, "customer_ref"),
+		Note:        stringValue(meta, "note"),
+		Voucher:     stringValue(meta, "voucher_code"),
+		PLN:         plnCustomerFromMetadata(meta),
+	}
+}
@@ -0,0 +1,16 @@
+package convo
+
+import "testing"
+
+func TestExtractOrderAnnotations(t *testing.T) {
+	ann, rest := extractOrderAnnotations("beli ML3 69827740 ref: INV-553 catatan: buat adik")
+	if ann.CustomerRef != "INV-553" {
+		t.Fatalf("expected customer ref INV-553, got %q", ann.CustomerRef)
+	}
+	if ann.Note != "buat adik" {
+		t.Fatalf("expected note 'buat adik', got %q", ann.Note)
+	}
+	if rest != "beli ML3 69827740" {
+		t.Fatalf("unexpected remaining text %q", rest)
+	}
+}
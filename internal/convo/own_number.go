@@ -0,0 +1,54 @@
+package convo
+
+import (
+	"regexp"
+	"strings"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ownNumberPattern matches the ways users refer to their own WhatsApp
+// number as the purchase target, as in "isi pulsa buat nomorku".
+var ownNumberPattern = regexp.MustCompile(`(?i)\b(?:nomor|nomer|no)\s?(?:ku|saya|aku|sendiri|wa ini|wa saya|wa aku|whatsapp saya|whatsapp aku)\b`)
+
+// refersToOwnNumber reports whether text names the sender's own number
+// rather than spelling one out.
+func refersToOwnNumber(text string) bool {
+	return ownNumberPattern.MatchString(text)
+}
+
+// ownPhoneNumber returns the user's WhatsApp number in the local form the
+// supplier expects (08...), or "" when it is unknown or not Indonesian.
+// The stored phone number wins; otherwise it is read off the sender's
+// address, which is only a phone number when WhatsApp does not use a LID.
+func ownPhoneNumber(user *repo.User, evt *events.Message) string {
+	var phone string
+	if user.PhoneNumber != nil {
+		phone = strings.TrimPrefix(strings.TrimSpace(*user.PhoneNumber), "+")
+	}
+	if phone == "" {
+		phone = senderPhone(evt.Info.MessageSource)
+	}
+	if phone == "" && user.WAJID != nil {
+		if jid, err := types.ParseJID(*user.WAJID); err == nil && jid.Server == types.DefaultUserServer {
+			phone = jid.User
+		}
+	}
+	return localPhoneNumber(phone)
+}
+
+// localPhoneNumber turns an Indonesian number in international form
+// (628...) into its local form (08...).
+func localPhoneNumber(phone string) string {
+	switch {
+	case strings.HasPrefix(phone, "62") && len(phone) > 2:
+		return "0" + phone[2:]
+	case strings.HasPrefix(phone, "08"):
+		return phone
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,36 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestOwnPhoneNumber(t *testing.T) {
+	if !refersToOwnNumber("isi pulsa 10rb buat Nomorku dong") || refersToOwnNumber("isi pulsa 10rb ke 08123") || refersToOwnNumber("cek nomor kuota") {
+		t.Fatalf("refersToOwnNumber misread the request")
+	}
+
+	evt := &events.Message{}
+	evt.Info.Sender = types.NewJID("6281234567890", types.DefaultUserServer)
+	if got := ownPhoneNumber(&repo.User{}, evt); got != "081234567890" {
+		t.Fatalf("from sender = %q", got)
+	}
+
+	stored := "+6289876543210"
+	evt.Info.Sender = types.NewJID("123456789", types.HiddenUserServer)
+	if got := ownPhoneNumber(&repo.User{PhoneNumber: &stored}, evt); got != "089876543210" {
+		t.Fatalf("from stored number = %q", got)
+	}
+	if got := ownPhoneNumber(&repo.User{}, evt); got != "" {
+		t.Fatalf("LID sender resolved to %q", got)
+	}
+
+	foreign := "60123456789"
+	if got := ownPhoneNumber(&repo.User{PhoneNumber: &foreign}, evt); got != "" {
+		t.Fatalf("foreign number resolved to %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package convo
+
+import (
+	"context"
+	"errors"
+
+	"bot-jual/internal/atl"
+)
+
+// providerAtlantic names the default payment provider, Atlantic deposits.
+const providerAtlantic = "atlantic"
+
+var errPaymentsUnavailable = errors.New("payment provider unavailable")
+
+// PaymentProvider creates the deposits users pay for orders and balance
+// top-ups with, and checks or cancels them later by the provider's ID.
+// The Atlantic client is one; SetPaymentProvider switches to another.
+type PaymentProvider interface {
+	CreateDeposit(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, error)
+	DepositStatus(ctx context.Context, id string) (*atl.DepositStatusResponse, error)
+	CancelDeposit(ctx context.Context, id string) (*atl.DepositCancelResponse, error)
+}
+
+// SetPaymentProvider takes new deposits through provider, recorded on each
+// deposit as name so it is later checked with the provider that made it.
+func (e *Engine) SetPaymentProvider(name string, provider PaymentProvider) {
+	e.paymentName = name
+	e.payments = provider
+}
+
+// paymentProvider is where new deposits are created, and its name.
+func (e *Engine) paymentProvider() (string, PaymentProvider) {
+	if e.payments != nil {
+		return e.paymentName, e.payments
+	}
+	if e.atl == nil {
+		return providerAtlantic, nil
+	}
+	return providerAtlantic, e.atl
+}
+
+// createDeposit starts a deposit with the configured provider and returns
+// the provider's name for the caller to store as payment_provider.
+func (e *Engine) createDeposit(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, string, error) {
+	name, provider := e.paymentProvider()
+	if provider == nil {
+		return nil, name, errPaymentsUnavailable
+	}
+	resp, err := provider.CreateDeposit(ctx, req)
+	return resp, name, err
+}
+
+// depositProvider returns the provider a stored deposit was created with,
+// or nil when it is not configured any more. Deposits made before
+// providers were recorded are Atlantic's.
+func (e *Engine) depositProvider(meta map[string]any) PaymentProvider {
+	name := stringValue(meta, "payment_provider")
+	if name == "" || name == providerAtlantic {
+		if e.atl == nil {
+			return nil
+		}
+		return e.atl
+	}
+	if name == e.paymentName {
+		return e.payments
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// errMeterNotFound is returned when PLN does not know a meter number.
+var errMeterNotFound = errors.New("pln meter not found")
+
+// plnCustomer is who a PLN meter number is registered to.
+type plnCustomer struct {
+	Meter  string `json:"meter,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Tariff string `json:"tariff,omitempty"`
+}
+
+func plnCustomerFromEntities(entities map[string]string) plnCustomer {
+	return plnCustomer{
+		Meter:  strings.TrimSpace(entities["pln_meter"]),
+		Name:   strings.TrimSpace(entities["pln_name"]),
+		Tariff: strings.TrimSpace(entities["pln_tariff"]),
+	}
+}
+
+func plnCustomerFromMetadata(meta map[string]any) plnCustomer {
+	return plnCustomer{
+		Meter:  stringValue(meta, "pln_meter"),
+		Name:   stringValue(meta, "pln_customer_name"),
+		Tariff: stringValue(meta, "pln_tariff"),
+	}
+}
+
+func (c plnCustomer) toEntities(entities map[string]string) {
+	if c.Name == "" {
+		return
+	}
+	entities["pln_meter"] = c.Meter
+	entities["pln_name"] = c.Name
+	entities["pln_tariff"] = c.Tariff
+}
+
+// applyTo stores the customer in order metadata.
+func (c plnCustomer) applyTo(meta map[string]any) {
+	if c.Name == "" {
+		return
+	}
+	meta["pln_meter"] = c.Meter
+	meta["pln_customer_name"] = c.Name
+	if c.Tariff != "" {
+		meta["pln_tariff"] = c.Tariff
+	}
+}
+
+// label renders the customer as "BUDI SANTOSO (R1/900VA)".
+func (c plnCustomer) label() string {
+	if c.Name == "" {
+		return ""
+	}
+	if c.Tariff == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.Tariff)
+}
+
+// isPLNToken reports whether item is prepaid electricity, whose target is a
+// meter number worth checking before it is charged.
+func isPLNToken(item *atl.PriceListItem, productType string) bool {
+	if item == nil || strings.EqualFold(productType, "pascabayar") {
+		return false
+	}
+	combined := strings.ToLower(strings.Join([]string{item.Code, item.Name, item.Category, item.Provider}, " "))
+	if strings.Contains(combined, "pasca") {
+		return false
+	}
+	return strings.Contains(combined, "pln") || strings.Contains(combined, "token listrik")
+}
+
+// inquirePLNMeter resolves a meter number through Atlantic's bill inquiry.
+// PLN rejecting the number is reported as errMeterNotFound; a customer
+// without a name means the inquiry told us nothing and is returned empty.
+func (e *Engine) inquirePLNMeter(ctx context.Context, productCode, meter string) (plnCustomer, error) {
+	code := e.cfg.PLNInquiryCode
+	if code == "" {
+		code = productCode
+	}
+	resp, err := e.atl.BillInquiry(ctx, atl.BillInquiryRequest{
+		ProductCode: code,
+		CustomerID:  meter,
+		RefID:       generateRefID("inq"),
+	})
+	if err != nil {
+		if meterRejected(err.Error()) {
+			return plnCustomer{}, fmt.Errorf("%w: %v", errMeterNotFound, err)
+		}
+		return plnCustomer{}, err
+	}
+	if resp.Status == "failed" {
+		return plnCustomer{}, fmt.Errorf("%w: %s", errMeterNotFound, resp.Message)
+	}
+	sources := []map[string]any{resp.BillInfo, resp.Raw}
+	customer := plnCustomer{
+		Meter: meter,
+		Name:  firstInMaps(sources, "customer_name", "nama_pelanggan", "nama", "name"),
+	}
+	tariff := firstInMaps(sources, "segment_power", "tarif_daya")
+	if tariff == "" {
+		tariff = firstInMaps(sources, "tarif", "tariff", "segment")
+		if power := firstInMaps(sources, "daya", "power"); power != "" {
+			if !strings.HasSuffix(strings.ToUpper(power), "VA") {
+				power += "VA"
+			}
+			if tariff == "" {
+				tariff = power
+			} else {
+				tariff += "/" + power
+			}
+		}
+	}
+	customer.Tariff = tariff
+	return customer, nil
+}
+
+func meterRejected(message string) bool {
+	lower := strings.ToLower(message)
+	for _, kw := range []string{"tidak ditemukan", "tidak terdaftar", "idpel salah", "nomor meter salah", "nomor salah", "not found", "invalid customer"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstInMaps(sources []map[string]any, keys ...string) string {
+	for _, key := range keys {
+		for _, src := range sources {
+			if val := strings.TrimSpace(stringValue(src, key)); val != "" {
+				return val
+			}
+		}
+	}
+	return ""
+}
+
+// resolvePLNMeter looks up who the draft's meter number belongs to, once
+// per number, recording it on the draft and annotations so the order keeps
+// it. fresh reports a new lookup the user should confirm before paying.
+// An unknown meter sends the user back to enter it again and returns
+// proceed false; other inquiry failures let the sale go ahead unchecked.
+func (e *Engine) resolvePLNMeter(ctx context.Context, evt *events.Message, user *repo.User, draft *draftOrder, ann *orderAnnotations) (fresh, proceed bool, err error) {
+	if e.atl == nil {
+		return false, true, nil
+	}
+	meter := draft.CustomerID
+	if ann.PLN.Meter == meter && ann.PLN.Name != "" {
+		draft.PLN = ann.PLN
+		return false, true, nil
+	}
+	ann.PLN, draft.PLN = plnCustomer{}, plnCustomer{}
+	customer, inqErr := e.inquirePLNMeter(ctx, draft.ProductCode, meter)
+	switch {
+	case errors.Is(inqErr, errMeterNotFound):
+		e.logger.Info("pln meter not found", "meter", meter, "user_id", user.ID, "error", inqErr)
+		draft.CustomerID = ""
+		e.storeDraft(ctx, user.ID, draft, flowEnterTarget)
+		reply := localize(user, msgPLNMeterNotFound, meter)
+		return false, false, e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "prepaid_pln_meter_not_found")
+	case inqErr != nil:
+		e.logger.Warn("pln meter inquiry failed, selling without check", "error", inqErr, "meter", meter, "user_id", user.ID)
+		return false, true, nil
+	case customer.Name == "":
+		return false, true, nil
+	}
+	ann.PLN, draft.PLN = customer, customer
+	return true, true, nil
+}
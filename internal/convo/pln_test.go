@@ -0,0 +1,78 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func TestInquirePLNMeter(t *testing.T) {
+	var codes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		codes = append(codes, r.Form.Get("code"))
+		switch r.Form.Get("customer_no") {
+		case "52100000001":
+			_, _ = io.WriteString(w, `{"status":true,"message":"ok","data":{"status":"success","customer_name":"BUDI SANTOSO","tarif":"R1","daya":"900"}}`)
+		case "52100000002":
+			_, _ = io.WriteString(w, `{"status":false,"message":"Nomor meter tidak ditemukan"}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := &Engine{logger: logger, atl: atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil), cfg: EngineConfig{PLNInquiryCode: "PLNINQ"}}
+	ctx := context.Background()
+
+	customer, err := e.inquirePLNMeter(ctx, "PLN20", "52100000001")
+	if err != nil || customer.label() != "BUDI SANTOSO (R1/900VA)" || customer.Meter != "52100000001" {
+		t.Fatalf("customer = %+v, %v", customer, err)
+	}
+	if _, err := e.inquirePLNMeter(ctx, "PLN20", "52100000002"); !errors.Is(err, errMeterNotFound) {
+		t.Fatalf("unknown meter error = %v", err)
+	}
+	if _, err := e.inquirePLNMeter(ctx, "PLN20", "52100000003"); err == nil || errors.Is(err, errMeterNotFound) {
+		t.Fatalf("outage error = %v", err)
+	}
+	if codes[0] != "PLNINQ" {
+		t.Fatalf("inquiry code = %q", codes[0])
+	}
+
+	meta := map[string]any{}
+	orderAnnotations{PLN: customer}.applyTo(meta)
+	if meta["pln_customer_name"] != "BUDI SANTOSO" || meta["pln_tariff"] != "R1/900VA" {
+		t.Fatalf("metadata = %v", meta)
+	}
+	draft := &draftOrder{ProductCode: "PLN20", ProductName: "Token PLN 20.000", CustomerID: "52100000001", Price: 20500, PLN: customer}
+	if summary := draft.summary(nil, money.LocaleFor("id", false)); !strings.Contains(summary, "Nama pelanggan: BUDI SANTOSO (R1/900VA)") {
+		t.Fatalf("summary = %q", summary)
+	}
+}
+
+func TestIsPLNToken(t *testing.T) {
+	cases := []struct {
+		item atl.PriceListItem
+		typ  string
+		want bool
+	}{
+		{atl.PriceListItem{Code: "PLN20", Name: "Token PLN 20.000"}, "prabayar", true},
+		{atl.PriceListItem{Code: "TL50", Name: "Token Listrik 50rb"}, "", true},
+		{atl.PriceListItem{Code: "PLNPASCA", Name: "PLN Pascabayar"}, "", false},
+		{atl.PriceListItem{Code: "PLN", Name: "Tagihan PLN"}, "pascabayar", false},
+		{atl.PriceListItem{Code: "TSEL10", Name: "Telkomsel 10.000"}, "prabayar", false},
+	}
+	for _, tc := range cases {
+		if got := isPLNToken(&tc.item, tc.typ); got != tc.want {
+			t.Errorf("isPLNToken(%s, %q) = %v, want %v", tc.item.Code, tc.typ, got, tc.want)
+		}
+	}
+}
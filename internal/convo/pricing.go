@@ -0,0 +1,46 @@
+package convo
+
+import (
+	"context"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/pricing"
+)
+
+// pricingTTL bounds how long admin edits to markup rules take to reach quotes.
+const pricingTTL = time.Minute
+
+type pricingCache struct {
+	rules   pricing.Rules
+	expires time.Time
+}
+
+// pricingRules returns the markup rules that turn supplier costs into
+// selling prices.
+func (e *Engine) pricingRules(ctx context.Context) pricing.Rules {
+	e.mu.RLock()
+	cached := e.pricing
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.rules
+	}
+
+	rules, err := e.repo.ListPricingRules(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading pricing rules", "error", err)
+		return cached.rules
+	}
+	built := pricing.NewRules(rules)
+
+	e.mu.Lock()
+	e.pricing = pricingCache{rules: built, expires: time.Now().Add(pricingTTL)}
+	e.mu.Unlock()
+	return built
+}
+
+// sellingRules returns the markup rules floored at the minimum margin, so a
+// listed price always passes the margin check at checkout.
+func (e *Engine) sellingRules(ctx context.Context) pricing.Rules {
+	return e.pricingRules(ctx).WithMinMargin(money.FromRupiah(e.tunables().MinMargin))
+}
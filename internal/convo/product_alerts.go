@@ -0,0 +1,258 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// productAlertCheckInterval is how often price lists are compared with
+	// what alert subscribers are waiting for.
+	productAlertCheckInterval = 5 * time.Minute
+	// maxProductAlerts caps how many active alerts a user may keep.
+	maxProductAlerts = 10
+)
+
+var (
+	alertPriceDropPattern = regexp.MustCompile(`(?i)\b(?:turun|murah|murahan|diskon|promo|drop|cheaper)\b`)
+	alertAvailablePattern = regexp.MustCompile(`(?i)\b(?:ready|tersedia|ada lagi|restock|available|in stock|normal lagi)\b`)
+)
+
+// alertKind picks what the user is waiting for: the words they used, or
+// availability for a product that is out of stock and a price drop otherwise.
+func alertKind(requested, text string, item atl.PriceListItem) string {
+	switch strings.ToLower(strings.TrimSpace(requested)) {
+	case repo.AlertAvailable:
+		return repo.AlertAvailable
+	case repo.AlertPriceDrop:
+		return repo.AlertPriceDrop
+	}
+	switch {
+	case alertPriceDropPattern.MatchString(text):
+		return repo.AlertPriceDrop
+	case alertAvailablePattern.MatchString(text):
+		return repo.AlertAvailable
+	case !itemAvailable(item):
+		return repo.AlertAvailable
+	default:
+		return repo.AlertPriceDrop
+	}
+}
+
+// itemAvailable reports whether the supplier currently sells item. Items
+// without a status are treated as available, as at checkout.
+func itemAvailable(item atl.PriceListItem) bool {
+	return item.Status == "" || strings.EqualFold(item.Status, "available")
+}
+
+// alertTriggered reports whether item, as the latest price list has it,
+// is what alert waits for.
+func alertTriggered(alert repo.ProductAlert, item atl.PriceListItem) bool {
+	if !itemAvailable(item) {
+		return false
+	}
+	if alert.Kind == repo.AlertAvailable {
+		return true
+	}
+	price := item.Price.Rupiah()
+	if alert.TargetPrice > 0 {
+		return price <= alert.TargetPrice
+	}
+	return price < alert.BaselinePrice
+}
+
+// handleProductAlert subscribes the user to a product becoming available
+// again or getting cheaper.
+func (e *Engine) handleProductAlert(ctx context.Context, evt *events.Message, user *repo.User, text string, intent *nlu.IntentResult) error {
+	code := strings.ToUpper(strings.TrimSpace(intent.Entities["product_code"]))
+	query := strings.TrimSpace(intent.Entities["product_query"])
+	if code == "" && query == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Produk apa yang mau kupantau? Contoh: \"kabari kalau ML 86 diamond ready\" atau /pantau ML86.", "product_alert_missing")
+	}
+	item, productType, err := e.resolveProductFromQuery(ctx, code, intent.Entities["product_type"], query, intent.Entities["provider"])
+	if err != nil {
+		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "product_alert_fetch")
+	}
+	if item == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Produknya belum ketemu. Cek dulu dengan /harga lalu sebutkan kodenya ya.", "product_alert_not_found")
+	}
+	locale := e.currencyLocale(user)
+	kind := alertKind(intent.Entities["alert"], text, *item)
+	var target int64
+	if raw := strings.TrimSpace(intent.Entities["target_price"]); raw != "" {
+		if target, err = parseExactAmount(raw); err != nil {
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Harga targetnya belum kebaca, tulis seperti 20rb atau 20000 ya.", "product_alert_invalid_price")
+		}
+		kind = repo.AlertPriceDrop
+	}
+	if kind == repo.AlertAvailable && itemAvailable(*item) {
+		reply := fmt.Sprintf("%s (%s) sudah ready sekarang, harganya %s. Langsung beli aja dengan /beli %s TUJUAN ya.", item.Name, item.Code, formatCurrency(locale, item.Price), item.Code)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "product_alert_already_available")
+	}
+
+	existing, err := e.repo.ListProductAlerts(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list product alerts: %w", err)
+	}
+	for _, a := range existing {
+		if strings.EqualFold(a.ProductCode, item.Code) && a.Kind == kind {
+			reply := fmt.Sprintf("%s (%s) sudah kupantau kok. Nanti kukabari ya.", item.Name, item.Code)
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "product_alert_exists")
+		}
+	}
+	if len(existing) >= maxProductAlerts {
+		reply := fmt.Sprintf("Kamu sudah memantau %d produk, maksimalnya %d. Hapus salah satu dulu lewat /pantau ya.", len(existing), maxProductAlerts)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "product_alert_limit")
+	}
+
+	alert, err := e.repo.InsertProductAlert(ctx, repo.ProductAlert{
+		UserID:        user.ID,
+		ChatJID:       evt.Info.Sender.ToNonAD().String(),
+		ProductCode:   item.Code,
+		ProductName:   item.Name,
+		ProductType:   productType,
+		Kind:          kind,
+		BaselinePrice: item.Price.Rupiah(),
+		TargetPrice:   target,
+	})
+	if err != nil {
+		return fmt.Errorf("insert product alert: %w", err)
+	}
+	e.logger.Info("product alert created", "user_id", user.ID, "alert_id", alert.ID, "product_code", alert.ProductCode, "kind", kind)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Oke, "+describeAlert(*alert, locale)+" Lihat atau hapus pantauan lewat /pantau.", "product_alert_created")
+}
+
+// describeAlert says what the user will be told about, e.g. "kukabari
+// begitu ML86 ready lagi."
+func describeAlert(a repo.ProductAlert, locale money.Locale) string {
+	switch {
+	case a.Kind == repo.AlertAvailable:
+		return fmt.Sprintf("kukabari begitu %s (%s) ready lagi.", a.ProductName, a.ProductCode)
+	case a.TargetPrice > 0:
+		return fmt.Sprintf("kukabari begitu harga %s (%s) jadi %s atau kurang (sekarang %s).", a.ProductName, a.ProductCode, formatCurrency(locale, money.FromRupiah(a.TargetPrice)), formatCurrency(locale, money.FromRupiah(a.BaselinePrice)))
+	default:
+		return fmt.Sprintf("kukabari begitu harga %s (%s) turun dari %s.", a.ProductName, a.ProductCode, formatCurrency(locale, money.FromRupiah(a.BaselinePrice)))
+	}
+}
+
+func (e *Engine) handleListProductAlerts(ctx context.Context, evt *events.Message, user *repo.User) error {
+	alerts, err := e.repo.ListProductAlerts(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list product alerts: %w", err)
+	}
+	if len(alerts) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Belum ada produk yang kupantau. Contoh: \"kabari kalau ML 86 diamond ready\" atau /pantau ML86.", "product_alert_list")
+	}
+	locale := e.currencyLocale(user)
+	var sb strings.Builder
+	sb.WriteString("Produk yang kupantau:")
+	for i, a := range alerts {
+		sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, describeAlert(a, locale)))
+	}
+	sb.WriteString("\n\nHapus dengan /pantau batal NOMOR.")
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, sb.String(), "product_alert_list")
+}
+
+// handleCancelProductAlert drops the numbered alert from /pantau.
+func (e *Engine) handleCancelProductAlert(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	alerts, err := e.repo.ListProductAlerts(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list product alerts: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(intent.Entities["index"]), "#"))
+	if err != nil || n < 1 || n > len(alerts) {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nomor pantauan itu tidak ada. Cek dulu dengan /pantau ya.", "product_alert_cancel_invalid")
+	}
+	a := alerts[n-1]
+	if err := e.repo.CancelProductAlert(ctx, user.ID, a.ID); err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("cancel product alert: %w", err)
+	}
+	reply := fmt.Sprintf("Pantauan %s (%s) dihapus.", a.ProductName, a.ProductCode)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "product_alert_cancel")
+}
+
+// RunProductAlerts compares each price list refresh with what alert
+// subscribers wait for until ctx is cancelled.
+func (e *Engine) RunProductAlerts(ctx context.Context) {
+	ticker := time.NewTicker(productAlertCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.checkProductAlerts(ctx)
+		}
+	}
+}
+
+func (e *Engine) checkProductAlerts(ctx context.Context) {
+	alerts, err := e.repo.ListActiveProductAlerts(ctx)
+	if err != nil {
+		e.logger.Warn("failed listing product alerts", "error", err)
+		return
+	}
+	// Each product type's price list is fetched once per check.
+	catalogs := map[string]map[string]atl.PriceListItem{}
+	for _, alert := range alerts {
+		if ctx.Err() != nil {
+			return
+		}
+		catalog, ok := catalogs[alert.ProductType]
+		if !ok {
+			items, _, err := e.fetchPriceList(ctx, alert.ProductType)
+			if err != nil {
+				e.logger.Warn("product alert price list fetch failed", "error", err, "type", alert.ProductType)
+			}
+			catalog = make(map[string]atl.PriceListItem, len(items))
+			for _, item := range items {
+				catalog[strings.ToUpper(item.Code)] = item
+			}
+			catalogs[alert.ProductType] = catalog
+		}
+		item, ok := catalog[strings.ToUpper(alert.ProductCode)]
+		if !ok || !alertTriggered(alert, item) {
+			continue
+		}
+		if err := e.notifyProductAlert(ctx, alert, item); err != nil {
+			e.logger.Warn("failed sending product alert", "error", err, "alert_id", alert.ID, "user_id", alert.UserID)
+		}
+	}
+}
+
+// notifyProductAlert tells the subscriber and closes the alert. The alert
+// is closed first, so a second instance checking at the same time stays quiet.
+func (e *Engine) notifyProductAlert(ctx context.Context, alert repo.ProductAlert, item atl.PriceListItem) error {
+	jid, err := types.ParseJID(alert.ChatJID)
+	if err != nil {
+		return fmt.Errorf("parse chat jid: %w", err)
+	}
+	if err := e.repo.MarkProductAlertNotified(ctx, alert.ID, time.Now()); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	locale := e.userCurrencyLocale(ctx, alert.UserID)
+	price := formatCurrency(locale, item.Price)
+	msg := fmt.Sprintf("🔔 %s (%s) sudah ready lagi! Harganya %s.", item.Name, item.Code, price)
+	if alert.Kind == repo.AlertPriceDrop {
+		msg = fmt.Sprintf("🔔 Harga %s (%s) turun dari %s jadi %s!", item.Name, item.Code, formatCurrency(locale, money.FromRupiah(alert.BaselinePrice)), price)
+	}
+	msg += fmt.Sprintf("\nBeli sekarang dengan /beli %s TUJUAN sebelum berubah lagi ya.", item.Code)
+	e.logger.Info("product alert triggered", "alert_id", alert.ID, "user_id", alert.UserID, "product_code", item.Code, "kind", alert.Kind)
+	return e.respondAndLog(ctx, jid, alert.UserID, msg, "product_alert")
+}
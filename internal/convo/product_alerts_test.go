@@ -0,0 +1,50 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestAlertKind(t *testing.T) {
+	available := atl.PriceListItem{Code: "ML86", Status: "available"}
+	soldOut := atl.PriceListItem{Code: "ML86", Status: "unavailable"}
+	cases := []struct {
+		requested, text string
+		item            atl.PriceListItem
+		want            string
+	}{
+		{"", "kabari kalau ML 86 diamond ready", available, repo.AlertAvailable},
+		{"", "infoin kalau ML86 turun", soldOut, repo.AlertPriceDrop},
+		{"", "pantau ML86", soldOut, repo.AlertAvailable},
+		{"", "pantau ML86", available, repo.AlertPriceDrop},
+		{"price_drop", "kabari kalau ML86 ready", soldOut, repo.AlertPriceDrop},
+	}
+	for _, tc := range cases {
+		if got := alertKind(tc.requested, tc.text, tc.item); got != tc.want {
+			t.Errorf("alertKind(%q, %q) = %q, want %q", tc.requested, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestAlertTriggered(t *testing.T) {
+	item := func(price int64, status string) atl.PriceListItem {
+		return atl.PriceListItem{Code: "ML86", Price: money.FromRupiah(price), Status: status}
+	}
+	restock := repo.ProductAlert{Kind: repo.AlertAvailable, BaselinePrice: 20000}
+	if alertTriggered(restock, item(20000, "unavailable")) || !alertTriggered(restock, item(21000, "available")) {
+		t.Fatalf("availability alert misfired")
+	}
+
+	drop := repo.ProductAlert{Kind: repo.AlertPriceDrop, BaselinePrice: 20000}
+	if alertTriggered(drop, item(20000, "available")) || !alertTriggered(drop, item(19500, "")) || alertTriggered(drop, item(19000, "unavailable")) {
+		t.Fatalf("price drop alert misfired")
+	}
+
+	target := repo.ProductAlert{Kind: repo.AlertPriceDrop, BaselinePrice: 20000, TargetPrice: 18000}
+	if alertTriggered(target, item(19000, "available")) || !alertTriggered(target, item(18000, "available")) {
+		t.Fatalf("target price alert misfired")
+	}
+}
@@ -0,0 +1,77 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+)
+
+// productStatsTTL bounds how stale success rates may be when ranking matches.
+const productStatsTTL = time.Minute
+
+type productStatsCache struct {
+	flaky   map[string]bool
+	expires time.Time
+}
+
+// flakyProducts returns the product codes whose supplier success rate is below the configured threshold.
+func (e *Engine) flakyProducts(ctx context.Context) map[string]bool {
+	e.mu.RLock()
+	cached := e.productStats
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.flaky
+	}
+
+	stats, err := e.repo.ListProductStats(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading product stats", "error", err)
+		return cached.flaky
+	}
+	flaky := map[string]bool{}
+	for _, st := range stats {
+		if st.Total() >= e.cfg.LowSuccessMinSamples && st.SuccessRate() < e.cfg.LowSuccessRate {
+			flaky[strings.ToUpper(st.ProductCode)] = true
+		}
+	}
+
+	e.mu.Lock()
+	e.productStats = productStatsCache{flaky: flaky, expires: time.Now().Add(productStatsTTL)}
+	e.mu.Unlock()
+	return flaky
+}
+
+// deprioritizeFlaky moves a healthy equivalent (same provider, category and
+// nominal) ahead of each unreliable product. Matches without an equivalent keep
+// their rank so relevance still wins over reliability.
+func deprioritizeFlaky(items []atl.PriceListItem, flaky map[string]bool) []atl.PriceListItem {
+	if len(flaky) == 0 || len(items) <= 1 {
+		return items
+	}
+	res := make([]atl.PriceListItem, len(items))
+	copy(res, items)
+	for i := range res {
+		if !flaky[strings.ToUpper(res[i].Code)] {
+			continue
+		}
+		for j := i + 1; j < len(res); j++ {
+			if flaky[strings.ToUpper(res[j].Code)] || !equivalentProducts(res[i], res[j]) {
+				continue
+			}
+			healthy := res[j]
+			copy(res[i+1:j+1], res[i:j])
+			res[i] = healthy
+			break
+		}
+	}
+	return res
+}
+
+func equivalentProducts(a, b atl.PriceListItem) bool {
+	nominal := parseNominalAmount(a.Nominal)
+	return nominal > 0 && nominal == parseNominalAmount(b.Nominal) &&
+		strings.EqualFold(strings.TrimSpace(a.Provider), strings.TrimSpace(b.Provider)) &&
+		strings.EqualFold(strings.TrimSpace(a.Category), strings.TrimSpace(b.Category))
+}
@@ -0,0 +1,26 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+)
+
+func TestDeprioritizeFlaky(t *testing.T) {
+	items := []atl.PriceListItem{
+		{Code: "TSEL10", Provider: "Telkomsel", Category: "Pulsa", Nominal: "10000"},
+		{Code: "TSEL5", Provider: "Telkomsel", Category: "Pulsa", Nominal: "5000"},
+		{Code: "TSEL10B", Provider: "Telkomsel", Category: "Pulsa", Nominal: "10.000"},
+		{Code: "XL10", Provider: "XL", Category: "Pulsa", Nominal: "10000"},
+	}
+	got := deprioritizeFlaky(items, map[string]bool{"TSEL10": true, "XL10": true})
+	want := []string{"TSEL10B", "TSEL10", "TSEL5", "XL10"}
+	for i, code := range want {
+		if got[i].Code != code {
+			t.Fatalf("position %d = %s, want %s", i, got[i].Code, code)
+		}
+	}
+	if items[0].Code != "TSEL10" {
+		t.Fatalf("input slice was modified")
+	}
+}
@@ -2,16 +2,14 @@ package convo
 
 import (
 	"fmt"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
 	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
 )
 
-var amountRegex = regexp.MustCompile(`\d+(?:[.,]?\d+)?`)
-
 func filterByQuery(items []atl.PriceListItem, query, provider string, full bool) []atl.PriceListItem {
 	provider = strings.TrimSpace(strings.ToLower(provider))
 	if query == "" && provider == "" {
@@ -61,8 +59,8 @@ func filterByQuery(items []atl.PriceListItem, query, provider string, full bool)
 	for _, sc := range scored {
 		top = append(top, sc.Item)
 	}
-	if amount, err := parseAmount(query); err == nil && amount > 0 {
-		top = refineMatchesByAmount(top, amount)
+	if amount, err := parseAmount(query); err == nil && !amount.IsRange() && amount.Max > 0 {
+		top = refineMatchesByAmount(top, amount.Max)
 	}
 	if full {
 		return top
@@ -70,12 +68,18 @@ func filterByQuery(items []atl.PriceListItem, query, provider string, full bool)
 	return topN(top, 10)
 }
 
-func filterByBudget(items []atl.PriceListItem, budget int64) []atl.PriceListItem {
+// filterByBudget keeps available items priced within budget. A single amount
+// is a price cap; a range also drops items below its lower bound.
+func filterByBudget(items []atl.PriceListItem, budget Amount) []atl.PriceListItem {
 	var res []atl.PriceListItem
 	for _, item := range items {
-		if item.Price <= float64(budget) && strings.EqualFold(item.Status, "available") {
-			res = append(res, item)
+		if item.Price > money.FromRupiah(budget.Max) || !strings.EqualFold(item.Status, "available") {
+			continue
+		}
+		if budget.IsRange() && item.Price < money.FromRupiah(budget.Min) {
+			continue
 		}
+		res = append(res, item)
 	}
 	sort.Slice(res, func(i, j int) bool {
 		return res[i].Price < res[j].Price
@@ -83,10 +87,12 @@ func filterByBudget(items []atl.PriceListItem, budget int64) []atl.PriceListItem
 	return topN(res, 10)
 }
 
-func formatPriceList(items []atl.PriceListItem, full bool) string {
+// formatPriceList renders matches with "#N" tokens and returns the product
+// codes in token order, so replies like "#3 0812..." can select one.
+func formatPriceList(items []atl.PriceListItem, full bool, locale money.Locale) (string, []string) {
 	categoryMap, order := groupByCategory(items)
 	if len(order) == 0 {
-		return "Belum ada produk yang cocok."
+		return "Belum ada produk yang cocok.", nil
 	}
 
 	var builder strings.Builder
@@ -96,6 +102,7 @@ func formatPriceList(items []atl.PriceListItem, full bool) string {
 		builder.WriteString("Daftar produk:\n")
 	}
 
+	var codes []string
 	for _, category := range order {
 		builder.WriteString("- ")
 		builder.WriteString(category)
@@ -108,26 +115,29 @@ func formatPriceList(items []atl.PriceListItem, full bool) string {
 		}
 		for i := 0; i < limit; i++ {
 			item := entries[i]
+			codes = append(codes, item.Code)
 			builder.WriteString("  - ")
-			builder.WriteString(fmt.Sprintf("%s (%s) - Rp%.0f [%s]", item.Name, item.Code, item.Price, strings.ToUpper(item.Status)))
+			builder.WriteString(fmt.Sprintf("#%d %s (%s) - %s [%s]", len(codes), item.Name, item.Code, formatCurrency(locale, item.Price), strings.ToUpper(item.Status)))
 			builder.WriteString("\n")
 		}
 		if !full && len(entries) > limit {
 			builder.WriteString("  - ...\n")
 		}
 	}
+	builder.WriteString(selectionHint)
 
-	return strings.TrimSpace(builder.String())
+	return strings.TrimSpace(builder.String()), codes
 }
 
-func formatCatalogSummary(items []atl.PriceListItem) string {
+func formatCatalogSummary(items []atl.PriceListItem, locale money.Locale) (string, []string) {
 	categoryMap, order := groupByCategory(items)
 	if len(order) == 0 {
-		return "Belum ada produk yang tersedia."
+		return "Belum ada produk yang tersedia.", nil
 	}
 
 	var builder strings.Builder
 	builder.WriteString("Daftar produk lengkap:\n")
+	var codes []string
 	for _, category := range order {
 		builder.WriteString(strings.ToUpper(category))
 		builder.WriteString(":\n")
@@ -139,8 +149,9 @@ func formatCatalogSummary(items []atl.PriceListItem) string {
 		}
 		for i := 0; i < limit; i++ {
 			item := entries[i]
+			codes = append(codes, item.Code)
 			builder.WriteString("  - ")
-			builder.WriteString(fmt.Sprintf("%s (%s) - Rp%.0f [%s]", item.Name, item.Code, item.Price, strings.ToUpper(item.Status)))
+			builder.WriteString(fmt.Sprintf("#%d %s (%s) - %s [%s]", len(codes), item.Name, item.Code, formatCurrency(locale, item.Price), strings.ToUpper(item.Status)))
 			builder.WriteString("\n")
 		}
 		if len(entries) > limit {
@@ -148,8 +159,9 @@ func formatCatalogSummary(items []atl.PriceListItem) string {
 		}
 	}
 	builder.WriteString("\nKetik nama kategori atau provider untuk daftar lebih rinci.")
+	builder.WriteString(selectionHint)
 
-	return strings.TrimSpace(builder.String())
+	return strings.TrimSpace(builder.String()), codes
 }
 
 func matchScore(item atl.PriceListItem, tokens []string, provider string) int {
@@ -228,47 +240,6 @@ func filterStopWords(tokens []string) []string {
 	return result
 }
 
-func parseAmount(text string) (int64, error) {
-	if text == "" {
-		return 0, fmt.Errorf("empty amount")
-	}
-	text = strings.ToLower(strings.TrimSpace(text))
-
-	// Match number followed by optional suffix (k, rb, ribu, jt, juta, m)
-	// This avoids false matches like 'k' in 'kirim' or 'm' in 'mobile'
-	re := regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(k|rb|ribu|jt|juta|m)?(?:\s|$|[^a-z])`)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) < 2 {
-		// Fallback: just find any number
-		basic := amountRegex.FindString(text)
-		if basic == "" {
-			return 0, fmt.Errorf("no numeric value")
-		}
-		basic = strings.ReplaceAll(basic, ".", "")
-		basic = strings.ReplaceAll(basic, ",", "")
-		return strconv.ParseInt(basic, 10, 64)
-	}
-
-	numStr := matches[1]
-	suffix := matches[2]
-	numStr = strings.ReplaceAll(numStr, ".", "")
-	numStr = strings.ReplaceAll(numStr, ",", "")
-
-	num, err := strconv.ParseInt(numStr, 10, 64)
-	if err != nil {
-		return 0, err
-	}
-
-	switch suffix {
-	case "k", "rb", "ribu":
-		num *= 1000
-	case "jt", "juta", "m":
-		num *= 1000000
-	}
-
-	return num, nil
-}
-
 type scoredItem struct {
 	Item  atl.PriceListItem
 	Score int
@@ -349,7 +320,7 @@ func refineMatchesByAmount(items []atl.PriceListItem, amount int64) []atl.PriceL
 }
 
 func amountDiff(item atl.PriceListItem, amount int64) int64 {
-	best := absInt64(int64(item.Price) - amount)
+	best := absInt64(item.Price.Rupiah() - amount)
 	if nominal := parseNominalAmount(item.Nominal); nominal > 0 {
 		diff := absInt64(nominal - amount)
 		if diff < best {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
 )
 
 func TestFilterByQueryPrefersAmount(t *testing.T) {
@@ -14,7 +15,7 @@ func TestFilterByQueryPrefersAmount(t *testing.T) {
 			Category: "Pulsa",
 			Provider: "Telkomsel",
 			Nominal:  "10000",
-			Price:    10000,
+			Price:    money.FromRupiah(10000),
 			Status:   "available",
 		},
 		{
@@ -23,7 +24,7 @@ func TestFilterByQueryPrefersAmount(t *testing.T) {
 			Category: "Pulsa",
 			Provider: "Telkomsel",
 			Nominal:  "20000",
-			Price:    20000,
+			Price:    money.FromRupiah(20000),
 			Status:   "available",
 		},
 	}
@@ -39,8 +40,8 @@ func TestFilterByQueryPrefersAmount(t *testing.T) {
 
 func TestRefineMatchesByAmountUsesNominal(t *testing.T) {
 	items := []atl.PriceListItem{
-		{Code: "A", Name: "Item A", Nominal: "5000", Price: 7000},
-		{Code: "B", Name: "Item B", Nominal: "10000", Price: 7000},
+		{Code: "A", Name: "Item A", Nominal: "5000", Price: money.FromRupiah(7000)},
+		{Code: "B", Name: "Item B", Nominal: "10000", Price: money.FromRupiah(7000)},
 	}
 
 	res := refineMatchesByAmount(items, 10000)
@@ -0,0 +1,59 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bot-jual/internal/cache"
+)
+
+// purchaseLockTTL outlasts the slowest purchase (variant retries included),
+// and bounds how long a crashed instance blocks the user's next purchase.
+const purchaseLockTTL = 2 * time.Minute
+
+const purchaseBusyMessage = "Pesanan kamu sebelumnya masih diproses. Tunggu sebentar ya, nanti aku kabari hasilnya."
+
+// lockPurchase serialises order and deposit creation per user, so a
+// double-sent message or two instances handling it at once cannot create two
+// Atlantic transactions. ok is false while another purchase holds the lock;
+// otherwise release must be called when the purchase is done. Without Redis,
+// or while it is down, the lock only spans this process.
+func (e *Engine) lockPurchase(ctx context.Context, userID string) (release func(), ok bool) {
+	key := "lock:purchase:" + userID
+	if e.cacheAvailable() {
+		lock, err := e.cache.Lock(ctx, key, purchaseLockTTL)
+		switch {
+		case err == nil:
+			return func() {
+				if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+					e.logger.Warn("failed releasing purchase lock", "error", err, "user_id", userID)
+				}
+			}, true
+		case errors.Is(err, cache.ErrLocked):
+			return nil, false
+		default:
+			e.logger.Warn("purchase lock unavailable, using local lock", "error", err, "user_id", userID)
+		}
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if expires, held := e.purchaseLocks[key]; held && now.Before(expires) {
+		return nil, false
+	}
+	if e.purchaseLocks == nil {
+		e.purchaseLocks = make(map[string]time.Time)
+	}
+	expires := now.Add(purchaseLockTTL)
+	e.purchaseLocks[key] = expires
+	return func() {
+		e.mu.Lock()
+		// A holder that outlived the TTL must not free its successor's lock.
+		if e.purchaseLocks[key].Equal(expires) {
+			delete(e.purchaseLocks, key)
+		}
+		e.mu.Unlock()
+	}, true
+}
@@ -0,0 +1,45 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLockPurchaseLocalFallback(t *testing.T) {
+	e := &Engine{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ctx := context.Background()
+
+	release, ok := e.lockPurchase(ctx, "u1")
+	if !ok {
+		t.Fatalf("first lock refused")
+	}
+	if _, ok := e.lockPurchase(ctx, "u1"); ok {
+		t.Fatalf("second purchase for the same user got the lock")
+	}
+	releaseOther, ok := e.lockPurchase(ctx, "u2")
+	if !ok {
+		t.Fatalf("another user's purchase was blocked")
+	}
+	releaseOther()
+
+	release()
+	again, ok := e.lockPurchase(ctx, "u1")
+	if !ok {
+		t.Fatalf("lock not freed by release")
+	}
+
+	// A stale holder must not free the lock its successor took after expiry.
+	e.purchaseLocks["lock:purchase:u1"] = time.Now().Add(-time.Second)
+	successor, ok := e.lockPurchase(ctx, "u1")
+	if !ok {
+		t.Fatalf("expired lock not taken over")
+	}
+	again()
+	if _, ok := e.lockPurchase(ctx, "u1"); ok {
+		t.Fatalf("stale release freed the successor's lock")
+	}
+	successor()
+}
@@ -0,0 +1,278 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/receipt"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxOrderQuantity caps how many vouchers one order buys, so every SN still
+// fits on a single receipt.
+const maxOrderQuantity = 5
+
+// allowsQuantity reports whether several units of item can be bought in one
+// order. Only vouchers qualify: each unit is a separate code, while a
+// top-up bought twice for the same target is better bought as a bigger
+// denomination.
+func allowsQuantity(item *atl.PriceListItem) bool {
+	combined := strings.ToLower(strings.Join([]string{item.Category, item.Name, item.Provider}, " "))
+	return strings.Contains(combined, "voucher")
+}
+
+// orderQuantity resolves how many units of item to buy for the requested
+// quantity, with a note for the user when fewer are prepared.
+func orderQuantity(item *atl.PriceListItem, requested int) (int, string) {
+	switch {
+	case requested <= 1:
+		return 1, ""
+	case !allowsQuantity(item):
+		return 1, "Satu pesanan untuk satu transaksi ya, jadi aku siapkan 1 dulu. Sisanya bisa dipesan lagi setelah ini selesai."
+	case requested > maxOrderQuantity:
+		return maxOrderQuantity, fmt.Sprintf("Maksimal %d voucher sekali pesan ya, jadi aku siapkan %d dulu.", maxOrderQuantity, maxOrderQuantity)
+	default:
+		return requested, ""
+	}
+}
+
+// batchRefs returns the order refs for quantity units bought under ref. A
+// single unit keeps ref itself.
+func batchRefs(ref string, quantity int) []string {
+	if quantity <= 1 {
+		return []string{ref}
+	}
+	refs := make([]string, quantity)
+	for i := range refs {
+		refs[i] = receipt.UnitRef(ref, i+1)
+	}
+	return refs
+}
+
+// recordBatch marks an order's metadata as one unit of a batch, which the
+// receipts use to find the other units.
+func recordBatch(meta map[string]any, batchRef string, size int) {
+	meta["batch_ref"] = batchRef
+	meta["batch_size"] = size
+}
+
+// carryBatch copies the batch an order belongs to into meta, which is about
+// to replace the order's metadata.
+func (e *Engine) carryBatch(ctx context.Context, orderRef string, meta map[string]any) {
+	order, err := e.repo.GetOrderByRef(ctx, orderRef)
+	if err != nil {
+		return
+	}
+	if batchRef := stringValue(order.Metadata, "batch_ref"); batchRef != "" {
+		meta["batch_ref"] = batchRef
+		meta["batch_size"] = order.Metadata["batch_size"]
+	}
+}
+
+// executePrepaidBatchWithBalance buys quantity units of item from the
+// user's balance, one supplier transaction per unit. Units are bought in
+// turn; a unit that fails outright cancels the rest, since the same product
+// and target would most likely fail again. The user gets one reply listing
+// every unit and, once all have finished, one receipt for the batch.
+func (e *Engine) executePrepaidBatchWithBalance(ctx context.Context, evt *events.Message, user *repo.User, productCode, customerID, customerZone, rawCustomerID, batchRef string, item *atl.PriceListItem, productType string, quantity int, annotations orderAnnotations) error {
+	unitPrice := item.Price.Rupiah()
+	total := unitPrice * int64(quantity)
+	if proceed, err := e.checkTrustTotal(ctx, evt, user, money.FromRupiah(total)); !proceed {
+		return err
+	}
+	locale := e.currencyLocale(user)
+	ub, err := e.repo.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		e.logger.Error("failed to check balance", "error", err, "user", user.ID)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgBalanceCheckFailed), "balance_check_failed")
+	}
+	if ub == nil || ub.SaldoConfirmed < total {
+		currentBalance := int64(0)
+		if ub != nil {
+			currentBalance = ub.SaldoConfirmed
+		}
+		reply := localize(user, msgInsufficientBalanceQuantity, formatCurrency(locale, money.FromRupiah(currentBalance)), quantity, formatCurrency(locale, item.Price), formatCurrency(locale, money.FromRupiah(total)))
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "insufficient_balance")
+	}
+
+	batchRef = strings.TrimSpace(batchRef)
+	if batchRef == "" {
+		batchRef = generateRefID("trx")
+	}
+	instructions := e.productInstructions(ctx, *item)
+	refs := batchRefs(batchRef, quantity)
+	// Pre-create every unit so the whole batch shows up, and can be
+	// settled, even if the supplier errors midway.
+	for _, ref := range refs {
+		preMeta := map[string]any{
+			"customer_id": customerID,
+			"precreate":   true,
+		}
+		if customerZone != "" {
+			preMeta["customer_zone"] = customerZone
+		}
+		if strings.TrimSpace(productType) != "" {
+			preMeta["product_type"] = productType
+		}
+		recordBatch(preMeta, batchRef, quantity)
+		annotations.applyTo(preMeta)
+		recordInstructions(preMeta, instructions)
+		recordReplyTarget(preMeta, evt)
+		e.tagAcquisition(ctx, user.ID, preMeta)
+		if order, err := e.repo.InsertOrder(ctx, repo.Order{
+			UserID:      user.ID,
+			OrderRef:    ref,
+			ProductCode: productCode,
+			Amount:      unitPrice,
+			Status:      "processing",
+			Metadata:    preMeta,
+		}); err != nil {
+			e.logger.Warn("failed precreate order", "error", err, "order_ref", ref)
+		} else {
+			e.orderCreated(ctx, order)
+		}
+		e.recordOrderQuote(ctx, ref, productType, item)
+	}
+
+	candidates := generateTargetCandidates(customerID, customerZone, rawCustomerID)
+	lines := make([]string, 0, quantity)
+	var (
+		stopped   string
+		succeeded int
+		open      bool
+	)
+	for i, ref := range refs {
+		if stopped != "" {
+			e.cancelBatchUnit(ctx, ref, customerID, customerZone, batchRef, quantity, "batch stopped: "+stopped)
+			lines = append(lines, fmt.Sprintf("%d. %s — dibatalkan", i+1, ref))
+			continue
+		}
+		result, outcome, _ := e.buyBatchUnit(ctx, evt, user, item, ref, candidates, customerID, customerZone, batchRef, quantity, annotations, instructions)
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, ref, result))
+		switch outcome {
+		case unitSucceeded:
+			succeeded++
+		case unitOpen:
+			open = true
+		case unitFailed:
+			stopped = ref
+		}
+	}
+
+	reply := fmt.Sprintf("Pesanan %d× %s (%s), ref %s:\n%s", quantity, item.Name, item.Code, batchRef, strings.Join(lines, "\n"))
+	if open {
+		reply += "\nYang masih diproses akan ku kabari begitu ada update."
+	}
+	reply += annotations.replySuffix()
+	reply += e.receiptLine(user, refs[0])
+	if succeeded > 0 {
+		reply = withInstructions(user, reply, instructions)
+	}
+	event := "create_prepaid_batch"
+	if succeeded == 0 && !open {
+		event = "create_prepaid_batch_failed"
+	}
+	if err := e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, event); err != nil {
+		return err
+	}
+	if succeeded > 0 && !open {
+		e.sendReceiptDocument(ctx, evt.Info.Sender, user.ID, refs[0])
+	}
+	return nil
+}
+
+// unitOutcome is how buying one unit of a batch ended.
+type unitOutcome int
+
+const (
+	unitSucceeded unitOutcome = iota
+	// unitOpen is still processing at the supplier or queued for a retry.
+	unitOpen
+	unitFailed
+)
+
+// buyBatchUnit buys the unit ref of a batch for customerID, trying
+// candidates as the target, and records the outcome on the unit's order. It
+// returns the outcome worded for the batch reply and the supplier error, if
+// any.
+func (e *Engine) buyBatchUnit(ctx context.Context, evt *events.Message, user *repo.User, item *atl.PriceListItem, ref string, candidates []string, customerID, customerZone, batchRef string, size int, annotations orderAnnotations, instructions string) (string, unitOutcome, error) {
+	resp, usedTarget, err := e.createPrepaidWithRetry(ctx, item.Code, ref, annotations.atlanticNote(), candidates, user.ID)
+	if resp == nil {
+		if isTemporaryServerError(err, "") {
+			go e.retryPrepaidAsync(context.Background(), user, evt.Info.Sender, item.Name, item.Code, ref, annotations.atlanticNote(), candidates, customerZone, instructions)
+			return "diantre (gangguan server)", unitOpen, err
+		}
+		friendly := friendlyAtlanticError(err)
+		if friendly == "" {
+			friendly = "gangguan sistem"
+		}
+		failMeta := map[string]any{"customer_id": customerID}
+		if customerZone != "" {
+			failMeta["customer_zone"] = customerZone
+		}
+		recordBatch(failMeta, batchRef, size)
+		failMeta["error"] = strings.TrimSpace(err.Error())
+		e.carryAcquisition(ctx, ref, failMeta)
+		if err := e.repo.UpdateOrderStatus(ctx, ref, "failed", failMeta); err != nil {
+			e.logger.Warn("update order after failure", "error", err, "order_ref", ref)
+		}
+		return "gagal: " + friendly, unitFailed, err
+	}
+
+	target := customerID
+	if usedTarget != "" {
+		target = usedTarget
+	}
+	metadata := map[string]any{
+		"customer_id": target,
+		"message":     resp.Message,
+		"sn":          resp.SN,
+	}
+	if customerZone != "" {
+		metadata["customer_zone"] = customerZone
+	}
+	recordBatch(metadata, batchRef, size)
+	annotations.applyTo(metadata)
+	recordInstructions(metadata, instructions)
+	recordSupplier(metadata, resp)
+	e.carryAcquisition(ctx, ref, metadata)
+	if err := e.repo.UpdateOrderStatus(ctx, ref, resp.Status, metadata); err != nil {
+		e.logger.Warn("failed updating order after success", "error", err, "order_ref", ref)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(resp.Status)) {
+	case "", "pending", "processing", "process":
+		return "diproses", unitOpen, nil
+	case "success", "completed", "ok", "available":
+		e.orderSucceeded(ctx, ref, resp.SN)
+		if resp.SN != "" {
+			return "sukses, SN: " + resp.SN, unitSucceeded, nil
+		}
+		return "sukses", unitSucceeded, nil
+	default:
+		failure := strings.TrimSpace(resp.Message)
+		if failure == "" {
+			failure = "ditolak supplier"
+		}
+		return "gagal: " + failure, unitFailed, nil
+	}
+}
+
+// cancelBatchUnit fails the pre-created unit ref of a batch that will not
+// be bought, recording why.
+func (e *Engine) cancelBatchUnit(ctx context.Context, ref, customerID, customerZone, batchRef string, size int, reason string) {
+	meta := map[string]any{"customer_id": customerID, "error": reason}
+	if customerZone != "" {
+		meta["customer_zone"] = customerZone
+	}
+	recordBatch(meta, batchRef, size)
+	e.carryAcquisition(ctx, ref, meta)
+	if err := e.repo.UpdateOrderStatus(ctx, ref, "failed", meta); err != nil {
+		e.logger.Warn("update order after batch stop", "error", err, "order_ref", ref)
+	}
+}
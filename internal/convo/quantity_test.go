@@ -0,0 +1,89 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestOrderQuantity(t *testing.T) {
+	voucher := &atl.PriceListItem{Code: "GPLAY50", Name: "Google Play 50.000", Category: "Voucher"}
+	pulsa := &atl.PriceListItem{Code: "TSEL10", Name: "Telkomsel 10.000", Category: "Pulsa"}
+
+	if n, note := orderQuantity(voucher, 3); n != 3 || note != "" {
+		t.Fatalf("voucher x3 = %d, %q", n, note)
+	}
+	if n, note := orderQuantity(voucher, 9); n != maxOrderQuantity || !strings.Contains(note, "Maksimal") {
+		t.Fatalf("voucher x9 = %d, %q", n, note)
+	}
+	if n, note := orderQuantity(pulsa, 2); n != 1 || note == "" {
+		t.Fatalf("pulsa x2 = %d, %q", n, note)
+	}
+	if refs := batchRefs("trx-1", 3); len(refs) != 3 || refs[0] != "trx-1-1" || refs[2] != "trx-1-3" {
+		t.Fatalf("batchRefs = %q", refs)
+	}
+	if refs := batchRefs("trx-1", 1); len(refs) != 1 || refs[0] != "trx-1" {
+		t.Fatalf("batchRefs single = %q", refs)
+	}
+}
+
+func TestDraftQuantityCarriesOver(t *testing.T) {
+	draft := &draftOrder{ProductCode: "GPLAY50", ProductName: "Google Play 50.000", CustomerID: "081234567890", Quantity: 3, Price: 51000}
+	entities := map[string]string{"payment_method": "deposit"}
+	draft.fill(entities)
+	if draftQuantity(entities) != 3 {
+		t.Fatalf("quantity not carried: %v", entities)
+	}
+	summary := draft.summary(nil, money.Indonesian)
+	if !strings.Contains(summary, "Jumlah: 3 × Rp51.000") || !strings.Contains(summary, "Total: Rp153.000") {
+		t.Fatalf("summary = %q", summary)
+	}
+}
+
+type trustRepo struct {
+	repo.Repository
+	successful int
+}
+
+func (r *trustRepo) CountSuccessfulOrders(context.Context, string) (int, error) {
+	return r.successful, nil
+}
+
+func (r *trustRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+func TestBatchAboveTrustCap(t *testing.T) {
+	gw := &textGateway{}
+	// GetUserBalance and InsertOrder are left unimplemented: reaching them
+	// would mean units were about to be created.
+	e := &Engine{
+		repo:    &trustRepo{},
+		gateway: gw,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cfg:     EngineConfig{TrustLevels: []TrustLevel{{MinOrders: 0, MaxAmount: 100000}}},
+	}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	user := &repo.User{ID: "u1"}
+	item := &atl.PriceListItem{Code: "GPLAY50", Name: "Google Play 50.000", Price: money.FromRupiah(51000)}
+
+	// One voucher is within the cap; three are not.
+	if proceed, _ := e.checkTrust(context.Background(), evt, user, item, "voucher"); !proceed {
+		t.Fatalf("single unit held back")
+	}
+	if err := e.executePrepaidBatchWithBalance(context.Background(), evt, user, item.Code, "081234567890", "", "081234567890", "trx-b", item, "voucher", 3, orderAnnotations{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(gw.sent) != 1 || !strings.Contains(gw.sent[0], "total pesanan Rp153.000 melebihi limit") {
+		t.Fatalf("messages = %q", gw.sent)
+	}
+}
@@ -0,0 +1,116 @@
+package convo
+
+import (
+	"regexp"
+	"strings"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/wa"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var (
+	// quotedProductPattern matches price list lines such as "Telkomsel 25K (TSEL25) - Rp25500".
+	quotedProductPattern  = regexp.MustCompile(`\(([A-Za-z0-9_.-]+)\) - Rp`)
+	quotedOrderRefPattern = regexp.MustCompile(`\b(?:trx|dep|bill|tf|wd)-[0-9a-f]{16}\b`)
+)
+
+// quotedReference is what a user's reply points at when it quotes an earlier message.
+type quotedReference struct {
+	text string
+	// productCode is set when the quoted message lists exactly one product,
+	// or the reply names one of the listed codes.
+	productCode string
+	orderRef    string
+}
+
+// parseQuotedReference reads the message a reply quotes, if any.
+func parseQuotedReference(evt *events.Message, replyText string) quotedReference {
+	quoted := evt.Message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage()
+	text := quotedMessageText(quoted)
+	if text == "" {
+		return quotedReference{}
+	}
+	ref := quotedReference{
+		text:     text,
+		orderRef: quotedOrderRefPattern.FindString(text),
+	}
+
+	var codes []string
+	for _, match := range quotedProductPattern.FindAllStringSubmatch(text, -1) {
+		codes = append(codes, strings.ToUpper(match[1]))
+	}
+	switch len(codes) {
+	case 0:
+	case 1:
+		ref.productCode = codes[0]
+	default:
+		for _, code := range codes {
+			if containsWord(strings.ToUpper(replyText), code) {
+				ref.productCode = code
+				break
+			}
+		}
+	}
+	return ref
+}
+
+func quotedMessageText(msg *waProto.Message) string {
+	switch {
+	case msg == nil:
+		return ""
+	case msg.GetConversation() != "":
+		return strings.TrimSpace(msg.GetConversation())
+	case msg.GetExtendedTextMessage().GetText() != "":
+		return strings.TrimSpace(msg.GetExtendedTextMessage().GetText())
+	default:
+		return strings.TrimSpace(msg.GetImageMessage().GetCaption())
+	}
+}
+
+// applyQuotedReference fills the product or order a quoted message points at
+// into an intent that did not name one itself, so "yang ini" on a product
+// line starts that purchase and "gimana statusnya?" on a receipt checks it.
+func applyQuotedReference(intent *nlu.IntentResult, ref quotedReference) {
+	if intent == nil || ref.text == "" {
+		return
+	}
+	if intent.Entities == nil {
+		intent.Entities = map[string]string{}
+	}
+	switch intent.Intent {
+	case "check_status", "pay_bill":
+		if ref.orderRef != "" && intent.Entities["ref_id"] == "" {
+			intent.Entities["ref_id"] = ref.orderRef
+		}
+		return
+	case "create_prepaid":
+		if ref.productCode != "" && intent.Entities["product_code"] == "" {
+			intent.Entities["product_code"] = ref.productCode
+		}
+		return
+	case "", "fallback", "smalltalk":
+	default:
+		return
+	}
+
+	switch {
+	case ref.productCode != "":
+		intent.Intent = "create_prepaid"
+		intent.Entities["product_code"] = ref.productCode
+		delete(intent.Entities, "product_query")
+	case ref.orderRef != "":
+		intent.Intent = "check_status"
+		intent.Entities["ref_id"] = ref.orderRef
+	}
+}
+
+// recordReplyTarget remembers the message that placed an order so webhook
+// notifications about it can quote it, keeping parallel orders apart.
+func recordReplyTarget(meta map[string]any, evt *events.Message) {
+	if ref, ok := wa.NewReplyRef(evt, extractText(evt)); ok {
+		meta[wa.ReplyRefKey] = ref
+	}
+}
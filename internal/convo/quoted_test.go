@@ -0,0 +1,65 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/nlu"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func quotingEvent(reply, quoted string) *events.Message {
+	return &events.Message{Message: &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text: proto.String(reply),
+			ContextInfo: &waProto.ContextInfo{
+				QuotedMessage: &waProto.Message{Conversation: proto.String(quoted)},
+			},
+		},
+	}}
+}
+
+func TestParseQuotedReference(t *testing.T) {
+	list := "Daftar produk:\n- Pulsa:\n  - Telkomsel 25K (TSEL25) - Rp25.500 [AVAILABLE]\n  - Telkomsel 50K (TSEL50) - Rp50.200 [AVAILABLE]"
+
+	if ref := parseQuotedReference(quotingEvent("yang tsel50 ya", list), "yang tsel50 ya"); ref.productCode != "TSEL50" {
+		t.Fatalf("productCode = %q, want TSEL50", ref.productCode)
+	}
+	if ref := parseQuotedReference(quotingEvent("yang ini", list), "yang ini"); ref.productCode != "" {
+		t.Fatalf("ambiguous list resolved to %q", ref.productCode)
+	}
+	single := "Kamu mau beli Telkomsel 25K (TSEL25) - Rp25.500 [AVAILABLE]"
+	if ref := parseQuotedReference(quotingEvent("yang ini", single), "yang ini"); ref.productCode != "TSEL25" {
+		t.Fatalf("productCode = %q, want TSEL25", ref.productCode)
+	}
+	receipt := "Transaksi trx-0123456789abcdef sedang diproses."
+	if ref := parseQuotedReference(quotingEvent("udah masuk belum?", receipt), ""); ref.orderRef != "trx-0123456789abcdef" {
+		t.Fatalf("orderRef = %q", ref.orderRef)
+	}
+	plain := &events.Message{Message: &waProto.Message{Conversation: proto.String("halo")}}
+	if ref := parseQuotedReference(plain, "halo"); ref.text != "" {
+		t.Fatalf("unquoted message produced %+v", ref)
+	}
+}
+
+func TestApplyQuotedReference(t *testing.T) {
+	intent := &nlu.IntentResult{Intent: "fallback", Entities: map[string]string{}}
+	applyQuotedReference(intent, quotedReference{text: "x", productCode: "TSEL25"})
+	if intent.Intent != "create_prepaid" || intent.Entities["product_code"] != "TSEL25" {
+		t.Fatalf("got %s %v", intent.Intent, intent.Entities)
+	}
+
+	status := &nlu.IntentResult{Intent: "check_status", Entities: map[string]string{}}
+	applyQuotedReference(status, quotedReference{text: "x", orderRef: "trx-0123456789abcdef"})
+	if status.Entities["ref_id"] != "trx-0123456789abcdef" {
+		t.Fatalf("ref_id = %q", status.Entities["ref_id"])
+	}
+
+	lookup := &nlu.IntentResult{Intent: "price_lookup", Entities: map[string]string{"product_query": "yang lebih murah"}}
+	applyQuotedReference(lookup, quotedReference{text: "x", productCode: "TSEL25"})
+	if lookup.Intent != "price_lookup" || lookup.Entities["product_code"] != "" {
+		t.Fatalf("price lookup was rewritten: %s %v", lookup.Intent, lookup.Entities)
+	}
+}
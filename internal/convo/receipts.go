@@ -0,0 +1,64 @@
+package convo
+
+import (
+	"context"
+
+	"bot-jual/internal/receipt"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// documentGateway is implemented by gateways that can send files.
+type documentGateway interface {
+	SendDocument(ctx context.Context, to types.JID, data []byte, mimeType, fileName, caption string) error
+}
+
+// receiptLine renders the public receipt link for an order as an extra reply
+// line, or "" when receipts are not configured.
+func (e *Engine) receiptLine(user *repo.User, orderRef string) string {
+	if url := e.cfg.Receipts.URL(orderRef); url != "" {
+		return "\n" + localize(user, msgReceiptLine, url)
+	}
+	return ""
+}
+
+// sendReceiptDocument follows a success reply with the order's PDF receipt.
+// It is best effort: the reply already carries the reference and SN. For a
+// unit of a batch it sends one receipt for the whole batch once every unit
+// has finished, and nothing before that.
+func (e *Engine) sendReceiptDocument(ctx context.Context, to types.JID, userID, orderRef string) {
+	if !e.cfg.ReceiptDocuments {
+		return
+	}
+	sender, ok := e.gateway.(documentGateway)
+	if !ok {
+		return
+	}
+	order, err := e.repo.GetOrderByRef(ctx, orderRef)
+	if err != nil {
+		e.logger.Warn("failed loading order for receipt document", "error", err, "order_ref", orderRef)
+		return
+	}
+	units, err := receipt.LoadBatch(ctx, e.repo, order)
+	if err != nil {
+		e.logger.Warn("failed loading batch for receipt document", "error", err, "order_ref", orderRef)
+		return
+	}
+	var doc receipt.Document
+	switch {
+	case units == nil:
+		doc = receipt.NewDocument(order, e.cfg.Persona.ShopName, e.userCurrencyLocale(ctx, userID))
+	case receipt.BatchFinal(units):
+		doc = receipt.NewBatchDocument(units, e.cfg.Persona.ShopName, e.userCurrencyLocale(ctx, userID))
+	default:
+		return
+	}
+	doc.URL = e.cfg.Receipts.URL(orderRef)
+	doc.Location = e.userZone(ctx, userID)
+	sendCtx := wa.WithOrigin(ctx, wa.Origin{UserID: userID, Category: "receipt_document"})
+	if err := sender.SendDocument(sendCtx, to, doc.PDF(), "application/pdf", doc.FileName(), "Bukti transaksi "+doc.OrderRef); err != nil {
+		e.logger.Warn("failed sending receipt document", "error", err, "order_ref", orderRef)
+	}
+}
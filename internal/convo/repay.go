@@ -0,0 +1,269 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// repayWindow bounds how old an order may be for "bayar ulang" to reissue
+// its payment at the original quote.
+const repayWindow = 24 * time.Hour
+
+// repayOrderScan is how many recent orders are searched when the user does
+// not name one.
+const repayOrderScan = 10
+
+func looksLikeRepayRequest(text string) bool {
+	return strings.Contains(text, "bayar ulang") ||
+		strings.Contains(text, "qr baru") ||
+		strings.Contains(text, "qris baru") ||
+		strings.Contains(text, "qr ulang") ||
+		strings.Contains(text, "qris ulang")
+}
+
+// repayable reports whether order is still waiting for its payment, or was
+// cancelled only because its deposit expired or failed.
+func repayable(order repo.Order, now time.Time) bool {
+	if stringValue(order.Metadata, "deposit_ref") == "" {
+		return false
+	}
+	if !order.CreatedAt.IsZero() && now.Sub(order.CreatedAt) > repayWindow {
+		return false
+	}
+	switch order.Status {
+	case "awaiting_payment":
+		return true
+	case "failed":
+		return stringValue(order.Metadata, "auto_fulfill_error") == "" && stringValue(order.Metadata, "sn") == ""
+	default:
+		return false
+	}
+}
+
+// findRepayOrder picks the order to pay again: the one named by ref (an
+// order or deposit ref), else the user's newest repayable order.
+func (e *Engine) findRepayOrder(ctx context.Context, userID, ref string) (*repo.Order, error) {
+	now := time.Now()
+	if ref != "" {
+		if strings.HasPrefix(ref, "dep-") {
+			orders, err := e.repo.ListOrdersByUser(ctx, userID, repayOrderScan, 0)
+			if err != nil {
+				return nil, err
+			}
+			for _, order := range orders {
+				if stringValue(order.Metadata, "deposit_ref") == ref && repayable(order, now) {
+					return &order, nil
+				}
+			}
+			return nil, nil
+		}
+		order, err := e.repo.GetOrderByRef(ctx, ref)
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if order.UserID != userID || !repayable(*order, now) {
+			return nil, nil
+		}
+		return order, nil
+	}
+	orders, err := e.repo.ListOrdersByUser(ctx, userID, repayOrderScan, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if repayable(order, now) {
+			return &order, nil
+		}
+	}
+	return nil, nil
+}
+
+// handleRegenerateDeposit replaces the expired deposit of an order with a
+// fresh one and a new QR. The order keeps its quoted amount, so a flash
+// sale price applied to the original quote still holds.
+func (e *Engine) handleRegenerateDeposit(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	if _, provider := e.paymentProvider(); provider == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgRepayUnavailable), "regenerate_deposit_unavailable")
+	}
+	ref := strings.TrimSpace(intent.Entities["ref_id"])
+	order, err := e.findRepayOrder(ctx, user.ID, ref)
+	if err != nil {
+		e.logger.Error("failed finding order to repay", "error", err, "user_id", user.ID, "ref_id", ref)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgRepayLookupFailed), "regenerate_deposit_failed")
+	}
+	if order == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgRepayNone), "regenerate_deposit_none")
+	}
+
+	oldRef := stringValue(order.Metadata, "deposit_ref")
+	oldDep, err := e.repo.GetDepositByRef(ctx, oldRef)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		e.logger.Warn("failed loading deposit to replace", "error", err, "deposit_ref", oldRef)
+	}
+	var oldMeta map[string]any
+	method := e.defaultDepositMethod()
+	if oldDep != nil {
+		oldMeta = oldDep.Metadata
+		if oldDep.Method != "" {
+			method = oldDep.Method
+		}
+	}
+	if method == "" {
+		method = "qris"
+	}
+
+	if providerID, provider := stringValue(oldMeta, "provider_id"), e.depositProvider(oldMeta); providerID != "" && provider != nil {
+		if status, err := provider.DepositStatus(ctx, providerID); err == nil && strings.EqualFold(status.Status, "success") {
+			reply := localize(user, msgRepayAlreadyPaid, oldRef, order.OrderRef)
+			return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "regenerate_deposit_paid")
+		}
+		if _, err := provider.CancelDeposit(ctx, providerID); err != nil {
+			// Usually the provider already expired it; the new deposit is
+			// what the order waits on from here.
+			e.logger.Info("cancel of replaced deposit failed", "error", err, "deposit_ref", oldRef)
+		}
+	}
+
+	amount := order.Amount
+	grossAmount := e.requiredDepositGross(amount)
+	depositType := e.cfg.DefaultDepositType
+	if strings.EqualFold(method, "bri") {
+		depositType = "bank"
+	}
+	newRef := generateRefID("dep")
+	depResp, provider, err := e.createDeposit(ctx, atl.DepositRequest{
+		Method: method,
+		Amount: money.FromRupiah(grossAmount),
+		RefID:  newRef,
+		Type:   depositType,
+	})
+	if err != nil {
+		e.logger.Warn("regenerate deposit request failed", "error", err, "user_id", user.ID, "order_ref", order.OrderRef)
+		feedback := friendlyAtlanticError(err)
+		if feedback == "" {
+			feedback = localize(user, msgRepayQRUnavailable)
+		} else {
+			feedback = localize(user, msgRepayQRFailed, feedback)
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, feedback, "regenerate_deposit_failed")
+	}
+
+	feeAmount := depResp.Fee.Rupiah()
+	netAmount := deriveNetAmount(amount, feeAmount, depResp.NetAmount.Rupiah())
+	productName := stringValue(order.Metadata, "product")
+	if productName == "" {
+		productName = stringValue(oldMeta, "product")
+	}
+	if productName == "" {
+		productName = order.ProductCode
+	}
+	metadata := map[string]any{
+		"checkout":          depResp.Checkout,
+		"payment_provider":  provider,
+		"product":           productName,
+		"product_code":      order.ProductCode,
+		"gross_amount":      grossAmount,
+		"requested_amount":  grossAmount,
+		"target_net_amount": amount,
+		"replaces_deposit":  oldRef,
+	}
+	if depResp.ID != "" {
+		metadata["provider_id"] = depResp.ID
+	}
+	if providerAmount := depResp.Amount.Rupiah(); providerAmount > 0 {
+		metadata["provider_amount"] = providerAmount
+	}
+	if feeAmount > 0 {
+		metadata["fee"] = feeAmount
+	}
+	if netAmount > 0 {
+		metadata["net_amount"] = netAmount
+	}
+	if zone := stringValue(order.Metadata, "customer_zone"); zone != "" {
+		metadata["customer_zone"] = zone
+	}
+	depositAmount := grossAmount
+	if netAmount > 0 {
+		depositAmount = netAmount
+	}
+	depStatus, forced := forceSuccessIfPending(depResp.Status)
+	if forced {
+		metadata["forced_success"] = true
+		metadata["original_status"] = depResp.Status
+	}
+	if _, err := e.repo.InsertDeposit(ctx, repo.Deposit{
+		UserID:     user.ID,
+		DepositRef: newRef,
+		Method:     method,
+		Amount:     depositAmount,
+		Status:     depStatus,
+		Metadata:   metadata,
+	}); err != nil {
+		e.logger.Warn("failed storing regenerated deposit", "error", err, "deposit_ref", newRef)
+	}
+	if oldDep != nil {
+		replacedMeta := cloneMeta(oldDep.Metadata)
+		replacedMeta["replaced_by"] = newRef
+		if err := e.repo.UpdateDepositStatus(ctx, oldRef, "cancelled", replacedMeta); err != nil {
+			e.logger.Warn("failed cancelling replaced deposit", "error", err, "deposit_ref", oldRef)
+		}
+	}
+
+	orderMeta := cloneMeta(order.Metadata)
+	orderMeta["deposit_ref"] = newRef
+	orderMeta["previous_deposit_refs"] = append(previousDepositRefs(order.Metadata), oldRef)
+	delete(orderMeta, "deposit_failure_message")
+	delete(orderMeta, "auto_fulfilled")
+	if err := e.repo.UpdateOrderStatus(ctx, order.OrderRef, "awaiting_payment", orderMeta); err != nil {
+		e.logger.Error("failed linking regenerated deposit to order", "error", err, "order_ref", order.OrderRef, "deposit_ref", newRef)
+	}
+
+	locale := e.currencyLocale(user)
+	annotations := annotationsFromMetadata(order.Metadata)
+	summaryLine := summarizeDepositAmounts(user, grossAmount, feeAmount, netAmount, locale)
+	if depositType == "bank" {
+		reply := localize(user, msgRepayReadyBank, oldRef, formatCurrency(locale, money.FromRupiah(grossAmount)), productName, order.ProductCode, newRef, order.OrderRef, annotations.replySuffix(), formatBankTransferInfo(user, depResp.Checkout, locale, user.Location()))
+		if summaryLine != "" {
+			reply = fmt.Sprintf("%s\n%s", reply, summaryLine)
+		}
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "regenerate_deposit")
+	}
+
+	qrCaption := localize(user, msgRepayQRCaption, newRef, strings.ToUpper(method), productName, order.ProductCode)
+	if summaryLine != "" {
+		qrCaption = fmt.Sprintf("%s\n%s", qrCaption, summaryLine)
+	}
+	qrSent := e.sendCheckoutQRImage(ctx, evt.Info.Sender, user.ID, depResp.Checkout, qrCaption, "regenerate_deposit")
+	reply := localize(user, msgRepayReady, oldRef, strings.ToUpper(method), formatCurrency(locale, money.FromRupiah(grossAmount)), productName, order.ProductCode, newRef, order.OrderRef, annotations.replySuffix(), formatCheckoutInfo(user, depResp.Checkout, qrSent, locale, user.Location()))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "regenerate_deposit")
+}
+
+// previousDepositRefs returns the deposits an order was linked to before
+// its current one, oldest first.
+func previousDepositRefs(meta map[string]any) []string {
+	var refs []string
+	switch v := meta["previous_deposit_refs"].(type) {
+	case []string:
+		refs = append(refs, v...)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				refs = append(refs, s)
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,58 @@
+package convo
+
+import (
+	"testing"
+	"time"
+
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+)
+
+func TestRepayable(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		order repo.Order
+		want  bool
+	}{
+		{"awaiting payment", repo.Order{Status: "awaiting_payment", CreatedAt: now.Add(-time.Hour), Metadata: map[string]any{"deposit_ref": "dep-1"}}, true},
+		{"deposit expired", repo.Order{Status: "failed", CreatedAt: now.Add(-time.Hour), Metadata: map[string]any{"deposit_ref": "dep-1", "auto_fulfilled": false}}, true},
+		{"fulfilment failed", repo.Order{Status: "failed", CreatedAt: now.Add(-time.Hour), Metadata: map[string]any{"deposit_ref": "dep-1", "auto_fulfill_error": "boom"}}, false},
+		{"paid from balance", repo.Order{Status: "failed", CreatedAt: now.Add(-time.Hour), Metadata: map[string]any{}}, false},
+		{"success", repo.Order{Status: "success", CreatedAt: now.Add(-time.Hour), Metadata: map[string]any{"deposit_ref": "dep-1"}}, false},
+		{"quote too old", repo.Order{Status: "awaiting_payment", CreatedAt: now.Add(-2 * repayWindow), Metadata: map[string]any{"deposit_ref": "dep-1"}}, false},
+	}
+	for _, tt := range tests {
+		if got := repayable(tt.order, now); got != tt.want {
+			t.Errorf("%s: repayable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichIntentDetectsRepayRequest(t *testing.T) {
+	e := &Engine{}
+	for text, ref := range map[string]string{
+		"bayar ulang dong":                    "",
+		"QRIS baru buat trx-0123456789abcdef": "trx-0123456789abcdef",
+	} {
+		intent := &nlu.IntentResult{}
+		e.enrichIntentFromText(text, intent)
+		if intent.Intent != "regenerate_deposit" {
+			t.Fatalf("%q: intent = %q, want regenerate_deposit", text, intent.Intent)
+		}
+		if intent.Entities["ref_id"] != ref {
+			t.Fatalf("%q: ref_id = %q, want %q", text, intent.Entities["ref_id"], ref)
+		}
+	}
+}
+
+func TestPreviousDepositRefs(t *testing.T) {
+	meta := map[string]any{"previous_deposit_refs": []any{"dep-1", "dep-2"}}
+	got := append(previousDepositRefs(meta), "dep-3")
+	if len(got) != 3 || got[0] != "dep-1" || got[2] != "dep-3" {
+		t.Fatalf("previousDepositRefs = %v", got)
+	}
+	if refs := previousDepositRefs(nil); len(refs) != 0 {
+		t.Fatalf("previousDepositRefs(nil) = %v", refs)
+	}
+}
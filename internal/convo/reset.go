@@ -0,0 +1,51 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+
+	"bot-jual/internal/repo"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// contextResetType marks the point in a user's message log before which
+// history is no longer fed into prompts or summaries.
+const contextResetType = "context_reset"
+
+// handleResetContext forgets the user's conversation so far: recent history,
+// the summarised memory and any unfinished flow. Orders and balances are kept.
+func (e *Engine) handleResetContext(ctx context.Context, evt *events.Message, user *repo.User) error {
+	if err := e.resetConversation(ctx, user.ID); err != nil {
+		return err
+	}
+	e.logger.Info("conversation context reset", "user_id", user.ID)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgResetContext), "reset_context")
+}
+
+func (e *Engine) resetConversation(ctx context.Context, userID string) error {
+	if err := e.repo.InsertMessage(ctx, repo.MessageRecord{
+		UserID:    userID,
+		Direction: "outgoing",
+		Type:      contextResetType,
+	}); err != nil {
+		return fmt.Errorf("mark context reset: %w", err)
+	}
+	if err := e.repo.ResetUserMemory(ctx, userID); err != nil {
+		return fmt.Errorf("reset user memory: %w", err)
+	}
+	if err := e.clearSession(ctx, userID); err != nil {
+		return fmt.Errorf("clear session: %w", err)
+	}
+	return nil
+}
+
+// sinceContextReset trims newest-first history to the messages after the
+// user's last reset.
+func sinceContextReset(messages []repo.MessageRecord) []repo.MessageRecord {
+	for i, msg := range messages {
+		if msg.Type == contextResetType {
+			return messages[:i]
+		}
+	}
+	return messages
+}
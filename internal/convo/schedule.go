@@ -0,0 +1,376 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// scheduledOrderCheckInterval is how often due schedules are looked for.
+	scheduledOrderCheckInterval = time.Minute
+	scheduledOrderBatch         = 50
+	// scheduledOrderHour is the local hour schedules run at, so top-ups land
+	// in the morning rather than at midnight.
+	scheduledOrderHour = 8
+	// maxScheduledOrders caps how many active schedules a user may keep.
+	maxScheduledOrders = 10
+)
+
+var (
+	scheduleMonthlyPattern = regexp.MustCompile(`(?i)\b(?:tiap|setiap)\s+(?:tgl|tanggal)\s*(\d{1,2})\b|\bevery\s+(?:month\s+on\s+the\s+)?(\d{1,2})(?:st|nd|rd|th)\b`)
+	// "tiap minggu" means every week, so Sunday needs "hari" in front.
+	scheduleWeeklyPattern = regexp.MustCompile(`(?i)\b(?:(?:tiap|setiap)\s+(?:hari\s+(senin|selasa|rabu|kamis|jum'?at|sabtu|minggu)|(senin|selasa|rabu|kamis|jum'?at|sabtu))|every\s+(sunday|monday|tuesday|wednesday|thursday|friday|saturday))\b`)
+	scheduleDailyPattern  = regexp.MustCompile(`(?i)\b(?:(?:tiap|setiap)\s+hari|every\s+day|daily)\b`)
+)
+
+// scheduleWeekdays maps weekday names to time.Weekday.
+var scheduleWeekdays = map[string]time.Weekday{
+	"minggu": time.Sunday, "senin": time.Monday, "selasa": time.Tuesday, "rabu": time.Wednesday,
+	"kamis": time.Thursday, "jumat": time.Friday, "jum'at": time.Friday, "sabtu": time.Saturday,
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var weekdayNames = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// parseSchedule reads how often a purchase repeats: "tiap tanggal 1",
+// "tiap Senin" or "tiap hari". Day is the weekday for weekly schedules and
+// the day of the month for monthly ones.
+func parseSchedule(text string) (frequency string, day int, ok bool) {
+	if m := scheduleMonthlyPattern.FindStringSubmatch(text); m != nil {
+		raw := m[1]
+		if raw == "" {
+			raw = m[2]
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 31 {
+			return "", 0, false
+		}
+		return repo.ScheduleMonthly, n, true
+	}
+	if m := scheduleWeeklyPattern.FindStringSubmatch(text); m != nil {
+		name := strings.ToLower(m[1] + m[2] + m[3])
+		return repo.ScheduleWeekly, int(scheduleWeekdays[name]), true
+	}
+	if scheduleDailyPattern.MatchString(text) {
+		return repo.ScheduleDaily, 0, true
+	}
+	return "", 0, false
+}
+
+// describeSchedule renders a schedule for the user, e.g. "tiap tanggal 1".
+func describeSchedule(frequency string, day int) string {
+	switch frequency {
+	case repo.ScheduleDaily:
+		return "tiap hari"
+	case repo.ScheduleWeekly:
+		if day >= 0 && day < len(weekdayNames) {
+			return "tiap " + weekdayNames[day]
+		}
+	case repo.ScheduleMonthly:
+		return fmt.Sprintf("tiap tanggal %d", day)
+	}
+	return frequency
+}
+
+// nextScheduledRun is the first run of the schedule strictly after after, at
+// scheduledOrderHour in loc. Monthly schedules on a day the month lacks run
+// on its last day.
+func nextScheduledRun(frequency string, day int, after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, scheduledOrderHour, 0, 0, 0, loc)
+	}
+	switch frequency {
+	case repo.ScheduleWeekly:
+		candidate := at(local.Year(), local.Month(), local.Day())
+		offset := (day - int(candidate.Weekday()) + 7) % 7
+		candidate = candidate.AddDate(0, 0, offset)
+		if !candidate.After(after) {
+			candidate = candidate.AddDate(0, 0, 7)
+		}
+		return candidate
+	case repo.ScheduleMonthly:
+		for i := 0; ; i++ {
+			first := time.Date(local.Year(), local.Month()+time.Month(i), 1, 0, 0, 0, 0, loc)
+			d := min(day, daysIn(first.Year(), first.Month(), loc))
+			if candidate := at(first.Year(), first.Month(), d); candidate.After(after) {
+				return candidate
+			}
+		}
+	default:
+		candidate := at(local.Year(), local.Month(), local.Day())
+		if !candidate.After(after) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		return candidate
+	}
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// hasSchedule reports whether a purchase message asks for it to repeat.
+func hasSchedule(text string) bool {
+	_, _, ok := parseSchedule(text)
+	return ok
+}
+
+// handleScheduleOrder sets up a recurring purchase paid from the balance.
+func (e *Engine) handleScheduleOrder(ctx context.Context, evt *events.Message, user *repo.User, text string, intent *nlu.IntentResult) error {
+	frequency, day, ok := parseSchedule(intent.Entities["schedule"])
+	if !ok {
+		frequency, day, ok = parseSchedule(text)
+	}
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Mau diulang kapan? Contoh: \"isi pulsa telkomsel 25k ke 081234567890 tiap tanggal 1\", \"tiap Senin\" atau \"tiap hari\".", "schedule_missing")
+	}
+	code := strings.ToUpper(strings.TrimSpace(intent.Entities["product_code"]))
+	query := strings.TrimSpace(intent.Entities["product_query"])
+	if code == "" && query == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Produk apa yang mau dibeli rutin? Sebutkan nama atau kodenya ya.", "schedule_missing")
+	}
+	item, productType, err := e.resolveProductFromQuery(ctx, code, "prabayar", query, intent.Entities["provider"])
+	if err != nil {
+		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "schedule_fetch")
+	}
+	if item == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Produknya belum ketemu. Cek dulu dengan /harga lalu sebutkan kodenya ya.", "schedule_product_not_found")
+	}
+
+	target := strings.TrimSpace(intent.Entities["customer_id"])
+	if refersToOwnNumber(target) || (target == "" && refersToOwnNumber(text)) {
+		target = ""
+		if !productRequiresZone(item) {
+			target = ownPhoneNumber(user, evt)
+		}
+	}
+	customerID, customerZone := normalizeCustomerTarget(target, intent.Entities["customer_zone"])
+	if customerID == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, fmt.Sprintf("Nomor/ID tujuan untuk %s (%s) berapa?", item.Name, item.Code), "schedule_missing")
+	}
+	if productRequiresZone(item) && customerZone == "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, fmt.Sprintf("%s (%s) butuh ID plus Server, formatkan seperti 12345678(1234) ya.", item.Name, item.Code), "schedule_missing")
+	}
+	if proceed, err := e.checkTrust(ctx, evt, user, item, productType); !proceed {
+		return err
+	}
+
+	existing, err := e.repo.ListScheduledOrders(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list scheduled orders: %w", err)
+	}
+	if len(existing) >= maxScheduledOrders {
+		reply := fmt.Sprintf("Kamu sudah punya %d langganan aktif, maksimalnya %d. Batalkan salah satu dulu lewat /langganan ya.", len(existing), maxScheduledOrders)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "schedule_limit")
+	}
+
+	loc := user.Location()
+	s, err := e.repo.InsertScheduledOrder(ctx, repo.ScheduledOrder{
+		UserID:       user.ID,
+		ChatJID:      evt.Info.Sender.ToNonAD().String(),
+		ProductCode:  item.Code,
+		ProductName:  item.Name,
+		ProductType:  productType,
+		CustomerID:   customerID,
+		CustomerZone: customerZone,
+		Frequency:    frequency,
+		Day:          day,
+		NextRunAt:    nextScheduledRun(frequency, day, time.Now(), loc),
+	})
+	if err != nil {
+		return fmt.Errorf("insert scheduled order: %w", err)
+	}
+	e.logger.Info("scheduled order created", "user_id", user.ID, "schedule_id", s.ID, "product_code", s.ProductCode, "frequency", frequency, "day", day)
+
+	locale := e.currencyLocale(user)
+	reply := fmt.Sprintf("Sip, langganan dibuat: %s (%s) ke %s %s.\nPertama jalan: %s.\n\nDibayar otomatis dari saldo dengan harga saat itu (sekarang %s). Kalau saldonya kurang, aku ingatkan dan langganannya jalan begitu saldo cukup. Lihat atau batalkan lewat /langganan.",
+		s.ProductName, s.ProductCode, scheduledTarget(*s), describeSchedule(frequency, day), s.NextRunAt.In(loc).Format(expiryLayout), formatCurrency(locale, item.Price))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "schedule_created")
+}
+
+func scheduledTarget(s repo.ScheduledOrder) string {
+	return cartItem{CustomerID: s.CustomerID, CustomerZone: s.CustomerZone}.target()
+}
+
+func (e *Engine) handleListSchedules(ctx context.Context, evt *events.Message, user *repo.User) error {
+	schedules, err := e.repo.ListScheduledOrders(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list scheduled orders: %w", err)
+	}
+	if len(schedules) == 0 {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Belum ada langganan. Buat dengan, contoh: \"isi pulsa telkomsel 25k ke 081234567890 tiap tanggal 1\".", "schedule_list")
+	}
+	loc := user.Location()
+	var sb strings.Builder
+	sb.WriteString("Langganan kamu:")
+	for i, s := range schedules {
+		sb.WriteString(fmt.Sprintf("\n%d. %s (%s) → %s, %s. Berikutnya %s", i+1, s.ProductName, s.ProductCode, scheduledTarget(s), describeSchedule(s.Frequency, s.Day), s.NextRunAt.In(loc).Format(expiryLayout)))
+	}
+	sb.WriteString("\n\nBatalkan dengan /langganan batal NOMOR.")
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, sb.String(), "schedule_list")
+}
+
+// handleCancelSchedule stops the numbered schedule from /langganan.
+func (e *Engine) handleCancelSchedule(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	schedules, err := e.repo.ListScheduledOrders(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list scheduled orders: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(intent.Entities["index"]), "#"))
+	if err != nil || n < 1 || n > len(schedules) {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Nomor langganan itu tidak ada. Cek dulu dengan /langganan ya.", "schedule_cancel_invalid")
+	}
+	s := schedules[n-1]
+	if err := e.repo.CancelScheduledOrder(ctx, user.ID, s.ID); err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("cancel scheduled order: %w", err)
+	}
+	reply := fmt.Sprintf("Langganan %s (%s) ke %s %s dibatalkan.", s.ProductName, s.ProductCode, scheduledTarget(s), describeSchedule(s.Frequency, s.Day))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "schedule_cancel")
+}
+
+// RunScheduledOrders executes due scheduled purchases until ctx is cancelled.
+func (e *Engine) RunScheduledOrders(ctx context.Context) {
+	ticker := time.NewTicker(scheduledOrderCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.runDueSchedules(ctx)
+		}
+	}
+}
+
+func (e *Engine) runDueSchedules(ctx context.Context) {
+	due, err := e.repo.ListDueScheduledOrders(ctx, time.Now(), scheduledOrderBatch)
+	if err != nil {
+		e.logger.Warn("failed listing due scheduled orders", "error", err)
+		return
+	}
+	for _, s := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := e.runSchedule(ctx, s); err != nil {
+			e.logger.Warn("scheduled order run failed", "error", err, "schedule_id", s.ID, "user_id", s.UserID)
+		}
+	}
+}
+
+// runSchedule buys a due schedule's product from the user's balance. A run
+// the balance cannot cover stays due, with one reminder, until the balance
+// allows it or the next run comes round, at which point it is skipped.
+// Supplier trouble and a busy purchase lock leave it due for the next tick.
+func (e *Engine) runSchedule(ctx context.Context, s repo.ScheduledOrder) error {
+	jid, err := types.ParseJID(s.ChatJID)
+	if err != nil {
+		return fmt.Errorf("parse chat jid: %w", err)
+	}
+	user, err := e.repo.GetUserByID(ctx, s.UserID)
+	if err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	now := time.Now()
+	loc := user.Location()
+	locale := e.currencyLocale(user)
+	if next := nextScheduledRun(s.Frequency, s.Day, s.NextRunAt, loc); !now.Before(next) {
+		if err := e.advanceSchedule(ctx, s, now, loc, ""); err != nil {
+			return err
+		}
+		e.logger.Info("scheduled order run skipped", "schedule_id", s.ID, "due_at", s.NextRunAt)
+		notice := fmt.Sprintf("Langganan %s (%s) ke %s untuk %s terlewat dan tidak kuproses. Langganannya tetap aktif untuk jadwal berikutnya.",
+			s.ProductName, s.ProductCode, scheduledTarget(s), s.NextRunAt.In(loc).Format(expiryLayout))
+		if s.RemindedAt != nil {
+			notice = fmt.Sprintf("Langganan %s (%s) ke %s untuk %s terlewat karena saldo belum cukup. Langganannya tetap aktif untuk jadwal berikutnya.",
+				s.ProductName, s.ProductCode, scheduledTarget(s), s.NextRunAt.In(loc).Format(expiryLayout))
+		}
+		return e.respondAndLog(ctx, jid, user.ID, notice, "schedule_skipped")
+	}
+
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return nil
+	}
+	defer release()
+	if e.purchasesHeld() {
+		return nil
+	}
+	// Refresh the catalog first so the run is charged the current price and
+	// the margin check does not ask an absent user to re-send the order.
+	if fresh, err := e.atl.PriceList(ctx, s.ProductType, true); err == nil {
+		e.storePriceCache(s.ProductType, fresh)
+	}
+	item, productType, err := e.resolveProductFromQuery(ctx, s.ProductCode, s.ProductType, "", "")
+	if err != nil {
+		return fmt.Errorf("resolve product: %w", err)
+	}
+	if item == nil {
+		if err := e.repo.CancelScheduledOrder(ctx, user.ID, s.ID); err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("cancel scheduled order: %w", err)
+		}
+		notice := fmt.Sprintf("Langganan %s (%s) ke %s kuhentikan karena produknya sudah tidak dijual. Buat langganan baru dengan produk lain ya.", s.ProductName, s.ProductCode, scheduledTarget(s))
+		return e.respondAndLog(ctx, jid, user.ID, notice, "schedule_cancelled")
+	}
+
+	ub, err := e.repo.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("check balance: %w", err)
+	}
+	if ub == nil || ub.SaldoConfirmed < item.Price.Rupiah() {
+		if s.RemindedAt != nil {
+			return nil
+		}
+		balance := int64(0)
+		if ub != nil {
+			balance = ub.SaldoConfirmed
+		}
+		if err := e.repo.MarkScheduledOrderReminded(ctx, s.ID, now); err != nil {
+			return fmt.Errorf("mark reminded: %w", err)
+		}
+		reminder := fmt.Sprintf("⏰ Waktunya langganan %s (%s) ke %s, tapi saldo kamu belum cukup.\n\n💰 Saldo: %s\n🏷️ Harga: %s\n\nDeposit dulu ya, misalnya \"deposit %d\". Begitu saldo cukup, langganannya langsung kuproses.",
+			s.ProductName, s.ProductCode, scheduledTarget(s), formatCurrency(locale, money.FromRupiah(balance)), formatCurrency(locale, item.Price), item.Price.Rupiah()-balance)
+		return e.respondAndLog(ctx, jid, user.ID, reminder, "schedule_reminder")
+	}
+
+	evt := &events.Message{}
+	evt.Info.Sender = jid
+	evt.Info.Chat = jid
+	offer, proceed, err := e.verifyMargin(ctx, evt, user, item, productType)
+	if !proceed {
+		return err
+	}
+	orderRef := generateRefID("trx")
+	// Claim the run before buying, so another instance cannot buy it too.
+	if err := e.advanceSchedule(ctx, s, now, loc, orderRef); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	e.logger.Info("running scheduled order", "schedule_id", s.ID, "user_id", user.ID, "product_code", offer.Code, "order_ref", orderRef)
+	note := orderAnnotations{Note: "langganan " + describeSchedule(s.Frequency, s.Day)}
+	return e.executePrepaidWithBalance(ctx, evt, user, offer.Code, s.CustomerID, s.CustomerZone, s.CustomerID, orderRef, offer, productType, note)
+}
+
+func (e *Engine) advanceSchedule(ctx context.Context, s repo.ScheduledOrder, now time.Time, loc *time.Location, orderRef string) error {
+	next := nextScheduledRun(s.Frequency, s.Day, now, loc)
+	if err := e.repo.AdvanceScheduledOrder(ctx, s.ID, s.NextRunAt, next, orderRef); err != nil {
+		return fmt.Errorf("advance scheduled order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package convo
+
+import (
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+func TestParseSchedule(t *testing.T) {
+	cases := []struct {
+		text      string
+		frequency string
+		day       int
+		ok        bool
+	}{
+		{"isi pulsa 25k ke 0812 tiap tanggal 1", repo.ScheduleMonthly, 1, true},
+		{"Setiap tgl 31 ya", repo.ScheduleMonthly, 31, true},
+		{"top up dana 50rb tiap Senin", repo.ScheduleWeekly, int(time.Monday), true},
+		{"tiap hari minggu", repo.ScheduleWeekly, int(time.Sunday), true},
+		{"every friday", repo.ScheduleWeekly, int(time.Friday), true},
+		{"paket data tiap hari", repo.ScheduleDaily, 0, true},
+		{"every month on the 15th", repo.ScheduleMonthly, 15, true},
+		{"tiap minggu", "", 0, false},
+		{"tiap tanggal 40", "", 0, false},
+		{"isi pulsa 25k ke 0812", "", 0, false},
+	}
+	for _, tc := range cases {
+		frequency, day, ok := parseSchedule(tc.text)
+		if ok != tc.ok || frequency != tc.frequency || day != tc.day {
+			t.Errorf("parseSchedule(%q) = %q, %d, %v; want %q, %d, %v", tc.text, frequency, day, ok, tc.frequency, tc.day, tc.ok)
+		}
+	}
+}
+
+func TestNextScheduledRun(t *testing.T) {
+	loc := repo.DefaultZone
+	at := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, loc) }
+	cases := []struct {
+		name      string
+		frequency string
+		day       int
+		after     time.Time
+		want      time.Time
+	}{
+		{"monthly later this month", repo.ScheduleMonthly, 20, at(2025, 1, 15, 12), at(2025, 1, 20, 8)},
+		{"monthly past this month", repo.ScheduleMonthly, 1, at(2025, 1, 15, 12), at(2025, 2, 1, 8)},
+		{"monthly same day before the hour", repo.ScheduleMonthly, 15, at(2025, 1, 15, 7), at(2025, 1, 15, 8)},
+		{"monthly short month", repo.ScheduleMonthly, 31, at(2025, 2, 1, 12), at(2025, 2, 28, 8)},
+		{"monthly from the due time", repo.ScheduleMonthly, 31, at(2025, 2, 28, 8), at(2025, 3, 31, 8)},
+		{"weekly", repo.ScheduleWeekly, int(time.Monday), at(2025, 1, 15, 12), at(2025, 1, 20, 8)},
+		{"weekly same day passed", repo.ScheduleWeekly, int(time.Wednesday), at(2025, 1, 15, 12), at(2025, 1, 22, 8)},
+		{"daily", repo.ScheduleDaily, 0, at(2025, 12, 31, 9), at(2026, 1, 1, 8)},
+	}
+	for _, tc := range cases {
+		if got := nextScheduledRun(tc.frequency, tc.day, tc.after, loc); !got.Equal(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDescribeSchedule(t *testing.T) {
+	if got := describeSchedule(repo.ScheduleMonthly, 1); got != "tiap tanggal 1" {
+		t.Fatalf("monthly = %q", got)
+	}
+	if got := describeSchedule(repo.ScheduleWeekly, int(time.Friday)); got != "tiap Jumat" {
+		t.Fatalf("weekly = %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package convo
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bot-jual/internal/nlu"
+)
+
+// selectionHint closes every numbered product list.
+const selectionHint = "\n\nBalas #nomor dan tujuan untuk beli, contoh: #1 081234567890"
+
+// selectionPattern matches "#17 0812..." style replies to a numbered list.
+var selectionPattern = regexp.MustCompile(`^#(\d{1,3})(?:\s+(.*))?$`)
+
+// rememberSelections stores the product codes behind a list's "#N" tokens.
+func (e *Engine) rememberSelections(ctx context.Context, userID string, codes []string) {
+	if len(codes) == 0 {
+		return
+	}
+	s := e.loadSession(ctx, userID)
+	s.advance(flowChooseProduct)
+	s.Selections = make(map[int]string, len(codes))
+	for i, code := range codes {
+		s.Selections[i+1] = code
+	}
+	e.saveSession(ctx, userID, s)
+}
+
+// parseSelection turns "#N target [metode]" into a purchase of the Nth
+// product of the last list sent to the user. ok is false when text is not a
+// selection; a token the session no longer knows yields "selection_expired".
+func (e *Engine) parseSelection(ctx context.Context, userID, text string) (*nlu.IntentResult, bool) {
+	match := selectionPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return nil, false
+	}
+	n, _ := strconv.Atoi(match[1])
+	code, ok := e.loadSession(ctx, userID).Selections[n]
+	if !ok {
+		return &nlu.IntentResult{Intent: "selection_expired", Entities: map[string]string{}}, true
+	}
+	intent, _ := parseCommand("/beli " + code + " " + match[2])
+	return intent, true
+}
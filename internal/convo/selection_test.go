@@ -0,0 +1,41 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func TestListSelection(t *testing.T) {
+	e := &Engine{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), sessions: map[string]sessionEntry{}}
+	ctx := context.Background()
+
+	reply, codes := formatPriceList([]atl.PriceListItem{
+		{Code: "TSEL25", Name: "Telkomsel 25K", Category: "Pulsa", Price: money.FromRupiah(25500), Status: "available"},
+		{Code: "TSEL50", Name: "Telkomsel 50K", Category: "Pulsa", Price: money.FromRupiah(50200), Status: "available"},
+	}, false, money.Indonesian)
+	if !strings.Contains(reply, "#2 Telkomsel 50K (TSEL50)") {
+		t.Fatalf("list is missing selection tokens:\n%s", reply)
+	}
+	e.rememberSelections(ctx, "u1", codes)
+
+	intent, ok := e.parseSelection(ctx, "u1", "#2 081234567890 qris")
+	if !ok || intent.Intent != "create_prepaid" {
+		t.Fatalf("parseSelection = %+v, %v", intent, ok)
+	}
+	if intent.Entities["product_code"] != "TSEL50" || intent.Entities["customer_id"] != "081234567890" || intent.Entities["payment_method"] != "qris" {
+		t.Fatalf("entities = %v", intent.Entities)
+	}
+
+	if intent, _ := e.parseSelection(ctx, "u2", "#2 081234567890"); intent.Intent != "selection_expired" {
+		t.Fatalf("another user's list resolved: %+v", intent)
+	}
+	if _, ok := e.parseSelection(ctx, "u1", "pulsa #2"); ok {
+		t.Fatalf("text that does not start with a token was treated as a selection")
+	}
+}
@@ -0,0 +1,147 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// senderPolicyTTL bounds how long admin edits take to reach message handling.
+const senderPolicyTTL = time.Minute
+
+// Reasons a sender is refused, used in logs and metrics.
+const (
+	senderBlockedBusiness   = "business"
+	senderBlockedNotContact = "not_contact"
+	senderBlockedNotAllowed = "not_allowed"
+	senderBlockedDenied     = "denied"
+)
+
+// ContactChecker reports whether a sender is saved in the bot's contacts.
+type ContactChecker interface {
+	IsContact(ctx context.Context, jid types.JID) (bool, error)
+}
+
+type senderPolicyCache struct {
+	policy  repo.SenderPolicy
+	expires time.Time
+}
+
+// SetContactChecker enables the contacts-only sender policy.
+func (e *Engine) SetContactChecker(contacts ContactChecker) {
+	e.contacts = contacts
+}
+
+func (e *Engine) senderPolicy(ctx context.Context) repo.SenderPolicy {
+	e.mu.RLock()
+	cached := e.senderPolicyCache
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.policy
+	}
+
+	policy, err := e.repo.GetSenderPolicy(ctx)
+	if err != nil {
+		e.logger.Warn("failed loading sender policy", "error", err)
+		return cached.policy
+	}
+
+	e.mu.Lock()
+	e.senderPolicyCache = senderPolicyCache{policy: *policy, expires: time.Now().Add(senderPolicyTTL)}
+	e.mu.Unlock()
+	return *policy
+}
+
+// blockedSender reports why the policy refuses the sender of evt, or "" when
+// the message may be processed. Admins are always served.
+func (e *Engine) blockedSender(ctx context.Context, evt *events.Message) string {
+	if e.isAdmin(evt.Info.MessageSource) {
+		return ""
+	}
+	policy := e.senderPolicy(ctx)
+	isContact := func() bool {
+		if e.contacts == nil {
+			return false
+		}
+		for _, jid := range []types.JID{evt.Info.Sender, evt.Info.SenderAlt} {
+			if jid.IsEmpty() {
+				continue
+			}
+			saved, err := e.contacts.IsContact(ctx, jid.ToNonAD())
+			if err != nil {
+				e.logger.Warn("failed checking sender contact", "error", err, "jid", jid.String())
+				continue
+			}
+			if saved {
+				return true
+			}
+		}
+		return false
+	}
+	return evaluateSenderPolicy(policy, senderPhone(evt.Info.MessageSource), evt.Info.VerifiedName != nil, isContact)
+}
+
+// evaluateSenderPolicy applies policy to a sender. phone is empty when only
+// the sender's LID is known, which never matches a listed number or country.
+func evaluateSenderPolicy(policy repo.SenderPolicy, phone string, business bool, isContact func() bool) string {
+	if policy.BlockBusiness && business {
+		return senderBlockedBusiness
+	}
+	if policy.ContactsOnly && !isContact() {
+		return senderBlockedNotContact
+	}
+	listed := phone != "" && senderListed(policy, phone)
+	switch policy.Mode {
+	case repo.SenderPolicyAllowlist:
+		if !listed {
+			return senderBlockedNotAllowed
+		}
+	case repo.SenderPolicyDenylist:
+		if listed {
+			return senderBlockedDenied
+		}
+	}
+	return ""
+}
+
+func senderListed(policy repo.SenderPolicy, phone string) bool {
+	for _, number := range policy.Numbers {
+		if number == phone {
+			return true
+		}
+	}
+	for _, code := range policy.CountryCodes {
+		if code != "" && strings.HasPrefix(phone, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// senderPhone returns the sender's phone number, taken from the alternate
+// address when WhatsApp addresses the sender by LID.
+func senderPhone(src types.MessageSource) string {
+	for _, jid := range []types.JID{src.Sender, src.SenderAlt} {
+		if jid.Server == types.DefaultUserServer {
+			return jid.User
+		}
+	}
+	return ""
+}
+
+// userPhone is senderPhone falling back to the number stored on the user, for
+// LID messages that arrive without the phone-number address.
+func userPhone(src types.MessageSource, user *repo.User) string {
+	if phone := senderPhone(src); phone != "" {
+		return phone
+	}
+	if user != nil && user.PhoneNumber != nil {
+		return strings.TrimPrefix(*user.PhoneNumber, "+")
+	}
+	return ""
+}
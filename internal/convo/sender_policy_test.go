@@ -0,0 +1,55 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestEvaluateSenderPolicy(t *testing.T) {
+	saved := func() bool { return true }
+	stranger := func() bool { return false }
+	allowID := repo.SenderPolicy{Mode: repo.SenderPolicyAllowlist, CountryCodes: []string{"62"}, Numbers: []string{"60123456789"}}
+	deny := repo.SenderPolicy{Mode: repo.SenderPolicyDenylist, Numbers: []string{"6281111"}, CountryCodes: []string{"1"}}
+
+	tests := []struct {
+		name     string
+		policy   repo.SenderPolicy
+		phone    string
+		business bool
+		contact  func() bool
+		want     string
+	}{
+		{"off serves everyone", repo.SenderPolicy{Mode: repo.SenderPolicyOff}, "15550001", true, stranger, ""},
+		{"allowlist country", allowID, "628123", false, stranger, ""},
+		{"allowlist number", allowID, "60123456789", false, stranger, ""},
+		{"allowlist other country", allowID, "60199999", false, stranger, senderBlockedNotAllowed},
+		{"allowlist unknown phone", allowID, "", false, stranger, senderBlockedNotAllowed},
+		{"denylist number", deny, "6281111", false, stranger, senderBlockedDenied},
+		{"denylist country", deny, "15550001", false, stranger, senderBlockedDenied},
+		{"denylist others", deny, "6282222", false, stranger, ""},
+		{"block business", repo.SenderPolicy{Mode: repo.SenderPolicyOff, BlockBusiness: true}, "628123", true, saved, senderBlockedBusiness},
+		{"contacts only stranger", repo.SenderPolicy{Mode: repo.SenderPolicyOff, ContactsOnly: true}, "628123", false, stranger, senderBlockedNotContact},
+		{"contacts only saved", repo.SenderPolicy{Mode: repo.SenderPolicyOff, ContactsOnly: true}, "628123", false, saved, ""},
+	}
+	for _, tc := range tests {
+		if got := evaluateSenderPolicy(tc.policy, tc.phone, tc.business, tc.contact); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSenderPhonePrefersPhoneNumberJID(t *testing.T) {
+	src := types.MessageSource{
+		Sender:    types.NewJID("123456789", types.HiddenUserServer),
+		SenderAlt: types.NewJID("628123", types.DefaultUserServer),
+	}
+	if got := senderPhone(src); got != "628123" {
+		t.Fatalf("senderPhone = %q, want 628123", got)
+	}
+	if got := senderPhone(types.MessageSource{Sender: types.NewJID("123456789", types.HiddenUserServer)}); got != "" {
+		t.Fatalf("senderPhone for lid only = %q, want empty", got)
+	}
+}
@@ -0,0 +1,208 @@
+package convo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// sessionTTL is how long a user's session survives without new writes.
+	sessionTTL = 30 * time.Minute
+	// staleSessionGrace keeps an expired session stored a while longer, so
+	// a user coming back to an unfinished purchase is told it lapsed rather
+	// than finding it silently forgotten.
+	staleSessionGrace = 24 * time.Hour
+)
+
+// flowStep is where the user is in a multi-step purchase: choose a product
+// from a list, send the target, then confirm by picking a payment method.
+// Paying happens outside the session, on the order and its deposit.
+type flowStep string
+
+const (
+	flowIdle          flowStep = ""
+	flowChooseProduct flowStep = "choose_product"
+	flowEnterTarget   flowStep = "enter_target"
+	flowConfirm       flowStep = "confirm"
+)
+
+// flowTransitions lists the steps each step may move to; any step may go
+// back to idle. A product list sent while a draft is pending leaves the
+// user on the draft's step, so the draft is still what they are asked about.
+var flowTransitions = map[flowStep][]flowStep{
+	flowIdle:          {flowChooseProduct, flowEnterTarget, flowConfirm},
+	flowChooseProduct: {flowChooseProduct, flowEnterTarget, flowConfirm},
+	flowEnterTarget:   {flowEnterTarget, flowConfirm},
+	flowConfirm:       {flowEnterTarget, flowConfirm},
+}
+
+// purchasing reports whether the step belongs to an unfinished draft.
+func (s flowStep) purchasing() bool {
+	return s == flowEnterTarget || s == flowConfirm
+}
+
+// session holds short-lived per-user state between messages. It is keyed by
+// user ID only, so nothing in it can surface in another user's replies.
+type session struct {
+	// Step is where the user is in the purchase flow.
+	Step flowStep `json:"step,omitempty"`
+	// Selections maps the "#N" tokens of the last product list sent to product codes.
+	Selections map[int]string `json:"selections,omitempty"`
+	// Draft is the purchase the user is still assembling, if any.
+	Draft *draftOrder `json:"draft,omitempty"`
+	// Cart holds products the user will check out together.
+	Cart []cartItem `json:"cart,omitempty"`
+	// Transfer is the balance transfer the user is still assembling, if any.
+	Transfer *transferDraft `json:"transfer,omitempty"`
+	// ExpiresAt is when the session lapses, sessionTTL after the last write.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// advance moves the session to step when the flow allows it and reports
+// whether it did.
+func (s *session) advance(step flowStep) bool {
+	if step != flowIdle && !slices.Contains(flowTransitions[s.Step], step) {
+		return false
+	}
+	s.Step = step
+	return true
+}
+
+// expired reports whether the session lapsed at now. Sessions stored before
+// steps existed carry no expiry and rely on the store's own.
+func (s session) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+type sessionEntry struct {
+	session session
+	expires time.Time
+}
+
+func sessionKey(userID string) string {
+	return fmt.Sprintf("convo:session:%s", userID)
+}
+
+// loadSession returns the user's session, or an empty one when it expired.
+func (e *Engine) loadSession(ctx context.Context, userID string) session {
+	s, ok := e.loadStoredSession(ctx, userID)
+	if !ok || s.expired(time.Now()) {
+		return session{}
+	}
+	return s
+}
+
+// loadStoredSession reads the session as stored, including one that expired
+// but is still within staleSessionGrace. Sessions live in Redis; without
+// Redis, or while it is down, they go to the database so a restart keeps
+// them, and to process memory only when the database fails too.
+func (e *Engine) loadStoredSession(ctx context.Context, userID string) (session, bool) {
+	var s session
+	if e.cacheAvailable() {
+		ok, err := e.cache.GetJSON(ctx, sessionKey(userID), &s)
+		if err != nil {
+			e.logger.Warn("failed loading session", "error", err, "user_id", userID)
+		}
+		return s, ok
+	}
+	if e.repo != nil {
+		stored, err := e.repo.GetConversationState(ctx, userID)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(stored.State, &s); err != nil {
+				e.logger.Warn("failed decoding stored session", "error", err, "user_id", userID)
+				return session{}, false
+			}
+			return s, true
+		case errors.Is(err, repo.ErrNotFound):
+			return s, false
+		default:
+			e.logger.Warn("failed loading stored session", "error", err, "user_id", userID)
+		}
+	}
+	e.mu.RLock()
+	entry, ok := e.sessions[userID]
+	e.mu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.session, true
+	}
+	return s, false
+}
+
+func (e *Engine) saveSession(ctx context.Context, userID string, s session) {
+	s.ExpiresAt = time.Now().Add(sessionTTL)
+	if e.cacheAvailable() {
+		if err := e.cache.SetJSON(ctx, sessionKey(userID), s, sessionTTL+staleSessionGrace); err != nil {
+			e.logger.Warn("failed saving session", "error", err, "user_id", userID)
+		}
+		return
+	}
+	if e.repo != nil {
+		state, err := json.Marshal(s)
+		if err == nil {
+			err = e.repo.SaveConversationState(ctx, repo.ConversationState{
+				UserID:    userID,
+				State:     state,
+				ExpiresAt: s.ExpiresAt.Add(staleSessionGrace),
+			})
+		}
+		if err == nil {
+			return
+		}
+		e.logger.Warn("failed storing session", "error", err, "user_id", userID)
+	}
+	e.mu.Lock()
+	e.sessions[userID] = sessionEntry{session: s, expires: s.ExpiresAt.Add(staleSessionGrace)}
+	e.mu.Unlock()
+}
+
+func (e *Engine) clearSession(ctx context.Context, userID string) error {
+	e.mu.Lock()
+	delete(e.sessions, userID)
+	e.mu.Unlock()
+	if e.cacheAvailable() {
+		return e.cache.Delete(ctx, sessionKey(userID))
+	}
+	if e.repo != nil {
+		return e.repo.DeleteConversationState(ctx, userID)
+	}
+	return nil
+}
+
+// expireStaleFlow drops a session that lapsed before the user's message and,
+// when it held an unfinished purchase, transfer or cart, tells the user so they are
+// not left waiting on a confirmation the bot has forgotten.
+func (e *Engine) expireStaleFlow(ctx context.Context, evt *events.Message, user *repo.User) {
+	s, ok := e.loadStoredSession(ctx, user.ID)
+	if !ok || !s.expired(time.Now()) {
+		return
+	}
+	if err := e.clearSession(ctx, user.ID); err != nil {
+		e.logger.Warn("failed clearing expired session", "error", err, "user_id", user.ID)
+	}
+	var reply string
+	switch {
+	case s.Step.purchasing() && s.Draft != nil:
+		e.logger.Info("purchase flow expired", "user_id", user.ID, "step", s.Step, "product_code", s.Draft.ProductCode)
+		reply = localize(user, msgFlowExpired, s.Draft.ProductName, s.Draft.ProductCode)
+	case s.Transfer != nil:
+		e.logger.Info("transfer flow expired", "user_id", user.ID, "step", s.Transfer.Step)
+		reply = localize(user, msgTransferExpired)
+	case len(s.Cart) > 0:
+		e.logger.Info("cart expired", "user_id", user.ID, "items", len(s.Cart))
+		reply = localize(user, msgCartExpired, len(s.Cart))
+	default:
+		return
+	}
+	if err := e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "flow_expired"); err != nil {
+		e.logger.Warn("failed sending flow expiry notice", "error", err, "user_id", user.ID)
+	}
+}
@@ -0,0 +1,84 @@
+package convo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type stateRepo struct {
+	repo.Repository
+	states map[string]repo.ConversationState
+}
+
+func (r *stateRepo) SaveConversationState(_ context.Context, s repo.ConversationState) error {
+	r.states[s.UserID] = s
+	return nil
+}
+
+func (r *stateRepo) GetConversationState(_ context.Context, userID string) (*repo.ConversationState, error) {
+	s, ok := r.states[userID]
+	if !ok || !time.Now().Before(s.ExpiresAt) {
+		return nil, repo.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *stateRepo) DeleteConversationState(_ context.Context, userID string) error {
+	delete(r.states, userID)
+	return nil
+}
+
+func TestSessionFlowSteps(t *testing.T) {
+	var s session
+	if !s.advance(flowChooseProduct) || !s.advance(flowEnterTarget) || !s.advance(flowConfirm) {
+		t.Fatalf("purchase flow rejected a forward step, at %q", s.Step)
+	}
+	if s.advance(flowChooseProduct) || s.Step != flowConfirm {
+		t.Fatalf("a product list replaced the pending draft's step: %q", s.Step)
+	}
+	if !s.advance(flowIdle) || s.Step != flowIdle {
+		t.Fatalf("flow did not return to idle: %q", s.Step)
+	}
+}
+
+func TestSessionStoredInDatabaseWithoutRedis(t *testing.T) {
+	states := &stateRepo{states: map[string]repo.ConversationState{}}
+	e := &Engine{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), repo: states, sessions: map[string]sessionEntry{}}
+	ctx := context.Background()
+
+	e.storeDraft(ctx, "u1", &draftOrder{ProductCode: "ML86", ProductName: "MLBB 86 Diamonds"}, flowEnterTarget)
+	if len(e.sessions) != 0 {
+		t.Fatalf("session kept in memory while the database works")
+	}
+	stored, ok := states.states["u1"]
+	if !ok || stored.ExpiresAt.Before(time.Now().Add(sessionTTL+staleSessionGrace-time.Minute)) {
+		t.Fatalf("stored state = %+v, %v", stored, ok)
+	}
+
+	// A new engine, as after a restart, picks the flow up where it was.
+	restarted := &Engine{logger: e.logger, repo: states, sessions: map[string]sessionEntry{}}
+	if s := restarted.loadSession(ctx, "u1"); s.Step != flowEnterTarget || s.Draft == nil || s.Draft.ProductCode != "ML86" {
+		t.Fatalf("loaded session = %+v", s)
+	}
+
+	// Past its TTL the flow is gone for handlers but still known as lapsed.
+	s, _ := restarted.loadStoredSession(ctx, "u1")
+	s.ExpiresAt = time.Now().Add(-time.Minute)
+	state, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states.states["u1"] = repo.ConversationState{UserID: "u1", State: state, ExpiresAt: time.Now().Add(staleSessionGrace)}
+	if s := restarted.loadSession(ctx, "u1"); s.Draft != nil {
+		t.Fatalf("expired draft still loaded: %+v", s.Draft)
+	}
+	if s, ok := restarted.loadStoredSession(ctx, "u1"); !ok || !s.expired(time.Now()) || !s.Step.purchasing() {
+		t.Fatalf("lapsed session = %+v, %v", s, ok)
+	}
+}
@@ -0,0 +1,226 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	// staleDepositCheckInterval is how often unpaid deposits are looked for.
+	staleDepositCheckInterval = time.Minute
+	staleDepositBatch         = 50
+	// staleDepositMaxAge stops the job touching deposits from before it
+	// existed, which the provider may no longer know about.
+	staleDepositMaxAge = 7 * 24 * time.Hour
+	// StaleDepositEventType marks deposit updates found by the expiry check
+	// rather than delivered by a provider webhook.
+	StaleDepositEventType = "deposit_expiry_check"
+)
+
+// SetDepositSettler settles deposits the expiry check finds paid through
+// the same processor as payment webhooks, so waiting orders are fulfilled.
+func (e *Engine) SetDepositSettler(settler atl.WebhookProcessor) {
+	e.depositSettler = settler
+}
+
+// RunStaleDeposits reminds users of deposits they have not paid and cancels
+// the ones left unpaid past DepositExpireAfter until ctx is cancelled.
+func (e *Engine) RunStaleDeposits(ctx context.Context) {
+	if e.cfg.DepositReminderAfter <= 0 && e.cfg.DepositExpireAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(staleDepositCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.sweepStaleDeposits(ctx, time.Now())
+		}
+	}
+}
+
+func (e *Engine) sweepStaleDeposits(ctx context.Context, now time.Time) {
+	minAge := e.cfg.DepositExpireAfter
+	if remind := e.cfg.DepositReminderAfter; remind > 0 && (minAge <= 0 || remind < minAge) {
+		minAge = remind
+	}
+	deposits, err := e.repo.ListUnpaidDeposits(ctx, now.Add(-staleDepositMaxAge), now.Add(-minAge), staleDepositBatch)
+	if err != nil {
+		e.logger.Warn("failed listing unpaid deposits", "error", err)
+		return
+	}
+	for i := range deposits {
+		if ctx.Err() != nil {
+			return
+		}
+		dep := &deposits[i]
+		age := now.Sub(dep.CreatedAt)
+		var err error
+		switch {
+		case e.cfg.DepositExpireAfter > 0 && age >= e.cfg.DepositExpireAfter:
+			err = e.expireDeposit(ctx, dep)
+		case e.cfg.DepositReminderAfter > 0 && stringValue(dep.Metadata, "reminded_at") == "":
+			err = e.remindDeposit(ctx, dep, now)
+		}
+		if err != nil {
+			e.logger.Warn("stale deposit handling failed", "error", err, "deposit_ref", dep.DepositRef, "user_id", dep.UserID)
+		}
+	}
+}
+
+// depositUserJID loads the deposit's owner and where to message them.
+func (e *Engine) depositUserJID(ctx context.Context, dep *repo.Deposit) (*repo.User, types.JID, error) {
+	user, err := e.repo.GetUserByID(ctx, dep.UserID)
+	if err != nil {
+		return nil, types.JID{}, fmt.Errorf("load user: %w", err)
+	}
+	if user.WAJID == nil || *user.WAJID == "" {
+		return user, types.JID{}, errors.New("user has no whatsapp jid")
+	}
+	jid, err := types.ParseJID(*user.WAJID)
+	if err != nil {
+		return user, types.JID{}, fmt.Errorf("parse user jid: %w", err)
+	}
+	return user, jid, nil
+}
+
+// depositGross is what the user was asked to pay for dep.
+func depositGross(dep *repo.Deposit) int64 {
+	if gross := parseAmountString(stringValue(dep.Metadata, "gross_amount")); gross > 0 {
+		return gross
+	}
+	return dep.Amount
+}
+
+// remindDeposit resends the payment details of a deposit still unpaid. The
+// reminder is recorded on the deposit first so it goes out only once.
+func (e *Engine) remindDeposit(ctx context.Context, dep *repo.Deposit, now time.Time) error {
+	user, jid, err := e.depositUserJID(ctx, dep)
+	if err != nil {
+		return err
+	}
+	meta := cloneMeta(dep.Metadata)
+	meta["reminded_at"] = now.UTC().Format(time.RFC3339)
+	if err := e.repo.UpdateDepositStatus(ctx, dep.DepositRef, dep.Status, meta); err != nil {
+		return fmt.Errorf("mark deposit reminded: %w", err)
+	}
+
+	locale := e.currencyLocale(user)
+	loc := user.Location()
+	checkout, _ := dep.Metadata["checkout"].(map[string]any)
+	details := formatCheckoutInfo(user, checkout, false, locale, loc)
+	if strings.EqualFold(dep.Method, "bri") || e.cfg.DefaultDepositType == "bank" {
+		details = formatBankTransferInfo(user, checkout, locale, loc)
+	}
+	var sb strings.Builder
+	sb.WriteString(localize(user, msgDepositReminder,
+		dep.DepositRef, strings.ToUpper(dep.Method), formatCurrency(locale, money.FromRupiah(depositGross(dep))), details))
+	if e.cfg.DepositExpireAfter > 0 {
+		sb.WriteString("\n\n" + localize(user, msgDepositAutoCancel, dep.CreatedAt.Add(e.cfg.DepositExpireAfter).In(loc).Format(expiryLayout)))
+	}
+	e.logger.Info("reminding user of unpaid deposit", "deposit_ref", dep.DepositRef, "user_id", user.ID)
+	return e.respondAndLog(ctx, jid, user.ID, sb.String(), "deposit_reminder")
+}
+
+// expireDeposit cancels a deposit left unpaid at the provider, marks it
+// failed and releases the orders waiting on it so they can be paid again
+// with "bayar ulang". A deposit the provider reports paid is settled
+// instead; one it cannot be asked about is left for the next sweep.
+func (e *Engine) expireDeposit(ctx context.Context, dep *repo.Deposit) error {
+	meta := cloneMeta(dep.Metadata)
+	if providerID, provider := stringValue(dep.Metadata, "provider_id"), e.depositProvider(dep.Metadata); providerID != "" && provider != nil {
+		status, err := provider.DepositStatus(ctx, providerID)
+		if err != nil {
+			return fmt.Errorf("check deposit status: %w", err)
+		}
+		switch strings.ToLower(status.Status) {
+		case "success":
+			// Paid, but the webhook saying so never arrived.
+			e.logger.Info("unpaid deposit found paid at provider", "deposit_ref", dep.DepositRef, "user_id", dep.UserID)
+			return e.settleDeposit(ctx, dep, status, meta)
+		case "failed":
+			// The provider already closed it.
+		default:
+			if _, err := provider.CancelDeposit(ctx, providerID); err != nil {
+				return fmt.Errorf("cancel deposit: %w", err)
+			}
+		}
+	}
+
+	meta["expired"] = true
+	if err := e.repo.UpdateDepositStatus(ctx, dep.DepositRef, "failed", meta); err != nil {
+		return fmt.Errorf("mark deposit failed: %w", err)
+	}
+	e.logger.Info("unpaid deposit expired", "deposit_ref", dep.DepositRef, "user_id", dep.UserID)
+
+	orders, err := e.repo.ListOrdersAwaitingDeposit(ctx, dep.DepositRef)
+	if err != nil {
+		e.logger.Error("list orders awaiting deposit failed", "error", err, "deposit_ref", dep.DepositRef)
+	}
+	refs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderMeta := cloneMeta(order.Metadata)
+		orderMeta["deposit_ref"] = dep.DepositRef
+		orderMeta["deposit_failure_message"] = "deposit expired"
+		orderMeta["auto_fulfilled"] = false
+		if err := e.repo.UpdateOrderStatus(ctx, order.OrderRef, "failed", orderMeta); err != nil {
+			e.logger.Error("update order after deposit expiry", "error", err, "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
+			continue
+		}
+		refs = append(refs, order.OrderRef)
+	}
+
+	user, jid, err := e.depositUserJID(ctx, dep)
+	if err != nil {
+		return err
+	}
+	locale := e.currencyLocale(user)
+	notice := localize(user, msgDepositExpired, dep.DepositRef, formatCurrency(locale, money.FromRupiah(depositGross(dep))))
+	if len(refs) > 0 {
+		notice += "\n" + localize(user, msgDepositExpiredOrders, strings.Join(refs, ", "))
+	}
+	return e.respondAndLog(ctx, jid, user.ID, notice, "deposit_expired")
+}
+
+// settleDeposit applies a provider's success report as if its webhook had
+// arrived, crediting the deposit and fulfilling the orders paid with it.
+func (e *Engine) settleDeposit(ctx context.Context, dep *repo.Deposit, status *atl.DepositStatusResponse, meta map[string]any) error {
+	if e.depositSettler == nil {
+		e.logger.Warn("no deposit settler, marking paid deposit without fulfilment", "deposit_ref", dep.DepositRef)
+		delete(meta, "forced_success")
+		delete(meta, "original_status")
+		meta["confirmed_by"] = "stale_deposit_check"
+		return e.repo.UpdateDepositStatus(ctx, dep.DepositRef, "success", meta)
+	}
+	event := atl.WebhookEvent{
+		Type:       StaleDepositEventType,
+		ReceivedAt: time.Now(),
+		Deposit: &atl.DepositUpdate{
+			StatusUpdate: atl.StatusUpdate{
+				Ref:       dep.DepositRef,
+				RawStatus: status.Status,
+				Status:    "success",
+				Amount:    status.Amount.Rupiah(),
+				Payload:   status.Raw,
+			},
+			Method:    status.Method,
+			Fee:       status.Fee.Rupiah(),
+			NetAmount: status.NetAmount.Rupiah(),
+		},
+	}
+	if err := e.depositSettler.HandleAtlanticEvent(ctx, event); err != nil {
+		return fmt.Errorf("settle paid deposit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type depositRepo struct {
+	repo.Repository
+	deposits      []repo.Deposit
+	after, before time.Time
+	statuses      map[string]string
+	meta          map[string]map[string]any
+	orders        map[string][]repo.Order
+	orderStatuses map[string]string
+}
+
+func (r *depositRepo) ListUnpaidDeposits(_ context.Context, createdAfter, createdBefore time.Time, _ int) ([]repo.Deposit, error) {
+	r.after, r.before = createdAfter, createdBefore
+	return r.deposits, nil
+}
+
+func (r *depositRepo) UpdateDepositStatus(_ context.Context, ref, status string, metadata map[string]any) error {
+	r.statuses[ref] = status
+	r.meta[ref] = metadata
+	return nil
+}
+
+func (r *depositRepo) ListOrdersAwaitingDeposit(_ context.Context, depositRef string) ([]repo.Order, error) {
+	return r.orders[depositRef], nil
+}
+
+func (r *depositRepo) UpdateOrderStatus(_ context.Context, orderRef, status string, _ map[string]any) error {
+	r.orderStatuses[orderRef] = status
+	return nil
+}
+
+func (r *depositRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "6281234567890@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+func (r *depositRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+type textGateway struct {
+	sent []string
+}
+
+func (g *textGateway) SendText(_ context.Context, _ types.JID, text string) error {
+	g.sent = append(g.sent, text)
+	return nil
+}
+
+func (g *textGateway) SendImage(context.Context, types.JID, []byte, string, string) error {
+	return nil
+}
+
+func (g *textGateway) DownloadMedia(context.Context, *waProto.Message) ([]byte, string, error) {
+	return nil, "", nil
+}
+
+func TestSweepStaleDepositsRemindsThenExpires(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := &depositRepo{
+		deposits: []repo.Deposit{
+			{UserID: "u1", DepositRef: "dep-new", Method: "qris", Amount: 10000, Status: "success", CreatedAt: now.Add(-20 * time.Minute),
+				Metadata: map[string]any{"forced_success": true, "checkout": map[string]any{"qr_string": "000201"}}},
+			{UserID: "u1", DepositRef: "dep-reminded", Method: "qris", Amount: 10000, Status: "success", CreatedAt: now.Add(-30 * time.Minute),
+				Metadata: map[string]any{"forced_success": true, "reminded_at": "2024-05-01T11:45:00Z"}},
+			{UserID: "u1", DepositRef: "dep-old", Method: "qris", Amount: 20000, Status: "success", CreatedAt: now.Add(-2 * time.Hour),
+				Metadata: map[string]any{"forced_success": true, "gross_amount": float64(20700)}},
+		},
+		statuses:      map[string]string{},
+		meta:          map[string]map[string]any{},
+		orders:        map[string][]repo.Order{"dep-old": {{OrderRef: "trx-1", Status: "awaiting_payment"}}},
+		orderStatuses: map[string]string{},
+	}
+	gw := &textGateway{}
+	e := &Engine{
+		repo:    r,
+		gateway: gw,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cfg:     EngineConfig{DepositReminderAfter: 15 * time.Minute, DepositExpireAfter: time.Hour},
+	}
+
+	e.sweepStaleDeposits(context.Background(), now)
+
+	if !r.before.Equal(now.Add(-15*time.Minute)) || !r.after.Equal(now.Add(-staleDepositMaxAge)) {
+		t.Fatalf("window = %s..%s", r.after, r.before)
+	}
+	if r.statuses["dep-new"] != "success" || r.meta["dep-new"]["reminded_at"] == nil {
+		t.Fatalf("reminder not recorded: %q %v", r.statuses["dep-new"], r.meta["dep-new"])
+	}
+	if _, touched := r.statuses["dep-reminded"]; touched {
+		t.Fatalf("deposit reminded twice")
+	}
+	if r.statuses["dep-old"] != "failed" || r.orderStatuses["trx-1"] != "failed" {
+		t.Fatalf("expired deposit = %q, order = %q", r.statuses["dep-old"], r.orderStatuses["trx-1"])
+	}
+	if len(gw.sent) != 2 {
+		t.Fatalf("sent %d messages, want 2: %q", len(gw.sent), gw.sent)
+	}
+	if !strings.Contains(gw.sent[0], "dep-new") || !strings.Contains(gw.sent[0], "000201") {
+		t.Fatalf("reminder lacks payment details: %q", gw.sent[0])
+	}
+	if !strings.Contains(gw.sent[1], "dep-old") || !strings.Contains(gw.sent[1], "20.700") || !strings.Contains(gw.sent[1], "trx-1") {
+		t.Fatalf("expiry notice = %q", gw.sent[1])
+	}
+}
+
+type paidProvider struct {
+	PaymentProvider
+}
+
+func (paidProvider) DepositStatus(_ context.Context, id string) (*atl.DepositStatusResponse, error) {
+	return &atl.DepositStatusResponse{ID: id, Status: "success", Method: "qris", Amount: money.FromRupiah(20700), NetAmount: money.FromRupiah(20000)}, nil
+}
+
+type recordingSettler struct {
+	events []atl.WebhookEvent
+}
+
+func (s *recordingSettler) HandleAtlanticEvent(_ context.Context, event atl.WebhookEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestExpireDepositSettlesPaidDeposit(t *testing.T) {
+	r := &depositRepo{statuses: map[string]string{}, meta: map[string]map[string]any{}, orderStatuses: map[string]string{}}
+	settler := &recordingSettler{}
+	e := &Engine{repo: r, gateway: &textGateway{}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	e.SetPaymentProvider("xendit", paidProvider{})
+	e.SetDepositSettler(settler)
+	dep := &repo.Deposit{UserID: "u1", DepositRef: "dep-paid", Method: "qris", Amount: 20000, Status: "success",
+		Metadata: map[string]any{"forced_success": true, "provider_id": "inv-1", "payment_provider": "xendit"}}
+
+	if err := e.expireDeposit(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+	// Settlement, including fulfilling waiting orders, is the settler's job;
+	// nothing is marked here.
+	if len(r.statuses) != 0 || len(r.orderStatuses) != 0 {
+		t.Fatalf("deposit updated outside the settler: %v %v", r.statuses, r.orderStatuses)
+	}
+	if len(settler.events) != 1 {
+		t.Fatalf("settler got %d events, want 1", len(settler.events))
+	}
+	got := settler.events[0].Deposit
+	if got == nil || got.Ref != "dep-paid" || got.Status != "success" || got.NetAmount != 20000 {
+		t.Fatalf("settled %+v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package convo
+
+import (
+	"bot-jual/internal/atl"
+	"bot-jual/internal/supplier"
+)
+
+// SetSupplierRouter places prepaid purchases through router, so they go to
+// the cheapest supplier that is up instead of always to Atlantic.
+func (e *Engine) SetSupplierRouter(router *supplier.Router) {
+	e.suppliers = router
+}
+
+// fulfiller is where prepaid purchases are placed and checked: the supplier
+// router when set, Atlantic otherwise.
+func (e *Engine) fulfiller() supplier.Fulfiller {
+	if e.suppliers != nil {
+		return e.suppliers
+	}
+	return e.atl
+}
+
+// purchasesHeld reports whether prepaid purchases must wait: Atlantic is
+// down and no other supplier can take them.
+func (e *Engine) purchasesHeld() bool {
+	if !e.atlanticDown() {
+		return false
+	}
+	return e.suppliers == nil || !e.suppliers.HasFallback(supplier.Atlantic)
+}
+
+// recordSupplier notes on order metadata which supplier took a purchase so
+// its status is later asked of the same one.
+func recordSupplier(meta map[string]any, resp *atl.TransactionResponse) {
+	if resp != nil && resp.Supplier != "" {
+		meta["supplier"] = resp.Supplier
+	}
+}
@@ -0,0 +1,41 @@
+package convo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+// expiryLayout renders payment deadlines, e.g. "15 Jan 2025 11:30 WITA".
+const expiryLayout = "02 Jan 2006 15:04 MST"
+
+// formatExpiry renders an Atlantic expiry time in the user's zone. Atlantic
+// sends "2006-01-02 15:04:05" in WIB or RFC 3339; anything else is shown
+// as sent.
+func formatExpiry(raw string, loc *time.Location) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	t, err := time.ParseInLocation(time.DateTime, raw, repo.DefaultZone)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, raw); err != nil {
+			return raw
+		}
+	}
+	return t.In(loc).Format(expiryLayout)
+}
+
+// userZone is User.Location for notifications that only carry the user's ID.
+func (e *Engine) userZone(ctx context.Context, userID string) *time.Location {
+	if userID == "" {
+		return repo.DefaultZone
+	}
+	user, err := e.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return repo.DefaultZone
+	}
+	return user.Location()
+}
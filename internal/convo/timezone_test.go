@@ -0,0 +1,27 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+)
+
+func TestFormatExpiry(t *testing.T) {
+	tests := []struct {
+		raw  string
+		user *repo.User
+		want string
+	}{
+		{"2025-01-15 10:30:00", nil, "15 Jan 2025 10:30 WIB"},
+		{"2025-01-15 10:30:00", &repo.User{Timezone: "Asia/Makassar"}, "15 Jan 2025 11:30 WITA"},
+		{"2025-01-15T03:30:00Z", &repo.User{Timezone: "Asia/Jayapura"}, "15 Jan 2025 12:30 WIT"},
+		{"2025-01-15 10:30:00", &repo.User{Timezone: "Mars/Olympus"}, "15 Jan 2025 10:30 WIB"},
+		{"besok siang", nil, "besok siang"},
+		{"", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := formatExpiry(tt.raw, tt.user.Location()); got != tt.want {
+			t.Errorf("formatExpiry(%q, %v) = %q, want %q", tt.raw, tt.user.Location(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,602 @@
+package convo
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// withdrawalKindTransfer marks withdrawals the user sent to another
+	// account through the transfer flow rather than cashing out.
+	withdrawalKindTransfer = "transfer"
+	// pinLength is how many digits a transaction PIN has.
+	pinLength = 6
+	// maxPINAttempts wrong PINs in a row, across transfers, cancel the
+	// transfer being confirmed and lock transfers for pinLockout.
+	maxPINAttempts = 3
+	pinLockout     = 30 * time.Minute
+	pinIterations  = 100_000
+	// transferBankListTTL is how long the supported banks are reused.
+	transferBankListTTL = time.Hour
+)
+
+// transferStep is where the user is in a guided transfer: choose the bank or
+// e-wallet, send the account number, have the owner's name verified, send
+// the amount, confirm it and authorise the transfer with their PIN. Users
+// without a PIN choose one and repeat it instead of entering it.
+type transferStep string
+
+const (
+	transferChooseBank   transferStep = "choose_bank"
+	transferEnterAccount transferStep = "enter_account"
+	transferEnterAmount  transferStep = "enter_amount"
+	transferConfirm      transferStep = "confirm"
+	transferEnterPIN     transferStep = "enter_pin"
+	transferNewPIN       transferStep = "new_pin"
+	transferRepeatPIN    transferStep = "repeat_pin"
+)
+
+// transferDraft is the transfer the user is still assembling. It lives in
+// the session next to any purchase draft.
+type transferDraft struct {
+	Step         transferStep   `json:"step"`
+	BankCode     string         `json:"bank_code,omitempty"`
+	BankName     string         `json:"bank_name,omitempty"`
+	AccountNo    string         `json:"account_no,omitempty"`
+	AccountName  string         `json:"account_name,omitempty"`
+	AccountCheck map[string]any `json:"account_check,omitempty"`
+	Amount       int64          `json:"amount,omitempty"`
+	// NewPINHash holds the PIN chosen at transferNewPIN until it is repeated.
+	NewPINHash string `json:"new_pin_hash,omitempty"`
+	// Withdraw marks a "tarik saldo" cash-out. It goes through the same
+	// confirmation and PIN steps but is recorded as a plain withdrawal.
+	Withdraw bool `json:"withdraw,omitempty"`
+}
+
+// verb names the payout in replies.
+func (t *transferDraft) verb() string {
+	if t.Withdraw {
+		return "tarik saldo"
+	}
+	return "transfer"
+}
+
+// destination names the receiving account, e.g. "DANA 0812345 a.n BUDI".
+func (t *transferDraft) destination() string {
+	name := t.BankName
+	if name == "" {
+		name = strings.ToUpper(t.BankCode)
+	}
+	dest := name + " " + t.AccountNo
+	if t.AccountName != "" {
+		dest += " a.n " + t.AccountName
+	}
+	return dest
+}
+
+type transferBankCache struct {
+	banks   []atl.TransferBank
+	expires time.Time
+}
+
+// transferBanks returns the banks and e-wallets Atlantic transfers to.
+func (e *Engine) transferBanks(ctx context.Context) ([]atl.TransferBank, error) {
+	e.mu.RLock()
+	cached := e.bankList
+	e.mu.RUnlock()
+	if time.Now().Before(cached.expires) {
+		return cached.banks, nil
+	}
+	banks, err := e.atl.TransferBankList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.bankList = transferBankCache{banks: banks, expires: time.Now().Add(transferBankListTTL)}
+	e.mu.Unlock()
+	return banks, nil
+}
+
+// findTransferBank matches a bank code or name, or a unique part of a name.
+func findTransferBank(banks []atl.TransferBank, query string) (atl.TransferBank, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return atl.TransferBank{}, false
+	}
+	for _, bank := range banks {
+		if strings.EqualFold(bank.Code, query) || strings.EqualFold(bank.Name, query) {
+			return bank, true
+		}
+	}
+	var match atl.TransferBank
+	found := 0
+	for _, bank := range banks {
+		if strings.Contains(strings.ToLower(bank.Name), query) {
+			match = bank
+			found++
+		}
+	}
+	return match, found == 1
+}
+
+// popularTransferBanks lists the well-known codes among banks, for the
+// prompt; the full list is too long for a chat message.
+func popularTransferBanks(banks []atl.TransferBank) string {
+	var codes []string
+	for _, code := range knownBankCodes {
+		if bank, ok := findTransferBank(banks, code); ok {
+			codes = append(codes, strings.ToUpper(bank.Code))
+		}
+	}
+	return strings.Join(codes, ", ")
+}
+
+func (e *Engine) loadTransfer(ctx context.Context, userID string) *transferDraft {
+	return e.loadSession(ctx, userID).Transfer
+}
+
+// storeTransfer saves the transfer draft in the user's session; nil ends the flow.
+func (e *Engine) storeTransfer(ctx context.Context, userID string, t *transferDraft) {
+	s := e.loadSession(ctx, userID)
+	if s.Transfer == nil && t == nil {
+		return
+	}
+	s.Transfer = t
+	e.saveSession(ctx, userID, s)
+}
+
+// awaitingPIN reports whether the user's next message is a PIN, so it is
+// kept out of the message log.
+func (e *Engine) awaitingPIN(ctx context.Context, userID string) bool {
+	t := e.loadTransfer(ctx, userID)
+	return t != nil && (t.Step == transferEnterPIN || t.Step == transferNewPIN || t.Step == transferRepeatPIN)
+}
+
+// redactPIN masks a message that answers a PIN prompt.
+func (e *Engine) redactPIN(ctx context.Context, userID, text string) string {
+	if len(text) != pinLength || !isDigits(text) || !e.awaitingPIN(ctx, userID) {
+		return text
+	}
+	return strings.Repeat("•", pinLength)
+}
+
+// parseTransferReply turns a reply that fits the pending transfer step, or
+// "batal", into a transfer_reply intent. Anything else goes through the
+// normal pipeline and leaves the transfer waiting.
+func (e *Engine) parseTransferReply(ctx context.Context, userID, text string) (*nlu.IntentResult, bool) {
+	reply := strings.TrimSpace(text)
+	if reply == "" || strings.HasPrefix(reply, "/") {
+		return nil, false
+	}
+	t := e.loadTransfer(ctx, userID)
+	if t == nil || !(isCancelReply(reply) || t.Step.accepts(reply)) {
+		return nil, false
+	}
+	return &nlu.IntentResult{Intent: "transfer_reply", Confidence: 1, Entities: map[string]string{"reply": reply}}, true
+}
+
+// accepts reports whether reply looks like an answer to the step.
+func (s transferStep) accepts(reply string) bool {
+	switch s {
+	case transferChooseBank:
+		return len(strings.Fields(reply)) <= 3 && !strings.Contains(reply, "?")
+	case transferEnterAccount:
+		return accountDigits(reply) != ""
+	case transferEnterAmount:
+		amount, err := parseExactAmount(reply)
+		return err == nil && amount > 0 && len(strings.Fields(reply)) <= 3
+	case transferConfirm:
+		return isConfirmReply(reply)
+	case transferEnterPIN, transferNewPIN, transferRepeatPIN:
+		return isDigits(reply)
+	}
+	return false
+}
+
+// accountDigits returns the reply as an account number, ignoring spaces and
+// dashes, or "" when it is not one.
+func accountDigits(reply string) string {
+	digits := strings.NewReplacer(" ", "", "-", "", ".", "").Replace(reply)
+	if len(digits) < 6 || !isDigits(digits) {
+		return ""
+	}
+	return digits
+}
+
+func isCancelReply(reply string) bool {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(reply), ".!")) {
+	case "batal", "cancel", "gak jadi", "ga jadi":
+		return true
+	}
+	return false
+}
+
+func isConfirmReply(reply string) bool {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(reply), ".!")) {
+	case "ya", "iya", "y", "ok", "oke", "lanjut", "benar", "betul", "yes":
+		return true
+	}
+	return false
+}
+
+// handleCreateTransfer starts a guided transfer from the user's balance to a
+// bank account or e-wallet. Whatever the message already names, such as
+// "transfer 100rb ke dana 0812345", is filled in and only the rest is asked.
+func (e *Engine) handleCreateTransfer(ctx context.Context, evt *events.Message, user *repo.User, rawText string, intent *nlu.IntentResult) error {
+	return e.startTransfer(ctx, evt, user, rawText, intent, false)
+}
+
+// startTransfer fills a transfer draft from the message and moves it to the
+// first step it still needs; withdraw marks a cash-out.
+func (e *Engine) startTransfer(ctx context.Context, evt *events.Message, user *repo.User, rawText string, intent *nlu.IntentResult, withdraw bool) error {
+	amount, bank, account := parseWithdrawRequest(rawText)
+	if code := strings.TrimSpace(intent.Entities["bank_code"]); code != "" {
+		bank = code
+	}
+	if no := accountDigits(intent.Entities["account_no"]); no != "" {
+		account = no
+	}
+	if amountStr := strings.TrimSpace(intent.Entities["amount"]); amountStr != "" {
+		if val, err := parseExactAmount(amountStr); err == nil && val > 0 {
+			amount = val
+		}
+	}
+
+	t := &transferDraft{AccountNo: account, Amount: amount, Withdraw: withdraw}
+	if bank != "" {
+		banks, err := e.transferBanks(ctx)
+		if err != nil {
+			return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, "transfer_bank_list")
+		}
+		if found, ok := findTransferBank(banks, bank); ok {
+			t.BankCode, t.BankName = found.Code, found.Name
+		}
+	}
+	return e.advanceTransfer(ctx, evt, user, t)
+}
+
+// advanceTransfer asks for the first detail the transfer still lacks, or for
+// confirmation once it is complete.
+func (e *Engine) advanceTransfer(ctx context.Context, evt *events.Message, user *repo.User, t *transferDraft) error {
+	to := evt.Info.Sender
+	if t.BankCode == "" {
+		banks, err := e.transferBanks(ctx)
+		if err != nil {
+			return e.handleAtlanticFailure(ctx, to, user.ID, err, "transfer_bank_list")
+		}
+		t.Step = transferChooseBank
+		e.storeTransfer(ctx, user.ID, t)
+		reply := fmt.Sprintf("Mau transfer ke bank atau e-wallet apa? Balas kode atau namanya, misalnya %s.\nBalas BATAL kalau tidak jadi.", popularTransferBanks(banks))
+		return e.respondAndLog(ctx, to, user.ID, reply, "transfer_choose_bank")
+	}
+	if t.AccountNo == "" {
+		t.Step = transferEnterAccount
+		e.storeTransfer(ctx, user.ID, t)
+		reply := fmt.Sprintf("Kirim nomor rekening atau nomor akun %s tujuannya ya.", t.destination())
+		return e.respondAndLog(ctx, to, user.ID, reply, "transfer_enter_account")
+	}
+	if t.AccountName == "" {
+		check, err := e.atl.TransferCheckAccount(ctx, t.BankCode, t.AccountNo)
+		if err != nil {
+			t.Step = transferEnterAccount
+			e.storeTransfer(ctx, user.ID, t)
+			return e.handleAtlanticFailure(ctx, to, user.ID, err, "transfer_check_account")
+		}
+		if strings.TrimSpace(check.OwnerName) == "" {
+			reply := fmt.Sprintf("Rekening %s tidak ditemukan. Cek lagi nomornya lalu kirim ulang ya.", t.destination())
+			t.AccountNo = ""
+			t.Step = transferEnterAccount
+			e.storeTransfer(ctx, user.ID, t)
+			return e.respondAndLog(ctx, to, user.ID, reply, "transfer_account_invalid")
+		}
+		t.AccountName = strings.TrimSpace(check.OwnerName)
+		t.AccountCheck = check.Raw
+	}
+	if t.Amount <= 0 {
+		t.Step = transferEnterAmount
+		e.storeTransfer(ctx, user.ID, t)
+		reply := fmt.Sprintf("Rekening tujuan: %s.\nBerapa nominal yang mau ditransfer?", t.destination())
+		return e.respondAndLog(ctx, to, user.ID, reply, "transfer_enter_amount")
+	}
+
+	fee := e.tunables().WithdrawFee
+	refusal, reason, err := e.payoutRefusal(ctx, user, t.verb(), t.Amount, fee)
+	if err != nil {
+		return err
+	}
+	if refusal != "" {
+		t.Amount = 0
+		t.Step = transferEnterAmount
+		e.storeTransfer(ctx, user.ID, t)
+		return e.respondAndLog(ctx, to, user.ID, refusal+"\nKirim nominal lain atau balas BATAL.", "transfer_"+reason)
+	}
+	t.Step = transferConfirm
+	e.storeTransfer(ctx, user.ID, t)
+	locale := e.currencyLocale(user)
+	heading := "Cek dulu ya:"
+	if t.Withdraw {
+		// The owner's name is the user's last chance to spot a wrong account.
+		heading = "Cek dulu penarikan saldo kamu ya, dana yang sudah terkirim tidak bisa ditarik kembali:"
+	}
+	reply := fmt.Sprintf("%s\n• Tujuan: %s\n• Nominal: %s\n• Biaya admin: %s\n• Total dipotong dari saldo: %s\nBalas YA untuk lanjut atau BATAL.",
+		heading, t.destination(), formatCurrency(locale, money.FromRupiah(t.Amount)), formatCurrency(locale, money.FromRupiah(fee)), formatCurrency(locale, money.FromRupiah(t.Amount+fee)))
+	return e.respondAndLog(ctx, to, user.ID, reply, "transfer_confirm")
+}
+
+// handleTransferReply moves the pending transfer on with the user's reply.
+func (e *Engine) handleTransferReply(ctx context.Context, evt *events.Message, user *repo.User, intent *nlu.IntentResult) error {
+	to := evt.Info.Sender
+	reply := strings.TrimSpace(intent.Entities["reply"])
+	t := e.loadTransfer(ctx, user.ID)
+	if t == nil {
+		return e.respondAndLog(ctx, to, user.ID, "Tidak ada transfer yang sedang diproses. Ketik \"transfer\" untuk mulai.", "transfer_none")
+	}
+	if isCancelReply(reply) {
+		e.storeTransfer(ctx, user.ID, nil)
+		return e.respondAndLog(ctx, to, user.ID, fmt.Sprintf("Oke, %s dibatalkan. Saldo kamu tidak terpotong.", t.verb()), "transfer_cancelled")
+	}
+
+	switch t.Step {
+	case transferChooseBank:
+		banks, err := e.transferBanks(ctx)
+		if err != nil {
+			return e.handleAtlanticFailure(ctx, to, user.ID, err, "transfer_bank_list")
+		}
+		bank, ok := findTransferBank(banks, reply)
+		if !ok {
+			msg := fmt.Sprintf("Bank atau e-wallet %q belum kukenali. Coba kirim kodenya, misalnya %s.", reply, popularTransferBanks(banks))
+			return e.respondAndLog(ctx, to, user.ID, msg, "transfer_bank_unknown")
+		}
+		t.BankCode, t.BankName = bank.Code, bank.Name
+	case transferEnterAccount:
+		t.AccountNo = accountDigits(reply)
+		t.AccountName, t.AccountCheck = "", nil
+	case transferEnterAmount:
+		amount, err := parseExactAmount(reply)
+		if err != nil || amount <= 0 {
+			return e.respondAndLog(ctx, to, user.ID, "Nominal transfer belum jelas. Tulis angka seperti 100000 atau 100rb ya.", "transfer_invalid_amount")
+		}
+		t.Amount = amount
+	case transferConfirm:
+		return e.promptTransferPIN(ctx, evt, user, t)
+	case transferEnterPIN:
+		return e.checkTransferPIN(ctx, evt, user, t, reply)
+	case transferNewPIN:
+		if !validPIN(reply) {
+			return e.respondAndLog(ctx, to, user.ID, fmt.Sprintf("PIN harus %d angka dan jangan yang mudah ditebak seperti 123456 atau 111111. Kirim PIN lain ya.", pinLength), "transfer_pin_weak")
+		}
+		hash, err := hashPIN(reply)
+		if err != nil {
+			return err
+		}
+		t.NewPINHash = hash
+		t.Step = transferRepeatPIN
+		e.storeTransfer(ctx, user.ID, t)
+		return e.respondAndLog(ctx, to, user.ID, "Kirim sekali lagi PIN yang sama untuk memastikan.", "transfer_pin_repeat")
+	case transferRepeatPIN:
+		if !checkPIN(t.NewPINHash, reply) {
+			t.NewPINHash = ""
+			t.Step = transferNewPIN
+			e.storeTransfer(ctx, user.ID, t)
+			return e.respondAndLog(ctx, to, user.ID, "PIN-nya tidak sama. Buat ulang PIN transaksi kamu ya.", "transfer_pin_mismatch")
+		}
+		if err := e.repo.SetUserPINHash(ctx, user.ID, t.NewPINHash); err != nil {
+			return fmt.Errorf("set user pin: %w", err)
+		}
+		e.logger.Info("transaction pin set", "user_id", user.ID)
+		return e.submitTransfer(ctx, evt, user, t)
+	}
+	return e.advanceTransfer(ctx, evt, user, t)
+}
+
+// promptTransferPIN asks for the user's PIN, or to choose one when none is set.
+func (e *Engine) promptTransferPIN(ctx context.Context, evt *events.Message, user *repo.User, t *transferDraft) error {
+	hash, err := e.repo.GetUserPINHash(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("get user pin: %w", err)
+	}
+	if hash == "" {
+		t.Step = transferNewPIN
+		e.storeTransfer(ctx, user.ID, t)
+		reply := fmt.Sprintf("Kamu belum punya PIN transaksi. Buat dulu ya: kirim %d angka yang mudah kamu ingat tapi sulit ditebak orang lain.", pinLength)
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "transfer_pin_new")
+	}
+	if _, locked, err := e.loadPINLock(ctx, evt, user); locked {
+		return err
+	}
+	t.Step = transferEnterPIN
+	e.storeTransfer(ctx, user.ID, t)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, "Kirim PIN transaksi kamu untuk menyelesaikan transfer.", "transfer_pin")
+}
+
+// checkTransferPIN submits the transfer when pin is right. Wrong PINs are
+// counted on the user rather than the transfer, so starting over does not
+// reset them; the maxPINAttempts-th cancels the transfer and locks transfers
+// for pinLockout.
+func (e *Engine) checkTransferPIN(ctx context.Context, evt *events.Message, user *repo.User, t *transferDraft, pin string) error {
+	lock, locked, err := e.loadPINLock(ctx, evt, user)
+	if locked {
+		return err
+	}
+	hash, err := e.repo.GetUserPINHash(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("get user pin: %w", err)
+	}
+	if checkPIN(hash, pin) {
+		if lock.FailedAttempts > 0 || lock.LockedUntil != nil {
+			if err := e.repo.SetUserPINLock(ctx, user.ID, repo.PINLock{}); err != nil {
+				return fmt.Errorf("reset user pin lock: %w", err)
+			}
+		}
+		return e.submitTransfer(ctx, evt, user, t)
+	}
+	lock.FailedAttempts++
+	e.logger.Warn("wrong transaction pin", "user_id", user.ID, "attempts", lock.FailedAttempts)
+	if lock.FailedAttempts >= maxPINAttempts {
+		until := time.Now().Add(pinLockout)
+		if err := e.repo.SetUserPINLock(ctx, user.ID, repo.PINLock{LockedUntil: &until}); err != nil {
+			return fmt.Errorf("lock user pin: %w", err)
+		}
+		e.storeTransfer(ctx, user.ID, nil)
+		reply := fmt.Sprintf("PIN salah terlalu banyak, jadi transfer ini kubatalkan dan transfer dikunci sampai %s. Saldo kamu tidak terpotong.", until.In(user.Location()).Format(expiryLayout))
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "transfer_pin_locked")
+	}
+	lock.LockedUntil = nil
+	if err := e.repo.SetUserPINLock(ctx, user.ID, lock); err != nil {
+		return fmt.Errorf("count wrong pin: %w", err)
+	}
+	e.storeTransfer(ctx, user.ID, t)
+	reply := fmt.Sprintf("PIN salah. Sisa %d kali percobaan lagi.", maxPINAttempts-lock.FailedAttempts)
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "transfer_pin_wrong")
+}
+
+// loadPINLock returns the user's wrong-PIN count. While too many wrong PINs
+// keep transfers locked it cancels the transfer, tells the user and reports
+// locked.
+func (e *Engine) loadPINLock(ctx context.Context, evt *events.Message, user *repo.User) (repo.PINLock, bool, error) {
+	lock, err := e.repo.GetUserPINLock(ctx, user.ID)
+	if err != nil {
+		return lock, true, fmt.Errorf("get user pin lock: %w", err)
+	}
+	if lock.LockedUntil == nil || !time.Now().Before(*lock.LockedUntil) {
+		return lock, false, nil
+	}
+	e.storeTransfer(ctx, user.ID, nil)
+	reply := fmt.Sprintf("Transfer sedang dikunci karena PIN salah terlalu banyak. Coba lagi setelah %s ya.", lock.LockedUntil.In(user.Location()).Format(expiryLayout))
+	return lock, true, e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "transfer_pin_locked")
+}
+
+// submitTransfer records the authorised transfer as a withdrawal, so it is
+// charged to the balance, limited and settled like one, and sends it.
+func (e *Engine) submitTransfer(ctx context.Context, evt *events.Message, user *repo.User, t *transferDraft) error {
+	// Held from the draft check on, so a double-sent PIN cannot send the
+	// transfer twice or race a purchase for the same balance.
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, localize(user, msgPurchaseBusy), "purchase_in_progress")
+	}
+	defer release()
+	if e.loadTransfer(ctx, user.ID) == nil {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, fmt.Sprintf("Permintaan %s ini sudah diproses.", t.verb()), "transfer_already_submitted")
+	}
+	e.storeTransfer(ctx, user.ID, nil)
+	fee := e.tunables().WithdrawFee
+	// The balance may have moved since the transfer was confirmed.
+	refusal, reason, err := e.payoutRefusal(ctx, user, t.verb(), t.Amount, fee)
+	if err != nil {
+		return err
+	}
+	if refusal != "" {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, refusal, "transfer_"+reason)
+	}
+
+	wd := repo.Withdrawal{
+		UserID:        user.ID,
+		WithdrawalRef: generateRefID("tf"),
+		BankCode:      t.BankCode,
+		AccountNo:     t.AccountNo,
+		AccountName:   optionalString(t.AccountName),
+		Amount:        t.Amount,
+		Fee:           fee,
+		Status:        "pending",
+		Metadata: map[string]any{
+			"kind":          withdrawalKindTransfer,
+			"account_check": t.AccountCheck,
+		},
+	}
+	label := "create_transfer"
+	if t.Withdraw {
+		wd.WithdrawalRef = generateRefID("wd")
+		delete(wd.Metadata, "kind")
+		label = "withdraw_balance"
+	}
+	if e.needsWithdrawApproval(t.Amount) {
+		return e.requestWithdrawApproval(ctx, evt, user, wd)
+	}
+	if _, err := e.repo.InsertWithdrawal(ctx, wd); err != nil {
+		return fmt.Errorf("insert %s: %w", t.verb(), err)
+	}
+	status, err := e.submitWithdrawal(ctx, wd)
+	if err != nil {
+		return e.handleAtlanticFailure(ctx, evt.Info.Sender, user.ID, err, label)
+	}
+	locale := e.currencyLocale(user)
+	if t.Withdraw {
+		return e.respondAndLog(ctx, evt.Info.Sender, user.ID, withdrawalProcessingMessage(wd, status, locale), label)
+	}
+	reply := fmt.Sprintf("Sip, transfer %s sebesar %s ke %s sedang diproses (status: %s).\nBiaya admin %s, total dipotong dari saldo %s.",
+		wd.WithdrawalRef, formatCurrency(locale, money.FromRupiah(wd.Amount)), t.destination(), strings.ToUpper(status),
+		formatCurrency(locale, money.FromRupiah(wd.Fee)), formatCurrency(locale, money.FromRupiah(wd.Amount+wd.Fee)))
+	return e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "create_transfer")
+}
+
+// validPIN accepts pinLength digits that are not all the same or a run
+// such as 123456.
+func validPIN(pin string) bool {
+	if len(pin) != pinLength || !isDigits(pin) {
+		return false
+	}
+	same, up, down := true, true, true
+	for i := 1; i < len(pin); i++ {
+		same = same && pin[i] == pin[0]
+		up = up && pin[i] == pin[i-1]+1
+		down = down && pin[i] == pin[i-1]-1
+	}
+	return !same && !up && !down
+}
+
+// hashPIN derives a salted PBKDF2-SHA256 hash stored as
+// "pbkdf2-sha256$iterations$salt$key".
+func hashPIN(pin string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("pin salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, pin, salt, pinIterations, 32)
+	if err != nil {
+		return "", fmt.Errorf("hash pin: %w", err)
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", pinIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// checkPIN reports whether pin matches a hash made by hashPIN.
+func checkPIN(hash, pin string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, pin, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
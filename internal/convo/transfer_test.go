@@ -0,0 +1,225 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type transferRepo struct {
+	*stateRepo
+	balance     int64
+	pinHash     string
+	pinLock     repo.PINLock
+	withdrawals []repo.Withdrawal
+	statuses    map[string]string
+}
+
+func (r *transferRepo) GetUserBalance(context.Context, string) (*repo.UserBalance, error) {
+	return &repo.UserBalance{SaldoConfirmed: r.balance}, nil
+}
+
+func (r *transferRepo) SumWithdrawalsSince(context.Context, string, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *transferRepo) GetUserPINHash(context.Context, string) (string, error) {
+	return r.pinHash, nil
+}
+
+func (r *transferRepo) SetUserPINHash(_ context.Context, _ string, hash string) error {
+	r.pinHash = hash
+	return nil
+}
+
+func (r *transferRepo) GetUserPINLock(context.Context, string) (repo.PINLock, error) {
+	return r.pinLock, nil
+}
+
+func (r *transferRepo) SetUserPINLock(_ context.Context, _ string, lock repo.PINLock) error {
+	r.pinLock = lock
+	return nil
+}
+
+func (r *transferRepo) InsertWithdrawal(_ context.Context, wd repo.Withdrawal) (*repo.Withdrawal, error) {
+	r.withdrawals = append(r.withdrawals, wd)
+	return &wd, nil
+}
+
+func (r *transferRepo) UpdateWithdrawalStatus(_ context.Context, ref, status string, _ map[string]any) error {
+	r.statuses[ref] = status
+	return nil
+}
+
+func (r *transferRepo) InsertMessage(context.Context, repo.MessageRecord) error {
+	return nil
+}
+
+func TestTransferFlow(t *testing.T) {
+	var transfers []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.URL.Path {
+		case "/transfer/bank_list":
+			_, _ = io.WriteString(w, `{"status":true,"data":[{"bank_code":"bca","bank_name":"Bank Central Asia"},{"bank_code":"dana","bank_name":"DANA"}]}`)
+		case "/transfer/cek_rekening":
+			if r.Form.Get("account_number") != "081234567890" {
+				_, _ = io.WriteString(w, `{"status":true,"data":{"status":"success"}}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"status":true,"data":{"nama_pemilik":"BUDI SANTOSO","status":"success"}}`)
+		case "/transfer/create":
+			transfers = append(transfers, r.Form.Get("kode_bank")+" "+r.Form.Get("nomor_akun")+" "+r.Form.Get("nominal"))
+			_, _ = io.WriteString(w, `{"status":true,"data":{"id":"T1","reff_id":"`+r.Form.Get("reff_id")+`","status":"pending"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := &transferRepo{stateRepo: &stateRepo{states: map[string]repo.ConversationState{}}, balance: 100000, statuses: map[string]string{}}
+	gw := &textGateway{}
+	e := &Engine{
+		repo:     r,
+		gateway:  gw,
+		logger:   logger,
+		atl:      atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil),
+		sessions: map[string]sessionEntry{},
+		cfg:      EngineConfig{WithdrawFee: 2500},
+	}
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	last := func() string { return gw.sent[len(gw.sent)-1] }
+	reply := func(text string) {
+		t.Helper()
+		intent, ok := e.parseTransferReply(ctx, user.ID, text)
+		if !ok {
+			t.Fatalf("reply %q not taken by the transfer flow", text)
+		}
+		if err := e.handleTransferReply(ctx, evt, user, intent); err != nil {
+			t.Fatalf("reply %q: %v", text, err)
+		}
+	}
+
+	if err := e.handleCreateTransfer(ctx, evt, user, "transfer dong", &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(last(), "BCA, DANA") {
+		t.Fatalf("bank prompt = %q", last())
+	}
+	if _, ok := e.parseTransferReply(ctx, user.ID, "harga pulsa telkomsel 10rb berapa?"); ok {
+		t.Fatalf("unrelated message taken by the transfer flow")
+	}
+	reply("dana")
+	reply("0812 0000 0000")
+	if !strings.Contains(last(), "tidak ditemukan") {
+		t.Fatalf("unknown account reply = %q", last())
+	}
+	reply("081234567890")
+	if !strings.Contains(last(), "a.n BUDI SANTOSO") {
+		t.Fatalf("amount prompt = %q", last())
+	}
+	reply("200rb")
+	if !strings.Contains(last(), "belum cukup") {
+		t.Fatalf("over-balance reply = %q", last())
+	}
+	reply("50rb")
+	if !strings.Contains(last(), "52.500") {
+		t.Fatalf("confirmation = %q", last())
+	}
+	reply("ya")
+	if !strings.Contains(last(), "belum punya PIN") {
+		t.Fatalf("pin prompt = %q", last())
+	}
+	if got := e.redactPIN(ctx, user.ID, "493817"); got == "493817" {
+		t.Fatalf("pin logged in clear")
+	}
+	reply("123456")
+	if !strings.Contains(last(), "mudah ditebak") {
+		t.Fatalf("weak pin accepted: %q", last())
+	}
+	reply("493817")
+	reply("493817")
+	if r.pinHash == "" || !checkPIN(r.pinHash, "493817") {
+		t.Fatalf("pin not stored: %q", r.pinHash)
+	}
+
+	if len(transfers) != 1 || transfers[0] != "dana 081234567890 50000" {
+		t.Fatalf("transfers = %v", transfers)
+	}
+	wd := r.withdrawals[0]
+	if wd.Fee != 2500 || stringValue(wd.Metadata, "kind") != withdrawalKindTransfer || r.statuses[wd.WithdrawalRef] != "pending" {
+		t.Fatalf("withdrawal = %+v, status %q", wd, r.statuses[wd.WithdrawalRef])
+	}
+	if e.loadTransfer(ctx, user.ID) != nil {
+		t.Fatalf("transfer draft kept after submitting")
+	}
+
+	// Later transfers ask for the stored PIN. Wrong PINs count across
+	// transfers, so starting over does not earn more guesses.
+	start := func() {
+		t.Helper()
+		if err := e.handleCreateTransfer(ctx, evt, user, "transfer 10rb ke dana 081234567890", &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+		reply("ya")
+	}
+	start()
+	for range maxPINAttempts - 1 {
+		reply("000000")
+	}
+	start()
+	reply("000000")
+	if !strings.Contains(last(), "PIN salah terlalu banyak") || e.loadTransfer(ctx, user.ID) != nil {
+		t.Fatalf("wrong pins reply = %q", last())
+	}
+	if r.pinLock.LockedUntil == nil || time.Until(*r.pinLock.LockedUntil) < pinLockout-time.Minute {
+		t.Fatalf("pin lock = %+v", r.pinLock)
+	}
+	start()
+	if !strings.Contains(last(), "dikunci") || e.loadTransfer(ctx, user.ID) != nil {
+		t.Fatalf("locked transfer reply = %q", last())
+	}
+	if len(transfers) != 1 {
+		t.Fatalf("transfer sent without the right pin: %v", transfers)
+	}
+
+	// Once the lockout ends, the right PIN goes through and clears the count.
+	past := time.Now().Add(-time.Minute)
+	r.pinLock.LockedUntil = &past
+	start()
+	reply("000000")
+	reply("493817")
+	if len(transfers) != 2 || r.pinLock.FailedAttempts != 0 || r.pinLock.LockedUntil != nil {
+		t.Fatalf("transfers %v, pin lock %+v", transfers, r.pinLock)
+	}
+}
+
+func TestValidPIN(t *testing.T) {
+	for pin, want := range map[string]bool{"493817": true, "123456": false, "654321": false, "111111": false, "12345": false, "12a456": false} {
+		if got := validPIN(pin); got != want {
+			t.Errorf("validPIN(%q) = %v, want %v", pin, got, want)
+		}
+	}
+	hash, err := hashPIN("493817")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkPIN(hash, "493817") || checkPIN(hash, "493818") || checkPIN("", "493817") {
+		t.Fatalf("checkPIN disagrees with hashPIN: %q", hash)
+	}
+}
@@ -0,0 +1,126 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// TrustLevel caps the amount of a single order for users with at least
+// MinOrders successful orders; a zero MaxAmount means no cap.
+type TrustLevel struct {
+	MinOrders int
+	MaxAmount int64
+}
+
+// trustCap returns the order cap for a user with the given number of
+// successful orders and the next level that raises it, if any. Users below
+// the lowest level get its cap.
+func trustCap(levels []TrustLevel, orders int) (int64, *TrustLevel) {
+	if len(levels) == 0 {
+		return 0, nil
+	}
+	sorted := append([]TrustLevel(nil), levels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinOrders < sorted[j].MinOrders })
+
+	current := sorted[0]
+	for _, level := range sorted[1:] {
+		if level.MinOrders <= orders {
+			current = level
+		}
+	}
+	if current.MaxAmount == 0 {
+		return 0, nil
+	}
+	for i := range sorted {
+		level := sorted[i]
+		if level.MinOrders > orders && (level.MaxAmount == 0 || level.MaxAmount > current.MaxAmount) {
+			return current.MaxAmount, &level
+		}
+	}
+	return current.MaxAmount, nil
+}
+
+// productMinOrders returns how many successful orders a user needs before
+// buying item, matched on its code first, then its product type.
+func productMinOrders(rules map[string]int, item *atl.PriceListItem, productType string) int {
+	if n, ok := rules[strings.ToLower(item.Code)]; ok {
+		return n
+	}
+	return rules[strings.ToLower(productType)]
+}
+
+// unlockHint tells the user how many more successful orders unlock a level.
+func unlockHint(needed, have int) string {
+	if have == 0 {
+		return fmt.Sprintf("setelah %d transaksi sukses", needed)
+	}
+	return fmt.Sprintf("setelah %d transaksi sukses lagi", needed-have)
+}
+
+// checkTrust holds back purchases above the user's trust level: products
+// reserved for established users and orders above the level's cap. Admins
+// are exempt. It returns whether the purchase may proceed.
+func (e *Engine) checkTrust(ctx context.Context, evt *events.Message, user *repo.User, item *atl.PriceListItem, productType string) (bool, error) {
+	t := e.tunables()
+	minOrders := productMinOrders(t.TrustProductMinOrders, item, productType)
+	if (len(t.TrustLevels) == 0 && minOrders == 0) || e.isAdmin(evt.Info.MessageSource) {
+		return true, nil
+	}
+	orders, err := e.repo.CountSuccessfulOrders(ctx, user.ID)
+	if err != nil {
+		// Blocking every purchase on a read error hurts more than one
+		// uncapped order.
+		e.logger.Warn("trust check skipped, order count failed", "error", err, "user_id", user.ID)
+		return true, nil
+	}
+
+	if orders < minOrders {
+		reply := fmt.Sprintf("Maaf, %s (%s) baru bisa dibeli %s ya. Sementara itu kamu bisa pilih produk lain dulu.", item.Name, item.Code, unlockHint(minOrders, orders))
+		return false, e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "trust_product_locked")
+	}
+
+	locale := e.currencyLocale(user)
+	subject := fmt.Sprintf("%s (%s) seharga %s", item.Name, item.Code, formatCurrency(locale, item.Price))
+	return e.withinTrustCap(ctx, evt, user, orders, item.Price, subject)
+}
+
+// checkTrustTotal holds back an order of several units, whether a batch, a
+// bulk order or a cart, whose total is above the user's trust cap: the cap is
+// per order, not per unit. checkTrust still vets each product on its own.
+func (e *Engine) checkTrustTotal(ctx context.Context, evt *events.Message, user *repo.User, total money.Money) (bool, error) {
+	if len(e.tunables().TrustLevels) == 0 || e.isAdmin(evt.Info.MessageSource) {
+		return true, nil
+	}
+	orders, err := e.repo.CountSuccessfulOrders(ctx, user.ID)
+	if err != nil {
+		e.logger.Warn("trust check skipped, order count failed", "error", err, "user_id", user.ID)
+		return true, nil
+	}
+	subject := "total pesanan " + formatCurrency(e.currencyLocale(user), total)
+	return e.withinTrustCap(ctx, evt, user, orders, total, subject)
+}
+
+// withinTrustCap compares amount with the cap for a user with the given
+// number of successful orders, telling the user about subject when it is
+// above.
+func (e *Engine) withinTrustCap(ctx context.Context, evt *events.Message, user *repo.User, orders int, amount money.Money, subject string) (bool, error) {
+	limit, next := trustCap(e.tunables().TrustLevels, orders)
+	if limit == 0 || amount <= money.FromRupiah(limit) {
+		return true, nil
+	}
+	e.logger.Info("order above trust cap", "user_id", user.ID, "order", subject, "amount", amount.Rupiah(), "cap", limit, "successful_orders", orders)
+	locale := e.currencyLocale(user)
+	reply := fmt.Sprintf("Maaf, %s melebihi limit transaksi akunmu (%s per pesanan).", subject, formatCurrency(locale, money.FromRupiah(limit)))
+	if next != nil {
+		reply += fmt.Sprintf(" Limit akan naik %s.", unlockHint(next.MinOrders, orders))
+	}
+	return false, e.respondAndLog(ctx, evt.Info.Sender, user.ID, reply, "trust_limit")
+}
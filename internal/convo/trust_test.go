@@ -0,0 +1,67 @@
+package convo
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+)
+
+func TestTrustCap(t *testing.T) {
+	levels := []TrustLevel{
+		{MinOrders: 10, MaxAmount: 0},
+		{MinOrders: 0, MaxAmount: 100000},
+		{MinOrders: 3, MaxAmount: 500000},
+	}
+	cases := []struct {
+		orders   int
+		wantCap  int64
+		wantNext int
+	}{
+		{orders: 0, wantCap: 100000, wantNext: 3},
+		{orders: 2, wantCap: 100000, wantNext: 3},
+		{orders: 3, wantCap: 500000, wantNext: 10},
+		{orders: 12, wantCap: 0, wantNext: -1},
+	}
+	for _, tc := range cases {
+		limit, next := trustCap(levels, tc.orders)
+		if limit != tc.wantCap {
+			t.Errorf("orders %d: cap = %d, want %d", tc.orders, limit, tc.wantCap)
+		}
+		switch {
+		case tc.wantNext < 0 && next != nil:
+			t.Errorf("orders %d: unexpected next level %+v", tc.orders, *next)
+		case tc.wantNext >= 0 && (next == nil || next.MinOrders != tc.wantNext):
+			t.Errorf("orders %d: next = %+v, want min orders %d", tc.orders, next, tc.wantNext)
+		}
+	}
+
+	if limit, next := trustCap(nil, 0); limit != 0 || next != nil {
+		t.Fatalf("no levels should mean no cap, got %d %+v", limit, next)
+	}
+	// A new user below the lowest configured level still gets its cap.
+	if limit, _ := trustCap([]TrustLevel{{MinOrders: 2, MaxAmount: 50000}}, 0); limit != 50000 {
+		t.Fatalf("cap below lowest level = %d, want 50000", limit)
+	}
+}
+
+func TestProductMinOrders(t *testing.T) {
+	rules := map[string]int{"ewallet": 3, "gopay100": 5}
+	if got := productMinOrders(rules, &atl.PriceListItem{Code: "GOPAY100"}, "ewallet"); got != 5 {
+		t.Fatalf("code rule = %d, want 5", got)
+	}
+	if got := productMinOrders(rules, &atl.PriceListItem{Code: "DANA50"}, "ewallet"); got != 3 {
+		t.Fatalf("type rule = %d, want 3", got)
+	}
+	if got := productMinOrders(rules, &atl.PriceListItem{Code: "TSEL10"}, "pulsa"); got != 0 {
+		t.Fatalf("unrestricted product = %d, want 0", got)
+	}
+}
+
+func TestUnlockHint(t *testing.T) {
+	if got := unlockHint(3, 0); got != "setelah 3 transaksi sukses" {
+		t.Fatalf("unexpected hint %q", got)
+	}
+	if got := unlockHint(3, 1); got != "setelah 2 transaksi sukses lagi" {
+		t.Fatalf("unexpected hint %q", got)
+	}
+}
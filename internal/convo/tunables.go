@@ -0,0 +1,59 @@
+package convo
+
+import "time"
+
+// Tunables are the EngineConfig settings that may change while the engine
+// runs, e.g. after a config reload.
+type Tunables struct {
+	DepositFeeFixed           int64
+	DepositFeePercent         float64
+	WithdrawMinAmount         int64
+	WithdrawFee               int64
+	WithdrawDailyLimit        int64
+	WithdrawApprovalThreshold int64
+	ApprovalTTL               time.Duration
+	MinMargin                 int64
+	// PriceCacheTTL applies to price lists cached from now on; zero keeps
+	// the current TTL.
+	PriceCacheTTL         time.Duration
+	TrustLevels           []TrustLevel
+	TrustProductMinOrders map[string]int
+}
+
+// UpdateTunables swaps in new fee, limit and cache settings. Conversations
+// in flight pick them up on their next read.
+func (e *Engine) UpdateTunables(t Tunables) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg.DepositFeeFixed = t.DepositFeeFixed
+	e.cfg.DepositFeePercent = t.DepositFeePercent
+	e.cfg.WithdrawMinAmount = t.WithdrawMinAmount
+	e.cfg.WithdrawFee = t.WithdrawFee
+	e.cfg.WithdrawDailyLimit = t.WithdrawDailyLimit
+	e.cfg.WithdrawApprovalThreshold = t.WithdrawApprovalThreshold
+	e.cfg.ApprovalTTL = t.ApprovalTTL
+	e.cfg.MinMargin = t.MinMargin
+	e.cfg.TrustLevels = t.TrustLevels
+	e.cfg.TrustProductMinOrders = t.TrustProductMinOrders
+	if t.PriceCacheTTL > 0 {
+		e.priceCacheTTL = t.PriceCacheTTL
+	}
+}
+
+func (e *Engine) tunables() Tunables {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return Tunables{
+		DepositFeeFixed:           e.cfg.DepositFeeFixed,
+		DepositFeePercent:         e.cfg.DepositFeePercent,
+		WithdrawMinAmount:         e.cfg.WithdrawMinAmount,
+		WithdrawFee:               e.cfg.WithdrawFee,
+		WithdrawDailyLimit:        e.cfg.WithdrawDailyLimit,
+		WithdrawApprovalThreshold: e.cfg.WithdrawApprovalThreshold,
+		ApprovalTTL:               e.cfg.ApprovalTTL,
+		MinMargin:                 e.cfg.MinMargin,
+		PriceCacheTTL:             e.priceCacheTTL,
+		TrustLevels:               e.cfg.TrustLevels,
+		TrustProductMinOrders:     e.cfg.TrustProductMinOrders,
+	}
+}
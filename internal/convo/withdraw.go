@@ -0,0 +1,166 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// handleWithdrawBalance cashes the balance out to the user's own account,
+// e.g. "tarik saldo 50rb ke bca 1234567890". Payouts cannot be reversed, so
+// it runs through the transfer flow: the owner's name is verified and the
+// user confirms and enters their PIN before anything is recorded or sent.
+func (e *Engine) handleWithdrawBalance(ctx context.Context, evt *events.Message, user *repo.User, rawText string, intent *nlu.IntentResult) error {
+	return e.startTransfer(ctx, evt, user, rawText, intent, true)
+}
+
+// payoutRefusal checks amount, about to leave the user's balance with fee on
+// top, against the withdrawal limits and the confirmed balance. It returns
+// the reply explaining a refusal and a label for it, or "" when the payout
+// may go ahead; verb names the payout in the reply.
+func (e *Engine) payoutRefusal(ctx context.Context, user *repo.User, verb string, amount, fee int64) (string, string, error) {
+	locale := e.currencyLocale(user)
+	limits := e.tunables()
+	if limits.WithdrawMinAmount > 0 && amount < limits.WithdrawMinAmount {
+		return fmt.Sprintf("Minimal %s %s ya kak.", verb, formatCurrency(locale, money.FromRupiah(limits.WithdrawMinAmount))), "below_minimum", nil
+	}
+
+	if limits.WithdrawDailyLimit > 0 {
+		// The daily limit resets at the user's midnight, not the server's.
+		now := time.Now().In(user.Location())
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		usedToday, err := e.repo.SumWithdrawalsSince(ctx, user.ID, startOfDay)
+		if err != nil {
+			return "", "", fmt.Errorf("sum withdrawals: %w", err)
+		}
+		if usedToday+amount > limits.WithdrawDailyLimit {
+			remaining := limits.WithdrawDailyLimit - usedToday
+			if remaining < 0 {
+				remaining = 0
+			}
+			return fmt.Sprintf("Batas %s harian %s. Sisa limit hari ini %s.", verb, formatCurrency(locale, money.FromRupiah(limits.WithdrawDailyLimit)), formatCurrency(locale, money.FromRupiah(remaining))), "daily_limit", nil
+		}
+	}
+
+	balance, err := e.repo.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		e.logger.Warn("failed to load balance for payout", "error", err, "user_id", user.ID)
+		return "Saldo kamu belum bisa dicek sekarang. Coba lagi sebentar lagi ya.", "balance_unavailable", nil
+	}
+	if balance.SaldoConfirmed < amount+fee {
+		return fmt.Sprintf("Saldo kamu %s, belum cukup untuk %s %s + biaya %s.", formatCurrency(locale, money.FromRupiah(balance.SaldoConfirmed)), verb, formatCurrency(locale, money.FromRupiah(amount)), formatCurrency(locale, money.FromRupiah(fee))), "insufficient_balance", nil
+	}
+	return "", "", nil
+}
+
+// submitWithdrawal sends a recorded withdrawal to Atlantic as a transfer and
+// returns the supplier status. Only a transfer Atlantic refused marks the
+// withdrawal failed and releases the balance. After a timeout or an outage
+// the money may already be on its way, so the withdrawal stays pending for
+// the transfer webhook to settle and the admins are asked to check it.
+func (e *Engine) submitWithdrawal(ctx context.Context, wd repo.Withdrawal) (string, error) {
+	ownerName := ""
+	if wd.AccountName != nil {
+		ownerName = *wd.AccountName
+	}
+	resp, err := e.atl.CreateTransfer(ctx, atl.TransferRequest{
+		BankCode:    wd.BankCode,
+		AccountNo:   wd.AccountNo,
+		AccountName: ownerName,
+		Amount:      money.FromRupiah(wd.Amount),
+		RefID:       wd.WithdrawalRef,
+		Description: withdrawalDescription(wd),
+	})
+	if err != nil && !errors.Is(err, atl.ErrRejected) {
+		meta := cloneMeta(wd.Metadata)
+		meta["submit_error"] = err.Error()
+		if updErr := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, "pending", meta); updErr != nil {
+			e.logger.Warn("failed update withdrawal record", "error", updErr, "ref_id", wd.WithdrawalRef)
+		}
+		e.logger.Error("withdrawal outcome unknown", "error", err, "ref_id", wd.WithdrawalRef)
+		e.notifyAdmins(ctx, fmt.Sprintf("Transfer %s sebesar %s ke %s %s belum pasti terkirim (%v). Status dibiarkan PENDING; cek di dashboard Atlantic kalau webhook tidak datang.",
+			wd.WithdrawalRef, formatCurrency(e.adminCurrencyLocale(), money.FromRupiah(wd.Amount)), strings.ToUpper(wd.BankCode), wd.AccountNo, err))
+		return "pending", nil
+	}
+	if err != nil {
+		meta := cloneMeta(wd.Metadata)
+		meta["error"] = err.Error()
+		if updErr := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, "failed", meta); updErr != nil {
+			e.logger.Warn("failed marking withdrawal failed", "error", updErr, "ref_id", wd.WithdrawalRef)
+		}
+		return "", err
+	}
+
+	status := resp.Status
+	if status == "" {
+		status = "pending"
+	}
+	meta := cloneMeta(wd.Metadata)
+	meta["transfer"] = resp.Raw
+	meta["message"] = resp.Message
+	if err := e.repo.UpdateWithdrawalStatus(ctx, wd.WithdrawalRef, status, meta); err != nil {
+		e.logger.Warn("failed update withdrawal record", "error", err, "ref_id", wd.WithdrawalRef)
+	}
+	return status, nil
+}
+
+// withdrawalDescription is the transfer remark for a withdrawal, or for a
+// transfer the user sent to someone through the transfer flow.
+func withdrawalDescription(wd repo.Withdrawal) string {
+	if stringValue(wd.Metadata, "kind") == withdrawalKindTransfer {
+		return "Transfer " + wd.WithdrawalRef
+	}
+	return "Tarik saldo " + wd.WithdrawalRef
+}
+
+// withdrawalNoun names wd in messages: a transfer the user sent through the
+// transfer flow, or a withdrawal of their own balance.
+func withdrawalNoun(wd repo.Withdrawal) string {
+	if stringValue(wd.Metadata, "kind") == withdrawalKindTransfer {
+		return "Transfer"
+	}
+	return "Penarikan"
+}
+
+func withdrawalProcessingMessage(wd repo.Withdrawal, status string, locale money.Locale) string {
+	ownerName := ""
+	if wd.AccountName != nil {
+		ownerName = *wd.AccountName
+	}
+	return fmt.Sprintf("Sip, %s %s sebesar %s ke %s %s a.n %s sedang diproses (status: %s).\nBiaya admin %s, total dipotong dari saldo %s.",
+		strings.ToLower(withdrawalNoun(wd)), wd.WithdrawalRef, formatCurrency(locale, money.FromRupiah(wd.Amount)), strings.ToUpper(wd.BankCode), wd.AccountNo, ownerName, strings.ToUpper(status),
+		formatCurrency(locale, money.FromRupiah(wd.Fee)), formatCurrency(locale, money.FromRupiah(wd.Amount+wd.Fee)))
+}
+
+func looksLikeWithdrawRequest(text string) bool {
+	return strings.Contains(text, "tarik saldo") ||
+		strings.Contains(text, "tarik dana") ||
+		strings.Contains(text, "cairkan saldo") ||
+		strings.Contains(text, "withdraw")
+}
+
+// parseWithdrawRequest extracts amount, bank code and account number from
+// messages like "tarik saldo 50rb ke bca 1234567890".
+func parseWithdrawRequest(text string) (int64, string, string) {
+	lowered := strings.ToLower(strings.TrimSpace(text))
+	bank, account := parseBankAccount(lowered)
+
+	amountText := lowered
+	if account != "" {
+		amountText = strings.Replace(amountText, account, " ", 1)
+	}
+	amount, err := parseExactAmount(amountText)
+	if err != nil {
+		amount = 0
+	}
+	return amount, bank, account
+}
@@ -0,0 +1,241 @@
+package convo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/nlu"
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestParseWithdrawRequest(t *testing.T) {
+	amount, bank, account := parseWithdrawRequest("tarik saldo 50rb ke bca 1234567890")
+	if amount != 50000 {
+		t.Fatalf("expected amount 50000, got %d", amount)
+	}
+	if bank != "bca" {
+		t.Fatalf("expected bank bca, got %s", bank)
+	}
+	if account != "1234567890" {
+		t.Fatalf("expected account 1234567890, got %s", account)
+	}
+}
+
+func TestParseWithdrawRequestAccountBeforeAmount(t *testing.T) {
+	amount, bank, account := parseWithdrawRequest("withdraw ke dana 081234567890 100000")
+	if amount != 100000 {
+		t.Fatalf("expected amount 100000, got %d", amount)
+	}
+	if bank != "dana" {
+		t.Fatalf("expected bank dana, got %s", bank)
+	}
+	if account != "081234567890" {
+		t.Fatalf("expected account 081234567890, got %s", account)
+	}
+}
+
+// newWithdrawEngine returns an engine whose Atlantic knows BCA account
+// 1234567890 and records the refs of the transfers it is asked to send.
+func newWithdrawEngine(t *testing.T, pinHash string) (*Engine, *transferRepo, *textGateway, *[]string) {
+	t.Helper()
+	var transfers []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.URL.Path {
+		case "/transfer/bank_list":
+			_, _ = io.WriteString(w, `{"status":true,"data":[{"bank_code":"bca","bank_name":"Bank Central Asia"}]}`)
+		case "/transfer/cek_rekening":
+			_, _ = io.WriteString(w, `{"status":true,"data":{"nama_pemilik":"BUDI SANTOSO","status":"success"}}`)
+		case "/transfer/create":
+			transfers = append(transfers, r.Form.Get("reff_id"))
+			_, _ = io.WriteString(w, `{"status":true,"data":{"id":"T1","reff_id":"`+r.Form.Get("reff_id")+`","status":"pending"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := &transferRepo{stateRepo: &stateRepo{states: map[string]repo.ConversationState{}}, balance: 100000, pinHash: pinHash, statuses: map[string]string{}}
+	gw := &textGateway{}
+	e := &Engine{
+		repo:     r,
+		gateway:  gw,
+		logger:   logger,
+		atl:      atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil),
+		sessions: map[string]sessionEntry{},
+		cfg:      EngineConfig{WithdrawFee: 2500},
+	}
+	return e, r, gw, &transfers
+}
+
+func TestWithdrawWaitsForConfirmation(t *testing.T) {
+	e, r, gw, transfers := newWithdrawEngine(t, "")
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+
+	if err := e.handleWithdrawBalance(ctx, evt, user, "tarik saldo 50rb ke bca 1234567890", &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	confirm := gw.sent[len(gw.sent)-1]
+	for _, want := range []string{"penarikan saldo", "a.n BUDI SANTOSO", "Rp50.000", "Rp2.500", "Balas YA"} {
+		if !strings.Contains(confirm, want) {
+			t.Fatalf("confirmation missing %q:\n%s", want, confirm)
+		}
+	}
+	intent, ok := e.parseTransferReply(ctx, user.ID, "batal")
+	if !ok {
+		t.Fatal("cancel not taken by the withdrawal")
+	}
+	if err := e.handleTransferReply(ctx, evt, user, intent); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.withdrawals) != 0 || len(*transfers) != 0 || e.loadTransfer(ctx, user.ID) != nil {
+		t.Fatalf("cancelled withdrawal recorded: %v, sent %v", r.withdrawals, *transfers)
+	}
+}
+
+func TestWithdrawNeedsPIN(t *testing.T) {
+	pinHash, err := hashPIN("493817")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, r, gw, transfers := newWithdrawEngine(t, pinHash)
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	last := func() string { return gw.sent[len(gw.sent)-1] }
+	reply := func(text string) {
+		t.Helper()
+		intent, ok := e.parseTransferReply(ctx, user.ID, text)
+		if !ok {
+			t.Fatalf("reply %q not taken by the withdrawal", text)
+		}
+		if err := e.handleTransferReply(ctx, evt, user, intent); err != nil {
+			t.Fatalf("reply %q: %v", text, err)
+		}
+	}
+	withdraw := func() {
+		t.Helper()
+		if err := e.handleWithdrawBalance(ctx, evt, user, "tarik saldo 50rb ke bca 1234567890", &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withdraw()
+	if len(*transfers) != 0 || len(r.withdrawals) != 0 {
+		t.Fatalf("withdrawal sent without confirmation: %v", *transfers)
+	}
+	reply("ya")
+	if !strings.Contains(last(), "PIN transaksi") {
+		t.Fatalf("pin prompt = %q", last())
+	}
+	for range maxPINAttempts {
+		reply("000000")
+	}
+	if !strings.Contains(last(), "PIN salah terlalu banyak") {
+		t.Fatalf("wrong pins reply = %q", last())
+	}
+	withdraw()
+	reply("ya")
+	if !strings.Contains(last(), "dikunci") || e.loadTransfer(ctx, user.ID) != nil {
+		t.Fatalf("locked withdrawal reply = %q", last())
+	}
+	if len(*transfers) != 0 || len(r.withdrawals) != 0 {
+		t.Fatalf("withdrawal sent without the right pin: %v", *transfers)
+	}
+
+	r.pinLock = repo.PINLock{}
+	withdraw()
+	reply("ya")
+	reply("493817")
+	if len(*transfers) != 1 || !strings.HasPrefix((*transfers)[0], "wd") {
+		t.Fatalf("transfers = %v", *transfers)
+	}
+	if wd := r.withdrawals[0]; stringValue(wd.Metadata, "kind") != "" || !strings.Contains(last(), "penarikan") {
+		t.Fatalf("withdrawal = %+v, reply %q", wd, last())
+	}
+}
+
+func TestSubmitWithdrawalKeepsUncertainTransfersPending(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		answer func(w http.ResponseWriter)
+		status string
+		err    bool
+	}{
+		{"rejected", func(w http.ResponseWriter) {
+			_, _ = io.WriteString(w, `{"status":false,"message":"rekening tidak valid"}`)
+		}, "failed", true},
+		{"bad request", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"status":false,"message":"nominal tidak valid"}`)
+		}, "failed", true},
+		{"outage", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, "pending", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { tc.answer(w) }))
+			defer srv.Close()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			r := &transferRepo{statuses: map[string]string{}}
+			e := &Engine{repo: r, logger: logger, atl: atl.New(atl.Config{BaseURL: srv.URL, APIKey: "k"}, logger, nil, nil)}
+
+			status, err := e.submitWithdrawal(context.Background(), repo.Withdrawal{WithdrawalRef: "wd-1", BankCode: "bca", AccountNo: "1234567890", Amount: 50000})
+			if (err != nil) != tc.err {
+				t.Fatalf("err = %v", err)
+			}
+			if got := r.statuses["wd-1"]; got != tc.status {
+				t.Fatalf("withdrawal status = %q, want %q (returned %q)", got, tc.status, status)
+			}
+		})
+	}
+}
+
+func TestSubmitTransferOncePerDraft(t *testing.T) {
+	e, r, gw, transfers := newWithdrawEngine(t, "")
+	ctx := context.Background()
+	user := &repo.User{ID: "u1"}
+	evt := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}}}
+	if err := e.handleWithdrawBalance(ctx, evt, user, "tarik saldo 50rb ke bca 1234567890", &nlu.IntentResult{Entities: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	draft := e.loadTransfer(ctx, user.ID)
+
+	// Another purchase holds the lock: nothing is sent and the draft stays.
+	release, ok := e.lockPurchase(ctx, user.ID)
+	if !ok {
+		t.Fatal("lock not taken")
+	}
+	if err := e.submitTransfer(ctx, evt, user, draft); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if len(*transfers) != 0 || e.loadTransfer(ctx, user.ID) == nil {
+		t.Fatalf("transfer sent while locked: %v", *transfers)
+	}
+
+	// A double-sent PIN submits the same draft twice; only the first sends.
+	for range 2 {
+		if err := e.submitTransfer(ctx, evt, user, draft); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*transfers) != 1 || len(r.withdrawals) != 1 {
+		t.Fatalf("transfers = %v", *transfers)
+	}
+	if last := gw.sent[len(gw.sent)-1]; !strings.Contains(last, "sudah diproses") {
+		t.Fatalf("second submit reply = %q", last)
+	}
+}
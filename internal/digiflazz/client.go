@@ -0,0 +1,316 @@
+// Package digiflazz buys prepaid products from Digiflazz, in the shapes the
+// Atlantic client uses so the supplier router can fail over between them.
+package digiflazz
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/httpx"
+	"bot-jual/internal/money"
+)
+
+const (
+	// Name is the supplier name recorded on orders Digiflazz takes.
+	Name = "digiflazz"
+
+	defaultBaseURL = "https://api.digiflazz.com/v1"
+	// defaultPriceTTL stays above Digiflazz's price list rate limit.
+	defaultPriceTTL = 5 * time.Minute
+)
+
+// Config holds Digiflazz client configuration.
+type Config struct {
+	Username string
+	APIKey   string
+	// BaseURL overrides the API endpoint.
+	BaseURL string
+	// SKUs maps Atlantic product codes to Digiflazz buyer SKU codes; codes
+	// not listed are assumed to be set up under the same SKU.
+	SKUs map[string]string
+	// PriceTTL is how long the price list is reused before it is fetched again.
+	PriceTTL time.Duration
+	Timeout  time.Duration
+	// Transport carries Digiflazz calls; nil builds a dedicated pooled transport.
+	Transport http.RoundTripper
+}
+
+// Client talks to the Digiflazz buyer API.
+type Client struct {
+	logger   *slog.Logger
+	username string
+	apiKey   string
+	baseURL  string
+	skus     map[string]string
+	priceTTL time.Duration
+	http     *http.Client
+
+	mu        sync.Mutex
+	prices    []atl.PriceListItem
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+// New creates a Digiflazz client.
+func New(cfg Config, logger *slog.Logger) *Client {
+	base := defaultBaseURL
+	if cfg.BaseURL != "" {
+		base = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	ttl := cfg.PriceTTL
+	if ttl <= 0 {
+		ttl = defaultPriceTTL
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = httpx.NewTransport(httpx.TransportConfig{})
+	}
+	skus := make(map[string]string, len(cfg.SKUs))
+	for code, sku := range cfg.SKUs {
+		skus[strings.ToUpper(code)] = sku
+	}
+	return &Client{
+		logger:   logger.With("component", "digiflazz"),
+		username: cfg.Username,
+		apiKey:   cfg.APIKey,
+		baseURL:  base,
+		skus:     skus,
+		priceTTL: ttl,
+		http:     &http.Client{Timeout: timeout, Transport: transport},
+		now:      time.Now,
+	}
+}
+
+// Name satisfies supplier.Supplier.
+func (c *Client) Name() string { return Name }
+
+// Available satisfies supplier.Supplier; Digiflazz has no breaker, so its
+// failures show up as errors instead.
+func (c *Client) Available() bool { return true }
+
+// sku is the Digiflazz SKU for an Atlantic product code.
+func (c *Client) sku(productCode string) string {
+	if sku, ok := c.skus[strings.ToUpper(productCode)]; ok {
+		return sku
+	}
+	return productCode
+}
+
+// sign is the request signature: the MD5 of username, API key and suffix.
+func (c *Client) sign(suffix string) string {
+	sum := md5.Sum([]byte(c.username + c.apiKey + suffix))
+	return hex.EncodeToString(sum[:])
+}
+
+type priceItem struct {
+	ProductName         string  `json:"product_name"`
+	Category            string  `json:"category"`
+	Brand               string  `json:"brand"`
+	Price               float64 `json:"price"`
+	BuyerSKUCode        string  `json:"buyer_sku_code"`
+	BuyerProductStatus  bool    `json:"buyer_product_status"`
+	SellerProductStatus bool    `json:"seller_product_status"`
+	Desc                string  `json:"desc"`
+}
+
+// PriceList returns the prepaid price list, keyed by buyer SKU code and
+// reused for the price TTL unless forceRefresh is set.
+func (c *Client) PriceList(ctx context.Context, forceRefresh bool) ([]atl.PriceListItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !forceRefresh && c.prices != nil && c.now().Sub(c.fetchedAt) < c.priceTTL {
+		return c.prices, nil
+	}
+	var raw []priceItem
+	err := c.post(ctx, "/price-list", map[string]any{
+		"cmd":      "prepaid",
+		"username": c.username,
+		"sign":     c.sign("pricelist"),
+	}, &raw)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]atl.PriceListItem, 0, len(raw))
+	for _, p := range raw {
+		status := "available"
+		if !p.BuyerProductStatus || !p.SellerProductStatus {
+			status = "empty"
+		}
+		items = append(items, atl.PriceListItem{
+			Code:        p.BuyerSKUCode,
+			Name:        p.ProductName,
+			Category:    p.Category,
+			Provider:    p.Brand,
+			Price:       money.FromRupiah(int64(p.Price)),
+			Status:      status,
+			Description: p.Desc,
+		})
+	}
+	c.prices, c.fetchedAt = items, c.now()
+	return items, nil
+}
+
+// Quote satisfies supplier.Supplier.
+func (c *Client) Quote(ctx context.Context, productCode string) (money.Money, bool, error) {
+	items, err := c.PriceList(ctx, false)
+	if err != nil {
+		return 0, false, err
+	}
+	sku := c.sku(productCode)
+	for _, item := range items {
+		if strings.EqualFold(item.Code, sku) {
+			return item.Price, item.Status == "available", nil
+		}
+	}
+	return 0, false, nil
+}
+
+type transaction struct {
+	RefID        string  `json:"ref_id"`
+	CustomerNo   string  `json:"customer_no"`
+	BuyerSKUCode string  `json:"buyer_sku_code"`
+	Message      string  `json:"message"`
+	Status       string  `json:"status"`
+	RC           string  `json:"rc"`
+	SN           string  `json:"sn"`
+	Price        float64 `json:"price"`
+}
+
+// normalizeStatus maps Digiflazz transaction statuses to Atlantic's.
+func normalizeStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "sukses":
+		return "success"
+	case "pending":
+		return "pending"
+	case "gagal":
+		return "failed"
+	case "":
+		return "unknown"
+	default:
+		return strings.ToLower(strings.TrimSpace(status))
+	}
+}
+
+// transact places a transaction, or reports on the one already placed
+// under the same ref ID, which is how Digiflazz checks status.
+func (c *Client) transact(ctx context.Context, productCode, customerID, refID string, maxPrice int64) (*transaction, map[string]any, error) {
+	body := map[string]any{
+		"username":       c.username,
+		"buyer_sku_code": c.sku(productCode),
+		"customer_no":    customerID,
+		"ref_id":         refID,
+		"sign":           c.sign(refID),
+	}
+	if maxPrice > 0 {
+		body["max_price"] = maxPrice
+	}
+	var tx transaction
+	if err := c.post(ctx, "/transaction", body, &tx); err != nil {
+		return nil, nil, err
+	}
+	raw := map[string]any{}
+	if encoded, err := json.Marshal(tx); err == nil {
+		_ = json.Unmarshal(encoded, &raw)
+	}
+	return &tx, raw, nil
+}
+
+// CreatePrepaidTransaction buys req.ProductCode for req.CustomerID. A
+// refused purchase comes back with status failed, as with Atlantic.
+func (c *Client) CreatePrepaidTransaction(ctx context.Context, req atl.CreatePrepaidRequest) (*atl.TransactionResponse, error) {
+	tx, raw, err := c.transact(ctx, req.ProductCode, req.CustomerID, req.RefID, req.LimitPrice)
+	if err != nil {
+		return nil, err
+	}
+	return &atl.TransactionResponse{
+		RefID:   tx.RefID,
+		Status:  normalizeStatus(tx.Status),
+		Message: tx.Message,
+		SN:      tx.SN,
+		Raw:     raw,
+	}, nil
+}
+
+// TransactionStatus checks a transaction by resending it with the same ref
+// ID, so req must carry the product code and customer ID it was placed with.
+func (c *Client) TransactionStatus(ctx context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error) {
+	if req.RefID == "" || req.ProductCode == "" || req.CustomerID == "" {
+		return nil, fmt.Errorf("digiflazz status check needs ref id, product code and customer id")
+	}
+	tx, raw, err := c.transact(ctx, req.ProductCode, req.CustomerID, req.RefID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &atl.TransactionStatusResponse{
+		RefID:        tx.RefID,
+		Status:       normalizeStatus(tx.Status),
+		Message:      tx.Message,
+		ResponseCode: tx.RC,
+		SN:           tx.SN,
+		Raw:          raw,
+	}, nil
+}
+
+// post sends body to path and decodes the response's data into dest.
+// Digiflazz answers refused transactions with a 4xx status and the usual
+// body, so those are decoded too; an error body without a status is
+// returned as an error.
+func (c *Client) post(ctx context.Context, path string, body map[string]any, dest any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("digiflazz request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("digiflazz http %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var env struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &env); err != nil || len(env.Data) == 0 {
+		return fmt.Errorf("digiflazz http %d: unexpected response %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var failure struct {
+		RC      string `json:"rc"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	}
+	if env.Data[0] == '{' && json.Unmarshal(env.Data, &failure) == nil && failure.Status == "" && failure.RC != "" {
+		return fmt.Errorf("digiflazz %s error: %s (rc=%s)", strings.TrimPrefix(path, "/"), failure.Message, failure.RC)
+	}
+	if err := json.Unmarshal(env.Data, dest); err != nil {
+		return fmt.Errorf("decode response (http %d): %w", resp.StatusCode, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package digiflazz
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(Config{Username: "shop", APIKey: "key", BaseURL: srv.URL, SKUs: map[string]string{"tsel10": "tsel10k"}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestQuoteUsesMappedSKUAndCachesPriceList(t *testing.T) {
+	fetches := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/price-list" || body["sign"] != md5Hex("shopkeypricelist") {
+			t.Errorf("request %s with body %v", r.URL.Path, body)
+		}
+		fetches++
+		_, _ = io.WriteString(w, `{"data":[
+			{"product_name":"Telkomsel 10.000","brand":"TELKOMSEL","price":10150,"buyer_sku_code":"tsel10k","buyer_product_status":true,"seller_product_status":true},
+			{"product_name":"XL 5.000","brand":"XL","price":5100,"buyer_sku_code":"XL5","buyer_product_status":true,"seller_product_status":false}]}`)
+	})
+	ctx := context.Background()
+
+	price, sells, err := c.Quote(ctx, "TSEL10")
+	if err != nil || !sells || price.Rupiah() != 10150 {
+		t.Fatalf("mapped quote = %d, %v, %v", price.Rupiah(), sells, err)
+	}
+	if _, sells, _ := c.Quote(ctx, "XL5"); sells {
+		t.Fatalf("product the seller disabled quoted as sold")
+	}
+	if _, sells, _ := c.Quote(ctx, "ISAT5"); sells {
+		t.Fatalf("unknown product quoted as sold")
+	}
+	if fetches != 1 {
+		t.Fatalf("price list fetched %d times", fetches)
+	}
+	c.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, _, _ = c.Quote(ctx, "TSEL10")
+	if fetches != 2 {
+		t.Fatalf("stale price list reused")
+	}
+}
+
+func TestTransactionsMapStatuses(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["sign"] != md5Hex("shopkey"+body["ref_id"].(string)) {
+			t.Errorf("bad sign for %v", body["ref_id"])
+		}
+		switch body["ref_id"] {
+		case "trx-ok":
+			_, _ = io.WriteString(w, `{"data":{"ref_id":"trx-ok","customer_no":"0812","buyer_sku_code":"tsel10k","message":"Transaksi Sukses","status":"Sukses","rc":"00","sn":"SN123"}}`)
+		case "trx-fail":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"data":{"ref_id":"trx-fail","message":"Saldo tidak cukup","status":"Gagal","rc":"44"}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"data":{"rc":"41","message":"Signature anda salah"}}`)
+		}
+	})
+	ctx := context.Background()
+
+	resp, err := c.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", CustomerID: "0812", RefID: "trx-ok"})
+	if err != nil || resp.Status != "success" || resp.SN != "SN123" || resp.Raw["buyer_sku_code"] != "tsel10k" {
+		t.Fatalf("success = %+v, %v", resp, err)
+	}
+	resp, err = c.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", CustomerID: "0812", RefID: "trx-fail"})
+	if err != nil || resp.Status != "failed" || resp.Message != "Saldo tidak cukup" {
+		t.Fatalf("refused = %+v, %v", resp, err)
+	}
+	if _, err := c.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", CustomerID: "0812", RefID: "trx-bad"}); err == nil {
+		t.Fatalf("expected error for a rejected request")
+	}
+	status, err := c.TransactionStatus(ctx, atl.TransactionStatusRequest{RefID: "trx-ok", ProductCode: "TSEL10", CustomerID: "0812"})
+	if err != nil || status.Status != "success" || status.ResponseCode != "00" {
+		t.Fatalf("status = %+v, %v", status, err)
+	}
+	if _, err := c.TransactionStatus(ctx, atl.TransactionStatusRequest{RefID: "trx-ok"}); err == nil {
+		t.Fatalf("expected error for a status check without the original request")
+	}
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
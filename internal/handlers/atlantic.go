@@ -9,8 +9,14 @@ import (
 	"time"
 
 	"bot-jual/internal/atl"
+	"bot-jual/internal/cache"
 	"bot-jual/internal/metrics"
+	"bot-jual/internal/money"
+	"bot-jual/internal/persona"
+	"bot-jual/internal/receipt"
 	"bot-jual/internal/repo"
+	"bot-jual/internal/supplier"
+	"bot-jual/internal/wa"
 
 	"log/slog"
 
@@ -22,6 +28,11 @@ type Notifier interface {
 	SendText(ctx context.Context, to types.JID, text string) error
 }
 
+// PriceCacheInvalidator drops in-process price list caches.
+type PriceCacheInvalidator interface {
+	InvalidatePriceCache(productTypes ...string)
+}
+
 // AtlanticWebhookProcessor processes Atlantic webhook callbacks.
 type AtlanticWebhookProcessor struct {
 	repo     repo.Repository
@@ -29,95 +40,276 @@ type AtlanticWebhookProcessor struct {
 	metrics  *metrics.Metrics
 	notifier Notifier
 	atl      *atl.Client
+	// suppliers, when set, places auto-fulfilled purchases instead of atl.
+	suppliers *supplier.Router
+	cfg       ProcessorConfig
+	prices    PriceCacheInvalidator
+	dedup     *cache.Redis
+	events    EventNotifier
+}
+
+// ProcessorConfig groups optional knobs for webhook processing.
+type ProcessorConfig struct {
+	AdminJIDs            []string
+	LowSuccessRate       float64
+	LowSuccessMinSamples int64
+	Persona              persona.Persona
+	// CampaignAttributionWindow is how long after a campaign message a
+	// successful order still counts as its conversion. Zero disables it.
+	CampaignAttributionWindow time.Duration
+	// Receipts builds public receipt links for order updates; nil omits them.
+	Receipts *receipt.Links
+	// OrderSLA is how long an order may stay processing before it is
+	// escalated to the admins. Zero disables the check.
+	OrderSLA time.Duration
+	// CurrencySymbolSpace writes amounts as "Rp 10.000" instead of "Rp10.000".
+	CurrencySymbolSpace bool
+	// ReceiptDocuments follows successful order notifications with a PDF
+	// receipt when the notifier can send documents.
+	ReceiptDocuments bool
+	// NotificationMaxAttempts is how often a journaled payment notification
+	// is sent before it is marked dead. Zero means 10.
+	NotificationMaxAttempts int
 }
 
 // NewAtlanticWebhookProcessor constructs processor.
-func NewAtlanticWebhookProcessor(repository repo.Repository, notifier Notifier, metrics *metrics.Metrics, logger *slog.Logger, atlClient *atl.Client) *AtlanticWebhookProcessor {
+func NewAtlanticWebhookProcessor(repository repo.Repository, notifier Notifier, metrics *metrics.Metrics, logger *slog.Logger, atlClient *atl.Client, cfg ProcessorConfig) *AtlanticWebhookProcessor {
 	return &AtlanticWebhookProcessor{
 		repo:     repository,
 		notifier: notifier,
 		metrics:  metrics,
 		logger:   logger.With("component", "atlantic_processor"),
 		atl:      atlClient,
+		cfg:      cfg,
+	}
+}
+
+// EventNotifier publishes order and deposit events to merchant integrations.
+type EventNotifier interface {
+	OrderSucceeded(ctx context.Context, order repo.Order, sn string)
+	DepositPaid(ctx context.Context, dep repo.Deposit)
+}
+
+// SetEventNotifier publishes order.success and deposit.paid as webhook
+// updates complete orders and deposits.
+func (p *AtlanticWebhookProcessor) SetEventNotifier(events EventNotifier) {
+	p.events = events
+}
+
+// SetSupplierRouter places purchases made after a deposit is paid through
+// router, and checks stuck orders with the supplier that took them.
+func (p *AtlanticWebhookProcessor) SetSupplierRouter(router *supplier.Router) {
+	p.suppliers = router
+}
+
+// fulfiller is the supplier router when set, else Atlantic, else nil.
+func (p *AtlanticWebhookProcessor) fulfiller() supplier.Fulfiller {
+	if p.suppliers != nil {
+		return p.suppliers
+	}
+	if p.atl != nil {
+		return p.atl
 	}
+	return nil
+}
+
+// SetPriceCacheInvalidator registers the cache dropped on price update events.
+func (p *AtlanticWebhookProcessor) SetPriceCacheInvalidator(inv PriceCacheInvalidator) {
+	p.prices = inv
 }
 
 // HandleAtlanticEvent satisfies atl.WebhookProcessor.
 func (p *AtlanticWebhookProcessor) HandleAtlanticEvent(ctx context.Context, event atl.WebhookEvent) error {
-	var payload map[string]any
-	if err := json.Unmarshal(event.Payload, &payload); err != nil {
-		p.metrics.Errors.WithLabelValues("atlantic_webhook_decode").Inc()
-		return fmt.Errorf("decode payload: %w", err)
+	if event.Type == atl.EventPriceUpdate {
+		return p.handlePriceUpdate(ctx, event)
 	}
 
-	flattened := flattenPayload(payload)
-	ref := firstString(flattened, "ref_id", "reff_id", "reference", "transaction_ref", "order_ref", "deposit_ref")
-	if ref == "" {
-		p.logger.Error("atlantic webhook missing ref", "event", event.Type, "payload", payload)
+	update := event.Update()
+	if update == nil {
+		p.metrics.Errors.WithLabelValues("atlantic_webhook_decode").Inc()
+		return fmt.Errorf("unparsed webhook event %q", event.Type)
+	}
+	if update.Ref == "" {
+		p.logger.Error("atlantic webhook missing ref", "event", event.Type, "payload", update.Payload)
 		return fmt.Errorf("missing ref_id in payload")
 	}
-	rawStatus := firstString(flattened, "status", "state")
-	status := atl.NormalizeTransactionStatus(rawStatus)
-	message := firstString(flattened, "message", "info", "description")
-	sn := firstString(flattened, "sn", "serial_number")
-	originalStatus := status
+	ctx = wa.WithOrigin(ctx, wa.Origin{Trigger: wa.TriggerWebhook, CorrelationID: update.Ref})
 
 	meta := map[string]any{
-		"payload": payload,
+		"payload": update.Payload,
 		"headers": event.Headers,
 		"event":   event.Type,
 		"status": map[string]any{
-			"raw":        rawStatus,
-			"normalized": status,
+			"raw":        update.RawStatus,
+			"normalized": update.Status,
 		},
 	}
 
-	if shouldForceSuccess(event.Type, status) {
+	release, fresh, err := p.claimEvent(ctx, event.Type, update)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		if p.metrics != nil {
+			p.metrics.WebhookEvents.WithLabelValues("duplicate").Inc()
+		}
+		p.logger.Info("skipping re-delivered atlantic webhook", "event", event.Type, "ref_id", update.Ref, "status", update.Status)
+		return nil
+	}
+	if err := p.applyUpdate(ctx, event, update, meta); err != nil {
+		release()
+		return err
+	}
+	return nil
+}
+
+func (p *AtlanticWebhookProcessor) applyUpdate(ctx context.Context, event atl.WebhookEvent, update *atl.StatusUpdate, meta map[string]any) error {
+	switch {
+	case event.Deposit != nil:
+		return p.handleDepositUpdate(ctx, event.Type, event.Deposit, meta)
+	case event.Transfer != nil:
+		if wd, err := p.repo.GetWithdrawalByRef(ctx, update.Ref); err == nil {
+			if err := p.repo.UpdateWithdrawalStatus(ctx, update.Ref, update.Status, meta); err != nil {
+				return err
+			}
+			p.notifyUser(ctx, wd.UserID, formatWithdrawalStatusMessage(p.userLanguage(ctx, wd.UserID), wd, update.Status, update.Message, p.userLocale(ctx, wd.UserID)))
+			return nil
+		}
+		// Transfers that are not withdrawals are tracked as orders.
+		return p.handleTransactionUpdate(ctx, event.Type, &atl.TransactionUpdate{StatusUpdate: *update}, meta)
+	default:
+		return p.handleTransactionUpdate(ctx, event.Type, event.Transaction, meta)
+	}
+}
+
+func (p *AtlanticWebhookProcessor) handleDepositUpdate(ctx context.Context, eventType string, update *atl.DepositUpdate, meta map[string]any) error {
+	status := update.Status
+	if shouldForceSuccess(eventType, status) {
 		status = "success"
 		meta["forced_success"] = true
-		meta["original_status"] = originalStatus
+		meta["original_status"] = update.Status
 	}
 
-	if strings.Contains(strings.ToLower(event.Type), "deposit") {
-		if err := p.repo.UpdateDepositStatus(ctx, ref, status, meta); err != nil {
-			return err
-		}
-		dep, err := p.repo.GetDepositByRef(ctx, ref)
-		if err != nil {
-			return fmt.Errorf("lookup deposit %s: %w", ref, err)
-		}
+	var previous *repo.Deposit
+	if (p.events != nil && status == "success") || status == "failed" {
+		previous, _ = p.repo.GetDepositByRef(ctx, update.Ref)
+	}
+	if status == "failed" && previous != nil && previous.Status == "failed" {
+		// Already closed here, e.g. expired unpaid before the provider said so.
+		return nil
+	}
+	if err := p.repo.UpdateDepositStatus(ctx, update.Ref, status, meta); err != nil {
+		return err
+	}
+	dep, err := p.repo.GetDepositByRef(ctx, update.Ref)
+	if err != nil {
+		return fmt.Errorf("lookup deposit %s: %w", update.Ref, err)
+	}
+	if p.events != nil && status == "success" && (previous == nil || previous.Status != "success") {
+		p.events.DepositPaid(ctx, *dep)
+	}
 
-		handled := false
-		switch status {
-		case "success":
-			handled = p.handleDepositSuccess(ctx, dep, message)
-		case "failed":
-			handled = p.handleDepositFailure(ctx, dep, message)
+	handled := false
+	switch status {
+	case "success":
+		handled = p.handleDepositSuccess(ctx, dep, update.Message)
+	case "failed":
+		handled = p.handleDepositFailure(ctx, dep, update.Message)
+	}
+
+	if !handled {
+		text := formatDepositStatusMessage(p.userLanguage(ctx, dep.UserID), dep, status, update.Message, p.userLocale(ctx, dep.UserID))
+		if status == "success" {
+			p.notifyUserDurably(ctx, dep.UserID, NotificationDepositPaid, dep.DepositRef, text)
+		} else {
+			p.notifyUser(ctx, dep.UserID, text)
 		}
+	}
+	return nil
+}
 
-		if !handled {
-			p.notifyUser(ctx, dep.UserID, formatDepositStatusMessage(dep, status, message))
+func (p *AtlanticWebhookProcessor) handleTransactionUpdate(ctx context.Context, eventType string, update *atl.TransactionUpdate, meta map[string]any) error {
+	ref := update.Ref
+	status := update.Status
+	if shouldForceSuccess(eventType, status) {
+		status = "success"
+		meta["forced_success"] = true
+		meta["original_status"] = update.Status
+	}
+
+	// The stored metadata is replaced on update, so the webhook fields are
+	// laid over it; what the order was created with (target, PLN customer,
+	// voucher, notes) survives every callback.
+	existing, err := p.repo.GetOrderByRef(ctx, ref)
+	if err == nil {
+		merged := cloneMetadata(existing.Metadata)
+		for key, val := range meta {
+			merged[key] = val
 		}
-		return nil
+		meta = merged
+	}
+	// What the supplier charged us, for profit in the daily sales summary.
+	if update.Price > 0 {
+		meta["supplier_price"] = update.Price
+	}
+	// The SN is what history and receipts show the customer.
+	if update.SN != "" {
+		meta["sn"] = update.SN
 	}
 
-	if err := p.repo.UpdateOrderStatus(ctx, ref, status, meta); err != nil {
+	if status == "success" {
+		// Only the update that moves the order to success reports it; a
+		// webhook and a poll racing on the same order must not both notify.
+		changed, err := p.repo.MarkOrderSucceeded(ctx, ref, meta)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			p.logger.Info("order already succeeded, skipping repeat update", "event", eventType, "order_ref", ref)
+			return nil
+		}
+	} else if err := p.repo.UpdateOrderStatus(ctx, ref, status, meta); err != nil {
 		return err
 	}
+	p.trackProductOutcome(ctx, existing, status)
+	p.attributeCampaignConversion(ctx, existing, status)
 
 	order, err := p.repo.GetOrderByRef(ctx, ref)
 	if err == nil {
+		lang := p.userLanguage(ctx, order.UserID)
 		info := strings.Builder{}
-		info.WriteString(fmt.Sprintf("Update transaksi %s: %s", ref, strings.ToUpper(status)))
-		if message != "" {
+		info.WriteString(localize(lang, msgTransactionUpdate, ref, strings.ToUpper(status)))
+		if update.Message != "" {
 			info.WriteString(". ")
-			info.WriteString(message)
+			info.WriteString(update.Message)
 		}
-		if sn != "" {
+		if update.SN != "" {
 			info.WriteString(". SN: ")
-			info.WriteString(sn)
+			info.WriteString(update.SN)
+		}
+		if customerRef := stringValue(order.Metadata, "customer_ref"); customerRef != "" {
+			info.WriteString(localize(lang, msgCustomerRef, customerRef))
+		}
+		if url := p.cfg.Receipts.URL(ref); url != "" {
+			info.WriteString("\n")
+			info.WriteString(localize(lang, msgReceiptLine, url))
+		}
+		if status == "success" {
+			info.WriteString(instructionsSuffix(lang, order.Metadata))
+			p.notifyUserDurably(quoteOrder(ctx, order.Metadata), order.UserID, NotificationOrderSuccess, ref, info.String())
+		} else {
+			p.notifyUser(quoteOrder(ctx, order.Metadata), order.UserID, info.String())
+		}
+		if status == "success" && p.events != nil {
+			p.events.OrderSucceeded(ctx, *order, update.SN)
+		}
+		if status == "success" {
+			if order.Metadata["customer_id"] == nil && existing != nil {
+				order.Metadata = cloneMetadata(order.Metadata)
+				order.Metadata["customer_id"] = existing.Metadata["customer_id"]
+			}
+			p.sendReceiptDocument(ctx, order, update.SN)
 		}
-		p.notifyUser(ctx, order.UserID, info.String())
 	}
 
 	return nil
@@ -141,75 +333,44 @@ func (p *AtlanticWebhookProcessor) notifyUser(ctx context.Context, userID string
 	if p.notifier == nil {
 		return
 	}
+	jid, ok := p.userJID(ctx, userID)
+	if !ok {
+		return
+	}
+	ctx = wa.WithOrigin(ctx, wa.Origin{UserID: userID})
+	if err := p.notifier.SendText(ctx, jid, p.cfg.Persona.Apply(text)); err != nil {
+		p.logger.Warn("failed sending notification", "error", err)
+	}
+}
+
+// userJID resolves where notifications for userID go.
+func (p *AtlanticWebhookProcessor) userJID(ctx context.Context, userID string) (types.JID, bool) {
 	user, err := p.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		p.logger.Warn("failed fetching user for notification", "error", err, "user_id", userID)
-		return
+		return types.JID{}, false
 	}
 	if user.WAJID == nil || *user.WAJID == "" {
-		return
+		return types.JID{}, false
 	}
 	jid, err := types.ParseJID(*user.WAJID)
 	if err != nil {
 		p.logger.Warn("invalid user jid", "error", err, "jid", *user.WAJID)
-		return
-	}
-	if err := p.notifier.SendText(ctx, jid, text); err != nil {
-		p.logger.Warn("failed sending notification", "error", err)
-	}
-}
-
-func flattenPayload(payload map[string]any) map[string]any {
-	if payload == nil {
-		return map[string]any{}
-	}
-	flat := make(map[string]any, len(payload)+8)
-	for k, v := range payload {
-		flat[k] = v
-	}
-	dataRaw, ok := payload["data"]
-	if !ok || dataRaw == nil {
-		return flat
+		return types.JID{}, false
 	}
-	switch data := dataRaw.(type) {
-	case map[string]any:
-		for k, v := range data {
-			if _, exists := flat[k]; !exists {
-				flat[k] = v
-			}
-		}
-	case string:
-		var nested map[string]any
-		if err := json.Unmarshal([]byte(data), &nested); err == nil {
-			for k, v := range nested {
-				if _, exists := flat[k]; !exists {
-					flat[k] = v
-				}
-			}
-		}
-	}
-	return flat
+	return jid, true
 }
 
-func firstString(payload map[string]any, keys ...string) string {
-	for _, key := range keys {
-		if val, ok := payload[key]; ok {
-			switch v := val.(type) {
-			case string:
-				if strings.TrimSpace(v) != "" {
-					return v
-				}
-			case float64:
-				return fmt.Sprintf("%.0f", v)
-			case json.Number:
-				return v.String()
-			}
-		}
+// quoteOrder makes a notification about an order quote the message that placed
+// it, so users with several orders in flight can tell which one it is about.
+func quoteOrder(ctx context.Context, meta map[string]any) context.Context {
+	if ref, ok := wa.ReplyRefFromMetadata(meta); ok {
+		return wa.WithReplyRef(ctx, ref)
 	}
-	return ""
+	return ctx
 }
 
-func formatDepositStatusMessage(dep *repo.Deposit, status, message string) string {
+func formatDepositStatusMessage(lang string, dep *repo.Deposit, status, message string, locale money.Locale) string {
 	var ref string
 	if dep != nil && strings.TrimSpace(dep.DepositRef) != "" {
 		ref = dep.DepositRef
@@ -218,22 +379,40 @@ func formatDepositStatusMessage(dep *repo.Deposit, status, message string) strin
 	}
 	stat := strings.ToUpper(strings.TrimSpace(status))
 	if stat == "" || stat == "UNKNOWN" {
-		stat = "STATUS TIDAK DIKETAHUI"
+		stat = localize(lang, msgStatusUnknown)
 	}
-	base := fmt.Sprintf("Update deposit %s: %s", ref, stat)
+	base := localize(lang, msgDepositUpdate, ref, stat)
 	if strings.TrimSpace(message) != "" {
 		base = fmt.Sprintf("%s. %s", base, message)
 	} else {
 		base += "."
 	}
-	if summary := depositSummary(dep); summary != "" {
+	if summary := depositSummary(lang, dep, locale); summary != "" {
 		return fmt.Sprintf("%s\n%s", base, summary)
 	}
 	return base
 }
 
+func formatWithdrawalStatusMessage(lang string, wd *repo.Withdrawal, status, message string, locale money.Locale) string {
+	stat := strings.ToUpper(strings.TrimSpace(status))
+	if stat == "" || stat == "UNKNOWN" {
+		stat = localize(lang, msgStatusUnknown)
+	}
+	base := localize(lang, msgWithdrawalUpdate, wd.WithdrawalRef, stat)
+	if strings.TrimSpace(message) != "" {
+		base = fmt.Sprintf("%s. %s", base, message)
+	} else {
+		base += "."
+	}
+	detail := localize(lang, msgWithdrawalDetail, money.FromRupiah(wd.Amount).Format(locale), strings.ToUpper(wd.BankCode), wd.AccountNo)
+	if strings.EqualFold(status, "failed") {
+		detail += localize(lang, msgWithdrawalRefunded)
+	}
+	return fmt.Sprintf("%s\n%s", base, detail)
+}
+
 func (p *AtlanticWebhookProcessor) handleDepositSuccess(ctx context.Context, dep *repo.Deposit, depositMessage string) bool {
-	if p.atl == nil {
+	if p.fulfiller() == nil {
 		p.logger.Warn("atlantic client unavailable for auto-fulfill", "deposit_ref", dep.DepositRef)
 		return false
 	}
@@ -245,6 +424,16 @@ func (p *AtlanticWebhookProcessor) handleDepositSuccess(ctx context.Context, dep
 	if len(orders) == 0 {
 		return false
 	}
+	// A batch shares one deposit, so it has to cover every order before any
+	// of them is fulfilled.
+	var required int64
+	for _, order := range orders {
+		required += order.Amount
+	}
+	if availableNet := numberFromMetadata(dep.Metadata, "net_amount"); availableNet > 0 && required > 0 && availableNet < required {
+		p.holdOrdersForShortfall(ctx, dep, orders, availableNet, required)
+		return true
+	}
 	handled := false
 	for _, order := range orders {
 		if p.autoFulfillOrderAfterDeposit(ctx, dep, order, depositMessage) {
@@ -263,7 +452,8 @@ func (p *AtlanticWebhookProcessor) handleDepositFailure(ctx context.Context, dep
 	if len(orders) == 0 {
 		return false
 	}
-	statusText := formatDepositStatusMessage(dep, "failed", failureMessage)
+	lang := p.userLanguage(ctx, dep.UserID)
+	statusText := formatDepositStatusMessage(lang, dep, "failed", failureMessage, p.userLocale(ctx, dep.UserID))
 	for _, order := range orders {
 		meta := cloneMetadata(order.Metadata)
 		meta["deposit_ref"] = dep.DepositRef
@@ -274,34 +464,39 @@ func (p *AtlanticWebhookProcessor) handleDepositFailure(ctx context.Context, dep
 		if err := p.repo.UpdateOrderStatus(ctx, order.OrderRef, "failed", meta); err != nil {
 			p.logger.Error("update order after deposit failure", "error", err, "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
 		}
-		msg := fmt.Sprintf("%s\nPesanan %s dibatalkan. Silakan buat ulang jika masih ingin melanjutkan.", statusText, order.OrderRef)
-		p.notifyUser(ctx, order.UserID, msg)
+		msg := statusText + "\n" + localize(lang, msgDepositFailedOrder, order.OrderRef)
+		p.notifyUser(quoteOrder(ctx, order.Metadata), order.UserID, msg)
 	}
 	return true
 }
 
-func (p *AtlanticWebhookProcessor) autoFulfillOrderAfterDeposit(ctx context.Context, dep *repo.Deposit, order repo.Order, depositMessage string) bool {
-	customerID := stringValue(order.Metadata, "customer_id")
-	if customerID == "" {
-		p.logger.Warn("order missing customer id for auto-fulfill", "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
-		p.notifyUser(ctx, order.UserID, fmt.Sprintf("Deposit %s diterima, tapi data tujuan untuk pesanan %s belum lengkap. Hubungi admin ya.", dep.DepositRef, order.OrderRef))
-		return true
-	}
-	availableNet := numberFromMetadata(dep.Metadata, "net_amount")
-	expected := order.Amount
-	if availableNet > 0 && expected > 0 && availableNet < expected {
-		diff := expected - availableNet
+// holdOrdersForShortfall keeps the orders of a deposit that paid less than
+// required waiting for payment and tells the user how much is missing.
+func (p *AtlanticWebhookProcessor) holdOrdersForShortfall(ctx context.Context, dep *repo.Deposit, orders []repo.Order, availableNet, required int64) {
+	refs := make([]string, 0, len(orders))
+	for _, order := range orders {
 		meta := cloneMetadata(order.Metadata)
 		meta["deposit_ref"] = dep.DepositRef
 		meta["auto_fulfilled"] = false
 		meta["auto_fulfill_error"] = "insufficient_net_amount"
 		meta["net_amount_available"] = availableNet
-		meta["required_amount"] = expected
+		meta["required_amount"] = required
 		if err := p.repo.UpdateOrderStatus(ctx, order.OrderRef, "awaiting_payment", meta); err != nil {
 			p.logger.Error("update order insufficient deposit", "error", err, "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
 		}
-		msg := fmt.Sprintf("Deposit %s sudah masuk %s, tapi masih kurang %s untuk transaksi %s. Tambah deposit ya supaya bisa ku proses.", dep.DepositRef, formatIDR(availableNet), formatIDR(diff), order.OrderRef)
-		p.notifyUser(ctx, order.UserID, msg)
+		refs = append(refs, order.OrderRef)
+	}
+	first := orders[0]
+	locale := p.userLocale(ctx, first.UserID)
+	msg := localize(p.userLanguage(ctx, first.UserID), msgDepositShortfall, dep.DepositRef, money.FromRupiah(availableNet).Format(locale), money.FromRupiah(required-availableNet).Format(locale), strings.Join(refs, ", "))
+	p.notifyUser(quoteOrder(ctx, first.Metadata), first.UserID, msg)
+}
+
+func (p *AtlanticWebhookProcessor) autoFulfillOrderAfterDeposit(ctx context.Context, dep *repo.Deposit, order repo.Order, depositMessage string) bool {
+	customerID := stringValue(order.Metadata, "customer_id")
+	if customerID == "" {
+		p.logger.Warn("order missing customer id for auto-fulfill", "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
+		p.notifyUser(quoteOrder(ctx, order.Metadata), order.UserID, localize(p.userLanguage(ctx, order.UserID), msgDepositMissingTarget, dep.DepositRef, order.OrderRef))
 		return true
 	}
 	candidates := targetCandidatesFromMetadata(order.Metadata)
@@ -318,10 +513,11 @@ func (p *AtlanticWebhookProcessor) autoFulfillOrderAfterDeposit(ctx context.Cont
 		if attempt == "" {
 			continue
 		}
-		r, err := p.atl.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{
+		r, err := p.fulfiller().CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{
 			ProductCode: order.ProductCode,
 			CustomerID:  attempt,
 			RefID:       order.OrderRef,
+			Note:        atl.FormatTransactionNote(stringValue(order.Metadata, "customer_ref"), stringValue(order.Metadata, "note")),
 		})
 		if err != nil {
 			lastErr = err
@@ -355,7 +551,7 @@ func (p *AtlanticWebhookProcessor) autoFulfillOrderAfterDeposit(ctx context.Cont
 			if err := p.repo.UpdateOrderStatus(ctx, order.OrderRef, "failed", meta); err != nil {
 				p.logger.Error("update order after auto-fulfill failure", "error", err, "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
 			}
-			p.notifyUser(ctx, order.UserID, fmt.Sprintf("Deposit %s sudah diterima, tapi transaksi %s gagal dibuat: %v. Tolong hubungi admin ya.", dep.DepositRef, order.OrderRef, err))
+			p.notifyUser(quoteOrder(ctx, order.Metadata), order.UserID, localize(p.userLanguage(ctx, order.UserID), msgAutoFulfillFailed, dep.DepositRef, order.OrderRef, err))
 			return true
 		}
 		return true
@@ -377,31 +573,62 @@ func (p *AtlanticWebhookProcessor) autoFulfillOrderAfterDeposit(ctx context.Cont
 	if resp.Raw != nil {
 		meta["transaction_raw"] = resp.Raw
 	}
+	if resp.Supplier != "" {
+		meta["supplier"] = resp.Supplier
+	}
 	if err := p.repo.UpdateOrderStatus(ctx, order.OrderRef, resp.Status, meta); err != nil {
 		p.logger.Error("update order after auto-fulfill success", "error", err, "order_ref", order.OrderRef, "deposit_ref", dep.DepositRef)
 	}
 
+	lang := p.userLanguage(ctx, order.UserID)
 	var lines []string
-	lines = append(lines, fmt.Sprintf("Deposit %s sudah diterima.", dep.DepositRef))
-	if summary := depositSummary(dep); summary != "" {
+	lines = append(lines, localize(lang, msgDepositReceived, dep.DepositRef))
+	if summary := depositSummary(lang, dep, p.userLocale(ctx, order.UserID)); summary != "" {
 		lines = append(lines, summary)
 	}
 	product := productLabel(dep, order)
-	statusLine := fmt.Sprintf("Transaksi %s untuk %s status: %s", order.OrderRef, product, strings.ToUpper(resp.Status))
+	statusLine := localize(lang, msgAutoFulfillStatus, order.OrderRef, product, strings.ToUpper(resp.Status))
 	if resp.Message != "" {
 		statusLine = fmt.Sprintf("%s. %s", statusLine, resp.Message)
 	} else {
 		statusLine += "."
 	}
 	lines = append(lines, statusLine)
-	lines = append(lines, fmt.Sprintf("Tujuan: %s", customerID))
+	lines = append(lines, localize(lang, msgTargetLine, customerID))
 	if resp.SN != "" {
 		lines = append(lines, fmt.Sprintf("SN: %s", resp.SN))
 	}
-	p.notifyUser(ctx, order.UserID, strings.Join(lines, "\n"))
+	if url := p.cfg.Receipts.URL(order.OrderRef); url != "" {
+		lines = append(lines, localize(lang, msgReceiptLine, url))
+	}
+	msg := strings.Join(lines, "\n")
+	success := strings.EqualFold(resp.Status, "success")
+	kind, ref := NotificationDepositPaid, dep.DepositRef
+	if success {
+		msg += instructionsSuffix(lang, order.Metadata)
+		kind, ref = NotificationOrderSuccess, order.OrderRef
+	}
+	p.notifyUserDurably(quoteOrder(ctx, order.Metadata), order.UserID, kind, ref, msg)
+	if success {
+		fulfilled := order
+		fulfilled.Status = resp.Status
+		fulfilled.Metadata = meta
+		if p.events != nil {
+			p.events.OrderSucceeded(ctx, fulfilled, resp.SN)
+		}
+		p.sendReceiptDocument(ctx, &fulfilled, resp.SN)
+	}
 	return true
 }
 
+// instructionsSuffix renders the purchase instructions recorded on an order.
+func instructionsSuffix(lang string, meta map[string]any) string {
+	if text := stringValue(meta, "instructions"); text != "" {
+		return "\n\n" + localize(lang, msgProductInfo) + "\n" + text
+	}
+	return ""
+}
+
 func cloneMetadata(src map[string]any) map[string]any {
 	if len(src) == 0 {
 		return map[string]any{}
@@ -431,19 +658,21 @@ func stringValue(meta map[string]any, key string) string {
 	}
 }
 
+// productLabel names the product of an order paid by dep. Orders checked
+// out from a cart share a deposit, so their own product comes first.
 func productLabel(dep *repo.Deposit, order repo.Order) string {
+	if name := stringValue(order.Metadata, "product"); name != "" {
+		return name
+	}
 	if dep != nil && dep.Metadata != nil {
 		if name := stringValue(dep.Metadata, "product"); name != "" {
 			return name
 		}
 	}
-	if name := stringValue(order.Metadata, "product"); name != "" {
-		return name
-	}
 	return order.ProductCode
 }
 
-func depositSummary(dep *repo.Deposit) string {
+func depositSummary(lang string, dep *repo.Deposit, locale money.Locale) string {
 	if dep == nil {
 		return ""
 	}
@@ -468,17 +697,17 @@ func depositSummary(dep *repo.Deposit) string {
 	if net == 0 {
 		net = numberFromMetadata(dep.Metadata, "saldo_masuk")
 	}
-	summary := summarizeAmounts(gross, fee, net)
+	summary := summarizeAmounts(lang, gross, fee, net, locale)
 	if summary == "" {
 		return summary
 	}
 	if shortfall := numberFromMetadata(dep.Metadata, "net_shortfall"); shortfall > 0 {
-		summary = fmt.Sprintf("%s | Kekurangan: %s", summary, formatIDR(shortfall))
+		summary += " | " + localize(lang, msgAmountShortfall, money.FromRupiah(shortfall).Format(locale))
 	}
 	return summary
 }
 
-func summarizeAmounts(gross, fee, net int64) string {
+func summarizeAmounts(lang string, gross, fee, net int64, locale money.Locale) string {
 	if gross <= 0 && net <= 0 {
 		return ""
 	}
@@ -490,13 +719,13 @@ func summarizeAmounts(gross, fee, net int64) string {
 	}
 	parts := make([]string, 0, 3)
 	if gross > 0 {
-		parts = append(parts, fmt.Sprintf("Tagihan: %s", formatIDR(gross)))
+		parts = append(parts, localize(lang, msgAmountBilled, money.FromRupiah(gross).Format(locale)))
 	}
 	if fee > 0 {
-		parts = append(parts, fmt.Sprintf("Biaya: %s", formatIDR(fee)))
+		parts = append(parts, localize(lang, msgAmountFee, money.FromRupiah(fee).Format(locale)))
 	}
 	if net > 0 {
-		parts = append(parts, fmt.Sprintf("Saldo masuk: %s", formatIDR(net)))
+		parts = append(parts, localize(lang, msgAmountCredited, money.FromRupiah(net).Format(locale)))
 	}
 	if len(parts) == 0 {
 		return ""
@@ -554,11 +783,22 @@ func numberFromMetadata(meta map[string]any, key string) int64 {
 	return 0
 }
 
-func formatIDR(value int64) string {
-	if value <= 0 {
-		return "Rp0"
+// userLocale is how amounts are written for the user, falling back to the
+// shop default when the user cannot be loaded.
+func (p *AtlanticWebhookProcessor) userLocale(ctx context.Context, userID string) money.Locale {
+	tag := ""
+	if user, err := p.repo.GetUserByID(ctx, userID); err == nil {
+		tag = user.NumberLocale()
+	}
+	return money.LocaleFor(tag, p.cfg.CurrencySymbolSpace)
+}
+
+// userZone is the zone timestamps sent to the user are rendered in.
+func (p *AtlanticWebhookProcessor) userZone(ctx context.Context, userID string) *time.Location {
+	if user, err := p.repo.GetUserByID(ctx, userID); err == nil {
+		return user.Location()
 	}
-	return fmt.Sprintf("Rp%d", value)
+	return repo.DefaultZone
 }
 
 func targetCandidatesFromMetadata(meta map[string]any) []string {
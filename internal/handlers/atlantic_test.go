@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+// orderRepo stores metadata as given, like the COALESCE in UpdateOrderStatus.
+type orderRepo struct {
+	repo.Repository
+	order repo.Order
+}
+
+func (r *orderRepo) GetOrderByRef(_ context.Context, ref string) (*repo.Order, error) {
+	if ref != r.order.OrderRef {
+		return nil, repo.ErrNotFound
+	}
+	order := r.order
+	return &order, nil
+}
+
+func (r *orderRepo) UpdateOrderStatus(_ context.Context, _ string, status string, meta map[string]any) error {
+	r.order.Status, r.order.Metadata = status, meta
+	return nil
+}
+
+func (r *orderRepo) MarkOrderSucceeded(ctx context.Context, ref string, meta map[string]any) (bool, error) {
+	if r.order.Status == "success" {
+		return false, nil
+	}
+	return true, r.UpdateOrderStatus(ctx, ref, "success", meta)
+}
+
+func (r *orderRepo) ClaimWebhookEvent(context.Context, string, string, string) (bool, error) {
+	return true, nil
+}
+
+func (r *orderRepo) RecordProductOutcome(_ context.Context, code, _ string, _ bool) (*repo.ProductStat, error) {
+	return &repo.ProductStat{ProductCode: code}, nil
+}
+
+func (r *orderRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "628111@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+func (r *orderRepo) InsertNotification(_ context.Context, n repo.Notification) (*repo.Notification, error) {
+	return &n, nil
+}
+
+func (r *orderRepo) UpdateNotification(context.Context, repo.Notification) error {
+	return nil
+}
+
+func TestTransactionWebhookKeepsOrderMetadata(t *testing.T) {
+	repository := &orderRepo{order: repo.Order{OrderRef: "trx-1", UserID: "u1", ProductCode: "PLN20", Status: "pending", Metadata: map[string]any{
+		"customer_id":       "12345678901",
+		"pln_meter":         "12345678901",
+		"pln_customer_name": "BUDI SANTOSO",
+		"pln_tariff":        "R1/900VA",
+		"voucher_code":      "HEMAT5",
+	}}}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{})
+	ctx := context.Background()
+
+	for _, status := range []string{"processing", "success"} {
+		err := p.HandleAtlanticEvent(ctx, atl.WebhookEvent{Type: "transaksi", Transaction: &atl.TransactionUpdate{
+			StatusUpdate: atl.StatusUpdate{Ref: "trx-1", RawStatus: status, Status: status},
+			SN:           "1234-5678-9012-3456",
+		}})
+		if err != nil {
+			t.Fatalf("%s: %v", status, err)
+		}
+	}
+
+	meta := repository.order.Metadata
+	for key, want := range map[string]string{
+		"customer_id":       "12345678901",
+		"pln_meter":         "12345678901",
+		"pln_customer_name": "BUDI SANTOSO",
+		"pln_tariff":        "R1/900VA",
+		"voucher_code":      "HEMAT5",
+		"sn":                "1234-5678-9012-3456",
+	} {
+		if got := stringValue(meta, key); got != want {
+			t.Errorf("%s after webhooks = %q, want %q", key, got, want)
+		}
+	}
+	if repository.order.Status != "success" || meta["event"] != "transaksi" {
+		t.Fatalf("order = %+v", repository.order)
+	}
+}
+
+func TestRepeatedSuccessNotifiesOnce(t *testing.T) {
+	repository := &orderRepo{order: repo.Order{OrderRef: "trx-1", UserID: "u1", ProductCode: "PLN20", Status: "processing", Metadata: map[string]any{}}}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{})
+	ctx := context.Background()
+
+	// The webhook and the pending poller both report the same success.
+	for _, eventType := range []string{"transaksi", "transaction_poll"} {
+		err := p.HandleAtlanticEvent(ctx, atl.WebhookEvent{Type: eventType, Transaction: &atl.TransactionUpdate{
+			StatusUpdate: atl.StatusUpdate{Ref: "trx-1", RawStatus: "success", Status: "success"},
+			SN:           "1234",
+		}})
+		if err != nil {
+			t.Fatalf("%s: %v", eventType, err)
+		}
+	}
+	if msgs := notifier.sent["628111"]; len(msgs) != 1 {
+		t.Fatalf("customer notified %d times: %q", len(msgs), msgs)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+// attributeCampaignConversion credits a newly successful order to the
+// campaign message the user received most recently within the window.
+func (p *AtlanticWebhookProcessor) attributeCampaignConversion(ctx context.Context, previous *repo.Order, status string) {
+	if previous == nil || status != "success" || p.cfg.CampaignAttributionWindow <= 0 {
+		return
+	}
+	// Only the first success of an order counts as a conversion.
+	if previous.Status == "success" {
+		return
+	}
+	since := time.Now().Add(-p.cfg.CampaignAttributionWindow)
+	attributed, err := p.repo.AttributeCampaignConversion(ctx, previous.UserID, previous.OrderRef, since)
+	if err != nil {
+		p.logger.Warn("failed attributing campaign conversion", "error", err, "order_ref", previous.OrderRef)
+		return
+	}
+	if attributed {
+		p.logger.Info("order attributed to campaign", "order_ref", previous.OrderRef, "user_id", previous.UserID)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+// StoreDeadLetter satisfies atl.DeadLetterStore.
+func (p *AtlanticWebhookProcessor) StoreDeadLetter(ctx context.Context, event atl.WebhookEvent, procErr error) error {
+	dl, err := p.repo.InsertWebhookDeadLetter(ctx, repo.WebhookDeadLetter{
+		Source:    "atlantic",
+		EventType: event.Type,
+		Headers:   event.Headers,
+		Payload:   event.Payload,
+		Error:     procErr.Error(),
+	})
+	if err != nil {
+		return err
+	}
+	p.logger.Warn("webhook event dead-lettered", "id", dl.ID, "event", event.Type, "error", procErr)
+	return nil
+}
+
+// deadLetterReplayLease is how long a replay may hold a dead letter before
+// another replay may take it over, e.g. after a crash mid-replay.
+const deadLetterReplayLease = 5 * time.Minute
+
+// ReplayDeadLetter re-runs a stored event through the processor and records the outcome.
+// The dead letter is claimed first so two concurrent replays cannot both apply it.
+func (p *AtlanticWebhookProcessor) ReplayDeadLetter(ctx context.Context, id string) (*repo.WebhookDeadLetter, error) {
+	claimed, err := p.repo.ClaimWebhookDeadLetter(ctx, id, time.Now().Add(-deadLetterReplayLease))
+	if err != nil {
+		return nil, err
+	}
+	dl, err := p.repo.GetWebhookDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		if dl.Status == repo.DeadLetterReplayed {
+			return dl, nil
+		}
+		return nil, fmt.Errorf("replay dead letter %s: %w", id, repo.ErrDeadLetterReplaying)
+	}
+
+	event, procErr := atl.NewWebhookEvent(dl.EventType, dl.Headers, dl.Payload, dl.CreatedAt)
+	if procErr == nil {
+		procErr = p.HandleAtlanticEvent(ctx, event)
+	}
+	status, lastError := repo.DeadLetterReplayed, ""
+	if procErr != nil {
+		status, lastError = repo.DeadLetterPending, procErr.Error()
+	}
+	if err := p.repo.UpdateWebhookDeadLetter(ctx, id, status, lastError); err != nil {
+		return nil, err
+	}
+	if procErr != nil {
+		return nil, fmt.Errorf("replay dead letter %s: %w", id, procErr)
+	}
+	return p.repo.GetWebhookDeadLetter(ctx, id)
+}
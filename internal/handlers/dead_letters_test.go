@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type deadLetterRepo struct {
+	dedupRepo
+	dl repo.WebhookDeadLetter
+}
+
+func (r *deadLetterRepo) ClaimWebhookDeadLetter(_ context.Context, id string, _ time.Time) (bool, error) {
+	if id != r.dl.ID || r.dl.Status != repo.DeadLetterPending {
+		return false, nil
+	}
+	r.dl.Status = repo.DeadLetterReplaying
+	return true, nil
+}
+
+func (r *deadLetterRepo) GetWebhookDeadLetter(_ context.Context, id string) (*repo.WebhookDeadLetter, error) {
+	if id != r.dl.ID {
+		return nil, repo.ErrNotFound
+	}
+	dl := r.dl
+	return &dl, nil
+}
+
+func (r *deadLetterRepo) UpdateWebhookDeadLetter(_ context.Context, _, status, _ string) error {
+	r.dl.Status = status
+	r.dl.Attempts++
+	return nil
+}
+
+func TestReplayDeadLetterRequiresClaim(t *testing.T) {
+	repository := &deadLetterRepo{
+		dedupRepo: dedupRepo{claims: map[string]bool{}},
+		dl: repo.WebhookDeadLetter{ID: "dl-1", EventType: "transfer", Status: repo.DeadLetterReplaying,
+			Payload: []byte(`{"event":"transfer","data":{"ref_id":"WD-1","status":"success"}}`)},
+	}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{})
+	ctx := context.Background()
+
+	if _, err := p.ReplayDeadLetter(ctx, "dl-1"); !errors.Is(err, repo.ErrDeadLetterReplaying) {
+		t.Fatalf("replay while another runs: err = %v", err)
+	}
+	if repository.updates != 0 {
+		t.Fatalf("updates = %d, want the event left to the running replay", repository.updates)
+	}
+
+	repository.dl.Status = repo.DeadLetterPending
+	dl, err := p.ReplayDeadLetter(ctx, "dl-1")
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if dl.Status != repo.DeadLetterReplayed || repository.updates != 1 {
+		t.Fatalf("status = %s, updates = %d", dl.Status, repository.updates)
+	}
+
+	if _, err := p.ReplayDeadLetter(ctx, "dl-1"); err != nil {
+		t.Fatalf("replay of replayed letter: %v", err)
+	}
+	if repository.updates != 1 || repository.dl.Attempts != 1 {
+		t.Fatalf("replayed letter processed again: updates = %d", repository.updates)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/cache"
+)
+
+// webhookDedupTTL is how long Redis remembers an event. Atlantic re-delivers
+// within minutes; the webhook_events table catches anything later.
+const webhookDedupTTL = 24 * time.Hour
+
+// SetDedupCache lets re-delivered events be recognised in Redis before the
+// webhook_events table is consulted. Without it the table alone is used.
+func (p *AtlanticWebhookProcessor) SetDedupCache(c *cache.Redis) {
+	p.dedup = c
+}
+
+// claimEvent takes an event for processing, keyed on its ref, type and
+// normalised status: a status change is a new event, a repeat of the same
+// status is a re-delivery. fresh is false for events seen before; otherwise
+// release must be called if processing fails, so a retry or dead letter
+// replay is not mistaken for a duplicate.
+func (p *AtlanticWebhookProcessor) claimEvent(ctx context.Context, eventType string, update *atl.StatusUpdate) (release func(), fresh bool, err error) {
+	var lock *cache.Lock
+	if p.dedup != nil && p.dedup.Available() {
+		key := "webhook:event:" + update.Ref + ":" + eventType + ":" + update.Status
+		l, err := p.dedup.Lock(ctx, key, webhookDedupTTL)
+		switch {
+		case err == nil:
+			lock = l
+		case errors.Is(err, cache.ErrLocked):
+			return nil, false, nil
+		default:
+			p.logger.Warn("webhook dedup cache unavailable", "error", err, "ref_id", update.Ref)
+		}
+	}
+	releaseLock := func(ctx context.Context) {
+		if lock == nil {
+			return
+		}
+		if err := lock.Release(ctx); err != nil {
+			p.logger.Warn("failed releasing webhook dedup key", "error", err, "ref_id", update.Ref)
+		}
+	}
+
+	claimed, err := p.repo.ClaimWebhookEvent(ctx, update.Ref, eventType, update.Status)
+	if err != nil {
+		releaseLock(context.WithoutCancel(ctx))
+		return nil, false, err
+	}
+	if !claimed {
+		return nil, false, nil
+	}
+	return func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := p.repo.ReleaseWebhookEvent(ctx, update.Ref, eventType, update.Status); err != nil {
+			p.logger.Warn("failed releasing webhook event", "error", err, "ref_id", update.Ref)
+		}
+		releaseLock(ctx)
+	}, true, nil
+}
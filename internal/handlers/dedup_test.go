@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+type dedupRepo struct {
+	repo.Repository
+	claims  map[string]bool
+	updates int
+	failing bool
+}
+
+func (r *dedupRepo) ClaimWebhookEvent(_ context.Context, ref, eventType, status string) (bool, error) {
+	key := ref + "|" + eventType + "|" + status
+	if r.claims[key] {
+		return false, nil
+	}
+	r.claims[key] = true
+	return true, nil
+}
+
+func (r *dedupRepo) ReleaseWebhookEvent(_ context.Context, ref, eventType, status string) error {
+	delete(r.claims, ref+"|"+eventType+"|"+status)
+	return nil
+}
+
+func (r *dedupRepo) GetWithdrawalByRef(_ context.Context, ref string) (*repo.Withdrawal, error) {
+	return &repo.Withdrawal{UserID: "u1", WithdrawalRef: ref, Amount: 50000}, nil
+}
+
+func (r *dedupRepo) UpdateWithdrawalStatus(context.Context, string, string, map[string]any) error {
+	r.updates++
+	if r.failing {
+		return errors.New("database down")
+	}
+	return nil
+}
+
+func (r *dedupRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "628111@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+func TestHandleAtlanticEventSkipsRedelivery(t *testing.T) {
+	transfer := func(status string) atl.WebhookEvent {
+		return atl.WebhookEvent{Type: "transfer", Transfer: &atl.TransferUpdate{StatusUpdate: atl.StatusUpdate{Ref: "WD-1", RawStatus: status, Status: status}}}
+	}
+	repository := &dedupRepo{claims: map[string]bool{}}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{})
+	ctx := context.Background()
+
+	for _, status := range []string{"pending", "pending", "success", "success"} {
+		if err := p.HandleAtlanticEvent(ctx, transfer(status)); err != nil {
+			t.Fatalf("%s: %v", status, err)
+		}
+	}
+	if repository.updates != 2 {
+		t.Fatalf("updates = %d, want one per distinct status", repository.updates)
+	}
+	if msgs := notifier.sent["628111"]; len(msgs) != 2 {
+		t.Fatalf("notifications = %v, want 2", msgs)
+	}
+
+	// A failed attempt gives its claim back so the retry is processed.
+	repository.failing = true
+	if err := p.HandleAtlanticEvent(ctx, transfer("failed")); err == nil {
+		t.Fatal("expected processing error")
+	}
+	repository.failing = false
+	if err := p.HandleAtlanticEvent(ctx, transfer("failed")); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if repository.updates != 4 {
+		t.Fatalf("updates = %d, want the retry applied", repository.updates)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+)
+
+// Notification languages, matching what repo.User.Language returns. The
+// catalog falls back to Indonesian.
+const (
+	langIndonesian = "id"
+	langEnglish    = "en"
+)
+
+// msgKey names a customer notification in the message catalog.
+type msgKey int
+
+const (
+	msgStatusUnknown msgKey = iota
+	msgDepositUpdate
+	msgWithdrawalUpdate
+	msgWithdrawalDetail
+	msgWithdrawalRefunded
+	msgTransactionUpdate
+	msgCustomerRef
+	msgReceiptLine
+	msgReceiptCaption
+	msgProductInfo
+	msgAmountBilled
+	msgAmountFee
+	msgAmountCredited
+	msgAmountShortfall
+	msgDepositFailedOrder
+	msgDepositShortfall
+	msgDepositMissingTarget
+	msgAutoFulfillFailed
+	msgDepositReceived
+	msgAutoFulfillStatus
+	msgTargetLine
+	msgOrderStuck
+)
+
+var messageCatalog = map[string]map[msgKey]string{
+	langIndonesian: {
+		msgStatusUnknown:        "STATUS TIDAK DIKETAHUI",
+		msgDepositUpdate:        "Update deposit %s: %s",
+		msgWithdrawalUpdate:     "Update tarik saldo %s: %s",
+		msgWithdrawalDetail:     "Nominal %s ke %s %s.",
+		msgWithdrawalRefunded:   " Saldo sudah dikembalikan ke akun kamu.",
+		msgTransactionUpdate:    "Update transaksi %s: %s",
+		msgCustomerRef:          ". Ref kamu: %s",
+		msgReceiptLine:          "Bukti transaksi: %s",
+		msgReceiptCaption:       "Bukti transaksi %s",
+		msgProductInfo:          "Info produk:",
+		msgAmountBilled:         "Tagihan: %s",
+		msgAmountFee:            "Biaya: %s",
+		msgAmountCredited:       "Saldo masuk: %s",
+		msgAmountShortfall:      "Kekurangan: %s",
+		msgDepositFailedOrder:   "Pesanan %s dibatalkan. Balas \"bayar ulang\" untuk QR baru dengan harga yang sama, atau buat pesanan baru.",
+		msgDepositShortfall:     "Deposit %s sudah masuk %s, tapi masih kurang %s untuk transaksi %s. Tambah deposit ya supaya bisa ku proses.",
+		msgDepositMissingTarget: "Deposit %s diterima, tapi data tujuan untuk pesanan %s belum lengkap. Hubungi admin ya.",
+		msgAutoFulfillFailed:    "Deposit %s sudah diterima, tapi transaksi %s gagal dibuat: %v. Tolong hubungi admin ya.",
+		msgDepositReceived:      "Deposit %s sudah diterima.",
+		msgAutoFulfillStatus:    "Transaksi %s untuk %s status: %s",
+		msgTargetLine:           "Tujuan: %s",
+		msgOrderStuck:           "Maaf, transaksi %s (%s) masih diproses supplier dan lebih lama dari biasanya. Admin sudah kami kabari untuk bantu cek, nanti langsung diinfokan begitu ada update. Tidak perlu order ulang ya.",
+	},
+	langEnglish: {
+		msgStatusUnknown:        "UNKNOWN STATUS",
+		msgDepositUpdate:        "Deposit %s update: %s",
+		msgWithdrawalUpdate:     "Withdrawal %s update: %s",
+		msgWithdrawalDetail:     "Amount %s to %s %s.",
+		msgWithdrawalRefunded:   " The balance has been returned to your account.",
+		msgTransactionUpdate:    "Transaction %s update: %s",
+		msgCustomerRef:          ". Your ref: %s",
+		msgReceiptLine:          "Receipt: %s",
+		msgReceiptCaption:       "Receipt %s",
+		msgProductInfo:          "Product info:",
+		msgAmountBilled:         "Amount due: %s",
+		msgAmountFee:            "Fee: %s",
+		msgAmountCredited:       "Credited: %s",
+		msgAmountShortfall:      "Short by: %s",
+		msgDepositFailedOrder:   "Order %s has been cancelled. Reply \"bayar ulang\" for a new QR at the same price, or place a new order.",
+		msgDepositShortfall:     "Deposit %s came in at %s, but it is still %s short for transaction %s. Please add a deposit so I can process it.",
+		msgDepositMissingTarget: "Deposit %s was received, but the destination for order %s is incomplete. Please contact the admin.",
+		msgAutoFulfillFailed:    "Deposit %s was received, but transaction %s could not be created: %v. Please contact the admin.",
+		msgDepositReceived:      "Deposit %s has been received.",
+		msgAutoFulfillStatus:    "Transaction %s for %s status: %s",
+		msgTargetLine:           "Destination: %s",
+		msgOrderStuck:           "Sorry, transaction %s (%s) is still being processed by the supplier and is taking longer than usual. The admin has been told and will check on it; we'll update you as soon as there is news. No need to order again.",
+	},
+}
+
+// localize returns the notification key in lang, formatted with args when
+// given.
+func localize(lang string, key msgKey, args ...any) string {
+	format, ok := messageCatalog[lang][key]
+	if !ok {
+		format = messageCatalog[langIndonesian][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// userLanguage is the language notifications to the user are written in,
+// falling back to Indonesian when the user cannot be loaded.
+func (p *AtlanticWebhookProcessor) userLanguage(ctx context.Context, userID string) string {
+	if user, err := p.repo.GetUserByID(ctx, userID); err == nil {
+		return user.Language()
+	}
+	return langIndonesian
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestMessageCatalogComplete(t *testing.T) {
+	for key, id := range messageCatalog[langIndonesian] {
+		en, ok := messageCatalog[langEnglish][key]
+		if !ok {
+			t.Errorf("message %d has no English version", key)
+			continue
+		}
+		if strings.Count(id, "%") != strings.Count(en, "%") {
+			t.Errorf("message %d takes different arguments: %q vs %q", key, id, en)
+		}
+	}
+}
+
+func TestWithdrawalStatusMessageFollowsLanguage(t *testing.T) {
+	wd := &repo.Withdrawal{WithdrawalRef: "WD-1", Amount: 50000, BankCode: "bca", AccountNo: "123"}
+	got := formatWithdrawalStatusMessage(langEnglish, wd, "failed", "", money.English)
+	want := "Withdrawal WD-1 update: FAILED.\nAmount Rp50,000 to BCA 123. The balance has been returned to your account."
+	if got != want {
+		t.Fatalf("english message = %q, want %q", got, want)
+	}
+	got = formatWithdrawalStatusMessage(langIndonesian, wd, "failed", "", money.Indonesian)
+	want = "Update tarik saldo WD-1: FAILED.\nNominal Rp50.000 ke BCA 123. Saldo sudah dikembalikan ke akun kamu."
+	if got != want {
+		t.Fatalf("indonesian message = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Kinds of journaled notifications.
+const (
+	NotificationDepositPaid  = "deposit_paid"
+	NotificationOrderSuccess = "order_success"
+)
+
+const (
+	notificationRetryInterval = 30 * time.Second
+	notificationBatchSize     = 20
+	// notificationLease keeps a notification from being retried while a
+	// send of it is still in flight.
+	notificationLease      = 2 * time.Minute
+	notificationMaxBackoff = 30 * time.Minute
+)
+
+// notifyUserDurably sends a notification the customer must not miss, such
+// as a paid deposit or an SN. It is journaled first and, if the send fails,
+// retried by RunNotificationRetries until delivered or marked dead.
+func (p *AtlanticWebhookProcessor) notifyUserDurably(ctx context.Context, userID, kind, ref, text string) {
+	if p.notifier == nil {
+		return
+	}
+	jid, ok := p.userJID(ctx, userID)
+	if !ok {
+		return
+	}
+	entry, err := p.repo.InsertNotification(ctx, repo.Notification{
+		UserID:        userID,
+		ChatJID:       jid.String(),
+		Kind:          kind,
+		RefID:         ref,
+		Body:          p.cfg.Persona.Apply(text),
+		NextAttemptAt: time.Now().Add(notificationLease),
+	})
+	if err != nil {
+		// The journal is a safety net, not a gate: still try once.
+		p.logger.Error("failed journaling notification", "error", err, "kind", kind, "ref_id", ref)
+		p.notifyUser(ctx, userID, text)
+		return
+	}
+	p.deliverNotification(ctx, *entry)
+}
+
+// RunNotificationRetries resends journaled notifications whose send failed
+// until ctx is cancelled.
+func (p *AtlanticWebhookProcessor) RunNotificationRetries(ctx context.Context) {
+	ticker := time.NewTicker(notificationRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.retryNotifications(ctx)
+		}
+	}
+}
+
+func (p *AtlanticWebhookProcessor) retryNotifications(ctx context.Context) {
+	due, err := p.repo.ClaimDueNotifications(ctx, time.Now(), notificationLease, notificationBatchSize)
+	if err != nil {
+		p.logger.Warn("failed claiming due notifications", "error", err)
+		return
+	}
+	for _, n := range due {
+		retryCtx := wa.WithOrigin(ctx, wa.Origin{Trigger: wa.TriggerResend, CorrelationID: n.RefID})
+		p.deliverNotification(retryCtx, n)
+	}
+}
+
+func (p *AtlanticWebhookProcessor) deliverNotification(ctx context.Context, n repo.Notification) {
+	logger := p.logger.With("notification_id", n.ID, "kind", n.Kind, "ref_id", n.RefID)
+	jid, err := types.ParseJID(n.ChatJID)
+	if err == nil {
+		err = p.notifier.SendText(wa.WithOrigin(ctx, wa.Origin{UserID: n.UserID}), jid, n.Body)
+	}
+	now := time.Now()
+	n.Attempts++
+	if err == nil {
+		n.Status = repo.NotificationDelivered
+		n.DeliveredAt = &now
+		n.LastError = nil
+	} else {
+		msg := err.Error()
+		n.LastError = &msg
+		if n.Attempts >= p.notificationMaxAttempts() {
+			n.Status = repo.NotificationDead
+			logger.Error("notification undeliverable", "error", err, "attempts", n.Attempts)
+			if p.metrics != nil {
+				p.metrics.Errors.WithLabelValues("notification_dead").Inc()
+			}
+		} else {
+			n.NextAttemptAt = now.Add(notificationBackoff(n.Attempts))
+			logger.Warn("failed sending notification, will retry", "error", err, "attempts", n.Attempts, "next_attempt_at", n.NextAttemptAt)
+		}
+	}
+	if err := p.repo.UpdateNotification(context.WithoutCancel(ctx), n); err != nil {
+		logger.Error("failed recording notification attempt", "error", err)
+	}
+}
+
+func (p *AtlanticWebhookProcessor) notificationMaxAttempts() int {
+	if p.cfg.NotificationMaxAttempts > 0 {
+		return p.cfg.NotificationMaxAttempts
+	}
+	return 10
+}
+
+// notificationBackoff is the wait after the given number of failed sends:
+// 30s, 1m, 2m, ... capped at 30 minutes.
+func notificationBackoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= notificationMaxBackoff {
+			return notificationMaxBackoff
+		}
+	}
+	return wait
+}
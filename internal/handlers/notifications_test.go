@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+type journalRepo struct {
+	repo.Repository
+	journal []repo.Notification
+}
+
+func (r *journalRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "628111@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+func (r *journalRepo) InsertNotification(_ context.Context, n repo.Notification) (*repo.Notification, error) {
+	n.ID = "n1"
+	n.Status = repo.NotificationPending
+	r.journal = append(r.journal, n)
+	return &n, nil
+}
+
+func (r *journalRepo) ClaimDueNotifications(_ context.Context, now time.Time, _ time.Duration, _ int) ([]repo.Notification, error) {
+	var due []repo.Notification
+	for _, n := range r.journal {
+		if n.Status == repo.NotificationPending && !n.NextAttemptAt.After(now) {
+			due = append(due, n)
+		}
+	}
+	return due, nil
+}
+
+func (r *journalRepo) UpdateNotification(_ context.Context, n repo.Notification) error {
+	for i := range r.journal {
+		if r.journal[i].ID == n.ID {
+			r.journal[i] = n
+		}
+	}
+	return nil
+}
+
+// flakyNotifier fails its first failures sends.
+type flakyNotifier struct {
+	failures int
+	sent     []string
+}
+
+func (n *flakyNotifier) SendText(_ context.Context, _ types.JID, text string) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("not connected")
+	}
+	n.sent = append(n.sent, text)
+	return nil
+}
+
+func TestDurableNotificationRetriedUntilDelivered(t *testing.T) {
+	repository := &journalRepo{}
+	notifier := &flakyNotifier{failures: 1}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{})
+	ctx := context.Background()
+
+	p.notifyUserDurably(ctx, "u1", NotificationOrderSuccess, "trx-1", "Update transaksi trx-1: SUCCESS. SN: 123")
+	n := repository.journal[0]
+	if n.Status != repo.NotificationPending || n.Attempts != 1 || n.LastError == nil {
+		t.Fatalf("after failed send = %+v", n)
+	}
+	if n.ChatJID != "628111@s.whatsapp.net" || n.Kind != NotificationOrderSuccess || n.RefID != "trx-1" {
+		t.Fatalf("journal entry = %+v", n)
+	}
+
+	repository.journal[0].NextAttemptAt = time.Now().Add(-time.Second)
+	p.retryNotifications(ctx)
+	n = repository.journal[0]
+	if n.Status != repo.NotificationDelivered || n.Attempts != 2 || n.DeliveredAt == nil || n.LastError != nil {
+		t.Fatalf("after retry = %+v", n)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0] != "Update transaksi trx-1: SUCCESS. SN: 123" {
+		t.Fatalf("sent = %v", notifier.sent)
+	}
+}
+
+func TestDurableNotificationDeadAfterMaxAttempts(t *testing.T) {
+	repository := &journalRepo{}
+	p := NewAtlanticWebhookProcessor(repository, &flakyNotifier{failures: 5}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{NotificationMaxAttempts: 2})
+	ctx := context.Background()
+
+	p.notifyUserDurably(ctx, "u1", NotificationDepositPaid, "dep-1", "Update deposit dep-1: SUCCESS.")
+	repository.journal[0].NextAttemptAt = time.Now().Add(-time.Second)
+	p.retryNotifications(ctx)
+
+	if n := repository.journal[0]; n.Status != repo.NotificationDead || n.Attempts != 2 {
+		t.Fatalf("journal entry = %+v", n)
+	}
+}
+
+func TestNotificationBackoff(t *testing.T) {
+	cases := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 20: notificationMaxBackoff}
+	for attempts, want := range cases {
+		if got := notificationBackoff(attempts); got != want {
+			t.Errorf("notificationBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
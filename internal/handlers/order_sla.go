@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+const (
+	orderSLACheckInterval = time.Minute
+	orderSLABatchSize     = 50
+)
+
+// RunOrderSLA watches for orders stuck in processing past the configured SLA
+// until ctx is cancelled. Zero OrderSLA disables the watch.
+func (p *AtlanticWebhookProcessor) RunOrderSLA(ctx context.Context) {
+	if p.cfg.OrderSLA <= 0 {
+		return
+	}
+	ticker := time.NewTicker(orderSLACheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkStuckOrders(ctx)
+		}
+	}
+}
+
+func (p *AtlanticWebhookProcessor) checkStuckOrders(ctx context.Context) {
+	orders, err := p.repo.ListStuckOrders(ctx, time.Now().Add(-p.cfg.OrderSLA), orderSLABatchSize)
+	if err != nil {
+		p.logger.Warn("failed listing stuck orders", "error", err)
+		return
+	}
+	for i := range orders {
+		p.handleStuckOrder(ctx, &orders[i])
+	}
+}
+
+// handleStuckOrder tells the customer about the delay and asks the admins to
+// follow up. Asking the supplier for the order's status is left to the
+// pending order poller (internal/jobs), which settles orders through the
+// usual webhook path; an order still stuck here has not finished there.
+func (p *AtlanticWebhookProcessor) handleStuckOrder(ctx context.Context, order *repo.Order) {
+	if err := p.repo.MarkOrderEscalated(ctx, order.OrderRef, time.Now()); err != nil {
+		p.logger.Warn("failed marking order escalated", "error", err, "order_ref", order.OrderRef)
+		return
+	}
+	p.observeStuckOrder("escalated")
+	waited := time.Since(order.CreatedAt).Round(time.Minute)
+	p.logger.Warn("order past sla escalated", "order_ref", order.OrderRef, "product_code", order.ProductCode, "waited", waited, "status", order.Status)
+
+	p.notifyUser(quoteOrder(ctx, order.Metadata), order.UserID, localize(p.userLanguage(ctx, order.UserID), msgOrderStuck,
+		order.OrderRef, order.ProductCode))
+	target := stringValue(order.Metadata, "customer_id")
+	p.notifyAdmins(ctx, fmt.Sprintf("Order tertahan %s: %s (%s) tujuan %s sudah %s, status: %s.",
+		order.OrderRef, order.ProductCode, money.FromRupiah(order.Amount).Format(money.LocaleFor("", p.cfg.CurrencySymbolSpace)), target, waited, strings.ToUpper(order.Status)))
+}
+
+func (p *AtlanticWebhookProcessor) observeStuckOrder(outcome string) {
+	if p.metrics != nil {
+		p.metrics.StuckOrders.WithLabelValues(outcome).Inc()
+	}
+}
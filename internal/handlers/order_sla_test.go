@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+type slaRepo struct {
+	repo.Repository
+	stuck     []repo.Order
+	escalated []string
+}
+
+func (r *slaRepo) ListStuckOrders(_ context.Context, createdBefore time.Time, _ int) ([]repo.Order, error) {
+	var res []repo.Order
+	for _, o := range r.stuck {
+		if o.CreatedAt.Before(createdBefore) {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func (r *slaRepo) MarkOrderEscalated(_ context.Context, orderRef string, _ time.Time) error {
+	r.escalated = append(r.escalated, orderRef)
+	return nil
+}
+
+func (r *slaRepo) GetUserByID(_ context.Context, id string) (*repo.User, error) {
+	jid := "628111@s.whatsapp.net"
+	return &repo.User{ID: id, WAJID: &jid}, nil
+}
+
+type recordingNotifier struct {
+	sent map[string][]string
+}
+
+func (n *recordingNotifier) SendText(_ context.Context, to types.JID, text string) error {
+	n.sent[to.User] = append(n.sent[to.User], text)
+	return nil
+}
+
+func TestStuckOrderEscalation(t *testing.T) {
+	repository := &slaRepo{stuck: []repo.Order{
+		{OrderRef: "trx-old", UserID: "u1", ProductCode: "PLN20", Status: "processing", CreatedAt: time.Now().Add(-time.Hour)},
+		{OrderRef: "trx-new", UserID: "u1", ProductCode: "PLN20", Status: "processing", CreatedAt: time.Now()},
+	}}
+	notifier := &recordingNotifier{sent: map[string][]string{}}
+	p := NewAtlanticWebhookProcessor(repository, notifier, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, ProcessorConfig{
+		AdminJIDs: []string{"628999"},
+		OrderSLA:  15 * time.Minute,
+	})
+
+	p.checkStuckOrders(context.Background())
+
+	if len(repository.escalated) != 1 || repository.escalated[0] != "trx-old" {
+		t.Fatalf("escalated = %v, want only trx-old", repository.escalated)
+	}
+	if msgs := notifier.sent["628111"]; len(msgs) != 1 || !strings.Contains(msgs[0], "trx-old") {
+		t.Fatalf("customer notifications = %v", msgs)
+	}
+	if msgs := notifier.sent["628999"]; len(msgs) != 1 || !strings.Contains(msgs[0], "trx-old") {
+		t.Fatalf("admin notifications = %v", msgs)
+	}
+}
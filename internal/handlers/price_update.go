@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bot-jual/internal/atl"
+)
+
+// handlePriceUpdate drops every cached copy of the affected price lists and
+// refreshes the catalog snapshot instead of waiting for the cache TTL.
+func (p *AtlanticWebhookProcessor) handlePriceUpdate(ctx context.Context, event atl.WebhookEvent) error {
+	productTypes := atl.PriceUpdateTypes(event.Payload)
+	if len(productTypes) == 0 {
+		productTypes = []string{"prabayar", "pascabayar"}
+	}
+	p.logger.Info("price update hint received, invalidating caches", "event", event.Type, "types", productTypes)
+
+	if p.atl != nil {
+		if err := p.atl.InvalidatePriceList(ctx, productTypes...); err != nil {
+			return fmt.Errorf("invalidate price cache: %w", err)
+		}
+	}
+	if p.prices != nil {
+		p.prices.InvalidatePriceCache(productTypes...)
+	}
+
+	if p.atl != nil {
+		go func() {
+			catalogCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := p.atl.FetchAndSaveAllProducts(catalogCtx, atl.DefaultCatalogPath); err != nil {
+				p.logger.Warn("failed refreshing product catalog after price update", "error", err)
+			}
+		}()
+	}
+	return nil
+}
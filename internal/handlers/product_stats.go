@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+)
+
+// trackProductOutcome records the final supplier outcome of an order and
+// alerts admins when the product's success rate drops below the threshold.
+func (p *AtlanticWebhookProcessor) trackProductOutcome(ctx context.Context, previous *repo.Order, status string) {
+	if previous == nil || previous.ProductCode == "" {
+		return
+	}
+	if status != "success" && status != "failed" {
+		return
+	}
+	// Repeated or late webhooks for an already settled order must not be counted twice.
+	if previous.Status == "success" || previous.Status == "failed" {
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(previous.ProductCode))
+	stat, err := p.repo.RecordProductOutcome(ctx, code, status, status == "success")
+	if err != nil {
+		p.logger.Warn("failed recording product outcome", "error", err, "product_code", code)
+		return
+	}
+	if status == "success" || !crossedLowSuccess(*stat, p.cfg.LowSuccessRate, p.cfg.LowSuccessMinSamples) {
+		return
+	}
+
+	p.logger.Warn("product success rate below threshold", "product_code", code, "success", stat.SuccessCount, "failed", stat.FailureCount, "rate", stat.SuccessRate())
+	p.notifyAdmins(ctx, fmt.Sprintf("Peringatan: tingkat sukses produk %s turun ke %.0f%% (%d sukses, %d gagal).",
+		code, stat.SuccessRate()*100, stat.SuccessCount, stat.FailureCount))
+}
+
+// crossedLowSuccess reports whether the latest failure pushed the product
+// below the threshold, so each drop alerts once instead of on every failure.
+func crossedLowSuccess(stat repo.ProductStat, threshold float64, minSamples int64) bool {
+	if threshold <= 0 || stat.Total() < minSamples || stat.SuccessRate() >= threshold {
+		return false
+	}
+	before := stat
+	before.FailureCount--
+	if before.Total() < minSamples {
+		return true
+	}
+	return before.SuccessRate() >= threshold
+}
+
+func (p *AtlanticWebhookProcessor) notifyAdmins(ctx context.Context, text string) {
+	if p.notifier == nil {
+		return
+	}
+	for _, raw := range p.cfg.AdminJIDs {
+		jid, err := wa.ParseAdminJID(raw)
+		if err != nil {
+			p.logger.Warn("invalid admin jid", "error", err, "jid", raw)
+			continue
+		}
+		if err := p.notifier.SendText(ctx, jid, text); err != nil {
+			p.logger.Warn("failed sending admin notification", "error", err, "jid", raw)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"bot-jual/internal/repo"
+)
+
+func TestCrossedLowSuccess(t *testing.T) {
+	cases := []struct {
+		name    string
+		success int64
+		failure int64
+		want    bool
+	}{
+		{"not enough samples", 1, 2, false},
+		{"reaches samples below threshold", 2, 3, true},
+		{"drops below threshold", 7, 4, true},
+		{"already below threshold", 2, 5, false},
+		{"still healthy", 9, 1, false},
+	}
+	for _, tc := range cases {
+		stat := repo.ProductStat{SuccessCount: tc.success, FailureCount: tc.failure}
+		if got := crossedLowSuccess(stat, 0.7, 5); got != tc.want {
+			t.Errorf("%s: crossedLowSuccess(%d/%d) = %v, want %v", tc.name, tc.success, tc.failure, got, tc.want)
+		}
+	}
+}
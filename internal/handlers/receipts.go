@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"bot-jual/internal/receipt"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// DocumentNotifier is implemented by notifiers that can also send files.
+// Receipt documents are skipped for notifiers without it.
+type DocumentNotifier interface {
+	SendDocument(ctx context.Context, to types.JID, data []byte, mimeType, fileName, caption string) error
+}
+
+// sendReceiptDocument follows the success notification of order with a PDF
+// receipt. sn fills in the serial number when the stored metadata lacks it.
+// A unit of a batch waits for the rest, so the last one to finish sends a
+// single receipt for the whole batch.
+func (p *AtlanticWebhookProcessor) sendReceiptDocument(ctx context.Context, order *repo.Order, sn string) {
+	if !p.cfg.ReceiptDocuments || order == nil {
+		return
+	}
+	sender, ok := p.notifier.(DocumentNotifier)
+	if !ok {
+		return
+	}
+	jid, ok := p.userJID(ctx, order.UserID)
+	if !ok {
+		return
+	}
+	units, err := receipt.LoadBatch(ctx, p.repo, order)
+	if err != nil {
+		p.logger.Warn("failed loading batch for receipt document", "error", err, "order_ref", order.OrderRef)
+		return
+	}
+	var doc receipt.Document
+	switch {
+	case units == nil:
+		doc = receipt.NewDocument(order, p.cfg.Persona.ShopName, p.userLocale(ctx, order.UserID))
+		if doc.SN == "" {
+			doc.SN = strings.TrimSpace(sn)
+		}
+	case receipt.BatchFinal(units):
+		doc = receipt.NewBatchDocument(units, p.cfg.Persona.ShopName, p.userLocale(ctx, order.UserID))
+	default:
+		return
+	}
+	doc.URL = p.cfg.Receipts.URL(order.OrderRef)
+	doc.Location = p.userZone(ctx, order.UserID)
+	ctx = wa.WithOrigin(ctx, wa.Origin{UserID: order.UserID, Category: "receipt_document"})
+	if err := sender.SendDocument(ctx, jid, doc.PDF(), "application/pdf", doc.FileName(), localize(p.userLanguage(ctx, order.UserID), msgReceiptCaption, doc.OrderRef)); err != nil {
+		p.logger.Warn("failed sending receipt document", "error", err, "order_ref", order.OrderRef)
+	}
+}
@@ -0,0 +1,540 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type aliasPayload struct {
+	Alias       string `json:"alias"`
+	Replacement string `json:"replacement"`
+}
+
+// handleCatalogAliases lists (GET), upserts (POST) and deletes (DELETE ?alias=) catalog aliases.
+func (s *Server) handleCatalogAliases(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		aliases, err := s.deps.Repository.ListCatalogAliases(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing catalog aliases", "error", err)
+			http.Error(w, "failed listing aliases", http.StatusInternalServerError)
+			return
+		}
+		items := make([]aliasPayload, 0, len(aliases))
+		for _, a := range aliases {
+			items = append(items, aliasPayload{Alias: a.Alias, Replacement: a.Replacement})
+		}
+		writeJSON(w, map[string]any{"aliases": items})
+	case http.MethodPost:
+		var payload aliasPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		alias := strings.ToLower(strings.TrimSpace(payload.Alias))
+		replacement := strings.TrimSpace(payload.Replacement)
+		if alias == "" || replacement == "" {
+			http.Error(w, "alias and replacement are required", http.StatusBadRequest)
+			return
+		}
+		saved, err := s.deps.Repository.UpsertCatalogAlias(r.Context(), alias, replacement)
+		if err != nil {
+			s.logger.Error("failed saving catalog alias", "error", err, "alias", alias)
+			http.Error(w, "failed saving alias", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, aliasPayload{Alias: saved.Alias, Replacement: saved.Replacement})
+	case http.MethodDelete:
+		alias := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("alias")))
+		if alias == "" {
+			http.Error(w, "alias query parameter is required", http.StatusBadRequest)
+			return
+		}
+		if err := s.deps.Repository.DeleteCatalogAlias(r.Context(), alias); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				http.Error(w, "alias not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("failed deleting catalog alias", "error", err, "alias", alias)
+			http.Error(w, "failed deleting alias", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type curationPayload struct {
+	Scope        string `json:"scope"`
+	Key          string `json:"key"`
+	Hidden       bool   `json:"hidden"`
+	Reason       string `json:"reason,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// handleCuratedProducts lists (GET), upserts (POST) and deletes (DELETE ?scope=&key=) product curation entries.
+func (s *Server) handleCuratedProducts(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := s.deps.Repository.ListCuratedProducts(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing curated products", "error", err)
+			http.Error(w, "failed listing curation", http.StatusInternalServerError)
+			return
+		}
+		items := make([]curationPayload, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, toCurationPayload(entry))
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var payload curationPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		scope, key, ok := normaliseCurationKey(payload.Scope, payload.Key)
+		if !ok {
+			http.Error(w, "scope must be product or category and key is required", http.StatusBadRequest)
+			return
+		}
+		entry := repo.CuratedProduct{Scope: scope, Key: key, Hidden: payload.Hidden}
+		if reason := strings.TrimSpace(payload.Reason); reason != "" {
+			entry.Reason = &reason
+		}
+		if instructions := strings.TrimSpace(payload.Instructions); instructions != "" {
+			entry.Instructions = &instructions
+		}
+		saved, err := s.deps.Repository.UpsertCuratedProduct(r.Context(), entry)
+		if err != nil {
+			s.logger.Error("failed saving curated product", "error", err, "scope", scope, "key", key)
+			http.Error(w, "failed saving curation", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, toCurationPayload(*saved))
+	case http.MethodDelete:
+		scope, key, ok := normaliseCurationKey(r.URL.Query().Get("scope"), r.URL.Query().Get("key"))
+		if !ok {
+			http.Error(w, "scope and key query parameters are required", http.StatusBadRequest)
+			return
+		}
+		if err := s.deps.Repository.DeleteCuratedProduct(r.Context(), scope, key); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				http.Error(w, "curation entry not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("failed deleting curated product", "error", err, "scope", scope, "key", key)
+			http.Error(w, "failed deleting curation", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func normaliseCurationKey(scope, key string) (string, string, bool) {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", false
+	}
+	switch scope {
+	case repo.CurationScopeProduct:
+		return scope, strings.ToUpper(key), true
+	case repo.CurationScopeCategory:
+		return scope, strings.ToLower(key), true
+	default:
+		return "", "", false
+	}
+}
+
+func toCurationPayload(entry repo.CuratedProduct) curationPayload {
+	payload := curationPayload{Scope: entry.Scope, Key: entry.Key, Hidden: entry.Hidden}
+	if entry.Reason != nil {
+		payload.Reason = *entry.Reason
+	}
+	if entry.Instructions != nil {
+		payload.Instructions = *entry.Instructions
+	}
+	return payload
+}
+
+type productStatPayload struct {
+	ProductCode   string     `json:"product_code"`
+	Success       int64      `json:"success"`
+	Failed        int64      `json:"failed"`
+	SuccessRate   float64    `json:"success_rate"`
+	LastStatus    string     `json:"last_status,omitempty"`
+	LastOutcomeAt *time.Time `json:"last_outcome_at,omitempty"`
+}
+
+// handleProductStats lists supplier success rates per product (GET ?max_rate=&min_samples=), worst first.
+func (s *Server) handleProductStats(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxRate := 1.0
+	if raw := strings.TrimSpace(r.URL.Query().Get("max_rate")); raw != "" {
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			http.Error(w, "invalid max_rate", http.StatusBadRequest)
+			return
+		}
+		maxRate = val
+	}
+	var minSamples int64
+	if raw := strings.TrimSpace(r.URL.Query().Get("min_samples")); raw != "" {
+		val, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid min_samples", http.StatusBadRequest)
+			return
+		}
+		minSamples = val
+	}
+
+	stats, err := s.deps.Repository.ListProductStats(r.Context())
+	if err != nil {
+		s.logger.Error("failed listing product stats", "error", err)
+		http.Error(w, "failed listing product stats", http.StatusInternalServerError)
+		return
+	}
+	items := make([]productStatPayload, 0, len(stats))
+	for _, st := range stats {
+		if st.Total() < minSamples || st.SuccessRate() > maxRate {
+			continue
+		}
+		payload := productStatPayload{
+			ProductCode:   st.ProductCode,
+			Success:       st.SuccessCount,
+			Failed:        st.FailureCount,
+			SuccessRate:   st.SuccessRate(),
+			LastOutcomeAt: st.LastOutcomeAt,
+		}
+		if st.LastStatus != nil {
+			payload.LastStatus = *st.LastStatus
+		}
+		items = append(items, payload)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].SuccessRate < items[j].SuccessRate
+	})
+	writeJSON(w, map[string]any{"items": items})
+}
+
+type acquisitionStatPayload struct {
+	Source         string  `json:"source"`
+	Detail         string  `json:"detail,omitempty"`
+	Users          int64   `json:"users"`
+	Buyers         int64   `json:"buyers"`
+	ConversionRate float64 `json:"conversion_rate"`
+	Orders         int64   `json:"orders"`
+	Revenue        int64   `json:"revenue"`
+}
+
+// handleAcquisitionReport reports conversions per acquisition source over the
+// last N days (GET ?days=, default 30), highest revenue first.
+func (s *Server) handleAcquisitionReport(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	days, err := queryInt(r, "days", 30)
+	if err != nil || days <= 0 {
+		http.Error(w, "invalid days", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.deps.Repository.AcquisitionReport(r.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		s.logger.Error("failed building acquisition report", "error", err)
+		http.Error(w, "failed building acquisition report", http.StatusInternalServerError)
+		return
+	}
+	items := make([]acquisitionStatPayload, 0, len(stats))
+	for _, st := range stats {
+		payload := acquisitionStatPayload{
+			Source:  st.Source,
+			Detail:  st.Detail,
+			Users:   st.Users,
+			Buyers:  st.Buyers,
+			Orders:  st.Orders,
+			Revenue: st.Revenue,
+		}
+		if st.Users > 0 {
+			payload.ConversionRate = float64(st.Buyers) / float64(st.Users)
+		}
+		items = append(items, payload)
+	}
+	writeJSON(w, map[string]any{"days": days, "items": items})
+}
+
+type messagePayload struct {
+	ID        string    `json:"id"`
+	Direction string    `json:"direction"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content,omitempty"`
+	MediaURL  string    `json:"media_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleUserMessages pages through a user's transcript, newest first (GET ?limit=&offset=).
+func (s *Server) handleUserMessages(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := strings.TrimSpace(r.PathValue("id"))
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil || limit <= 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.deps.Repository.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed loading user", "error", err, "user_id", userID)
+		http.Error(w, "failed loading user", http.StatusInternalServerError)
+		return
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	records, err := s.deps.Repository.ListMessages(r.Context(), user.ID, limit+1, offset)
+	if err != nil {
+		s.logger.Error("failed listing messages", "error", err, "user_id", user.ID)
+		http.Error(w, "failed listing messages", http.StatusInternalServerError)
+		return
+	}
+	hasMore := len(records) > limit
+	if hasMore {
+		records = records[:limit]
+	}
+
+	items := make([]messagePayload, 0, len(records))
+	for _, rec := range records {
+		item := messagePayload{ID: rec.ID, Direction: rec.Direction, Type: rec.Type, CreatedAt: rec.CreatedAt}
+		if rec.Content != nil {
+			item.Content = *rec.Content
+		}
+		if rec.MediaURL != nil {
+			item.MediaURL = *rec.MediaURL
+		}
+		items = append(items, item)
+	}
+
+	resp := map[string]any{
+		"user": map[string]any{
+			"id":           user.ID,
+			"wa_id":        user.WAID,
+			"display_name": user.DisplayName,
+		},
+		"messages": items,
+		"limit":    limit,
+		"offset":   offset,
+	}
+	if hasMore {
+		resp["next_offset"] = offset + limit
+	}
+	writeJSON(w, resp)
+}
+
+// handleDeleteUser anonymizes a user on request (DELETE). Their orders stay
+// for accounting; everything that identifies them is removed.
+func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := strings.TrimSpace(r.PathValue("id"))
+	if err := s.deps.Repository.AnonymizeUser(r.Context(), userID, time.Now()); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed anonymizing user", "error", err, "user_id", userID)
+		http.Error(w, "failed anonymizing user", http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("user anonymized", "user_id", userID)
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+// DeadLetterReplayer re-processes a stored webhook dead letter.
+type DeadLetterReplayer interface {
+	ReplayDeadLetter(ctx context.Context, id string) (*repo.WebhookDeadLetter, error)
+}
+
+type deadLetterPayload struct {
+	ID         string            `json:"id"`
+	Source     string            `json:"source"`
+	EventType  string            `json:"event_type"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error"`
+	Attempts   int               `json:"attempts"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Payload    json.RawMessage   `json:"payload,omitempty"`
+	RawPayload string            `json:"raw_payload,omitempty"`
+	ReplayedAt *time.Time        `json:"replayed_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+func toDeadLetterPayload(dl repo.WebhookDeadLetter) deadLetterPayload {
+	payload := deadLetterPayload{
+		ID:         dl.ID,
+		Source:     dl.Source,
+		EventType:  dl.EventType,
+		Status:     dl.Status,
+		Error:      dl.Error,
+		Attempts:   dl.Attempts,
+		Headers:    dl.Headers,
+		ReplayedAt: dl.ReplayedAt,
+		CreatedAt:  dl.CreatedAt,
+	}
+	if json.Valid(dl.Payload) {
+		payload.Payload = json.RawMessage(dl.Payload)
+	} else {
+		payload.RawPayload = string(dl.Payload)
+	}
+	return payload
+}
+
+// handleDeadLetters lists webhook dead letters (GET ?status=&limit=).
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil || limit <= 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
+
+	entries, err := s.deps.Repository.ListWebhookDeadLetters(r.Context(), status, limit)
+	if err != nil {
+		s.logger.Error("failed listing dead letters", "error", err)
+		http.Error(w, "failed listing dead letters", http.StatusInternalServerError)
+		return
+	}
+	items := make([]deadLetterPayload, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, toDeadLetterPayload(entry))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+// handleDeadLetter shows a single dead letter (GET).
+func (s *Server) handleDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	dl, err := s.deps.Repository.GetWebhookDeadLetter(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "dead letter not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed loading dead letter", "error", err, "id", id)
+		http.Error(w, "failed loading dead letter", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, toDeadLetterPayload(*dl))
+}
+
+// handleReplayDeadLetter re-runs a dead letter through the webhook processor (POST).
+func (s *Server) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if s.deps.DeadLetters == nil {
+		http.Error(w, "webhook processor unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	dl, err := s.deps.DeadLetters.ReplayDeadLetter(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "dead letter not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, repo.ErrDeadLetterReplaying) {
+			http.Error(w, "dead letter replay already in progress", http.StatusConflict)
+			return
+		}
+		s.logger.Warn("dead letter replay failed", "error", err, "id", id)
+		http.Error(w, "replay failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, toDeadLetterPayload(*dl))
+}
@@ -0,0 +1,239 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// adminPrefix marks the routes guarded by AdminAuth.
+const adminPrefix = "/admin/"
+
+// jwtLeeway tolerates clock skew between the token issuer and this server.
+const jwtLeeway = 30 * time.Second
+
+// AdminAuth configures who may call the /admin routes. Either a static API
+// key (X-API-Key header or bearer token) or an HS256-signed JWT bearer token
+// is accepted; browsers may send either as the Basic auth password, for
+// same-origin reads only. With neither configured every admin request is
+// refused.
+type AdminAuth struct {
+	APIKeys   []string
+	JWTSecret string
+	// JWTIssuer, when set, must match the token's iss claim.
+	JWTIssuer string
+	// JWTRole must appear in the token's role or roles claim; tokens without
+	// it are authenticated but get 403.
+	JWTRole string
+}
+
+func (a AdminAuth) enabled() bool {
+	return len(a.APIKeys) > 0 || a.JWTSecret != ""
+}
+
+var (
+	errNoCredentials  = errors.New("missing credentials")
+	errBadCredentials = errors.New("invalid credentials")
+	errTokenExpired   = errors.New("token expired")
+	errMissingRole    = errors.New("token lacks admin role")
+	errBasicRefused   = errors.New("basic auth only accepted for same-origin reads")
+)
+
+// SetAdminAuth configures authentication for the /admin routes.
+func (s *Server) SetAdminAuth(auth AdminAuth) {
+	s.adminAuth = auth
+	if !auth.enabled() {
+		s.logger.Warn("admin api disabled: set ADMIN_API_KEYS or ADMIN_JWT_SECRET to enable it")
+	}
+}
+
+// requireAdmin rejects /admin requests without valid credentials: 401 when
+// they are missing or invalid, 403 when they are valid but not allowed.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, adminPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !s.adminAuth.enabled() {
+			s.observeAuth("none", "disabled")
+			http.Error(w, "admin api disabled", http.StatusForbidden)
+			return
+		}
+
+		method, subject, err := s.adminAuth.authenticate(r, time.Now())
+		switch {
+		case err == nil:
+			s.observeAuth(method, "ok")
+			next.ServeHTTP(w, r)
+		case errors.Is(err, errMissingRole):
+			s.observeAuth(method, "forbidden")
+			s.logger.Warn("admin request forbidden", "path", r.URL.Path, "subject", subject, "error", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			s.observeAuth(method, "unauthorized")
+			challenge := `Bearer realm="admin"`
+			if !errors.Is(err, errNoCredentials) {
+				challenge += `, error="invalid_token"`
+				s.logger.Warn("admin request unauthorized", "path", r.URL.Path, "method", method, "remote", r.RemoteAddr, "error", err)
+			}
+			if r.URL.Path == dashboardPath {
+				// Make browsers prompt for the key.
+				challenge = `Basic realm="admin", charset="UTF-8"`
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	})
+}
+
+func (s *Server) observeAuth(method, outcome string) {
+	if s.metrics != nil {
+		s.metrics.AdminAuth.WithLabelValues(method, outcome).Inc()
+	}
+}
+
+// authenticate reports which credential type the request used and, for
+// tokens, the subject it names.
+func (a AdminAuth) authenticate(r *http.Request, now time.Time) (method, subject string, err error) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return "api_key", "", a.checkAPIKey(key)
+	}
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	token = strings.TrimSpace(token)
+	if _, password, basic := r.BasicAuth(); basic {
+		if !basicAllowed(r) {
+			return "basic", "", errBasicRefused
+		}
+		// The user name is ignored; the password carries the credential.
+		scheme, token, ok = "Bearer", strings.TrimSpace(password), true
+	}
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "none", "", errNoCredentials
+	}
+	if strings.Count(token, ".") != 2 {
+		return "api_key", "", a.checkAPIKey(token)
+	}
+	if a.JWTSecret == "" {
+		return "jwt", "", errBadCredentials
+	}
+	claims, err := verifyHS256(token, []byte(a.JWTSecret))
+	if err != nil {
+		return "jwt", "", err
+	}
+	if err := claims.validate(now, a.JWTIssuer); err != nil {
+		return "jwt", claims.Subject, err
+	}
+	if a.JWTRole != "" && !claims.hasRole(a.JWTRole) {
+		return "jwt", claims.Subject, errMissingRole
+	}
+	return "jwt", claims.Subject, nil
+}
+
+// basicAllowed reports whether r may authenticate with Basic credentials.
+// Browsers replay them on every request to this host, including ones a
+// hostile page triggers, so they only count for reads from the same origin:
+// GET or HEAD with no Origin header, as on a page load, or one naming this
+// host, as on the dashboard's own WebSocket or fetch calls.
+func basicAllowed(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (a AdminAuth) checkAPIKey(key string) error {
+	match := 0
+	for _, candidate := range a.APIKeys {
+		match |= subtle.ConstantTimeCompare([]byte(key), []byte(candidate))
+	}
+	if match != 1 {
+		return errBadCredentials
+	}
+	return nil
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	Role      string          `json:"role"`
+	Roles     json.RawMessage `json:"roles"`
+}
+
+// verifyHS256 checks the token signature and decodes its claims. Only HS256
+// is accepted so a forged "alg" header cannot downgrade verification.
+func verifyHS256(token string, secret []byte) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errBadCredentials
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, errBadCredentials
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errBadCredentials
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errBadCredentials
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errBadCredentials
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errBadCredentials
+	}
+	return &claims, nil
+}
+
+// validate requires an expiry so a leaked token cannot be used forever.
+func (c *jwtClaims) validate(now time.Time, issuer string) error {
+	if c.ExpiresAt == 0 || now.Add(-jwtLeeway).Unix() >= c.ExpiresAt {
+		return errTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(jwtLeeway).Unix() < c.NotBefore {
+		return errBadCredentials
+	}
+	if issuer != "" && c.Issuer != issuer {
+		return errBadCredentials
+	}
+	return nil
+}
+
+// hasRole accepts roles as a list or a single string.
+func (c *jwtClaims) hasRole(role string) bool {
+	if c.Role == role {
+		return true
+	}
+	var roles []string
+	if len(c.Roles) > 0 && json.Unmarshal(c.Roles, &roles) != nil {
+		var single string
+		if json.Unmarshal(c.Roles, &single) == nil {
+			roles = []string{single}
+		}
+	}
+	return slices.Contains(roles, role)
+}
@@ -0,0 +1,144 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "jwt-secret"
+
+// signJWT builds a token with the given header algorithm and claims, signed
+// with secret as HS256 whatever the header says.
+func signJWT(t *testing.T, alg, secret string, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestRequireAdmin(t *testing.T) {
+	now := time.Now()
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{"sub": "ops", "iss": "bot-jual", "exp": now.Add(time.Hour).Unix(), "role": "admin"}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	valid := signJWT(t, "HS256", testJWTSecret, claims(nil))
+
+	const (
+		bearerChallenge  = `Bearer realm="admin"`
+		invalidChallenge = `Bearer realm="admin", error="invalid_token"`
+		basicChallenge   = `Basic realm="admin", charset="UTF-8"`
+	)
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		header    map[string]string
+		basic     string
+		wantCode  int
+		challenge string
+	}{
+		{name: "no credentials", wantCode: http.StatusUnauthorized, challenge: bearerChallenge},
+		{name: "no credentials on dashboard", path: dashboardPath, wantCode: http.StatusUnauthorized, challenge: basicChallenge},
+		{name: "api key header", header: map[string]string{"X-API-Key": "key-1"}, wantCode: http.StatusOK},
+		{name: "wrong api key", header: map[string]string{"X-API-Key": "key-2"}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "api key as bearer", header: map[string]string{"Authorization": "Bearer key-1"}, wantCode: http.StatusOK},
+		{name: "api key header wins over bearer", header: map[string]string{"X-API-Key": "key-2", "Authorization": "Bearer " + valid}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "unknown scheme", header: map[string]string{"Authorization": "Token key-1"}, wantCode: http.StatusUnauthorized, challenge: bearerChallenge},
+		{name: "jwt", header: map[string]string{"Authorization": "Bearer " + valid}, wantCode: http.StatusOK},
+		{name: "jwt roles list", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"role": nil, "roles": []string{"viewer", "admin"}}))}, wantCode: http.StatusOK},
+		{name: "jwt roles string", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"role": nil, "roles": "admin"}))}, wantCode: http.StatusOK},
+		{name: "alg none", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "none", testJWTSecret, claims(nil))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "alg RS256", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "RS256", testJWTSecret, claims(nil))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "bad signature", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", "other-secret", claims(nil))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "expired", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()}))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "no expiry", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"exp": nil}))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "not yet valid", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()}))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "wrong issuer", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"iss": "elsewhere"}))}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "missing role", header: map[string]string{"Authorization": "Bearer " + signJWT(t, "HS256", testJWTSecret, claims(map[string]any{"role": "viewer"}))}, wantCode: http.StatusForbidden},
+		{name: "basic on dashboard", path: dashboardPath, basic: "key-1", wantCode: http.StatusOK},
+		{name: "basic same-origin fetch", basic: valid, header: map[string]string{"Origin": "http://admin.example"}, wantCode: http.StatusOK},
+		{name: "basic cross-origin", basic: "key-1", header: map[string]string{"Origin": "https://evil.example"}, wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "basic on post", method: http.MethodPost, basic: "key-1", wantCode: http.StatusUnauthorized, challenge: invalidChallenge},
+		{name: "api key on post", method: http.MethodPost, header: map[string]string{"X-API-Key": "key-1"}, wantCode: http.StatusOK},
+	}
+
+	s := &Server{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		adminAuth: AdminAuth{APIKeys: []string{"key-1"}, JWTSecret: testJWTSecret, JWTIssuer: "bot-jual", JWTRole: "admin"},
+	}
+	handler := s.requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method, path := tc.method, tc.path
+			if method == "" {
+				method = http.MethodGet
+			}
+			if path == "" {
+				path = adminPrefix + "api/orders"
+			}
+			req := httptest.NewRequest(method, "http://admin.example"+path, nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			if tc.basic != "" {
+				req.SetBasicAuth("admin", tc.basic)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantCode)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tc.challenge {
+				t.Fatalf("challenge = %q, want %q", got, tc.challenge)
+			}
+		})
+	}
+}
+
+func TestRequireAdminDisabled(t *testing.T) {
+	s := &Server{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	handler := s.requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, adminPrefix+"api/orders", nil)
+	req.Header.Set("X-API-Key", "anything")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("admin status without auth configured = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public route status = %d", rec.Code)
+	}
+}
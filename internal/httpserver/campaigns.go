@@ -0,0 +1,257 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/broadcast"
+	"bot-jual/internal/repo"
+)
+
+const defaultCampaignThrottle = 30
+
+type campaignRequest struct {
+	Name              string       `json:"name"`
+	Template          string       `json:"template"`
+	Segment           repo.Segment `json:"segment"`
+	ScheduledAt       time.Time    `json:"scheduled_at"`
+	ThrottlePerMinute int          `json:"throttle_per_minute"`
+}
+
+type campaignPayload struct {
+	ID                string                `json:"id"`
+	Name              string                `json:"name"`
+	Template          string                `json:"template"`
+	Segment           repo.Segment          `json:"segment"`
+	ScheduledAt       time.Time             `json:"scheduled_at"`
+	ThrottlePerMinute int                   `json:"throttle_per_minute"`
+	Status            string                `json:"status"`
+	StartedAt         *time.Time            `json:"started_at,omitempty"`
+	CompletedAt       *time.Time            `json:"completed_at,omitempty"`
+	Stats             *campaignStatsPayload `json:"stats,omitempty"`
+}
+
+type campaignStatsPayload struct {
+	Sent           int64   `json:"sent"`
+	Failed         int64   `json:"failed"`
+	Converted      int64   `json:"converted"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+func toCampaignPayload(c repo.Campaign, stats *repo.CampaignStats) campaignPayload {
+	payload := campaignPayload{
+		ID:                c.ID,
+		Name:              c.Name,
+		Template:          c.Template,
+		Segment:           c.Segment,
+		ScheduledAt:       c.ScheduledAt,
+		ThrottlePerMinute: c.ThrottlePerMinute,
+		Status:            c.Status,
+		StartedAt:         c.StartedAt,
+		CompletedAt:       c.CompletedAt,
+	}
+	if stats != nil {
+		payload.Stats = &campaignStatsPayload{Sent: stats.Sent, Failed: stats.Failed, Converted: stats.Converted}
+		if stats.Sent > 0 {
+			payload.Stats.ConversionRate = float64(stats.Converted) / float64(stats.Sent)
+		}
+	}
+	return payload
+}
+
+// handleCampaigns lists (GET ?limit=) and schedules (POST) promotional campaigns.
+func (s *Server) handleCampaigns(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit, err := queryInt(r, "limit", 50)
+		if err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		campaigns, err := s.deps.Repository.ListCampaigns(r.Context(), limit)
+		if err != nil {
+			s.logger.Error("failed listing campaigns", "error", err)
+			http.Error(w, "failed listing campaigns", http.StatusInternalServerError)
+			return
+		}
+		items := make([]campaignPayload, 0, len(campaigns))
+		for _, c := range campaigns {
+			items = append(items, toCampaignPayload(c, nil))
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var req campaignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		template := strings.TrimSpace(req.Template)
+		if name == "" || template == "" {
+			http.Error(w, "name and template are required", http.StatusBadRequest)
+			return
+		}
+		if req.ThrottlePerMinute < 0 || !validSegment(req.Segment) {
+			http.Error(w, "throttle_per_minute and segment values must not be negative", http.StatusBadRequest)
+			return
+		}
+		if req.ThrottlePerMinute == 0 {
+			req.ThrottlePerMinute = defaultCampaignThrottle
+		}
+		if req.ScheduledAt.IsZero() {
+			req.ScheduledAt = time.Now()
+		}
+		saved, err := s.deps.Repository.InsertCampaign(r.Context(), repo.Campaign{
+			Name:              name,
+			Template:          template,
+			Segment:           req.Segment,
+			ScheduledAt:       req.ScheduledAt,
+			ThrottlePerMinute: req.ThrottlePerMinute,
+		})
+		if err != nil {
+			s.logger.Error("failed saving campaign", "error", err, "name", name)
+			http.Error(w, "failed saving campaign", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, toCampaignPayload(*saved, nil))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCampaign shows a campaign with its delivery and conversion stats (GET).
+func (s *Server) handleCampaign(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	c, err := s.deps.Repository.GetCampaign(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "campaign not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed loading campaign", "error", err, "id", id)
+		http.Error(w, "failed loading campaign", http.StatusInternalServerError)
+		return
+	}
+	stats, err := s.deps.Repository.GetCampaignStats(r.Context(), c.ID)
+	if err != nil {
+		s.logger.Error("failed loading campaign stats", "error", err, "id", id)
+		http.Error(w, "failed loading campaign stats", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, toCampaignPayload(*c, stats))
+}
+
+// handleCancelCampaign stops a scheduled or running campaign (POST).
+func (s *Server) handleCancelCampaign(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	c, err := s.deps.Repository.GetCampaign(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "campaign not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed loading campaign", "error", err, "id", id)
+		http.Error(w, "failed loading campaign", http.StatusInternalServerError)
+		return
+	}
+	if c.Status != repo.CampaignScheduled && c.Status != repo.CampaignRunning {
+		http.Error(w, "campaign already "+c.Status, http.StatusConflict)
+		return
+	}
+	if err := s.deps.Repository.UpdateCampaignStatus(r.Context(), c.ID, repo.CampaignCancelled); err != nil {
+		s.logger.Error("failed cancelling campaign", "error", err, "id", id)
+		http.Error(w, "failed cancelling campaign", http.StatusInternalServerError)
+		return
+	}
+	c.Status = repo.CampaignCancelled
+	writeJSON(w, toCampaignPayload(*c, nil))
+}
+
+func validSegment(seg repo.Segment) bool {
+	return seg.ActiveWithinDays >= 0 && seg.InactiveDays >= 0 && seg.MinSpent >= 0
+}
+
+type segmentUserPayload struct {
+	ID          string  `json:"id"`
+	WAID        string  `json:"wa_id"`
+	DisplayName *string `json:"display_name,omitempty"`
+}
+
+// handleSegmentPreview counts the users matching a segment and lists a sample (POST ?limit=).
+func (s *Server) handleSegmentPreview(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil || limit < 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	var seg repo.Segment
+	if err := json.NewDecoder(r.Body).Decode(&seg); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	if !validSegment(seg) {
+		http.Error(w, "segment values must not be negative", http.StatusBadRequest)
+		return
+	}
+	seg.BoughtCategory = strings.TrimSpace(seg.BoughtCategory)
+
+	var resolver broadcast.CategoryResolver
+	if s.deps.Atlantic != nil {
+		resolver = s.deps.Atlantic
+	}
+	seg, err = broadcast.ResolveSegment(r.Context(), resolver, seg)
+	if err != nil {
+		s.logger.Error("failed resolving segment category", "error", err, "category", seg.BoughtCategory)
+		http.Error(w, "failed resolving category", http.StatusBadGateway)
+		return
+	}
+	users, err := s.deps.Repository.ListUsersInSegment(r.Context(), seg)
+	if err != nil {
+		s.logger.Error("failed listing segment users", "error", err)
+		http.Error(w, "failed listing segment users", http.StatusInternalServerError)
+		return
+	}
+
+	sample := users
+	if len(sample) > limit {
+		sample = sample[:limit]
+	}
+	items := make([]segmentUserPayload, 0, len(sample))
+	for _, u := range sample {
+		items = append(items, segmentUserPayload{ID: u.ID, WAID: u.WAID, DisplayName: u.DisplayName})
+	}
+	writeJSON(w, map[string]any{"count": len(users), "users": items})
+}
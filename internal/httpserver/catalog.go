@@ -0,0 +1,164 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type catalogSnapshotPayload struct {
+	ProductType string    `json:"product_type"`
+	Version     int       `json:"version"`
+	Checksum    string    `json:"checksum"`
+	ItemCount   int       `json:"item_count"`
+	Pinned      bool      `json:"pinned"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type catalogPinRequest struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+func toCatalogSnapshotPayload(snap repo.CatalogSnapshot) catalogSnapshotPayload {
+	return catalogSnapshotPayload{
+		ProductType: snap.ProductType,
+		Version:     snap.Version,
+		Checksum:    snap.Checksum,
+		ItemCount:   snap.ItemCount,
+		Pinned:      snap.Pinned,
+		CreatedAt:   snap.CreatedAt,
+	}
+}
+
+func catalogType(raw string) string {
+	if t := strings.ToLower(strings.TrimSpace(raw)); t != "" {
+		return t
+	}
+	return "prabayar"
+}
+
+// handleCatalogVersions lists recorded catalog snapshots (GET ?type=&limit=).
+func (s *Server) handleCatalogVersions(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := queryInt(r, "limit", 20)
+	if err != nil || limit <= 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	productType := catalogType(r.URL.Query().Get("type"))
+	snaps, err := s.deps.Repository.ListCatalogSnapshots(r.Context(), productType, limit)
+	if err != nil {
+		s.logger.Error("failed listing catalog snapshots", "error", err, "type", productType)
+		http.Error(w, "failed listing catalog snapshots", http.StatusInternalServerError)
+		return
+	}
+	items := make([]catalogSnapshotPayload, 0, len(snaps))
+	for _, snap := range snaps {
+		items = append(items, toCatalogSnapshotPayload(snap))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+// handleCatalogPin pins a snapshot version (POST {type, version}) or returns a
+// product type to live supplier prices (DELETE ?type=). Replies pick up the
+// change within a minute.
+func (s *Server) handleCatalogPin(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req catalogPinRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		if req.Version <= 0 {
+			http.Error(w, "version is required", http.StatusBadRequest)
+			return
+		}
+		s.pinCatalog(w, r, catalogType(req.Type), req.Version)
+	case http.MethodDelete:
+		productType := catalogType(r.URL.Query().Get("type"))
+		if err := s.deps.Repository.UnpinCatalog(r.Context(), productType); err != nil {
+			s.logger.Error("failed unpinning catalog", "error", err, "type", productType)
+			http.Error(w, "failed unpinning catalog", http.StatusInternalServerError)
+			return
+		}
+		s.logger.Info("catalog unpinned", "type", productType)
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCatalogRollback pins the snapshot preceding the currently served one (POST {type}).
+func (s *Server) handleCatalogRollback(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req catalogPinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	productType := catalogType(req.Type)
+
+	current, err := s.deps.Repository.GetPinnedCatalogSnapshot(r.Context(), productType)
+	if errors.Is(err, repo.ErrNotFound) {
+		current, err = s.deps.Repository.GetLatestCatalogSnapshot(r.Context(), productType)
+	}
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "no catalog snapshots recorded", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed loading catalog snapshot", "error", err, "type", productType)
+		http.Error(w, "failed loading catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+	if current.Version <= 1 {
+		http.Error(w, "no earlier catalog snapshot", http.StatusConflict)
+		return
+	}
+	s.pinCatalog(w, r, productType, current.Version-1)
+}
+
+func (s *Server) pinCatalog(w http.ResponseWriter, r *http.Request, productType string, version int) {
+	if err := s.deps.Repository.PinCatalogSnapshot(r.Context(), productType, version); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "catalog snapshot not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed pinning catalog", "error", err, "type", productType, "version", version)
+		http.Error(w, "failed pinning catalog", http.StatusInternalServerError)
+		return
+	}
+	snap, err := s.deps.Repository.GetCatalogSnapshot(r.Context(), productType, version)
+	if err != nil {
+		s.logger.Error("failed loading catalog snapshot", "error", err, "type", productType, "version", version)
+		http.Error(w, "failed loading catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("catalog pinned", "type", productType, "version", version)
+	writeJSON(w, toCatalogSnapshotPayload(*snap))
+}
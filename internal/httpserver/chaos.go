@@ -0,0 +1,60 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type chaosFaultRequest struct {
+	Fault string `json:"fault"`
+	// Rate is the share of calls that fail; zero means all of them.
+	Rate float64 `json:"rate"`
+	// DurationSeconds clears the fault after this long; zero keeps it
+	// until deleted.
+	DurationSeconds int `json:"duration_seconds"`
+}
+
+// handleChaos lists (GET), starts (POST) and clears (DELETE ?fault=) injected
+// dependency failures. It only answers when CHAOS_ENABLED is set:
+//
+//	curl -H "X-API-Key: ..." -d '{"fault":"atlantic","rate":0.5,"duration_seconds":300}' .../admin/api/chaos
+func (s *Server) handleChaos(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Chaos == nil {
+		http.Error(w, "failure injection disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]any{"active": s.deps.Chaos.Active()})
+	case http.MethodPost:
+		var req chaosFaultRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		if req.Rate == 0 {
+			req.Rate = 1
+		}
+		fault := strings.TrimSpace(req.Fault)
+		if err := s.deps.Chaos.Set(fault, req.Rate, time.Duration(req.DurationSeconds)*time.Second); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.Warn("failure injection started", "fault", fault, "rate", req.Rate, "duration_seconds", req.DurationSeconds)
+		writeJSON(w, map[string]any{"active": s.deps.Chaos.Active()})
+	case http.MethodDelete:
+		fault := strings.TrimSpace(r.URL.Query().Get("fault"))
+		if fault == "" {
+			http.Error(w, "fault query parameter is required", http.StatusBadRequest)
+			return
+		}
+		s.deps.Chaos.Clear(fault)
+		s.logger.Info("failure injection cleared", "fault", fault)
+		writeJSON(w, map[string]any{"active": s.deps.Chaos.Active()})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
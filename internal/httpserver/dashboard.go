@@ -0,0 +1,150 @@
+package httpserver
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"bot-jual/internal/money"
+)
+
+// dashboardPath serves the admin dashboard page. Browsers cannot attach an
+// API key to a page load, so unauthenticated requests here are challenged
+// with Basic auth and the page's own API calls reuse those credentials.
+const dashboardPath = adminPrefix + "dashboard"
+
+// dashboardRecent caps the orders and deposits shown on the dashboard.
+const dashboardRecent = 20
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+type dashboardPayload struct {
+	WhatsApp *pairingPayload    `json:"whatsapp,omitempty"`
+	Atlantic *dashboardBalance  `json:"atlantic,omitempty"`
+	Keys     []apiKeyPayload    `json:"keys"`
+	Orders   []dashboardOrder   `json:"orders"`
+	Deposits []dashboardDeposit `json:"deposits"`
+	// Errors names the sections that could not be loaded, so one failing
+	// dependency does not blank the whole page.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type dashboardBalance struct {
+	Username string      `json:"username"`
+	Balance  money.Money `json:"balance"`
+}
+
+type dashboardOrder struct {
+	OrderRef    string    `json:"order_ref"`
+	ProductCode string    `json:"product_code"`
+	Amount      int64     `json:"amount"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type dashboardDeposit struct {
+	DepositRef string    `json:"deposit_ref"`
+	Method     string    `json:"method"`
+	Amount     int64     `json:"amount"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// handleDashboard serves the embedded dashboard page, which polls
+// handleDashboardData.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	_, _ = w.Write(dashboardHTML)
+}
+
+// handleDashboardData reports the WhatsApp session, Atlantic balance, Gemini
+// key cooldowns and the latest orders and deposits in one response.
+func (s *Server) handleDashboardData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	payload := dashboardPayload{
+		Keys:     []apiKeyPayload{},
+		Orders:   []dashboardOrder{},
+		Deposits: []dashboardDeposit{},
+		Errors:   map[string]string{},
+	}
+
+	if s.deps.WhatsApp != nil {
+		st := s.deps.WhatsApp.Status()
+		payload.WhatsApp = &pairingPayload{State: st.State, Connected: st.Connected, PairCode: st.PairCode, Reason: st.Reason}
+		if !st.LoggedOutAt.IsZero() {
+			payload.WhatsApp.LoggedOutAt = &st.LoggedOutAt
+		}
+	} else {
+		payload.Errors["whatsapp"] = "whatsapp client unavailable"
+	}
+
+	if s.deps.Atlantic != nil {
+		if profile, err := s.deps.Atlantic.GetProfile(ctx); err != nil {
+			s.logger.Warn("dashboard failed reading atlantic balance", "error", err)
+			payload.Errors["atlantic"] = "failed reading balance"
+		} else {
+			payload.Atlantic = &dashboardBalance{Username: profile.Username, Balance: profile.Balance}
+		}
+	} else {
+		payload.Errors["atlantic"] = "atlantic client unavailable"
+	}
+
+	if s.deps.Repository == nil {
+		for _, section := range []string{"keys", "orders", "deposits"} {
+			payload.Errors[section] = "repository unavailable"
+		}
+		writeDashboard(w, payload)
+		return
+	}
+
+	if keys, err := s.deps.Repository.ListGeminiKeys(ctx); err != nil {
+		s.logger.Error("dashboard failed listing api keys", "error", err)
+		payload.Errors["keys"] = "failed listing api keys"
+	} else {
+		for _, k := range keys {
+			item := toAPIKeyPayload(k)
+			if s.deps.NLU != nil {
+				if used, err := s.deps.NLU.KeyUsage(ctx, k.ID); err == nil {
+					item.Usage = &keyUsage{Requests: used.Requests, Tokens: used.Tokens}
+				}
+			}
+			payload.Keys = append(payload.Keys, item)
+		}
+	}
+
+	if orders, err := s.deps.Repository.ListRecentOrders(ctx, dashboardRecent); err != nil {
+		s.logger.Error("dashboard failed listing orders", "error", err)
+		payload.Errors["orders"] = "failed listing orders"
+	} else {
+		for _, o := range orders {
+			payload.Orders = append(payload.Orders, dashboardOrder{OrderRef: o.OrderRef, ProductCode: o.ProductCode, Amount: o.Amount, Status: o.Status, CreatedAt: o.CreatedAt})
+		}
+	}
+
+	if deposits, err := s.deps.Repository.ListRecentDeposits(ctx, dashboardRecent); err != nil {
+		s.logger.Error("dashboard failed listing deposits", "error", err)
+		payload.Errors["deposits"] = "failed listing deposits"
+	} else {
+		for _, d := range deposits {
+			payload.Deposits = append(payload.Deposits, dashboardDeposit{DepositRef: d.DepositRef, Method: d.Method, Amount: d.Amount, Status: d.Status, CreatedAt: d.CreatedAt})
+		}
+	}
+
+	writeDashboard(w, payload)
+}
+
+func writeDashboard(w http.ResponseWriter, payload dashboardPayload) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, payload)
+}
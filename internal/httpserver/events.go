@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// eventWriteTimeout bounds how long one event may take to reach a client
+// before the connection is given up on.
+const eventWriteTimeout = 10 * time.Second
+
+// handleEvents upgrades to a WebSocket and streams order, deposit and
+// message events as JSON text messages until the client goes away.
+// ?types= narrows the stream to comma-separated type prefixes:
+//
+//	websocat -H "X-API-Key: ..." "ws://.../admin/events?types=order,deposit"
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.deps.Events == nil {
+		http.Error(w, "event stream unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var prefixes []string
+	for _, prefix := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	// Accept rejects cross-origin browser upgrades, so a logged-in
+	// dashboard session cannot be ridden by another site.
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		s.logger.Warn("events websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.CloseNow()
+
+	events, cancel := s.deps.Events.Subscribe(prefixes)
+	defer cancel()
+
+	// The stream is one-way; CloseRead answers pings and notices the client
+	// closing.
+	ctx := conn.CloseRead(r.Context())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			writeCtx, writeCancel := context.WithTimeout(ctx, eventWriteTimeout)
+			err := wsjson.Write(writeCtx, conn, ev)
+			writeCancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
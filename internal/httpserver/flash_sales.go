@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/broadcast"
+	"bot-jual/internal/repo"
+)
+
+type flashSaleRequest struct {
+	ProductCode string    `json:"product_code"`
+	Price       int64     `json:"price"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	// Announce schedules a campaign for the start of the sale. Its template
+	// may use {name}, {code}, {price} and {until}.
+	Announce *flashSaleAnnouncement `json:"announce,omitempty"`
+}
+
+type flashSaleAnnouncement struct {
+	Template          string       `json:"template"`
+	Segment           repo.Segment `json:"segment"`
+	ThrottlePerMinute int          `json:"throttle_per_minute"`
+}
+
+type flashSalePayload struct {
+	ID          string    `json:"id"`
+	ProductCode string    `json:"product_code"`
+	Price       int64     `json:"price"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Active      bool      `json:"active"`
+	CampaignID  *string   `json:"campaign_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func toFlashSalePayload(f repo.FlashSale, now time.Time) flashSalePayload {
+	return flashSalePayload{
+		ID:          f.ID,
+		ProductCode: f.ProductCode,
+		Price:       f.Price,
+		StartsAt:    f.StartsAt,
+		EndsAt:      f.EndsAt,
+		Active:      f.Active(now),
+		CampaignID:  f.CampaignID,
+		CreatedAt:   f.CreatedAt,
+	}
+}
+
+// handleFlashSales lists running and upcoming flash sales (GET) and schedules
+// one, optionally with an announcement campaign (POST). Prices reach the bot
+// within a minute.
+func (s *Server) handleFlashSales(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		now := time.Now()
+		sales, err := s.deps.Repository.ListFlashSales(r.Context(), now)
+		if err != nil {
+			s.logger.Error("failed listing flash sales", "error", err)
+			http.Error(w, "failed listing flash sales", http.StatusInternalServerError)
+			return
+		}
+		items := make([]flashSalePayload, 0, len(sales))
+		for _, f := range sales {
+			items = append(items, toFlashSalePayload(f, now))
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var req flashSaleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		code := strings.ToUpper(strings.TrimSpace(req.ProductCode))
+		if code == "" || req.Price <= 0 {
+			http.Error(w, "product_code and a positive price are required", http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		if req.StartsAt.IsZero() {
+			req.StartsAt = now
+		}
+		if !req.EndsAt.After(req.StartsAt) || !req.EndsAt.After(now) {
+			http.Error(w, "ends_at must be in the future and after starts_at", http.StatusBadRequest)
+			return
+		}
+		sale := repo.FlashSale{ProductCode: code, Price: req.Price, StartsAt: req.StartsAt, EndsAt: req.EndsAt}
+
+		if a := req.Announce; a != nil {
+			if a.ThrottlePerMinute < 0 || !validSegment(a.Segment) {
+				http.Error(w, "throttle_per_minute and segment values must not be negative", http.StatusBadRequest)
+				return
+			}
+			if a.ThrottlePerMinute == 0 {
+				a.ThrottlePerMinute = defaultCampaignThrottle
+			}
+			campaign, err := s.deps.Repository.InsertCampaign(r.Context(), repo.Campaign{
+				Name:              "Flash sale " + code,
+				Template:          broadcast.FlashSaleTemplate(a.Template, sale),
+				Segment:           a.Segment,
+				ScheduledAt:       req.StartsAt,
+				ThrottlePerMinute: a.ThrottlePerMinute,
+			})
+			if err != nil {
+				s.logger.Error("failed saving flash sale campaign", "error", err, "product_code", code)
+				http.Error(w, "failed saving flash sale campaign", http.StatusInternalServerError)
+				return
+			}
+			sale.CampaignID = &campaign.ID
+		}
+
+		saved, err := s.deps.Repository.InsertFlashSale(r.Context(), sale)
+		if err != nil {
+			s.logger.Error("failed saving flash sale", "error", err, "product_code", code)
+			s.cancelFlashSaleCampaign(r, sale.CampaignID)
+			http.Error(w, "failed saving flash sale", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, toFlashSalePayload(*saved, now))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFlashSale ends a flash sale early or drops an upcoming one (DELETE),
+// cancelling its announcement if it has not gone out yet.
+func (s *Server) handleFlashSale(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	sale, err := s.deps.Repository.DeleteFlashSale(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "flash sale not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed deleting flash sale", "error", err, "id", id)
+		http.Error(w, "failed deleting flash sale", http.StatusInternalServerError)
+		return
+	}
+	s.cancelFlashSaleCampaign(r, sale.CampaignID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) cancelFlashSaleCampaign(r *http.Request, campaignID *string) {
+	if campaignID == nil {
+		return
+	}
+	c, err := s.deps.Repository.GetCampaign(r.Context(), *campaignID)
+	if err != nil {
+		s.logger.Warn("failed loading flash sale campaign", "error", err, "campaign_id", *campaignID)
+		return
+	}
+	if c.Status != repo.CampaignScheduled {
+		return
+	}
+	if err := s.deps.Repository.UpdateCampaignStatus(r.Context(), c.ID, repo.CampaignCancelled); err != nil {
+		s.logger.Warn("failed cancelling flash sale campaign", "error", err, "campaign_id", c.ID)
+	}
+}
@@ -0,0 +1,218 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type apiKeyPayload struct {
+	ID             string     `json:"id"`
+	Key            string     `json:"key"`
+	Priority       int        `json:"priority"`
+	Source         string     `json:"source"`
+	Active         bool       `json:"active"`
+	CooldownUntil  *time.Time `json:"cooldown_until,omitempty"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DailyRequests  int64      `json:"daily_request_quota"`
+	DailyTokens    int64      `json:"daily_token_quota"`
+	Usage          *keyUsage  `json:"usage_today,omitempty"`
+}
+
+type keyUsage struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+type apiKeyRequest struct {
+	Key      string `json:"key"`
+	Priority *int   `json:"priority"`
+	apiKeyQuotaRequest
+}
+
+type apiKeyQuotaRequest struct {
+	DailyRequests int64 `json:"daily_request_quota"`
+	DailyTokens   int64 `json:"daily_token_quota"`
+}
+
+func toAPIKeyPayload(k repo.APIKey) apiKeyPayload {
+	payload := apiKeyPayload{
+		ID:            k.ID,
+		Key:           maskKey(k.Value),
+		Priority:      k.Priority,
+		Source:        k.Source,
+		Active:        k.DisabledAt == nil,
+		CooldownUntil: k.CooldownUntil,
+		DisabledAt:    k.DisabledAt,
+		DailyRequests: k.DailyRequestQuota,
+		DailyTokens:   k.DailyTokenQuota,
+	}
+	if k.DisabledReason != nil {
+		payload.DisabledReason = *k.DisabledReason
+	}
+	return payload
+}
+
+// maskKey keeps only the last four characters so keys never leave the server in full.
+func maskKey(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
+
+// handleAPIKeys lists (GET, with today's usage) and adds (POST {key, priority,
+// daily_request_quota, daily_token_quota}) Gemini keys at runtime. Without a
+// priority the key is tried after every existing key.
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := s.deps.Repository.ListGeminiKeys(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing api keys", "error", err)
+			http.Error(w, "failed listing api keys", http.StatusInternalServerError)
+			return
+		}
+		items := make([]apiKeyPayload, 0, len(keys))
+		for _, k := range keys {
+			payload := toAPIKeyPayload(k)
+			if s.deps.NLU != nil {
+				if used, err := s.deps.NLU.KeyUsage(r.Context(), k.ID); err == nil {
+					payload.Usage = &keyUsage{Requests: used.Requests, Tokens: used.Tokens}
+				} else {
+					s.logger.Warn("failed reading key usage", "error", err, "id", k.ID)
+				}
+			}
+			items = append(items, payload)
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var req apiKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		value := strings.TrimSpace(req.Key)
+		if value == "" {
+			http.Error(w, "key is required", http.StatusBadRequest)
+			return
+		}
+		if req.DailyRequests < 0 || req.DailyTokens < 0 {
+			http.Error(w, "quotas must not be negative", http.StatusBadRequest)
+			return
+		}
+		priority := 0
+		if req.Priority != nil {
+			if *req.Priority < 0 {
+				http.Error(w, "priority must not be negative", http.StatusBadRequest)
+				return
+			}
+			priority = *req.Priority
+		} else {
+			keys, err := s.deps.Repository.ListGeminiKeys(r.Context())
+			if err != nil {
+				s.logger.Error("failed listing api keys", "error", err)
+				http.Error(w, "failed listing api keys", http.StatusInternalServerError)
+				return
+			}
+			for _, k := range keys {
+				if k.Priority >= priority {
+					priority = k.Priority + 1
+				}
+			}
+		}
+		saved, err := s.deps.Repository.AddGeminiKey(r.Context(), value, priority)
+		if err != nil {
+			s.logger.Error("failed adding api key", "error", err)
+			http.Error(w, "failed adding api key", http.StatusInternalServerError)
+			return
+		}
+		if err := s.deps.Repository.SetAPIKeyQuota(r.Context(), saved.ID, req.DailyRequests, req.DailyTokens); err != nil {
+			s.logger.Error("failed setting api key quota", "error", err, "id", saved.ID)
+			http.Error(w, "failed setting api key quota", http.StatusInternalServerError)
+			return
+		}
+		saved.DailyRequestQuota, saved.DailyTokenQuota = req.DailyRequests, req.DailyTokens
+		s.invalidateNLUKeys()
+		s.logger.Info("gemini key added", "id", saved.ID, "priority", saved.Priority)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, toAPIKeyPayload(*saved))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDisableAPIKey stops a key from being used (POST). Adding the same key again re-enables it.
+func (s *Server) handleDisableAPIKey(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	if err := s.deps.Repository.DisableAPIKey(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed disabling api key", "error", err, "id", id)
+		http.Error(w, "failed disabling api key", http.StatusInternalServerError)
+		return
+	}
+	s.invalidateNLUKeys()
+	s.logger.Info("gemini key disabled", "id", id)
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// handleAPIKeyQuota sets a key's daily quotas (PUT {daily_request_quota, daily_token_quota}); zero means unlimited.
+func (s *Server) handleAPIKeyQuota(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req apiKeyQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	if req.DailyRequests < 0 || req.DailyTokens < 0 {
+		http.Error(w, "quotas must not be negative", http.StatusBadRequest)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	if err := s.deps.Repository.SetAPIKeyQuota(r.Context(), id, req.DailyRequests, req.DailyTokens); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed setting api key quota", "error", err, "id", id)
+		http.Error(w, "failed setting api key quota", http.StatusInternalServerError)
+		return
+	}
+	s.invalidateNLUKeys()
+	s.logger.Info("gemini key quota updated", "id", id, "daily_requests", req.DailyRequests, "daily_tokens", req.DailyTokens)
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *Server) invalidateNLUKeys() {
+	if s.deps.NLU != nil {
+		s.deps.NLU.InvalidateKeys()
+	}
+}
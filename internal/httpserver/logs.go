@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bot-jual/internal/logging"
+)
+
+// defaultLogLimit is how many entries /admin/logs returns without ?limit.
+const defaultLogLimit = 200
+
+// handleLogs returns recent log entries, oldest first, optionally narrowed
+// with ?component= and ?level= (debug, info, warn or error; default info)
+// and capped by ?limit=. With ?follow=1 the response stays open and streams
+// matching entries as newline-delimited JSON until the client disconnects:
+//
+//	curl -N -H "X-API-Key: ..." ".../admin/logs?component=convo&level=warn&follow=1"
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.deps.Logs == nil {
+		http.Error(w, "log buffer disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := logging.Filter{Component: strings.TrimSpace(query.Get("component")), Limit: defaultLogLimit}
+	if level := strings.ToLower(strings.TrimSpace(query.Get("level"))); level != "" {
+		switch level {
+		case "debug", "info", "warn", "error":
+			filter.MinLevel = logging.ParseLevel(level)
+		default:
+			http.Error(w, "level must be debug, info, warn or error", http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	follow, _ := strconv.ParseBool(query.Get("follow"))
+	if !follow {
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, map[string]any{"entries": s.deps.Logs.Entries(filter)})
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing logged in between is
+	// lost; an entry may show up twice instead.
+	live, cancel := s.deps.Logs.Subscribe(filter)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for _, entry := range s.deps.Logs.Entries(filter) {
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+	}
+	if err := flusher.Flush(); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry := <-live:
+			if err := enc.Encode(entry); err != nil {
+				return
+			}
+			if err := flusher.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
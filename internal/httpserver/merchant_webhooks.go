@@ -0,0 +1,193 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/notify"
+	"bot-jual/internal/repo"
+)
+
+type merchantWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+type merchantWebhookPayload struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type merchantDeliveryPayload struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	Error          *string         `json:"error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// toMerchantWebhookPayload masks the secret like API keys; it is shown in
+// full only in the response that creates the webhook.
+func toMerchantWebhookPayload(hook repo.MerchantWebhook) merchantWebhookPayload {
+	events := hook.Events
+	if len(events) == 0 {
+		events = notify.EventTypes
+	}
+	return merchantWebhookPayload{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Secret:    maskKey(hook.Secret),
+		Events:    events,
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// handleMerchantWebhooks lists (GET) and registers (POST {url, secret,
+// events}) merchant webhooks. Without events the webhook receives every
+// event; without a secret one is generated.
+func (s *Server) handleMerchantWebhooks(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hooks, err := s.deps.Repository.ListMerchantWebhooks(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing merchant webhooks", "error", err)
+			http.Error(w, "failed listing merchant webhooks", http.StatusInternalServerError)
+			return
+		}
+		items := make([]merchantWebhookPayload, 0, len(hooks))
+		for _, hook := range hooks {
+			items = append(items, toMerchantWebhookPayload(hook))
+		}
+		writeJSON(w, map[string]any{"items": items, "event_types": notify.EventTypes})
+	case http.MethodPost:
+		var req merchantWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		target, err := url.Parse(strings.TrimSpace(req.URL))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			http.Error(w, "url must be an absolute http(s) url", http.StatusBadRequest)
+			return
+		}
+		events := make([]string, 0, len(req.Events))
+		for _, name := range req.Events {
+			name = strings.TrimSpace(name)
+			if !notify.ValidEventType(name) {
+				http.Error(w, "unknown event type: "+name, http.StatusBadRequest)
+				return
+			}
+			events = append(events, name)
+		}
+		secret := strings.TrimSpace(req.Secret)
+		if secret == "" {
+			secret = notify.NewSecret()
+		}
+		saved, err := s.deps.Repository.InsertMerchantWebhook(r.Context(), repo.MerchantWebhook{
+			URL:    target.String(),
+			Secret: secret,
+			Events: events,
+		})
+		if err != nil {
+			s.logger.Error("failed adding merchant webhook", "error", err)
+			http.Error(w, "failed adding merchant webhook", http.StatusInternalServerError)
+			return
+		}
+		s.logger.Info("merchant webhook added", "id", saved.ID, "url", saved.URL)
+		payload := toMerchantWebhookPayload(*saved)
+		payload.Secret = saved.Secret
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, payload)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMerchantWebhook removes a merchant webhook and its delivery log (DELETE).
+func (s *Server) handleMerchantWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	if err := s.deps.Repository.DeleteMerchantWebhook(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "merchant webhook not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed deleting merchant webhook", "error", err, "id", id)
+		http.Error(w, "failed deleting merchant webhook", http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("merchant webhook deleted", "id", id)
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// handleMerchantWebhookDeliveries returns a webhook's newest deliveries (GET ?limit=).
+func (s *Server) handleMerchantWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	deliveries, err := s.deps.Repository.ListMerchantWebhookDeliveries(r.Context(), id, limit)
+	if err != nil {
+		s.logger.Error("failed listing merchant webhook deliveries", "error", err, "id", id)
+		http.Error(w, "failed listing merchant webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	items := make([]merchantDeliveryPayload, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, merchantDeliveryPayload{
+			ID:             d.ID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			ResponseStatus: d.ResponseStatus,
+			Error:          d.Error,
+			NextAttemptAt:  d.NextAttemptAt,
+			DeliveredAt:    d.DeliveredAt,
+			CreatedAt:      d.CreatedAt,
+			Payload:        json.RawMessage(d.Payload),
+		})
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type notificationPayload struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	ChatJID       string     `json:"chat_jid"`
+	Kind          string     `json:"kind"`
+	RefID         string     `json:"ref_id"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func toNotificationPayload(n repo.Notification) notificationPayload {
+	payload := notificationPayload{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		ChatJID:   n.ChatJID,
+		Kind:      n.Kind,
+		RefID:     n.RefID,
+		Body:      n.Body,
+		Status:    n.Status,
+		Attempts:  n.Attempts,
+		LastError: n.LastError,
+		CreatedAt: n.CreatedAt,
+	}
+	if n.Status == repo.NotificationPending {
+		payload.NextAttemptAt = &n.NextAttemptAt
+	}
+	return payload
+}
+
+// handleUndeliveredNotifications lists journaled payment notifications that
+// are still being retried or were given up on (GET ?limit=).
+func (s *Server) handleUndeliveredNotifications(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil || limit <= 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	entries, err := s.deps.Repository.ListUndeliveredNotifications(r.Context(), limit)
+	if err != nil {
+		s.logger.Error("failed listing undelivered notifications", "error", err)
+		http.Error(w, "failed listing undelivered notifications", http.StatusInternalServerError)
+		return
+	}
+	items := make([]notificationPayload, 0, len(entries))
+	for _, n := range entries {
+		items = append(items, toNotificationPayload(n))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+// handleRetryNotification queues an undelivered notification for an
+// immediate resend with a fresh set of attempts (POST).
+func (s *Server) handleRetryNotification(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimSpace(r.PathValue("id"))
+	if err := s.deps.Repository.RequeueNotification(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "undelivered notification not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("failed requeueing notification", "error", err, "id", id)
+		http.Error(w, "failed requeueing notification", http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("notification requeued", "id", id)
+	writeJSON(w, map[string]string{"status": "queued"})
+}
@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"bot-jual/internal/repo"
+)
+
+type pricingRulePayload struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key,omitempty"`
+	// MarginFixed is in rupiah and MarginPercent in percent (2.5 for 2.5%).
+	MarginFixed   int64   `json:"margin_fixed"`
+	MarginPercent float64 `json:"margin_percent"`
+	// RoundTo rounds selling prices up to a multiple of this many rupiah.
+	RoundTo int64 `json:"round_to"`
+}
+
+// handlePricingRules lists (GET), upserts (POST) and deletes (DELETE
+// ?scope=&key=) markup rules. Edits reach quotes within a minute.
+func (s *Server) handlePricingRules(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.deps.Repository.ListPricingRules(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing pricing rules", "error", err)
+			http.Error(w, "failed listing pricing rules", http.StatusInternalServerError)
+			return
+		}
+		items := make([]pricingRulePayload, 0, len(rules))
+		for _, rule := range rules {
+			items = append(items, toPricingRulePayload(rule))
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var payload pricingRulePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		scope, key, ok := normalisePricingKey(payload.Scope, payload.Key)
+		if !ok {
+			http.Error(w, "scope must be default, category or product, and category and product rules need a key", http.StatusBadRequest)
+			return
+		}
+		if payload.MarginFixed < 0 || payload.MarginPercent < 0 || payload.MarginPercent > 100 || payload.RoundTo < 0 {
+			http.Error(w, "margins and round_to must not be negative and margin_percent must be at most 100", http.StatusBadRequest)
+			return
+		}
+		saved, err := s.deps.Repository.UpsertPricingRule(r.Context(), repo.PricingRule{
+			Scope:         scope,
+			Key:           key,
+			MarginFixed:   payload.MarginFixed,
+			MarginPercent: payload.MarginPercent,
+			RoundTo:       payload.RoundTo,
+		})
+		if err != nil {
+			s.logger.Error("failed saving pricing rule", "error", err, "scope", scope, "key", key)
+			http.Error(w, "failed saving pricing rule", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, toPricingRulePayload(*saved))
+	case http.MethodDelete:
+		scope, key, ok := normalisePricingKey(r.URL.Query().Get("scope"), r.URL.Query().Get("key"))
+		if !ok {
+			http.Error(w, "scope query parameter is required, with key for category and product rules", http.StatusBadRequest)
+			return
+		}
+		if err := s.deps.Repository.DeletePricingRule(r.Context(), scope, key); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				http.Error(w, "pricing rule not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("failed deleting pricing rule", "error", err, "scope", scope, "key", key)
+			http.Error(w, "failed deleting pricing rule", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func normalisePricingKey(scope, key string) (string, string, bool) {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	key = strings.TrimSpace(key)
+	switch scope {
+	case repo.PricingScopeDefault:
+		return scope, "", true
+	case repo.PricingScopeProduct:
+		return scope, strings.ToUpper(key), key != ""
+	case repo.PricingScopeCategory:
+		return scope, strings.ToLower(key), key != ""
+	default:
+		return "", "", false
+	}
+}
+
+func toPricingRulePayload(rule repo.PricingRule) pricingRulePayload {
+	return pricingRulePayload{
+		Scope:         rule.Scope,
+		Key:           rule.Key,
+		MarginFixed:   rule.MarginFixed,
+		MarginPercent: rule.MarginPercent,
+		RoundTo:       rule.RoundTo,
+	}
+}
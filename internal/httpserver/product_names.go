@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"bot-jual/internal/naming"
+	"bot-jual/internal/repo"
+)
+
+type productNameRulePayload struct {
+	Scope string `json:"scope"`
+	// Target is a product code, or a regular expression for pattern rules.
+	Target string `json:"target"`
+	// Name replaces the whole name for product rules and each match for
+	// pattern rules, where it may be empty to drop the match.
+	Name string `json:"name"`
+}
+
+// handleProductNames lists (GET), upserts (POST) and deletes (DELETE
+// ?scope=&target=) the rules that rename supplier products. Pattern rules
+// apply in the order they were added. Edits reach replies within a minute.
+func (s *Server) handleProductNames(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.deps.Repository.ListProductNameRules(r.Context())
+		if err != nil {
+			s.logger.Error("failed listing product name rules", "error", err)
+			http.Error(w, "failed listing product name rules", http.StatusInternalServerError)
+			return
+		}
+		items := make([]productNameRulePayload, 0, len(rules))
+		for _, rule := range rules {
+			items = append(items, productNameRulePayload{Scope: rule.Scope, Target: rule.Target, Name: rule.Name})
+		}
+		writeJSON(w, map[string]any{"items": items})
+	case http.MethodPost:
+		var payload productNameRulePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		scope, target, ok := normaliseProductNameTarget(payload.Scope, payload.Target)
+		if !ok {
+			http.Error(w, "scope must be product or pattern, and target is required", http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(payload.Name)
+		switch scope {
+		case repo.ProductNameScopeProduct:
+			if name == "" {
+				http.Error(w, "name is required for product rules", http.StatusBadRequest)
+				return
+			}
+		case repo.ProductNameScopePattern:
+			if _, err := naming.CompilePattern(target); err != nil {
+				http.Error(w, "invalid "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		saved, err := s.deps.Repository.UpsertProductNameRule(r.Context(), repo.ProductNameRule{Scope: scope, Target: target, Name: name})
+		if err != nil {
+			s.logger.Error("failed saving product name rule", "error", err, "scope", scope, "target", target)
+			http.Error(w, "failed saving product name rule", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, productNameRulePayload{Scope: saved.Scope, Target: saved.Target, Name: saved.Name})
+	case http.MethodDelete:
+		scope, target, ok := normaliseProductNameTarget(r.URL.Query().Get("scope"), r.URL.Query().Get("target"))
+		if !ok {
+			http.Error(w, "scope and target query parameters are required", http.StatusBadRequest)
+			return
+		}
+		if err := s.deps.Repository.DeleteProductNameRule(r.Context(), scope, target); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				http.Error(w, "product name rule not found", http.StatusNotFound)
+				return
+			}
+			s.logger.Error("failed deleting product name rule", "error", err, "scope", scope, "target", target)
+			http.Error(w, "failed deleting product name rule", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func normaliseProductNameTarget(scope, target string) (string, string, bool) {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	target = strings.TrimSpace(target)
+	switch scope {
+	case repo.ProductNameScopeProduct:
+		return scope, strings.ToUpper(target), target != ""
+	case repo.ProductNameScopePattern:
+		return scope, target, target != ""
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,159 @@
+package httpserver
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/receipt"
+	"bot-jual/internal/repo"
+)
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Bukti Transaksi {{.OrderRef}}</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f4f5f7;margin:0;padding:24px;color:#222}
+.card{max-width:420px;margin:0 auto;background:#fff;border-radius:12px;padding:24px;box-shadow:0 1px 4px rgba(0,0,0,.08)}
+h1{font-size:1.2rem;margin:0 0 4px}
+.status{display:inline-block;padding:2px 10px;border-radius:999px;font-weight:600;font-size:.85rem;background:#eee}
+.status.success{background:#d9f7e4;color:#137a3a}.status.failed{background:#fde2e1;color:#a32018}
+dl{display:grid;grid-template-columns:auto 1fr;gap:8px 16px;margin:20px 0 0}
+dt{color:#666}dd{margin:0;text-align:right;word-break:break-all}
+</style>
+</head>
+<body>
+<div class="card">
+<h1>{{if .Shop}}{{.Shop}} · {{end}}Bukti Transaksi</h1>
+<span class="status {{.StatusClass}}">{{.Status}}</span>
+<dl>
+<dt>Ref</dt><dd>{{.OrderRef}}</dd>
+<dt>Produk</dt><dd>{{.Product}}</dd>
+{{if .Customer}}<dt>Tujuan</dt><dd>{{.Customer}}</dd>{{end}}
+{{if .Quantity}}<dt>Jumlah</dt><dd>{{.Quantity}}</dd>{{end}}
+{{if .Amount}}<dt>Harga</dt><dd>{{.Amount}}</dd>{{end}}
+{{if .SN}}<dt>SN</dt><dd>{{.SN}}</dd>{{end}}
+{{range $i, $sn := .SNs}}<dt>SN {{inc $i}}</dt><dd>{{$sn}}</dd>{{end}}
+<dt>Dibuat</dt><dd>{{.CreatedAt}}</dd>
+<dt>Diperbarui</dt><dd>{{.UpdatedAt}}</dd>
+</dl>
+</div>
+</body>
+</html>
+`))
+
+type receiptView struct {
+	Shop        string
+	OrderRef    string
+	Status      string
+	StatusClass string
+	Product     string
+	Customer    string
+	Amount      string
+	SN          string
+	// Quantity and SNs are set for a batch, whose page sums up every unit.
+	Quantity  int
+	SNs       []string
+	CreatedAt string
+	UpdatedAt string
+}
+
+// handleReceipt serves the public receipt page for a signed token (GET /r/{token}).
+func (s *Server) handleReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.deps.Repository == nil || s.deps.Receipts == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ref, ok := s.deps.Receipts.Verify(r.PathValue("token"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	order, err := s.deps.Repository.GetOrderByRef(r.Context(), ref)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		s.logger.Error("failed loading receipt order", "error", err, "order_ref", ref)
+		http.Error(w, "failed loading receipt", http.StatusInternalServerError)
+		return
+	}
+
+	view := newReceiptView(order, s.deps.ShopName)
+	units, err := receipt.LoadBatch(r.Context(), s.deps.Repository, order)
+	if err != nil {
+		s.logger.Error("failed loading receipt batch", "error", err, "order_ref", ref)
+		http.Error(w, "failed loading receipt", http.StatusInternalServerError)
+		return
+	}
+	if units != nil {
+		view = newBatchReceiptView(units, s.deps.ShopName)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := receiptTemplate.Execute(w, view); err != nil {
+		s.logger.Warn("failed rendering receipt", "error", err, "order_ref", ref)
+	}
+}
+
+func newReceiptView(order *repo.Order, shop string) receiptView {
+	status := strings.ToLower(strings.TrimSpace(order.Status))
+	view := receiptView{
+		Shop:        shop,
+		OrderRef:    order.OrderRef,
+		Status:      receipt.StatusLabel(status),
+		StatusClass: status,
+		Product:     order.ProductCode,
+		Customer:    receipt.MaskCustomerID(metadataString(order.Metadata, "customer_id")),
+		SN:          metadataString(order.Metadata, "sn"),
+		CreatedAt:   order.CreatedAt.In(receipt.Zone).Format("02 Jan 2006 15:04 MST"),
+		UpdatedAt:   order.UpdatedAt.In(receipt.Zone).Format("02 Jan 2006 15:04 MST"),
+	}
+	if order.Amount > 0 {
+		view.Amount = money.FromRupiah(order.Amount).String()
+	}
+	return view
+}
+
+// newBatchReceiptView describes the units of a batch, in unit order, as one
+// receipt under the batch ref.
+func newBatchReceiptView(units []repo.Order, shop string) receiptView {
+	doc := receipt.NewBatchDocument(units, shop, money.Indonesian)
+	view := newReceiptView(&units[0], shop)
+	view.OrderRef = doc.OrderRef
+	view.Status = receipt.StatusLabel(doc.Status)
+	view.StatusClass = doc.Status
+	view.SN = ""
+	view.Quantity = doc.Quantity
+	view.SNs = doc.SNs
+	view.Amount = ""
+	if doc.Amount > 0 {
+		view.Amount = doc.Amount.String()
+	}
+	view.UpdatedAt = doc.IssuedAt.In(receipt.Zone).Format("02 Jan 2006 15:04 MST")
+	return view
+}
+
+func metadataString(meta map[string]any, key string) string {
+	if meta == nil {
+		return ""
+	}
+	if val, ok := meta[key].(string); ok {
+		return strings.TrimSpace(val)
+	}
+	return ""
+}
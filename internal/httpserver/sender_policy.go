@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type senderPolicyPayload struct {
+	Mode          string     `json:"mode"`
+	Numbers       []string   `json:"numbers"`
+	CountryCodes  []string   `json:"country_codes"`
+	ContactsOnly  bool       `json:"contacts_only"`
+	BlockBusiness bool       `json:"block_business"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+func toSenderPolicyPayload(p repo.SenderPolicy) senderPolicyPayload {
+	payload := senderPolicyPayload{
+		Mode:          p.Mode,
+		Numbers:       p.Numbers,
+		CountryCodes:  p.CountryCodes,
+		ContactsOnly:  p.ContactsOnly,
+		BlockBusiness: p.BlockBusiness,
+	}
+	if payload.Numbers == nil {
+		payload.Numbers = []string{}
+	}
+	if payload.CountryCodes == nil {
+		payload.CountryCodes = []string{}
+	}
+	if !p.UpdatedAt.IsZero() {
+		payload.UpdatedAt = &p.UpdatedAt
+	}
+	return payload
+}
+
+// handleSenderPolicy shows (GET) and replaces (PUT {mode, numbers,
+// country_codes, contacts_only, block_business}) the policy deciding which
+// senders the bot serves. Changes reach the bot within a minute.
+func (s *Server) handleSenderPolicy(w http.ResponseWriter, r *http.Request) {
+	if s.deps.Repository == nil {
+		http.Error(w, "repository unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := s.deps.Repository.GetSenderPolicy(r.Context())
+		if err != nil {
+			s.logger.Error("failed loading sender policy", "error", err)
+			http.Error(w, "failed loading sender policy", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, toSenderPolicyPayload(*policy))
+	case http.MethodPut:
+		var payload senderPolicyPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+		policy, err := normaliseSenderPolicy(payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		saved, err := s.deps.Repository.SaveSenderPolicy(r.Context(), policy)
+		if err != nil {
+			s.logger.Error("failed saving sender policy", "error", err)
+			http.Error(w, "failed saving sender policy", http.StatusInternalServerError)
+			return
+		}
+		s.logger.Info("sender policy updated", "mode", saved.Mode, "numbers", len(saved.Numbers), "country_codes", len(saved.CountryCodes), "contacts_only", saved.ContactsOnly, "block_business", saved.BlockBusiness)
+		writeJSON(w, toSenderPolicyPayload(*saved))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func normaliseSenderPolicy(payload senderPolicyPayload) (repo.SenderPolicy, error) {
+	policy := repo.SenderPolicy{
+		Mode:          strings.ToLower(strings.TrimSpace(payload.Mode)),
+		ContactsOnly:  payload.ContactsOnly,
+		BlockBusiness: payload.BlockBusiness,
+	}
+	if policy.Mode == "" {
+		policy.Mode = repo.SenderPolicyOff
+	}
+	switch policy.Mode {
+	case repo.SenderPolicyOff, repo.SenderPolicyAllowlist, repo.SenderPolicyDenylist:
+	default:
+		return policy, fmt.Errorf("mode must be off, allowlist or denylist")
+	}
+
+	for _, raw := range payload.Numbers {
+		number := digitsOnly(raw)
+		if number == "" {
+			continue
+		}
+		if strings.HasPrefix(number, "0") {
+			return policy, fmt.Errorf("number %q must start with its country code", raw)
+		}
+		policy.Numbers = append(policy.Numbers, number)
+	}
+	for _, raw := range payload.CountryCodes {
+		code := digitsOnly(raw)
+		if code == "" {
+			continue
+		}
+		if len(code) > 3 || strings.HasPrefix(code, "0") {
+			return policy, fmt.Errorf("invalid country code %q", raw)
+		}
+		policy.CountryCodes = append(policy.CountryCodes, code)
+	}
+	// An empty allowlist would silence the bot for everyone but admins.
+	if policy.Mode == repo.SenderPolicyAllowlist && len(policy.Numbers) == 0 && len(policy.CountryCodes) == 0 {
+		return policy, fmt.Errorf("allowlist mode needs numbers or country_codes")
+	}
+	return policy, nil
+}
+
+func digitsOnly(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
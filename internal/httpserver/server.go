@@ -9,11 +9,17 @@ import (
 	"strings"
 	"time"
 
+	"bot-jual/internal/activity"
 	"bot-jual/internal/atl"
 	"bot-jual/internal/cache"
+	"bot-jual/internal/chaos"
+	"bot-jual/internal/httpx"
+	"bot-jual/internal/logging"
 	"bot-jual/internal/metrics"
 	"bot-jual/internal/nlu"
+	"bot-jual/internal/receipt"
 	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -21,14 +27,39 @@ import (
 // Handlers groups optional HTTP handlers to mount.
 type Handlers struct {
 	AtlanticWebhook http.Handler
+	// MidtransWebhook receives Midtrans payment notifications; nil leaves
+	// /webhook/midtrans unmounted.
+	MidtransWebhook http.Handler
+	// XenditWebhook receives Xendit invoice and QR callbacks; nil leaves
+	// /webhook/xendit unmounted.
+	XenditWebhook http.Handler
 }
 
 // Dependencies exposes core dependencies to handlers that need them.
 type Dependencies struct {
-	Repository repo.Repository
-	Redis      *cache.Redis
-	NLU        *nlu.Client
-	Atlantic   *atl.Client
+	Repository  repo.Repository
+	Redis       *cache.Redis
+	NLU         *nlu.Client
+	Atlantic    *atl.Client
+	DeadLetters DeadLetterReplayer
+	// AtlanticProbe reports supplier reachability for the readiness check.
+	AtlanticProbe *atl.HealthProbe
+	// ProxyProbes report outbound proxy reachability; a dead proxy is shown
+	// but does not fail readiness on its own.
+	ProxyProbes []*httpx.ProxyProbe
+	// Receipts verifies public receipt links; nil disables /r/{token}.
+	Receipts *receipt.Links
+	// ShopName titles receipt pages.
+	ShopName string
+	// WhatsApp reports the session state; an unpaired session fails readiness.
+	WhatsApp *wa.Client
+	// Logs holds recent log entries for /admin/logs; nil disables it.
+	Logs *logging.Ring
+	// Events feeds /admin/events; nil disables it.
+	Events *activity.Bus
+	// Chaos injects dependency failures for staging drills; nil disables
+	// /admin/api/chaos.
+	Chaos *chaos.Injector
 }
 
 // Server wraps an http.Server with predefined routes.
@@ -39,6 +70,7 @@ type Server struct {
 	handlers   Handlers
 	deps       Dependencies
 	basePath   string
+	adminAuth  AdminAuth
 }
 
 // New creates a new HTTP server listening on addr with health and metrics endpoints.
@@ -52,14 +84,62 @@ func New(addr string, logger *slog.Logger, metricRegistry *metrics.Metrics, hand
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", healthHandler)
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/readyz", server.handleReady)
+	if metricRegistry != nil {
+		mux.Handle("/metrics", metricRegistry.Handler())
+	} else {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
+	mux.HandleFunc("/r/{token}", server.handleReceipt)
+	mux.HandleFunc(dashboardPath, server.handleDashboard)
+	mux.HandleFunc("/admin/api/dashboard", server.handleDashboardData)
 	mux.HandleFunc("/admin/reload-price-cache", server.handleReloadPriceCache)
+	mux.HandleFunc("/admin/logs", server.handleLogs)
+	mux.HandleFunc("/admin/events", server.handleEvents)
+	mux.HandleFunc("/admin/api/atlantic/breakers", server.handleAtlanticBreakers)
+	mux.HandleFunc("/admin/api/chaos", server.handleChaos)
+	mux.HandleFunc("/admin/api/whatsapp/pairing", server.handleWhatsAppPairing)
+	mux.HandleFunc("/admin/api/aliases", server.handleCatalogAliases)
+	mux.HandleFunc("/admin/api/curation", server.handleCuratedProducts)
+	mux.HandleFunc("/admin/api/pricing-rules", server.handlePricingRules)
+	mux.HandleFunc("/admin/api/product-names", server.handleProductNames)
+	mux.HandleFunc("/admin/api/sender-policy", server.handleSenderPolicy)
+	mux.HandleFunc("/admin/api/product-stats", server.handleProductStats)
+	mux.HandleFunc("/admin/api/reports/acquisition", server.handleAcquisitionReport)
+	mux.HandleFunc("/admin/api/users/{id}", server.handleDeleteUser)
+	mux.HandleFunc("/admin/api/users/{id}/messages", server.handleUserMessages)
+	mux.HandleFunc("/admin/api/webhooks/dead-letters", server.handleDeadLetters)
+	mux.HandleFunc("/admin/api/webhooks/dead-letters/{id}", server.handleDeadLetter)
+	mux.HandleFunc("/admin/api/webhooks/dead-letters/{id}/replay", server.handleReplayDeadLetter)
+	mux.HandleFunc("/admin/api/notifications/undelivered", server.handleUndeliveredNotifications)
+	mux.HandleFunc("/admin/api/notifications/{id}/retry", server.handleRetryNotification)
+	mux.HandleFunc("/admin/api/campaigns", server.handleCampaigns)
+	mux.HandleFunc("/admin/api/campaigns/{id}", server.handleCampaign)
+	mux.HandleFunc("/admin/api/campaigns/{id}/cancel", server.handleCancelCampaign)
+	mux.HandleFunc("/admin/api/flash-sales", server.handleFlashSales)
+	mux.HandleFunc("/admin/api/flash-sales/{id}", server.handleFlashSale)
+	mux.HandleFunc("/admin/api/segments/preview", server.handleSegmentPreview)
+	mux.HandleFunc("/admin/api/catalog/versions", server.handleCatalogVersions)
+	mux.HandleFunc("/admin/api/catalog/pin", server.handleCatalogPin)
+	mux.HandleFunc("/admin/api/catalog/rollback", server.handleCatalogRollback)
+	mux.HandleFunc("/admin/api/keys", server.handleAPIKeys)
+	mux.HandleFunc("/admin/api/keys/{id}/disable", server.handleDisableAPIKey)
+	mux.HandleFunc("/admin/api/keys/{id}/quota", server.handleAPIKeyQuota)
+	mux.HandleFunc("/admin/api/merchant-webhooks", server.handleMerchantWebhooks)
+	mux.HandleFunc("/admin/api/merchant-webhooks/{id}", server.handleMerchantWebhook)
+	mux.HandleFunc("/admin/api/merchant-webhooks/{id}/deliveries", server.handleMerchantWebhookDeliveries)
 
 	if handlers.AtlanticWebhook != nil {
 		mux.Handle("/webhook/atlantic", handlers.AtlanticWebhook)
 	}
+	if handlers.MidtransWebhook != nil {
+		mux.Handle("/webhook/midtrans", handlers.MidtransWebhook)
+	}
+	if handlers.XenditWebhook != nil {
+		mux.Handle("/webhook/xendit", handlers.XenditWebhook)
+	}
 
-	handler := mountWithBasePath(server.basePath, mux)
+	handler := mountWithBasePath(server.basePath, server.requireAdmin(mux))
 
 	server.httpServer = &http.Server{
 		Addr:              addr,
@@ -119,6 +199,20 @@ func (s *Server) handleReloadPriceCache(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handleAtlanticBreakers lists the circuit breaker of every Atlantic endpoint
+// that has failed since startup.
+func (s *Server) handleAtlanticBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.deps.Atlantic == nil {
+		http.Error(w, "atlantic client unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, map[string]any{"breakers": s.deps.Atlantic.BreakerStates()})
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -127,6 +221,73 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
+// handleReady reports database and supplier reachability. Supplier state comes
+// from the background probe so readiness checks never call Atlantic directly.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ready := true
+	checks := map[string]any{}
+	if s.deps.Repository != nil {
+		if err := s.deps.Repository.Ping(r.Context()); err != nil {
+			ready = false
+			checks["database"] = map[string]any{"up": false, "error": err.Error()}
+		} else {
+			checks["database"] = map[string]any{"up": true}
+		}
+	}
+	if s.deps.AtlanticProbe != nil {
+		st := s.deps.AtlanticProbe.Status()
+		check := map[string]any{"up": st.Up}
+		if st.Error != "" {
+			check["error"] = st.Error
+		}
+		if !st.CheckedAt.IsZero() {
+			check["checked_at"] = st.CheckedAt
+		}
+		if !st.Up {
+			ready = false
+		}
+		checks["atlantic"] = check
+	}
+	if s.deps.WhatsApp != nil {
+		st := s.deps.WhatsApp.Status()
+		check := map[string]any{"up": st.State == wa.StatePaired, "state": st.State, "connected": st.Connected}
+		if st.State != wa.StatePaired {
+			ready = false
+		}
+		if !st.LoggedOutAt.IsZero() {
+			check["logged_out_at"] = st.LoggedOutAt
+			check["reason"] = st.Reason
+		}
+		checks["whatsapp"] = check
+	}
+	for _, probe := range s.deps.ProxyProbes {
+		st := probe.Status()
+		check := map[string]any{"up": st.Up}
+		if st.Error != "" {
+			check["error"] = st.Error
+		}
+		if !st.CheckedAt.IsZero() {
+			check["checked_at"] = st.CheckedAt
+		}
+		checks["proxy_"+probe.Name()] = check
+	}
+
+	status := "ok"
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(map[string]any{"status": status, "checks": checks}); err != nil {
+		s.logger.Warn("failed encoding readiness response", "error", err)
+	}
+}
+
 func writeJSON(w http.ResponseWriter, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(data); err != nil {
@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"bot-jual/internal/wa"
+
+	"github.com/skip2/go-qrcode"
+)
+
+type pairingPayload struct {
+	State       string     `json:"state"`
+	Connected   bool       `json:"connected"`
+	QRCode      string     `json:"qr_code,omitempty"`
+	QRUpdatedAt *time.Time `json:"qr_updated_at,omitempty"`
+	PairCode    string     `json:"pair_code,omitempty"`
+	LoggedOutAt *time.Time `json:"logged_out_at,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// handleWhatsAppPairing reports the WhatsApp session state and, while the bot
+// waits to be paired, the current QR code and any phone-number pairing code.
+// With ?format=png the QR code is rendered as an image ready to scan; QR codes
+// rotate every 20 seconds or so.
+func (s *Server) handleWhatsAppPairing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.deps.WhatsApp == nil {
+		http.Error(w, "whatsapp client unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	st := s.deps.WhatsApp.Status()
+	if r.URL.Query().Get("format") == "png" {
+		if st.State != wa.StatePairing || st.QRCode == "" {
+			http.Error(w, "no pairing code available", http.StatusNotFound)
+			return
+		}
+		png, err := qrcode.Encode(st.QRCode, qrcode.Medium, 320)
+		if err != nil {
+			s.logger.Error("failed rendering pairing qr", "error", err)
+			http.Error(w, "failed rendering qr code", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(png)
+		return
+	}
+
+	payload := pairingPayload{
+		State:     st.State,
+		Connected: st.Connected,
+		QRCode:    st.QRCode,
+		PairCode:  st.PairCode,
+		Reason:    st.Reason,
+	}
+	if !st.QRUpdatedAt.IsZero() {
+		payload.QRUpdatedAt = &st.QRUpdatedAt
+	}
+	if !st.LoggedOutAt.IsZero() {
+		payload.LoggedOutAt = &st.LoggedOutAt
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, payload)
+}
@@ -0,0 +1,72 @@
+// Package httpx builds the HTTP transports used for outbound provider calls.
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultDialTimeout         = 5 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// TransportConfig tunes connection reuse. Zero fields use the defaults above.
+type TransportConfig struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+	// Proxy routes every request through an HTTP or SOCKS5 proxy; nil falls
+	// back to the HTTP_PROXY/HTTPS_PROXY environment variables.
+	Proxy *url.URL
+}
+
+// NewTransport returns a transport that keeps enough idle connections per
+// host for supplier and Gemini calls to skip the TCP and TLS handshake.
+// Share one transport between clients to share its connection pool; clients
+// behind different proxies need their own.
+func NewTransport(cfg TransportConfig) *http.Transport {
+	cfg = cfg.withDefaults()
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != nil {
+		proxy = http.ProxyURL(cfg.Proxy)
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          max(defaultMaxIdleConns, cfg.MaxIdleConnsPerHost),
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+func (c TransportConfig) withDefaults() TransportConfig {
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = defaultDialTimeout
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = defaultKeepAlive
+	}
+	if c.TLSHandshakeTimeout <= 0 {
+		c.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+	return c
+}
@@ -0,0 +1,22 @@
+package httpx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransportDefaults(t *testing.T) {
+	tr := NewTransport(TransportConfig{MaxIdleConnsPerHost: 32})
+	if tr.MaxIdleConnsPerHost != 32 {
+		t.Fatalf("MaxIdleConnsPerHost = %d, want 32", tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != defaultIdleConnTimeout || tr.TLSHandshakeTimeout != defaultTLSHandshakeTimeout {
+		t.Fatalf("zero fields did not take defaults: idle=%v tls=%v", tr.IdleConnTimeout, tr.TLSHandshakeTimeout)
+	}
+	if tr.MaxIdleConns < tr.MaxIdleConnsPerHost {
+		t.Fatalf("MaxIdleConns %d below per-host limit %d", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+	if tr.ExpectContinueTimeout != time.Second {
+		t.Fatalf("ExpectContinueTimeout = %v", tr.ExpectContinueTimeout)
+	}
+}
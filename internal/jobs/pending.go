@@ -0,0 +1,145 @@
+// Package jobs holds background workers that keep local state in sync with
+// the supplier when webhooks alone are not enough.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/metrics"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/supplier"
+)
+
+// PendingEventType marks updates produced by the poller in order metadata.
+const PendingEventType = "transaction_poll"
+
+// StatusChecker asks the supplier for a transaction's current status; the
+// Atlantic client and the supplier router are both one.
+type StatusChecker interface {
+	TransactionStatus(ctx context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error)
+}
+
+// PendingPollerConfig groups knobs for the pending order poller.
+type PendingPollerConfig struct {
+	// Interval between polls. Zero disables the poller.
+	Interval time.Duration
+	// MinAge gives the webhook a head start before an order is polled.
+	MinAge time.Duration
+	// MaxAge stops polling orders the supplier has long forgotten.
+	MaxAge    time.Duration
+	BatchSize int
+}
+
+// PendingPoller settles orders whose status webhook never arrived by asking
+// the supplier that took them directly. Final statuses are fed through the webhook processor so
+// the order, stats and customer notification follow the usual path.
+type PendingPoller struct {
+	repo      repo.Repository
+	checker   StatusChecker
+	processor atl.WebhookProcessor
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
+	cfg       PendingPollerConfig
+	now       func() time.Time
+}
+
+// NewPendingPoller constructs a pending order poller.
+func NewPendingPoller(repository repo.Repository, checker StatusChecker, processor atl.WebhookProcessor, metrics *metrics.Metrics, logger *slog.Logger, cfg PendingPollerConfig) *PendingPoller {
+	if cfg.MinAge <= 0 {
+		cfg.MinAge = 2 * time.Minute
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	return &PendingPoller{
+		repo:      repository,
+		checker:   checker,
+		processor: processor,
+		metrics:   metrics,
+		logger:    logger.With("component", "pending_poller"),
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// Run polls unsettled orders until ctx is cancelled.
+func (p *PendingPoller) Run(ctx context.Context) {
+	if p.cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+func (p *PendingPoller) poll(ctx context.Context) {
+	now := p.now()
+	orders, err := p.repo.ListPendingOrders(ctx, now.Add(-p.cfg.MaxAge), now.Add(-p.cfg.MinAge), p.cfg.BatchSize)
+	if err != nil {
+		p.logger.Warn("failed listing pending orders", "error", err)
+		return
+	}
+	for i := range orders {
+		if ctx.Err() != nil {
+			return
+		}
+		p.pollOrder(ctx, &orders[i])
+	}
+}
+
+func (p *PendingPoller) pollOrder(ctx context.Context, order *repo.Order) {
+	resp, err := p.checker.TransactionStatus(ctx, supplier.StatusRequest(order))
+	if markErr := p.repo.MarkOrderPolled(ctx, order.OrderRef, p.now()); markErr != nil {
+		p.logger.Warn("failed marking order polled", "error", markErr, "order_ref", order.OrderRef)
+	}
+	if err != nil {
+		p.observe("error")
+		p.logger.Warn("pending order poll failed", "error", err, "order_ref", order.OrderRef)
+		return
+	}
+	if resp.Status != "success" && resp.Status != "failed" {
+		p.observe("pending")
+		return
+	}
+
+	event := atl.WebhookEvent{
+		Type:       PendingEventType,
+		ReceivedAt: p.now(),
+		Transaction: &atl.TransactionUpdate{
+			StatusUpdate: atl.StatusUpdate{
+				Ref:       order.OrderRef,
+				RawStatus: resp.Status,
+				Status:    resp.Status,
+				Message:   resp.Message,
+				Payload:   resp.Raw,
+			},
+			SN: resp.SN,
+		},
+	}
+	if err := p.processor.HandleAtlanticEvent(ctx, event); err != nil {
+		p.observe("error")
+		p.logger.Warn("failed applying polled order status", "error", err, "order_ref", order.OrderRef)
+		return
+	}
+	p.observe("settled")
+	p.logger.Info("pending order settled by poll", "order_ref", order.OrderRef, "status", resp.Status)
+}
+
+func (p *PendingPoller) observe(outcome string) {
+	if p.metrics != nil {
+		p.metrics.PendingPolls.WithLabelValues(outcome).Inc()
+	}
+}
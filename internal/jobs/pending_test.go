@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+type pendingRepo struct {
+	repo.Repository
+	orders        []repo.Order
+	after, before time.Time
+	polled        []string
+}
+
+func (r *pendingRepo) ListPendingOrders(_ context.Context, createdAfter, createdBefore time.Time, _ int) ([]repo.Order, error) {
+	r.after, r.before = createdAfter, createdBefore
+	return r.orders, nil
+}
+
+func (r *pendingRepo) MarkOrderPolled(_ context.Context, orderRef string, _ time.Time) error {
+	r.polled = append(r.polled, orderRef)
+	return nil
+}
+
+type fakeChecker struct {
+	statuses map[string]string
+	types    map[string]string
+}
+
+func (c *fakeChecker) TransactionStatus(_ context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error) {
+	c.types[req.RefID] = req.Type
+	status, ok := c.statuses[req.RefID]
+	if !ok {
+		return nil, errors.New("timeout")
+	}
+	return &atl.TransactionStatusResponse{RefID: req.RefID, Status: status, SN: "SN-" + req.RefID}, nil
+}
+
+type recordingProcessor struct {
+	events []atl.WebhookEvent
+}
+
+func (p *recordingProcessor) HandleAtlanticEvent(_ context.Context, event atl.WebhookEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestPendingPollerSettlesFinalStatuses(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := &pendingRepo{orders: []repo.Order{
+		{OrderRef: "TRX-OK"},
+		{OrderRef: "TRX-WAIT"},
+		{OrderRef: "TRX-FAIL", Metadata: map[string]any{"product_type": "pascabayar"}},
+		{OrderRef: "TRX-ERR"},
+	}}
+	checker := &fakeChecker{
+		statuses: map[string]string{"TRX-OK": "success", "TRX-WAIT": "processing", "TRX-FAIL": "failed"},
+		types:    map[string]string{},
+	}
+	proc := &recordingProcessor{}
+	p := NewPendingPoller(r, checker, proc, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), PendingPollerConfig{
+		Interval: time.Minute,
+		MinAge:   5 * time.Minute,
+		MaxAge:   time.Hour,
+	})
+	p.now = func() time.Time { return now }
+
+	p.poll(context.Background())
+
+	if !r.after.Equal(now.Add(-time.Hour)) || !r.before.Equal(now.Add(-5*time.Minute)) {
+		t.Fatalf("window = %s..%s", r.after, r.before)
+	}
+	if len(r.polled) != 4 {
+		t.Fatalf("polled = %v, want all four orders marked", r.polled)
+	}
+	if checker.types["TRX-FAIL"] != "pascabayar" || checker.types["TRX-OK"] != "prabayar" {
+		t.Fatalf("product types = %v", checker.types)
+	}
+	if len(proc.events) != 2 {
+		t.Fatalf("events = %+v, want success and failure only", proc.events)
+	}
+	got := proc.events[0]
+	if got.Type != PendingEventType || got.Transaction == nil || got.Transaction.Ref != "TRX-OK" || got.Transaction.Status != "success" || got.Transaction.SN != "SN-TRX-OK" {
+		t.Fatalf("first event = %+v", got)
+	}
+	if proc.events[1].Transaction.Ref != "TRX-FAIL" || proc.events[1].Transaction.Status != "failed" {
+		t.Fatalf("second event = %+v", proc.events[1].Transaction)
+	}
+}
@@ -0,0 +1,224 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-jual/internal/nlu"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// Step is one message of a synthetic conversation. Intent is what the fake
+// LLM answers it with; leave it empty for messages the engine handles without
+// the LLM, such as commands and draft replies.
+type Step struct {
+	Text   string
+	Intent nlu.IntentResult
+}
+
+// DefaultScript walks a typical purchase: greet, compare prices, order, pick
+// a payment method and look at the order history.
+var DefaultScript = []Step{
+	{Text: "halo kak", Intent: nlu.IntentResult{Intent: "smalltalk_greeting", Confidence: 0.9, Reply: "Halo kak! Mau cari apa hari ini?"}},
+	{Text: "harga pulsa telkomsel", Intent: nlu.IntentResult{Intent: "price_lookup", Confidence: 0.9, Entities: map[string]string{"product_query": "pulsa telkomsel", "provider": "telkomsel", "product_type": "prabayar"}}},
+	{Text: "beli TSEL10 081200000000", Intent: nlu.IntentResult{Intent: "create_prepaid", Confidence: 0.9, Entities: map[string]string{"product_code": "TSEL10", "customer_id": "081200000000"}}},
+	{Text: "saldo"},
+	{Text: "/riwayat"},
+}
+
+// Processor is the engine entry point being driven.
+type Processor interface {
+	ProcessMessage(ctx context.Context, evt *events.Message)
+}
+
+// Config shapes a run.
+type Config struct {
+	// Rate is how many conversations start per second.
+	Rate float64
+	// Duration is how long new conversations keep starting; the run then
+	// waits for those in flight.
+	Duration time.Duration
+	// Think is the pause between a reply and the user's next message.
+	Think  time.Duration
+	Script []Step
+	// FirstPhone numbers synthetic users consecutively from here, so runs
+	// against the same database can use fresh users.
+	FirstPhone uint64
+}
+
+// Percentiles summarises a latency distribution.
+type Percentiles struct {
+	P50, P90, P99, Max time.Duration
+}
+
+// StepReport is the latency of one scripted message across conversations.
+type StepReport struct {
+	Text    string
+	Latency Percentiles
+}
+
+// Report is the outcome of a run. Latency is the time ProcessMessage took to
+// handle a message, replies included.
+type Report struct {
+	Conversations int
+	Messages      int
+	Elapsed       time.Duration
+	Latency       Percentiles
+	Steps         []StepReport
+}
+
+// Throughput is messages handled per second over the whole run.
+func (r Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Messages) / r.Elapsed.Seconds()
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "conversations: %d\nmessages:      %d\nelapsed:       %s\nthroughput:    %.1f msg/s\n",
+		r.Conversations, r.Messages, r.Elapsed.Round(time.Millisecond), r.Throughput())
+	fmt.Fprintf(&sb, "\n%-32s %10s %10s %10s %10s\n", "step", "p50", "p90", "p99", "max")
+	row := func(name string, p Percentiles) {
+		fmt.Fprintf(&sb, "%-32s %10s %10s %10s %10s\n", name, round(p.P50), round(p.P90), round(p.P99), round(p.Max))
+	}
+	for _, step := range r.Steps {
+		row(truncate(step.Text, 32), step.Latency)
+	}
+	row("all", r.Latency)
+	return sb.String()
+}
+
+// Run starts cfg.Rate conversations per second for cfg.Duration against p
+// and waits for all of them to finish.
+func Run(ctx context.Context, p Processor, cfg Config) Report {
+	script := cfg.Script
+	if len(script) == 0 {
+		script = DefaultScript
+	}
+	rate := cfg.Rate
+	if rate <= 0 {
+		rate = 1
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+
+	var (
+		mu      sync.Mutex
+		samples = make([][]time.Duration, len(script))
+		wg      sync.WaitGroup
+	)
+	record := func(step int, d time.Duration) {
+		mu.Lock()
+		samples[step] = append(samples[step], d)
+		mu.Unlock()
+	}
+
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	conversations := 0
+	for {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			converse(ctx, p, script, cfg, n, record)
+		}(conversations)
+		conversations++
+
+		select {
+		case <-ctx.Done():
+		case now := <-ticker.C:
+			if now.Before(deadline) {
+				continue
+			}
+		}
+		break
+	}
+	wg.Wait()
+
+	report := Report{Conversations: conversations, Elapsed: time.Since(start)}
+	var all []time.Duration
+	for i, step := range script {
+		report.Messages += len(samples[i])
+		all = append(all, samples[i]...)
+		report.Steps = append(report.Steps, StepReport{Text: step.Text, Latency: percentiles(samples[i])})
+	}
+	report.Latency = percentiles(all)
+	return report
+}
+
+// converse plays script as synthetic user n, one message after another.
+func converse(ctx context.Context, p Processor, script []Step, cfg Config, n int, record func(step int, d time.Duration)) {
+	jid := types.NewJID(strconv.FormatUint(cfg.FirstPhone+uint64(n), 10), types.DefaultUserServer)
+	for i, step := range script {
+		if ctx.Err() != nil {
+			return
+		}
+		if i > 0 && cfg.Think > 0 {
+			select {
+			case <-time.After(cfg.Think):
+			case <-ctx.Done():
+				return
+			}
+		}
+		evt := &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: jid, Sender: jid},
+				ID:            types.MessageID(fmt.Sprintf("LT%d-%d", n, i)),
+				PushName:      fmt.Sprintf("Load %d", n),
+				Timestamp:     time.Now(),
+			},
+			Message: &waProto.Message{Conversation: proto.String(step.Text)},
+		}
+		began := time.Now()
+		p.ProcessMessage(ctx, evt)
+		record(i, time.Since(began))
+	}
+}
+
+// percentiles uses the nearest-rank method.
+func percentiles(samples []time.Duration) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) time.Duration {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return sorted[idx]
+	}
+	return Percentiles{P50: rank(0.50), P90: rank(0.90), P99: rank(0.99), Max: sorted[len(sorted)-1]}
+}
+
+func round(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit-3] + "..."
+}
@@ -0,0 +1,58 @@
+package loadtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type countingProcessor struct {
+	mu      sync.Mutex
+	senders map[string]int
+}
+
+func (p *countingProcessor) ProcessMessage(_ context.Context, evt *events.Message) {
+	time.Sleep(time.Millisecond)
+	p.mu.Lock()
+	p.senders[evt.Info.Sender.User]++
+	p.mu.Unlock()
+}
+
+func TestRunPlaysScriptPerUser(t *testing.T) {
+	proc := &countingProcessor{senders: map[string]int{}}
+	script := []Step{{Text: "halo"}, {Text: "/riwayat"}}
+	report := Run(context.Background(), proc, Config{Rate: 200, Duration: 50 * time.Millisecond, Script: script, FirstPhone: 628100})
+
+	if report.Conversations < 2 || report.Messages != report.Conversations*len(script) {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(proc.senders) != report.Conversations {
+		t.Fatalf("senders = %d, conversations = %d", len(proc.senders), report.Conversations)
+	}
+	for sender, n := range proc.senders {
+		if n != len(script) {
+			t.Fatalf("sender %s got %d messages", sender, n)
+		}
+	}
+	if len(report.Steps) != len(script) || report.Latency.P50 < time.Millisecond || report.Throughput() <= 0 {
+		t.Fatalf("latency report = %+v", report)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	got := percentiles(samples)
+	want := Percentiles{P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond, Max: 100 * time.Millisecond}
+	if got != want {
+		t.Fatalf("percentiles = %+v, want %+v", got, want)
+	}
+	if (percentiles(nil) != Percentiles{}) {
+		t.Fatalf("empty percentiles not zero")
+	}
+}
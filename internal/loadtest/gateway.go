@@ -0,0 +1,64 @@
+// Package loadtest drives synthetic conversations through the conversation
+// engine against in-process stand-ins for WhatsApp, Atlantic and the LLM, to
+// size instances before promotions.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Gateway is a WhatsApp sender that only counts what the engine sends,
+// optionally taking Latency per send like a real socket round trip.
+type Gateway struct {
+	Latency time.Duration
+
+	texts  atomic.Int64
+	images atomic.Int64
+	docs   atomic.Int64
+}
+
+// SendText records a text reply.
+func (g *Gateway) SendText(ctx context.Context, _ types.JID, _ string) error {
+	g.texts.Add(1)
+	return g.wait(ctx)
+}
+
+// SendImage records an image reply such as a QRIS code.
+func (g *Gateway) SendImage(ctx context.Context, _ types.JID, _ []byte, _, _ string) error {
+	g.images.Add(1)
+	return g.wait(ctx)
+}
+
+// SendDocument records a document reply such as a PDF receipt.
+func (g *Gateway) SendDocument(ctx context.Context, _ types.JID, _ []byte, _, _, _ string) error {
+	g.docs.Add(1)
+	return g.wait(ctx)
+}
+
+// DownloadMedia fails: synthetic conversations are text only.
+func (g *Gateway) DownloadMedia(context.Context, *waProto.Message) ([]byte, string, error) {
+	return nil, "", errors.New("loadtest gateway has no media")
+}
+
+// Sent returns how many messages of each kind went out.
+func (g *Gateway) Sent() (texts, images, documents int64) {
+	return g.texts.Load(), g.images.Load(), g.docs.Load()
+}
+
+func (g *Gateway) wait(ctx context.Context) error {
+	if g.Latency <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(g.Latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
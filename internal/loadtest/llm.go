@@ -0,0 +1,89 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"bot-jual/internal/nlu"
+)
+
+// userMessageMarker precedes the user's message in intent prompts.
+const userMessageMarker = "\nPesan user:\n"
+
+// LLM is a fake OpenAI-compatible /chat/completions endpoint. Intent prompts
+// are answered with the scripted intent of the message they carry, and
+// anything else (summaries, unscripted text) with a neutral answer.
+type LLM struct {
+	srv     *httptest.Server
+	latency time.Duration
+	intents map[string]nlu.IntentResult
+	calls   atomic.Int64
+}
+
+// NewLLM starts the fake endpoint for script; Close stops it.
+func NewLLM(latency time.Duration, script []Step) *LLM {
+	l := &LLM{latency: latency, intents: make(map[string]nlu.IntentResult, len(script))}
+	for _, step := range script {
+		if step.Intent.Intent != "" {
+			l.intents[strings.TrimSpace(step.Text)] = step.Intent
+		}
+	}
+	l.srv = httptest.NewServer(http.HandlerFunc(l.serve))
+	return l
+}
+
+// URL is the base URL to configure the openai provider with.
+func (l *LLM) URL() string { return l.srv.URL }
+
+// Calls returns how many completions were served.
+func (l *LLM) Calls() int64 { return l.calls.Load() }
+
+// Close shuts the server down.
+func (l *LLM) Close() { l.srv.Close() }
+
+func (l *LLM) serve(w http.ResponseWriter, r *http.Request) {
+	l.calls.Add(1)
+	var req struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	if l.latency > 0 {
+		select {
+		case <-time.After(l.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	var prompt string
+	if len(req.Messages) > 0 {
+		_ = json.Unmarshal(req.Messages[0].Content, &prompt)
+	}
+	content := "Ringkasan percakapan uji beban."
+	if _, message, ok := strings.Cut(prompt, userMessageMarker); ok {
+		intent, found := l.intents[strings.TrimSpace(message)]
+		if !found {
+			intent = nlu.IntentResult{Intent: "fallback", Confidence: 0.3}
+		}
+		if intent.Entities == nil {
+			intent.Entities = map[string]string{}
+		}
+		data, _ := json.Marshal(intent)
+		content = string(data)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{"message": map[string]any{"role": "assistant", "content": content}}},
+		"usage":   map[string]any{"total_tokens": len(prompt)/4 + len(content)/4},
+	})
+}
@@ -0,0 +1,91 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+)
+
+// Supplier is a fake Atlantic H2H API. Every call answers successfully after
+// Latency, and transactions complete immediately with a serial number.
+type Supplier struct {
+	srv     *httptest.Server
+	latency time.Duration
+	calls   atomic.Int64
+	seq     atomic.Int64
+}
+
+// supplierProducts is the price list the fake supplier serves.
+var supplierProducts = []map[string]any{
+	{"code": "TSEL5", "name": "Telkomsel 5.000", "category": "Pulsa", "provider": "TELKOMSEL", "nominal": "5000", "price": 5350, "status": "available"},
+	{"code": "TSEL10", "name": "Telkomsel 10.000", "category": "Pulsa", "provider": "TELKOMSEL", "nominal": "10000", "price": 10400, "status": "available"},
+	{"code": "TSEL25", "name": "Telkomsel 25.000", "category": "Pulsa", "provider": "TELKOMSEL", "nominal": "25000", "price": 25150, "status": "available"},
+	{"code": "ISAT10", "name": "Indosat 10.000", "category": "Pulsa", "provider": "INDOSAT", "nominal": "10000", "price": 10725, "status": "available"},
+	{"code": "XLD10", "name": "XL Data 10GB", "category": "Data", "provider": "XL", "price": 41250, "status": "available"},
+	{"code": "PLN20", "name": "PLN 20.000", "category": "PLN", "provider": "PLN", "nominal": "20000", "price": 20650, "status": "available"},
+	{"code": "ML86", "name": "Mobile Legends 86 Diamonds", "category": "Games", "provider": "MOBILE LEGENDS", "price": 21500, "status": "available"},
+}
+
+// NewSupplier starts the fake API; Close stops it.
+func NewSupplier(latency time.Duration) *Supplier {
+	s := &Supplier{latency: latency}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL is the base URL to configure the Atlantic client with.
+func (s *Supplier) URL() string { return s.srv.URL }
+
+// Calls returns how many API requests the supplier answered.
+func (s *Supplier) Calls() int64 { return s.calls.Load() }
+
+// Close shuts the server down.
+func (s *Supplier) Close() { s.srv.Close() }
+
+func (s *Supplier) serve(w http.ResponseWriter, r *http.Request) {
+	s.calls.Add(1)
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	_ = r.ParseForm()
+
+	var data any
+	switch r.URL.Path {
+	case "/layanan/price_list":
+		data = supplierProducts
+	case "/get_profile":
+		data = map[string]any{"name": "Load Test", "username": "loadtest", "balance": 100000000, "status": "active"}
+	case "/transaksi/create", "/transaksi/status":
+		n := s.seq.Add(1)
+		data = map[string]any{
+			"id":      fmt.Sprintf("TRX%08d", n),
+			"reff_id": r.Form.Get("reff_id"),
+			"code":    r.Form.Get("code"),
+			"target":  r.Form.Get("target"),
+			"status":  "success",
+			"sn":      fmt.Sprintf("LT%012d", n),
+		}
+	case "/deposit/metode":
+		data = []map[string]any{{"metode": "QRIS", "type": "ewallet", "name": "QRIS", "min": 1000, "max": 10000000, "fee": 0, "fee_persen": 0.7, "status": "aktif"}}
+	case "/deposit/create", "/deposit/status":
+		n := s.seq.Add(1)
+		data = map[string]any{
+			"id":        fmt.Sprintf("DEP%08d", n),
+			"reff_id":   r.Form.Get("reff_id"),
+			"nominal":   r.Form.Get("nominal"),
+			"status":    "pending",
+			"qr_string": "00020101021226670016COM.EXAMPLE.WWW01189360000000000000000215000000000000000303UMI5204541153033605802ID5909LOAD TEST6007JAKARTA6304ABCD",
+		}
+	default:
+		data = map[string]any{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"status": true, "message": "success", "code": 200, "data": data})
+}
@@ -1,22 +1,63 @@
 package logging
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
 )
 
-// NewLogger initialises an slog.Logger with the provided level string.
+// Config selects the log level and output format.
+type Config struct {
+	Level string
+	// Format is "text" (default) or "json".
+	Format string
+	// ComponentLevels overrides Level for loggers tagged with a "component"
+	// attribute, keyed by component name.
+	ComponentLevels map[string]string
+	// Ring, when set, also receives every record that is written.
+	Ring *Ring
+}
+
+// NewLogger initialises a text slog.Logger with the provided level string.
 func NewLogger(levelStr string) *slog.Logger {
-	level := parseLevel(levelStr)
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-	})
-	return slog.New(handler)
+	return New(Config{Level: levelStr})
+}
+
+// New initialises an slog.Logger writing to stdout.
+func New(cfg Config) *slog.Logger {
+	return slog.New(newHandler(os.Stdout, cfg))
+}
+
+func newHandler(w io.Writer, cfg Config) slog.Handler {
+	base := parseLevel(cfg.Level)
+	levels := make(map[string]slog.Level, len(cfg.ComponentLevels))
+	lowest := base
+	for component, levelStr := range cfg.ComponentLevels {
+		level := parseLevel(levelStr)
+		levels[component] = level
+		lowest = min(lowest, level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lowest}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	if cfg.Ring != nil {
+		handler = &ringHandler{inner: handler, ring: cfg.Ring}
+	}
+	if len(levels) == 0 {
+		return handler
+	}
+	return &componentHandler{inner: handler, base: base, levels: levels, level: base}
 }
 
-func parseLevel(levelStr string) slog.Leveler {
-	switch strings.ToLower(levelStr) {
+func parseLevel(levelStr string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(levelStr)) {
 	case "debug":
 		return slog.LevelDebug
 	case "warn", "warning":
@@ -27,3 +68,41 @@ func parseLevel(levelStr string) slog.Leveler {
 		return slog.LevelInfo
 	}
 }
+
+// componentHandler filters records by the level configured for the
+// logger's component, as set with logger.With("component", name).
+type componentHandler struct {
+	inner  slog.Handler
+	base   slog.Level
+	levels map[string]slog.Level
+	level  slog.Level
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key != "component" {
+			continue
+		}
+		next.level = h.base
+		if level, ok := h.levels[a.Value.String()]; ok {
+			next.level = level
+		}
+	}
+	return &next
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithGroup(name)
+	return &next
+}
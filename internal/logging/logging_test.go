@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newHandler(&buf, Config{Level: "info", Format: "json"}))
+	logger.With("component", "convo").Info("hello", "user_id", "u1")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output %q is not json: %v", buf.String(), err)
+	}
+	if rec["msg"] != "hello" || rec["component"] != "convo" || rec["user_id"] != "u1" {
+		t.Fatalf("record = %v", rec)
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newHandler(&buf, Config{
+		Level:           "info",
+		ComponentLevels: map[string]string{"convo": "debug", "atlantic_webhook": "error"},
+	}))
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.With("component", "convo").Debug("convo debug")
+	webhook := logger.With("component", "atlantic_webhook")
+	webhook.Warn("webhook warn")
+	webhook.Error("webhook error")
+	logger.With("component", "broadcast").Debug("broadcast debug")
+
+	out := buf.String()
+	for _, want := range []string{"root info", "convo debug", "webhook error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"root debug", "webhook warn", "broadcast debug"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestRingKeepsRecentEntries(t *testing.T) {
+	ring := NewRing(3)
+	logger := slog.New(newHandler(io.Discard, Config{Level: "debug", Ring: ring}))
+	convo := logger.With("component", "convo")
+	convo.Debug("one")
+	convo.Info("two", "user_id", "u1")
+	logger.With("component", "http").Warn("three", "error", errors.New("boom"))
+	convo.WithGroup("req").Error("four", "path", "/x")
+
+	all := ring.Entries(Filter{MinLevel: slog.LevelDebug})
+	if len(all) != 3 || all[0].Message != "two" || all[2].Message != "four" {
+		t.Fatalf("entries = %+v", all)
+	}
+	if all[0].Attrs["user_id"] != "u1" || all[1].Attrs["error"] != "boom" || all[2].Attrs["req.path"] != "/x" {
+		t.Fatalf("attrs = %v, %v, %v", all[0].Attrs, all[1].Attrs, all[2].Attrs)
+	}
+
+	convoOnly := ring.Entries(Filter{Component: "convo", MinLevel: slog.LevelError})
+	if len(convoOnly) != 1 || convoOnly[0].Message != "four" {
+		t.Fatalf("filtered = %+v", convoOnly)
+	}
+
+	live, cancel := ring.Subscribe(Filter{Component: "http"})
+	convo.Info("ignored")
+	logger.With("component", "http").Info("five")
+	if e := <-live; e.Message != "five" {
+		t.Fatalf("live entry = %+v", e)
+	}
+	cancel()
+	if _, open := <-live; open {
+		t.Fatal("channel still open after cancel")
+	}
+}
@@ -0,0 +1,192 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is a log record kept in a Ring.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Component string         `json:"component,omitempty"`
+	Message   string         `json:"msg"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// Filter selects entries from a Ring. The zero Filter matches info and
+// above from every component.
+type Filter struct {
+	Component string
+	MinLevel  slog.Level
+	// Limit keeps only the newest entries.
+	Limit int
+}
+
+func (f Filter) match(e Entry) bool {
+	return e.level >= f.MinLevel && (f.Component == "" || e.Component == f.Component)
+}
+
+// ParseLevel maps debug, info, warn or error to its slog level, defaulting
+// to info.
+func ParseLevel(level string) slog.Level {
+	return parseLevel(level)
+}
+
+// Ring keeps the most recent log entries in memory so operators can read
+// them without access to the process output.
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	subs    map[chan Entry]Filter
+}
+
+// NewRing keeps the last size entries.
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]Entry, max(size, 1)), subs: map[chan Entry]Filter{}}
+}
+
+func (r *Ring) add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	r.full = r.full || r.next == 0
+	for ch, f := range r.subs {
+		if !f.match(e) {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+			// A slow reader misses entries rather than blocking logging.
+		}
+	}
+}
+
+// Entries returns the kept entries matching f, oldest first.
+func (r *Ring) Entries(f Filter) []Entry {
+	r.mu.Lock()
+	ordered := make([]Entry, 0, len(r.entries))
+	if r.full {
+		ordered = append(ordered, r.entries[r.next:]...)
+	}
+	ordered = append(ordered, r.entries[:r.next]...)
+	r.mu.Unlock()
+
+	res := make([]Entry, 0, len(ordered))
+	for _, e := range ordered {
+		if f.match(e) {
+			res = append(res, e)
+		}
+	}
+	if f.Limit > 0 && len(res) > f.Limit {
+		res = res[len(res)-f.Limit:]
+	}
+	return res
+}
+
+// Subscribe delivers entries matching f as they are logged until cancel is
+// called, which also closes the channel. Entries logged while the channel
+// is full are dropped for this subscriber.
+func (r *Ring) Subscribe(f Filter) (entries <-chan Entry, cancel func()) {
+	ch := make(chan Entry, 256)
+	r.mu.Lock()
+	r.subs[ch] = f
+	r.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subs, ch)
+			r.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// ringHandler copies every record its inner handler receives into a Ring.
+type ringHandler struct {
+	inner     slog.Handler
+	ring      *Ring
+	component string
+	attrs     []slog.Attr
+	group     string
+}
+
+func (h *ringHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *ringHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := Entry{
+		Time:      r.Time,
+		Level:     r.Level.String(),
+		Component: h.component,
+		Message:   r.Message,
+		level:     r.Level,
+	}
+	if len(h.attrs) > 0 || r.NumAttrs() > 0 {
+		e.Attrs = make(map[string]any, len(h.attrs)+r.NumAttrs())
+		for _, a := range h.attrs {
+			e.Attrs[a.Key] = attrValue(a.Value)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			e.Attrs[h.group+a.Key] = attrValue(a.Value)
+			return true
+		})
+	}
+	h.ring.add(e)
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithAttrs(attrs)
+	next.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if a.Key == "component" && h.group == "" {
+			next.component = a.Value.String()
+			continue
+		}
+		next.attrs = append(next.attrs, slog.Attr{Key: h.group + a.Key, Value: a.Value})
+	}
+	return &next
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithGroup(name)
+	next.group = h.group + name + "."
+	return &next
+}
+
+// attrValue converts a value into something encoding/json renders readably;
+// errors, for one, would otherwise encode as {}.
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := make(map[string]any, len(v.Group()))
+		for _, a := range v.Group() {
+			group[a.Key] = attrValue(a.Value)
+		}
+		return group
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
@@ -1,79 +1,266 @@
 package metrics
 
 import (
-	"sync"
+	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Metrics stores Prometheus collectors used across the service.
 type Metrics struct {
 	WAIncomingMessages *prometheus.CounterVec
 	WAOutgoingMessages *prometheus.CounterVec
+	WAReceipts         *prometheus.CounterVec
+	WADeliveryLatency  prometheus.Histogram
+	WAResends          *prometheus.CounterVec
+	WAPaired           prometheus.Gauge
+	WALogouts          *prometheus.CounterVec
 	GeminiRequests     *prometheus.CounterVec
 	GeminiLatency      *prometheus.HistogramVec
+	GeminiQueueDepth   prometheus.Gauge
+	GeminiShed         *prometheus.CounterVec
+	LLMRequests        *prometheus.CounterVec
 	AtlanticRequests   *prometheus.CounterVec
 	AtlanticLatency    *prometheus.HistogramVec
+	AtlanticUp         prometheus.Gauge
+	AtlanticAnomalies  *prometheus.CounterVec
+	AtlanticCircuit    *prometheus.GaugeVec
+	ProxyUp            *prometheus.GaugeVec
+	CatalogProducts    *prometheus.GaugeVec
+	WebhookEvents      *prometheus.CounterVec
+	WebhookQueueDepth  prometheus.Gauge
+	DegradedResponses  *prometheus.CounterVec
+	RedisUp            prometheus.Gauge
+	StuckOrders        *prometheus.CounterVec
+	PendingPolls       *prometheus.CounterVec
+	Backups            *prometheus.CounterVec
+	BackupLastSuccess  prometheus.Gauge
+	AdminAuth          *prometheus.CounterVec
+	SenderBlocked      *prometheus.CounterVec
 	Errors             *prometheus.CounterVec
+
+	registry *prometheus.Registry
 }
 
-var (
-	regOnce         sync.Once
-	metricsInstance *Metrics
-)
+// Options configures a metrics set.
+type Options struct {
+	Namespace string
+	// Env and Instance are attached to every series as env and instance_id
+	// labels, so environments and replicas scraped into one Prometheus do
+	// not collide. Empty values are left out.
+	Env      string
+	Instance string
+}
 
-// Registry builds and registers the metrics singleton with optional namespace.
-func Registry(namespace string) *Metrics {
-	regOnce.Do(func() {
-		metricsInstance = &Metrics{
-			WAIncomingMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Namespace: namespace,
-				Name:      "wa_incoming_messages_total",
-				Help:      "Total incoming WhatsApp messages processed.",
-			}, []string{"type"}),
-			WAOutgoingMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Namespace: namespace,
-				Name:      "wa_outgoing_messages_total",
-				Help:      "Total outgoing WhatsApp messages sent.",
-			}, []string{"type"}),
-			GeminiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Namespace: namespace,
-				Name:      "gemini_requests_total",
-				Help:      "Total Gemini API requests by outcome.",
-			}, []string{"status"}),
-			GeminiLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
-				Namespace: namespace,
-				Name:      "gemini_request_duration_seconds",
-				Help:      "Latency distribution for Gemini API calls.",
-				Buckets:   prometheus.DefBuckets,
-			}, []string{"status"}),
-			AtlanticRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Namespace: namespace,
-				Name:      "atlantic_requests_total",
-				Help:      "Total Atlantic API requests by endpoint and status.",
-			}, []string{"endpoint", "status"}),
-			AtlanticLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
-				Namespace: namespace,
-				Name:      "atlantic_request_duration_seconds",
-				Help:      "Latency distribution for Atlantic API requests.",
-				Buckets:   prometheus.DefBuckets,
-			}, []string{"endpoint", "status"}),
-			Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Namespace: namespace,
-				Name:      "errors_total",
-				Help:      "Total errors grouped by component.",
-			}, []string{"component"}),
-		}
+// New builds a metrics set on its own registry, along with the Go runtime
+// and process collectors. Sets are independent, so tests can create one
+// each and assert on its values.
+func New(opts Options) *Metrics {
+	namespace := opts.Namespace
+	m := &Metrics{
+		WAIncomingMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wa_incoming_messages_total",
+			Help:      "Total incoming WhatsApp messages processed.",
+		}, []string{"type"}),
+		WAOutgoingMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wa_outgoing_messages_total",
+			Help:      "Total outgoing WhatsApp messages sent.",
+		}, []string{"type"}),
+		WAReceipts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wa_message_receipts_total",
+			Help:      "Outgoing WhatsApp text messages confirmed delivered or read.",
+		}, []string{"status"}),
+		WADeliveryLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "wa_delivery_latency_seconds",
+			Help:      "Time from sending a WhatsApp text message to its delivery receipt.",
+			Buckets:   []float64{1, 5, 15, 60, 300, 900, 3600},
+		}),
+		WAResends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wa_message_resends_total",
+			Help:      "Undelivered WhatsApp text messages by resend outcome.",
+		}, []string{"outcome"}),
+		WAPaired: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "wa_paired",
+			Help:      "Whether the WhatsApp session is paired (1) or waiting for a QR scan (0).",
+		}),
+		WALogouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wa_logouts_total",
+			Help:      "WhatsApp sessions logged out remotely, by reason.",
+		}, []string{"reason"}),
+		GeminiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gemini_requests_total",
+			Help:      "Total Gemini API requests by outcome.",
+		}, []string{"status"}),
+		GeminiLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "gemini_request_duration_seconds",
+			Help:      "Latency distribution for Gemini API calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		GeminiQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gemini_queue_depth",
+			Help:      "Gemini requests waiting for a free call slot.",
+		}),
+		GeminiShed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gemini_requests_shed_total",
+			Help:      "Gemini requests rejected by load shedding, by priority.",
+		}, []string{"priority"}),
+		LLMRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_requests_total",
+			Help:      "LLM calls by provider and outcome, including fallbacks.",
+		}, []string{"provider", "outcome"}),
+		AtlanticRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "atlantic_requests_total",
+			Help:      "Total Atlantic API requests by endpoint and status.",
+		}, []string{"endpoint", "status"}),
+		AtlanticLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "atlantic_request_duration_seconds",
+			Help:      "Latency distribution for Atlantic API requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		AtlanticUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "atlantic_up",
+			Help:      "Whether the last Atlantic health probe succeeded (1) or failed (0).",
+		}),
+		AtlanticAnomalies: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "atlantic_payload_anomalies_total",
+			Help:      "Atlantic responses that needed heuristic parsing, by endpoint and anomaly kind.",
+		}, []string{"endpoint", "kind"}),
+		AtlanticCircuit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "atlantic_circuit_state",
+			Help:      "Atlantic circuit breaker state per endpoint: 0 closed, 1 half-open, 2 open.",
+		}, []string{"endpoint"}),
+		ProxyUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "proxy_up",
+			Help:      "Whether the last dial to a client's outbound proxy succeeded (1) or failed (0).",
+		}, []string{"client"}),
+		CatalogProducts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "catalog_products",
+			Help:      "Products in the last fetched Atlantic price list by type, category and availability (available or unavailable).",
+		}, []string{"type", "category", "availability"}),
+		WebhookEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "atlantic_webhook_events_total",
+			Help:      "Atlantic webhook events by queue outcome.",
+		}, []string{"outcome"}),
+		WebhookQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "atlantic_webhook_queue_depth",
+			Help:      "Atlantic webhook events waiting for a worker.",
+		}),
+		DegradedResponses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "degraded_responses_total",
+			Help:      "Replies served in degraded mode, by the dependency that was down.",
+		}, []string{"dependency"}),
+		RedisUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "redis_up",
+			Help:      "Whether the last Redis health check succeeded (1) or failed (0).",
+		}),
+		StuckOrders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "stuck_orders_total",
+			Help:      "Orders past their SLA escalated to the admins.",
+		}, []string{"outcome"}),
+		PendingPolls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pending_order_polls_total",
+			Help:      "Status polls of unsettled orders, by whether the order settled, is still pending or the poll failed.",
+		}, []string{"outcome"}),
+		Backups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "backups_total",
+			Help:      "Database backups by outcome.",
+		}, []string{"outcome"}),
+		BackupLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "backup_last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful database backup.",
+		}),
+		AdminAuth: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "admin_auth_total",
+			Help:      "Admin API authentication attempts by credential type and outcome.",
+		}, []string{"method", "outcome"}),
+		SenderBlocked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sender_policy_blocked_total",
+			Help:      "Incoming messages ignored by the sender policy, by reason.",
+		}, []string{"reason"}),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Total errors grouped by component.",
+		}, []string{"component"}),
+	}
+
+	labels := prometheus.Labels{}
+	if opts.Env != "" {
+		labels["env"] = opts.Env
+	}
+	if opts.Instance != "" {
+		labels["instance_id"] = opts.Instance
+	}
+	m.registry = prometheus.NewRegistry()
+	prometheus.WrapRegistererWith(labels, m.registry).MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.WAIncomingMessages,
+		m.WAOutgoingMessages,
+		m.WAReceipts,
+		m.WADeliveryLatency,
+		m.WAResends,
+		m.WAPaired,
+		m.WALogouts,
+		m.GeminiRequests,
+		m.GeminiLatency,
+		m.GeminiQueueDepth,
+		m.GeminiShed,
+		m.LLMRequests,
+		m.AtlanticRequests,
+		m.AtlanticLatency,
+		m.AtlanticUp,
+		m.AtlanticAnomalies,
+		m.AtlanticCircuit,
+		m.ProxyUp,
+		m.CatalogProducts,
+		m.WebhookEvents,
+		m.WebhookQueueDepth,
+		m.DegradedResponses,
+		m.RedisUp,
+		m.StuckOrders,
+		m.PendingPolls,
+		m.Backups,
+		m.BackupLastSuccess,
+		m.AdminAuth,
+		m.SenderBlocked,
+		m.Errors,
+	)
+	return m
+}
 
-		prometheus.MustRegister(
-			metricsInstance.WAIncomingMessages,
-			metricsInstance.WAOutgoingMessages,
-			metricsInstance.GeminiRequests,
-			metricsInstance.GeminiLatency,
-			metricsInstance.AtlanticRequests,
-			metricsInstance.AtlanticLatency,
-			metricsInstance.Errors,
-		)
-	})
-	return metricsInstance
+// Handler serves the metrics set in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
 }
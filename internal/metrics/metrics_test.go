@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewSetsAreIndependent(t *testing.T) {
+	a := New(Options{Namespace: "test"})
+	b := New(Options{Namespace: "test"})
+
+	a.Errors.WithLabelValues("boom").Inc()
+	a.Errors.WithLabelValues("boom").Inc()
+	b.Errors.WithLabelValues("boom").Inc()
+
+	if got := testutil.ToFloat64(a.Errors.WithLabelValues("boom")); got != 2 {
+		t.Fatalf("a errors = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(b.Errors.WithLabelValues("boom")); got != 1 {
+		t.Fatalf("b errors = %v, want 1", got)
+	}
+}
+
+func TestNewAttachesEnvAndInstanceLabels(t *testing.T) {
+	m := New(Options{Namespace: "test", Env: "staging", Instance: "bot-1"})
+	m.Errors.WithLabelValues("boom").Inc()
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_errors_total" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, pair := range family.GetMetric()[0].GetLabel() {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		if labels["env"] != "staging" || labels["instance_id"] != "bot-1" {
+			t.Fatalf("labels = %v, want env=staging instance_id=bot-1", labels)
+		}
+		return
+	}
+	t.Fatal("test_errors_total not gathered")
+}
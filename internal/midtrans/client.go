@@ -0,0 +1,336 @@
+// Package midtrans takes deposits through Midtrans, as QRIS and bank
+// virtual account charges or a Snap payment page, in the same shapes the
+// Atlantic client uses so the rest of the bot treats both alike.
+package midtrans
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/httpx"
+	"bot-jual/internal/money"
+)
+
+const (
+	sandboxBaseURL    = "https://api.sandbox.midtrans.com"
+	productionBaseURL = "https://api.midtrans.com"
+	sandboxSnapURL    = "https://app.sandbox.midtrans.com/snap/v1/transactions"
+	productionSnapURL = "https://app.midtrans.com/snap/v1/transactions"
+)
+
+// vaBanks are the deposit methods charged as a bank virtual account.
+var vaBanks = map[string]bool{"bri": true, "bni": true, "bca": true, "permata": true, "cimb": true}
+
+// Config holds Midtrans client configuration.
+type Config struct {
+	ServerKey string
+	// Production switches from the sandbox to the live API.
+	Production bool
+	// BaseURL and SnapURL override the API endpoints; empty uses the ones
+	// Production selects.
+	BaseURL string
+	SnapURL string
+	Timeout time.Duration
+	// Transport carries Midtrans calls; nil builds a dedicated pooled transport.
+	Transport http.RoundTripper
+}
+
+// Client talks to the Midtrans Core and Snap APIs.
+type Client struct {
+	logger    *slog.Logger
+	serverKey string
+	baseURL   string
+	snapURL   string
+	http      *http.Client
+}
+
+// New creates a Midtrans client.
+func New(cfg Config, logger *slog.Logger) *Client {
+	base, snap := sandboxBaseURL, sandboxSnapURL
+	if cfg.Production {
+		base, snap = productionBaseURL, productionSnapURL
+	}
+	if cfg.BaseURL != "" {
+		base = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	if cfg.SnapURL != "" {
+		snap = cfg.SnapURL
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = httpx.NewTransport(httpx.TransportConfig{})
+	}
+	return &Client{
+		logger:    logger.With("component", "midtrans"),
+		serverKey: cfg.ServerKey,
+		baseURL:   base,
+		snapURL:   snap,
+		http:      &http.Client{Timeout: timeout, Transport: transport},
+	}
+}
+
+// transaction is the Core API's charge and status response.
+type transaction struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	PaymentType       string `json:"payment_type"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	TransactionTime   string `json:"transaction_time"`
+	ExpiryTime        string `json:"expiry_time"`
+	QRString          string `json:"qr_string"`
+	Actions           []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"actions"`
+	VANumbers []struct {
+		Bank     string `json:"bank"`
+		VANumber string `json:"va_number"`
+	} `json:"va_numbers"`
+	PermataVANumber string `json:"permata_va_number"`
+}
+
+// NormalizeStatus maps a Midtrans transaction status to the statuses used
+// for Atlantic deposits: success, pending or failed. Refunds and other
+// statuses are returned as they are.
+func NormalizeStatus(transactionStatus, fraudStatus string) string {
+	switch strings.ToLower(strings.TrimSpace(transactionStatus)) {
+	case "settlement":
+		return "success"
+	case "capture":
+		if strings.EqualFold(fraudStatus, "challenge") {
+			return "pending"
+		}
+		return "success"
+	case "pending", "authorize":
+		return "pending"
+	case "deny", "cancel", "expire", "failure":
+		return "failed"
+	case "":
+		return "unknown"
+	default:
+		return strings.ToLower(strings.TrimSpace(transactionStatus))
+	}
+}
+
+// CreateDeposit charges req.Amount under req.RefID as the Midtrans order ID.
+// QRIS and the virtual account banks are charged directly so the reply can
+// carry the QR or account number; any other method opens a Snap payment
+// page where the user picks how to pay.
+func (c *Client) CreateDeposit(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, error) {
+	amount := req.Amount.Rupiah()
+	details := map[string]any{"order_id": req.RefID, "gross_amount": amount}
+	method := strings.ToLower(strings.TrimSpace(req.Method))
+	switch {
+	case method == "qris":
+		return c.charge(ctx, req, map[string]any{
+			"payment_type":        "qris",
+			"transaction_details": details,
+		})
+	case vaBanks[method]:
+		return c.charge(ctx, req, map[string]any{
+			"payment_type":        "bank_transfer",
+			"transaction_details": details,
+			"bank_transfer":       map[string]any{"bank": method},
+		})
+	default:
+		return c.snap(ctx, req, map[string]any{"transaction_details": details})
+	}
+}
+
+func (c *Client) charge(ctx context.Context, req atl.DepositRequest, body map[string]any) (*atl.DepositResponse, error) {
+	var tx transaction
+	raw, err := c.do(ctx, http.MethodPost, c.baseURL+"/v2/charge", body, &tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.err("charge"); err != nil {
+		return nil, err
+	}
+	checkout := map[string]any{
+		"nominal":    req.Amount.Rupiah(),
+		"expired_at": tx.ExpiryTime,
+	}
+	resp := &atl.DepositResponse{
+		ID:        tx.TransactionID,
+		RefID:     tx.OrderID,
+		Status:    NormalizeStatus(tx.TransactionStatus, tx.FraudStatus),
+		Message:   tx.StatusMessage,
+		ExpiredAt: tx.ExpiryTime,
+		Amount:    req.Amount,
+		NetAmount: req.Amount,
+		Raw:       raw,
+	}
+	if tx.QRString != "" {
+		checkout["qr_string"] = tx.QRString
+		resp.QRString = tx.QRString
+	}
+	for _, action := range tx.Actions {
+		if action.Name == "generate-qr-code" {
+			checkout["qr_image"] = action.URL
+			resp.QRImage = action.URL
+		}
+	}
+	if len(tx.VANumbers) > 0 {
+		checkout["bank"] = strings.ToUpper(tx.VANumbers[0].Bank)
+		checkout["va_number"] = tx.VANumbers[0].VANumber
+	} else if tx.PermataVANumber != "" {
+		checkout["bank"] = "PERMATA"
+		checkout["va_number"] = tx.PermataVANumber
+	}
+	resp.Checkout = checkout
+	return resp, nil
+}
+
+func (c *Client) snap(ctx context.Context, req atl.DepositRequest, body map[string]any) (*atl.DepositResponse, error) {
+	var out struct {
+		Token         string   `json:"token"`
+		RedirectURL   string   `json:"redirect_url"`
+		ErrorMessages []string `json:"error_messages"`
+	}
+	raw, err := c.do(ctx, http.MethodPost, c.snapURL, body, &out)
+	if err != nil {
+		return nil, err
+	}
+	if out.RedirectURL == "" {
+		message := strings.Join(out.ErrorMessages, "; ")
+		if message == "" {
+			message = "no payment page returned"
+		}
+		return nil, fmt.Errorf("midtrans snap error: %s", message)
+	}
+	return &atl.DepositResponse{
+		ID:     req.RefID,
+		RefID:  req.RefID,
+		Status: "pending",
+		Checkout: map[string]any{
+			"nominal":      req.Amount.Rupiah(),
+			"redirect_url": out.RedirectURL,
+			"token":        out.Token,
+		},
+		Amount:    req.Amount,
+		NetAmount: req.Amount,
+		Raw:       raw,
+	}, nil
+}
+
+// DepositStatus looks a deposit up by its Midtrans order or transaction ID.
+// An order Midtrans has no transaction for was never paid and reports failed.
+func (c *Client) DepositStatus(ctx context.Context, id string) (*atl.DepositStatusResponse, error) {
+	var tx transaction
+	raw, err := c.do(ctx, http.MethodGet, c.baseURL+"/v2/"+url.PathEscape(id)+"/status", nil, &tx)
+	if err != nil {
+		return nil, err
+	}
+	status := NormalizeStatus(tx.TransactionStatus, tx.FraudStatus)
+	if tx.StatusCode == "404" {
+		status = "failed"
+	} else if err := tx.err("status"); err != nil {
+		return nil, err
+	}
+	amount := money.FromRupiah(parseAmount(tx.GrossAmount))
+	return &atl.DepositStatusResponse{
+		ID:        tx.TransactionID,
+		RefID:     tx.OrderID,
+		Status:    status,
+		Method:    tx.PaymentType,
+		Amount:    amount,
+		NetAmount: amount,
+		CreatedAt: tx.TransactionTime,
+		Raw:       raw,
+	}, nil
+}
+
+// CancelDeposit expires a pending deposit so it can no longer be paid.
+func (c *Client) CancelDeposit(ctx context.Context, id string) (*atl.DepositCancelResponse, error) {
+	var tx transaction
+	raw, err := c.do(ctx, http.MethodPost, c.baseURL+"/v2/"+url.PathEscape(id)+"/expire", nil, &tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.err("expire"); err != nil {
+		return nil, err
+	}
+	return &atl.DepositCancelResponse{
+		ID:        tx.TransactionID,
+		Status:    NormalizeStatus(tx.TransactionStatus, tx.FraudStatus),
+		CreatedAt: tx.TransactionTime,
+		Raw:       raw,
+	}, nil
+}
+
+// err reports a Core API failure, which Midtrans signals in status_code
+// rather than always in the HTTP status.
+func (tx transaction) err(op string) error {
+	if strings.HasPrefix(tx.StatusCode, "2") {
+		return nil
+	}
+	return fmt.Errorf("midtrans %s error: %s (code=%s)", op, tx.StatusMessage, tx.StatusCode)
+}
+
+// do sends body as JSON and decodes the response into dest, returning it
+// as a map too for auditing.
+func (c *Client) do(ctx context.Context, method, endpoint string, body any, dest any) (map[string]any, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.SetBasicAuth(c.serverKey, "")
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("midtrans request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("midtrans http %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return nil, fmt.Errorf("decode response (http %d): %w", resp.StatusCode, err)
+	}
+	var raw map[string]any
+	_ = json.Unmarshal(data, &raw)
+	return raw, nil
+}
+
+// parseAmount reads Midtrans amounts such as "10000.00" as whole rupiah.
+func parseAmount(val string) int64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(f)
+}
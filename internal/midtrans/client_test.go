@@ -0,0 +1,116 @@
+package midtrans
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(Config{ServerKey: "SB-key", BaseURL: srv.URL, SnapURL: srv.URL + "/snap"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCreateDepositChargesQRIS(t *testing.T) {
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != "SB-key" || r.URL.Path != "/v2/charge" {
+			t.Errorf("request %s with auth %q", r.URL.Path, user)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"status_code":"201","transaction_id":"tx-1","order_id":"dep-1","gross_amount":"10000.00","transaction_status":"pending","qr_string":"000201","expiry_time":"2024-05-01 12:15:00","actions":[{"name":"generate-qr-code","url":"https://qr.example/1"}]}`)
+	})
+
+	resp, err := c.CreateDeposit(context.Background(), atl.DepositRequest{Method: "qris", Amount: money.FromRupiah(10000), RefID: "dep-1"})
+	if err != nil {
+		t.Fatalf("create deposit: %v", err)
+	}
+	if body["payment_type"] != "qris" {
+		t.Fatalf("payment_type = %v", body["payment_type"])
+	}
+	if resp.ID != "tx-1" || resp.Status != "pending" || resp.Checkout["qr_image"] != "https://qr.example/1" || resp.Checkout["qr_string"] != "000201" {
+		t.Fatalf("response = %+v", resp)
+	}
+	if resp.NetAmount.Rupiah() != 10000 {
+		t.Fatalf("net amount = %d", resp.NetAmount.Rupiah())
+	}
+}
+
+func TestCreateDepositBankTransferAndSnap(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/snap" {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"token":"tok","redirect_url":"https://pay.example/tok"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status_code":"201","transaction_id":"tx-2","order_id":"dep-2","transaction_status":"pending","va_numbers":[{"bank":"bri","va_number":"8888123"}]}`)
+	})
+
+	bank, err := c.CreateDeposit(context.Background(), atl.DepositRequest{Method: "bri", Amount: money.FromRupiah(50000), RefID: "dep-2"})
+	if err != nil {
+		t.Fatalf("bank deposit: %v", err)
+	}
+	if bank.Checkout["bank"] != "BRI" || bank.Checkout["va_number"] != "8888123" {
+		t.Fatalf("bank checkout = %v", bank.Checkout)
+	}
+	snap, err := c.CreateDeposit(context.Background(), atl.DepositRequest{Method: "ewallet", Amount: money.FromRupiah(50000), RefID: "dep-3"})
+	if err != nil {
+		t.Fatalf("snap deposit: %v", err)
+	}
+	if snap.ID != "dep-3" || snap.Checkout["redirect_url"] != "https://pay.example/tok" {
+		t.Fatalf("snap response = %+v", snap)
+	}
+}
+
+func TestDepositStatus(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/dep-paid/status":
+			_, _ = io.WriteString(w, `{"status_code":"200","transaction_id":"tx-1","order_id":"dep-paid","gross_amount":"10000.00","payment_type":"qris","transaction_status":"settlement"}`)
+		case "/v2/dep-missing/status":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"status_code":"404","status_message":"Transaction doesn't exist."}`)
+		default:
+			_, _ = io.WriteString(w, `{"status_code":"401","status_message":"Unknown Merchant server_key/id"}`)
+		}
+	})
+	ctx := context.Background()
+
+	paid, err := c.DepositStatus(ctx, "dep-paid")
+	if err != nil || paid.Status != "success" || paid.Amount.Rupiah() != 10000 {
+		t.Fatalf("paid = %+v, %v", paid, err)
+	}
+	missing, err := c.DepositStatus(ctx, "dep-missing")
+	if err != nil || missing.Status != "failed" {
+		t.Fatalf("missing = %+v, %v", missing, err)
+	}
+	if _, err := c.DepositStatus(ctx, "dep-other"); err == nil {
+		t.Fatalf("expected error for a rejected request")
+	}
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	cases := []struct{ status, fraud, want string }{
+		{"settlement", "", "success"},
+		{"capture", "accept", "success"},
+		{"capture", "challenge", "pending"},
+		{"pending", "", "pending"},
+		{"expire", "", "failed"},
+		{"deny", "", "failed"},
+		{"refund", "", "refund"},
+	}
+	for _, tc := range cases {
+		if got := NormalizeStatus(tc.status, tc.fraud); got != tc.want {
+			t.Errorf("NormalizeStatus(%q, %q) = %q, want %q", tc.status, tc.fraud, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package midtrans
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/metrics"
+)
+
+// EventType marks deposit updates that came from a Midtrans notification.
+const EventType = "midtrans_payment"
+
+// Notification is the body Midtrans posts when a transaction changes.
+type Notification struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	GrossAmount       string `json:"gross_amount"`
+	PaymentType       string `json:"payment_type"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+// Signature is the signature_key Midtrans computes for a notification:
+// the hex SHA-512 of order ID, status code, gross amount and server key.
+func Signature(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether the notification was signed with serverKey.
+func (n Notification) Verify(serverKey string) bool {
+	if serverKey == "" || n.SignatureKey == "" {
+		return false
+	}
+	want := Signature(n.OrderID, n.StatusCode, n.GrossAmount, serverKey)
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(n.SignatureKey)), []byte(want)) == 1
+}
+
+// Event turns the notification into a deposit update for the Atlantic
+// webhook processor, so Midtrans payments settle deposits and the orders
+// waiting on them the same way Atlantic callbacks do.
+func (n Notification) Event(headers map[string]string, body []byte, receivedAt time.Time) atl.WebhookEvent {
+	var payload map[string]any
+	_ = json.Unmarshal(body, &payload)
+	amount := parseAmount(n.GrossAmount)
+	return atl.WebhookEvent{
+		Type:       EventType,
+		Headers:    headers,
+		Payload:    body,
+		ReceivedAt: receivedAt,
+		Deposit: &atl.DepositUpdate{
+			StatusUpdate: atl.StatusUpdate{
+				Ref:       n.OrderID,
+				RawStatus: n.TransactionStatus,
+				Status:    NormalizeStatus(n.TransactionStatus, n.FraudStatus),
+				Message:   n.StatusMessage,
+				Amount:    amount,
+				Payload:   payload,
+			},
+			Method:    n.PaymentType,
+			NetAmount: amount,
+		},
+	}
+}
+
+// WebhookHandler verifies Midtrans payment notifications and forwards them
+// to the webhook processor. Failures answer 500 so Midtrans retries.
+type WebhookHandler struct {
+	logger    *slog.Logger
+	metrics   *metrics.Metrics
+	serverKey string
+	processor atl.WebhookProcessor
+}
+
+// NewWebhookHandler creates a handler for notifications signed with serverKey.
+func NewWebhookHandler(logger *slog.Logger, metrics *metrics.Metrics, serverKey string, processor atl.WebhookProcessor) *WebhookHandler {
+	return &WebhookHandler{
+		logger:    logger.With("component", "midtrans_webhook"),
+		metrics:   metrics,
+		serverKey: serverKey,
+		processor: processor,
+	}
+}
+
+// ServeHTTP satisfies http.Handler.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.countError("midtrans_webhook")
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil || n.OrderID == "" {
+		h.countError("midtrans_webhook_decode")
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if !n.Verify(h.serverKey) {
+		h.countError("midtrans_webhook_auth")
+		h.logger.Warn("rejected midtrans notification with bad signature", "order_id", n.OrderID)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	event := n.Event(atl.WebhookHeaders(r.Header), body, time.Now())
+	// The deposit was stored as pending when it was created; only the
+	// outcome needs processing.
+	if event.Deposit.Status == "pending" {
+		atl.WriteWebhookOK(w)
+		return
+	}
+	if h.processor != nil {
+		if err := h.processor.HandleAtlanticEvent(r.Context(), event); err != nil {
+			h.logger.Error("failed processing midtrans notification", "error", err, "order_id", n.OrderID, "status", n.TransactionStatus)
+			h.countError("midtrans_webhook_process")
+			http.Error(w, "failed to process", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.logger.Info("midtrans notification processed", "order_id", n.OrderID, "status", n.TransactionStatus)
+	atl.WriteWebhookOK(w)
+}
+
+func (h *WebhookHandler) countError(label string) {
+	if h.metrics != nil {
+		h.metrics.Errors.WithLabelValues(label).Inc()
+	}
+}
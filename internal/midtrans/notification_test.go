@@ -0,0 +1,57 @@
+package midtrans
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+)
+
+type recordingProcessor struct {
+	events []atl.WebhookEvent
+}
+
+func (p *recordingProcessor) HandleAtlanticEvent(_ context.Context, event atl.WebhookEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func notificationBody(orderID, status, signature string) string {
+	if signature == "" {
+		signature = Signature(orderID, "200", "10000.00", "SB-key")
+	}
+	return fmt.Sprintf(`{"order_id":%q,"transaction_id":"tx-1","status_code":"200","gross_amount":"10000.00","payment_type":"qris","transaction_status":%q,"signature_key":%q}`, orderID, status, signature)
+}
+
+func TestWebhookHandlerForwardsVerifiedNotifications(t *testing.T) {
+	proc := &recordingProcessor{}
+	h := NewWebhookHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, "SB-key", proc)
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook/midtrans", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := post(notificationBody("dep-1", "settlement", strings.Repeat("0", 128))); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature answered %d", code)
+	}
+	if code := post(notificationBody("dep-1", "pending", "")); code != http.StatusOK {
+		t.Fatalf("pending answered %d", code)
+	}
+	if code := post(notificationBody("dep-1", "settlement", "")); code != http.StatusOK {
+		t.Fatalf("settlement answered %d", code)
+	}
+	if len(proc.events) != 1 {
+		t.Fatalf("forwarded %d events, want only the settlement", len(proc.events))
+	}
+	dep := proc.events[0].Deposit
+	if dep == nil || dep.Ref != "dep-1" || dep.Status != "success" || dep.Amount != 10000 || dep.NetAmount != 10000 || dep.Method != "qris" {
+		t.Fatalf("deposit update = %+v", dep)
+	}
+}
@@ -0,0 +1,67 @@
+package money
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Locale controls how amounts are written in replies.
+type Locale struct {
+	// Thousands separates digit groups.
+	Thousands string
+	// SymbolSpace writes "Rp 10.000" instead of "Rp10.000".
+	SymbolSpace bool
+}
+
+// Indonesian is the default locale: "Rp10.000".
+var Indonesian = Locale{Thousands: "."}
+
+// English groups thousands with commas: "Rp10,000".
+var English = Locale{Thousands: ","}
+
+// LocaleFor picks the separators for a language tag such as "id-ID" or
+// "en". Unknown and empty tags fall back to Indonesian.
+func LocaleFor(tag string, symbolSpace bool) Locale {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	locale := Indonesian
+	if lang == "en" {
+		locale = English
+	}
+	locale.SymbolSpace = symbolSpace
+	return locale
+}
+
+// Format writes the amount rounded to whole rupiah with thousand separators.
+func (m Money) Format(l Locale) string {
+	rupiah := m.Rupiah()
+	var sb strings.Builder
+	if rupiah < 0 {
+		sb.WriteByte('-')
+		rupiah = -rupiah
+	}
+	sb.WriteString("Rp")
+	if l.SymbolSpace {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(groupThousands(strconv.FormatInt(rupiah, 10), l.Thousands))
+	return sb.String()
+}
+
+func groupThousands(digits, sep string) string {
+	if len(digits) <= 3 || sep == "" {
+		return digits
+	}
+	var sb strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		sb.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
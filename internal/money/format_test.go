@@ -0,0 +1,40 @@
+package money
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		m    Money
+		l    Locale
+		want string
+	}{
+		{FromRupiah(0), Indonesian, "Rp0"},
+		{FromRupiah(999), Indonesian, "Rp999"},
+		{FromRupiah(10000), Indonesian, "Rp10.000"},
+		{FromRupiah(1250000), English, "Rp1,250,000"},
+		{FromRupiah(100000), LocaleFor("id-ID", true), "Rp 100.000"},
+		{FromRupiah(-25500), Indonesian, "-Rp25.500"},
+		{FromFloat(10499.5), Indonesian, "Rp10.500"},
+	}
+	for _, tc := range cases {
+		if got := tc.m.Format(tc.l); got != tc.want {
+			t.Errorf("Format(%d, %+v) = %q, want %q", tc.m, tc.l, got, tc.want)
+		}
+	}
+}
+
+func TestLocaleFor(t *testing.T) {
+	cases := map[string]Locale{
+		"":      Indonesian,
+		"id":    Indonesian,
+		"en":    English,
+		"en_US": English,
+		"EN-gb": English,
+		"ms":    Indonesian,
+	}
+	for tag, want := range cases {
+		if got := LocaleFor(tag, false); got != want {
+			t.Errorf("LocaleFor(%q) = %+v, want %+v", tag, got, want)
+		}
+	}
+}
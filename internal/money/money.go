@@ -0,0 +1,127 @@
+// Package money represents rupiah amounts as integer minor units so prices,
+// fees and margins are computed without float rounding errors.
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in sen (1/100 rupiah).
+type Money int64
+
+// Rupiah is one whole rupiah.
+const Rupiah Money = 100
+
+// rateScale is the precision fee rates are fixed to before integer math.
+const rateScale = 1_000_000
+
+// FromRupiah converts a whole-rupiah amount, as stored on orders and deposits.
+func FromRupiah(amount int64) Money {
+	return Money(amount) * Rupiah
+}
+
+// FromFloat converts a rupiah value decoded from a supplier payload.
+func FromFloat(value float64) Money {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return Money(math.Round(value * float64(Rupiah)))
+}
+
+// Parse reads a rupiah amount such as "10500" or "10500.50".
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	neg := strings.HasPrefix(whole, "-")
+	if neg {
+		whole = whole[1:]
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || len(frac) > 2 || strings.ContainsFunc(frac, func(r rune) bool { return r < '0' || r > '9' }) {
+		// Sub-sen precision or exponent notation; round through float.
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse amount %q: %w", s, err)
+		}
+		return FromFloat(f), nil
+	}
+	var sen int64
+	if frac != "" {
+		for len(frac) < 2 {
+			frac += "0"
+		}
+		if sen, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return 0, fmt.Errorf("parse amount %q: %w", s, err)
+		}
+	}
+	m := FromRupiah(w) + Money(sen)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// Rupiah rounds to whole rupiah, half away from zero.
+func (m Money) Rupiah() int64 {
+	if m < 0 {
+		return -int64((-m + Rupiah/2) / Rupiah)
+	}
+	return int64((m + Rupiah/2) / Rupiah)
+}
+
+// Float returns the amount in rupiah for payloads that expect a number.
+func (m Money) Float() float64 {
+	return float64(m) / float64(Rupiah)
+}
+
+// MulRateCeil returns m multiplied by rate (0.007 for 0.7%), rounded up to
+// whole rupiah. The rate is fixed to six decimals first so values like
+// 10000 * 0.007 do not become 71 through float error.
+func (m Money) MulRateCeil(rate float64) Money {
+	if rate <= 0 || m <= 0 {
+		return 0
+	}
+	ppm := int64(math.Round(rate * rateScale))
+	units := (int64(m)*ppm + rateScale - 1) / rateScale
+	return (Money(units) + Rupiah - 1) / Rupiah * Rupiah
+}
+
+// String formats the amount in the default locale, e.g. "Rp10.500".
+func (m Money) String() string {
+	return m.Format(Indonesian)
+}
+
+// MarshalJSON encodes the amount as a rupiah number so stored payloads keep
+// the shape Atlantic uses.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m%Rupiah == 0 {
+		return []byte(strconv.FormatInt(int64(m/Rupiah), 10)), nil
+	}
+	return []byte(strconv.FormatFloat(m.Float(), 'f', 2, 64)), nil
+}
+
+// UnmarshalJSON accepts a rupiah number or numeric string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" || raw == `""` {
+		*m = 0
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		raw = str
+	}
+	parsed, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
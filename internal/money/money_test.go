@@ -0,0 +1,83 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFromFloatRupiah(t *testing.T) {
+	cases := []struct {
+		in   float64
+		want int64
+	}{
+		{10500, 10500},
+		{10499.5, 10500},
+		{10499.49, 10499},
+		{0.1 + 0.2, 0},
+		{-2.5, -3},
+	}
+	for _, tc := range cases {
+		if got := FromFloat(tc.in).Rupiah(); got != tc.want {
+			t.Errorf("FromFloat(%v).Rupiah() = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]Money{
+		"10500":    1050000,
+		"10500.5":  1050050,
+		"10500.05": 1050005,
+		"-12.30":   -1230,
+	}
+	for in, want := range cases {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	if _, err := Parse("abc"); err == nil {
+		t.Errorf("Parse(abc) succeeded")
+	}
+}
+
+func TestMulRateCeil(t *testing.T) {
+	cases := []struct {
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{10000, 0.007, 70},
+		{10001, 0.007, 71},
+		{100000, 0.0117, 1170},
+		{5000, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := FromRupiah(tc.amount).MulRateCeil(tc.rate).Rupiah(); got != tc.want {
+			t.Errorf("%d * %v = %d, want %d", tc.amount, tc.rate, got, tc.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		Price Money `json:"price"`
+	}
+	for _, raw := range []string{`{"price":10500}`, `{"price":"10500"}`, `{"price":10500.0}`, `{"price":1.05e4}`, `{"price":"10500.00"}`} {
+		var p payload
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			t.Fatalf("unmarshal %s: %v", raw, err)
+		}
+		if p.Price != FromRupiah(10500) {
+			t.Fatalf("unmarshal %s = %d", raw, p.Price)
+		}
+		out, _ := json.Marshal(p)
+		if string(out) != `{"price":10500}` {
+			t.Fatalf("marshal = %s", out)
+		}
+	}
+	out, _ := json.Marshal(payload{Price: 1050050})
+	if string(out) != `{"price":10500.50}` {
+		t.Fatalf("marshal fractional = %s", out)
+	}
+}
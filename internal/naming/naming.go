@@ -0,0 +1,155 @@
+// Package naming turns supplier product names, often shouted in capitals
+// and abbreviated, into the consistent names customers see. Operators add
+// pattern rules and per-product overrides from the admin API.
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+// Translator rewrites the display name of a product. It returns name
+// unchanged when it has nothing to do.
+type Translator interface {
+	Translate(item atl.PriceListItem, name string) string
+}
+
+// Chain runs translators in order, each one seeing the previous output.
+type Chain []Translator
+
+// New builds the standard chain from the operator's rules: Tidy first, then
+// the pattern rules in order, then product overrides, which always win.
+// Rules whose pattern does not compile are skipped and reported in err; the
+// chain is usable either way.
+func New(rules []repo.ProductNameRule) (Chain, error) {
+	var patterns Patterns
+	overrides := Overrides{}
+	var errs []error
+	for _, rule := range rules {
+		switch rule.Scope {
+		case repo.ProductNameScopePattern:
+			re, err := CompilePattern(rule.Target)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			patterns = append(patterns, Pattern{re: re, replacement: rule.Name})
+		case repo.ProductNameScopeProduct:
+			overrides[strings.ToUpper(strings.TrimSpace(rule.Target))] = strings.TrimSpace(rule.Name)
+		}
+	}
+	return Chain{Tidy{}, patterns, overrides}, errors.Join(errs...)
+}
+
+// CompilePattern compiles a pattern rule's target. Patterns match case
+// insensitively since Tidy may already have changed the case.
+func CompilePattern(target string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(`(?i)` + target)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", target, err)
+	}
+	return re, nil
+}
+
+// Name returns the display name of item. A chain that would leave the name
+// empty keeps the supplier's name instead.
+func (c Chain) Name(item atl.PriceListItem) string {
+	name := item.Name
+	for _, t := range c {
+		name = t.Translate(item, name)
+	}
+	if strings.TrimSpace(name) == "" {
+		return item.Name
+	}
+	return name
+}
+
+// Apply returns a copy of items carrying their display names.
+func (c Chain) Apply(items []atl.PriceListItem) []atl.PriceListItem {
+	if len(c) == 0 {
+		return items
+	}
+	res := make([]atl.PriceListItem, len(items))
+	copy(res, items)
+	for i := range res {
+		res[i].Name = c.Name(res[i])
+	}
+	return res
+}
+
+// Tidy collapses runs of whitespace and, for names written entirely in
+// capitals, capitalises only the first letter of words of four or more
+// letters. Shorter words are usually acronyms (PLN, XL, BRI) and stay as
+// they are, as do names the supplier already wrote in mixed case.
+type Tidy struct{}
+
+func (Tidy) Translate(_ atl.PriceListItem, name string) string {
+	words := strings.Fields(name)
+	if !shouting(name) {
+		return strings.Join(words, " ")
+	}
+	for i, word := range words {
+		if len([]rune(word)) >= 4 && allLetters(word) {
+			lower := []rune(strings.ToLower(word))
+			lower[0] = unicode.ToUpper(lower[0])
+			words[i] = string(lower)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func shouting(name string) bool {
+	letters := false
+	for _, r := range name {
+		if unicode.IsLower(r) {
+			return false
+		}
+		letters = letters || unicode.IsLetter(r)
+	}
+	return letters
+}
+
+func allLetters(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Pattern replaces every match of a regular expression.
+type Pattern struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Patterns applies pattern rules in order, then tidies the spacing that
+// removed words leave behind.
+type Patterns []Pattern
+
+func (p Patterns) Translate(_ atl.PriceListItem, name string) string {
+	if len(p) == 0 {
+		return name
+	}
+	for _, rule := range p {
+		name = rule.re.ReplaceAllString(name, rule.replacement)
+	}
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Overrides names products outright, keyed by upper-case product code.
+type Overrides map[string]string
+
+func (o Overrides) Translate(item atl.PriceListItem, name string) string {
+	if override, ok := o[strings.ToUpper(strings.TrimSpace(item.Code))]; ok && override != "" {
+		return override
+	}
+	return name
+}
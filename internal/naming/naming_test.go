@@ -0,0 +1,60 @@
+package naming
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/repo"
+)
+
+func TestTidy(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"MOBILE LEGENDS  86 DIAMONDS", "Mobile Legends 86 Diamonds"},
+		{"PLN TOKEN 20.000", "PLN Token 20.000"},
+		{"TELKOMSEL 5GB 30 HARI", "Telkomsel 5GB 30 Hari"},
+		{"Free Fire 70 DM", "Free Fire 70 DM"},
+		{"  XL  ", "XL"},
+	}
+	for _, tc := range cases {
+		if got := (Tidy{}).Translate(atl.PriceListItem{}, tc.name); got != tc.want {
+			t.Errorf("Tidy(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain, err := New([]repo.ProductNameRule{
+		{Scope: repo.ProductNameScopePattern, Target: `\bDM\b`, Name: "Diamonds"},
+		{Scope: repo.ProductNameScopePattern, Target: `\s*\(promo\)`, Name: ""},
+		{Scope: repo.ProductNameScopePattern, Target: `(`, Name: "broken"},
+		{Scope: repo.ProductNameScopeProduct, Target: "gopay50", Name: "GoPay Saldo 50.000"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for the invalid pattern")
+	}
+
+	items := chain.Apply([]atl.PriceListItem{
+		{Code: "FF70", Name: "FREE FIRE 70 DM (PROMO)"},
+		{Code: "GOPAY50", Name: "GOPAY 50K"},
+		{Code: "ML86", Name: "Mobile Legends 86 Diamonds"},
+	})
+	want := []string{"Free Fire 70 Diamonds", "GoPay Saldo 50.000", "Mobile Legends 86 Diamonds"}
+	for i, item := range items {
+		if item.Name != want[i] {
+			t.Errorf("%s name = %q, want %q", item.Code, item.Name, want[i])
+		}
+	}
+}
+
+func TestChainKeepsSupplierNameWhenEmptied(t *testing.T) {
+	chain, err := New([]repo.ProductNameRule{{Scope: repo.ProductNameScopePattern, Target: `.*`, Name: ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chain.Name(atl.PriceListItem{Name: "AXIS 10K"}); got != "AXIS 10K" {
+		t.Errorf("Name = %q, want the supplier name", got)
+	}
+}
@@ -1,51 +1,35 @@
 package nlu
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
+	"bot-jual/internal/cache"
+	"bot-jual/internal/httpx"
 	"bot-jual/internal/metrics"
+	"bot-jual/internal/persona"
 	"bot-jual/internal/repo"
 
 	"log/slog"
 )
 
-const (
-	geminiAPIBase = "https://generativelanguage.googleapis.com/v1beta"
-	providerName  = "gemini"
-)
-
-// Client communicates with Gemini API to perform intent extraction and response generation.
+// Client performs intent extraction and response generation on the
+// configured LLM providers, Gemini first unless configured otherwise.
 type Client struct {
-	repo        repo.Repository
-	logger      *slog.Logger
-	metrics     *metrics.Metrics
-	httpClient  *http.Client
-	model       string
-	timeout     time.Duration
-	cooldown    time.Duration
-	keyCacheTTL time.Duration
-
-	mu       sync.Mutex
-	cachedAt time.Time
-	cached   []repo.APIKey
-}
-
-type callResult struct {
-	text string
-	key  string
-	err  error
+	logger     *slog.Logger
+	metrics    *metrics.Metrics
+	httpClient *http.Client
+	persona    persona.Persona
+	queue      *requestQueue
+	whisper    WhisperConfig
+	gemini     *geminiProvider
+	providers  []Provider
 }
 
 // Config holds NLU client configuration.
@@ -53,19 +37,83 @@ type Config struct {
 	Model    string
 	Timeout  time.Duration
 	Cooldown time.Duration
+	Persona  persona.Persona
+	// Redis stores per-key daily usage counters; quotas are not enforced without it.
+	Redis *cache.Redis
+	// MaxConcurrent bounds in-flight LLM calls and QueueSize the requests
+	// waiting for one; see requestQueue for how priorities are shed.
+	MaxConcurrent int
+	QueueSize     int
+	// BusyThreshold is the queue depth at which callers tagged with
+	// WithBusyNotice are told to expect a wait; zero disables the notice.
+	BusyThreshold int
+	// Transport carries Gemini calls; nil builds a dedicated pooled transport.
+	Transport http.RoundTripper
+	// Whisper transcribes voice notes through an OpenAI-compatible endpoint
+	// instead of Gemini when its URL is set.
+	Whisper WhisperConfig
+	// Providers lists the LLM backends to try, in order. Empty means Gemini
+	// only.
+	Providers []string
+	OpenAI    OpenAIConfig
+	Ollama    OllamaConfig
 }
 
-// New creates a Gemini client.
+// New creates an NLU client.
 func New(repository repo.Repository, logger *slog.Logger, metrics *metrics.Metrics, cfg Config) *Client {
-	return &Client{
+	queue := newRequestQueue(cfg.MaxConcurrent, cfg.QueueSize)
+	queue.busyAt = cfg.BusyThreshold
+	queue.onChange = func(depth int) { metrics.GeminiQueueDepth.Set(float64(depth)) }
+	queue.onShed = func(p Priority) { metrics.GeminiShed.WithLabelValues(p.String()).Inc() }
+	transport := cfg.Transport
+	if transport == nil {
+		transport = httpx.NewTransport(httpx.TransportConfig{})
+	}
+	httpClient := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	logger = logger.With("component", "nlu")
+	gemini := &geminiProvider{
 		repo:        repository,
-		logger:      logger.With("component", "nlu"),
+		logger:      logger,
 		metrics:     metrics,
-		httpClient:  &http.Client{Timeout: cfg.Timeout},
+		httpClient:  httpClient,
 		model:       cfg.Model,
 		timeout:     cfg.Timeout,
-		cooldown:    cfg.Cooldown,
 		keyCacheTTL: 10 * time.Second, // Short TTL so cooldown state refreshes quickly during rotation
+		quota:       newQuotaTracker(cfg.Redis, logger.With("component", "nlu_quota")),
+	}
+
+	gemini.cooldown.Store(int64(cfg.Cooldown))
+
+	names := cfg.Providers
+	if len(names) == 0 {
+		names = []string{ProviderGemini}
+	}
+	var providers []Provider
+	for _, name := range names {
+		switch name {
+		case ProviderGemini:
+			providers = append(providers, gemini)
+		case ProviderOpenAI:
+			providers = append(providers, newOpenAIProvider(cfg.OpenAI, cfg.Timeout))
+		case ProviderOllama:
+			providers = append(providers, newOllamaProvider(cfg.Ollama, cfg.Timeout))
+		default:
+			logger.Warn("unknown llm provider ignored", "provider", name)
+		}
+	}
+	if len(providers) == 0 {
+		providers = []Provider{gemini}
+	}
+
+	return &Client{
+		logger:     logger,
+		metrics:    metrics,
+		httpClient: httpClient,
+		persona:    cfg.Persona,
+		queue:      queue,
+		whisper:    cfg.Whisper,
+		gemini:     gemini,
+		providers:  providers,
 	}
 }
 
@@ -77,6 +125,10 @@ type IntentInput struct {
 	ContextSummary    string
 	Channel           string
 	UserLocale        string
+	// UserProfile is the long-term summary of the user's past conversations.
+	UserProfile string
+	// QuotedMessage is the earlier message the user replied to, if any.
+	QuotedMessage string
 }
 
 // IntentResult contains the structured response from Gemini.
@@ -102,15 +154,13 @@ type ImageAnalysis struct {
 	Entities      map[string]string `json:"entities"`
 }
 
-// DetectIntent analyses a WhatsApp message with Gemini and returns structured intent data.
+// DetectIntent analyses a WhatsApp message with the LLM and returns structured intent data.
 func (c *Client) DetectIntent(ctx context.Context, input IntentInput) (*IntentResult, error) {
-	payload := buildIntentPrompt(input)
-
-	res, keyUsed, err := c.callGemini(ctx, payload)
+	resp, err := c.generate(ctx, buildIntentPrompt(input, c.persona))
 	if err != nil {
 		return nil, err
 	}
-	c.metrics.GeminiRequests.WithLabelValues("success").Inc()
+	res, keyUsed := resp.Text, resp.Key
 
 	normalised := normaliseJSON(res)
 
@@ -157,41 +207,6 @@ func (c *Client) DetectIntent(ctx context.Context, input IntentInput) (*IntentRe
 	return &result, nil
 }
 
-// TranscribeAudio converts audio bytes into text using Gemini.
-func (c *Client) TranscribeAudio(ctx context.Context, audio []byte, mimeType string) (string, error) {
-	if len(audio) == 0 {
-		return "", fmt.Errorf("audio payload empty")
-	}
-	if mimeType == "" {
-		mimeType = "audio/ogg"
-	}
-
-	payload := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Role: "user",
-				Parts: []geminiPart{
-					{Text: "Transkripsikan voice note berikut ke teks bahasa Indonesia yang mudah dibaca. Balas hanya isi transkrip tanpa tambahan lain."},
-					{InlineData: &inlineData{
-						MimeType: mimeType,
-						Data:     base64.StdEncoding.EncodeToString(audio),
-					}},
-				},
-			},
-		},
-		GenerationConfig: generationConfig{
-			Temperature:     0.2,
-			MaxOutputTokens: 256,
-		},
-	}
-
-	text, _, err := c.callGemini(ctx, payload)
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(text), nil
-}
-
 // AnalyzeImage extracts structured info from an image.
 func (c *Client) AnalyzeImage(ctx context.Context, image []byte, mimeType string) (*ImageAnalysis, error) {
 	if len(image) == 0 {
@@ -201,32 +216,19 @@ func (c *Client) AnalyzeImage(ctx context.Context, image []byte, mimeType string
 		mimeType = "image/jpeg"
 	}
 
-	payload := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Role: "user",
-				Parts: []geminiPart{
-					{Text: "Analisa gambar berikut. Jelaskan ringkas apa isinya, ambil informasi penting seperti ID pelanggan, nomor akun, jumlah tagihan, atau paket produk. Balas dalam JSON dengan format {\"summary\":\"...\",\"extracted_text\":\"...\",\"entities\":{\"key\":\"value\"}} tanpa teks tambahan."},
-					{InlineData: &inlineData{
-						MimeType: mimeType,
-						Data:     base64.StdEncoding.EncodeToString(image),
-					}},
-				},
-			},
-		},
-		GenerationConfig: generationConfig{
-			Temperature:     0.3,
-			MaxOutputTokens: 512,
-		},
-	}
-
-	res, _, err := c.callGemini(ctx, payload)
+	resp, err := c.generate(ctx, Request{
+		Prompt:      "Analisa gambar berikut. Jelaskan ringkas apa isinya, ambil informasi penting seperti ID pelanggan, nomor akun, jumlah tagihan, atau paket produk. Balas dalam JSON dengan format {\"summary\":\"...\",\"extracted_text\":\"...\",\"entities\":{\"key\":\"value\"}} tanpa teks tambahan.",
+		Media:       &Media{MimeType: mimeType, Data: image},
+		Temperature: 0.3,
+		MaxTokens:   512,
+		JSON:        true,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	var analysis ImageAnalysis
-	if err := json.Unmarshal([]byte(res), &analysis); err != nil {
+	if err := json.Unmarshal([]byte(normaliseJSON(resp.Text)), &analysis); err != nil {
 		return nil, fmt.Errorf("parse image analysis: %w", err)
 	}
 	if analysis.Entities == nil {
@@ -235,26 +237,45 @@ func (c *Client) AnalyzeImage(ctx context.Context, image []byte, mimeType string
 	return &analysis, nil
 }
 
-func buildIntentPrompt(input IntentInput) geminiRequest {
+func buildIntentPrompt(input IntentInput, p persona.Persona) Request {
 	var sb strings.Builder
 	sb.WriteString("Anda adalah AI asisten customer service PPOB untuk WhatsApp. ")
-	sb.WriteString("Tugas Anda adalah mengklasifikasikan niat user dan menyiapkan respon singkat dengan gaya santai. ")
+	sb.WriteString("Tugas Anda adalah mengklasifikasikan niat user dan menyiapkan respon singkat. ")
 	sb.WriteString("Balasan wajib berupa JSON valid satu objek tanpa teks tambahan.\n")
-	sb.WriteString("Field \"reply\" bila diisi harus terdengar ramah (contoh: \"Sip, aku bantu cek dulu ya.\").\n\n")
+	sb.WriteString("Persona: " + p.PromptInstructions() + "\n")
+	if p.Formal() {
+		sb.WriteString("Field \"reply\" bila diisi harus sopan (contoh: \"Baik, kami bantu cek terlebih dahulu.\").\n\n")
+	} else {
+		sb.WriteString("Field \"reply\" bila diisi harus terdengar ramah (contoh: \"Sip, aku bantu cek dulu ya.\").\n\n")
+	}
 	sb.WriteString("Format JSON:\n")
 	sb.WriteString(`{"intent":"string","confidence":0.0,"reply":"string","requires_confirmation":false,"entities":{"key":"value"},"tool_call":{"name":"tool_name","arguments":{"arg":"value"}}}` + "\n\n")
-	sb.WriteString("Daftar intent utama: smalltalk_greeting, price_lookup, budget_filter, create_prepaid, check_bill, pay_bill, check_status, create_deposit, create_transfer, catalog_all, check_balance, help, fallback.\n")
+	sb.WriteString("Daftar intent utama: smalltalk_greeting, price_lookup, budget_filter, create_prepaid, check_bill, pay_bill, check_status, create_deposit, create_transfer, withdraw_balance, check_account, catalog_all, check_balance, order_history, update_draft, cancel_order, add_to_cart, view_cart, checkout_cart, schedule_order, list_schedules, product_alert, regenerate_deposit, reset_context, talk_to_human, set_language, help, fallback.\n")
 	sb.WriteString("Jika tidak yakin gunakan intent \"fallback\".\n\n")
 	sb.WriteString("Aturan entitas per intent:\n")
 	sb.WriteString("- price_lookup: entities.product_query wajib, isi nama/keyword produk; entities.product_type boleh \"prabayar\" atau \"pascabayar\" (default \"prabayar\"), entities.provider opsional.\n")
 	sb.WriteString("- budget_filter: entities.budget wajib (nominal), entities.product_type opsional.\n")
-	sb.WriteString("- create_prepaid: entities.product_code, entities.customer_id (format akhir target; gabungkan ID dan server bila ada, contoh \"12345678(1234)\"), entities.payment_method (deposit/saldo/qris/bri), opsional entities.customer_zone, entities.ref_id, dan entities.limit_price.\n")
+	sb.WriteString("- create_prepaid: entities.product_code, entities.customer_id (format akhir target; gabungkan ID dan server bila ada, contoh \"12345678(1234)\"), entities.payment_method (deposit/saldo/qris/bri), opsional entities.customer_zone, entities.ref_id, entities.limit_price, dan entities.quantity (jumlah unit, contoh \"beli 3 voucher google play 50rb\" -> quantity=3). Jika user menyebut nomornya sendiri (\"nomorku\", \"nomor saya\") tanpa menulis angkanya, kosongkan entities.customer_id. Jika user menulis \"ref: X\" isi entities.customer_ref=X, dan \"catatan: Y\" isi entities.order_note=Y.\n")
 	sb.WriteString("- check_bill/pay_bill: gunakan entities.product_code dan entities.customer_id (check) atau entities.ref_id (pay).\n")
 	sb.WriteString("- check_status: gunakan entities.ref_id atau entities.id. entities.product_type boleh \"prabayar\" atau \"pascabayar\".\n")
 	sb.WriteString("- create_deposit: entities.method/metode dan entities.amount/nominal wajib, entities.type opsional.\n")
 	sb.WriteString("- create_transfer: entities.bank_code, entities.account_no, entities.account_name, entities.amount.\n")
+	sb.WriteString("- withdraw_balance: user ingin tarik/cairkan saldo ke rekening sendiri (\"tarik saldo 50rb ke bca 123...\"); entities.amount, entities.bank_code, entities.account_no.\n")
+	sb.WriteString("- check_account: user ingin cek nama pemilik rekening/e-wallet (\"cek rekening BCA 123...\"); entities.bank_code dan entities.account_no.\n")
 	sb.WriteString("- catalog_all: tidak butuh entitas; gunakan saat user minta semua produk/menu.\n")
-	sb.WriteString("- check_balance: tidak butuh entitas; gunakan saat user menanyakan saldo/akun atlantic.\n\n")
+	sb.WriteString("- check_balance: tidak butuh entitas; gunakan saat user menanyakan saldo/akun atlantic.\n")
+	sb.WriteString("- order_history: user ingin melihat riwayat/daftar transaksi sebelumnya (\"riwayat transaksi\", \"pesananku kemarin apa aja\"); entities.page opsional (angka halaman).\n")
+	sb.WriteString("- update_draft: user mengubah pesanan yang sedang disiapkan (\"ganti nomornya 0813...\", \"pakai kode promo HEMAT\", \"beli 2\"); isi hanya yang berubah: entities.customer_id, entities.customer_zone, entities.product_code, entities.payment_method, entities.voucher_code, entities.quantity.\n")
+	sb.WriteString("- cancel_order: tidak butuh entitas; gunakan saat user membatalkan pesanan yang sedang disiapkan (\"batal\", \"gak jadi\").\n")
+	sb.WriteString("- add_to_cart: user mengumpulkan beberapa produk untuk dibayar sekaligus (\"masukin keranjang pulsa 10rb ke 0812...\"); entities.product_code atau entities.product_query, entities.customer_id, opsional entities.customer_zone.\n")
+	sb.WriteString("- view_cart/checkout_cart: user ingin melihat keranjang atau membayar seluruh isinya; checkout_cart boleh berisi entities.payment_method.\n")
+	sb.WriteString("- schedule_order: user ingin pembelian berulang otomatis (\"isi pulsa 25k ke 0812... tiap tanggal 1\", \"top up dana 50rb tiap senin\"); entitas seperti create_prepaid plus entities.schedule berisi jadwalnya apa adanya (\"tiap tanggal 1\", \"tiap senin\", \"tiap hari\").\n")
+	sb.WriteString("- list_schedules: tidak butuh entitas; user ingin melihat langganan/pembelian rutinnya.\n")
+	sb.WriteString("- product_alert: user minta dikabari saat produk ready lagi atau harganya turun (\"kabari kalau ML 86 diamond ready\", \"infoin kalau pulsa tsel 50rb turun di bawah 49rb\"); entities.product_code atau entities.product_query, entities.alert \"available\" atau \"price_drop\", opsional entities.target_price.\n")
+	sb.WriteString("- regenerate_deposit: entitas opsional ref_id; gunakan saat QR/deposit pesanan kedaluwarsa dan user minta bayar ulang atau QR baru.\n")
+	sb.WriteString("- reset_context: tidak butuh entitas; gunakan saat user minta mulai ulang atau melupakan obrolan sebelumnya.\n")
+	sb.WriteString("- talk_to_human: tidak butuh entitas; gunakan saat user minta bicara dengan admin/CS/manusia (\"mau ngobrol sama admin\", \"sambungkan ke CS\").\n")
+	sb.WriteString("- set_language: user minta dibalas dalam bahasa lain (\"reply in english please\", \"pakai bahasa indonesia aja\"); entities.language \"id\" atau \"en\".\n\n")
 	sb.WriteString("Saat seluruh slot untuk sebuah aksi sudah lengkap, isi field \"tool_call\" untuk memicu backend. ")
 	sb.WriteString("Nama tool harus diambil dari daftar berikut dan argument wajib dalam lowercase key:\n")
 	sb.WriteString("- price_list(type, code?)\n")
@@ -360,163 +381,47 @@ func buildIntentPrompt(input IntentInput) geminiRequest {
 	sb.WriteString(`Output: {"intent":"smalltalk_greeting","confidence":0.95,"reply":"Waalaikumsalam! Aku menyediakan berbagai layanan digital:\n\n📱 Pulsa & Paket Data - Telkomsel, Indosat, XL, Tri, Smartfren\n🎮 Top Up Game - Mobile Legends, Free Fire, PUBG, dll\n⚡ Token Listrik - Prabayar & Pascabayar\n💳 Bayar Tagihan - PLN, PDAM, BPJS, dll\n💰 Deposit & Transfer - QRIS, Bank Transfer, E-wallet\n\nKetik nama produk yang kamu cari, contoh: \"pulsa telkomsel 20k\" atau \"top up ML\"","requires_confirmation":false,"entities":{}}` + "\n\n")
 	sb.WriteString("Konteks percakapan:\n")
 
+	if input.UserProfile != "" {
+		sb.WriteString("- Profil user (dari percakapan sebelumnya): " + input.UserProfile + "\n")
+	}
 	if input.ContextSummary != "" {
 		sb.WriteString("- Ringkasan: " + input.ContextSummary + "\n")
 	}
 	if input.LastBotMessage != "" {
 		sb.WriteString("- Pesan bot terakhir: " + input.LastBotMessage + "\n")
 	}
+	if input.QuotedMessage != "" {
+		sb.WriteString("- Pesan yang dikutip/dibalas user (\"yang ini\" merujuk ke sini): " + input.QuotedMessage + "\n")
+	}
 	sb.WriteString("- Kanal: " + nonEmpty(input.Channel, "whatsapp") + "\n")
 	if input.UserLocale != "" {
-		sb.WriteString("- Bahasa user: " + input.UserLocale + "\n")
+		sb.WriteString("- Bahasa user: " + input.UserLocale + " (tulis field \"reply\" dalam bahasa ini)\n")
 	}
 	sb.WriteString("\nPesan user:\n")
 	sb.WriteString(input.UserMessage)
 
-	return geminiRequest{
-		Contents: []geminiContent{
-			{
-				Role: "user",
-				Parts: []geminiPart{
-					{Text: sb.String()},
-				},
-			},
-		},
-		GenerationConfig: generationConfig{
-			Temperature:     0.3,
-			MaxOutputTokens: 512,
-			TopP:            0.8,
-		},
+	return Request{
+		Prompt:      sb.String(),
+		Temperature: 0.3,
+		MaxTokens:   512,
+		TopP:        0.8,
+		JSON:        true,
 	}
 }
 
-func (c *Client) callGemini(ctx context.Context, payload geminiRequest) (string, string, error) {
-	var lastErr error
-
-	keys, err := c.fetchKeys(ctx)
-	if err != nil {
-		return "", "", err
-	}
-
-	skipped := 0
-	for idx, k := range keys {
-		if k.CooldownUntil != nil && time.Now().Before(*k.CooldownUntil) {
-			c.logger.Debug("skipping key on cooldown", "key_index", idx, "cooldown_until", k.CooldownUntil.Format(time.RFC3339))
-			skipped++
-			continue
-		}
-
-		c.logger.Info("trying gemini key", "key_index", idx, "total_keys", len(keys), "skipped", skipped)
-		res := c.invokeWithKey(ctx, k, payload)
-		if res.err == nil {
-			return res.text, res.key, nil
-		}
-		lastErr = res.err
-
-		if errors.Is(res.err, errQuotaExceeded) || errors.Is(res.err, errUnauthorised) {
-			c.logger.Warn("gemini key rate limited, rotating", "key_index", idx, "error", res.err, "cooldown", c.cooldown)
-			if err := c.repo.SetCooldownUntil(ctx, k.ID, time.Now().Add(c.cooldown)); err != nil {
-				c.logger.Error("set cooldown failed", "error", err, "key", k.ID)
-			}
-			// Invalidate cache so next call sees updated cooldown
-			c.mu.Lock()
-			c.cached = nil
-			c.mu.Unlock()
-		}
-	}
-
-	if lastErr == nil {
-		lastErr = fmt.Errorf("no available gemini keys")
-	}
-	c.logger.Error("all gemini keys exhausted", "total_keys", len(keys), "skipped_cooldown", skipped)
-	c.metrics.GeminiRequests.WithLabelValues("failed").Inc()
-	return "", "", lastErr
-}
-
-func (c *Client) invokeWithKey(ctx context.Context, key repo.APIKey, payload geminiRequest) callResult {
-	bodyBytes, err := json.Marshal(payload)
-	if err != nil {
-		return callResult{err: fmt.Errorf("marshal payload: %w", err)}
-	}
-	reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", geminiAPIBase, c.model, key.Value)
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return callResult{err: fmt.Errorf("new request: %w", err)}
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	start := time.Now()
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		c.metrics.GeminiRequests.WithLabelValues("error").Inc()
-		return callResult{err: fmt.Errorf("gemini http: %w", err)}
-	}
-	defer resp.Body.Close()
-
-	latency := time.Since(start).Seconds()
-	statusLabel := fmt.Sprintf("%d", resp.StatusCode)
-	c.metrics.GeminiLatency.WithLabelValues(statusLabel).Observe(latency)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return callResult{err: fmt.Errorf("read body: %w", err)}
-	}
-
-	if resp.StatusCode == http.StatusOK {
-		text, err := extractCandidateText(body)
-		if err != nil {
-			return callResult{err: err}
-		}
-		return callResult{text: text, key: key.ID}
-	}
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return callResult{err: errQuotaExceeded}
-	}
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return callResult{err: errUnauthorised}
-	}
-
-	return callResult{err: fmt.Errorf("gemini request failed: status=%d body=%s", resp.StatusCode, string(body))}
+// KeyUsage returns how many requests and tokens a Gemini key has used today.
+func (c *Client) KeyUsage(ctx context.Context, keyID string) (KeyUsage, error) {
+	return c.gemini.quota.usage(ctx, keyID)
 }
 
-func (c *Client) fetchKeys(ctx context.Context) ([]repo.APIKey, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if len(c.cached) > 0 && time.Since(c.cachedAt) < c.keyCacheTTL {
-		return c.cached, nil
-	}
-
-	keys, err := c.repo.ListActiveGeminiKeys(ctx)
-	if err != nil {
-		return nil, err
-	}
-	if len(keys) == 0 {
-		return nil, fmt.Errorf("gemini keys not found")
-	}
-
-	c.cached = keys
-	c.cachedAt = time.Now()
-	return keys, nil
+// InvalidateKeys drops the cached Gemini key list so the next call reloads it.
+func (c *Client) InvalidateKeys() {
+	c.gemini.invalidateKeys()
 }
 
-func extractCandidateText(body []byte) (string, error) {
-	var resp geminiResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return "", fmt.Errorf("decode gemini response: %w", err)
-	}
-	for _, cand := range resp.Candidates {
-		for _, part := range cand.Content.Parts {
-			if part.Text != "" {
-				return part.Text, nil
-			}
-		}
-	}
-	return "", fmt.Errorf("no candidate text found")
+// SetCooldown changes how long a rate limited Gemini key is rested from now on.
+func (c *Client) SetCooldown(d time.Duration) {
+	c.gemini.cooldown.Store(int64(d))
 }
 
 func nonEmpty(val, fallback string) string {
@@ -526,47 +431,6 @@ func nonEmpty(val, fallback string) string {
 	return val
 }
 
-var (
-	errQuotaExceeded = errors.New("gemini quota exceeded")
-	errUnauthorised  = errors.New("gemini unauthorised")
-)
-
-type geminiRequest struct {
-	Contents         []geminiContent  `json:"contents"`
-	GenerationConfig generationConfig `json:"generationConfig,omitempty"`
-}
-
-type generationConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	TopP            float64 `json:"topP,omitempty"`
-	TopK            float64 `json:"topK,omitempty"`
-	MaxOutputTokens int32   `json:"maxOutputTokens,omitempty"`
-}
-
-type geminiContent struct {
-	Role  string       `json:"role"`
-	Parts []geminiPart `json:"parts"`
-}
-
-type geminiPart struct {
-	Text       string      `json:"text,omitempty"`
-	InlineData *inlineData `json:"inlineData,omitempty"`
-}
-
-type inlineData struct {
-	MimeType string `json:"mimeType"`
-	Data     string `json:"data"`
-}
-
-type geminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Role  string       `json:"role"`
-			Parts []geminiPart `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
-}
-
 func normaliseJSON(text string) string {
 	s := strings.TrimSpace(text)
 	if strings.HasPrefix(s, "```") {
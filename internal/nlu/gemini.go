@@ -0,0 +1,260 @@
+package nlu
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bot-jual/internal/metrics"
+	"bot-jual/internal/repo"
+
+	"log/slog"
+)
+
+const geminiAPIBase = "https://generativelanguage.googleapis.com/v1beta"
+
+// geminiProvider calls Gemini with the API keys stored in the database,
+// rotating to the next key when one is rate limited or over its daily quota.
+type geminiProvider struct {
+	repo       repo.Repository
+	logger     *slog.Logger
+	metrics    *metrics.Metrics
+	httpClient *http.Client
+	model      string
+	timeout    time.Duration
+	// cooldown is the time.Duration a rate limited key rests for.
+	cooldown    atomic.Int64
+	keyCacheTTL time.Duration
+	quota       *quotaTracker
+
+	mu       sync.Mutex
+	cachedAt time.Time
+	cached   []repo.APIKey
+}
+
+type callResult struct {
+	text   string
+	key    string
+	tokens int64
+	err    error
+}
+
+func (g *geminiProvider) Name() string { return ProviderGemini }
+
+func (g *geminiProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	parts := []geminiPart{{Text: req.Prompt}}
+	if req.Media != nil {
+		parts = append(parts, geminiPart{InlineData: &inlineData{
+			MimeType: req.Media.MimeType,
+			Data:     base64.StdEncoding.EncodeToString(req.Media.Data),
+		}})
+	}
+	payload := geminiRequest{
+		Contents: []geminiContent{{Role: "user", Parts: parts}},
+		GenerationConfig: generationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			MaxOutputTokens: int32(req.MaxTokens),
+		},
+	}
+
+	var lastErr error
+
+	keys, err := g.fetchKeys(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	skipped := 0
+	for idx, k := range keys {
+		if k.CooldownUntil != nil && time.Now().Before(*k.CooldownUntil) {
+			g.logger.Debug("skipping key on cooldown", "key_index", idx, "cooldown_until", k.CooldownUntil.Format(time.RFC3339))
+			skipped++
+			continue
+		}
+
+		if g.quota.exhausted(ctx, k) {
+			g.logger.Debug("skipping key over daily quota", "key_index", idx)
+			skipped++
+			continue
+		}
+
+		g.logger.Info("trying gemini key", "key_index", idx, "total_keys", len(keys), "skipped", skipped)
+		res := g.invokeWithKey(ctx, k, payload)
+		if res.err == nil {
+			g.quota.record(ctx, k.ID, res.tokens)
+			g.metrics.GeminiRequests.WithLabelValues("success").Inc()
+			return Response{Text: res.text, Key: res.key, Tokens: res.tokens}, nil
+		}
+		lastErr = res.err
+
+		if errors.Is(res.err, errQuotaExceeded) || errors.Is(res.err, errUnauthorised) {
+			g.logger.Warn("gemini key rate limited, rotating", "key_index", idx, "error", res.err, "cooldown", time.Duration(g.cooldown.Load()))
+			if err := g.repo.SetCooldownUntil(ctx, k.ID, time.Now().Add(time.Duration(g.cooldown.Load()))); err != nil {
+				g.logger.Error("set cooldown failed", "error", err, "key", k.ID)
+			}
+			// Invalidate cache so next call sees updated cooldown
+			g.invalidateKeys()
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no available gemini keys")
+	}
+	g.logger.Error("all gemini keys exhausted", "total_keys", len(keys), "skipped", skipped)
+	g.metrics.GeminiRequests.WithLabelValues("failed").Inc()
+	return Response{}, lastErr
+}
+
+func (g *geminiProvider) invokeWithKey(ctx context.Context, key repo.APIKey, payload geminiRequest) callResult {
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return callResult{err: fmt.Errorf("marshal payload: %w", err)}
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", geminiAPIBase, g.model, key.Value)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return callResult{err: fmt.Errorf("new request: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		g.metrics.GeminiRequests.WithLabelValues("error").Inc()
+		return callResult{err: fmt.Errorf("gemini http: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	latency := time.Since(start).Seconds()
+	statusLabel := fmt.Sprintf("%d", resp.StatusCode)
+	g.metrics.GeminiLatency.WithLabelValues(statusLabel).Observe(latency)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return callResult{err: fmt.Errorf("read body: %w", err)}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		text, err := extractCandidateText(body)
+		if err != nil {
+			return callResult{err: err}
+		}
+		return callResult{text: text, key: key.ID, tokens: extractTokenCount(body)}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return callResult{err: errQuotaExceeded}
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return callResult{err: errUnauthorised}
+	}
+
+	return callResult{err: fmt.Errorf("gemini request failed: status=%d body=%s", resp.StatusCode, string(body))}
+}
+
+func (g *geminiProvider) invalidateKeys() {
+	g.mu.Lock()
+	g.cached = nil
+	g.mu.Unlock()
+}
+
+func (g *geminiProvider) fetchKeys(ctx context.Context) ([]repo.APIKey, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.cached) > 0 && time.Since(g.cachedAt) < g.keyCacheTTL {
+		return g.cached, nil
+	}
+
+	keys, err := g.repo.ListActiveGeminiKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("gemini keys not found")
+	}
+
+	g.cached = keys
+	g.cachedAt = time.Now()
+	return keys, nil
+}
+
+func extractCandidateText(body []byte) (string, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("decode gemini response: %w", err)
+	}
+	for _, cand := range resp.Candidates {
+		for _, part := range cand.Content.Parts {
+			if part.Text != "" {
+				return part.Text, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no candidate text found")
+}
+
+// extractTokenCount returns the total tokens billed for a response, or zero when not reported.
+func extractTokenCount(body []byte) int64 {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0
+	}
+	return resp.UsageMetadata.TotalTokenCount
+}
+
+var (
+	errQuotaExceeded = errors.New("gemini quota exceeded")
+	errUnauthorised  = errors.New("gemini unauthorised")
+)
+
+type geminiRequest struct {
+	Contents         []geminiContent  `json:"contents"`
+	GenerationConfig generationConfig `json:"generationConfig,omitempty"`
+}
+
+type generationConfig struct {
+	Temperature     float64 `json:"temperature,omitempty"`
+	TopP            float64 `json:"topP,omitempty"`
+	TopK            float64 `json:"topK,omitempty"`
+	MaxOutputTokens int32   `json:"maxOutputTokens,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text       string      `json:"text,omitempty"`
+	InlineData *inlineData `json:"inlineData,omitempty"`
+}
+
+type inlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Role  string       `json:"role"`
+			Parts []geminiPart `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		TotalTokenCount int64 `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
@@ -0,0 +1,131 @@
+package nlu
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/httpx"
+)
+
+// OllamaConfig points the ollama provider at a local or self-hosted Ollama
+// server.
+type OllamaConfig struct {
+	BaseURL string
+	Model   string
+}
+
+type ollamaProvider struct {
+	cfg        OllamaConfig
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+func newOllamaProvider(cfg OllamaConfig, timeout time.Duration) *ollamaProvider {
+	return &ollamaProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Transport: httpx.NewTransport(httpx.TransportConfig{})},
+		timeout:    timeout,
+	}
+}
+
+func (p *ollamaProvider) Name() string { return ProviderOllama }
+
+func (p *ollamaProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	if !imageOnly(req) {
+		return Response{}, ErrUnsupported
+	}
+	msg := ollamaMessage{Role: "user", Content: req.Prompt}
+	if req.Media != nil {
+		msg.Images = []string{base64.StdEncoding.EncodeToString(req.Media.Data)}
+	}
+	payload := ollamaRequest{
+		Model:    p.cfg.Model,
+		Messages: []ollamaMessage{msg},
+		Stream:   false,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+	if req.JSON {
+		payload.Format = "json"
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	url := strings.TrimRight(p.cfg.BaseURL, "/") + "/api/chat"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return Response{}, fmt.Errorf("new request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return Response{}, fmt.Errorf("ollama http: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Response{}, fmt.Errorf("ollama request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var parsed ollamaResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Response{}, fmt.Errorf("decode ollama response: %w", err)
+	}
+	if parsed.Message.Content == "" {
+		return Response{}, fmt.Errorf("no message text found")
+	}
+	return Response{
+		Text:   parsed.Message.Content,
+		Key:    p.cfg.Model,
+		Tokens: parsed.PromptEvalCount + parsed.EvalCount,
+	}, nil
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature,omitempty"`
+	TopP        float64 `json:"top_p,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int64 `json:"prompt_eval_count"`
+	EvalCount       int64 `json:"eval_count"`
+}
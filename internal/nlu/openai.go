@@ -0,0 +1,148 @@
+package nlu
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bot-jual/internal/httpx"
+)
+
+// OpenAIConfig points the openai provider at a /chat/completions endpoint:
+// OpenAI itself or any compatible server (Groq, OpenRouter, vLLM).
+type OpenAIConfig struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+type openAIProvider struct {
+	cfg        OpenAIConfig
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+func newOpenAIProvider(cfg OpenAIConfig, timeout time.Duration) *openAIProvider {
+	return &openAIProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Transport: httpx.NewTransport(httpx.TransportConfig{})},
+		timeout:    timeout,
+	}
+}
+
+func (p *openAIProvider) Name() string { return ProviderOpenAI }
+
+func (p *openAIProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	if !imageOnly(req) {
+		return Response{}, ErrUnsupported
+	}
+	var content any = req.Prompt
+	if req.Media != nil {
+		content = []openAIPart{
+			{Type: "text", Text: req.Prompt},
+			{Type: "image_url", ImageURL: &openAIImageURL{
+				URL: "data:" + req.Media.MimeType + ";base64," + base64.StdEncoding.EncodeToString(req.Media.Data),
+			}},
+		}
+	}
+	payload := openAIRequest{
+		Model:       p.cfg.Model,
+		Messages:    []openAIMessage{{Role: "user", Content: content}},
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
+	}
+	if req.JSON {
+		payload.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	url := strings.TrimRight(p.cfg.BaseURL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return Response{}, fmt.Errorf("new request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.cfg.APIKey)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return Response{}, fmt.Errorf("openai http: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Response{}, fmt.Errorf("openai request failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var parsed openAIResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Response{}, fmt.Errorf("decode openai response: %w", err)
+	}
+	for _, choice := range parsed.Choices {
+		if choice.Message.Content != "" {
+			return Response{Text: choice.Message.Content, Key: p.cfg.Model, Tokens: parsed.Usage.TotalTokens}, nil
+		}
+	}
+	return Response{}, fmt.Errorf("no choice text found")
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float64               `json:"temperature,omitempty"`
+	TopP           float64               `json:"top_p,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIMessage content is a plain string, or a list of parts when an image
+// is attached.
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type openAIPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int64 `json:"total_tokens"`
+	} `json:"usage"`
+}
@@ -0,0 +1,102 @@
+package nlu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Provider names accepted in Config.Providers.
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+)
+
+// Provider is one LLM backend. Client tries its providers in order, so the
+// bot keeps answering when one is down or out of quota.
+type Provider interface {
+	Name() string
+	Generate(ctx context.Context, req Request) (Response, error)
+}
+
+// Request is a single-turn prompt, optionally with an image or audio clip.
+type Request struct {
+	Prompt      string
+	Media       *Media
+	Temperature float64
+	TopP        float64
+	MaxTokens   int
+	// JSON asks for a bare JSON object where the backend can enforce it.
+	JSON bool
+}
+
+// Media is inline binary input such as a photo or voice note.
+type Media struct {
+	MimeType string
+	Data     []byte
+}
+
+// Response is the generated text and what it cost.
+type Response struct {
+	Text string
+	// Key identifies the credential that answered, for logs.
+	Key    string
+	Tokens int64
+}
+
+// ErrUnsupported is returned by providers that cannot handle a request, such
+// as audio input; the next provider is tried without counting a failure.
+var ErrUnsupported = errors.New("not supported by provider")
+
+// generate runs req on the first provider that answers. Calls share one queue
+// regardless of provider, so load shedding still applies during fallback.
+func (c *Client) generate(ctx context.Context, req Request) (Response, error) {
+	priority := priorityFrom(ctx)
+	release, err := c.queue.acquire(ctx, priority)
+	if err != nil {
+		if errors.Is(err, ErrOverloaded) {
+			c.logger.Warn("llm request shed", "priority", priority.String())
+		}
+		return Response{}, err
+	}
+	defer release()
+
+	var errs []error
+	for i, p := range c.providers {
+		resp, err := p.Generate(ctx, req)
+		if err == nil {
+			c.observeProvider(p.Name(), "success")
+			if i > 0 {
+				c.logger.Info("llm request served by fallback provider", "provider", p.Name())
+			}
+			return resp, nil
+		}
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+		c.observeProvider(p.Name(), "failed")
+		if ctx.Err() != nil {
+			return Response{}, err
+		}
+		c.logger.Warn("llm provider failed", "provider", p.Name(), "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return Response{}, fmt.Errorf("no llm provider supports this request")
+	}
+	return Response{}, errors.Join(errs...)
+}
+
+func (c *Client) observeProvider(provider, outcome string) {
+	if c.metrics != nil {
+		c.metrics.LLMRequests.WithLabelValues(provider, outcome).Inc()
+	}
+}
+
+// imageOnly reports whether req carries no media or an image, the only
+// input the OpenAI-compatible and Ollama providers accept besides text.
+func imageOnly(req Request) bool {
+	return req.Media == nil || strings.HasPrefix(req.Media.MimeType, "image/")
+}
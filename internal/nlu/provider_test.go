@@ -0,0 +1,158 @@
+package nlu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeProvider struct {
+	name  string
+	text  string
+	err   error
+	calls int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Generate(ctx context.Context, req Request) (Response, error) {
+	f.calls++
+	if f.err != nil {
+		return Response{}, f.err
+	}
+	return Response{Text: f.text}, nil
+}
+
+func newTestClient(providers ...Provider) *Client {
+	return &Client{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		queue:     newRequestQueue(1, 4),
+		providers: providers,
+	}
+}
+
+func TestGenerateFallsBackInOrder(t *testing.T) {
+	down := &fakeProvider{name: "gemini", err: errors.New("all keys exhausted")}
+	audioless := &fakeProvider{name: "ollama", err: ErrUnsupported}
+	up := &fakeProvider{name: "openai", text: "ok"}
+	unused := &fakeProvider{name: "spare", text: "unused"}
+	c := newTestClient(down, audioless, up, unused)
+
+	resp, err := c.generate(context.Background(), Request{Prompt: "halo"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if resp.Text != "ok" {
+		t.Fatalf("text = %q, want ok", resp.Text)
+	}
+	if down.calls != 1 || audioless.calls != 1 || up.calls != 1 || unused.calls != 0 {
+		t.Fatalf("calls = %d/%d/%d/%d", down.calls, audioless.calls, up.calls, unused.calls)
+	}
+}
+
+func TestGenerateJoinsErrorsWhenAllFail(t *testing.T) {
+	first := errors.New("quota")
+	second := errors.New("connection refused")
+	c := newTestClient(&fakeProvider{name: "gemini", err: first}, &fakeProvider{name: "ollama", err: second})
+
+	_, err := c.generate(context.Background(), Request{Prompt: "halo"})
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Fatalf("err = %v, want both provider errors", err)
+	}
+
+	c = newTestClient(&fakeProvider{name: "ollama", err: ErrUnsupported})
+	if _, err := c.generate(context.Background(), Request{Prompt: "halo"}); err == nil {
+		t.Fatalf("unsupported request reported success")
+	}
+}
+
+func TestOpenAIProviderGenerate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad request", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Model          string `json:"model"`
+			MaxTokens      int    `json:"max_tokens"`
+			ResponseFormat struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.Model != "gpt-4o-mini" || body.MaxTokens != 512 || body.ResponseFormat.Type != "json_object" {
+			http.Error(w, "unexpected payload", http.StatusBadRequest)
+			return
+		}
+		if len(body.Messages) != 1 || !strings.Contains(string(body.Messages[0].Content), "data:image/png;base64,") {
+			http.Error(w, "image missing", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"intent\":\"help\"}"}}],"usage":{"total_tokens":42}}`))
+	}))
+	defer srv.Close()
+
+	p := newOpenAIProvider(OpenAIConfig{BaseURL: srv.URL + "/v1/", APIKey: "secret", Model: "gpt-4o-mini"}, 0)
+	resp, err := p.Generate(context.Background(), Request{
+		Prompt:    "apa isi gambar ini",
+		Media:     &Media{MimeType: "image/png", Data: []byte("png")},
+		MaxTokens: 512,
+		JSON:      true,
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if resp.Text != `{"intent":"help"}` || resp.Tokens != 42 {
+		t.Fatalf("resp = %+v", resp)
+	}
+
+	if _, err := p.Generate(context.Background(), Request{Prompt: "x", Media: &Media{MimeType: "audio/ogg"}}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("audio err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestOllamaProviderGenerate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body ollamaRequest
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.Model != "llama3.1" || body.Stream || body.Format != "json" || body.Options.NumPredict != 256 {
+			http.Error(w, "unexpected payload", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"{\"intent\":\"help\"}"},"prompt_eval_count":30,"eval_count":12}`))
+	}))
+	defer srv.Close()
+
+	p := newOllamaProvider(OllamaConfig{BaseURL: srv.URL, Model: "llama3.1"}, 0)
+	resp, err := p.Generate(context.Background(), Request{Prompt: "halo", MaxTokens: 256, JSON: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if resp.Text != `{"intent":"help"}` || resp.Tokens != 42 {
+		t.Fatalf("resp = %+v", resp)
+	}
+
+	p.cfg.Model = "missing"
+	if _, err := p.Generate(context.Background(), Request{Prompt: "halo", MaxTokens: 256, JSON: true}); err == nil {
+		t.Fatalf("failed request reported success")
+	}
+}
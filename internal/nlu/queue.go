@@ -0,0 +1,209 @@
+package nlu
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Priority orders Gemini requests when the queue is under pressure.
+type Priority int
+
+// Priority classes, highest first.
+const (
+	// PriorityHigh is for purchase and payment flows.
+	PriorityHigh Priority = iota
+	// PriorityNormal is for product and account questions.
+	PriorityNormal
+	// PriorityLow is for casual chat, which is shed first.
+	PriorityLow
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+const (
+	defaultMaxConcurrent = 4
+	defaultQueueSize     = 32
+)
+
+// ErrOverloaded is returned when a request is shed instead of queued.
+var ErrOverloaded = errors.New("gemini queue overloaded")
+
+type priorityKey struct{}
+
+// WithPriority tags ctx so Gemini calls made with it are queued at p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+type busyNoticeKey struct{}
+
+// WithBusyNotice tags ctx so fn runs once when an LLM call made with it has
+// to wait behind a busy queue, letting the caller acknowledge the user
+// instead of going silent. fn runs on the caller's goroutine before waiting.
+func WithBusyNotice(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, busyNoticeKey{}, fn)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PriorityNormal
+}
+
+// waiter is handed a slot by a send on ready, or shed by closing it.
+type waiter struct {
+	ready chan struct{}
+}
+
+// requestQueue limits concurrent Gemini calls. Waiting requests are admitted
+// highest priority first. Low priority requests are shed once half the queue
+// is used, and a full queue evicts its newest lower-priority waiter to make
+// room for a higher-priority one.
+type requestQueue struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	capacity  int
+	// busyAt is the queue depth at which waiting callers get their busy
+	// notice; zero disables it.
+	busyAt  int
+	waiting [numPriorities][]*waiter
+	// onChange reports queue depth and onShed counts shed requests; both may be nil.
+	onChange func(depth int)
+	onShed   func(p Priority)
+}
+
+func newRequestQueue(maxActive, capacity int) *requestQueue {
+	if maxActive <= 0 {
+		maxActive = defaultMaxConcurrent
+	}
+	if capacity <= 0 {
+		capacity = defaultQueueSize
+	}
+	return &requestQueue{maxActive: maxActive, capacity: capacity}
+}
+
+// acquire blocks until a call slot is free. The returned release must be
+// called once the call finishes.
+func (q *requestQueue) acquire(ctx context.Context, p Priority) (func(), error) {
+	q.mu.Lock()
+	if q.active < q.maxActive && q.depthLocked() == 0 {
+		q.active++
+		q.mu.Unlock()
+		return q.release, nil
+	}
+	depth := q.depthLocked()
+	if p == PriorityLow && depth >= q.capacity/2 {
+		q.mu.Unlock()
+		q.shed(p)
+		return nil, ErrOverloaded
+	}
+	if depth >= q.capacity && !q.evictBelowLocked(p) {
+		q.mu.Unlock()
+		q.shed(p)
+		return nil, ErrOverloaded
+	}
+	w := &waiter{ready: make(chan struct{}, 1)}
+	q.waiting[p] = append(q.waiting[p], w)
+	q.changedLocked()
+	busy := q.busyAt > 0 && q.depthLocked() >= q.busyAt
+	q.mu.Unlock()
+	if notice, ok := ctx.Value(busyNoticeKey{}).(func()); ok && busy {
+		notice()
+	}
+
+	select {
+	case _, ok := <-w.ready:
+		if !ok {
+			return nil, ErrOverloaded
+		}
+		return q.release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		removed := q.removeLocked(p, w)
+		q.mu.Unlock()
+		if !removed {
+			// The slot was handed over concurrently; pass it on.
+			if _, ok := <-w.ready; ok {
+				q.release()
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// release hands the slot to the highest-priority waiter, or frees it.
+func (q *requestQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for p := range q.waiting {
+		if len(q.waiting[p]) == 0 {
+			continue
+		}
+		w := q.waiting[p][0]
+		q.waiting[p] = q.waiting[p][1:]
+		q.changedLocked()
+		w.ready <- struct{}{}
+		return
+	}
+	q.active--
+}
+
+// evictBelowLocked sheds the newest waiter with a lower priority than p.
+func (q *requestQueue) evictBelowLocked(p Priority) bool {
+	for lower := numPriorities - 1; lower > p; lower-- {
+		n := len(q.waiting[lower])
+		if n == 0 {
+			continue
+		}
+		w := q.waiting[lower][n-1]
+		q.waiting[lower] = q.waiting[lower][:n-1]
+		close(w.ready)
+		q.shed(lower)
+		return true
+	}
+	return false
+}
+
+func (q *requestQueue) removeLocked(p Priority, target *waiter) bool {
+	for i, w := range q.waiting[p] {
+		if w == target {
+			q.waiting[p] = append(q.waiting[p][:i], q.waiting[p][i+1:]...)
+			q.changedLocked()
+			return true
+		}
+	}
+	return false
+}
+
+func (q *requestQueue) depthLocked() int {
+	n := 0
+	for _, ws := range q.waiting {
+		n += len(ws)
+	}
+	return n
+}
+
+func (q *requestQueue) changedLocked() {
+	if q.onChange != nil {
+		q.onChange(q.depthLocked())
+	}
+}
+
+func (q *requestQueue) shed(p Priority) {
+	if q.onShed != nil {
+		q.onShed(p)
+	}
+}
@@ -0,0 +1,145 @@
+package nlu
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestQueueAdmitsByPriority(t *testing.T) {
+	q := newRequestQueue(1, 8)
+	release, err := q.acquire(context.Background(), PriorityNormal)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	order := make(chan Priority, 2)
+	start := func(p Priority) {
+		go func() {
+			rel, err := q.acquire(context.Background(), p)
+			if err != nil {
+				t.Errorf("acquire %v: %v", p, err)
+				return
+			}
+			order <- p
+			rel()
+		}()
+	}
+	start(PriorityNormal)
+	waitDepth(t, q, 1)
+	start(PriorityHigh)
+	waitDepth(t, q, 2)
+
+	release()
+	if first := <-order; first != PriorityHigh {
+		t.Fatalf("first admitted = %v, want high", first)
+	}
+	if second := <-order; second != PriorityNormal {
+		t.Fatalf("second admitted = %v, want normal", second)
+	}
+}
+
+func TestRequestQueueShedsLowPriority(t *testing.T) {
+	q := newRequestQueue(1, 2)
+	var shed []Priority
+	q.onShed = func(p Priority) { shed = append(shed, p) }
+	release, _ := q.acquire(context.Background(), PriorityHigh)
+	defer release()
+
+	// Half the queue is used, so casual chat is shed immediately.
+	go q.acquire(context.Background(), PriorityNormal)
+	waitDepth(t, q, 1)
+	if _, err := q.acquire(context.Background(), PriorityLow); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("low priority acquire err = %v, want ErrOverloaded", err)
+	}
+
+	// A full queue evicts the newest lower-priority waiter for a high one.
+	evicted := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(context.Background(), PriorityNormal)
+		evicted <- err
+	}()
+	waitDepth(t, q, 2)
+	go q.acquire(context.Background(), PriorityHigh)
+	if err := <-evicted; !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("evicted waiter err = %v, want ErrOverloaded", err)
+	}
+	waitDepth(t, q, 2)
+	if len(shed) != 2 || shed[0] != PriorityLow || shed[1] != PriorityNormal {
+		t.Fatalf("shed = %v, want [low normal]", shed)
+	}
+}
+
+func TestRequestQueueCancelledWaiterLeaves(t *testing.T) {
+	q := newRequestQueue(1, 4)
+	release, _ := q.acquire(context.Background(), PriorityNormal)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(ctx, PriorityNormal)
+		done <- err
+	}()
+	waitDepth(t, q, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled acquire err = %v", err)
+	}
+	waitDepth(t, q, 0)
+	release()
+	rel, err := q.acquire(context.Background(), PriorityLow)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	rel()
+}
+
+func waitDepth(t *testing.T, q *requestQueue, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		depth := q.depthLocked()
+		q.mu.Unlock()
+		if depth == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queue depth never reached %d", want)
+}
+
+func TestRequestQueueBusyNotice(t *testing.T) {
+	q := newRequestQueue(1, 8)
+	q.busyAt = 2
+	notices := make(chan int, 4)
+	withNotice := func(id int) context.Context {
+		return WithBusyNotice(context.Background(), func() { notices <- id })
+	}
+
+	release, err := q.acquire(withNotice(0), PriorityNormal)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	for id := 1; id <= 2; id++ {
+		go func() {
+			rel, err := q.acquire(withNotice(id), PriorityNormal)
+			if err == nil {
+				rel()
+			}
+		}()
+		waitDepth(t, q, id)
+	}
+
+	// Only the caller that queued at the threshold is told to wait.
+	if id := <-notices; id != 2 {
+		t.Fatalf("notice for %d, want 2", id)
+	}
+	release()
+	waitDepth(t, q, 0)
+	select {
+	case id := <-notices:
+		t.Fatalf("unexpected notice for %d", id)
+	default:
+	}
+}
@@ -0,0 +1,100 @@
+package nlu
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"bot-jual/internal/cache"
+	"bot-jual/internal/repo"
+)
+
+// quotaCounterTTL keeps yesterday's counters around long enough to inspect.
+const quotaCounterTTL = 48 * time.Hour
+
+// KeyUsage is the number of requests and tokens a key has used today.
+type KeyUsage struct {
+	Requests int64
+	Tokens   int64
+}
+
+// quotaTracker counts per-key daily usage in Redis so keys can be rotated
+// out before they hit provider limits. Without Redis every key is allowed.
+type quotaTracker struct {
+	redis  *cache.Redis
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func newQuotaTracker(r *cache.Redis, logger *slog.Logger) *quotaTracker {
+	return &quotaTracker{redis: r, logger: logger, now: time.Now}
+}
+
+// counterKeys returns the request and token counter keys for today. Days
+// roll over at midnight UTC.
+func (q *quotaTracker) counterKeys(keyID string) (string, string) {
+	day := q.now().UTC().Format("20060102")
+	base := fmt.Sprintf("nlu:quota:%s:%s", keyID, day)
+	return base + ":req", base + ":tok"
+}
+
+// usage returns today's counters for a key.
+func (q *quotaTracker) usage(ctx context.Context, keyID string) (KeyUsage, error) {
+	if q.redis == nil {
+		return KeyUsage{}, nil
+	}
+	reqKey, tokKey := q.counterKeys(keyID)
+	vals, err := q.redis.Client().MGet(ctx, reqKey, tokKey).Result()
+	if err != nil {
+		return KeyUsage{}, fmt.Errorf("read key usage: %w", err)
+	}
+	return KeyUsage{Requests: counterValue(vals[0]), Tokens: counterValue(vals[1])}, nil
+}
+
+// exhausted reports whether a key has reached either of its daily quotas.
+// Counter read failures allow the key; the provider still enforces its limits.
+func (q *quotaTracker) exhausted(ctx context.Context, key repo.APIKey) bool {
+	if key.DailyRequestQuota <= 0 && key.DailyTokenQuota <= 0 {
+		return false
+	}
+	used, err := q.usage(ctx, key.ID)
+	if err != nil {
+		q.logger.Warn("quota check failed", "error", err, "key", key.ID)
+		return false
+	}
+	return quotaReached(key, used)
+}
+
+func quotaReached(key repo.APIKey, used KeyUsage) bool {
+	if key.DailyRequestQuota > 0 && used.Requests >= key.DailyRequestQuota {
+		return true
+	}
+	return key.DailyTokenQuota > 0 && used.Tokens >= key.DailyTokenQuota
+}
+
+// record adds one request and its tokens to today's counters.
+func (q *quotaTracker) record(ctx context.Context, keyID string, tokens int64) {
+	if q.redis == nil {
+		return
+	}
+	reqKey, tokKey := q.counterKeys(keyID)
+	pipe := q.redis.Client().TxPipeline()
+	pipe.Incr(ctx, reqKey)
+	pipe.IncrBy(ctx, tokKey, tokens)
+	pipe.Expire(ctx, reqKey, quotaCounterTTL)
+	pipe.Expire(ctx, tokKey, quotaCounterTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		q.logger.Warn("record key usage failed", "error", err, "key", keyID)
+	}
+}
+
+func counterValue(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
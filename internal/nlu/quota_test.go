@@ -0,0 +1,51 @@
+package nlu
+
+import (
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+func TestQuotaReached(t *testing.T) {
+	cases := []struct {
+		name string
+		key  repo.APIKey
+		used KeyUsage
+		want bool
+	}{
+		{"unlimited", repo.APIKey{}, KeyUsage{Requests: 10000, Tokens: 1 << 30}, false},
+		{"under request quota", repo.APIKey{DailyRequestQuota: 100}, KeyUsage{Requests: 99}, false},
+		{"at request quota", repo.APIKey{DailyRequestQuota: 100}, KeyUsage{Requests: 100}, true},
+		{"token quota only", repo.APIKey{DailyTokenQuota: 5000}, KeyUsage{Requests: 1, Tokens: 5000}, true},
+		{"either quota", repo.APIKey{DailyRequestQuota: 100, DailyTokenQuota: 5000}, KeyUsage{Requests: 3, Tokens: 6000}, true},
+	}
+	for _, tc := range cases {
+		if got := quotaReached(tc.key, tc.used); got != tc.want {
+			t.Errorf("%s: quotaReached = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestQuotaCounterKeysRollOverDaily(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	q := &quotaTracker{now: func() time.Time { return now }}
+	req, tok := q.counterKeys("k1")
+	if req != "nlu:quota:k1:20260301:req" || tok != "nlu:quota:k1:20260301:tok" {
+		t.Fatalf("counter keys = %s, %s", req, tok)
+	}
+	now = now.Add(2 * time.Minute)
+	if next, _ := q.counterKeys("k1"); next == req {
+		t.Fatalf("counter key did not roll over at midnight UTC")
+	}
+}
+
+func TestExtractTokenCount(t *testing.T) {
+	body := []byte(`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":3,"totalTokenCount":15}}`)
+	if got := extractTokenCount(body); got != 15 {
+		t.Fatalf("extractTokenCount = %d, want 15", got)
+	}
+	if got := extractTokenCount([]byte(`{}`)); got != 0 {
+		t.Fatalf("extractTokenCount without usage = %d, want 0", got)
+	}
+}
@@ -0,0 +1,54 @@
+package nlu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxSummaryRunes keeps user profiles short enough to inject into every prompt.
+const maxSummaryRunes = 400
+
+// SummarizeConversation condenses a transcript, together with the previous
+// summary, into a short profile of the user's habits and preferences.
+func (c *Client) SummarizeConversation(ctx context.Context, previous, transcript string) (string, error) {
+	if strings.TrimSpace(transcript) == "" {
+		return "", fmt.Errorf("transcript empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Buat profil singkat pelanggan toko PPOB dari percakapan WhatsApp berikut. ")
+	sb.WriteString("Catat kebiasaan yang berguna untuk transaksi berikutnya: produk/nominal yang sering dibeli, operator atau game, nomor/ID tujuan yang sering dipakai, metode pembayaran favorit, dan gaya bahasa. ")
+	sb.WriteString("Abaikan obrolan yang tidak relevan dan jangan mengarang. ")
+	sb.WriteString("Balas hanya profilnya dalam satu paragraf bahasa Indonesia, maksimal 300 karakter, tanpa teks tambahan.\n\n")
+	if previous = strings.TrimSpace(previous); previous != "" {
+		sb.WriteString("Profil sebelumnya (perbarui bila ada info baru):\n")
+		sb.WriteString(previous + "\n\n")
+	}
+	sb.WriteString("Percakapan terbaru:\n")
+	sb.WriteString(transcript)
+
+	resp, err := c.generate(ctx, Request{
+		Prompt:      sb.String(),
+		Temperature: 0.2,
+		MaxTokens:   256,
+	})
+	if err != nil {
+		return "", err
+	}
+	summary := cleanSummary(resp.Text)
+	if summary == "" {
+		return "", fmt.Errorf("empty conversation summary")
+	}
+	return summary, nil
+}
+
+// cleanSummary flattens the model output to one line and caps its length.
+func cleanSummary(text string) string {
+	summary := strings.Join(strings.Fields(strings.Trim(strings.TrimSpace(text), "`\"")), " ")
+	runes := []rune(summary)
+	if len(runes) > maxSummaryRunes {
+		summary = strings.TrimSpace(string(runes[:maxSummaryRunes])) + "..."
+	}
+	return summary
+}
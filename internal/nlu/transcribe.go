@@ -0,0 +1,135 @@
+package nlu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// WhisperConfig points voice-note transcription at an OpenAI-compatible
+// /audio/transcriptions endpoint (OpenAI, Groq, a self-hosted whisper server).
+type WhisperConfig struct {
+	URL    string
+	APIKey string
+	Model  string
+	// Language hints the spoken language, e.g. "id"; empty lets the model guess.
+	Language string
+}
+
+// TranscribeAudio converts a voice note into text, using Whisper when it is
+// configured and the LLM providers that accept audio otherwise or when
+// Whisper fails.
+func (c *Client) TranscribeAudio(ctx context.Context, audio []byte, mimeType string) (string, error) {
+	if len(audio) == 0 {
+		return "", fmt.Errorf("audio payload empty")
+	}
+	if mimeType == "" {
+		mimeType = "audio/ogg"
+	}
+	if c.whisper.URL != "" {
+		text, err := c.transcribeWhisper(ctx, audio, mimeType)
+		if err == nil {
+			return text, nil
+		}
+		c.logger.Warn("whisper transcription failed, trying llm", "error", err)
+	}
+	return c.transcribeLLM(ctx, audio, mimeType)
+}
+
+func (c *Client) transcribeLLM(ctx context.Context, audio []byte, mimeType string) (string, error) {
+	resp, err := c.generate(ctx, Request{
+		Prompt:      "Transkripsikan voice note berikut ke teks bahasa Indonesia yang mudah dibaca. Balas hanya isi transkrip tanpa tambahan lain.",
+		Media:       &Media{MimeType: mimeType, Data: audio},
+		Temperature: 0.2,
+		MaxTokens:   256,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Text), nil
+}
+
+func (c *Client) transcribeWhisper(ctx context.Context, audio []byte, mimeType string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "voice"+audioExtension(mimeType))
+	if err != nil {
+		return "", fmt.Errorf("build whisper form: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("build whisper form: %w", err)
+	}
+	fields := map[string]string{
+		"model":           nonEmpty(c.whisper.Model, "whisper-1"),
+		"language":        c.whisper.Language,
+		"response_format": "json",
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("build whisper form: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("build whisper form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.whisper.URL, &body)
+	if err != nil {
+		return "", fmt.Errorf("build whisper request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if c.whisper.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.whisper.APIKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whisper request: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read whisper response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("whisper status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var parsed struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("decode whisper response: %w", err)
+	}
+	return strings.TrimSpace(parsed.Text), nil
+}
+
+// audioExtension names the upload after its type; Whisper servers pick the
+// decoder from the file name. WhatsApp voice notes are "audio/ogg; codecs=opus".
+func audioExtension(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = mimeType
+	}
+	switch mediaType {
+	case "audio/ogg", "audio/opus":
+		return ".ogg"
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/mp4", "audio/aac", "audio/x-m4a":
+		return ".m4a"
+	case "audio/wav", "audio/x-wav":
+		return ".wav"
+	case "audio/webm":
+		return ".webm"
+	default:
+		return ".ogg"
+	}
+}
@@ -0,0 +1,63 @@
+package nlu
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTranscribeWhisper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "voice.ogg" || string(data) != "opus" || r.FormValue("model") != "whisper-large-v3" || r.FormValue("language") != "id" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"text":" beli pulsa telkomsel dua puluh ribu "}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		httpClient: srv.Client(),
+		whisper:    WhisperConfig{URL: srv.URL, APIKey: "secret", Model: "whisper-large-v3", Language: "id"},
+	}
+	got, err := c.transcribeWhisper(context.Background(), []byte("opus"), "audio/ogg; codecs=opus")
+	if err != nil {
+		t.Fatalf("transcribeWhisper: %v", err)
+	}
+	if got != "beli pulsa telkomsel dua puluh ribu" {
+		t.Fatalf("transcript = %q", got)
+	}
+
+	c.whisper.APIKey = "wrong"
+	if _, err := c.transcribeWhisper(context.Background(), []byte("opus"), "audio/ogg"); err == nil {
+		t.Fatalf("rejected request reported success")
+	}
+}
+
+func TestAudioExtension(t *testing.T) {
+	cases := map[string]string{
+		"audio/ogg; codecs=opus": ".ogg",
+		"audio/mpeg":             ".mp3",
+		"audio/mp4":              ".m4a",
+		"":                       ".ogg",
+	}
+	for in, want := range cases {
+		if got := audioExtension(in); got != want {
+			t.Errorf("audioExtension(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+const (
+	deliveryBatchSize = 20
+	maxBackoff        = 6 * time.Hour
+	// responseBodyLimit caps how much of a merchant response is read before
+	// the connection is released; the body itself is ignored.
+	responseBodyLimit = 64 << 10
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256 of
+// timestamp + "." + body under the webhook secret. Merchants recompute it to
+// verify a request, and reject old timestamps to stop replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a signing secret for a new webhook.
+func NewSecret() string {
+	return "whsec_" + randomHex(24)
+}
+
+// Run delivers due events until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		n.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Notifier) deliverDue(ctx context.Context) {
+	// The lease outlasts a full batch of timed-out posts, so a delivery is
+	// not claimed again while still in flight.
+	lease := n.cfg.Timeout*deliveryBatchSize + time.Minute
+	due, err := n.repo.ClaimDueMerchantWebhookDeliveries(ctx, n.now(), lease, deliveryBatchSize)
+	if err != nil {
+		n.logger.Error("failed claiming merchant webhook deliveries", "error", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+	hooks, err := n.repo.ListMerchantWebhooks(ctx)
+	if err != nil {
+		n.logger.Error("failed listing merchant webhooks", "error", err)
+		return
+	}
+	byID := make(map[string]repo.MerchantWebhook, len(hooks))
+	for _, hook := range hooks {
+		byID[hook.ID] = hook
+	}
+	for _, d := range due {
+		hook, ok := byID[d.WebhookID]
+		if !ok {
+			// Deleted since the claim; its deliveries go with it.
+			continue
+		}
+		n.attempt(ctx, hook, d)
+	}
+}
+
+func (n *Notifier) attempt(ctx context.Context, hook repo.MerchantWebhook, d repo.MerchantWebhookDelivery) {
+	logger := n.logger.With("webhook_id", hook.ID, "delivery_id", d.ID, "event", d.EventType)
+	status, err := n.post(ctx, hook, d)
+	now := n.now()
+	d.Attempts++
+	d.ResponseStatus = nil
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+	if err == nil {
+		d.Status = repo.WebhookDeliveryDelivered
+		d.DeliveredAt = &now
+		d.Error = nil
+	} else {
+		msg := err.Error()
+		d.Error = &msg
+		if d.Attempts >= n.cfg.MaxAttempts {
+			d.Status = repo.WebhookDeliveryFailed
+			logger.Warn("merchant webhook delivery failed", "error", err, "attempts", d.Attempts)
+		} else {
+			d.NextAttemptAt = now.Add(backoff(d.Attempts))
+			logger.Info("merchant webhook delivery will retry", "error", err, "attempts", d.Attempts, "next_attempt_at", d.NextAttemptAt)
+		}
+	}
+	if err := n.repo.UpdateMerchantWebhookDelivery(context.WithoutCancel(ctx), d); err != nil {
+		logger.Error("failed recording merchant webhook delivery", "error", err)
+	}
+}
+
+// post sends one delivery and returns the response status, zero if there
+// was no response. Any non-2xx status is an error.
+func (n *Notifier) post(ctx context.Context, hook repo.MerchantWebhook, d repo.MerchantWebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	timestamp := strconv.FormatInt(n.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bot-jual-webhooks/1")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderEventID, d.EventID)
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, d.Payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, responseBodyLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("merchant responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait before the attempt after the given number of failed
+// ones: 30s, 2m, 8m, 32m, ... capped at six hours.
+func backoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		wait *= 4
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
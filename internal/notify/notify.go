@@ -0,0 +1,196 @@
+// Package notify delivers order and deposit events to merchant-configured
+// webhooks. Events are queued per webhook in the delivery log and posted as
+// signed JSON by a background worker, which retries failures with backoff.
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+// Event types merchant webhooks can subscribe to.
+const (
+	EventOrderCreated = "order.created"
+	EventOrderSuccess = "order.success"
+	EventDepositPaid  = "deposit.paid"
+)
+
+// EventTypes lists every event type, in the order they are documented.
+var EventTypes = []string{EventOrderCreated, EventOrderSuccess, EventDepositPaid}
+
+// Config groups knobs for webhook delivery.
+type Config struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+	HTTPClient   *http.Client
+}
+
+// Notifier queues events for merchant webhooks and delivers them.
+type Notifier struct {
+	repo   repo.Repository
+	client *http.Client
+	logger *slog.Logger
+	cfg    Config
+	now    func() time.Time
+}
+
+// New constructs a Notifier.
+func New(repository repo.Repository, logger *slog.Logger, cfg Config) *Notifier {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 6
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 15 * time.Second
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Notifier{
+		repo:   repository,
+		client: client,
+		logger: logger.With("component", "merchant_webhooks"),
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// Event is the JSON body posted to a merchant webhook.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// OrderData is the data of order.created and order.success events.
+type OrderData struct {
+	OrderRef    string    `json:"order_ref"`
+	ProductCode string    `json:"product_code"`
+	CustomerRef string    `json:"customer_ref,omitempty"`
+	Amount      int64     `json:"amount"`
+	Fee         int64     `json:"fee"`
+	Status      string    `json:"status"`
+	SN          string    `json:"sn,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DepositData is the data of deposit.paid events.
+type DepositData struct {
+	DepositRef string    `json:"deposit_ref"`
+	Method     string    `json:"method"`
+	Amount     int64     `json:"amount"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderCreated publishes order.created for a newly inserted order.
+func (n *Notifier) OrderCreated(ctx context.Context, order repo.Order) {
+	n.publish(ctx, EventOrderCreated, orderData(order, ""))
+}
+
+// OrderSucceeded publishes order.success once an order is fulfilled. sn is
+// the serial number from the provider, if known.
+func (n *Notifier) OrderSucceeded(ctx context.Context, order repo.Order, sn string) {
+	data := orderData(order, sn)
+	data.Status = "success"
+	n.publish(ctx, EventOrderSuccess, data)
+}
+
+// DepositPaid publishes deposit.paid once a deposit is credited.
+func (n *Notifier) DepositPaid(ctx context.Context, dep repo.Deposit) {
+	n.publish(ctx, EventDepositPaid, DepositData{
+		DepositRef: dep.DepositRef,
+		Method:     dep.Method,
+		Amount:     dep.Amount,
+		Status:     "success",
+		CreatedAt:  dep.CreatedAt.UTC(),
+	})
+}
+
+func orderData(order repo.Order, sn string) OrderData {
+	data := OrderData{
+		OrderRef:    order.OrderRef,
+		ProductCode: order.ProductCode,
+		Amount:      order.Amount,
+		Fee:         order.Fee,
+		Status:      order.Status,
+		SN:          sn,
+		CreatedAt:   order.CreatedAt.UTC(),
+	}
+	if v, ok := order.Metadata["customer_ref"].(string); ok {
+		data.CustomerRef = v
+	}
+	if data.SN == "" {
+		if v, ok := order.Metadata["sn"].(string); ok {
+			data.SN = v
+		}
+	}
+	return data
+}
+
+// publish queues an event for every webhook subscribed to its type. Failures
+// are logged, never returned: a merchant integration must not hold up the
+// order or deposit that raised the event.
+func (n *Notifier) publish(ctx context.Context, eventType string, data any) {
+	if n == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	hooks, err := n.repo.ListMerchantWebhooks(ctx)
+	if err != nil {
+		n.logger.Error("failed listing merchant webhooks", "error", err, "event", eventType)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	event := Event{ID: newEventID(), Type: eventType, CreatedAt: n.now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Error("failed encoding merchant webhook event", "error", err, "event", eventType)
+		return
+	}
+	for _, hook := range hooks {
+		if !Subscribed(hook, eventType) {
+			continue
+		}
+		delivery := repo.MerchantWebhookDelivery{WebhookID: hook.ID, EventID: event.ID, EventType: eventType, Payload: body}
+		if err := n.repo.InsertMerchantWebhookDelivery(ctx, delivery); err != nil {
+			n.logger.Error("failed queueing merchant webhook delivery", "error", err, "webhook_id", hook.ID, "event", eventType)
+		}
+	}
+}
+
+// Subscribed reports whether hook receives events of eventType. A webhook
+// without an event list receives everything.
+func Subscribed(hook repo.MerchantWebhook, eventType string) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, eventType)
+}
+
+// ValidEventType reports whether name is a known event type.
+func ValidEventType(name string) bool {
+	return slices.Contains(EventTypes, name)
+}
+
+func newEventID() string {
+	return "evt_" + randomHex(12)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
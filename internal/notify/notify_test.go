@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+)
+
+type fakeRepo struct {
+	repo.Repository
+	hooks      []repo.MerchantWebhook
+	deliveries []repo.MerchantWebhookDelivery
+}
+
+func (f *fakeRepo) ListMerchantWebhooks(context.Context) ([]repo.MerchantWebhook, error) {
+	return f.hooks, nil
+}
+
+func (f *fakeRepo) InsertMerchantWebhookDelivery(_ context.Context, d repo.MerchantWebhookDelivery) error {
+	d.ID = "d" + string(rune('1'+len(f.deliveries)))
+	d.Status = repo.WebhookDeliveryPending
+	f.deliveries = append(f.deliveries, d)
+	return nil
+}
+
+func (f *fakeRepo) ClaimDueMerchantWebhookDeliveries(_ context.Context, now time.Time, _ time.Duration, _ int) ([]repo.MerchantWebhookDelivery, error) {
+	var due []repo.MerchantWebhookDelivery
+	for _, d := range f.deliveries {
+		if d.Status == repo.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeRepo) UpdateMerchantWebhookDelivery(_ context.Context, d repo.MerchantWebhookDelivery) error {
+	for i := range f.deliveries {
+		if f.deliveries[i].ID == d.ID {
+			f.deliveries[i] = d
+		}
+	}
+	return nil
+}
+
+func TestPublishQueuesSubscribedWebhooks(t *testing.T) {
+	r := &fakeRepo{hooks: []repo.MerchantWebhook{
+		{ID: "all"},
+		{ID: "deposits", Events: []string{EventDepositPaid}},
+		{ID: "orders", Events: []string{EventOrderCreated, EventOrderSuccess}},
+	}}
+	n := New(r, slog.New(slog.NewTextHandler(io.Discard, nil)), Config{})
+
+	n.OrderCreated(context.Background(), repo.Order{OrderRef: "ORD-1", ProductCode: "TSEL10", Amount: 10500, Status: "pending"})
+
+	if len(r.deliveries) != 2 || r.deliveries[0].WebhookID != "all" || r.deliveries[1].WebhookID != "orders" {
+		t.Fatalf("deliveries = %+v", r.deliveries)
+	}
+	var event struct {
+		ID   string    `json:"id"`
+		Type string    `json:"type"`
+		Data OrderData `json:"data"`
+	}
+	if err := json.Unmarshal(r.deliveries[0].Payload, &event); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if event.Type != EventOrderCreated || event.ID != r.deliveries[0].EventID || event.Data.OrderRef != "ORD-1" || event.Data.Amount != 10500 {
+		t.Fatalf("event = %+v", event)
+	}
+}
+
+func TestDeliverSignsAndRetries(t *testing.T) {
+	var got []*http.Request
+	var bodies [][]byte
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		got = append(got, req)
+		bodies = append(bodies, body)
+		if fail {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	r := &fakeRepo{hooks: []repo.MerchantWebhook{{ID: "h1", URL: srv.URL, Secret: "whsec_test"}}}
+	n := New(r, slog.New(slog.NewTextHandler(io.Discard, nil)), Config{MaxAttempts: 2})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	n.DepositPaid(context.Background(), repo.Deposit{DepositRef: "DEP-1", Method: "qris", Amount: 50000})
+
+	n.deliverDue(context.Background())
+	d := r.deliveries[0]
+	if d.Status != repo.WebhookDeliveryPending || d.Attempts != 1 || d.ResponseStatus == nil || *d.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("after failure = %+v", d)
+	}
+	if !d.NextAttemptAt.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("next attempt = %v", d.NextAttemptAt)
+	}
+
+	req := got[0]
+	if req.Header.Get(HeaderEvent) != EventDepositPaid || req.Header.Get(HeaderDelivery) != d.ID {
+		t.Fatalf("headers = %v", req.Header)
+	}
+	want := "sha256=" + Sign("whsec_test", req.Header.Get(HeaderTimestamp), bodies[0])
+	if req.Header.Get(HeaderSignature) != want {
+		t.Fatalf("signature = %q, want %q", req.Header.Get(HeaderSignature), want)
+	}
+
+	// Not due yet.
+	n.deliverDue(context.Background())
+	if len(got) != 1 {
+		t.Fatalf("posts = %d, want the retry to wait", len(got))
+	}
+
+	now = now.Add(time.Minute)
+	fail = false
+	n.deliverDue(context.Background())
+	d = r.deliveries[0]
+	if d.Status != repo.WebhookDeliveryDelivered || d.Attempts != 2 || d.DeliveredAt == nil || d.Error != nil {
+		t.Fatalf("after retry = %+v", d)
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := &fakeRepo{hooks: []repo.MerchantWebhook{{ID: "h1", URL: srv.URL, Secret: "s"}}}
+	n := New(r, slog.New(slog.NewTextHandler(io.Discard, nil)), Config{MaxAttempts: 1})
+	n.OrderSucceeded(context.Background(), repo.Order{OrderRef: "ORD-1"}, "SN123")
+
+	n.deliverDue(context.Background())
+	d := r.deliveries[0]
+	if d.Status != repo.WebhookDeliveryFailed || d.Error == nil || !strings.Contains(*d.Error, "500") {
+		t.Fatalf("delivery = %+v", d)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{1: 30 * time.Second, 2: 2 * time.Minute, 3: 8 * time.Minute, 10: maxBackoff}
+	for attempts, want := range cases {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
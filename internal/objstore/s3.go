@@ -0,0 +1,165 @@
+// Package objstore uploads files to S3-compatible object storage (AWS S3,
+// MinIO, Cloudflare R2, ...) using AWS Signature Version 4.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion  = "us-east-1"
+	defaultTimeout = time.Minute
+	signAlgorithm  = "AWS4-HMAC-SHA256"
+	signedHeaders  = "host;x-amz-content-sha256;x-amz-date"
+)
+
+// Config identifies the bucket and credentials to upload with.
+type Config struct {
+	// Endpoint is the storage base URL, e.g. "https://s3.ap-southeast-1.amazonaws.com".
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses objects as endpoint/bucket/key instead of
+	// bucket.endpoint/key; MinIO and most self-hosted stores need it.
+	PathStyle bool
+}
+
+// Client puts objects into one bucket.
+type Client struct {
+	cfg      Config
+	endpoint *url.URL
+	http     *http.Client
+	now      func() time.Time
+}
+
+// New validates cfg and returns a client. transport may be nil.
+func New(cfg Config, transport http.RoundTripper) (*Client, error) {
+	endpoint, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid object storage endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("object storage bucket, access key and secret key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	return &Client{
+		cfg:      cfg,
+		endpoint: endpoint,
+		http:     &http.Client{Timeout: defaultTimeout, Transport: transport},
+		now:      time.Now,
+	}, nil
+}
+
+// PutObject uploads body under key, replacing any existing object.
+func (c *Client) PutObject(ctx context.Context, key, contentType string, body []byte) error {
+	key = strings.TrimLeft(key, "/")
+	if key == "" {
+		return fmt.Errorf("object key is required")
+	}
+	target := c.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build put request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.sign(req, target, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("put object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("put object %s: status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func (c *Client) objectURL(key string) *url.URL {
+	u := *c.endpoint
+	path := "/" + key
+	if c.cfg.PathStyle {
+		path = "/" + c.cfg.Bucket + path
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(c.endpoint.Path, "/") + path
+	u.RawPath = strings.TrimRight(c.endpoint.EscapedPath(), "/") + escapePath(path)
+	return &u
+}
+
+// sign adds SigV4 headers for an unqueried request to target.
+func (c *Client) sign(req *http.Request, target *url.URL, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		target.EscapedPath(),
+		"",
+		"host:" + target.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), day)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapePath percent-encodes everything but unreserved characters and "/", as SigV4 requires.
+func escapePath(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,57 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPutObjectPathStyle(t *testing.T) {
+	var gotPath, gotAuth, gotBody, gotType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Endpoint: srv.URL, Bucket: "backups", AccessKey: "AK", SecretKey: "SK", PathStyle: true}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := c.PutObject(context.Background(), "catalog/products 1.json", "application/json", []byte(`[]`)); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if gotPath != "/backups/catalog/products%201.json" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AK/20260102/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Fatalf("authorization = %q", gotAuth)
+	}
+	if gotBody != "[]" || gotType != "application/json" {
+		t.Fatalf("body = %q, content type = %q", gotBody, gotType)
+	}
+}
+
+func TestPutObjectReportsStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	c, err := New(Config{Endpoint: srv.URL, Bucket: "b", AccessKey: "AK", SecretKey: "SK", PathStyle: true}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	err = c.PutObject(context.Background(), "k", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("PutObject error = %v", err)
+	}
+}
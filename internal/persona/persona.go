@@ -0,0 +1,118 @@
+// Package persona holds the operator's branding for bot replies.
+package persona
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Supported tones.
+const (
+	ToneCasual = "casual"
+	ToneFormal = "formal"
+)
+
+// Persona describes how the bot presents itself to customers.
+type Persona struct {
+	ShopName string
+	Tone     string
+	// NoEmoji strips emoji from replies; the zero value keeps them.
+	NoEmoji   bool
+	Signature string
+}
+
+// Default returns the persona used when nothing is configured.
+func Default() Persona {
+	return Persona{Tone: ToneCasual}
+}
+
+// Formal reports whether replies should use formal Indonesian.
+func (p Persona) Formal() bool {
+	return strings.EqualFold(p.Tone, ToneFormal)
+}
+
+// PromptInstructions describes the persona for the LLM prompt.
+func (p Persona) PromptInstructions() string {
+	var sb strings.Builder
+	if p.ShopName != "" {
+		sb.WriteString("Anda mewakili toko \"" + p.ShopName + "\"; sebut nama toko bila menyapa. ")
+	}
+	if p.Formal() {
+		sb.WriteString("Gunakan bahasa Indonesia formal dan sopan: sapa user dengan \"Anda\", sebut diri sebagai \"kami\", hindari slang. ")
+	} else {
+		sb.WriteString("Gunakan gaya santai dan akrab: sapa user dengan \"kak\"/\"kamu\", sebut diri sebagai \"aku\". ")
+	}
+	if p.NoEmoji {
+		sb.WriteString("Jangan gunakan emoji.")
+	} else {
+		sb.WriteString("Emoji boleh dipakai secukupnya.")
+	}
+	return sb.String()
+}
+
+var formalReplacements = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{regexp.MustCompile(`\bkamu\b`), "Anda"},
+	{regexp.MustCompile(`\bKamu\b`), "Anda"},
+	{regexp.MustCompile(`\baku\b`), "kami"},
+	{regexp.MustCompile(`\bAku\b`), "Kami"},
+}
+
+// Apply adapts a template reply to the persona's tone and emoji preference.
+func (p Persona) Apply(text string) string {
+	if p.Formal() {
+		for _, r := range formalReplacements {
+			text = r.pattern.ReplaceAllString(text, r.repl)
+		}
+	}
+	if p.NoEmoji {
+		text = stripEmoji(text)
+	}
+	return text
+}
+
+// Sign appends the signature line, if any, to a template reply.
+func (p Persona) Sign(text string) string {
+	if strings.TrimSpace(p.Signature) == "" {
+		return text
+	}
+	return text + "\n\n" + p.Signature
+}
+
+func stripEmoji(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	skipSpace := false
+	for _, r := range text {
+		if isEmoji(r) {
+			// Drop the space that separated the emoji from the text, as in "📱 *Pulsa*".
+			skipSpace = true
+			continue
+		}
+		if skipSpace && r == ' ' {
+			skipSpace = false
+			continue
+		}
+		skipSpace = false
+		sb.WriteRune(r)
+	}
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r == 0xFE0F || r == 0x200D:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package persona
+
+import "testing"
+
+func TestApply(t *testing.T) {
+	p := Persona{Tone: ToneFormal, NoEmoji: true}
+	got := p.Apply("📱 *Pulsa* - aku cek dulu\n  - saldo kamu cukup ✅")
+	want := "*Pulsa* - kami cek dulu\n  - saldo Anda cukup"
+	if got != want {
+		t.Fatalf("Apply = %q, want %q", got, want)
+	}
+
+	casual := Default()
+	if got := casual.Apply("Halo kamu 👋"); got != "Halo kamu 👋" {
+		t.Fatalf("default persona should not change text, got %q", got)
+	}
+}
+
+func TestSign(t *testing.T) {
+	p := Persona{Signature: "— Toko Pulsa Jaya"}
+	if got := p.Sign("Halo"); got != "Halo\n\n— Toko Pulsa Jaya" {
+		t.Fatalf("Sign = %q", got)
+	}
+	if got := Default().Sign("Halo"); got != "Halo" {
+		t.Fatalf("Sign without signature = %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+// Package pricing turns supplier costs into selling prices using the markup
+// rules an operator manages from the admin API.
+package pricing
+
+import (
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+// Rules resolves the markup for a product: its own rule, else its
+// category's, else the default. Without any rule products sell at cost.
+// A minimum margin, when set, is the least any price adds to cost.
+type Rules struct {
+	fallback   *repo.PricingRule
+	categories map[string]repo.PricingRule
+	products   map[string]repo.PricingRule
+	minMargin  money.Money
+}
+
+// NewRules indexes rules by scope. Keys are matched case-insensitively.
+func NewRules(rules []repo.PricingRule) Rules {
+	r := Rules{
+		categories: map[string]repo.PricingRule{},
+		products:   map[string]repo.PricingRule{},
+	}
+	for _, rule := range rules {
+		switch rule.Scope {
+		case repo.PricingScopeDefault:
+			rule := rule
+			r.fallback = &rule
+		case repo.PricingScopeCategory:
+			r.categories[strings.ToLower(strings.TrimSpace(rule.Key))] = rule
+		case repo.PricingScopeProduct:
+			r.products[strings.ToUpper(strings.TrimSpace(rule.Key))] = rule
+		}
+	}
+	return r
+}
+
+// WithMinMargin returns r with prices raised to at least cost plus margin,
+// so a small markup or none cannot quote a price the margin check refuses.
+func (r Rules) WithMinMargin(margin money.Money) Rules {
+	r.minMargin = margin
+	return r
+}
+
+// Empty reports whether no rule or minimum margin is configured.
+func (r Rules) Empty() bool {
+	return r.fallback == nil && len(r.categories) == 0 && len(r.products) == 0 && r.minMargin <= 0
+}
+
+// RuleFor returns the rule that prices item.
+func (r Rules) RuleFor(item atl.PriceListItem) (repo.PricingRule, bool) {
+	if rule, ok := r.products[strings.ToUpper(strings.TrimSpace(item.Code))]; ok {
+		return rule, true
+	}
+	if rule, ok := r.categories[strings.ToLower(strings.TrimSpace(item.Category))]; ok {
+		return rule, true
+	}
+	if r.fallback != nil {
+		return *r.fallback, true
+	}
+	return repo.PricingRule{}, false
+}
+
+// Price returns the selling price of item, whose Price is the supplier cost.
+func (r Rules) Price(item atl.PriceListItem) money.Money {
+	price := item.Price
+	if rule, ok := r.RuleFor(item); ok {
+		price = Markup(item.Price, rule)
+	}
+	if item.Price > 0 && price < item.Price+r.minMargin {
+		price = item.Price + r.minMargin
+	}
+	return price
+}
+
+// Apply returns a copy of items with selling prices in place of costs.
+func (r Rules) Apply(items []atl.PriceListItem) []atl.PriceListItem {
+	if r.Empty() {
+		return items
+	}
+	res := make([]atl.PriceListItem, len(items))
+	copy(res, items)
+	for i := range res {
+		res[i].Price = r.Price(res[i])
+	}
+	return res
+}
+
+// Markup adds rule's margins to cost and rounds the result up, so rounding
+// never eats into the margin.
+func Markup(cost money.Money, rule repo.PricingRule) money.Money {
+	if cost <= 0 {
+		return cost
+	}
+	price := cost + money.FromRupiah(rule.MarginFixed) + cost.MulRateCeil(rule.MarginPercent/100)
+	if step := money.FromRupiah(rule.RoundTo); step > 0 {
+		price = (price + step - 1) / step * step
+	}
+	return price
+}
@@ -0,0 +1,70 @@
+package pricing
+
+import (
+	"testing"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestMarkup(t *testing.T) {
+	cases := []struct {
+		name string
+		cost int64
+		rule repo.PricingRule
+		want int64
+	}{
+		{"fixed", 10250, repo.PricingRule{MarginFixed: 500}, 10750},
+		{"percent rounds up", 10250, repo.PricingRule{MarginPercent: 2.5}, 10507},
+		{"fixed and percent", 20000, repo.PricingRule{MarginFixed: 300, MarginPercent: 1}, 20500},
+		{"rounded up to step", 10250, repo.PricingRule{MarginFixed: 500, RoundTo: 500}, 11000},
+		{"already on step", 10500, repo.PricingRule{RoundTo: 500}, 10500},
+	}
+	for _, tc := range cases {
+		if got := Markup(money.FromRupiah(tc.cost), tc.rule); got != money.FromRupiah(tc.want) {
+			t.Errorf("%s: Markup = %v, want %d", tc.name, got.Rupiah(), tc.want)
+		}
+	}
+}
+
+func TestRulesPrecedence(t *testing.T) {
+	rules := NewRules([]repo.PricingRule{
+		{Scope: repo.PricingScopeDefault, MarginFixed: 1000},
+		{Scope: repo.PricingScopeCategory, Key: "Games", MarginFixed: 2000},
+		{Scope: repo.PricingScopeProduct, Key: "ml86", MarginFixed: 3000},
+	})
+	items := rules.Apply([]atl.PriceListItem{
+		{Code: "ML86", Category: "games", Price: money.FromRupiah(20000)},
+		{Code: "FF70", Category: "games", Price: money.FromRupiah(10000)},
+		{Code: "TSEL25", Category: "pulsa", Price: money.FromRupiah(25000)},
+	})
+	want := []int64{23000, 12000, 26000}
+	for i, item := range items {
+		if item.Price != money.FromRupiah(want[i]) {
+			t.Errorf("%s price = %d, want %d", item.Code, item.Price.Rupiah(), want[i])
+		}
+	}
+
+	none := NewRules(nil)
+	cost := []atl.PriceListItem{{Code: "A", Price: money.FromRupiah(5000)}}
+	if got := none.Apply(cost); got[0].Price != money.FromRupiah(5000) {
+		t.Errorf("no rules should sell at cost, got %d", got[0].Price.Rupiah())
+	}
+}
+
+func TestRulesMinMargin(t *testing.T) {
+	rules := NewRules([]repo.PricingRule{
+		{Scope: repo.PricingScopeProduct, Key: "ml86", MarginFixed: 3000},
+	}).WithMinMargin(money.FromRupiah(500))
+	items := rules.Apply([]atl.PriceListItem{
+		{Code: "ML86", Price: money.FromRupiah(20000)},
+		{Code: "FF70", Price: money.FromRupiah(10000)},
+	})
+	want := []int64{23000, 10500}
+	for i, item := range items {
+		if item.Price != money.FromRupiah(want[i]) {
+			t.Errorf("%s price = %d, want %d", item.Code, item.Price.Rupiah(), want[i])
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+// Zone renders receipt timestamps in Western Indonesia Time.
+var Zone = time.FixedZone("WIB", 7*3600)
+
+// Document is the content of a rendered receipt.
+type Document struct {
+	Shop     string
+	OrderRef string
+	// Status is the raw order status; StatusLabel words it for customers.
+	Status   string
+	Product  string
+	Customer string
+	SN       string
+	// Quantity is how many units a batch receipt covers and SNs holds the
+	// serial of each delivered unit; single orders leave both empty.
+	Quantity int
+	SNs      []string
+	Amount   money.Money
+	Fee      money.Money
+	IssuedAt time.Time
+	// URL links the public receipt page when receipts are configured.
+	URL    string
+	Locale money.Locale
+	// Location renders IssuedAt in the customer's zone; nil means Zone.
+	Location *time.Location
+}
+
+// NewDocument describes order as a receipt. The customer number is masked,
+// since receipts get forwarded.
+func NewDocument(order *repo.Order, shop string, locale money.Locale) Document {
+	return Document{
+		Shop:     shop,
+		OrderRef: order.OrderRef,
+		Status:   order.Status,
+		Product:  order.ProductCode,
+		Customer: MaskCustomerID(metadataString(order.Metadata, "customer_id")),
+		SN:       metadataString(order.Metadata, "sn"),
+		Amount:   money.FromRupiah(order.Amount),
+		Fee:      money.FromRupiah(order.Fee),
+		IssuedAt: order.UpdatedAt,
+		Locale:   locale,
+	}
+}
+
+// BatchRefs returns the refs of every order bought together with order,
+// itself included, or nil when it was bought alone. Units of a batch share
+// the batch ref and are numbered from one after it.
+func BatchRefs(order *repo.Order) []string {
+	batchRef := metadataString(order.Metadata, "batch_ref")
+	size := metadataInt(order.Metadata, "batch_size")
+	if batchRef == "" || size < 2 {
+		return nil
+	}
+	refs := make([]string, size)
+	for i := range refs {
+		refs[i] = UnitRef(batchRef, i+1)
+	}
+	return refs
+}
+
+// UnitRef returns the order ref of the unit-th unit, counted from one, of
+// the batch batchRef.
+func UnitRef(batchRef string, unit int) string {
+	return fmt.Sprintf("%s-%d", batchRef, unit)
+}
+
+// OrderGetter loads orders by ref; repo.Repository fits.
+type OrderGetter interface {
+	GetOrderByRef(ctx context.Context, orderRef string) (*repo.Order, error)
+}
+
+// LoadBatch loads every unit of the batch order belongs to, in unit order,
+// or returns nil when order was bought alone.
+func LoadBatch(ctx context.Context, orders OrderGetter, order *repo.Order) ([]repo.Order, error) {
+	refs := BatchRefs(order)
+	if refs == nil {
+		return nil, nil
+	}
+	units := make([]repo.Order, 0, len(refs))
+	for _, ref := range refs {
+		if ref == order.OrderRef {
+			units = append(units, *order)
+			continue
+		}
+		unit, err := orders.GetOrderByRef(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("load batch order %s: %w", ref, err)
+		}
+		units = append(units, *unit)
+	}
+	return units, nil
+}
+
+// BatchFinal reports whether every unit of a batch has succeeded or failed.
+func BatchFinal(units []repo.Order) bool {
+	return batchStatus(units) != "processing"
+}
+
+// NewBatchDocument describes the units of one batch, as listed by
+// BatchRefs, as a single receipt under the batch ref. Failed units are not
+// charged, so they count towards neither the amount nor the SNs. A batch
+// bought for several numbers lists each of them.
+func NewBatchDocument(orders []repo.Order, shop string, locale money.Locale) Document {
+	doc := NewDocument(&orders[0], shop, locale)
+	doc.OrderRef = metadataString(orders[0].Metadata, "batch_ref")
+	doc.Status = batchStatus(orders)
+	doc.SN = ""
+	doc.Quantity = len(orders)
+	doc.Amount, doc.Fee = 0, 0
+	var customers []string
+	for _, order := range orders {
+		if c := MaskCustomerID(metadataString(order.Metadata, "customer_id")); c != "" && !slices.Contains(customers, c) {
+			customers = append(customers, c)
+		}
+		if order.UpdatedAt.After(doc.IssuedAt) {
+			doc.IssuedAt = order.UpdatedAt
+		}
+		if order.Status == "failed" {
+			continue
+		}
+		doc.Amount += money.FromRupiah(order.Amount)
+		doc.Fee += money.FromRupiah(order.Fee)
+		if sn := metadataString(order.Metadata, "sn"); sn != "" {
+			doc.SNs = append(doc.SNs, sn)
+		}
+	}
+	if len(customers) > 1 {
+		doc.Customer = strings.Join(customers, ", ")
+	}
+	return doc
+}
+
+// batchStatus sums up the units: success or failed when they agree, partial
+// when some of the finished units failed, and processing while any unit is
+// still open.
+func batchStatus(orders []repo.Order) string {
+	var success, failed int
+	for _, order := range orders {
+		switch order.Status {
+		case "success":
+			success++
+		case "failed":
+			failed++
+		default:
+			return "processing"
+		}
+	}
+	switch {
+	case failed == 0:
+		return "success"
+	case success == 0:
+		return "failed"
+	default:
+		return "partial"
+	}
+}
+
+// FileName is the attachment name the receipt is sent under.
+func (d Document) FileName() string {
+	return "bukti-" + d.OrderRef + ".pdf"
+}
+
+// StatusLabel words an order status for customers.
+func StatusLabel(status string) string {
+	switch status = strings.ToLower(strings.TrimSpace(status)); status {
+	case "success":
+		return "Sukses"
+	case "failed":
+		return "Gagal"
+	case "partial":
+		return "Sebagian sukses"
+	case "awaiting_payment":
+		return "Menunggu pembayaran"
+	case "", "pending", "processing", "process":
+		return "Diproses"
+	default:
+		return strings.ToUpper(status)
+	}
+}
+
+// MaskCustomerID hides the middle of a customer number so a shared receipt
+// does not expose it in full.
+func MaskCustomerID(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) <= 6 {
+		return id
+	}
+	return fmt.Sprintf("%s%s%s", id[:3], strings.Repeat("•", len(id)-6), id[len(id)-3:])
+}
+
+func metadataString(meta map[string]any, key string) string {
+	if meta == nil {
+		return ""
+	}
+	if val, ok := meta[key].(string); ok {
+		return strings.TrimSpace(val)
+	}
+	return ""
+}
+
+func metadataInt(meta map[string]any, key string) int {
+	switch v := meta[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package receipt
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+func TestDocumentPDF(t *testing.T) {
+	order := &repo.Order{
+		OrderRef:    "trx-abc123",
+		ProductCode: "PLN50",
+		Amount:      51500,
+		Fee:         2500,
+		Status:      "success",
+		Metadata:    map[string]any{"customer_id": "081234567890", "sn": "1234-5678-9012-3456-7890"},
+		UpdatedAt:   time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC),
+	}
+	doc := NewDocument(order, "Toko (Jaya)", money.Indonesian)
+	pdf := doc.PDF()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF: %q...", pdf[:16])
+	}
+	for _, want := range []string{
+		"(trx-abc123)",
+		"(PLN50)",
+		"(Toko \\(Jaya\\))",
+		"(081\x95\x95\x95\x95\x95\x95890)",
+		"(Rp54.000)",
+		"(01 Mar 2026 15:30 WIB)",
+		"(SUKSES)",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF missing %q", want)
+		}
+	}
+
+	// Every xref entry must point at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		off, _ := strconv.Atoi(string(entry[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[off:off+8])
+		}
+	}
+	if doc.FileName() != "bukti-trx-abc123.pdf" {
+		t.Errorf("FileName = %q", doc.FileName())
+	}
+}
+
+func TestWrapValue(t *testing.T) {
+	lines := wrapValue("1234-5678-9012-3456-7890", 10)
+	if len(lines) != 3 || lines[0] != "1234-5678-" {
+		t.Fatalf("wrapValue = %q", lines)
+	}
+	if got := wrapValue("short", 10); len(got) != 1 || got[0] != "short" {
+		t.Fatalf("wrapValue short = %q", got)
+	}
+}
+
+func TestBatchDocument(t *testing.T) {
+	meta := func(sn string) map[string]any {
+		return map[string]any{"customer_id": "081234567890", "sn": sn, "batch_ref": "trx-b1", "batch_size": float64(3)}
+	}
+	orders := []repo.Order{
+		{OrderRef: "trx-b1-1", ProductCode: "GPLAY50", Amount: 51000, Status: "success", Metadata: meta("AAAA-1111")},
+		{OrderRef: "trx-b1-2", ProductCode: "GPLAY50", Amount: 51000, Status: "failed", Metadata: meta("")},
+		{OrderRef: "trx-b1-3", ProductCode: "GPLAY50", Amount: 51000, Status: "success", Metadata: meta("CCCC-3333")},
+	}
+	if refs := BatchRefs(&orders[1]); len(refs) != 3 || refs[0] != "trx-b1-1" || refs[2] != "trx-b1-3" {
+		t.Fatalf("BatchRefs = %q", refs)
+	}
+	if refs := BatchRefs(&repo.Order{OrderRef: "trx-x"}); refs != nil {
+		t.Fatalf("BatchRefs single = %q", refs)
+	}
+
+	doc := NewBatchDocument(orders, "Toko", money.Indonesian)
+	if doc.OrderRef != "trx-b1" || doc.Status != "partial" || doc.Quantity != 3 || doc.Amount != money.FromRupiah(102000) {
+		t.Fatalf("batch document = %+v", doc)
+	}
+	pdf := doc.PDF()
+	for _, want := range []string{"(SN 1)", "(AAAA-1111)", "(SN 2)", "(CCCC-3333)", "(Jumlah)", "(SEBAGIAN SUKSES)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF missing %q", want)
+		}
+	}
+	if doc.Customer != MaskCustomerID("081234567890") {
+		t.Fatalf("single-number batch customer = %q", doc.Customer)
+	}
+
+	orders[1].Metadata = map[string]any{"customer_id": "085712345678", "batch_ref": "trx-b1", "batch_size": float64(3)}
+	multi := NewBatchDocument(orders, "Toko", money.Indonesian)
+	if want := MaskCustomerID("081234567890") + ", " + MaskCustomerID("085712345678"); multi.Customer != want {
+		t.Fatalf("multi-number batch customer = %q, want %q", multi.Customer, want)
+	}
+}
@@ -0,0 +1,190 @@
+package receipt
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"strings"
+
+	"bot-jual/internal/money"
+)
+
+// The receipt is one A6 page drawn with the standard Helvetica faces, which
+// every PDF viewer ships, so no font files are embedded.
+const (
+	pageWidth   = 298
+	pageHeight  = 420
+	pageMargin  = 24
+	valueColumn = 110
+	// valueWrap is how many characters fit in the value column at body size.
+	valueWrap = 30
+)
+
+// PDF renders the receipt as a single-page PDF document.
+func (d Document) PDF() []byte {
+	content := d.pdfContent()
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+type pdfRow struct {
+	label string
+	value string
+	bold  bool
+}
+
+func (d Document) rows() []pdfRow {
+	locale := d.Locale
+	if locale.Thousands == "" {
+		locale = money.Indonesian
+	}
+	rows := []pdfRow{
+		{label: "Ref", value: d.OrderRef},
+		{label: "Produk", value: d.Product},
+	}
+	if d.Quantity > 1 {
+		rows = append(rows, pdfRow{label: "Jumlah", value: fmt.Sprintf("%d", d.Quantity)})
+	}
+	if d.Customer != "" {
+		rows = append(rows, pdfRow{label: "Tujuan", value: d.Customer})
+	}
+	if d.SN != "" {
+		rows = append(rows, pdfRow{label: "SN", value: d.SN, bold: true})
+	}
+	for i, sn := range d.SNs {
+		rows = append(rows, pdfRow{label: fmt.Sprintf("SN %d", i+1), value: sn, bold: true})
+	}
+	if d.Amount > 0 {
+		rows = append(rows, pdfRow{label: "Harga", value: d.Amount.Format(locale)})
+	}
+	if d.Fee > 0 {
+		rows = append(rows, pdfRow{label: "Biaya admin", value: d.Fee.Format(locale)})
+		rows = append(rows, pdfRow{label: "Total", value: (d.Amount + d.Fee).Format(locale), bold: true})
+	}
+	if !d.IssuedAt.IsZero() {
+		rows = append(rows, pdfRow{label: "Waktu", value: d.IssuedAt.In(cmp.Or(d.Location, Zone)).Format("02 Jan 2006 15:04 MST")})
+	}
+	return rows
+}
+
+func (d Document) pdfContent() string {
+	var sb strings.Builder
+	// Brand header.
+	fmt.Fprintf(&sb, "0.09 0.40 0.78 rg 0 %d %d 64 re f\n", pageHeight-64, pageWidth)
+	shop := d.Shop
+	if shop == "" {
+		shop = "Bukti Transaksi"
+	}
+	pdfText(&sb, "F2", 16, "1 g", pageMargin, pageHeight-34, shop)
+	pdfText(&sb, "F1", 9, "1 g", pageMargin, pageHeight-50, "Bukti Transaksi")
+
+	y := pageHeight - 92
+	status := StatusLabel(d.Status)
+	color := "0.2 g"
+	switch strings.ToLower(strings.TrimSpace(d.Status)) {
+	case "success":
+		color = "0.07 0.48 0.23 rg"
+	case "failed":
+		color = "0.64 0.13 0.09 rg"
+	}
+	pdfText(&sb, "F2", 13, color, pageMargin, y, strings.ToUpper(status))
+	y -= 14
+	fmt.Fprintf(&sb, "0.85 G 0.5 w %d %d m %d %d l S\n", pageMargin, y, pageWidth-pageMargin, y)
+	y -= 20
+
+	for _, row := range d.rows() {
+		pdfText(&sb, "F1", 10, "0.4 g", pageMargin, y, row.label)
+		font := "F1"
+		if row.bold {
+			font = "F2"
+		}
+		for i, line := range wrapValue(row.value, valueWrap) {
+			if i > 0 {
+				y -= 13
+			}
+			pdfText(&sb, font, 10, "0.13 g", valueColumn, y, line)
+		}
+		y -= 20
+	}
+
+	fmt.Fprintf(&sb, "0.85 G 0.5 w %d %d m %d %d l S\n", pageMargin, y+6, pageWidth-pageMargin, y+6)
+	y -= 10
+	pdfText(&sb, "F1", 9, "0.4 g", pageMargin, y, "Terima kasih sudah berbelanja.")
+	if d.URL != "" {
+		for _, line := range wrapValue(d.URL, 56) {
+			y -= 12
+			pdfText(&sb, "F1", 7, "0.09 0.40 0.78 rg", pageMargin, y, line)
+		}
+	}
+	return sb.String()
+}
+
+func pdfText(sb *strings.Builder, font string, size int, color string, x, y int, text string) {
+	fmt.Fprintf(sb, "BT %s /%s %d Tf %d %d Td (%s) Tj ET\n", color, font, size, x, y, pdfString(text))
+}
+
+// pdfString encodes text for a WinAnsi font inside a literal string. Runes
+// the encoding lacks are written as '?'.
+func pdfString(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '•':
+			sb.WriteByte(0x95)
+		case r == '–':
+			sb.WriteByte(0x96)
+		case r == '—':
+			sb.WriteByte(0x97)
+		case r == '\n' || r == '\r' || r == '\t':
+			sb.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			sb.WriteByte(byte(r))
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// wrapValue splits value into lines of at most width characters, breaking at
+// spaces or dashes where it can; tokens and serial numbers often have neither.
+func wrapValue(value string, width int) []string {
+	runes := []rune(strings.TrimSpace(value))
+	var lines []string
+	for len(runes) > width {
+		cut := width
+		for i := width; i > width/2; i-- {
+			if runes[i-1] == ' ' || runes[i-1] == '-' {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimSpace(string(runes[:cut])))
+		runes = runes[cut:]
+	}
+	return append(lines, strings.TrimSpace(string(runes)))
+}
@@ -0,0 +1,70 @@
+// Package receipt issues shareable links to public order receipts.
+package receipt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// signatureLen is the number of HMAC bytes kept in a token.
+const signatureLen = 12
+
+// Links signs order references into receipt tokens. A nil *Links issues no
+// links, so callers need not check whether receipts are configured.
+type Links struct {
+	secret  []byte
+	baseURL string
+}
+
+// New returns a Links that builds URLs under baseURL. It returns nil when
+// either the secret or the base URL is empty.
+func New(secret, baseURL string) *Links {
+	secret = strings.TrimSpace(secret)
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if secret == "" || baseURL == "" {
+		return nil
+	}
+	return &Links{secret: []byte(secret), baseURL: baseURL}
+}
+
+// Token returns the receipt token for an order reference: the reference and
+// a truncated HMAC, so receipts need no extra storage and cannot be guessed.
+func (l *Links) Token(orderRef string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(orderRef)) + "." + l.sign(orderRef)
+}
+
+// URL returns the public receipt link for an order, or "" when receipts are disabled.
+func (l *Links) URL(orderRef string) string {
+	if l == nil || strings.TrimSpace(orderRef) == "" {
+		return ""
+	}
+	return l.baseURL + "/r/" + l.Token(orderRef)
+}
+
+// Verify returns the order reference inside a valid token.
+func (l *Links) Verify(token string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return "", false
+	}
+	ref := string(raw)
+	if !hmac.Equal([]byte(sig), []byte(l.sign(ref))) {
+		return "", false
+	}
+	return ref, true
+}
+
+func (l *Links) sign(orderRef string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(orderRef))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureLen])
+}
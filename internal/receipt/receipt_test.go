@@ -0,0 +1,39 @@
+package receipt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinksRoundTrip(t *testing.T) {
+	links := New("s3cret", "https://shop.example/bot/")
+	url := links.URL("trx-abc123")
+	if !strings.HasPrefix(url, "https://shop.example/bot/r/") {
+		t.Fatalf("URL = %q", url)
+	}
+	token := strings.TrimPrefix(url, "https://shop.example/bot/r/")
+	if ref, ok := links.Verify(token); !ok || ref != "trx-abc123" {
+		t.Fatalf("Verify(%q) = %q, %v", token, ref, ok)
+	}
+
+	forged := New("other", "https://shop.example").Token("trx-abc123")
+	if _, ok := links.Verify(forged); ok {
+		t.Fatalf("forged token accepted")
+	}
+	if _, ok := links.Verify("garbage"); ok {
+		t.Fatalf("malformed token accepted")
+	}
+}
+
+func TestDisabledLinks(t *testing.T) {
+	links := New("", "https://shop.example")
+	if links != nil {
+		t.Fatalf("New without secret should disable receipts")
+	}
+	if url := links.URL("trx-1"); url != "" {
+		t.Fatalf("disabled URL = %q", url)
+	}
+	if _, ok := links.Verify("abc.def"); ok {
+		t.Fatalf("disabled Verify accepted a token")
+	}
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RecordUserAcquisition stores the user's first-touch source.
+func (r *PostgresRepository) RecordUserAcquisition(ctx context.Context, userID string, acq Acquisition) (bool, error) {
+	const q = `
+UPDATE users
+SET acquisition_source = $2, acquisition_detail = NULLIF($3, '')
+WHERE id = $1
+  AND acquisition_source IS NULL
+  AND NOT EXISTS (SELECT 1 FROM messages WHERE user_id = $1);
+`
+	ct, err := r.pool.Exec(ctx, q, userID, acq.Source, acq.Detail)
+	if err != nil {
+		return false, fmt.Errorf("record user acquisition: %w", err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// GetUserAcquisition returns how the user arrived; Source is empty for users
+// who predate tracking.
+func (r *PostgresRepository) GetUserAcquisition(ctx context.Context, userID string) (*Acquisition, error) {
+	const q = `SELECT COALESCE(acquisition_source, ''), COALESCE(acquisition_detail, '') FROM users WHERE id = $1`
+	var acq Acquisition
+	if err := r.pool.QueryRow(ctx, q, userID).Scan(&acq.Source, &acq.Detail); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user acquisition: %w", err)
+	}
+	return &acq, nil
+}
+
+// AcquisitionReport counts users, buyers and successful orders per source since the given time.
+func (r *PostgresRepository) AcquisitionReport(ctx context.Context, since time.Time) ([]AcquisitionStat, error) {
+	const usersQ = `
+SELECT COALESCE(u.acquisition_source, 'unknown'), COALESCE(u.acquisition_detail, ''), COUNT(*),
+       COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.status = 'success'))
+FROM users u
+WHERE u.created_at >= $1
+GROUP BY 1, 2;
+`
+	const ordersQ = `
+SELECT COALESCE(metadata->>'acquisition_source', 'unknown'), COALESCE(metadata->>'acquisition_detail', ''), COUNT(*), COALESCE(SUM(amount), 0)
+FROM orders
+WHERE status = 'success' AND created_at >= $1
+GROUP BY 1, 2;
+`
+	report := acquisitionReport{}
+	rows, err := r.pool.Query(ctx, usersQ, since)
+	if err != nil {
+		return nil, fmt.Errorf("acquisition report users: %w", err)
+	}
+	err = report.scan(rows, func(st *AcquisitionStat, users, buyers int64) { st.Users, st.Buyers = users, buyers })
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	rows, err = r.pool.Query(ctx, ordersQ, since)
+	if err != nil {
+		return nil, fmt.Errorf("acquisition report orders: %w", err)
+	}
+	defer rows.Close()
+	if err := report.scan(rows, func(st *AcquisitionStat, orders, revenue int64) { st.Orders, st.Revenue = orders, revenue }); err != nil {
+		return nil, err
+	}
+	return report.list(), nil
+}
+
+// acquisitionReport merges the user and order halves of the report by source and detail.
+type acquisitionReport map[[2]string]*AcquisitionStat
+
+type acquisitionRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+// scan reads rows of (source, detail, a, b) and hands a and b to set.
+func (m acquisitionReport) scan(rows acquisitionRows, set func(st *AcquisitionStat, a, b int64)) error {
+	for rows.Next() {
+		var source, detail string
+		var a, b int64
+		if err := rows.Scan(&source, &detail, &a, &b); err != nil {
+			return fmt.Errorf("scan acquisition stat: %w", err)
+		}
+		key := [2]string{source, detail}
+		st := m[key]
+		if st == nil {
+			st = &AcquisitionStat{Source: source, Detail: detail}
+			m[key] = st
+		}
+		set(st, a, b)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate acquisition stats: %w", err)
+	}
+	return nil
+}
+
+func (m acquisitionReport) list() []AcquisitionStat {
+	res := make([]AcquisitionStat, 0, len(m))
+	for _, st := range m {
+		res = append(res, *st)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Revenue != res[j].Revenue {
+			return res[i].Revenue > res[j].Revenue
+		}
+		if res[i].Source != res[j].Source {
+			return res[i].Source < res[j].Source
+		}
+		return res[i].Detail < res[j].Detail
+	})
+	return res
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const adminApprovalColumns = `id, code, kind, user_id, user_jid, subject_ref, summary, status, decided_by, decided_at, expires_at, created_at`
+
+type approvalRow interface {
+	Scan(dest ...any) error
+}
+
+func scanAdminApproval(row approvalRow) (*AdminApproval, error) {
+	var a AdminApproval
+	if err := row.Scan(&a.ID, &a.Code, &a.Kind, &a.UserID, &a.UserJID, &a.SubjectRef, &a.Summary, &a.Status, &a.DecidedBy, &a.DecidedAt, &a.ExpiresAt, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanAdminApprovals(rows outboundRows) ([]AdminApproval, error) {
+	var res []AdminApproval
+	for rows.Next() {
+		a, err := scanAdminApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan admin approval: %w", err)
+		}
+		res = append(res, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admin approvals: %w", err)
+	}
+	return res, nil
+}
+
+// InsertAdminApproval stores a pending approval request.
+func (r *PostgresRepository) InsertAdminApproval(ctx context.Context, a AdminApproval) (*AdminApproval, error) {
+	const q = `
+INSERT INTO admin_approvals (code, kind, user_id, user_jid, subject_ref, summary, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ` + adminApprovalColumns + `;`
+	created, err := scanAdminApproval(r.pool.QueryRow(ctx, q, a.Code, a.Kind, a.UserID, a.UserJID, a.SubjectRef, a.Summary, a.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert admin approval: %w", err)
+	}
+	return created, nil
+}
+
+// DecideAdminApproval records an admin's decision on a pending, unexpired
+// approval. Approvals already decided or expired are reported as not found.
+func (r *PostgresRepository) DecideAdminApproval(ctx context.Context, code, status, decidedBy string, at time.Time) (*AdminApproval, error) {
+	const q = `
+UPDATE admin_approvals
+SET status = $2, decided_by = $3, decided_at = $4
+WHERE code = $1 AND status = 'pending' AND expires_at > $4
+RETURNING ` + adminApprovalColumns + `;`
+	a, err := scanAdminApproval(r.pool.QueryRow(ctx, q, code, status, decidedBy, at))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("admin approval %s: %w", code, ErrNotFound)
+		}
+		return nil, fmt.Errorf("decide admin approval: %w", err)
+	}
+	return a, nil
+}
+
+// ExpireAdminApprovals marks pending approvals past their deadline expired and returns them.
+func (r *PostgresRepository) ExpireAdminApprovals(ctx context.Context, now time.Time) ([]AdminApproval, error) {
+	const q = `
+UPDATE admin_approvals
+SET status = 'expired', decided_at = $1
+WHERE status = 'pending' AND expires_at <= $1
+RETURNING ` + adminApprovalColumns + `;`
+	rows, err := r.pool.Query(ctx, q, now)
+	if err != nil {
+		return nil, fmt.Errorf("expire admin approvals: %w", err)
+	}
+	defer rows.Close()
+	return scanAdminApprovals(rows)
+}
@@ -3,48 +3,136 @@ package repo
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 const providerGemini = "gemini"
 
-// SyncGeminiKeys ensures provided keys exist in database with matching priority.
+const apiKeyColumns = `id, provider, value, priority, cooldown_until, source, disabled_at, disabled_reason, daily_request_quota, daily_token_quota, created_at, updated_at`
+
+type apiKeyScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row apiKeyScanner) (*APIKey, error) {
+	var k APIKey
+	if err := row.Scan(&k.ID, &k.Provider, &k.Value, &k.Priority, &k.CooldownUntil, &k.Source, &k.DisabledAt, &k.DisabledReason, &k.DailyRequestQuota, &k.DailyTokenQuota, &k.CreatedAt, &k.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// normaliseKeys trims keys and drops blanks and duplicates, keeping the
+// first occurrence so priorities follow config order.
+func normaliseKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, key)
+	}
+	return res
+}
+
+// SyncGeminiKeys makes the configured keys the active config keys. Keys are
+// upserted with their config priority and config keys no longer listed are
+// disabled; running it again with the same keys changes nothing. Keys an
+// admin disabled stay disabled.
 func (r *PostgresRepository) SyncGeminiKeys(ctx context.Context, keys []string) error {
+	keys = normaliseKeys(keys)
 	if len(keys) == 0 {
 		return fmt.Errorf("no gemini keys provided")
 	}
 
-	for idx, key := range keys {
-		if err := r.upsertAPIKey(ctx, providerGemini, key, idx); err != nil {
-			return err
+	var disabled int64
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		for idx, key := range keys {
+			if err := upsertConfigKey(ctx, tx, providerGemini, key, idx); err != nil {
+				return err
+			}
+		}
+		args := []any{providerGemini, APIKeyRemovedFromConfig}
+		placeholders := make([]string, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, key)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
 		}
+		q := `
+UPDATE api_keys
+SET disabled_at = NOW(),
+    disabled_reason = $2,
+    updated_at = NOW()
+WHERE provider = $1
+  AND source = 'config'
+  AND disabled_at IS NULL
+  AND value NOT IN (` + strings.Join(placeholders, ", ") + `);`
+		ct, err := tx.Exec(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("disable removed api keys: %w", err)
+		}
+		disabled = ct.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if disabled > 0 {
+		r.logger.Info("disabled gemini keys removed from config", "count", disabled)
 	}
 	return nil
 }
 
-func (r *PostgresRepository) upsertAPIKey(ctx context.Context, provider, value string, priority int) error {
+func upsertConfigKey(ctx context.Context, tx pgx.Tx, provider, value string, priority int) error {
 	const q = `
-INSERT INTO api_keys (provider, value, priority)
-VALUES ($1, $2, $3)
+INSERT INTO api_keys (provider, value, priority, source)
+VALUES ($1, $2, $3, 'config')
 ON CONFLICT (provider, value) DO UPDATE
 SET priority = EXCLUDED.priority,
-    updated_at = NOW();`
-	_, err := r.pool.Exec(ctx, q, provider, value, priority)
-	if err != nil {
+    source = 'config',
+    disabled_at = CASE WHEN api_keys.disabled_reason = $4 THEN NULL ELSE api_keys.disabled_at END,
+    disabled_reason = CASE WHEN api_keys.disabled_reason = $4 THEN NULL ELSE api_keys.disabled_reason END,
+    updated_at = NOW()
+WHERE api_keys.priority <> EXCLUDED.priority
+   OR api_keys.source <> 'config'
+   OR api_keys.disabled_reason = $4;`
+	if _, err := tx.Exec(ctx, q, provider, value, priority, APIKeyRemovedFromConfig); err != nil {
 		return fmt.Errorf("upsert api key: %w", err)
 	}
 	return nil
 }
 
-// ListActiveGeminiKeys returns Gemini API keys ordered by priority.
+// ListActiveGeminiKeys returns enabled Gemini API keys ordered by priority.
 func (r *PostgresRepository) ListActiveGeminiKeys(ctx context.Context) ([]APIKey, error) {
-	const q = `
-SELECT id, provider, value, priority, cooldown_until, created_at, updated_at
+	q := `
+SELECT ` + apiKeyColumns + `
 FROM api_keys
 WHERE provider = $1
+  AND disabled_at IS NULL
 ORDER BY priority ASC;
 `
-	rows, err := r.pool.Query(ctx, q, providerGemini)
+	return r.listAPIKeys(ctx, q, providerGemini)
+}
+
+// ListGeminiKeys returns every Gemini key, enabled ones first.
+func (r *PostgresRepository) ListGeminiKeys(ctx context.Context) ([]APIKey, error) {
+	q := `
+SELECT ` + apiKeyColumns + `
+FROM api_keys
+WHERE provider = $1
+ORDER BY disabled_at IS NOT NULL, priority ASC;
+`
+	return r.listAPIKeys(ctx, q, providerGemini)
+}
+
+func (r *PostgresRepository) listAPIKeys(ctx context.Context, q string, args ...any) ([]APIKey, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list api keys: %w", err)
 	}
@@ -52,11 +140,11 @@ ORDER BY priority ASC;
 
 	var res []APIKey
 	for rows.Next() {
-		var k APIKey
-		if err := rows.Scan(&k.ID, &k.Provider, &k.Value, &k.Priority, &k.CooldownUntil, &k.CreatedAt, &k.UpdatedAt); err != nil {
+		k, err := scanAPIKey(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan api key: %w", err)
 		}
-		res = append(res, k)
+		res = append(res, *k)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("list api keys rows: %w", err)
@@ -64,6 +152,57 @@ ORDER BY priority ASC;
 	return res, nil
 }
 
+// AddGeminiKey stores a key added at runtime, re-enabling it if it already exists.
+func (r *PostgresRepository) AddGeminiKey(ctx context.Context, value string, priority int) (*APIKey, error) {
+	q := `
+INSERT INTO api_keys (provider, value, priority, source)
+VALUES ($1, $2, $3, 'admin')
+ON CONFLICT (provider, value) DO UPDATE
+SET priority = EXCLUDED.priority,
+    disabled_at = NULL,
+    disabled_reason = NULL,
+    cooldown_until = NULL,
+    updated_at = NOW()
+RETURNING ` + apiKeyColumns + `;`
+	k, err := scanAPIKey(r.pool.QueryRow(ctx, q, providerGemini, strings.TrimSpace(value), priority))
+	if err != nil {
+		return nil, fmt.Errorf("add api key: %w", err)
+	}
+	return k, nil
+}
+
+// DisableAPIKey stops a key from being used until it is added again.
+func (r *PostgresRepository) DisableAPIKey(ctx context.Context, id string) error {
+	const q = `
+UPDATE api_keys
+SET disabled_at = COALESCE(disabled_at, NOW()),
+    disabled_reason = $2,
+    updated_at = NOW()
+WHERE id = $1;
+`
+	ct, err := r.pool.Exec(ctx, q, id, APIKeyDisabledByAdmin)
+	if err != nil {
+		return fmt.Errorf("disable api key: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("api key %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// SetAPIKeyQuota sets the daily request and token quotas of a key; zero disables a quota.
+func (r *PostgresRepository) SetAPIKeyQuota(ctx context.Context, id string, requests, tokens int64) error {
+	const q = `UPDATE api_keys SET daily_request_quota = $2, daily_token_quota = $3, updated_at = NOW() WHERE id = $1`
+	ct, err := r.pool.Exec(ctx, q, id, requests, tokens)
+	if err != nil {
+		return fmt.Errorf("set api key quota: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("api key %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
 // ClearCooldown resets cooldown for a key.
 func (r *PostgresRepository) ClearCooldown(ctx context.Context, id string) error {
 	const q = `UPDATE api_keys SET cooldown_until = NULL, updated_at = NOW() WHERE id = $1`
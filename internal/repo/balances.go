@@ -18,6 +18,7 @@ type UserBalance struct {
 	TotalSpent         int64
 	DepositedPending   int64
 	SpentPending       int64
+	Withdrawn          int64
 	UpdatedAt          *time.Time
 }
 
@@ -49,5 +50,13 @@ LIMIT 1;
 	); err != nil {
 		return nil, fmt.Errorf("get user balance: %w", err)
 	}
+
+	// Withdrawals are not part of user_balances_table yet; deduct them here.
+	withdrawn, err := r.withdrawnTotal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ub.Withdrawn = withdrawn
+	ub.SaldoConfirmed -= withdrawn
 	return &ub, nil
 }
@@ -0,0 +1,222 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const campaignColumns = `id, name, template, segment, scheduled_at, throttle_per_minute, status, started_at, completed_at, created_at, updated_at`
+
+type campaignScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCampaign(row campaignScanner) (*Campaign, error) {
+	var c Campaign
+	var segmentJSON []byte
+	if err := row.Scan(&c.ID, &c.Name, &c.Template, &segmentJSON, &c.ScheduledAt, &c.ThrottlePerMinute, &c.Status, &c.StartedAt, &c.CompletedAt, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(segmentJSON) > 0 {
+		if err := json.Unmarshal(segmentJSON, &c.Segment); err != nil {
+			return nil, fmt.Errorf("decode campaign segment: %w", err)
+		}
+	}
+	return &c, nil
+}
+
+func segmentParam(seg Segment) (string, error) {
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return "", fmt.Errorf("marshal segment: %w", err)
+	}
+	return string(data), nil
+}
+
+// InsertCampaign stores a new scheduled campaign.
+func (r *PostgresRepository) InsertCampaign(ctx context.Context, c Campaign) (*Campaign, error) {
+	segment, err := segmentParam(c.Segment)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+INSERT INTO campaigns (name, template, segment, scheduled_at, throttle_per_minute)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + campaignColumns + `;`
+	saved, err := scanCampaign(r.pool.QueryRow(ctx, q, c.Name, c.Template, segment, c.ScheduledAt, c.ThrottlePerMinute))
+	if err != nil {
+		return nil, fmt.Errorf("insert campaign: %w", err)
+	}
+	return saved, nil
+}
+
+// GetCampaign loads a single campaign.
+func (r *PostgresRepository) GetCampaign(ctx context.Context, id string) (*Campaign, error) {
+	q := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id = $1;`
+	c, err := scanCampaign(r.pool.QueryRow(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("campaign %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get campaign: %w", err)
+	}
+	return c, nil
+}
+
+// ListCampaigns returns the most recently scheduled campaigns.
+func (r *PostgresRepository) ListCampaigns(ctx context.Context, limit int) ([]Campaign, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + campaignColumns + `
+FROM campaigns
+ORDER BY scheduled_at DESC
+LIMIT $1;`
+	rows, err := r.pool.Query(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan campaign: %w", err)
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate campaigns: %w", err)
+	}
+	return res, nil
+}
+
+// ClaimDueCampaigns marks scheduled campaigns due at or before now as running
+// and returns them. A running campaign is claimed again once neither its
+// claim nor its last delivery is newer than lease.
+func (r *PostgresRepository) ClaimDueCampaigns(ctx context.Context, now time.Time, lease time.Duration) ([]Campaign, error) {
+	q := `
+UPDATE campaigns AS c
+SET started_at = CASE WHEN c.status = 'scheduled' THEN NOW() ELSE c.started_at END,
+    status = 'running',
+    updated_at = NOW()
+WHERE (c.status = 'scheduled' AND c.scheduled_at <= $1)
+   OR (c.status = 'running'
+       AND GREATEST(c.updated_at, (SELECT MAX(d.sent_at) FROM campaign_deliveries d WHERE d.campaign_id = c.id)) < $2)
+RETURNING ` + campaignColumns + `;`
+	rows, err := r.pool.Query(ctx, q, now, now.Add(-lease))
+	if err != nil {
+		return nil, fmt.Errorf("claim due campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan campaign: %w", err)
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate due campaigns: %w", err)
+	}
+	return res, nil
+}
+
+// UpdateCampaignStatus moves a campaign to the given status, stamping completed_at when it completes.
+func (r *PostgresRepository) UpdateCampaignStatus(ctx context.Context, id, status string) error {
+	const q = `
+UPDATE campaigns
+SET status = $2,
+    completed_at = CASE WHEN $2 = 'completed' THEN NOW() ELSE completed_at END,
+    updated_at = NOW()
+WHERE id = $1;
+`
+	ct, err := r.pool.Exec(ctx, q, id, status)
+	if err != nil {
+		return fmt.Errorf("update campaign status: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("campaign %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// RecordCampaignDelivery stores the outcome of sending a campaign to one user.
+func (r *PostgresRepository) RecordCampaignDelivery(ctx context.Context, d CampaignDelivery) error {
+	const q = `
+INSERT INTO campaign_deliveries (campaign_id, user_id, status, error)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (campaign_id, user_id) DO NOTHING;
+`
+	if _, err := r.pool.Exec(ctx, q, d.CampaignID, d.UserID, d.Status, d.Error); err != nil {
+		return fmt.Errorf("record campaign delivery: %w", err)
+	}
+	return nil
+}
+
+// AttributeCampaignConversion credits an order to the user's latest campaign message sent since the given time.
+// It reports whether a delivery was attributed.
+func (r *PostgresRepository) AttributeCampaignConversion(ctx context.Context, userID, orderRef string, since time.Time) (bool, error) {
+	const q = `
+UPDATE campaign_deliveries
+SET converted_at = NOW(),
+    order_ref = $2
+WHERE id = (
+    SELECT id FROM campaign_deliveries
+    WHERE user_id = $1
+      AND status = 'sent'
+      AND converted_at IS NULL
+      AND sent_at >= $3
+    ORDER BY sent_at DESC
+    LIMIT 1
+);
+`
+	ct, err := r.pool.Exec(ctx, q, userID, orderRef, since)
+	if err != nil {
+		return false, fmt.Errorf("attribute campaign conversion: %w", err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// LatestCampaignDelivery returns the campaign most recently sent to the user since the given time.
+func (r *PostgresRepository) LatestCampaignDelivery(ctx context.Context, userID string, since time.Time) (string, error) {
+	const q = `
+SELECT campaign_id FROM campaign_deliveries
+WHERE user_id = $1 AND status = 'sent' AND sent_at >= $2
+ORDER BY sent_at DESC
+LIMIT 1;
+`
+	var campaignID string
+	if err := r.pool.QueryRow(ctx, q, userID, since).Scan(&campaignID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("campaign delivery for %s: %w", userID, ErrNotFound)
+		}
+		return "", fmt.Errorf("latest campaign delivery: %w", err)
+	}
+	return campaignID, nil
+}
+
+// GetCampaignStats counts deliveries and conversions of a campaign.
+func (r *PostgresRepository) GetCampaignStats(ctx context.Context, campaignID string) (*CampaignStats, error) {
+	const q = `
+SELECT COUNT(*) FILTER (WHERE status = 'sent'),
+       COUNT(*) FILTER (WHERE status = 'failed'),
+       COUNT(*) FILTER (WHERE converted_at IS NOT NULL)
+FROM campaign_deliveries
+WHERE campaign_id = $1;
+`
+	var st CampaignStats
+	if err := r.pool.QueryRow(ctx, q, campaignID).Scan(&st.Sent, &st.Failed, &st.Converted); err != nil {
+		return nil, fmt.Errorf("get campaign stats: %w", err)
+	}
+	return &st, nil
+}
@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListCatalogAliases returns every configured alias ordered by alias.
+func (r *PostgresRepository) ListCatalogAliases(ctx context.Context) ([]CatalogAlias, error) {
+	const q = `
+SELECT id, alias, replacement, created_at, updated_at
+FROM catalog_aliases
+ORDER BY alias ASC;
+`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CatalogAlias
+	for rows.Next() {
+		var a CatalogAlias
+		if err := rows.Scan(&a.ID, &a.Alias, &a.Replacement, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan catalog alias: %w", err)
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog aliases: %w", err)
+	}
+	return res, nil
+}
+
+// UpsertCatalogAlias creates or updates an alias mapping.
+func (r *PostgresRepository) UpsertCatalogAlias(ctx context.Context, alias, replacement string) (*CatalogAlias, error) {
+	const q = `
+INSERT INTO catalog_aliases (alias, replacement)
+VALUES ($1, $2)
+ON CONFLICT (alias) DO UPDATE
+SET replacement = EXCLUDED.replacement,
+    updated_at = NOW()
+RETURNING id, alias, replacement, created_at, updated_at;
+`
+	var a CatalogAlias
+	if err := r.pool.QueryRow(ctx, q, alias, replacement).Scan(&a.ID, &a.Alias, &a.Replacement, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert catalog alias: %w", err)
+	}
+	return &a, nil
+}
+
+// DeleteCatalogAlias removes an alias mapping.
+func (r *PostgresRepository) DeleteCatalogAlias(ctx context.Context, alias string) error {
+	const q = `DELETE FROM catalog_aliases WHERE alias = $1`
+	ct, err := r.pool.Exec(ctx, q, alias)
+	if err != nil {
+		return fmt.Errorf("delete catalog alias: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("catalog alias %s: %w", alias, ErrNotFound)
+	}
+	return nil
+}
+
+// ListCuratedProducts returns every curation entry.
+func (r *PostgresRepository) ListCuratedProducts(ctx context.Context) ([]CuratedProduct, error) {
+	const q = `
+SELECT id, scope, key, hidden, reason, instructions, created_at, updated_at
+FROM curated_products
+ORDER BY scope ASC, key ASC;
+`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list curated products: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CuratedProduct
+	for rows.Next() {
+		var cp CuratedProduct
+		if err := rows.Scan(&cp.ID, &cp.Scope, &cp.Key, &cp.Hidden, &cp.Reason, &cp.Instructions, &cp.CreatedAt, &cp.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan curated product: %w", err)
+		}
+		res = append(res, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate curated products: %w", err)
+	}
+	return res, nil
+}
+
+// UpsertCuratedProduct creates or updates a curation entry keyed by scope and key.
+func (r *PostgresRepository) UpsertCuratedProduct(ctx context.Context, cp CuratedProduct) (*CuratedProduct, error) {
+	const q = `
+INSERT INTO curated_products (scope, key, hidden, reason, instructions)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (scope, key) DO UPDATE
+SET hidden = EXCLUDED.hidden,
+    reason = EXCLUDED.reason,
+    instructions = EXCLUDED.instructions,
+    updated_at = NOW()
+RETURNING id, scope, key, hidden, reason, instructions, created_at, updated_at;
+`
+	var saved CuratedProduct
+	if err := r.pool.QueryRow(ctx, q, cp.Scope, cp.Key, cp.Hidden, cp.Reason, cp.Instructions).Scan(&saved.ID, &saved.Scope, &saved.Key, &saved.Hidden, &saved.Reason, &saved.Instructions, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert curated product: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteCuratedProduct removes a curation entry.
+func (r *PostgresRepository) DeleteCuratedProduct(ctx context.Context, scope, key string) error {
+	const q = `DELETE FROM curated_products WHERE scope = $1 AND key = $2`
+	ct, err := r.pool.Exec(ctx, q, scope, key)
+	if err != nil {
+		return fmt.Errorf("delete curated product: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("curated product %s/%s: %w", scope, key, ErrNotFound)
+	}
+	return nil
+}
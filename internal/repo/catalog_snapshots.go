@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const catalogSnapshotColumns = `id, product_type, version, checksum, item_count, items, pinned, created_at`
+
+// catalogSnapshotSummaryColumns omits items, which can be large, for listings.
+const catalogSnapshotSummaryColumns = `id, product_type, version, checksum, item_count, '' AS items, pinned, created_at`
+
+type catalogSnapshotScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCatalogSnapshot(row catalogSnapshotScanner) (*CatalogSnapshot, error) {
+	var s CatalogSnapshot
+	var items string
+	if err := row.Scan(&s.ID, &s.ProductType, &s.Version, &s.Checksum, &s.ItemCount, &items, &s.Pinned, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if items != "" {
+		s.Items = []byte(items)
+	}
+	return &s, nil
+}
+
+// InsertCatalogSnapshot stores a price list snapshot as the next version of its product type.
+func (r *PostgresRepository) InsertCatalogSnapshot(ctx context.Context, snap CatalogSnapshot) (*CatalogSnapshot, error) {
+	q := `
+INSERT INTO catalog_snapshots (product_type, version, checksum, item_count, items)
+SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+FROM catalog_snapshots
+WHERE product_type = $1
+RETURNING ` + catalogSnapshotColumns + `;`
+	saved, err := scanCatalogSnapshot(r.pool.QueryRow(ctx, q, snap.ProductType, snap.Checksum, snap.ItemCount, string(snap.Items)))
+	if err != nil {
+		return nil, fmt.Errorf("insert catalog snapshot: %w", err)
+	}
+	return saved, nil
+}
+
+// GetLatestCatalogSnapshot returns the newest snapshot of a product type.
+func (r *PostgresRepository) GetLatestCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = $1 ORDER BY version DESC LIMIT 1;`
+	return r.getCatalogSnapshot(ctx, q, productType)
+}
+
+// GetCatalogSnapshot returns a specific snapshot version.
+func (r *PostgresRepository) GetCatalogSnapshot(ctx context.Context, productType string, version int) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = $1 AND version = $2;`
+	return r.getCatalogSnapshot(ctx, q, productType, version)
+}
+
+// GetPinnedCatalogSnapshot returns the snapshot admins pinned for a product type.
+func (r *PostgresRepository) GetPinnedCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = $1 AND pinned LIMIT 1;`
+	return r.getCatalogSnapshot(ctx, q, productType)
+}
+
+func (r *PostgresRepository) getCatalogSnapshot(ctx context.Context, q string, args ...any) (*CatalogSnapshot, error) {
+	snap, err := scanCatalogSnapshot(r.pool.QueryRow(ctx, q, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("catalog snapshot: %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("get catalog snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// ListCatalogSnapshots returns the newest snapshots of a product type without their items.
+func (r *PostgresRepository) ListCatalogSnapshots(ctx context.Context, productType string, limit int) ([]CatalogSnapshot, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	q := `
+SELECT ` + catalogSnapshotSummaryColumns + `
+FROM catalog_snapshots
+WHERE product_type = $1
+ORDER BY version DESC
+LIMIT $2;`
+	rows, err := r.pool.Query(ctx, q, productType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CatalogSnapshot
+	for rows.Next() {
+		snap, err := scanCatalogSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan catalog snapshot: %w", err)
+		}
+		res = append(res, *snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog snapshots: %w", err)
+	}
+	return res, nil
+}
+
+// PinCatalogSnapshot makes the given version the only pinned snapshot of its product type.
+func (r *PostgresRepository) PinCatalogSnapshot(ctx context.Context, productType string, version int) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		ct, err := tx.Exec(ctx, `UPDATE catalog_snapshots SET pinned = TRUE WHERE product_type = $1 AND version = $2`, productType, version)
+		if err != nil {
+			return fmt.Errorf("pin catalog snapshot: %w", err)
+		}
+		if ct.RowsAffected() == 0 {
+			return fmt.Errorf("catalog snapshot %s v%d: %w", productType, version, ErrNotFound)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE catalog_snapshots SET pinned = FALSE WHERE product_type = $1 AND version <> $2 AND pinned`, productType, version); err != nil {
+			return fmt.Errorf("unpin other catalog snapshots: %w", err)
+		}
+		return nil
+	})
+}
+
+// UnpinCatalog returns a product type to live supplier prices.
+func (r *PostgresRepository) UnpinCatalog(ctx context.Context, productType string) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE catalog_snapshots SET pinned = FALSE WHERE product_type = $1 AND pinned`, productType); err != nil {
+		return fmt.Errorf("unpin catalog: %w", err)
+	}
+	return nil
+}
+
+// RecordOrderCatalogVersion stores the catalog version an order was quoted against.
+func (r *PostgresRepository) RecordOrderCatalogVersion(ctx context.Context, v OrderCatalogVersion) error {
+	const q = `
+INSERT INTO order_catalog_versions (order_ref, product_type, catalog_version, quoted_price)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (order_ref) DO NOTHING;
+`
+	if _, err := r.pool.Exec(ctx, q, v.OrderRef, v.ProductType, v.CatalogVersion, v.QuotedPrice); err != nil {
+		return fmt.Errorf("record order catalog version: %w", err)
+	}
+	return nil
+}
+
+// GetOrderCatalogVersion returns the catalog version an order was quoted against.
+func (r *PostgresRepository) GetOrderCatalogVersion(ctx context.Context, orderRef string) (*OrderCatalogVersion, error) {
+	const q = `
+SELECT order_ref, product_type, catalog_version, quoted_price, created_at
+FROM order_catalog_versions
+WHERE order_ref = $1;
+`
+	var v OrderCatalogVersion
+	if err := r.pool.QueryRow(ctx, q, orderRef).Scan(&v.OrderRef, &v.ProductType, &v.CatalogVersion, &v.QuotedPrice, &v.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("order catalog version %s: %w", orderRef, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get order catalog version: %w", err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const conversationStateColumns = `user_id, state, expires_at, updated_at`
+
+func scanConversationState(row interface{ Scan(dest ...any) error }) (*ConversationState, error) {
+	var s ConversationState
+	if err := row.Scan(&s.UserID, &s.State, &s.ExpiresAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveConversationState replaces the user's stored conversation flow.
+func (r *PostgresRepository) SaveConversationState(ctx context.Context, s ConversationState) error {
+	const q = `
+INSERT INTO conversation_states (user_id, state, expires_at, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET state = EXCLUDED.state,
+    expires_at = EXCLUDED.expires_at,
+    updated_at = NOW()`
+	if _, err := r.pool.Exec(ctx, q, s.UserID, s.State, s.ExpiresAt); err != nil {
+		return fmt.Errorf("save conversation state: %w", err)
+	}
+	return nil
+}
+
+// GetConversationState returns the user's stored flow unless it expired.
+func (r *PostgresRepository) GetConversationState(ctx context.Context, userID string) (*ConversationState, error) {
+	q := `SELECT ` + conversationStateColumns + ` FROM conversation_states WHERE user_id = $1 AND expires_at > NOW()`
+	s, err := scanConversationState(r.pool.QueryRow(ctx, q, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("conversation state %s: %w", userID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get conversation state: %w", err)
+	}
+	return s, nil
+}
+
+// DeleteConversationState forgets the user's flow; a missing one is fine.
+func (r *PostgresRepository) DeleteConversationState(ctx context.Context, userID string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM conversation_states WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete conversation state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const flashSaleColumns = `id, product_code, price, starts_at, ends_at, campaign_id, created_at`
+
+func scanFlashSale(row campaignScanner) (*FlashSale, error) {
+	var f FlashSale
+	if err := row.Scan(&f.ID, &f.ProductCode, &f.Price, &f.StartsAt, &f.EndsAt, &f.CampaignID, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// InsertFlashSale stores a scheduled price override.
+func (r *PostgresRepository) InsertFlashSale(ctx context.Context, f FlashSale) (*FlashSale, error) {
+	q := `
+INSERT INTO flash_sales (product_code, price, starts_at, ends_at, campaign_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + flashSaleColumns + `;`
+	saved, err := scanFlashSale(r.pool.QueryRow(ctx, q, f.ProductCode, f.Price, f.StartsAt, f.EndsAt, f.CampaignID))
+	if err != nil {
+		return nil, fmt.Errorf("insert flash sale: %w", err)
+	}
+	return saved, nil
+}
+
+// ListFlashSales returns running and upcoming flash sales.
+func (r *PostgresRepository) ListFlashSales(ctx context.Context, endingAfter time.Time) ([]FlashSale, error) {
+	q := `
+SELECT ` + flashSaleColumns + `
+FROM flash_sales
+WHERE ends_at > $1
+ORDER BY starts_at, created_at;`
+	rows, err := r.pool.Query(ctx, q, endingAfter)
+	if err != nil {
+		return nil, fmt.Errorf("list flash sales: %w", err)
+	}
+	defer rows.Close()
+
+	var res []FlashSale
+	for rows.Next() {
+		f, err := scanFlashSale(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan flash sale: %w", err)
+		}
+		res = append(res, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate flash sales: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteFlashSale removes a flash sale and returns it.
+func (r *PostgresRepository) DeleteFlashSale(ctx context.Context, id string) (*FlashSale, error) {
+	q := `DELETE FROM flash_sales WHERE id = $1 RETURNING ` + flashSaleColumns + `;`
+	f, err := scanFlashSale(r.pool.QueryRow(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("flash sale %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("delete flash sale: %w", err)
+	}
+	return f, nil
+}
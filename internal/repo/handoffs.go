@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const handoffColumns = `user_id, chat_jid, phone, reason, started_at`
+
+func scanHandoff(row interface{ Scan(dest ...any) error }) (*Handoff, error) {
+	var h Handoff
+	if err := row.Scan(&h.UserID, &h.ChatJID, &h.Phone, &h.Reason, &h.StartedAt); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// StartHandoff hands a user's conversation to a human operator. Asking again
+// refreshes the chat and reason but keeps the original start time.
+func (r *PostgresRepository) StartHandoff(ctx context.Context, h Handoff) (*Handoff, error) {
+	q := `
+INSERT INTO operator_handoffs (user_id, chat_jid, phone, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET chat_jid = EXCLUDED.chat_jid,
+    phone = COALESCE(EXCLUDED.phone, operator_handoffs.phone),
+    reason = COALESCE(EXCLUDED.reason, operator_handoffs.reason)
+RETURNING ` + handoffColumns + `;`
+	saved, err := scanHandoff(r.pool.QueryRow(ctx, q, h.UserID, h.ChatJID, h.Phone, h.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("start handoff: %w", err)
+	}
+	return saved, nil
+}
+
+// GetHandoff returns the user's handoff in progress.
+func (r *PostgresRepository) GetHandoff(ctx context.Context, userID string) (*Handoff, error) {
+	q := `SELECT ` + handoffColumns + ` FROM operator_handoffs WHERE user_id = $1`
+	h, err := scanHandoff(r.pool.QueryRow(ctx, q, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("handoff %s: %w", userID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get handoff: %w", err)
+	}
+	return h, nil
+}
+
+// ListHandoffs returns every handoff in progress, oldest first.
+func (r *PostgresRepository) ListHandoffs(ctx context.Context) ([]Handoff, error) {
+	q := `SELECT ` + handoffColumns + ` FROM operator_handoffs ORDER BY started_at ASC`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Handoff
+	for rows.Next() {
+		h, err := scanHandoff(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan handoff: %w", err)
+		}
+		res = append(res, *h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate handoffs: %w", err)
+	}
+	return res, nil
+}
+
+// EndHandoff gives the user's conversation back to the bot.
+func (r *PostgresRepository) EndHandoff(ctx context.Context, userID string) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM operator_handoffs WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("end handoff: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("handoff %s: %w", userID, ErrNotFound)
+	}
+	return nil
+}
@@ -2,10 +2,18 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"io/fs"
 	"time"
 )
 
+// ErrNotFound is returned when a lookup by key matches no rows.
+var ErrNotFound = errors.New("not found")
+
+// ErrDeadLetterReplaying is returned when another replay of the same dead
+// letter is still running.
+var ErrDeadLetterReplaying = errors.New("dead letter replay in progress")
+
 // Repository defines the interface for data persistence.
 type Repository interface {
 	// Lifecycle
@@ -16,14 +24,45 @@ type Repository interface {
 	// Users
 	UpsertUserByWA(ctx context.Context, profile UserProfile) (*User, error)
 	GetUserByID(ctx context.Context, id string) (*User, error)
+	// GetUserByWAJID returns the user last seen chatting from jid.
+	GetUserByWAJID(ctx context.Context, jid string) (*User, error)
+	// MigrateUserWAID moves the user known as fromWAID to toWAID after
+	// WhatsApp changed the contact's address. A separate user already under
+	// toWAID is merged in: the older row survives, the other one's orders,
+	// deposits, withdrawals and messages move over and the row is dropped.
+	// Returns ErrNotFound when nobody is known as fromWAID.
+	MigrateUserWAID(ctx context.Context, fromWAID, toWAID string) (*User, error)
+	// AnonymizeUser soft-deletes a user: the WhatsApp ID is replaced by its
+	// hash, name, phone, JID and transaction PIN are cleared and the conversation log and
+	// memory are removed. Orders, deposits and withdrawals are kept.
+	AnonymizeUser(ctx context.Context, id string, at time.Time) error
+	// SetUserCurrencyLocale stores how amounts are written for the user; nil
+	// falls back to the language preference.
+	SetUserCurrencyLocale(ctx context.Context, id string, locale *string) error
+	// SetUserLanguage stores the language tag the bot replies to the user in.
+	SetUserLanguage(ctx context.Context, id, language string) error
+	// GetUserPINHash returns the hash of the user's transaction PIN, or ""
+	// when none is set.
+	GetUserPINHash(ctx context.Context, id string) (string, error)
+	// SetUserPINHash stores the hash of the user's transaction PIN.
+	SetUserPINHash(ctx context.Context, id, hash string) error
+	// GetUserPINLock returns the user's wrong-PIN count and lockout.
+	GetUserPINLock(ctx context.Context, id string) (PINLock, error)
+	// SetUserPINLock stores the user's wrong-PIN count and lockout.
+	SetUserPINLock(ctx context.Context, id string, lock PINLock) error
 
 	// Messages
 	InsertMessage(ctx context.Context, msg MessageRecord) error
 	ListRecentMessages(ctx context.Context, userID string, limit int) ([]MessageRecord, error)
+	ListMessages(ctx context.Context, userID string, limit, offset int) ([]MessageRecord, error)
 
 	// API Keys
 	SyncGeminiKeys(ctx context.Context, keys []string) error
 	ListActiveGeminiKeys(ctx context.Context) ([]APIKey, error)
+	ListGeminiKeys(ctx context.Context) ([]APIKey, error)
+	AddGeminiKey(ctx context.Context, value string, priority int) (*APIKey, error)
+	DisableAPIKey(ctx context.Context, id string) error
+	SetAPIKeyQuota(ctx context.Context, id string, requests, tokens int64) error
 	ClearCooldown(ctx context.Context, id string) error
 	SetCooldownUntil(ctx context.Context, id string, until time.Time) error
 	UpdateAPIKeyCooldown(ctx context.Context, id string, until time.Time) error
@@ -33,12 +72,225 @@ type Repository interface {
 
 	// Orders
 	InsertOrder(ctx context.Context, order Order) (*Order, error)
+	// InsertOrders stores orders in one transaction: either every order is
+	// inserted or, on error, none is.
+	InsertOrders(ctx context.Context, orders []Order) ([]Order, error)
 	GetOrderByRef(ctx context.Context, ref string) (*Order, error)
 	UpdateOrderStatus(ctx context.Context, orderRef, status string, metadata map[string]any) error
+	// MarkOrderSucceeded moves an order to success unless it already is and
+	// reports whether it did, so a webhook and a poll settling the same order
+	// at once notify the customer only once.
+	MarkOrderSucceeded(ctx context.Context, orderRef string, metadata map[string]any) (bool, error)
 	ListOrdersAwaitingDeposit(ctx context.Context, depositRef string) ([]Order, error)
+	// ListOrdersByUser pages through a user's orders, newest first.
+	ListOrdersByUser(ctx context.Context, userID string, limit, offset int) ([]Order, error)
+	// ListRecentOrders returns the latest orders of every user, newest first.
+	ListRecentOrders(ctx context.Context, limit int) ([]Order, error)
+	// CountSuccessfulOrders returns how many of a user's orders succeeded.
+	CountSuccessfulOrders(ctx context.Context, userID string) (int, error)
+	// ListStuckOrders returns orders still processing that were created
+	// before createdBefore and have not been escalated yet, oldest first.
+	ListStuckOrders(ctx context.Context, createdBefore time.Time, limit int) ([]Order, error)
+	MarkOrderEscalated(ctx context.Context, orderRef string, at time.Time) error
+	// ListPendingOrders returns orders still pending or processing that were
+	// created between createdAfter and createdBefore, least recently polled first.
+	ListPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Order, error)
+	MarkOrderPolled(ctx context.Context, orderRef string, at time.Time) error
 
 	// Deposits
 	InsertDeposit(ctx context.Context, dep Deposit) (*Deposit, error)
 	GetDepositByRef(ctx context.Context, ref string) (*Deposit, error)
+	// ListRecentDeposits returns the latest deposits of every user, newest first.
+	ListRecentDeposits(ctx context.Context, limit int) ([]Deposit, error)
 	UpdateDepositStatus(ctx context.Context, ref, status string, metadata map[string]any) error
+	// ListUnpaidDeposits returns deposits created between createdAfter and
+	// createdBefore that are still waiting for payment, oldest first.
+	ListUnpaidDeposits(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Deposit, error)
+
+	// Withdrawals
+	InsertWithdrawal(ctx context.Context, wd Withdrawal) (*Withdrawal, error)
+	GetWithdrawalByRef(ctx context.Context, ref string) (*Withdrawal, error)
+	UpdateWithdrawalStatus(ctx context.Context, ref, status string, metadata map[string]any) error
+	SumWithdrawalsSince(ctx context.Context, userID string, since time.Time) (int64, error)
+
+	// Catalog
+	ListCatalogAliases(ctx context.Context) ([]CatalogAlias, error)
+	UpsertCatalogAlias(ctx context.Context, alias, replacement string) (*CatalogAlias, error)
+	DeleteCatalogAlias(ctx context.Context, alias string) error
+	ListCuratedProducts(ctx context.Context) ([]CuratedProduct, error)
+	UpsertCuratedProduct(ctx context.Context, cp CuratedProduct) (*CuratedProduct, error)
+	DeleteCuratedProduct(ctx context.Context, scope, key string) error
+
+	// Catalog snapshots
+	InsertCatalogSnapshot(ctx context.Context, snap CatalogSnapshot) (*CatalogSnapshot, error)
+	GetLatestCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error)
+	GetCatalogSnapshot(ctx context.Context, productType string, version int) (*CatalogSnapshot, error)
+	GetPinnedCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error)
+	ListCatalogSnapshots(ctx context.Context, productType string, limit int) ([]CatalogSnapshot, error)
+	PinCatalogSnapshot(ctx context.Context, productType string, version int) error
+	UnpinCatalog(ctx context.Context, productType string) error
+	RecordOrderCatalogVersion(ctx context.Context, v OrderCatalogVersion) error
+	GetOrderCatalogVersion(ctx context.Context, orderRef string) (*OrderCatalogVersion, error)
+
+	// Product stats
+	RecordProductOutcome(ctx context.Context, productCode, status string, success bool) (*ProductStat, error)
+	ListProductStats(ctx context.Context) ([]ProductStat, error)
+
+	// Webhook dead letters
+	InsertWebhookDeadLetter(ctx context.Context, dl WebhookDeadLetter) (*WebhookDeadLetter, error)
+	ListWebhookDeadLetters(ctx context.Context, status string, limit int) ([]WebhookDeadLetter, error)
+	GetWebhookDeadLetter(ctx context.Context, id string) (*WebhookDeadLetter, error)
+	UpdateWebhookDeadLetter(ctx context.Context, id, status, lastError string) error
+	// ClaimWebhookDeadLetter moves a pending dead letter, or one left replaying
+	// since before staleBefore, to replaying. It returns false when another
+	// replay holds it or it was already replayed.
+	ClaimWebhookDeadLetter(ctx context.Context, id string, staleBefore time.Time) (bool, error)
+	// ClaimWebhookEvent records that the event ref/type/status is being
+	// processed. It returns false when the event was claimed before.
+	ClaimWebhookEvent(ctx context.Context, ref, eventType, status string) (bool, error)
+	// ReleaseWebhookEvent drops a claim whose processing failed, so a retry
+	// or replay is not mistaken for a duplicate.
+	ReleaseWebhookEvent(ctx context.Context, ref, eventType, status string) error
+
+	// Merchant webhooks
+	InsertMerchantWebhook(ctx context.Context, hook MerchantWebhook) (*MerchantWebhook, error)
+	ListMerchantWebhooks(ctx context.Context) ([]MerchantWebhook, error)
+	DeleteMerchantWebhook(ctx context.Context, id string) error
+	InsertMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error
+	// ClaimDueMerchantWebhookDeliveries returns pending deliveries due at or
+	// before now and pushes their next attempt back by lease, so concurrent
+	// workers do not post the same delivery twice.
+	ClaimDueMerchantWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]MerchantWebhookDelivery, error)
+	// UpdateMerchantWebhookDelivery records the outcome of an attempt.
+	UpdateMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error
+	ListMerchantWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]MerchantWebhookDelivery, error)
+
+	// Notification journal
+	InsertNotification(ctx context.Context, n Notification) (*Notification, error)
+	// ClaimDueNotifications returns pending notifications due at or before
+	// now and pushes their next attempt back by lease.
+	ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error)
+	// UpdateNotification records the outcome of a send attempt.
+	UpdateNotification(ctx context.Context, n Notification) error
+	// ListUndeliveredNotifications returns pending and dead notifications, newest first.
+	ListUndeliveredNotifications(ctx context.Context, limit int) ([]Notification, error)
+	// RequeueNotification makes a notification pending and due now with its
+	// attempts reset.
+	RequeueNotification(ctx context.Context, id string) error
+
+	// Pricing rules
+	ListPricingRules(ctx context.Context) ([]PricingRule, error)
+	UpsertPricingRule(ctx context.Context, rule PricingRule) (*PricingRule, error)
+	DeletePricingRule(ctx context.Context, scope, key string) error
+
+	// Product names
+	// ListProductNameRules returns every rule, patterns in the order they
+	// were first added.
+	ListProductNameRules(ctx context.Context) ([]ProductNameRule, error)
+	UpsertProductNameRule(ctx context.Context, rule ProductNameRule) (*ProductNameRule, error)
+	DeleteProductNameRule(ctx context.Context, scope, target string) error
+
+	// Flash sales
+	InsertFlashSale(ctx context.Context, f FlashSale) (*FlashSale, error)
+	// ListFlashSales returns sales that have not ended by the given time,
+	// earliest start first.
+	ListFlashSales(ctx context.Context, endingAfter time.Time) ([]FlashSale, error)
+	DeleteFlashSale(ctx context.Context, id string) (*FlashSale, error)
+
+	// Segments
+	ListUsersInSegment(ctx context.Context, seg Segment) ([]User, error)
+
+	// Campaigns
+	InsertCampaign(ctx context.Context, c Campaign) (*Campaign, error)
+	GetCampaign(ctx context.Context, id string) (*Campaign, error)
+	ListCampaigns(ctx context.Context, limit int) ([]Campaign, error)
+	// ClaimDueCampaigns marks due scheduled campaigns running and returns
+	// them, together with running campaigns that sent nothing for lease,
+	// whose sender presumably died; their recorded deliveries are skipped.
+	ClaimDueCampaigns(ctx context.Context, now time.Time, lease time.Duration) ([]Campaign, error)
+	UpdateCampaignStatus(ctx context.Context, id, status string) error
+	ListCampaignRecipients(ctx context.Context, campaignID string, seg Segment) ([]User, error)
+	RecordCampaignDelivery(ctx context.Context, d CampaignDelivery) error
+	AttributeCampaignConversion(ctx context.Context, userID, orderRef string, since time.Time) (bool, error)
+	GetCampaignStats(ctx context.Context, campaignID string) (*CampaignStats, error)
+	// LatestCampaignDelivery returns the ID of the last campaign sent to the
+	// user since the given time, or ErrNotFound.
+	LatestCampaignDelivery(ctx context.Context, userID string, since time.Time) (string, error)
+
+	// Acquisition
+	// RecordUserAcquisition stores how a user arrived. It only takes effect
+	// before the user's first logged message, so the first touch wins.
+	RecordUserAcquisition(ctx context.Context, userID string, acq Acquisition) (bool, error)
+	GetUserAcquisition(ctx context.Context, userID string) (*Acquisition, error)
+	AcquisitionReport(ctx context.Context, since time.Time) ([]AcquisitionStat, error)
+	// SalesSummary totals orders and deposits created in [from, to).
+	SalesSummary(ctx context.Context, from, to time.Time) (*SalesSummary, error)
+
+	// Sender policy
+	// GetSenderPolicy returns the saved policy, or mode off when none is saved.
+	GetSenderPolicy(ctx context.Context) (*SenderPolicy, error)
+	SaveSenderPolicy(ctx context.Context, p SenderPolicy) (*SenderPolicy, error)
+
+	// User memories
+	GetUserMemory(ctx context.Context, userID string) (*UserMemory, error)
+	UpsertUserMemory(ctx context.Context, mem UserMemory) error
+	ResetUserMemory(ctx context.Context, userID string) error
+	ListMemoryCandidates(ctx context.Context, minNewMessages, limit int) ([]MemoryCandidate, error)
+
+	// Outbound messages
+	InsertOutboundMessage(ctx context.Context, msg OutboundMessage) error
+	MarkOutboundDelivered(ctx context.Context, ids []string, at time.Time) ([]OutboundMessage, error)
+	MarkOutboundRead(ctx context.Context, ids []string, at time.Time) (int64, error)
+	ListUndeliveredOutbound(ctx context.Context, sentBefore time.Time, limit int) ([]OutboundMessage, error)
+	SetOutboundStatus(ctx context.Context, id, status string) error
+
+	// Admin approvals
+	InsertAdminApproval(ctx context.Context, a AdminApproval) (*AdminApproval, error)
+	DecideAdminApproval(ctx context.Context, code, status, decidedBy string, at time.Time) (*AdminApproval, error)
+	ExpireAdminApprovals(ctx context.Context, now time.Time) ([]AdminApproval, error)
+
+	// Operator handoffs
+	// StartHandoff hands a user's conversation to a human operator. A
+	// handoff already in progress keeps its start time.
+	StartHandoff(ctx context.Context, h Handoff) (*Handoff, error)
+	// GetHandoff returns ErrNotFound while the bot handles the user.
+	GetHandoff(ctx context.Context, userID string) (*Handoff, error)
+	ListHandoffs(ctx context.Context) ([]Handoff, error)
+	// EndHandoff gives the conversation back to the bot. Returns
+	// ErrNotFound when no handoff was in progress.
+	EndHandoff(ctx context.Context, userID string) error
+
+	// Conversation states
+	// SaveConversationState replaces the user's stored conversation flow.
+	SaveConversationState(ctx context.Context, s ConversationState) error
+	// GetConversationState returns ErrNotFound when the user has no flow
+	// or it expired.
+	GetConversationState(ctx context.Context, userID string) (*ConversationState, error)
+	DeleteConversationState(ctx context.Context, userID string) error
+
+	// Scheduled orders
+	InsertScheduledOrder(ctx context.Context, s ScheduledOrder) (*ScheduledOrder, error)
+	// ListScheduledOrders returns the user's active schedules, oldest first.
+	ListScheduledOrders(ctx context.Context, userID string) ([]ScheduledOrder, error)
+	// CancelScheduledOrder returns ErrNotFound unless the user has the
+	// schedule active.
+	CancelScheduledOrder(ctx context.Context, userID, id string) error
+	ListDueScheduledOrders(ctx context.Context, now time.Time, limit int) ([]ScheduledOrder, error)
+	// AdvanceScheduledOrder moves a schedule due at from on to next and
+	// clears its reminder, recording orderRef as its last run when set. It
+	// returns ErrNotFound when the schedule is no longer due at from, so
+	// only one runner executes each run.
+	AdvanceScheduledOrder(ctx context.Context, id string, from, next time.Time, orderRef string) error
+	MarkScheduledOrderReminded(ctx context.Context, id string, at time.Time) error
+
+	// Product alerts
+	InsertProductAlert(ctx context.Context, a ProductAlert) (*ProductAlert, error)
+	// ListProductAlerts returns the user's active alerts, oldest first.
+	ListProductAlerts(ctx context.Context, userID string) ([]ProductAlert, error)
+	ListActiveProductAlerts(ctx context.Context) ([]ProductAlert, error)
+	// CancelProductAlert returns ErrNotFound unless the user has the alert active.
+	CancelProductAlert(ctx context.Context, userID, id string) error
+	// MarkProductAlertNotified closes an active alert. It returns ErrNotFound
+	// when the alert is no longer active, so only one runner notifies.
+	MarkProductAlertNotified(ctx context.Context, id string, at time.Time) error
 }
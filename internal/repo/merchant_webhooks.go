@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const merchantWebhookColumns = `id, url, secret, events, created_at, updated_at`
+
+const merchantDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, response_status, error, next_attempt_at, delivered_at, created_at, updated_at`
+
+type merchantWebhookScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMerchantWebhook(row merchantWebhookScanner) (*MerchantWebhook, error) {
+	var hook MerchantWebhook
+	var eventsJSON []byte
+	if err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, &eventsJSON, &hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(eventsJSON) > 0 {
+		if err := json.Unmarshal(eventsJSON, &hook.Events); err != nil {
+			return nil, fmt.Errorf("decode merchant webhook events: %w", err)
+		}
+	}
+	return &hook, nil
+}
+
+func scanMerchantDelivery(row merchantWebhookScanner) (*MerchantWebhookDelivery, error) {
+	var d MerchantWebhookDelivery
+	var payload string
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.Error, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	d.Payload = []byte(payload)
+	return &d, nil
+}
+
+func eventsParam(events []string) (any, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("marshal merchant webhook events: %w", err)
+	}
+	return string(data), nil
+}
+
+type merchantDeliveryRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func collectMerchantDeliveries(rows merchantDeliveryRows) ([]MerchantWebhookDelivery, error) {
+	var res []MerchantWebhookDelivery
+	for rows.Next() {
+		d, err := scanMerchantDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan merchant webhook delivery: %w", err)
+		}
+		res = append(res, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate merchant webhook deliveries: %w", err)
+	}
+	return res, nil
+}
+
+// InsertMerchantWebhook registers a merchant URL for event delivery.
+func (r *PostgresRepository) InsertMerchantWebhook(ctx context.Context, hook MerchantWebhook) (*MerchantWebhook, error) {
+	events, err := eventsParam(hook.Events)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+INSERT INTO merchant_webhooks (url, secret, events)
+VALUES ($1, $2, $3)
+RETURNING ` + merchantWebhookColumns + `;`
+	saved, err := scanMerchantWebhook(r.pool.QueryRow(ctx, q, hook.URL, hook.Secret, events))
+	if err != nil {
+		return nil, fmt.Errorf("insert merchant webhook: %w", err)
+	}
+	return saved, nil
+}
+
+// ListMerchantWebhooks returns every registered merchant webhook, oldest first.
+func (r *PostgresRepository) ListMerchantWebhooks(ctx context.Context) ([]MerchantWebhook, error) {
+	q := `SELECT ` + merchantWebhookColumns + ` FROM merchant_webhooks ORDER BY created_at ASC;`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list merchant webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var res []MerchantWebhook
+	for rows.Next() {
+		hook, err := scanMerchantWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan merchant webhook: %w", err)
+		}
+		res = append(res, *hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate merchant webhooks: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteMerchantWebhook removes a merchant webhook and its delivery log.
+func (r *PostgresRepository) DeleteMerchantWebhook(ctx context.Context, id string) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM merchant_webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete merchant webhook: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("merchant webhook %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// InsertMerchantWebhookDelivery queues an event for a merchant webhook.
+func (r *PostgresRepository) InsertMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error {
+	const q = `
+INSERT INTO merchant_webhook_deliveries (webhook_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4);
+`
+	if _, err := r.pool.Exec(ctx, q, d.WebhookID, d.EventID, d.EventType, string(d.Payload)); err != nil {
+		return fmt.Errorf("insert merchant webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueMerchantWebhookDeliveries returns pending deliveries due at or
+// before now and pushes their next attempt back by lease.
+func (r *PostgresRepository) ClaimDueMerchantWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]MerchantWebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	q := `
+UPDATE merchant_webhook_deliveries
+SET next_attempt_at = $2,
+    updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM merchant_webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= $1
+    ORDER BY next_attempt_at ASC
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + merchantDeliveryColumns + `;`
+	rows, err := r.pool.Query(ctx, q, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim merchant webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return collectMerchantDeliveries(rows)
+}
+
+// UpdateMerchantWebhookDelivery records the outcome of a delivery attempt.
+func (r *PostgresRepository) UpdateMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error {
+	const q = `
+UPDATE merchant_webhook_deliveries
+SET status = $2,
+    attempts = $3,
+    response_status = $4,
+    error = $5,
+    next_attempt_at = $6,
+    delivered_at = $7,
+    updated_at = NOW()
+WHERE id = $1;
+`
+	ct, err := r.pool.Exec(ctx, q, d.ID, d.Status, d.Attempts, d.ResponseStatus, d.Error, d.NextAttemptAt, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("update merchant webhook delivery: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("merchant webhook delivery %s: %w", d.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// ListMerchantWebhookDeliveries returns the newest deliveries of a webhook.
+func (r *PostgresRepository) ListMerchantWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]MerchantWebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + merchantDeliveryColumns + `
+FROM merchant_webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC
+LIMIT $2;`
+	rows, err := r.pool.Query(ctx, q, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list merchant webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return collectMerchantDeliveries(rows)
+}
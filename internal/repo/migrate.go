@@ -2,50 +2,177 @@ package repo
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
+	"path"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// ApplyMigrations executes SQL files against the provided pool in lexicographical order.
-func ApplyMigrations(ctx context.Context, pool *pgxpool.Pool, filesystem fs.FS) error {
-	entries, err := fs.ReadDir(filesystem, ".")
+// AppliedMigration is a row of the schema_migrations table.
+type AppliedMigration struct {
+	Version   string
+	Checksum  string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// migration is one SQL file waiting to be applied.
+type migration struct {
+	version  string
+	checksum string
+	sql      string
+}
+
+// migrationStore records applied migrations in a database. A migration is
+// marked dirty before it runs and clean in the same transaction as its SQL,
+// so a dirty row means a run stopped partway through.
+type migrationStore interface {
+	ensureMigrationsTable(ctx context.Context) error
+	appliedMigrations(ctx context.Context) ([]AppliedMigration, error)
+	markMigrationDirty(ctx context.Context, m migration) error
+	applyMigration(ctx context.Context, m migration) error
+	// clearMigration drops the dirty row of a migration whose transaction
+	// rolled back, leaving the schema as it was.
+	clearMigration(ctx context.Context, version string) error
+}
+
+// runMigrations applies the .sql files of dir that are not recorded yet, in
+// lexicographical order. It refuses to run when an applied file was edited
+// or a previous run left a migration dirty.
+func runMigrations(ctx context.Context, store migrationStore, filesystem fs.FS, dir string) error {
+	pending, err := readMigrations(filesystem, dir)
+	if err != nil {
+		return err
+	}
+	if err := store.ensureMigrationsTable(ctx); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	applied, err := store.appliedMigrations(ctx)
 	if err != nil {
-		return fmt.Errorf("read migrations: %w", err)
+		return fmt.Errorf("list applied migrations: %w", err)
 	}
+	done := make(map[string]AppliedMigration, len(applied))
+	for _, a := range applied {
+		if a.Dirty {
+			return fmt.Errorf("migration %s is dirty: a previous run stopped partway; repair the schema, then delete its schema_migrations row", a.Version)
+		}
+		done[a.Version] = a
+	}
+
+	for _, m := range pending {
+		if a, ok := done[m.version]; ok {
+			if a.Checksum != m.checksum {
+				return fmt.Errorf("migration %s changed after it was applied (checksum %s, file %s); add a new migration instead", m.version, a.Checksum, m.checksum)
+			}
+			continue
+		}
+		if err := store.markMigrationDirty(ctx, m); err != nil {
+			return fmt.Errorf("record migration %s: %w", m.version, err)
+		}
+		if err := store.applyMigration(ctx, m); err != nil {
+			if clearErr := store.clearMigration(context.WithoutCancel(ctx), m.version); clearErr != nil {
+				err = errors.Join(err, fmt.Errorf("clear dirty migration: %w", clearErr))
+			}
+			return fmt.Errorf("execute migration %s: %w", m.version, err)
+		}
+	}
+	return nil
+}
 
+func readMigrations(filesystem fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(filesystem, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name() < entries[j].Name()
 	})
 
+	var out []migration
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
 		}
-
-		sqlBytes, err := fs.ReadFile(filesystem, entry.Name())
+		sqlBytes, err := fs.ReadFile(filesystem, path.Join(dir, entry.Name()))
 		if err != nil {
-			return fmt.Errorf("read migration %s: %w", entry.Name(), err)
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
 		}
-
 		if len(sqlBytes) == 0 {
 			continue
 		}
+		sum := sha256.Sum256(sqlBytes)
+		out = append(out, migration{
+			version:  entry.Name(),
+			checksum: hex.EncodeToString(sum[:]),
+			sql:      string(sqlBytes),
+		})
+	}
+	return out, nil
+}
+
+// ApplyMigrations applies the SQL files at the root of filesystem that the
+// database has not recorded in schema_migrations yet.
+func ApplyMigrations(ctx context.Context, pool *pgxpool.Pool, filesystem fs.FS) error {
+	return runMigrations(ctx, pgMigrationStore{pool: pool}, filesystem, ".")
+}
+
+type pgMigrationStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s pgMigrationStore) ensureMigrationsTable(ctx context.Context) error {
+	const q = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY,
+    checksum TEXT NOT NULL,
+    dirty BOOLEAN NOT NULL DEFAULT TRUE,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);`
+	_, err := s.pool.Exec(ctx, q)
+	return err
+}
 
-		if err := executeSQL(ctx, pool, string(sqlBytes)); err != nil {
-			return fmt.Errorf("execute migration %s: %w", entry.Name(), err)
+func (s pgMigrationStore) appliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := s.pool.Query(ctx, `SELECT version, checksum, dirty, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Checksum, &a.Dirty, &a.AppliedAt); err != nil {
+			return nil, err
 		}
+		out = append(out, a)
 	}
+	return out, rows.Err()
+}
 
-	return nil
+func (s pgMigrationStore) markMigrationDirty(ctx context.Context, m migration) error {
+	_, err := s.pool.Exec(ctx, `INSERT INTO schema_migrations (version, checksum, dirty) VALUES ($1, $2, TRUE)`, m.version, m.checksum)
+	return err
 }
 
-func executeSQL(ctx context.Context, pool *pgxpool.Pool, sql string) error {
-	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, sql)
+func (s pgMigrationStore) applyMigration(ctx context.Context, m migration) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, m.sql); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE schema_migrations SET dirty = FALSE, applied_at = NOW() WHERE version = $1`, m.version)
 		return err
 	})
 }
+
+func (s pgMigrationStore) clearMigration(ctx context.Context, version string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1 AND dirty`, version)
+	return err
+}
@@ -1,6 +1,9 @@
 package repo
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // User represents the users table row.
 type User struct {
@@ -11,8 +14,39 @@ type User struct {
 	PhoneNumber        *string
 	LanguagePreference string
 	Timezone           string
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	// CurrencyLocale overrides LanguagePreference for number formatting.
+	CurrencyLocale *string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// PINLock counts wrong transaction PINs across transfers, so starting a new
+// transfer does not earn more guesses.
+type PINLock struct {
+	FailedAttempts int
+	// LockedUntil is set while transfers are refused after too many wrong PINs.
+	LockedUntil *time.Time
+}
+
+// NumberLocale is the language tag amounts are formatted for.
+func (u *User) NumberLocale() string {
+	if u.CurrencyLocale != nil && *u.CurrencyLocale != "" {
+		return *u.CurrencyLocale
+	}
+	return u.LanguagePreference
+}
+
+// Language is the reply language picked from LanguagePreference: "en" for
+// English, otherwise "id".
+func (u *User) Language() string {
+	tag := strings.ToLower(strings.TrimSpace(u.LanguagePreference))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "en" {
+		return "en"
+	}
+	return "id"
 }
 
 // UserProfile carries data used to upsert a user.
@@ -27,6 +61,7 @@ type UserProfile struct {
 
 // MessageRecord is used to persist conversation logs.
 type MessageRecord struct {
+	ID         string
 	UserID     string
 	Direction  string
 	Type       string
@@ -36,6 +71,15 @@ type MessageRecord struct {
 	CreatedAt  time.Time
 }
 
+// API key sources and disable reasons.
+const (
+	APIKeySourceConfig = "config"
+	APIKeySourceAdmin  = "admin"
+
+	APIKeyRemovedFromConfig = "removed_from_config"
+	APIKeyDisabledByAdmin   = "admin"
+)
+
 // APIKey represents a record in api_keys table.
 type APIKey struct {
 	ID            string
@@ -43,8 +87,16 @@ type APIKey struct {
 	Value         string
 	Priority      int
 	CooldownUntil *time.Time
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// Source is APIKeySourceConfig for keys synced from GEMINI_KEYS and
+	// APIKeySourceAdmin for keys added at runtime.
+	Source         string
+	DisabledAt     *time.Time
+	DisabledReason *string
+	// Daily quotas rotate a key out once reached; zero means unlimited.
+	DailyRequestQuota int64
+	DailyTokenQuota   int64
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 }
 
 // Order represents a row in orders table.
@@ -73,3 +125,522 @@ type Deposit struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// Withdrawal represents a row in withdrawals table.
+type Withdrawal struct {
+	ID            string
+	UserID        string
+	WithdrawalRef string
+	BankCode      string
+	AccountNo     string
+	AccountName   *string
+	Amount        int64
+	Fee           int64
+	Status        string
+	Metadata      map[string]any
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// CatalogAlias maps a customer phrase to the wording used in the supplier catalog.
+type CatalogAlias struct {
+	ID          string
+	Alias       string
+	Replacement string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Curation scopes for CuratedProduct.Scope.
+const (
+	CurationScopeProduct  = "product"
+	CurationScopeCategory = "category"
+)
+
+// CuratedProduct holds operator overrides for a product code or a whole
+// category. Instructions tell buyers how to use the product, e.g. how to
+// redeem a voucher, and are appended to success messages.
+type CuratedProduct struct {
+	ID           string
+	Scope        string
+	Key          string
+	Hidden       bool
+	Reason       *string
+	Instructions *string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Pricing rule scopes for PricingRule.Scope. A product rule beats its
+// category's, which beats the default.
+const (
+	PricingScopeDefault  = "default"
+	PricingScopeCategory = "category"
+	PricingScopeProduct  = "product"
+)
+
+// PricingRule marks up the supplier price: cost plus MarginFixed rupiah plus
+// MarginPercent percent, rounded up to a multiple of RoundTo rupiah.
+type PricingRule struct {
+	ID            string
+	Scope         string
+	Key           string
+	MarginFixed   int64
+	MarginPercent float64
+	RoundTo       int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Product name rule scopes for ProductNameRule.Scope. A product rule names
+// one product outright; pattern rules rewrite every name they match.
+const (
+	ProductNameScopeProduct = "product"
+	ProductNameScopePattern = "pattern"
+)
+
+// ProductNameRule renames supplier products for display. Target is a
+// product code or, for pattern rules, a regular expression whose matches are
+// replaced with Name ($1 expands to the first group).
+type ProductNameRule struct {
+	ID        string
+	Scope     string
+	Target    string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// FlashSale overrides a product's price between StartsAt and EndsAt.
+// CampaignID is the announcement scheduled for the sale, if any.
+type FlashSale struct {
+	ID          string
+	ProductCode string
+	Price       int64
+	StartsAt    time.Time
+	EndsAt      time.Time
+	CampaignID  *string
+	CreatedAt   time.Time
+}
+
+// Active reports whether the sale price applies at t.
+func (f FlashSale) Active(t time.Time) bool {
+	return !t.Before(f.StartsAt) && t.Before(f.EndsAt)
+}
+
+// ProductStat aggregates supplier outcomes for a single product code.
+type ProductStat struct {
+	ProductCode   string
+	SuccessCount  int64
+	FailureCount  int64
+	LastStatus    *string
+	LastOutcomeAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Total returns the number of recorded outcomes.
+func (s ProductStat) Total() int64 {
+	return s.SuccessCount + s.FailureCount
+}
+
+// SuccessRate returns the share of successful outcomes, or 1 when nothing is recorded yet.
+func (s ProductStat) SuccessRate() float64 {
+	total := s.Total()
+	if total == 0 {
+		return 1
+	}
+	return float64(s.SuccessCount) / float64(total)
+}
+
+// Dead-letter statuses for WebhookDeadLetter.Status.
+const (
+	DeadLetterPending   = "pending"
+	DeadLetterReplaying = "replaying"
+	DeadLetterReplayed  = "replayed"
+)
+
+// WebhookDeadLetter stores a webhook event that could not be processed.
+type WebhookDeadLetter struct {
+	ID         string
+	Source     string
+	EventType  string
+	Headers    map[string]string
+	Payload    []byte
+	Error      string
+	Attempts   int
+	Status     string
+	ReplayedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// MerchantWebhook is a merchant URL that receives signed event POSTs.
+type MerchantWebhook struct {
+	ID     string
+	URL    string
+	Secret string
+	// Events lists the event types sent to URL; empty means all of them.
+	Events    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Merchant webhook delivery statuses for MerchantWebhookDelivery.Status.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// MerchantWebhookDelivery is one event queued for one merchant webhook.
+// Payload is the exact body that is signed and posted.
+type MerchantWebhookDelivery struct {
+	ID             string
+	WebhookID      string
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseStatus *int
+	Error          *string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Notification journal statuses for Notification.Status.
+const (
+	NotificationPending   = "pending"
+	NotificationDelivered = "delivered"
+	NotificationDead      = "dead"
+)
+
+// Notification is a customer notification journaled before it is sent, so
+// a failed send is retried instead of lost. Kind names what it reports,
+// such as a paid deposit, and RefID the order or deposit.
+type Notification struct {
+	ID            string
+	UserID        string
+	ChatJID       string
+	Kind          string
+	RefID         string
+	Body          string
+	Status        string
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Campaign statuses for Campaign.Status.
+const (
+	CampaignScheduled = "scheduled"
+	CampaignRunning   = "running"
+	CampaignCompleted = "completed"
+	CampaignCancelled = "cancelled"
+)
+
+// Segment selects a group of users for campaigns, broadcasts and targeted
+// offers. Filters are combined with AND; the zero value matches every user.
+type Segment struct {
+	// ActiveWithinDays keeps users who messaged the bot in the last N days.
+	ActiveWithinDays int `json:"active_within_days,omitempty"`
+	// InactiveDays keeps users with no messages or orders in the last N days.
+	InactiveDays int `json:"inactive_days,omitempty"`
+	// MinSpent keeps users whose successful orders total more than this amount.
+	MinSpent int64 `json:"min_spent,omitempty"`
+	// BoughtCategory keeps users with a successful order in this catalog category.
+	BoughtCategory string `json:"bought_category,omitempty"`
+	// ProductCodes are the codes of BoughtCategory, resolved from the catalog
+	// before querying since orders only store product codes.
+	ProductCodes []string `json:"-"`
+}
+
+// Campaign is a promotional message scheduled for a user segment.
+type Campaign struct {
+	ID                string
+	Name              string
+	Template          string
+	Segment           Segment
+	ScheduledAt       time.Time
+	ThrottlePerMinute int
+	Status            string
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// Campaign delivery statuses for CampaignDelivery.Status.
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// CampaignDelivery records a campaign message sent to one user.
+type CampaignDelivery struct {
+	CampaignID string
+	UserID     string
+	Status     string
+	Error      *string
+}
+
+// CampaignStats summarises deliveries and conversions of a campaign.
+type CampaignStats struct {
+	Sent      int64
+	Failed    int64
+	Converted int64
+}
+
+// Acquisition sources. Users carry organic, referral or channel; orders
+// placed soon after a campaign message are tagged with the campaign instead.
+const (
+	AcquisitionOrganic  = "organic"
+	AcquisitionReferral = "referral"
+	AcquisitionChannel  = "channel"
+	AcquisitionCampaign = "campaign"
+	AcquisitionUnknown  = "unknown"
+)
+
+// Acquisition is how a user arrived; Detail is the referral code, channel
+// name or campaign ID.
+type Acquisition struct {
+	Source string
+	Detail string
+}
+
+// AcquisitionStat is one row of the per-source conversion report. Users and
+// Buyers count users who arrived in the period; Orders and Revenue count
+// successful orders tagged with the source in the period.
+type AcquisitionStat struct {
+	Source  string
+	Detail  string
+	Users   int64
+	Buyers  int64
+	Orders  int64
+	Revenue int64
+}
+
+// SalesSummary totals the orders and deposits created in a period. Cost
+// and CostedRevenue only cover successful orders whose supplier price is
+// known (CostedOrders of them), so Profit is exact for those alone.
+type SalesSummary struct {
+	Orders        int64
+	OrdersSuccess int64
+	OrdersFailed  int64
+	Revenue       int64
+	CostedOrders  int64
+	CostedRevenue int64
+	Cost          int64
+	Deposits      int64
+	DepositsPaid  int64
+	DepositAmount int64
+}
+
+// Profit is the margin earned on the costed orders.
+func (s SalesSummary) Profit() int64 {
+	return s.CostedRevenue - s.Cost
+}
+
+// CatalogSnapshot is one version of a supplier price list for a product type.
+type CatalogSnapshot struct {
+	ID          string
+	ProductType string
+	Version     int
+	Checksum    string
+	ItemCount   int
+	// Items holds the JSON-encoded price list; list queries leave it empty.
+	Items     []byte
+	Pinned    bool
+	CreatedAt time.Time
+}
+
+// OrderCatalogVersion records the catalog snapshot an order was priced from.
+type OrderCatalogVersion struct {
+	OrderRef       string
+	ProductType    string
+	CatalogVersion int
+	QuotedPrice    int64
+	CreatedAt      time.Time
+}
+
+// UserMemory is the compact profile summarised from a user's conversation history.
+type UserMemory struct {
+	UserID  string
+	Summary string
+	// MessageCount is how many messages the user had when Summary was written.
+	MessageCount int64
+	UpdatedAt    time.Time
+}
+
+// MemoryCandidate is a user with enough new messages to refresh their memory.
+type MemoryCandidate struct {
+	UserID          string
+	PreviousSummary string
+	MessageCount    int64
+}
+
+// Outbound message statuses, in the order receipts advance them.
+const (
+	OutboundSent        = "sent"
+	OutboundDelivered   = "delivered"
+	OutboundRead        = "read"
+	OutboundResent      = "resent"
+	OutboundUndelivered = "undelivered"
+)
+
+// OutboundMessage is a WhatsApp text message sent to a user and its receipt state.
+type OutboundMessage struct {
+	ID      string
+	ChatJID string
+	Body    string
+	Status  string
+	// Attempts counts how many times this text was resent before this copy.
+	Attempts    int
+	SentAt      time.Time
+	DeliveredAt *time.Time
+	ReadAt      *time.Time
+}
+
+// Admin approval statuses.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalExpired  = "expired"
+)
+
+// ApprovalWithdrawal is the approval kind for withdrawals above the approval threshold.
+const ApprovalWithdrawal = "withdrawal"
+
+// AdminApproval is a risky action waiting for, or decided by, an admin. Code
+// is the short token admins reply with.
+type AdminApproval struct {
+	ID         string
+	Code       string
+	Kind       string
+	UserID     string
+	UserJID    string
+	SubjectRef string
+	Summary    string
+	Status     string
+	DecidedBy  *string
+	DecidedAt  *time.Time
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+// Sender policy modes.
+const (
+	SenderPolicyOff       = "off"
+	SenderPolicyAllowlist = "allowlist"
+	SenderPolicyDenylist  = "denylist"
+)
+
+// SenderPolicy controls who the bot serves. In allowlist mode only senders
+// whose number or country code is listed are served; in denylist mode those
+// senders are ignored. ContactsOnly and BlockBusiness apply in every mode.
+type SenderPolicy struct {
+	Mode string
+	// Numbers are phone numbers in international form without "+".
+	Numbers []string
+	// CountryCodes are calling codes such as "62".
+	CountryCodes  []string
+	ContactsOnly  bool
+	BlockBusiness bool
+	UpdatedAt     time.Time
+}
+
+// Handoff marks a conversation as handled by a human operator. The bot does
+// not reply to the user while it lasts. ChatJID and Phone identify the user
+// to the admins resuming the bot.
+type Handoff struct {
+	UserID    string
+	ChatJID   string
+	Phone     *string
+	Reason    *string
+	StartedAt time.Time
+}
+
+// ConversationState is a user's multi-step conversation flow as stored when
+// Redis is unavailable. State is the flow encoded as JSON by the caller; the
+// row is dropped once ExpiresAt passes.
+type ConversationState struct {
+	UserID    string
+	State     []byte
+	ExpiresAt time.Time
+	UpdatedAt time.Time
+}
+
+// Scheduled order statuses.
+const (
+	ScheduleActive    = "active"
+	ScheduleCancelled = "cancelled"
+)
+
+// Scheduled order frequencies. Day is the weekday (0 is Sunday) for weekly
+// schedules and the day of the month for monthly ones.
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// ScheduledOrder is a purchase repeated on a schedule and paid from the
+// user's balance. NextRunAt is when it is due next; RemindedAt is set once
+// the user was told their balance is short for that run.
+type ScheduledOrder struct {
+	ID           string
+	UserID       string
+	ChatJID      string
+	ProductCode  string
+	ProductName  string
+	ProductType  string
+	CustomerID   string
+	CustomerZone string
+	Frequency    string
+	Day          int
+	Status       string
+	NextRunAt    time.Time
+	LastRunAt    *time.Time
+	LastOrderRef *string
+	RemindedAt   *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Product alert kinds.
+const (
+	AlertAvailable = "available"
+	AlertPriceDrop = "price_drop"
+)
+
+// Product alert statuses.
+const (
+	AlertActive    = "active"
+	AlertNotified  = "notified"
+	AlertCancelled = "cancelled"
+)
+
+// ProductAlert is a user waiting to hear that a product is available again
+// or cheaper than BaselinePrice, the price when they subscribed. A non-zero
+// TargetPrice only fires a price drop at or below it. Alerts fire once.
+type ProductAlert struct {
+	ID            string
+	UserID        string
+	ChatJID       string
+	ProductCode   string
+	ProductName   string
+	ProductType   string
+	Kind          string
+	BaselinePrice int64
+	TargetPrice   int64
+	Status        string
+	CreatedAt     time.Time
+	NotifiedAt    *time.Time
+}
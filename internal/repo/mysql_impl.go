@@ -0,0 +1,2911 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// -- Users --
+
+func (r *MySQLRepository) UpsertUserByWA(ctx context.Context, profile UserProfile) (*User, error) {
+	const q = `
+INSERT INTO users (id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, updated_at)
+VALUES (?, ?, ?, ?, ?, COALESCE(?, 'id-ID'), COALESCE(?, 'Asia/Jakarta'), CURRENT_TIMESTAMP(6))
+ON DUPLICATE KEY UPDATE
+    wa_jid = VALUES(wa_jid),
+    display_name = COALESCE(VALUES(display_name), display_name),
+    phone_number = COALESCE(VALUES(phone_number), phone_number),
+    language_preference = COALESCE(VALUES(language_preference), language_preference),
+    timezone = COALESCE(VALUES(timezone), timezone),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q,
+		randomUUID(),
+		profile.WAID,
+		profile.WAJID,
+		profile.DisplayName,
+		profile.PhoneNumber,
+		profile.LanguagePreference,
+		profile.Timezone,
+	); err != nil {
+		return nil, fmt.Errorf("upsert user: %w", err)
+	}
+
+	var u User
+	if err := r.db.QueryRowContext(ctx, `SELECT `+mysqlUserColumns+` FROM users WHERE wa_id = ?`, profile.WAID).Scan(&u.ID, &u.WAID, &u.WAJID, &u.DisplayName, &u.PhoneNumber, &u.LanguagePreference, &u.Timezone, &u.CurrencyLocale, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert user: %w", err)
+	}
+	return &u, nil
+}
+
+func (r *MySQLRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	const q = `
+SELECT id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at
+FROM users
+WHERE id = ? AND deleted_at IS NULL
+LIMIT 1;
+`
+	row := r.db.QueryRowContext(ctx, q, id)
+	var user User
+	if err := row.Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *MySQLRepository) GetUserByWAJID(ctx context.Context, jid string) (*User, error) {
+	const q = `
+SELECT id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at
+FROM users
+WHERE wa_jid = ? AND deleted_at IS NULL
+ORDER BY updated_at DESC
+LIMIT 1;
+`
+	row := r.db.QueryRowContext(ctx, q, jid)
+	var user User
+	if err := row.Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user with jid %s: %w", jid, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user by jid: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *MySQLRepository) SetUserCurrencyLocale(ctx context.Context, id string, locale *string) error {
+	const q = `UPDATE users SET currency_locale = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ? AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, locale, id)
+	if err != nil {
+		return fmt.Errorf("set user currency locale: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) SetUserLanguage(ctx context.Context, id, language string) error {
+	const q = `UPDATE users SET language_preference = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ? AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, language, id)
+	if err != nil {
+		return fmt.Errorf("set user language: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) GetUserPINHash(ctx context.Context, id string) (string, error) {
+	var hash sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT transaction_pin_hash FROM users WHERE id = ? AND deleted_at IS NULL`, id).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get user pin: %w", err)
+	}
+	return hash.String, nil
+}
+
+func (r *MySQLRepository) SetUserPINHash(ctx context.Context, id, hash string) error {
+	const q = `UPDATE users SET transaction_pin_hash = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ? AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, hash, id)
+	if err != nil {
+		return fmt.Errorf("set user pin: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) GetUserPINLock(ctx context.Context, id string) (PINLock, error) {
+	var (
+		lock        PINLock
+		lockedUntil sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT pin_failed_attempts, pin_locked_until FROM users WHERE id = ? AND deleted_at IS NULL`, id).Scan(&lock.FailedAttempts, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PINLock{}, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return PINLock{}, fmt.Errorf("get user pin lock: %w", err)
+	}
+	if lockedUntil.Valid {
+		lock.LockedUntil = &lockedUntil.Time
+	}
+	return lock, nil
+}
+
+func (r *MySQLRepository) SetUserPINLock(ctx context.Context, id string, lock PINLock) error {
+	const q = `UPDATE users SET pin_failed_attempts = ?, pin_locked_until = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ? AND deleted_at IS NULL`
+	var lockedUntil any
+	if lock.LockedUntil != nil {
+		lockedUntil = lock.LockedUntil.UTC()
+	}
+	res, err := r.db.ExecContext(ctx, q, lock.FailedAttempts, lockedUntil, id)
+	if err != nil {
+		return fmt.Errorf("set user pin lock: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) AnonymizeUser(ctx context.Context, id string, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin anonymize user: %w", err)
+	}
+	defer tx.Rollback()
+
+	var waID string
+	var waJID *string
+	err = tx.QueryRowContext(ctx, `SELECT wa_id, wa_jid FROM users WHERE id = ? AND deleted_at IS NULL`, id).Scan(&waID, &waJID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("lookup user for anonymization: %w", err)
+	}
+	const q = `
+UPDATE users
+SET wa_id = ?, wa_jid = NULL, display_name = NULL, phone_number = NULL, transaction_pin_hash = NULL, pin_failed_attempts = 0, pin_locked_until = NULL, deleted_at = ?, updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	if _, err := tx.ExecContext(ctx, q, anonymizedWAID(), at.UTC(), id); err != nil {
+		return fmt.Errorf("anonymize user: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user messages: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_memories WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user memory: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM operator_handoffs WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user handoff: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_states WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user conversation state: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scheduled_orders WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user scheduled orders: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_alerts WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user product alerts: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_journal WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("delete user notifications: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM outbound_messages WHERE chat_jid = ? OR chat_jid LIKE ?`, waJID, waID+"@%"); err != nil {
+		return fmt.Errorf("delete user outbound messages: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE admin_approvals SET user_jid = '', summary = '' WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("scrub user approvals: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE withdrawals SET account_no = '', account_name = NULL, metadata = NULL WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("scrub user withdrawals: %w", err)
+	}
+	marks, paths := jsonRemovePaths(orderPersonalKeys)
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET metadata = JSON_REMOVE(metadata, `+marks+`) WHERE user_id = ? AND metadata IS NOT NULL`, append(paths, id)...); err != nil {
+		return fmt.Errorf("scrub user orders: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit anonymize user: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MigrateUserWAID(ctx context.Context, fromWAID, toWAID string) (*User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin migrate user: %w", err)
+	}
+	defer tx.Rollback()
+
+	const lookup = `SELECT id, created_at FROM users WHERE wa_id = ? AND deleted_at IS NULL`
+	var fromID string
+	var fromCreated time.Time
+	err = tx.QueryRowContext(ctx, lookup, fromWAID).Scan(&fromID, &fromCreated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %s: %w", fromWAID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup user for migration: %w", err)
+	}
+
+	keep := fromID
+	var toID string
+	var toCreated time.Time
+	err = tx.QueryRowContext(ctx, lookup, toWAID).Scan(&toID, &toCreated)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("lookup duplicate user: %w", err)
+	default:
+		drop := toID
+		if toCreated.Before(fromCreated) {
+			keep, drop = toID, fromID
+		}
+		if err := mergeUserMySQL(ctx, tx, keep, drop); err != nil {
+			return nil, err
+		}
+	}
+
+	const q = `UPDATE users SET wa_id = ?, wa_jid = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, q, toWAID, toWAID, keep); err != nil {
+		return nil, fmt.Errorf("migrate user wa id: %w", err)
+	}
+	// Journaled notifications, the merged user's included, go to the new JID.
+	if _, err := tx.ExecContext(ctx, `UPDATE notification_journal SET chat_jid = ? WHERE user_id = ?`, toWAID, keep); err != nil {
+		return nil, fmt.Errorf("move notifications to new jid: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE operator_handoffs SET chat_jid = ? WHERE user_id = ?`, toWAID, keep); err != nil {
+		return nil, fmt.Errorf("move handoff to new jid: %w", err)
+	}
+	var user User
+	if err := tx.QueryRowContext(ctx, `SELECT `+mysqlUserColumns+` FROM users WHERE id = ?`, keep).Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("migrate user wa id: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit migrate user: %w", err)
+	}
+	return &user, nil
+}
+
+func mergeUserMySQL(ctx context.Context, tx *sql.Tx, keep, drop string) error {
+	for _, table := range userOwnedTables {
+		if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET user_id = ? WHERE user_id = ?`, keep, drop); err != nil {
+			return fmt.Errorf("move %s to merged user: %w", table, err)
+		}
+	}
+	// MySQL cannot update a table it reads in a subquery, so rows the kept
+	// user already has are excluded with a self-join instead.
+	const deliveries = `
+UPDATE campaign_deliveries d
+LEFT JOIN campaign_deliveries k ON k.campaign_id = d.campaign_id AND k.user_id = ?
+SET d.user_id = ?
+WHERE d.user_id = ? AND k.id IS NULL;
+`
+	if _, err := tx.ExecContext(ctx, deliveries, keep, keep, drop); err != nil {
+		return fmt.Errorf("move campaign deliveries to merged user: %w", err)
+	}
+	const memory = `
+UPDATE user_memories m
+LEFT JOIN user_memories k ON k.user_id = ?
+SET m.user_id = ?
+WHERE m.user_id = ? AND k.user_id IS NULL;
+`
+	if _, err := tx.ExecContext(ctx, memory, keep, keep, drop); err != nil {
+		return fmt.Errorf("move user memory to merged user: %w", err)
+	}
+	// A handoff on either user keeps the bot quiet for the merged one.
+	const handoff = `
+UPDATE operator_handoffs h
+LEFT JOIN operator_handoffs k ON k.user_id = ?
+SET h.user_id = ?
+WHERE h.user_id = ? AND k.user_id IS NULL;
+`
+	if _, err := tx.ExecContext(ctx, handoff, keep, keep, drop); err != nil {
+		return fmt.Errorf("move handoff to merged user: %w", err)
+	}
+	const profile = `
+UPDATE users u
+JOIN users d ON d.id = ?
+SET u.display_name = COALESCE(u.display_name, d.display_name),
+    u.phone_number = COALESCE(u.phone_number, d.phone_number),
+    u.currency_locale = COALESCE(u.currency_locale, d.currency_locale),
+    u.acquisition_source = COALESCE(u.acquisition_source, d.acquisition_source),
+    u.acquisition_detail = COALESCE(u.acquisition_detail, d.acquisition_detail),
+    u.transaction_pin_hash = COALESCE(u.transaction_pin_hash, d.transaction_pin_hash),
+    u.pin_failed_attempts = GREATEST(u.pin_failed_attempts, d.pin_failed_attempts),
+    u.pin_locked_until = COALESCE(GREATEST(u.pin_locked_until, d.pin_locked_until), u.pin_locked_until, d.pin_locked_until)
+WHERE u.id = ?;
+`
+	if _, err := tx.ExecContext(ctx, profile, drop, keep); err != nil {
+		return fmt.Errorf("merge user profile: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, drop); err != nil {
+		return fmt.Errorf("delete merged user: %w", err)
+	}
+	return nil
+}
+
+// -- Messages --
+
+func (r *MySQLRepository) InsertMessage(ctx context.Context, msg MessageRecord) error {
+	id := randomUUID()
+	const q = `
+INSERT INTO messages (id, user_id, direction, message_type, content, media_url, raw_payload)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+`
+	_, err := r.db.ExecContext(ctx, q,
+		id,
+		msg.UserID,
+		msg.Direction,
+		msg.Type,
+		msg.Content,
+		msg.MediaURL,
+		msg.RawPayload,
+	)
+	if err != nil {
+		return fmt.Errorf("insert message: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListRecentMessages(ctx context.Context, userID string, limit int) ([]MessageRecord, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	const q = `
+SELECT direction, message_type, content, created_at
+FROM messages
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent messages: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MessageRecord
+	for rows.Next() {
+		var msg MessageRecord
+		if err := rows.Scan(&msg.Direction, &msg.Type, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan recent message: %w", err)
+		}
+		msg.UserID = userID
+		records = append(records, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent messages: %w", err)
+	}
+	return records, nil
+}
+
+func (r *MySQLRepository) ListMessages(ctx context.Context, userID string, limit, offset int) ([]MessageRecord, error) {
+	const q = `
+SELECT id, direction, message_type, content, media_url, created_at
+FROM messages
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MessageRecord
+	for rows.Next() {
+		msg := MessageRecord{UserID: userID}
+		if err := rows.Scan(&msg.ID, &msg.Direction, &msg.Type, &msg.Content, &msg.MediaURL, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		records = append(records, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+	return records, nil
+}
+
+// -- API Keys --
+
+func (r *MySQLRepository) SyncGeminiKeys(ctx context.Context, keys []string) error {
+	keys = normaliseKeys(keys)
+	if len(keys) == 0 {
+		return fmt.Errorf("no gemini keys provided")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin sync api keys: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Assignments run left to right and see earlier ones, so updated_at is
+	// decided before the columns it compares change.
+	const upsert = `
+INSERT INTO api_keys (id, provider, value, priority, source)
+VALUES (?, ?, ?, ?, 'config')
+ON DUPLICATE KEY UPDATE
+    updated_at = CASE WHEN priority <> VALUES(priority) OR source <> 'config' OR disabled_reason = ? THEN CURRENT_TIMESTAMP(6) ELSE updated_at END,
+    priority = VALUES(priority),
+    source = 'config',
+    disabled_at = CASE WHEN disabled_reason = ? THEN NULL ELSE disabled_at END,
+    disabled_reason = CASE WHEN disabled_reason = ? THEN NULL ELSE disabled_reason END;`
+	for idx, key := range keys {
+		if _, err := tx.ExecContext(ctx, upsert, randomUUID(), providerGemini, key, idx, APIKeyRemovedFromConfig, APIKeyRemovedFromConfig, APIKeyRemovedFromConfig); err != nil {
+			return fmt.Errorf("upsert api key: %w", err)
+		}
+	}
+
+	args := []any{APIKeyRemovedFromConfig, providerGemini}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	q := `
+UPDATE api_keys
+SET disabled_at = CURRENT_TIMESTAMP(6),
+    disabled_reason = ?,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE provider = ?
+  AND source = 'config'
+  AND disabled_at IS NULL
+  AND value NOT IN (` + mysqlPlaceholders(len(keys)) + `);`
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("disable removed api keys: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit sync api keys: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		r.logger.Info("disabled gemini keys removed from config", "count", n)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListActiveGeminiKeys(ctx context.Context) ([]APIKey, error) {
+	q := `
+SELECT ` + apiKeyColumns + `
+FROM api_keys
+WHERE provider = ?
+  AND disabled_at IS NULL
+ORDER BY priority ASC;
+`
+	return r.listAPIKeys(ctx, q, providerGemini)
+}
+
+func (r *MySQLRepository) ListGeminiKeys(ctx context.Context) ([]APIKey, error) {
+	q := `
+SELECT ` + apiKeyColumns + `
+FROM api_keys
+WHERE provider = ?
+ORDER BY disabled_at IS NOT NULL, priority ASC;
+`
+	return r.listAPIKeys(ctx, q, providerGemini)
+}
+
+func (r *MySQLRepository) listAPIKeys(ctx context.Context, q string, args ...any) ([]APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var res []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		res = append(res, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys rows: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) AddGeminiKey(ctx context.Context, value string, priority int) (*APIKey, error) {
+	const q = `
+INSERT INTO api_keys (id, provider, value, priority, source)
+VALUES (?, ?, ?, ?, 'admin')
+ON DUPLICATE KEY UPDATE
+    priority = VALUES(priority),
+    disabled_at = NULL,
+    disabled_reason = NULL,
+    cooldown_until = NULL,
+    updated_at = CURRENT_TIMESTAMP(6);`
+	value = strings.TrimSpace(value)
+	if _, err := r.db.ExecContext(ctx, q, randomUUID(), providerGemini, value, priority); err != nil {
+		return nil, fmt.Errorf("add api key: %w", err)
+	}
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE provider = ? AND value = ?`, providerGemini, value))
+	if err != nil {
+		return nil, fmt.Errorf("add api key: %w", err)
+	}
+	return k, nil
+}
+
+func (r *MySQLRepository) DisableAPIKey(ctx context.Context, id string) error {
+	const q = `
+UPDATE api_keys
+SET disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP(6)),
+    disabled_reason = ?,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	res, err := r.db.ExecContext(ctx, q, APIKeyDisabledByAdmin, id)
+	if err != nil {
+		return fmt.Errorf("disable api key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) SetAPIKeyQuota(ctx context.Context, id string, requests, tokens int64) error {
+	const q = `UPDATE api_keys SET daily_request_quota = ?, daily_token_quota = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ?`
+	res, err := r.db.ExecContext(ctx, q, requests, tokens, id)
+	if err != nil {
+		return fmt.Errorf("set api key quota: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ClearCooldown(ctx context.Context, id string) error {
+	const q = `UPDATE api_keys SET cooldown_until = NULL, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ?`
+	ct, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("clear cooldown: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key not found: %s", id)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) SetCooldownUntil(ctx context.Context, id string, until time.Time) error {
+	const q = `UPDATE api_keys SET cooldown_until = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ?`
+	ct, err := r.db.ExecContext(ctx, q, until, id)
+	if err != nil {
+		return fmt.Errorf("set cooldown: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key not found: %s", id)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) UpdateAPIKeyCooldown(ctx context.Context, id string, until time.Time) error {
+	return r.SetCooldownUntil(ctx, id, until)
+}
+
+// -- Balances --
+
+func (r *MySQLRepository) GetUserBalance(ctx context.Context, userID string) (*UserBalance, error) {
+	const userQ = `
+SELECT wa_id, wa_jid, updated_at
+FROM users
+WHERE id = ?
+LIMIT 1;
+`
+	var waid string
+	var wajid sql.NullString
+	var updatedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, userQ, userID).Scan(&waid, &wajid, &updatedAt); err != nil {
+		return nil, fmt.Errorf("get user balance user lookup: %w", err)
+	}
+
+	ub := &UserBalance{UserID: userID, WAID: waid}
+	if wajid.Valid {
+		ub.WAJID = &wajid.String
+	}
+	if updatedAt.Valid {
+		ub.UpdatedAt = &updatedAt.Time
+	}
+
+	const depQ = `
+SELECT
+	COALESCE(SUM(CASE WHEN status = 'success' THEN amount ELSE 0 END), 0) AS deposited_confirmed,
+	COALESCE(SUM(CASE WHEN status IN ('pending', 'processing') THEN amount ELSE 0 END), 0) AS deposited_pending,
+	COALESCE(SUM(amount), 0) AS total_deposited
+FROM deposits
+WHERE user_id = ?;
+`
+	var depConfirmed, depPending, depTotal int64
+	if err := r.db.QueryRowContext(ctx, depQ, userID).Scan(&depConfirmed, &depPending, &depTotal); err != nil {
+		return nil, fmt.Errorf("get user balance deposits: %w", err)
+	}
+
+	const ordQ = `
+SELECT
+	COALESCE(SUM(CASE WHEN status = 'success' THEN amount ELSE 0 END), 0) AS spent_confirmed,
+	COALESCE(SUM(CASE WHEN status IN ('pending', 'processing', 'awaiting_payment') THEN amount ELSE 0 END), 0) AS spent_pending,
+	COALESCE(SUM(amount), 0) AS total_spent
+FROM orders
+WHERE user_id = ?;
+`
+	var spentConfirmed, spentPending, spentTotal int64
+	if err := r.db.QueryRowContext(ctx, ordQ, userID).Scan(&spentConfirmed, &spentPending, &spentTotal); err != nil {
+		return nil, fmt.Errorf("get user balance orders: %w", err)
+	}
+
+	const wdQ = `
+SELECT COALESCE(SUM(amount + fee), 0)
+FROM withdrawals
+WHERE user_id = ?
+  AND status <> 'failed';
+`
+	var withdrawn int64
+	if err := r.db.QueryRowContext(ctx, wdQ, userID).Scan(&withdrawn); err != nil {
+		return nil, fmt.Errorf("get user balance withdrawals: %w", err)
+	}
+
+	ub.DepositedConfirmed = depConfirmed
+	ub.DepositedPending = depPending
+	ub.TotalDeposited = depTotal
+	ub.SpentConfirmed = spentConfirmed
+	ub.SpentPending = spentPending
+	ub.TotalSpent = spentTotal
+	ub.Withdrawn = withdrawn
+	ub.SaldoConfirmed = depConfirmed - spentConfirmed - withdrawn
+
+	return ub, nil
+}
+
+// -- Orders --
+
+func (r *MySQLRepository) InsertOrder(ctx context.Context, order Order) (*Order, error) {
+	return mysqlInsertOrder(ctx, r.db, order)
+}
+
+func (r *MySQLRepository) InsertOrders(ctx context.Context, orders []Order) ([]Order, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin insert orders: %w", err)
+	}
+	defer tx.Rollback()
+	inserted := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		o, err := mysqlInsertOrder(ctx, tx, order)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", order.OrderRef, err)
+		}
+		inserted = append(inserted, *o)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit insert orders: %w", err)
+	}
+	return inserted, nil
+}
+
+func mysqlInsertOrder(ctx context.Context, db sqlOrderInserter, order Order) (*Order, error) {
+	id := randomUUID()
+	meta, err := toJSON(order.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	metaParam := jsonParam(meta)
+
+	const q = `
+INSERT INTO orders (id, user_id, order_ref, product_code, amount, fee, status, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+	if _, err := db.ExecContext(ctx, q,
+		id,
+		order.UserID,
+		order.OrderRef,
+		order.ProductCode,
+		order.Amount,
+		order.Fee,
+		order.Status,
+		metaParam,
+	); err != nil {
+		return nil, fmt.Errorf("insert order: %w", err)
+	}
+	row := db.QueryRowContext(ctx, `SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at FROM orders WHERE id = ?`, id)
+
+	var inserted Order
+	var metaJSON []byte
+	if err := row.Scan(&inserted.ID, &inserted.UserID, &inserted.OrderRef, &inserted.ProductCode, &inserted.Amount, &inserted.Fee, &inserted.Status, &metaJSON, &inserted.CreatedAt, &inserted.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("insert order: %w", err)
+	}
+	inserted.Metadata = fromJSON(metaJSON)
+	return &inserted, nil
+}
+
+func (r *MySQLRepository) UpdateOrderStatus(ctx context.Context, orderRef, status string, metadata map[string]any) error {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return err
+	}
+	metaParam := jsonParam(meta)
+	const q = `
+UPDATE orders
+SET status = ?,
+    metadata = COALESCE(?, metadata),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE order_ref = ?;
+`
+	_, err = r.db.ExecContext(ctx, q, status, metaParam, orderRef)
+	if err != nil {
+		return fmt.Errorf("update order status: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MarkOrderSucceeded(ctx context.Context, orderRef string, metadata map[string]any) (bool, error) {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return false, err
+	}
+	const q = `
+UPDATE orders
+SET status = 'success',
+    metadata = COALESCE(?, metadata),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE order_ref = ? AND status <> 'success';
+`
+	res, err := r.db.ExecContext(ctx, q, jsonParam(meta), orderRef)
+	if err != nil {
+		return false, fmt.Errorf("mark order succeeded: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mark order succeeded: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *MySQLRepository) GetOrderByRef(ctx context.Context, ref string) (*Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE order_ref = ?
+LIMIT 1;
+`
+	row := r.db.QueryRowContext(ctx, q, ref)
+	var order Order
+	var metaJSON []byte
+	if err := row.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("order %s: %w", ref, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get order by ref: %w", err)
+	}
+	order.Metadata = fromJSON(metaJSON)
+	return &order, nil
+}
+
+func (r *MySQLRepository) ListOrdersAwaitingDeposit(ctx context.Context, depositRef string) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.deposit_ref')) = ?
+  AND status = 'awaiting_payment'
+ORDER BY created_at ASC;
+`
+	rows, err := r.db.QueryContext(ctx, q, depositRef)
+	if err != nil {
+		return nil, fmt.Errorf("list orders awaiting: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func (r *MySQLRepository) ListOrdersByUser(ctx context.Context, userID string, limit, offset int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list orders by user: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan user order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *MySQLRepository) ListRecentOrders(ctx context.Context, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan recent order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *MySQLRepository) CountSuccessfulOrders(ctx context.Context, userID string) (int, error) {
+	const q = `SELECT COUNT(*) FROM orders WHERE user_id = ? AND status = 'success';`
+	var count int
+	if err := r.db.QueryRowContext(ctx, q, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count successful orders: %w", err)
+	}
+	return count, nil
+}
+
+func (r *MySQLRepository) ListStuckOrders(ctx context.Context, createdBefore time.Time, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE status IN ('pending', 'processing', 'process')
+  AND created_at < ?
+  AND sla_escalated_at IS NULL
+ORDER BY created_at ASC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, createdBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stuck orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan stuck order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stuck orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *MySQLRepository) MarkOrderEscalated(ctx context.Context, orderRef string, at time.Time) error {
+	const q = `UPDATE orders SET sla_escalated_at = ? WHERE order_ref = ?`
+	ct, err := r.db.ExecContext(ctx, q, at.UTC(), orderRef)
+	if err != nil {
+		return fmt.Errorf("mark order escalated: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("order %s: %w", orderRef, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE status IN ('pending', 'processing', 'process')
+  AND created_at > ?
+  AND created_at < ?
+ORDER BY status_polled_at IS NOT NULL, status_polled_at ASC, created_at ASC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, createdAfter.UTC(), createdBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan pending order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *MySQLRepository) MarkOrderPolled(ctx context.Context, orderRef string, at time.Time) error {
+	const q = `UPDATE orders SET status_polled_at = ? WHERE order_ref = ?`
+	ct, err := r.db.ExecContext(ctx, q, at.UTC(), orderRef)
+	if err != nil {
+		return fmt.Errorf("mark order polled: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("order %s: %w", orderRef, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Deposits --
+
+func (r *MySQLRepository) InsertDeposit(ctx context.Context, dep Deposit) (*Deposit, error) {
+	id := randomUUID()
+	meta, err := toJSON(dep.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	metaParam := jsonParam(meta)
+
+	const q = `
+INSERT INTO deposits (id, user_id, deposit_ref, method, amount, status, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+	if _, err := r.db.ExecContext(ctx, q,
+		id,
+		dep.UserID,
+		dep.DepositRef,
+		dep.Method,
+		dep.Amount,
+		dep.Status,
+		metaParam,
+	); err != nil {
+		return nil, fmt.Errorf("insert deposit: %w", err)
+	}
+	row := r.db.QueryRowContext(ctx, `SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at FROM deposits WHERE id = ?`, id)
+
+	var inserted Deposit
+	var metaJSON []byte
+	if err := row.Scan(&inserted.ID, &inserted.UserID, &inserted.DepositRef, &inserted.Method, &inserted.Amount, &inserted.Status, &metaJSON, &inserted.CreatedAt, &inserted.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("insert deposit: %w", err)
+	}
+	inserted.Metadata = fromJSON(metaJSON)
+	return &inserted, nil
+}
+
+func (r *MySQLRepository) UpdateDepositStatus(ctx context.Context, ref, status string, metadata map[string]any) error {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return err
+	}
+	metaParam := jsonParam(meta)
+	const q = `
+UPDATE deposits
+SET status = ?,
+    metadata = COALESCE(?, metadata),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE deposit_ref = ?;
+`
+	_, err = r.db.ExecContext(ctx, q, status, metaParam, ref)
+	if err != nil {
+		return fmt.Errorf("update deposit status: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) GetDepositByRef(ctx context.Context, ref string) (*Deposit, error) {
+	const q = `
+SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at
+FROM deposits
+WHERE deposit_ref = ?
+LIMIT 1;
+`
+	row := r.db.QueryRowContext(ctx, q, ref)
+	var dep Deposit
+	var metaJSON []byte
+	if err := row.Scan(&dep.ID, &dep.UserID, &dep.DepositRef, &dep.Method, &dep.Amount, &dep.Status, &metaJSON, &dep.CreatedAt, &dep.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("get deposit by ref: %w", err)
+	}
+	dep.Metadata = fromJSON(metaJSON)
+	return &dep, nil
+}
+
+func (r *MySQLRepository) ListRecentDeposits(ctx context.Context, limit int) ([]Deposit, error) {
+	const q = `
+SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at
+FROM deposits
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent deposits: %w", err)
+	}
+	defer rows.Close()
+
+	var deposits []Deposit
+	for rows.Next() {
+		var dep Deposit
+		var metaJSON []byte
+		if err := rows.Scan(&dep.ID, &dep.UserID, &dep.DepositRef, &dep.Method, &dep.Amount, &dep.Status, &metaJSON, &dep.CreatedAt, &dep.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan recent deposit: %w", err)
+		}
+		dep.Metadata = fromJSON(metaJSON)
+		deposits = append(deposits, dep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent deposits: %w", err)
+	}
+	return deposits, nil
+}
+
+func (r *MySQLRepository) ListUnpaidDeposits(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Deposit, error) {
+	const q = `
+SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at
+FROM deposits
+WHERE (status = 'pending' OR (status = 'success' AND JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.forced_success')) = 'true'))
+  AND created_at > ?
+  AND created_at < ?
+ORDER BY created_at ASC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, createdAfter.UTC(), createdBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unpaid deposits: %w", err)
+	}
+	defer rows.Close()
+
+	var deposits []Deposit
+	for rows.Next() {
+		var dep Deposit
+		var metaJSON []byte
+		if err := rows.Scan(&dep.ID, &dep.UserID, &dep.DepositRef, &dep.Method, &dep.Amount, &dep.Status, &metaJSON, &dep.CreatedAt, &dep.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan unpaid deposit: %w", err)
+		}
+		dep.Metadata = fromJSON(metaJSON)
+		deposits = append(deposits, dep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate unpaid deposits: %w", err)
+	}
+	return deposits, nil
+}
+
+// -- Withdrawals --
+
+func (r *MySQLRepository) InsertWithdrawal(ctx context.Context, wd Withdrawal) (*Withdrawal, error) {
+	id := randomUUID()
+	meta, err := toJSON(wd.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	metaParam := jsonParam(meta)
+
+	const q = `
+INSERT INTO withdrawals (id, user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+	if _, err := r.db.ExecContext(ctx, q,
+		id,
+		wd.UserID,
+		wd.WithdrawalRef,
+		wd.BankCode,
+		wd.AccountNo,
+		wd.AccountName,
+		wd.Amount,
+		wd.Fee,
+		wd.Status,
+		metaParam,
+	); err != nil {
+		return nil, fmt.Errorf("insert withdrawal: %w", err)
+	}
+	row := r.db.QueryRowContext(ctx, `SELECT id, user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata, created_at, updated_at FROM withdrawals WHERE id = ?`, id)
+
+	var inserted Withdrawal
+	var metaJSON []byte
+	if err := row.Scan(&inserted.ID, &inserted.UserID, &inserted.WithdrawalRef, &inserted.BankCode, &inserted.AccountNo, &inserted.AccountName, &inserted.Amount, &inserted.Fee, &inserted.Status, &metaJSON, &inserted.CreatedAt, &inserted.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("insert withdrawal: %w", err)
+	}
+	inserted.Metadata = fromJSON(metaJSON)
+	return &inserted, nil
+}
+
+func (r *MySQLRepository) GetWithdrawalByRef(ctx context.Context, ref string) (*Withdrawal, error) {
+	const q = `
+SELECT id, user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata, created_at, updated_at
+FROM withdrawals
+WHERE withdrawal_ref = ?
+LIMIT 1;
+`
+	row := r.db.QueryRowContext(ctx, q, ref)
+	var wd Withdrawal
+	var metaJSON []byte
+	if err := row.Scan(&wd.ID, &wd.UserID, &wd.WithdrawalRef, &wd.BankCode, &wd.AccountNo, &wd.AccountName, &wd.Amount, &wd.Fee, &wd.Status, &metaJSON, &wd.CreatedAt, &wd.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("get withdrawal by ref: %w", err)
+	}
+	wd.Metadata = fromJSON(metaJSON)
+	return &wd, nil
+}
+
+func (r *MySQLRepository) UpdateWithdrawalStatus(ctx context.Context, ref, status string, metadata map[string]any) error {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return err
+	}
+	metaParam := jsonParam(meta)
+	const q = `
+UPDATE withdrawals
+SET status = ?,
+    metadata = COALESCE(?, metadata),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE withdrawal_ref = ?;
+`
+	_, err = r.db.ExecContext(ctx, q, status, metaParam, ref)
+	if err != nil {
+		return fmt.Errorf("update withdrawal status: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) SumWithdrawalsSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	// created_at is stored as UTC text by CURRENT_TIMESTAMP(6), so compare using the same layout.
+	const q = `
+SELECT COALESCE(SUM(amount), 0)
+FROM withdrawals
+WHERE user_id = ?
+  AND status <> 'failed'
+  AND created_at >= ?;
+`
+	var total int64
+	if err := r.db.QueryRowContext(ctx, q, userID, since.UTC()).Scan(&total); err != nil {
+		return 0, fmt.Errorf("sum withdrawals: %w", err)
+	}
+	return total, nil
+}
+
+// -- Catalog --
+
+func (r *MySQLRepository) ListCatalogAliases(ctx context.Context) ([]CatalogAlias, error) {
+	const q = `
+SELECT id, alias, replacement, created_at, updated_at
+FROM catalog_aliases
+ORDER BY alias ASC;
+`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CatalogAlias
+	for rows.Next() {
+		var a CatalogAlias
+		if err := rows.Scan(&a.ID, &a.Alias, &a.Replacement, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan catalog alias: %w", err)
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog aliases: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpsertCatalogAlias(ctx context.Context, alias, replacement string) (*CatalogAlias, error) {
+	id := randomUUID()
+	const q = `
+INSERT INTO catalog_aliases (id, alias, replacement)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    replacement = VALUES(replacement),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, id, alias, replacement); err != nil {
+		return nil, fmt.Errorf("upsert catalog alias: %w", err)
+	}
+	var a CatalogAlias
+	if err := r.db.QueryRowContext(ctx, `SELECT id, alias, replacement, created_at, updated_at FROM catalog_aliases WHERE alias = ?`, alias).Scan(&a.ID, &a.Alias, &a.Replacement, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert catalog alias: %w", err)
+	}
+	return &a, nil
+}
+
+func (r *MySQLRepository) DeleteCatalogAlias(ctx context.Context, alias string) error {
+	const q = `DELETE FROM catalog_aliases WHERE alias = ?`
+	ct, err := r.db.ExecContext(ctx, q, alias)
+	if err != nil {
+		return fmt.Errorf("delete catalog alias: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("catalog alias %s: %w", alias, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListCuratedProducts(ctx context.Context) ([]CuratedProduct, error) {
+	const q = "SELECT " + mysqlCuratedProductColumns + " FROM curated_products ORDER BY scope ASC, `key` ASC"
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list curated products: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CuratedProduct
+	for rows.Next() {
+		var cp CuratedProduct
+		if err := rows.Scan(&cp.ID, &cp.Scope, &cp.Key, &cp.Hidden, &cp.Reason, &cp.Instructions, &cp.CreatedAt, &cp.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan curated product: %w", err)
+		}
+		res = append(res, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate curated products: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpsertCuratedProduct(ctx context.Context, cp CuratedProduct) (*CuratedProduct, error) {
+	id := randomUUID()
+	const q = "INSERT INTO curated_products (id, scope, `key`, hidden, reason, instructions)" + `
+VALUES (?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    hidden = VALUES(hidden),
+    reason = VALUES(reason),
+    instructions = VALUES(instructions),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, id, cp.Scope, cp.Key, cp.Hidden, cp.Reason, cp.Instructions); err != nil {
+		return nil, fmt.Errorf("upsert curated product: %w", err)
+	}
+	var saved CuratedProduct
+	if err := r.db.QueryRowContext(ctx, `SELECT `+mysqlCuratedProductColumns+" FROM curated_products WHERE scope = ? AND `key` = ?", cp.Scope, cp.Key).Scan(&saved.ID, &saved.Scope, &saved.Key, &saved.Hidden, &saved.Reason, &saved.Instructions, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert curated product: %w", err)
+	}
+	return &saved, nil
+}
+
+func (r *MySQLRepository) DeleteCuratedProduct(ctx context.Context, scope, key string) error {
+	const q = "DELETE FROM curated_products WHERE scope = ? AND `key` = ?"
+	ct, err := r.db.ExecContext(ctx, q, scope, key)
+	if err != nil {
+		return fmt.Errorf("delete curated product: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("curated product %s/%s: %w", scope, key, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Product stats --
+
+func (r *MySQLRepository) RecordProductOutcome(ctx context.Context, productCode, status string, success bool) (*ProductStat, error) {
+	successInc, failureInc := 0, 1
+	if success {
+		successInc, failureInc = 1, 0
+	}
+	const q = `
+INSERT INTO product_stats (product_code, success_count, failure_count, last_status, last_outcome_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP(6))
+ON DUPLICATE KEY UPDATE
+    success_count = success_count + VALUES(success_count),
+    failure_count = failure_count + VALUES(failure_count),
+    last_status = VALUES(last_status),
+    last_outcome_at = VALUES(last_outcome_at),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, productCode, successInc, failureInc, status); err != nil {
+		return nil, fmt.Errorf("record product outcome: %w", err)
+	}
+	const get = `
+SELECT product_code, success_count, failure_count, last_status, last_outcome_at, created_at, updated_at
+FROM product_stats
+WHERE product_code = ?;
+`
+	var st ProductStat
+	if err := r.db.QueryRowContext(ctx, get, productCode).Scan(&st.ProductCode, &st.SuccessCount, &st.FailureCount, &st.LastStatus, &st.LastOutcomeAt, &st.CreatedAt, &st.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("record product outcome: %w", err)
+	}
+	return &st, nil
+}
+
+func (r *MySQLRepository) ListProductStats(ctx context.Context) ([]ProductStat, error) {
+	const q = `
+SELECT product_code, success_count, failure_count, last_status, last_outcome_at, created_at, updated_at
+FROM product_stats
+ORDER BY product_code ASC;
+`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list product stats: %w", err)
+	}
+	defer rows.Close()
+
+	var res []ProductStat
+	for rows.Next() {
+		var st ProductStat
+		if err := rows.Scan(&st.ProductCode, &st.SuccessCount, &st.FailureCount, &st.LastStatus, &st.LastOutcomeAt, &st.CreatedAt, &st.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan product stat: %w", err)
+		}
+		res = append(res, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product stats: %w", err)
+	}
+	return res, nil
+}
+
+// -- Webhooks --
+
+func (r *MySQLRepository) InsertWebhookDeadLetter(ctx context.Context, dl WebhookDeadLetter) (*WebhookDeadLetter, error) {
+	headers, err := headersParam(dl.Headers)
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+INSERT INTO webhook_dead_letters (id, source, event_type, headers, payload, error)
+VALUES (?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, dl.Source, dl.EventType, headers, string(dl.Payload), dl.Error); err != nil {
+		return nil, fmt.Errorf("insert webhook dead letter: %w", err)
+	}
+	return r.GetWebhookDeadLetter(ctx, id)
+}
+
+func (r *MySQLRepository) ListWebhookDeadLetters(ctx context.Context, status string, limit int) ([]WebhookDeadLetter, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + deadLetterColumns + `
+FROM webhook_dead_letters
+WHERE (? = '' OR status = ?)
+ORDER BY created_at DESC
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var res []WebhookDeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook dead letter: %w", err)
+		}
+		res = append(res, *dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook dead letters: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) GetWebhookDeadLetter(ctx context.Context, id string) (*WebhookDeadLetter, error) {
+	q := `SELECT ` + deadLetterColumns + ` FROM webhook_dead_letters WHERE id = ?;`
+	dl, err := scanDeadLetter(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("webhook dead letter %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get webhook dead letter: %w", err)
+	}
+	return dl, nil
+}
+
+func (r *MySQLRepository) UpdateWebhookDeadLetter(ctx context.Context, id, status, lastError string) error {
+	const q = `
+UPDATE webhook_dead_letters
+SET status = ?,
+    error = CASE WHEN ? = '' THEN error ELSE ? END,
+    attempts = attempts + 1,
+    replayed_at = CASE WHEN ? = 'replayed' THEN CURRENT_TIMESTAMP(6) ELSE replayed_at END,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	ct, err := r.db.ExecContext(ctx, q, status, lastError, lastError, status, id)
+	if err != nil {
+		return fmt.Errorf("update webhook dead letter: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("webhook dead letter %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ClaimWebhookDeadLetter(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	const q = `
+UPDATE webhook_dead_letters
+SET status = 'replaying', updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ? AND (status = 'pending' OR (status = 'replaying' AND updated_at < ?));
+`
+	ct, err := r.db.ExecContext(ctx, q, id, staleBefore.UTC())
+	if err != nil {
+		return false, fmt.Errorf("claim webhook dead letter: %w", err)
+	}
+	n, _ := ct.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *MySQLRepository) ClaimWebhookEvent(ctx context.Context, ref, eventType, status string) (bool, error) {
+	// The key covers every column, so IGNORE only ever skips duplicates.
+	const q = `
+INSERT IGNORE INTO webhook_events (ref_id, event_type, status)
+VALUES (?, ?, ?);
+`
+	res, err := r.db.ExecContext(ctx, q, ref, eventType, status)
+	if err != nil {
+		return false, fmt.Errorf("claim webhook event: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *MySQLRepository) ReleaseWebhookEvent(ctx context.Context, ref, eventType, status string) error {
+	const q = `DELETE FROM webhook_events WHERE ref_id = ? AND event_type = ? AND status = ?`
+	if _, err := r.db.ExecContext(ctx, q, ref, eventType, status); err != nil {
+		return fmt.Errorf("release webhook event: %w", err)
+	}
+	return nil
+}
+
+// -- Merchant webhooks --
+
+func (r *MySQLRepository) InsertMerchantWebhook(ctx context.Context, hook MerchantWebhook) (*MerchantWebhook, error) {
+	events, err := eventsParam(hook.Events)
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+INSERT INTO merchant_webhooks (id, url, secret, events)
+VALUES (?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, hook.URL, hook.Secret, events); err != nil {
+		return nil, fmt.Errorf("insert merchant webhook: %w", err)
+	}
+	saved, err := scanMerchantWebhook(r.db.QueryRowContext(ctx, `SELECT `+merchantWebhookColumns+` FROM merchant_webhooks WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert merchant webhook: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MySQLRepository) ListMerchantWebhooks(ctx context.Context) ([]MerchantWebhook, error) {
+	q := `SELECT ` + merchantWebhookColumns + ` FROM merchant_webhooks ORDER BY created_at ASC;`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list merchant webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var res []MerchantWebhook
+	for rows.Next() {
+		hook, err := scanMerchantWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan merchant webhook: %w", err)
+		}
+		res = append(res, *hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate merchant webhooks: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) DeleteMerchantWebhook(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM merchant_webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete merchant webhook: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("merchant webhook %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) InsertMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error {
+	const q = `
+INSERT INTO merchant_webhook_deliveries (id, webhook_id, event_id, event_type, payload)
+VALUES (?, ?, ?, ?, ?);
+`
+	if _, err := r.db.ExecContext(ctx, q, randomUUID(), d.WebhookID, d.EventID, d.EventType, string(d.Payload)); err != nil {
+		return fmt.Errorf("insert merchant webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ClaimDueMerchantWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]MerchantWebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	res, err := mysqlUpdateReturning(ctx, r.db, mysqlUpdate{
+		table:    "merchant_webhook_deliveries",
+		key:      "id",
+		columns:  merchantDeliveryColumns,
+		pick:     "status = 'pending' AND next_attempt_at <= ? ORDER BY next_attempt_at ASC LIMIT ?",
+		pickArgs: []any{now.UTC(), limit},
+		set:      "next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP(6)",
+		setArgs:  []any{now.Add(lease).UTC()},
+	}, func(rows *sql.Rows) ([]MerchantWebhookDelivery, error) { return collectMerchantDeliveries(rows) })
+	if err != nil {
+		return nil, fmt.Errorf("claim merchant webhook deliveries: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpdateMerchantWebhookDelivery(ctx context.Context, d MerchantWebhookDelivery) error {
+	const q = `
+UPDATE merchant_webhook_deliveries
+SET status = ?,
+    attempts = ?,
+    response_status = ?,
+    error = ?,
+    next_attempt_at = ?,
+    delivered_at = ?,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	var deliveredAt any
+	if d.DeliveredAt != nil {
+		deliveredAt = d.DeliveredAt.UTC()
+	}
+	res, err := r.db.ExecContext(ctx, q, d.Status, d.Attempts, d.ResponseStatus, d.Error, d.NextAttemptAt.UTC(), deliveredAt, d.ID)
+	if err != nil {
+		return fmt.Errorf("update merchant webhook delivery: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("merchant webhook delivery %s: %w", d.ID, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListMerchantWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]MerchantWebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + merchantDeliveryColumns + `
+FROM merchant_webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY created_at DESC
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list merchant webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return collectMerchantDeliveries(rows)
+}
+
+// -- Notification journal --
+
+func (r *MySQLRepository) InsertNotification(ctx context.Context, n Notification) (*Notification, error) {
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = time.Now()
+	}
+	const q = `
+INSERT INTO notification_journal (id, user_id, chat_jid, kind, ref_id, body, next_attempt_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, n.UserID, n.ChatJID, n.Kind, n.RefID, n.Body, n.NextAttemptAt.UTC()); err != nil {
+		return nil, fmt.Errorf("insert notification: %w", err)
+	}
+	saved, err := scanNotification(r.db.QueryRowContext(ctx, `SELECT `+notificationColumns+` FROM notification_journal WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert notification: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MySQLRepository) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	res, err := mysqlUpdateReturning(ctx, r.db, mysqlUpdate{
+		table:    "notification_journal",
+		key:      "id",
+		columns:  notificationColumns,
+		pick:     "status = 'pending' AND next_attempt_at <= ? ORDER BY next_attempt_at ASC LIMIT ?",
+		pickArgs: []any{now.UTC(), limit},
+		set:      "next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP(6)",
+		setArgs:  []any{now.Add(lease).UTC()},
+	}, func(rows *sql.Rows) ([]Notification, error) { return collectNotifications(rows) })
+	if err != nil {
+		return nil, fmt.Errorf("claim notifications: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpdateNotification(ctx context.Context, n Notification) error {
+	const q = `
+UPDATE notification_journal
+SET status = ?,
+    attempts = ?,
+    last_error = ?,
+    next_attempt_at = ?,
+    delivered_at = ?,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	var deliveredAt any
+	if n.DeliveredAt != nil {
+		deliveredAt = n.DeliveredAt.UTC()
+	}
+	res, err := r.db.ExecContext(ctx, q, n.Status, n.Attempts, n.LastError, n.NextAttemptAt.UTC(), deliveredAt, n.ID)
+	if err != nil {
+		return fmt.Errorf("update notification: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("notification %s: %w", n.ID, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListUndeliveredNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + notificationColumns + `
+FROM notification_journal
+WHERE status <> 'delivered'
+ORDER BY created_at DESC
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list undelivered notifications: %w", err)
+	}
+	defer rows.Close()
+	return collectNotifications(rows)
+}
+
+func (r *MySQLRepository) RequeueNotification(ctx context.Context, id string) error {
+	const q = `
+UPDATE notification_journal
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = CURRENT_TIMESTAMP(6),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ? AND status <> 'delivered';
+`
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("requeue notification: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("undelivered notification %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Pricing rules --
+
+func (r *MySQLRepository) ListPricingRules(ctx context.Context) ([]PricingRule, error) {
+	const q = "SELECT " + mysqlPricingRuleColumns + " FROM pricing_rules ORDER BY scope ASC, `key` ASC"
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	var res []PricingRule
+	for rows.Next() {
+		var rule PricingRule
+		if err := rows.Scan(&rule.ID, &rule.Scope, &rule.Key, &rule.MarginFixed, &rule.MarginPercent, &rule.RoundTo, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan pricing rule: %w", err)
+		}
+		res = append(res, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pricing rules: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpsertPricingRule(ctx context.Context, rule PricingRule) (*PricingRule, error) {
+	const q = "INSERT INTO pricing_rules (id, scope, `key`, margin_fixed, margin_percent, round_to)" + `
+VALUES (?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    margin_fixed = VALUES(margin_fixed),
+    margin_percent = VALUES(margin_percent),
+    round_to = VALUES(round_to),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, randomUUID(), rule.Scope, rule.Key, rule.MarginFixed, rule.MarginPercent, rule.RoundTo); err != nil {
+		return nil, fmt.Errorf("upsert pricing rule: %w", err)
+	}
+	const get = "SELECT " + mysqlPricingRuleColumns + " FROM pricing_rules WHERE scope = ? AND `key` = ?"
+	var saved PricingRule
+	if err := r.db.QueryRowContext(ctx, get, rule.Scope, rule.Key).Scan(&saved.ID, &saved.Scope, &saved.Key, &saved.MarginFixed, &saved.MarginPercent, &saved.RoundTo, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert pricing rule: %w", err)
+	}
+	return &saved, nil
+}
+
+func (r *MySQLRepository) DeletePricingRule(ctx context.Context, scope, key string) error {
+	const q = "DELETE FROM pricing_rules WHERE scope = ? AND `key` = ?"
+	ct, err := r.db.ExecContext(ctx, q, scope, key)
+	if err != nil {
+		return fmt.Errorf("delete pricing rule: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("pricing rule %s/%s: %w", scope, key, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Product names --
+
+func (r *MySQLRepository) ListProductNameRules(ctx context.Context) ([]ProductNameRule, error) {
+	q := `SELECT ` + productNameRuleColumns + ` FROM product_name_rules ORDER BY scope ASC, created_at ASC, id ASC;`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list product name rules: %w", err)
+	}
+	defer rows.Close()
+
+	var res []ProductNameRule
+	for rows.Next() {
+		var rule ProductNameRule
+		if err := rows.Scan(&rule.ID, &rule.Scope, &rule.Target, &rule.Name, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan product name rule: %w", err)
+		}
+		res = append(res, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product name rules: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpsertProductNameRule(ctx context.Context, rule ProductNameRule) (*ProductNameRule, error) {
+	const q = `
+INSERT INTO product_name_rules (id, scope, target, name)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    name = VALUES(name),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, randomUUID(), rule.Scope, rule.Target, rule.Name); err != nil {
+		return nil, fmt.Errorf("upsert product name rule: %w", err)
+	}
+	get := `SELECT ` + productNameRuleColumns + ` FROM product_name_rules WHERE scope = ? AND target = ?`
+	var saved ProductNameRule
+	if err := r.db.QueryRowContext(ctx, get, rule.Scope, rule.Target).Scan(&saved.ID, &saved.Scope, &saved.Target, &saved.Name, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert product name rule: %w", err)
+	}
+	return &saved, nil
+}
+
+func (r *MySQLRepository) DeleteProductNameRule(ctx context.Context, scope, target string) error {
+	const q = `DELETE FROM product_name_rules WHERE scope = ? AND target = ?`
+	ct, err := r.db.ExecContext(ctx, q, scope, target)
+	if err != nil {
+		return fmt.Errorf("delete product name rule: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("product name rule %s/%s: %w", scope, target, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Flash sales --
+
+func (r *MySQLRepository) InsertFlashSale(ctx context.Context, f FlashSale) (*FlashSale, error) {
+	const q = `
+INSERT INTO flash_sales (id, product_code, price, starts_at, ends_at, campaign_id)
+VALUES (?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, f.ProductCode, f.Price, f.StartsAt.UTC(), f.EndsAt.UTC(), f.CampaignID); err != nil {
+		return nil, fmt.Errorf("insert flash sale: %w", err)
+	}
+	saved, err := scanFlashSale(r.db.QueryRowContext(ctx, `SELECT `+flashSaleColumns+` FROM flash_sales WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert flash sale: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MySQLRepository) ListFlashSales(ctx context.Context, endingAfter time.Time) ([]FlashSale, error) {
+	q := `
+SELECT ` + flashSaleColumns + `
+FROM flash_sales
+WHERE ends_at > ?
+ORDER BY starts_at, created_at;`
+	rows, err := r.db.QueryContext(ctx, q, endingAfter.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list flash sales: %w", err)
+	}
+	defer rows.Close()
+
+	var res []FlashSale
+	for rows.Next() {
+		f, err := scanFlashSale(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan flash sale: %w", err)
+		}
+		res = append(res, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate flash sales: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) DeleteFlashSale(ctx context.Context, id string) (*FlashSale, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin delete flash sale: %w", err)
+	}
+	defer tx.Rollback()
+
+	f, err := scanFlashSale(tx.QueryRowContext(ctx, `SELECT `+flashSaleColumns+` FROM flash_sales WHERE id = ? FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("flash sale %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("delete flash sale: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM flash_sales WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("delete flash sale: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit delete flash sale: %w", err)
+	}
+	return f, nil
+}
+
+// -- Campaigns --
+
+func (r *MySQLRepository) InsertCampaign(ctx context.Context, c Campaign) (*Campaign, error) {
+	segment, err := segmentParam(c.Segment)
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+INSERT INTO campaigns (id, name, template, segment, scheduled_at, throttle_per_minute)
+VALUES (?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, c.Name, c.Template, segment, c.ScheduledAt.UTC(), c.ThrottlePerMinute); err != nil {
+		return nil, fmt.Errorf("insert campaign: %w", err)
+	}
+	return r.GetCampaign(ctx, id)
+}
+
+func (r *MySQLRepository) GetCampaign(ctx context.Context, id string) (*Campaign, error) {
+	q := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id = ?;`
+	c, err := scanCampaign(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("campaign %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get campaign: %w", err)
+	}
+	return c, nil
+}
+
+func (r *MySQLRepository) ListCampaigns(ctx context.Context, limit int) ([]Campaign, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + campaignColumns + `
+FROM campaigns
+ORDER BY scheduled_at DESC
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan campaign: %w", err)
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate campaigns: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) ClaimDueCampaigns(ctx context.Context, now time.Time, lease time.Duration) ([]Campaign, error) {
+	res, err := mysqlUpdateReturning(ctx, r.db, mysqlUpdate{
+		table:   "campaigns",
+		key:     "id",
+		columns: campaignColumns,
+		pick: `(status = 'scheduled' AND scheduled_at <= ?)
+   OR (status = 'running'
+       AND GREATEST(updated_at, COALESCE((SELECT MAX(d.sent_at) FROM campaign_deliveries d WHERE d.campaign_id = campaigns.id), updated_at)) < ?)`,
+		pickArgs: []any{now.UTC(), now.Add(-lease).UTC()},
+		// MySQL assigns left to right, so started_at reads the old status.
+		set: "started_at = CASE WHEN status = 'scheduled' THEN CURRENT_TIMESTAMP(6) ELSE started_at END, status = 'running', updated_at = CURRENT_TIMESTAMP(6)",
+	}, func(rows *sql.Rows) ([]Campaign, error) {
+		var res []Campaign
+		for rows.Next() {
+			c, err := scanCampaign(rows)
+			if err != nil {
+				return nil, fmt.Errorf("scan campaign: %w", err)
+			}
+			res = append(res, *c)
+		}
+		return res, rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim due campaigns: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) UpdateCampaignStatus(ctx context.Context, id, status string) error {
+	const q = `
+UPDATE campaigns
+SET status = ?,
+    completed_at = CASE WHEN ? = 'completed' THEN CURRENT_TIMESTAMP(6) ELSE completed_at END,
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ?;
+`
+	ct, err := r.db.ExecContext(ctx, q, status, status, id)
+	if err != nil {
+		return fmt.Errorf("update campaign status: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("campaign %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListUsersInSegment(ctx context.Context, seg Segment) ([]User, error) {
+	q := newMySQLSegmentQuery()
+	q.applySegment(seg, time.Now())
+	rows, err := r.db.QueryContext(ctx, q.sql(), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list users in segment: %w", err)
+	}
+	defer rows.Close()
+	return scanSegmentUsers(rows)
+}
+
+func (r *MySQLRepository) ListCampaignRecipients(ctx context.Context, campaignID string, seg Segment) ([]User, error) {
+	q := newMySQLSegmentQuery()
+	q.applySegment(seg, time.Now())
+	q.where("NOT EXISTS (SELECT 1 FROM campaign_deliveries d WHERE d.campaign_id = %s AND d.user_id = u.id)", campaignID)
+	rows, err := r.db.QueryContext(ctx, q.sql(), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list campaign recipients: %w", err)
+	}
+	defer rows.Close()
+	return scanSegmentUsers(rows)
+}
+
+func (r *MySQLRepository) RecordCampaignDelivery(ctx context.Context, d CampaignDelivery) error {
+	const q = `
+INSERT INTO campaign_deliveries (id, campaign_id, user_id, status, error)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE id = id;
+`
+	if _, err := r.db.ExecContext(ctx, q, randomUUID(), d.CampaignID, d.UserID, d.Status, d.Error); err != nil {
+		return fmt.Errorf("record campaign delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) AttributeCampaignConversion(ctx context.Context, userID, orderRef string, since time.Time) (bool, error) {
+	const q = `
+UPDATE campaign_deliveries
+SET converted_at = CURRENT_TIMESTAMP(6),
+    order_ref = ?
+WHERE user_id = ?
+  AND status = 'sent'
+  AND converted_at IS NULL
+  AND sent_at >= ?
+ORDER BY sent_at DESC
+LIMIT 1;
+`
+	ct, err := r.db.ExecContext(ctx, q, orderRef, userID, since.UTC())
+	if err != nil {
+		return false, fmt.Errorf("attribute campaign conversion: %w", err)
+	}
+	n, _ := ct.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *MySQLRepository) GetCampaignStats(ctx context.Context, campaignID string) (*CampaignStats, error) {
+	const q = `
+SELECT COALESCE(SUM(CASE WHEN status = 'sent' THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN converted_at IS NOT NULL THEN 1 ELSE 0 END), 0)
+FROM campaign_deliveries
+WHERE campaign_id = ?;
+`
+	var st CampaignStats
+	if err := r.db.QueryRowContext(ctx, q, campaignID).Scan(&st.Sent, &st.Failed, &st.Converted); err != nil {
+		return nil, fmt.Errorf("get campaign stats: %w", err)
+	}
+	return &st, nil
+}
+
+func (r *MySQLRepository) LatestCampaignDelivery(ctx context.Context, userID string, since time.Time) (string, error) {
+	const q = `
+SELECT campaign_id FROM campaign_deliveries
+WHERE user_id = ? AND status = 'sent' AND sent_at >= ?
+ORDER BY sent_at DESC
+LIMIT 1;
+`
+	var campaignID string
+	if err := r.db.QueryRowContext(ctx, q, userID, since.UTC()).Scan(&campaignID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("campaign delivery for %s: %w", userID, ErrNotFound)
+		}
+		return "", fmt.Errorf("latest campaign delivery: %w", err)
+	}
+	return campaignID, nil
+}
+
+// -- Acquisition --
+
+func (r *MySQLRepository) RecordUserAcquisition(ctx context.Context, userID string, acq Acquisition) (bool, error) {
+	const q = `
+UPDATE users
+SET acquisition_source = ?, acquisition_detail = NULLIF(?, '')
+WHERE id = ?
+  AND acquisition_source IS NULL
+  AND NOT EXISTS (SELECT 1 FROM messages WHERE user_id = ?);
+`
+	ct, err := r.db.ExecContext(ctx, q, acq.Source, acq.Detail, userID, userID)
+	if err != nil {
+		return false, fmt.Errorf("record user acquisition: %w", err)
+	}
+	n, _ := ct.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *MySQLRepository) GetUserAcquisition(ctx context.Context, userID string) (*Acquisition, error) {
+	const q = `SELECT COALESCE(acquisition_source, ''), COALESCE(acquisition_detail, '') FROM users WHERE id = ?`
+	var acq Acquisition
+	if err := r.db.QueryRowContext(ctx, q, userID).Scan(&acq.Source, &acq.Detail); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user acquisition: %w", err)
+	}
+	return &acq, nil
+}
+
+func (r *MySQLRepository) AcquisitionReport(ctx context.Context, since time.Time) ([]AcquisitionStat, error) {
+	const usersQ = `
+SELECT COALESCE(u.acquisition_source, 'unknown'), COALESCE(u.acquisition_detail, ''), COUNT(*),
+       COALESCE(SUM(CASE WHEN EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.status = 'success') THEN 1 ELSE 0 END), 0)
+FROM users u
+WHERE u.created_at >= ?
+GROUP BY 1, 2;
+`
+	const ordersQ = `
+SELECT COALESCE(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.acquisition_source')), 'unknown'), COALESCE(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.acquisition_detail')), ''), COUNT(*), COALESCE(SUM(amount), 0)
+FROM orders
+WHERE status = 'success' AND created_at >= ?
+GROUP BY 1, 2;
+`
+	sinceArg := since.UTC()
+	report := acquisitionReport{}
+	rows, err := r.db.QueryContext(ctx, usersQ, sinceArg)
+	if err != nil {
+		return nil, fmt.Errorf("acquisition report users: %w", err)
+	}
+	err = report.scan(rows, func(st *AcquisitionStat, users, buyers int64) { st.Users, st.Buyers = users, buyers })
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	rows, err = r.db.QueryContext(ctx, ordersQ, sinceArg)
+	if err != nil {
+		return nil, fmt.Errorf("acquisition report orders: %w", err)
+	}
+	defer rows.Close()
+	if err := report.scan(rows, func(st *AcquisitionStat, orders, revenue int64) { st.Orders, st.Revenue = orders, revenue }); err != nil {
+		return nil, err
+	}
+	return report.list(), nil
+}
+
+func (r *MySQLRepository) SalesSummary(ctx context.Context, from, to time.Time) (*SalesSummary, error) {
+	const ordersQ = `
+SELECT COUNT(*),
+       COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'success' THEN amount ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'success' AND JSON_EXTRACT(metadata, '$.supplier_price') IS NOT NULL THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'success' AND JSON_EXTRACT(metadata, '$.supplier_price') IS NOT NULL THEN amount ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'success' THEN COALESCE(CAST(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.supplier_price')) AS SIGNED), 0) ELSE 0 END), 0)
+FROM orders
+WHERE created_at >= ? AND created_at < ?;
+`
+	const depositsQ = `
+SELECT COUNT(*),
+       COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0),
+       COALESCE(SUM(CASE WHEN status = 'success' THEN amount ELSE 0 END), 0)
+FROM deposits
+WHERE created_at >= ? AND created_at < ?;
+`
+	fromArg, toArg := from.UTC(), to.UTC()
+	var s SalesSummary
+	if err := r.db.QueryRowContext(ctx, ordersQ, fromArg, toArg).Scan(&s.Orders, &s.OrdersSuccess, &s.OrdersFailed, &s.Revenue, &s.CostedOrders, &s.CostedRevenue, &s.Cost); err != nil {
+		return nil, fmt.Errorf("sales summary orders: %w", err)
+	}
+	if err := r.db.QueryRowContext(ctx, depositsQ, fromArg, toArg).Scan(&s.Deposits, &s.DepositsPaid, &s.DepositAmount); err != nil {
+		return nil, fmt.Errorf("sales summary deposits: %w", err)
+	}
+	return &s, nil
+}
+
+// -- Sender policy --
+
+func (r *MySQLRepository) GetSenderPolicy(ctx context.Context) (*SenderPolicy, error) {
+	const q = `
+SELECT mode, numbers, country_codes, contacts_only, block_business, updated_at
+FROM sender_policy
+WHERE id = 1;
+`
+	var p SenderPolicy
+	var numbers, countryCodes string
+	err := r.db.QueryRowContext(ctx, q).Scan(&p.Mode, &numbers, &countryCodes, &p.ContactsOnly, &p.BlockBusiness, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &SenderPolicy{Mode: SenderPolicyOff}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get sender policy: %w", err)
+	}
+	if err := decodeSenderPolicy(&p, []byte(numbers), []byte(countryCodes)); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *MySQLRepository) SaveSenderPolicy(ctx context.Context, p SenderPolicy) (*SenderPolicy, error) {
+	numbers, err := senderListParam(p.Numbers)
+	if err != nil {
+		return nil, err
+	}
+	countryCodes, err := senderListParam(p.CountryCodes)
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+INSERT INTO sender_policy (id, mode, numbers, country_codes, contacts_only, block_business)
+VALUES (1, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    mode = VALUES(mode),
+    numbers = VALUES(numbers),
+    country_codes = VALUES(country_codes),
+    contacts_only = VALUES(contacts_only),
+    block_business = VALUES(block_business),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, p.Mode, numbers, countryCodes, p.ContactsOnly, p.BlockBusiness); err != nil {
+		return nil, fmt.Errorf("save sender policy: %w", err)
+	}
+	return r.GetSenderPolicy(ctx)
+}
+
+// -- Catalog snapshots --
+
+func (r *MySQLRepository) InsertCatalogSnapshot(ctx context.Context, snap CatalogSnapshot) (*CatalogSnapshot, error) {
+	const q = `
+INSERT INTO catalog_snapshots (id, product_type, version, checksum, item_count, items)
+SELECT ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?
+FROM catalog_snapshots
+WHERE product_type = ?;
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, snap.ProductType, snap.Checksum, snap.ItemCount, string(snap.Items), snap.ProductType); err != nil {
+		return nil, fmt.Errorf("insert catalog snapshot: %w", err)
+	}
+	return r.getCatalogSnapshot(ctx, `SELECT `+catalogSnapshotColumns+` FROM catalog_snapshots WHERE id = ?`, id)
+}
+
+func (r *MySQLRepository) GetLatestCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = ? ORDER BY version DESC LIMIT 1;`
+	return r.getCatalogSnapshot(ctx, q, productType)
+}
+
+func (r *MySQLRepository) GetCatalogSnapshot(ctx context.Context, productType string, version int) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = ? AND version = ?;`
+	return r.getCatalogSnapshot(ctx, q, productType, version)
+}
+
+func (r *MySQLRepository) GetPinnedCatalogSnapshot(ctx context.Context, productType string) (*CatalogSnapshot, error) {
+	q := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots WHERE product_type = ? AND pinned LIMIT 1;`
+	return r.getCatalogSnapshot(ctx, q, productType)
+}
+
+func (r *MySQLRepository) getCatalogSnapshot(ctx context.Context, q string, args ...any) (*CatalogSnapshot, error) {
+	snap, err := scanCatalogSnapshot(r.db.QueryRowContext(ctx, q, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("catalog snapshot: %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("get catalog snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+func (r *MySQLRepository) ListCatalogSnapshots(ctx context.Context, productType string, limit int) ([]CatalogSnapshot, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	q := `
+SELECT ` + catalogSnapshotSummaryColumns + `
+FROM catalog_snapshots
+WHERE product_type = ?
+ORDER BY version DESC
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, productType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var res []CatalogSnapshot
+	for rows.Next() {
+		snap, err := scanCatalogSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan catalog snapshot: %w", err)
+		}
+		res = append(res, *snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate catalog snapshots: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) PinCatalogSnapshot(ctx context.Context, productType string, version int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin pin catalog snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	ct, err := tx.ExecContext(ctx, `UPDATE catalog_snapshots SET pinned = 1 WHERE product_type = ? AND version = ?`, productType, version)
+	if err != nil {
+		return fmt.Errorf("pin catalog snapshot: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("catalog snapshot %s v%d: %w", productType, version, ErrNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE catalog_snapshots SET pinned = 0 WHERE product_type = ? AND version <> ? AND pinned`, productType, version); err != nil {
+		return fmt.Errorf("unpin other catalog snapshots: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *MySQLRepository) UnpinCatalog(ctx context.Context, productType string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE catalog_snapshots SET pinned = 0 WHERE product_type = ? AND pinned`, productType); err != nil {
+		return fmt.Errorf("unpin catalog: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) RecordOrderCatalogVersion(ctx context.Context, v OrderCatalogVersion) error {
+	const q = `
+INSERT INTO order_catalog_versions (order_ref, product_type, catalog_version, quoted_price)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE order_ref = order_ref;
+`
+	if _, err := r.db.ExecContext(ctx, q, v.OrderRef, v.ProductType, v.CatalogVersion, v.QuotedPrice); err != nil {
+		return fmt.Errorf("record order catalog version: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) GetOrderCatalogVersion(ctx context.Context, orderRef string) (*OrderCatalogVersion, error) {
+	const q = `
+SELECT order_ref, product_type, catalog_version, quoted_price, created_at
+FROM order_catalog_versions
+WHERE order_ref = ?;
+`
+	var v OrderCatalogVersion
+	if err := r.db.QueryRowContext(ctx, q, orderRef).Scan(&v.OrderRef, &v.ProductType, &v.CatalogVersion, &v.QuotedPrice, &v.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("order catalog version %s: %w", orderRef, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get order catalog version: %w", err)
+	}
+	return &v, nil
+}
+
+// -- User memories --
+
+func (r *MySQLRepository) GetUserMemory(ctx context.Context, userID string) (*UserMemory, error) {
+	const q = `
+SELECT user_id, summary, message_count, updated_at
+FROM user_memories
+WHERE user_id = ?;
+`
+	var m UserMemory
+	if err := r.db.QueryRowContext(ctx, q, userID).Scan(&m.UserID, &m.Summary, &m.MessageCount, &m.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user memory %s: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user memory: %w", err)
+	}
+	return &m, nil
+}
+
+func (r *MySQLRepository) UpsertUserMemory(ctx context.Context, mem UserMemory) error {
+	const q = `
+INSERT INTO user_memories (user_id, summary, message_count, updated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP(6))
+ON DUPLICATE KEY UPDATE
+    summary = VALUES(summary),
+    message_count = VALUES(message_count),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, mem.UserID, mem.Summary, mem.MessageCount); err != nil {
+		return fmt.Errorf("upsert user memory: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ResetUserMemory(ctx context.Context, userID string) error {
+	const q = `
+INSERT INTO user_memories (user_id, summary, message_count, updated_at)
+SELECT ?, '', COUNT(*), CURRENT_TIMESTAMP(6) FROM messages WHERE user_id = ?
+ON DUPLICATE KEY UPDATE
+    summary = '',
+    message_count = VALUES(message_count),
+    updated_at = CURRENT_TIMESTAMP(6);
+`
+	if _, err := r.db.ExecContext(ctx, q, userID, userID); err != nil {
+		return fmt.Errorf("reset user memory: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListMemoryCandidates(ctx context.Context, minNewMessages, limit int) ([]MemoryCandidate, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	const q = `
+SELECT m.user_id, COALESCE(um.summary, ''), COUNT(*)
+FROM messages m
+LEFT JOIN user_memories um ON um.user_id = m.user_id
+GROUP BY m.user_id, um.summary, um.message_count
+HAVING COUNT(*) - COALESCE(um.message_count, 0) >= ?
+ORDER BY MAX(m.created_at) DESC
+LIMIT ?;
+`
+	rows, err := r.db.QueryContext(ctx, q, minNewMessages, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list memory candidates: %w", err)
+	}
+	defer rows.Close()
+	return scanMemoryCandidates(rows)
+}
+
+// -- Outbound messages --
+
+func (r *MySQLRepository) InsertOutboundMessage(ctx context.Context, msg OutboundMessage) error {
+	const q = `
+INSERT INTO outbound_messages (wa_message_id, chat_jid, body, status, attempts, sent_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE wa_message_id = wa_message_id;
+`
+	status := msg.Status
+	if status == "" {
+		status = OutboundSent
+	}
+	sentAt := msg.SentAt
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+	if _, err := r.db.ExecContext(ctx, q, msg.ID, msg.ChatJID, msg.Body, status, msg.Attempts, sentAt.UTC()); err != nil {
+		return fmt.Errorf("insert outbound message: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MarkOutboundDelivered(ctx context.Context, ids []string, at time.Time) ([]OutboundMessage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	pickArgs := make([]any, 0, len(ids))
+	for _, id := range ids {
+		pickArgs = append(pickArgs, id)
+	}
+	res, err := mysqlUpdateReturning(ctx, r.db, mysqlUpdate{
+		table:    "outbound_messages",
+		key:      "wa_message_id",
+		columns:  outboundMessageColumns,
+		pick:     "delivered_at IS NULL AND wa_message_id IN (" + mysqlPlaceholders(len(ids)) + ")",
+		pickArgs: pickArgs,
+		set:      "delivered_at = ?, status = CASE WHEN status IN ('sent', 'resent', 'undelivered') THEN 'delivered' ELSE status END",
+		setArgs:  []any{at.UTC()},
+	}, func(rows *sql.Rows) ([]OutboundMessage, error) { return scanOutboundMessages(rows) })
+	if err != nil {
+		return nil, fmt.Errorf("mark outbound delivered: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) MarkOutboundRead(ctx context.Context, ids []string, at time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	stamp := at.UTC()
+	args := []any{stamp, stamp}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	q := `
+UPDATE outbound_messages
+SET read_at = ?,
+    delivered_at = COALESCE(delivered_at, ?),
+    status = 'read'
+WHERE read_at IS NULL
+  AND wa_message_id IN (` + mysqlPlaceholders(len(ids)) + `);`
+	res, err := r.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, fmt.Errorf("mark outbound read: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *MySQLRepository) ListUndeliveredOutbound(ctx context.Context, sentBefore time.Time, limit int) ([]OutboundMessage, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + outboundMessageColumns + `
+FROM outbound_messages
+WHERE status = 'sent' AND sent_at < ?
+ORDER BY sent_at
+LIMIT ?;`
+	rows, err := r.db.QueryContext(ctx, q, sentBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list undelivered outbound: %w", err)
+	}
+	defer rows.Close()
+	return scanOutboundMessages(rows)
+}
+
+func (r *MySQLRepository) SetOutboundStatus(ctx context.Context, id, status string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE outbound_messages SET status = ? WHERE wa_message_id = ?`, status, id); err != nil {
+		return fmt.Errorf("set outbound status: %w", err)
+	}
+	return nil
+}
+
+// -- Admin approvals --
+
+func (r *MySQLRepository) InsertAdminApproval(ctx context.Context, a AdminApproval) (*AdminApproval, error) {
+	const q = `
+INSERT INTO admin_approvals (id, code, kind, user_id, user_jid, subject_ref, summary, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	now := time.Now().UTC()
+	if _, err := r.db.ExecContext(ctx, q, id, a.Code, a.Kind, a.UserID, a.UserJID, a.SubjectRef, a.Summary, a.ExpiresAt.UTC(), now); err != nil {
+		return nil, fmt.Errorf("insert admin approval: %w", err)
+	}
+	created, err := scanAdminApproval(r.db.QueryRowContext(ctx, `SELECT `+adminApprovalColumns+` FROM admin_approvals WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert admin approval: %w", err)
+	}
+	return created, nil
+}
+
+func (r *MySQLRepository) DecideAdminApproval(ctx context.Context, code, status, decidedBy string, at time.Time) (*AdminApproval, error) {
+	const q = `
+UPDATE admin_approvals
+SET status = ?, decided_by = ?, decided_at = ?
+WHERE code = ? AND status = 'pending' AND expires_at > ?;
+`
+	stamp := at.UTC()
+	res, err := r.db.ExecContext(ctx, q, status, decidedBy, stamp, code, stamp)
+	if err != nil {
+		return nil, fmt.Errorf("decide admin approval: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("decide admin approval: %w", err)
+	} else if n == 0 {
+		return nil, fmt.Errorf("admin approval %s: %w", code, ErrNotFound)
+	}
+	a, err := scanAdminApproval(r.db.QueryRowContext(ctx, `SELECT `+adminApprovalColumns+` FROM admin_approvals WHERE code = ?`, code))
+	if err != nil {
+		return nil, fmt.Errorf("decide admin approval: %w", err)
+	}
+	return a, nil
+}
+
+func (r *MySQLRepository) ExpireAdminApprovals(ctx context.Context, now time.Time) ([]AdminApproval, error) {
+	stamp := now.UTC()
+	res, err := mysqlUpdateReturning(ctx, r.db, mysqlUpdate{
+		table:    "admin_approvals",
+		key:      "id",
+		columns:  adminApprovalColumns,
+		pick:     "status = 'pending' AND expires_at <= ?",
+		pickArgs: []any{stamp},
+		set:      "status = 'expired', decided_at = ?",
+		setArgs:  []any{stamp},
+	}, func(rows *sql.Rows) ([]AdminApproval, error) { return scanAdminApprovals(rows) })
+	if err != nil {
+		return nil, fmt.Errorf("expire admin approvals: %w", err)
+	}
+	return res, nil
+}
+
+// -- Operator handoffs --
+
+func (r *MySQLRepository) StartHandoff(ctx context.Context, h Handoff) (*Handoff, error) {
+	const q = `
+INSERT INTO operator_handoffs (user_id, chat_jid, phone, reason)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    chat_jid = VALUES(chat_jid),
+    phone = COALESCE(VALUES(phone), phone),
+    reason = COALESCE(VALUES(reason), reason);
+`
+	if _, err := r.db.ExecContext(ctx, q, h.UserID, h.ChatJID, h.Phone, h.Reason); err != nil {
+		return nil, fmt.Errorf("start handoff: %w", err)
+	}
+	saved, err := r.GetHandoff(ctx, h.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("start handoff: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MySQLRepository) GetHandoff(ctx context.Context, userID string) (*Handoff, error) {
+	q := `SELECT ` + handoffColumns + ` FROM operator_handoffs WHERE user_id = ?`
+	h, err := scanHandoff(r.db.QueryRowContext(ctx, q, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("handoff %s: %w", userID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get handoff: %w", err)
+	}
+	return h, nil
+}
+
+func (r *MySQLRepository) ListHandoffs(ctx context.Context) ([]Handoff, error) {
+	q := `SELECT ` + handoffColumns + ` FROM operator_handoffs ORDER BY started_at ASC`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list handoffs: %w", err)
+	}
+	defer rows.Close()
+
+	var res []Handoff
+	for rows.Next() {
+		h, err := scanHandoff(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan handoff: %w", err)
+		}
+		res = append(res, *h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate handoffs: %w", err)
+	}
+	return res, nil
+}
+
+func (r *MySQLRepository) EndHandoff(ctx context.Context, userID string) error {
+	ct, err := r.db.ExecContext(ctx, `DELETE FROM operator_handoffs WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("end handoff: %w", err)
+	}
+	if n, _ := ct.RowsAffected(); n == 0 {
+		return fmt.Errorf("handoff %s: %w", userID, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Conversation states --
+
+func (r *MySQLRepository) SaveConversationState(ctx context.Context, s ConversationState) error {
+	const q = `
+INSERT INTO conversation_states (user_id, state, expires_at, updated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP(6))
+ON DUPLICATE KEY UPDATE
+    state = VALUES(state),
+    expires_at = VALUES(expires_at),
+    updated_at = CURRENT_TIMESTAMP(6)`
+	if _, err := r.db.ExecContext(ctx, q, s.UserID, string(s.State), s.ExpiresAt.UTC()); err != nil {
+		return fmt.Errorf("save conversation state: %w", err)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) GetConversationState(ctx context.Context, userID string) (*ConversationState, error) {
+	q := `SELECT ` + conversationStateColumns + ` FROM conversation_states WHERE user_id = ? AND expires_at > ?`
+	s, err := scanConversationState(r.db.QueryRowContext(ctx, q, userID, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("conversation state %s: %w", userID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get conversation state: %w", err)
+	}
+	return s, nil
+}
+
+func (r *MySQLRepository) DeleteConversationState(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM conversation_states WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("delete conversation state: %w", err)
+	}
+	return nil
+}
+
+// -- Scheduled orders --
+
+func (r *MySQLRepository) InsertScheduledOrder(ctx context.Context, s ScheduledOrder) (*ScheduledOrder, error) {
+	const q = `
+INSERT INTO scheduled_orders (id, user_id, chat_jid, product_code, product_name, product_type, customer_id, customer_zone, frequency, day, next_run_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, s.UserID, s.ChatJID, s.ProductCode, s.ProductName, s.ProductType, s.CustomerID, s.CustomerZone, s.Frequency, s.Day, s.NextRunAt.UTC()); err != nil {
+		return nil, fmt.Errorf("insert scheduled order: %w", err)
+	}
+	created, err := scanScheduledOrder(r.db.QueryRowContext(ctx, `SELECT `+scheduledOrderColumns+` FROM scheduled_orders WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert scheduled order: %w", err)
+	}
+	return created, nil
+}
+
+func (r *MySQLRepository) ListScheduledOrders(ctx context.Context, userID string) ([]ScheduledOrder, error) {
+	q := `SELECT ` + scheduledOrderColumns + ` FROM scheduled_orders WHERE user_id = ? AND status = 'active' ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled orders: %w", err)
+	}
+	defer rows.Close()
+	return scanScheduledOrders(rows)
+}
+
+func (r *MySQLRepository) CancelScheduledOrder(ctx context.Context, userID, id string) error {
+	const q = `
+UPDATE scheduled_orders
+SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ? AND user_id = ? AND status = 'active'`
+	res, err := r.db.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("cancel scheduled order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("cancel scheduled order: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("scheduled order %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) ListDueScheduledOrders(ctx context.Context, now time.Time, limit int) ([]ScheduledOrder, error) {
+	q := `SELECT ` + scheduledOrderColumns + ` FROM scheduled_orders WHERE status = 'active' AND next_run_at <= ? ORDER BY next_run_at, id LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, q, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list due scheduled orders: %w", err)
+	}
+	defer rows.Close()
+	return scanScheduledOrders(rows)
+}
+
+func (r *MySQLRepository) AdvanceScheduledOrder(ctx context.Context, id string, from, next time.Time, orderRef string) error {
+	const q = `
+UPDATE scheduled_orders
+SET next_run_at = ?,
+    reminded_at = NULL,
+    last_run_at = CASE WHEN ? = '' THEN last_run_at ELSE CURRENT_TIMESTAMP(6) END,
+    last_order_ref = COALESCE(NULLIF(?, ''), last_order_ref),
+    updated_at = CURRENT_TIMESTAMP(6)
+WHERE id = ? AND next_run_at = ? AND status = 'active'`
+	res, err := r.db.ExecContext(ctx, q, next.UTC(), orderRef, orderRef, id, from.UTC())
+	if err != nil {
+		return fmt.Errorf("advance scheduled order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("advance scheduled order: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("scheduled order %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MarkScheduledOrderReminded(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE scheduled_orders SET reminded_at = ?, updated_at = CURRENT_TIMESTAMP(6) WHERE id = ?`, at.UTC(), id); err != nil {
+		return fmt.Errorf("mark scheduled order reminded: %w", err)
+	}
+	return nil
+}
+
+// -- Product alerts --
+
+func (r *MySQLRepository) InsertProductAlert(ctx context.Context, a ProductAlert) (*ProductAlert, error) {
+	const q = `
+INSERT INTO product_alerts (id, user_id, chat_jid, product_code, product_name, product_type, kind, baseline_price, target_price)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+`
+	id := randomUUID()
+	if _, err := r.db.ExecContext(ctx, q, id, a.UserID, a.ChatJID, a.ProductCode, a.ProductName, a.ProductType, a.Kind, a.BaselinePrice, a.TargetPrice); err != nil {
+		return nil, fmt.Errorf("insert product alert: %w", err)
+	}
+	created, err := scanProductAlert(r.db.QueryRowContext(ctx, `SELECT `+productAlertColumns+` FROM product_alerts WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("insert product alert: %w", err)
+	}
+	return created, nil
+}
+
+func (r *MySQLRepository) ListProductAlerts(ctx context.Context, userID string) ([]ProductAlert, error) {
+	q := `SELECT ` + productAlertColumns + ` FROM product_alerts WHERE user_id = ? AND status = 'active' ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list product alerts: %w", err)
+	}
+	defer rows.Close()
+	return scanProductAlerts(rows)
+}
+
+func (r *MySQLRepository) ListActiveProductAlerts(ctx context.Context) ([]ProductAlert, error) {
+	q := `SELECT ` + productAlertColumns + ` FROM product_alerts WHERE status = 'active' ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list active product alerts: %w", err)
+	}
+	defer rows.Close()
+	return scanProductAlerts(rows)
+}
+
+func (r *MySQLRepository) CancelProductAlert(ctx context.Context, userID, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE product_alerts SET status = 'cancelled' WHERE id = ? AND user_id = ? AND status = 'active'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("cancel product alert: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("cancel product alert: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("product alert %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MarkProductAlertNotified(ctx context.Context, id string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE product_alerts SET status = 'notified', notified_at = ? WHERE id = ? AND status = 'active'`, at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("mark product alert notified: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("mark product alert notified: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("product alert %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// -- Helpers --
+
+const mysqlUserColumns = "id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at"
+
+// key is a reserved word in MySQL, so these tables quote the column.
+const (
+	mysqlCuratedProductColumns = "id, scope, `key`, hidden, reason, instructions, created_at, updated_at"
+	mysqlPricingRuleColumns    = "id, scope, `key`, margin_fixed, margin_percent, round_to, created_at, updated_at"
+)
+
+func mysqlPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// mysqlUpdate describes an UPDATE whose changed rows the caller needs back.
+// pick is the WHERE clause choosing rows, optionally with ORDER BY and LIMIT.
+type mysqlUpdate struct {
+	table    string
+	key      string
+	columns  string
+	pick     string
+	pickArgs []any
+	set      string
+	setArgs  []any
+}
+
+// mysqlUpdateReturning stands in for UPDATE ... RETURNING, which MySQL
+// lacks. It locks the picked rows, updates them by key and reads them back
+// in one transaction, so concurrent callers never claim the same row.
+func mysqlUpdateReturning[T any](ctx context.Context, db *sql.DB, u mysqlUpdate, collect func(*sql.Rows) ([]T, error)) ([]T, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+u.key+" FROM "+u.table+" WHERE "+u.pick+" FOR UPDATE", u.pickArgs...)
+	if err != nil {
+		return nil, err
+	}
+	var keys []any
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	match := u.key + " IN (" + mysqlPlaceholders(len(keys)) + ")"
+	args := append(append([]any{}, u.setArgs...), keys...)
+	if _, err := tx.ExecContext(ctx, "UPDATE "+u.table+" SET "+u.set+" WHERE "+match, args...); err != nil {
+		return nil, err
+	}
+	rows, err = tx.QueryContext(ctx, "SELECT "+u.columns+" FROM "+u.table+" WHERE "+match, keys...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := collect(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	return res, tx.Commit()
+}
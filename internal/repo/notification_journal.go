@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const notificationColumns = `id, user_id, chat_jid, kind, ref_id, body, status, attempts, last_error, next_attempt_at, delivered_at, created_at, updated_at`
+
+type notificationRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func scanNotification(row interface{ Scan(dest ...any) error }) (*Notification, error) {
+	var n Notification
+	if err := row.Scan(&n.ID, &n.UserID, &n.ChatJID, &n.Kind, &n.RefID, &n.Body, &n.Status, &n.Attempts, &n.LastError, &n.NextAttemptAt, &n.DeliveredAt, &n.CreatedAt, &n.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func collectNotifications(rows notificationRows) ([]Notification, error) {
+	var res []Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		res = append(res, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate notifications: %w", err)
+	}
+	return res, nil
+}
+
+// InsertNotification journals a notification before it is sent. Its first
+// attempt is due at n.NextAttemptAt, or now when unset.
+func (r *PostgresRepository) InsertNotification(ctx context.Context, n Notification) (*Notification, error) {
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = time.Now()
+	}
+	q := `
+INSERT INTO notification_journal (user_id, chat_jid, kind, ref_id, body, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING ` + notificationColumns + `;`
+	saved, err := scanNotification(r.pool.QueryRow(ctx, q, n.UserID, n.ChatJID, n.Kind, n.RefID, n.Body, n.NextAttemptAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert notification: %w", err)
+	}
+	return saved, nil
+}
+
+// ClaimDueNotifications returns pending notifications due at or before now
+// and pushes their next attempt back by lease.
+func (r *PostgresRepository) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	q := `
+UPDATE notification_journal
+SET next_attempt_at = $2,
+    updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM notification_journal
+    WHERE status = 'pending' AND next_attempt_at <= $1
+    ORDER BY next_attempt_at ASC
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + notificationColumns + `;`
+	rows, err := r.pool.Query(ctx, q, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim notifications: %w", err)
+	}
+	defer rows.Close()
+	return collectNotifications(rows)
+}
+
+// UpdateNotification records the outcome of a send attempt.
+func (r *PostgresRepository) UpdateNotification(ctx context.Context, n Notification) error {
+	const q = `
+UPDATE notification_journal
+SET status = $2,
+    attempts = $3,
+    last_error = $4,
+    next_attempt_at = $5,
+    delivered_at = $6,
+    updated_at = NOW()
+WHERE id = $1;
+`
+	ct, err := r.pool.Exec(ctx, q, n.ID, n.Status, n.Attempts, n.LastError, n.NextAttemptAt, n.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("update notification: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("notification %s: %w", n.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// ListUndeliveredNotifications returns pending and dead notifications, newest first.
+func (r *PostgresRepository) ListUndeliveredNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + notificationColumns + `
+FROM notification_journal
+WHERE status <> 'delivered'
+ORDER BY created_at DESC
+LIMIT $1;`
+	rows, err := r.pool.Query(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list undelivered notifications: %w", err)
+	}
+	defer rows.Close()
+	return collectNotifications(rows)
+}
+
+// RequeueNotification makes an undelivered notification due now with its
+// attempts reset.
+func (r *PostgresRepository) RequeueNotification(ctx context.Context, id string) error {
+	const q = `
+UPDATE notification_journal
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status <> 'delivered';
+`
+	ct, err := r.pool.Exec(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("requeue notification: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("undelivered notification %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
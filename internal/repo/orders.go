@@ -3,30 +3,60 @@ package repo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
+const insertOrderSQL = `
+INSERT INTO orders (user_id, order_ref, product_code, amount, fee, status, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at;
+`
+
 // InsertOrder stores a new order record.
 func (r *PostgresRepository) InsertOrder(ctx context.Context, order Order) (*Order, error) {
-	meta, err := toJSON(order.Metadata)
+	return insertOrder(ctx, r.pool, order)
+}
+
+// InsertOrders stores orders in one transaction.
+func (r *PostgresRepository) InsertOrders(ctx context.Context, orders []Order) ([]Order, error) {
+	inserted := make([]Order, 0, len(orders))
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, order := range orders {
+			o, err := insertOrder(ctx, tx, order)
+			if err != nil {
+				return fmt.Errorf("order %s: %w", order.OrderRef, err)
+			}
+			inserted = append(inserted, *o)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	metaParam := jsonParam(meta)
+	return inserted, nil
+}
 
-	const q = `
-INSERT INTO orders (user_id, order_ref, product_code, amount, fee, status, metadata)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at;
-`
-	row := r.pool.QueryRow(ctx, q,
+type orderInserter interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertOrder(ctx context.Context, db orderInserter, order Order) (*Order, error) {
+	meta, err := toJSON(order.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRow(ctx, insertOrderSQL,
 		order.UserID,
 		order.OrderRef,
 		order.ProductCode,
 		order.Amount,
 		order.Fee,
 		order.Status,
-		metaParam,
+		jsonParam(meta),
 	)
 
 	var inserted Order
@@ -59,6 +89,26 @@ WHERE order_ref = $1;
 	return nil
 }
 
+// MarkOrderSucceeded sets an order to success unless it already succeeded.
+func (r *PostgresRepository) MarkOrderSucceeded(ctx context.Context, orderRef string, metadata map[string]any) (bool, error) {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return false, err
+	}
+	const q = `
+UPDATE orders
+SET status = 'success',
+    metadata = COALESCE($2, metadata),
+    updated_at = NOW()
+WHERE order_ref = $1 AND status <> 'success';
+`
+	ct, err := r.pool.Exec(ctx, q, orderRef, jsonParam(meta))
+	if err != nil {
+		return false, fmt.Errorf("mark order succeeded: %w", err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
 // GetOrderByRef retrieves an order by reference.
 func (r *PostgresRepository) GetOrderByRef(ctx context.Context, ref string) (*Order, error) {
 	const q = `
@@ -71,6 +121,9 @@ LIMIT 1;
 	var order Order
 	var metaJSON []byte
 	if err := row.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("order %s: %w", ref, ErrNotFound)
+		}
 		return nil, fmt.Errorf("get order by ref: %w", err)
 	}
 	order.Metadata = fromJSON(metaJSON)
@@ -151,6 +204,72 @@ LIMIT 1;
 	return &dep, nil
 }
 
+// ListRecentDeposits returns the latest deposits of every user, newest first.
+func (r *PostgresRepository) ListRecentDeposits(ctx context.Context, limit int) ([]Deposit, error) {
+	const q = `
+SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at
+FROM deposits
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
+`
+	rows, err := r.pool.Query(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent deposits: %w", err)
+	}
+	defer rows.Close()
+
+	var deposits []Deposit
+	for rows.Next() {
+		var dep Deposit
+		var metaJSON []byte
+		if err := rows.Scan(&dep.ID, &dep.UserID, &dep.DepositRef, &dep.Method, &dep.Amount, &dep.Status, &metaJSON, &dep.CreatedAt, &dep.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan recent deposit: %w", err)
+		}
+		dep.Metadata = fromJSON(metaJSON)
+		deposits = append(deposits, dep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent deposits: %w", err)
+	}
+	return deposits, nil
+}
+
+// ListUnpaidDeposits returns deposits created between createdAfter and
+// createdBefore that are still waiting for payment, oldest first. That
+// includes deposits stored as success while the provider still reported
+// them pending.
+func (r *PostgresRepository) ListUnpaidDeposits(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Deposit, error) {
+	const q = `
+SELECT id, user_id, deposit_ref, method, amount, status, metadata, created_at, updated_at
+FROM deposits
+WHERE (status = 'pending' OR (status = 'success' AND metadata->>'forced_success' = 'true'))
+  AND created_at > $1
+  AND created_at < $2
+ORDER BY created_at ASC
+LIMIT $3;
+`
+	rows, err := r.pool.Query(ctx, q, createdAfter, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unpaid deposits: %w", err)
+	}
+	defer rows.Close()
+
+	var deposits []Deposit
+	for rows.Next() {
+		var dep Deposit
+		var metaJSON []byte
+		if err := rows.Scan(&dep.ID, &dep.UserID, &dep.DepositRef, &dep.Method, &dep.Amount, &dep.Status, &metaJSON, &dep.CreatedAt, &dep.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan unpaid deposit: %w", err)
+		}
+		dep.Metadata = fromJSON(metaJSON)
+		deposits = append(deposits, dep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate unpaid deposits: %w", err)
+	}
+	return deposits, nil
+}
+
 // ListOrdersAwaitingDeposit returns orders waiting for the specified deposit.
 func (r *PostgresRepository) ListOrdersAwaitingDeposit(ctx context.Context, depositRef string) ([]Order, error) {
 	const q = `
@@ -182,6 +301,169 @@ ORDER BY created_at ASC;
 	return orders, nil
 }
 
+// ListOrdersByUser pages through a user's orders, newest first.
+func (r *PostgresRepository) ListOrdersByUser(ctx context.Context, userID string, limit, offset int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+`
+	rows, err := r.pool.Query(ctx, q, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list orders by user: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan user order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user orders: %w", err)
+	}
+	return orders, nil
+}
+
+// ListRecentOrders returns the latest orders of every user, newest first.
+func (r *PostgresRepository) ListRecentOrders(ctx context.Context, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
+`
+	rows, err := r.pool.Query(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan recent order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recent orders: %w", err)
+	}
+	return orders, nil
+}
+
+// CountSuccessfulOrders returns how many of a user's orders succeeded.
+func (r *PostgresRepository) CountSuccessfulOrders(ctx context.Context, userID string) (int, error) {
+	const q = `SELECT COUNT(*) FROM orders WHERE user_id = $1 AND status = 'success';`
+	var count int
+	if err := r.pool.QueryRow(ctx, q, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count successful orders: %w", err)
+	}
+	return count, nil
+}
+
+// ListStuckOrders returns processing orders past their SLA that nobody has escalated yet.
+func (r *PostgresRepository) ListStuckOrders(ctx context.Context, createdBefore time.Time, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE status IN ('pending', 'processing', 'process')
+  AND created_at < $1
+  AND sla_escalated_at IS NULL
+ORDER BY created_at ASC
+LIMIT $2;
+`
+	rows, err := r.pool.Query(ctx, q, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stuck orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan stuck order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stuck orders: %w", err)
+	}
+	return orders, nil
+}
+
+// MarkOrderEscalated records that a stuck order was escalated so it is not escalated again.
+func (r *PostgresRepository) MarkOrderEscalated(ctx context.Context, orderRef string, at time.Time) error {
+	const q = `UPDATE orders SET sla_escalated_at = $2 WHERE order_ref = $1`
+	ct, err := r.pool.Exec(ctx, q, orderRef, at)
+	if err != nil {
+		return fmt.Errorf("mark order escalated: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("order %s: %w", orderRef, ErrNotFound)
+	}
+	return nil
+}
+
+// ListPendingOrders returns unsettled orders in the given creation window, least recently polled first.
+func (r *PostgresRepository) ListPendingOrders(ctx context.Context, createdAfter, createdBefore time.Time, limit int) ([]Order, error) {
+	const q = `
+SELECT id, user_id, order_ref, product_code, amount, fee, status, metadata, created_at, updated_at
+FROM orders
+WHERE status IN ('pending', 'processing', 'process')
+  AND created_at > $1
+  AND created_at < $2
+ORDER BY status_polled_at ASC NULLS FIRST, created_at ASC
+LIMIT $3;
+`
+	rows, err := r.pool.Query(ctx, q, createdAfter, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		var metaJSON []byte
+		if err := rows.Scan(&order.ID, &order.UserID, &order.OrderRef, &order.ProductCode, &order.Amount, &order.Fee, &order.Status, &metaJSON, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan pending order: %w", err)
+		}
+		order.Metadata = fromJSON(metaJSON)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending orders: %w", err)
+	}
+	return orders, nil
+}
+
+// MarkOrderPolled records when the pending-order poller last checked an order.
+func (r *PostgresRepository) MarkOrderPolled(ctx context.Context, orderRef string, at time.Time) error {
+	const q = `UPDATE orders SET status_polled_at = $2 WHERE order_ref = $1`
+	ct, err := r.pool.Exec(ctx, q, orderRef, at)
+	if err != nil {
+		return fmt.Errorf("mark order polled: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("order %s: %w", orderRef, ErrNotFound)
+	}
+	return nil
+}
+
 func toJSON(val map[string]any) ([]byte, error) {
 	if val == nil {
 		return nil, nil
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const outboundMessageColumns = `wa_message_id, chat_jid, body, status, attempts, sent_at, delivered_at, read_at`
+
+type outboundRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func scanOutboundMessages(rows outboundRows) ([]OutboundMessage, error) {
+	var res []OutboundMessage
+	for rows.Next() {
+		var m OutboundMessage
+		if err := rows.Scan(&m.ID, &m.ChatJID, &m.Body, &m.Status, &m.Attempts, &m.SentAt, &m.DeliveredAt, &m.ReadAt); err != nil {
+			return nil, fmt.Errorf("scan outbound message: %w", err)
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbound messages: %w", err)
+	}
+	return res, nil
+}
+
+// InsertOutboundMessage records a sent text message awaiting receipts.
+func (r *PostgresRepository) InsertOutboundMessage(ctx context.Context, msg OutboundMessage) error {
+	const q = `
+INSERT INTO outbound_messages (wa_message_id, chat_jid, body, status, attempts)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (wa_message_id) DO NOTHING;
+`
+	status := msg.Status
+	if status == "" {
+		status = OutboundSent
+	}
+	if _, err := r.pool.Exec(ctx, q, msg.ID, msg.ChatJID, msg.Body, status, msg.Attempts); err != nil {
+		return fmt.Errorf("insert outbound message: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboundDelivered stamps the delivery time of messages not yet marked
+// delivered and returns the ones it changed.
+func (r *PostgresRepository) MarkOutboundDelivered(ctx context.Context, ids []string, at time.Time) ([]OutboundMessage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []any{at}
+	q := `
+UPDATE outbound_messages
+SET delivered_at = $1,
+    status = CASE WHEN status IN ('sent', 'resent', 'undelivered') THEN 'delivered' ELSE status END
+WHERE delivered_at IS NULL
+  AND wa_message_id IN (` + pgPlaceholders(&args, ids) + `)
+RETURNING ` + outboundMessageColumns + `;`
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("mark outbound delivered: %w", err)
+	}
+	defer rows.Close()
+	return scanOutboundMessages(rows)
+}
+
+// MarkOutboundRead stamps the read time of messages, implying delivery, and
+// returns how many were newly read.
+func (r *PostgresRepository) MarkOutboundRead(ctx context.Context, ids []string, at time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []any{at}
+	q := `
+UPDATE outbound_messages
+SET read_at = $1,
+    delivered_at = COALESCE(delivered_at, $1),
+    status = 'read'
+WHERE read_at IS NULL
+  AND wa_message_id IN (` + pgPlaceholders(&args, ids) + `);`
+	ct, err := r.pool.Exec(ctx, q, args...)
+	if err != nil {
+		return 0, fmt.Errorf("mark outbound read: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
+
+// ListUndeliveredOutbound returns messages still without a delivery receipt
+// that were sent before sentBefore, oldest first.
+func (r *PostgresRepository) ListUndeliveredOutbound(ctx context.Context, sentBefore time.Time, limit int) ([]OutboundMessage, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + outboundMessageColumns + `
+FROM outbound_messages
+WHERE status = 'sent' AND sent_at < $1
+ORDER BY sent_at
+LIMIT $2;`
+	rows, err := r.pool.Query(ctx, q, sentBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list undelivered outbound: %w", err)
+	}
+	defer rows.Close()
+	return scanOutboundMessages(rows)
+}
+
+// SetOutboundStatus records that a message was resent or given up on.
+func (r *PostgresRepository) SetOutboundStatus(ctx context.Context, id, status string) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE outbound_messages SET status = $2 WHERE wa_message_id = $1`, id, status); err != nil {
+		return fmt.Errorf("set outbound status: %w", err)
+	}
+	return nil
+}
+
+// pgPlaceholders appends values to args and returns their numbered placeholders.
+func pgPlaceholders(args *[]any, values []string) string {
+	placeholders := make([]string, 0, len(values))
+	for _, v := range values {
+		*args = append(*args, v)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(*args)))
+	}
+	return strings.Join(placeholders, ", ")
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const pricingRuleColumns = `id, scope, key, margin_fixed, margin_percent, round_to, created_at, updated_at`
+
+// ListPricingRules returns every pricing rule.
+func (r *PostgresRepository) ListPricingRules(ctx context.Context) ([]PricingRule, error) {
+	q := `SELECT ` + pricingRuleColumns + ` FROM pricing_rules ORDER BY scope ASC, key ASC;`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	var res []PricingRule
+	for rows.Next() {
+		var rule PricingRule
+		if err := rows.Scan(&rule.ID, &rule.Scope, &rule.Key, &rule.MarginFixed, &rule.MarginPercent, &rule.RoundTo, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan pricing rule: %w", err)
+		}
+		res = append(res, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pricing rules: %w", err)
+	}
+	return res, nil
+}
+
+// UpsertPricingRule creates or replaces the rule for a scope and key.
+func (r *PostgresRepository) UpsertPricingRule(ctx context.Context, rule PricingRule) (*PricingRule, error) {
+	q := `
+INSERT INTO pricing_rules (scope, key, margin_fixed, margin_percent, round_to)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (scope, key) DO UPDATE
+SET margin_fixed = EXCLUDED.margin_fixed,
+    margin_percent = EXCLUDED.margin_percent,
+    round_to = EXCLUDED.round_to,
+    updated_at = NOW()
+RETURNING ` + pricingRuleColumns + `;`
+	var saved PricingRule
+	if err := r.pool.QueryRow(ctx, q, rule.Scope, rule.Key, rule.MarginFixed, rule.MarginPercent, rule.RoundTo).Scan(&saved.ID, &saved.Scope, &saved.Key, &saved.MarginFixed, &saved.MarginPercent, &saved.RoundTo, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert pricing rule: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeletePricingRule removes a pricing rule.
+func (r *PostgresRepository) DeletePricingRule(ctx context.Context, scope, key string) error {
+	const q = `DELETE FROM pricing_rules WHERE scope = $1 AND key = $2`
+	ct, err := r.pool.Exec(ctx, q, scope, key)
+	if err != nil {
+		return fmt.Errorf("delete pricing rule: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("pricing rule %s/%s: %w", scope, key, ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const productAlertColumns = `id, user_id, chat_jid, product_code, product_name, product_type, kind, baseline_price, target_price, status, created_at, notified_at`
+
+func scanProductAlert(row interface{ Scan(dest ...any) error }) (*ProductAlert, error) {
+	var a ProductAlert
+	if err := row.Scan(&a.ID, &a.UserID, &a.ChatJID, &a.ProductCode, &a.ProductName, &a.ProductType, &a.Kind,
+		&a.BaselinePrice, &a.TargetPrice, &a.Status, &a.CreatedAt, &a.NotifiedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanProductAlerts(rows outboundRows) ([]ProductAlert, error) {
+	var res []ProductAlert
+	for rows.Next() {
+		a, err := scanProductAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan product alert: %w", err)
+		}
+		res = append(res, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product alerts: %w", err)
+	}
+	return res, nil
+}
+
+// InsertProductAlert stores a new active alert.
+func (r *PostgresRepository) InsertProductAlert(ctx context.Context, a ProductAlert) (*ProductAlert, error) {
+	const q = `
+INSERT INTO product_alerts (user_id, chat_jid, product_code, product_name, product_type, kind, baseline_price, target_price)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING ` + productAlertColumns + `;`
+	created, err := scanProductAlert(r.pool.QueryRow(ctx, q, a.UserID, a.ChatJID, a.ProductCode, a.ProductName, a.ProductType, a.Kind, a.BaselinePrice, a.TargetPrice))
+	if err != nil {
+		return nil, fmt.Errorf("insert product alert: %w", err)
+	}
+	return created, nil
+}
+
+// ListProductAlerts returns the user's active alerts, oldest first.
+func (r *PostgresRepository) ListProductAlerts(ctx context.Context, userID string) ([]ProductAlert, error) {
+	q := `SELECT ` + productAlertColumns + ` FROM product_alerts WHERE user_id = $1 AND status = 'active' ORDER BY created_at, id`
+	rows, err := r.pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list product alerts: %w", err)
+	}
+	defer rows.Close()
+	return scanProductAlerts(rows)
+}
+
+// ListActiveProductAlerts returns every active alert, oldest first.
+func (r *PostgresRepository) ListActiveProductAlerts(ctx context.Context) ([]ProductAlert, error) {
+	q := `SELECT ` + productAlertColumns + ` FROM product_alerts WHERE status = 'active' ORDER BY created_at, id`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list active product alerts: %w", err)
+	}
+	defer rows.Close()
+	return scanProductAlerts(rows)
+}
+
+// CancelProductAlert stops one of the user's active alerts.
+func (r *PostgresRepository) CancelProductAlert(ctx context.Context, userID, id string) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE product_alerts SET status = 'cancelled' WHERE id = $1 AND user_id = $2 AND status = 'active'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("cancel product alert: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("product alert %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// MarkProductAlertNotified closes an active alert once its user was told.
+func (r *PostgresRepository) MarkProductAlertNotified(ctx context.Context, id string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE product_alerts SET status = 'notified', notified_at = $2 WHERE id = $1 AND status = 'active'`, id, at)
+	if err != nil {
+		return fmt.Errorf("mark product alert notified: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("product alert %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
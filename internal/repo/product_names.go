@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const productNameRuleColumns = `id, scope, target, name, created_at, updated_at`
+
+// ListProductNameRules returns every product name rule, oldest first within
+// each scope so pattern rules apply in the order they were added.
+func (r *PostgresRepository) ListProductNameRules(ctx context.Context) ([]ProductNameRule, error) {
+	q := `SELECT ` + productNameRuleColumns + ` FROM product_name_rules ORDER BY scope ASC, created_at ASC, id ASC;`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list product name rules: %w", err)
+	}
+	defer rows.Close()
+
+	var res []ProductNameRule
+	for rows.Next() {
+		var rule ProductNameRule
+		if err := rows.Scan(&rule.ID, &rule.Scope, &rule.Target, &rule.Name, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan product name rule: %w", err)
+		}
+		res = append(res, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product name rules: %w", err)
+	}
+	return res, nil
+}
+
+// UpsertProductNameRule creates or replaces the rule for a scope and target.
+// A replaced pattern keeps its place in the order.
+func (r *PostgresRepository) UpsertProductNameRule(ctx context.Context, rule ProductNameRule) (*ProductNameRule, error) {
+	q := `
+INSERT INTO product_name_rules (scope, target, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (scope, target) DO UPDATE
+SET name = EXCLUDED.name,
+    updated_at = NOW()
+RETURNING ` + productNameRuleColumns + `;`
+	var saved ProductNameRule
+	if err := r.pool.QueryRow(ctx, q, rule.Scope, rule.Target, rule.Name).Scan(&saved.ID, &saved.Scope, &saved.Target, &saved.Name, &saved.CreatedAt, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert product name rule: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteProductNameRule removes a product name rule.
+func (r *PostgresRepository) DeleteProductNameRule(ctx context.Context, scope, target string) error {
+	const q = `DELETE FROM product_name_rules WHERE scope = $1 AND target = $2`
+	ct, err := r.pool.Exec(ctx, q, scope, target)
+	if err != nil {
+		return fmt.Errorf("delete product name rule: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("product name rule %s/%s: %w", scope, target, ErrNotFound)
+	}
+	return nil
+}
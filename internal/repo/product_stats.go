@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordProductOutcome increments the success or failure counter of a product and returns the updated row.
+func (r *PostgresRepository) RecordProductOutcome(ctx context.Context, productCode, status string, success bool) (*ProductStat, error) {
+	successInc, failureInc := 0, 1
+	if success {
+		successInc, failureInc = 1, 0
+	}
+	const q = `
+INSERT INTO product_stats (product_code, success_count, failure_count, last_status, last_outcome_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (product_code) DO UPDATE
+SET success_count = product_stats.success_count + EXCLUDED.success_count,
+    failure_count = product_stats.failure_count + EXCLUDED.failure_count,
+    last_status = EXCLUDED.last_status,
+    last_outcome_at = EXCLUDED.last_outcome_at,
+    updated_at = NOW()
+RETURNING product_code, success_count, failure_count, last_status, last_outcome_at, created_at, updated_at;
+`
+	var st ProductStat
+	if err := r.pool.QueryRow(ctx, q, productCode, successInc, failureInc, status).Scan(&st.ProductCode, &st.SuccessCount, &st.FailureCount, &st.LastStatus, &st.LastOutcomeAt, &st.CreatedAt, &st.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("record product outcome: %w", err)
+	}
+	return &st, nil
+}
+
+// ListProductStats returns outcome counters for every product seen so far.
+func (r *PostgresRepository) ListProductStats(ctx context.Context) ([]ProductStat, error) {
+	const q = `
+SELECT product_code, success_count, failure_count, last_status, last_outcome_at, created_at, updated_at
+FROM product_stats
+ORDER BY product_code ASC;
+`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list product stats: %w", err)
+	}
+	defer rows.Close()
+
+	var res []ProductStat
+	for rows.Next() {
+		var st ProductStat
+		if err := rows.Scan(&st.ProductCode, &st.SuccessCount, &st.FailureCount, &st.LastStatus, &st.LastOutcomeAt, &st.CreatedAt, &st.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan product stat: %w", err)
+		}
+		res = append(res, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product stats: %w", err)
+	}
+	return res, nil
+}
@@ -83,7 +83,7 @@ ON CONFLICT (wa_id) DO UPDATE SET
     language_preference = COALESCE(EXCLUDED.language_preference, users.language_preference),
     timezone = COALESCE(EXCLUDED.timezone, users.timezone),
     updated_at = NOW()
-RETURNING id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, created_at, updated_at;
+RETURNING id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at;
 `
 	row := r.pool.QueryRow(ctx, q,
 		profile.WAID,
@@ -95,7 +95,7 @@ RETURNING id, wa_id, wa_jid, display_name, phone_number, language_preference, ti
 	)
 
 	var u User
-	if err := row.Scan(&u.ID, &u.WAID, &u.WAJID, &u.DisplayName, &u.PhoneNumber, &u.LanguagePreference, &u.Timezone, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.WAID, &u.WAJID, &u.DisplayName, &u.PhoneNumber, &u.LanguagePreference, &u.Timezone, &u.CurrencyLocale, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		return nil, fmt.Errorf("upsert user: %w", err)
 	}
 	return &u, nil
@@ -154,6 +154,35 @@ LIMIT $2;
 	return records, nil
 }
 
+// ListMessages returns a page of the user's messages, newest first.
+func (r *PostgresRepository) ListMessages(ctx context.Context, userID string, limit, offset int) ([]MessageRecord, error) {
+	const q = `
+SELECT id, direction, message_type, content, media_url, created_at
+FROM messages
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+`
+	rows, err := r.pool.Query(ctx, q, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MessageRecord
+	for rows.Next() {
+		msg := MessageRecord{UserID: userID}
+		if err := rows.Scan(&msg.ID, &msg.Direction, &msg.Type, &msg.Content, &msg.MediaURL, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		records = append(records, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+	return records, nil
+}
+
 // RunMigrations applies schema migrations on the connected database.
 func (r *PostgresRepository) RunMigrations(ctx context.Context, filesystem fs.FS) error {
 	return ApplyMigrations(ctx, r.pool, filesystem)
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SalesSummary totals orders and deposits created in [from, to). Supplier
+// prices come from the supplier_price order metadata the webhook records.
+func (r *PostgresRepository) SalesSummary(ctx context.Context, from, to time.Time) (*SalesSummary, error) {
+	const ordersQ = `
+SELECT COUNT(*),
+       COUNT(*) FILTER (WHERE status = 'success'),
+       COUNT(*) FILTER (WHERE status = 'failed'),
+       COALESCE(SUM(amount) FILTER (WHERE status = 'success'), 0),
+       COUNT(*) FILTER (WHERE status = 'success' AND metadata->>'supplier_price' IS NOT NULL),
+       COALESCE(SUM(amount) FILTER (WHERE status = 'success' AND metadata->>'supplier_price' IS NOT NULL), 0),
+       COALESCE(SUM((metadata->>'supplier_price')::numeric) FILTER (WHERE status = 'success'), 0)::bigint
+FROM orders
+WHERE created_at >= $1 AND created_at < $2;
+`
+	const depositsQ = `
+SELECT COUNT(*),
+       COUNT(*) FILTER (WHERE status = 'success'),
+       COALESCE(SUM(amount) FILTER (WHERE status = 'success'), 0)
+FROM deposits
+WHERE created_at >= $1 AND created_at < $2;
+`
+	var s SalesSummary
+	if err := r.pool.QueryRow(ctx, ordersQ, from, to).Scan(&s.Orders, &s.OrdersSuccess, &s.OrdersFailed, &s.Revenue, &s.CostedOrders, &s.CostedRevenue, &s.Cost); err != nil {
+		return nil, fmt.Errorf("sales summary orders: %w", err)
+	}
+	if err := r.pool.QueryRow(ctx, depositsQ, from, to).Scan(&s.Deposits, &s.DepositsPaid, &s.DepositAmount); err != nil {
+		return nil, fmt.Errorf("sales summary deposits: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const scheduledOrderColumns = `id, user_id, chat_jid, product_code, product_name, product_type, customer_id, customer_zone, frequency, day, status, next_run_at, last_run_at, last_order_ref, reminded_at, created_at, updated_at`
+
+func scanScheduledOrder(row interface{ Scan(dest ...any) error }) (*ScheduledOrder, error) {
+	var s ScheduledOrder
+	if err := row.Scan(&s.ID, &s.UserID, &s.ChatJID, &s.ProductCode, &s.ProductName, &s.ProductType, &s.CustomerID, &s.CustomerZone,
+		&s.Frequency, &s.Day, &s.Status, &s.NextRunAt, &s.LastRunAt, &s.LastOrderRef, &s.RemindedAt, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanScheduledOrders(rows outboundRows) ([]ScheduledOrder, error) {
+	var res []ScheduledOrder
+	for rows.Next() {
+		s, err := scanScheduledOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scheduled order: %w", err)
+		}
+		res = append(res, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate scheduled orders: %w", err)
+	}
+	return res, nil
+}
+
+// InsertScheduledOrder stores a new active schedule.
+func (r *PostgresRepository) InsertScheduledOrder(ctx context.Context, s ScheduledOrder) (*ScheduledOrder, error) {
+	const q = `
+INSERT INTO scheduled_orders (user_id, chat_jid, product_code, product_name, product_type, customer_id, customer_zone, frequency, day, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING ` + scheduledOrderColumns + `;`
+	created, err := scanScheduledOrder(r.pool.QueryRow(ctx, q, s.UserID, s.ChatJID, s.ProductCode, s.ProductName, s.ProductType, s.CustomerID, s.CustomerZone, s.Frequency, s.Day, s.NextRunAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert scheduled order: %w", err)
+	}
+	return created, nil
+}
+
+// ListScheduledOrders returns the user's active schedules, oldest first.
+func (r *PostgresRepository) ListScheduledOrders(ctx context.Context, userID string) ([]ScheduledOrder, error) {
+	q := `SELECT ` + scheduledOrderColumns + ` FROM scheduled_orders WHERE user_id = $1 AND status = 'active' ORDER BY created_at, id`
+	rows, err := r.pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled orders: %w", err)
+	}
+	defer rows.Close()
+	return scanScheduledOrders(rows)
+}
+
+// CancelScheduledOrder stops one of the user's active schedules.
+func (r *PostgresRepository) CancelScheduledOrder(ctx context.Context, userID, id string) error {
+	const q = `
+UPDATE scheduled_orders
+SET status = 'cancelled', updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status = 'active'`
+	tag, err := r.pool.Exec(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("cancel scheduled order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("scheduled order %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ListDueScheduledOrders returns active schedules due at now, longest overdue first.
+func (r *PostgresRepository) ListDueScheduledOrders(ctx context.Context, now time.Time, limit int) ([]ScheduledOrder, error) {
+	q := `SELECT ` + scheduledOrderColumns + ` FROM scheduled_orders WHERE status = 'active' AND next_run_at <= $1 ORDER BY next_run_at, id LIMIT $2`
+	rows, err := r.pool.Query(ctx, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list due scheduled orders: %w", err)
+	}
+	defer rows.Close()
+	return scanScheduledOrders(rows)
+}
+
+// AdvanceScheduledOrder moves a schedule still due at from on to next.
+func (r *PostgresRepository) AdvanceScheduledOrder(ctx context.Context, id string, from, next time.Time, orderRef string) error {
+	const q = `
+UPDATE scheduled_orders
+SET next_run_at = $3,
+    reminded_at = NULL,
+    last_run_at = CASE WHEN $4::text = '' THEN last_run_at ELSE NOW() END,
+    last_order_ref = COALESCE(NULLIF($4::text, ''), last_order_ref),
+    updated_at = NOW()
+WHERE id = $1 AND next_run_at = $2 AND status = 'active'`
+	tag, err := r.pool.Exec(ctx, q, id, from, next, orderRef)
+	if err != nil {
+		return fmt.Errorf("advance scheduled order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("scheduled order %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// MarkScheduledOrderReminded records that the user was told their balance
+// is short for the schedule's current run.
+func (r *PostgresRepository) MarkScheduledOrderReminded(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE scheduled_orders SET reminded_at = $2, updated_at = NOW() WHERE id = $1`, id, at); err != nil {
+		return fmt.Errorf("mark scheduled order reminded: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const segmentUserColumns = `u.id, u.wa_id, u.wa_jid, u.display_name, u.phone_number, u.language_preference, u.timezone, u.currency_locale, u.created_at, u.updated_at`
+
+// segmentQuery accumulates WHERE conditions and their arguments for a user
+// segment. Placeholders and time arguments differ between Postgres and SQLite,
+// so both are supplied by the caller.
+type segmentQuery struct {
+	conds       []string
+	args        []any
+	placeholder func(n int) string
+	timeArg     func(t time.Time) any
+}
+
+func (q *segmentQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return q.placeholder(len(q.args))
+}
+
+// where appends a condition; every %s in cond is replaced by a placeholder for the matching arg.
+func (q *segmentQuery) where(cond string, args ...any) {
+	ph := make([]any, len(args))
+	for i, a := range args {
+		ph[i] = q.arg(a)
+	}
+	q.conds = append(q.conds, fmt.Sprintf(cond, ph...))
+}
+
+// applySegment adds the conditions selecting users in seg. Users are always
+// required to have a WhatsApp JID so the result can be messaged, and deleted
+// users are never included.
+func (q *segmentQuery) applySegment(seg Segment, now time.Time) {
+	q.conds = append(q.conds, "u.deleted_at IS NULL")
+	q.conds = append(q.conds, "u.wa_jid IS NOT NULL AND u.wa_jid <> ''")
+	if seg.ActiveWithinDays > 0 {
+		since := q.timeArg(now.AddDate(0, 0, -seg.ActiveWithinDays))
+		q.where("EXISTS (SELECT 1 FROM messages m WHERE m.user_id = u.id AND m.created_at >= %s)", since)
+	}
+	if seg.InactiveDays > 0 {
+		since := q.timeArg(now.AddDate(0, 0, -seg.InactiveDays))
+		q.where("NOT EXISTS (SELECT 1 FROM messages m WHERE m.user_id = u.id AND m.created_at >= %s)", since)
+		q.where("NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.created_at >= %s)", since)
+	}
+	if seg.MinSpent > 0 {
+		q.where("(SELECT COALESCE(SUM(o.amount), 0) FROM orders o WHERE o.user_id = u.id AND o.status = 'success') > %s", seg.MinSpent)
+	}
+	if seg.BoughtCategory != "" {
+		if len(seg.ProductCodes) == 0 {
+			// A category with no known products matches nobody rather than everybody.
+			q.conds = append(q.conds, "1 = 0")
+			return
+		}
+		ph := make([]string, 0, len(seg.ProductCodes))
+		for _, code := range seg.ProductCodes {
+			ph = append(ph, q.arg(strings.ToUpper(strings.TrimSpace(code))))
+		}
+		q.conds = append(q.conds, "EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.status = 'success' AND UPPER(o.product_code) IN ("+strings.Join(ph, ", ")+"))")
+	}
+}
+
+func (q *segmentQuery) sql() string {
+	return `SELECT ` + segmentUserColumns + `
+FROM users u
+WHERE ` + strings.Join(q.conds, "\n  AND ") + `
+ORDER BY u.created_at ASC;`
+}
+
+func newPostgresSegmentQuery() *segmentQuery {
+	return &segmentQuery{
+		placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		timeArg:     func(t time.Time) any { return t },
+	}
+}
+
+func newSQLiteSegmentQuery() *segmentQuery {
+	return &segmentQuery{
+		placeholder: func(int) string { return "?" },
+		timeArg:     func(t time.Time) any { return t.UTC().Format(sqliteTimeLayout) },
+	}
+}
+
+func newMySQLSegmentQuery() *segmentQuery {
+	return &segmentQuery{
+		placeholder: func(int) string { return "?" },
+		timeArg:     func(t time.Time) any { return t.UTC() },
+	}
+}
+
+type userRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func scanSegmentUsers(rows userRows) ([]User, error) {
+	var res []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.WAID, &u.WAJID, &u.DisplayName, &u.PhoneNumber, &u.LanguagePreference, &u.Timezone, &u.CurrencyLocale, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan segment user: %w", err)
+		}
+		res = append(res, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate segment users: %w", err)
+	}
+	return res, nil
+}
+
+// ListUsersInSegment returns every messageable user matching the segment.
+func (r *PostgresRepository) ListUsersInSegment(ctx context.Context, seg Segment) ([]User, error) {
+	q := newPostgresSegmentQuery()
+	q.applySegment(seg, time.Now())
+	rows, err := r.pool.Query(ctx, q.sql(), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list users in segment: %w", err)
+	}
+	defer rows.Close()
+	return scanSegmentUsers(rows)
+}
+
+// ListCampaignRecipients returns users in the segment that have not yet received the campaign.
+func (r *PostgresRepository) ListCampaignRecipients(ctx context.Context, campaignID string, seg Segment) ([]User, error) {
+	q := newPostgresSegmentQuery()
+	q.applySegment(seg, time.Now())
+	q.where("NOT EXISTS (SELECT 1 FROM campaign_deliveries d WHERE d.campaign_id = %s AND d.user_id = u.id)", campaignID)
+	rows, err := r.pool.Query(ctx, q.sql(), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("list campaign recipients: %w", err)
+	}
+	defer rows.Close()
+	return scanSegmentUsers(rows)
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// senderListParam encodes a number or country code list for storage.
+func senderListParam(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("marshal sender list: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeSenderPolicy(p *SenderPolicy, numbers, countryCodes []byte) error {
+	if err := json.Unmarshal(numbers, &p.Numbers); err != nil {
+		return fmt.Errorf("decode sender policy numbers: %w", err)
+	}
+	if err := json.Unmarshal(countryCodes, &p.CountryCodes); err != nil {
+		return fmt.Errorf("decode sender policy country codes: %w", err)
+	}
+	return nil
+}
+
+// GetSenderPolicy returns the saved sender policy, or mode off when none is saved.
+func (r *PostgresRepository) GetSenderPolicy(ctx context.Context) (*SenderPolicy, error) {
+	const q = `
+SELECT mode, numbers, country_codes, contacts_only, block_business, updated_at
+FROM sender_policy
+WHERE id = 1;
+`
+	var p SenderPolicy
+	var numbers, countryCodes []byte
+	err := r.pool.QueryRow(ctx, q).Scan(&p.Mode, &numbers, &countryCodes, &p.ContactsOnly, &p.BlockBusiness, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &SenderPolicy{Mode: SenderPolicyOff}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get sender policy: %w", err)
+	}
+	if err := decodeSenderPolicy(&p, numbers, countryCodes); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveSenderPolicy replaces the sender policy.
+func (r *PostgresRepository) SaveSenderPolicy(ctx context.Context, p SenderPolicy) (*SenderPolicy, error) {
+	numbers, err := senderListParam(p.Numbers)
+	if err != nil {
+		return nil, err
+	}
+	countryCodes, err := senderListParam(p.CountryCodes)
+	if err != nil {
+		return nil, err
+	}
+	const q = `
+INSERT INTO sender_policy (id, mode, numbers, country_codes, contacts_only, block_business)
+VALUES (1, $1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE
+SET mode = EXCLUDED.mode,
+    numbers = EXCLUDED.numbers,
+    country_codes = EXCLUDED.country_codes,
+    contacts_only = EXCLUDED.contacts_only,
+    block_business = EXCLUDED.block_business,
+    updated_at = NOW()
+RETURNING mode, numbers, country_codes, contacts_only, block_business, updated_at;
+`
+	var saved SenderPolicy
+	var savedNumbers, savedCountryCodes []byte
+	if err := r.pool.QueryRow(ctx, q, p.Mode, numbers, countryCodes, p.ContactsOnly, p.BlockBusiness).Scan(&saved.Mode, &savedNumbers, &savedCountryCodes, &saved.ContactsOnly, &saved.BlockBusiness, &saved.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("save sender policy: %w", err)
+	}
+	if err := decodeSenderPolicy(&saved, savedNumbers, savedCountryCodes); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -64,25 +64,136 @@ func (r *SQLiteRepository) Ping(ctx context.Context) error {
 	return r.db.PingContext(ctx)
 }
 
-// RunMigrations applies schema migrations on the connected database.
+// Snapshot writes a consistent copy of the database to path, which must
+// not exist yet. Writers are not blocked while it runs.
+func (r *SQLiteRepository) Snapshot(ctx context.Context, path string) error {
+	if _, err := r.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("snapshot sqlite: %w", err)
+	}
+	return nil
+}
+
+// RunMigrations applies the SQL files under sqlite/ that the database has
+// not recorded in schema_migrations yet.
 func (r *SQLiteRepository) RunMigrations(ctx context.Context, filesystem fs.FS) error {
-	// We need a separate migration runner for database/sql
-	// vs pgxpool. The existing ApplyMigrations likely uses pgxpool.
-	// I will need to implement a simple migration runner for sql.DB here or adapt the existing one.
-	// For now, let's assume we can implement a simple one here.
+	// Databases created before migrations were versioned may predate columns
+	// that were folded into 001_init.sql. Backfill them before any file runs,
+	// so later migrations can rely on the full schema. New schema changes go
+	// in new files under migrations/sqlite.
+	for _, col := range sqliteAddedColumns {
+		if err := r.ensureColumn(ctx, col.table, col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	return runMigrations(ctx, sqliteMigrationStore{db: r.db}, filesystem, "sqlite")
+}
+
+var sqliteAddedColumns = []struct {
+	table, name, definition string
+}{
+	{"api_keys", "source", "TEXT NOT NULL DEFAULT 'config'"},
+	{"api_keys", "disabled_at", "DATETIME"},
+	{"api_keys", "disabled_reason", "TEXT"},
+	{"api_keys", "daily_request_quota", "INTEGER NOT NULL DEFAULT 0"},
+	{"api_keys", "daily_token_quota", "INTEGER NOT NULL DEFAULT 0"},
+	{"curated_products", "instructions", "TEXT"},
+	{"orders", "sla_escalated_at", "DATETIME"},
+	{"orders", "status_polled_at", "DATETIME"},
+	{"users", "deleted_at", "DATETIME"},
+	{"users", "acquisition_source", "TEXT"},
+	{"users", "acquisition_detail", "TEXT"},
+	{"users", "currency_locale", "TEXT"},
+}
 
-	// Actually, let's just read the file and exec it since we only have one migration for now.
-	// Or reuse the logic if possible.
+// ensureColumn adds column to table when the table exists without it.
+// Missing tables are left to the migrations that create them.
+func (r *SQLiteRepository) ensureColumn(ctx context.Context, table, column, definition string) error {
+	columns, err := r.tableColumns(ctx, table)
+	if err != nil || len(columns) == 0 || columns[column] {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
 
-	// Simplest: Read 001_init.sql from fs and Exec.
-	sqlContent, err := fs.ReadFile(filesystem, "sqlite/001_init.sql")
+// tableColumns returns the column names of table; it is empty when the
+// table does not exist.
+func (r *SQLiteRepository) tableColumns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
 	if err != nil {
-		return fmt.Errorf("read migration: %w", err)
+		return nil, fmt.Errorf("inspect %s columns: %w", table, err)
 	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan %s column: %w", table, err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("inspect %s columns: %w", table, err)
+	}
+	return columns, nil
+}
 
-	if _, err := r.db.ExecContext(ctx, string(sqlContent)); err != nil {
-		return fmt.Errorf("apply migration: %w", err)
+type sqliteMigrationStore struct {
+	db *sql.DB
+}
+
+func (s sqliteMigrationStore) ensureMigrationsTable(ctx context.Context) error {
+	const q = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY,
+    checksum TEXT NOT NULL,
+    dirty INTEGER NOT NULL DEFAULT 1,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+	_, err := s.db.ExecContext(ctx, q)
+	return err
+}
+
+func (s sqliteMigrationStore) appliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT version, checksum, dirty, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	var out []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Checksum, &a.Dirty, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
 
-	return nil
+func (s sqliteMigrationStore) markMigrationDirty(ctx context.Context, m migration) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO schema_migrations (version, checksum, dirty) VALUES (?, ?, 1)`, m.version, m.checksum)
+	return err
+}
+
+func (s sqliteMigrationStore) applyMigration(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE schema_migrations SET dirty = 0, applied_at = CURRENT_TIMESTAMP WHERE version = ?`, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s sqliteMigrationStore) clearMigration(ctx context.Context, version string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ? AND dirty = 1`, version)
+	return err
 }
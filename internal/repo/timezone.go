@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultZone is the zone of users without a usable timezone: Western
+// Indonesia Time, the users.timezone column default.
+var DefaultZone = time.FixedZone("WIB", 7*3600)
+
+// zones caches loaded IANA zones by name; a nil entry marks an unknown name.
+var zones sync.Map
+
+// Location is the user's time zone for rendering timestamps and judging
+// calendar days, or DefaultZone when unset or unknown.
+func (u *User) Location() *time.Location {
+	if u == nil {
+		return DefaultZone
+	}
+	return LoadZone(u.Timezone)
+}
+
+// LoadZone resolves an IANA zone name such as "Asia/Makassar", falling back
+// to DefaultZone.
+func LoadZone(name string) *time.Location {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return DefaultZone
+	}
+	if cached, ok := zones.Load(name); ok {
+		if loc := cached.(*time.Location); loc != nil {
+			return loc
+		}
+		return DefaultZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = nil
+	}
+	zones.Store(name, loc)
+	if loc == nil {
+		return DefaultZone
+	}
+	return loc
+}
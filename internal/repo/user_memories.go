@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUserMemory returns the stored conversation summary of a user.
+func (r *PostgresRepository) GetUserMemory(ctx context.Context, userID string) (*UserMemory, error) {
+	const q = `
+SELECT user_id, summary, message_count, updated_at
+FROM user_memories
+WHERE user_id = $1;
+`
+	var m UserMemory
+	if err := r.pool.QueryRow(ctx, q, userID).Scan(&m.UserID, &m.Summary, &m.MessageCount, &m.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user memory %s: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user memory: %w", err)
+	}
+	return &m, nil
+}
+
+// UpsertUserMemory replaces the conversation summary of a user.
+func (r *PostgresRepository) UpsertUserMemory(ctx context.Context, mem UserMemory) error {
+	const q = `
+INSERT INTO user_memories (user_id, summary, message_count, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET summary = EXCLUDED.summary,
+    message_count = EXCLUDED.message_count,
+    updated_at = NOW();
+`
+	if _, err := r.pool.Exec(ctx, q, mem.UserID, mem.Summary, mem.MessageCount); err != nil {
+		return fmt.Errorf("upsert user memory: %w", err)
+	}
+	return nil
+}
+
+// ResetUserMemory clears a user's summary and marks their current history as
+// already summarised, so it is not folded back in on the next run.
+func (r *PostgresRepository) ResetUserMemory(ctx context.Context, userID string) error {
+	const q = `
+INSERT INTO user_memories (user_id, summary, message_count, updated_at)
+SELECT $1, '', COUNT(*), NOW() FROM messages WHERE user_id = $1
+ON CONFLICT (user_id) DO UPDATE
+SET summary = '',
+    message_count = EXCLUDED.message_count,
+    updated_at = NOW();
+`
+	if _, err := r.pool.Exec(ctx, q, userID); err != nil {
+		return fmt.Errorf("reset user memory: %w", err)
+	}
+	return nil
+}
+
+// ListMemoryCandidates returns users with at least minNewMessages messages
+// since their memory was last summarised, most recently active first.
+func (r *PostgresRepository) ListMemoryCandidates(ctx context.Context, minNewMessages, limit int) ([]MemoryCandidate, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	const q = `
+SELECT m.user_id, COALESCE(um.summary, ''), COUNT(*)
+FROM messages m
+LEFT JOIN user_memories um ON um.user_id = m.user_id
+GROUP BY m.user_id, um.summary, um.message_count
+HAVING COUNT(*) - COALESCE(um.message_count, 0) >= $1
+ORDER BY MAX(m.created_at) DESC
+LIMIT $2;
+`
+	rows, err := r.pool.Query(ctx, q, minNewMessages, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list memory candidates: %w", err)
+	}
+	defer rows.Close()
+	return scanMemoryCandidates(rows)
+}
+
+type memoryCandidateRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func scanMemoryCandidates(rows memoryCandidateRows) ([]MemoryCandidate, error) {
+	var res []MemoryCandidate
+	for rows.Next() {
+		var c MemoryCandidate
+		if err := rows.Scan(&c.UserID, &c.PreviousSummary, &c.MessageCount); err != nil {
+			return nil, fmt.Errorf("scan memory candidate: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate memory candidates: %w", err)
+	}
+	return res, nil
+}
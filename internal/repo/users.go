@@ -2,21 +2,334 @@ package repo
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // GetUserByID returns user by internal identifier.
 func (r *PostgresRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
 	const q = `
-SELECT id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, created_at, updated_at
+SELECT id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at
 FROM users
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1;
 `
 	row := r.pool.QueryRow(ctx, q, id)
 	var user User
-	if err := row.Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+		}
 		return nil, fmt.Errorf("get user by id: %w", err)
 	}
 	return &user, nil
 }
+
+// GetUserByWAJID returns the user last seen chatting from jid.
+func (r *PostgresRepository) GetUserByWAJID(ctx context.Context, jid string) (*User, error) {
+	const q = `
+SELECT id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at
+FROM users
+WHERE wa_jid = $1 AND deleted_at IS NULL
+ORDER BY updated_at DESC
+LIMIT 1;
+`
+	row := r.pool.QueryRow(ctx, q, jid)
+	var user User
+	if err := row.Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user with jid %s: %w", jid, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get user by jid: %w", err)
+	}
+	return &user, nil
+}
+
+// SetUserCurrencyLocale stores how amounts are written for the user.
+func (r *PostgresRepository) SetUserCurrencyLocale(ctx context.Context, id string, locale *string) error {
+	const q = `UPDATE users SET currency_locale = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	ct, err := r.pool.Exec(ctx, q, id, locale)
+	if err != nil {
+		return fmt.Errorf("set user currency locale: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// SetUserLanguage stores the language the bot replies to the user in.
+func (r *PostgresRepository) SetUserLanguage(ctx context.Context, id, language string) error {
+	const q = `UPDATE users SET language_preference = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	ct, err := r.pool.Exec(ctx, q, id, language)
+	if err != nil {
+		return fmt.Errorf("set user language: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// GetUserPINHash returns the user's transaction PIN hash, "" when unset.
+func (r *PostgresRepository) GetUserPINHash(ctx context.Context, id string) (string, error) {
+	var hash *string
+	err := r.pool.QueryRow(ctx, `SELECT transaction_pin_hash FROM users WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get user pin: %w", err)
+	}
+	if hash == nil {
+		return "", nil
+	}
+	return *hash, nil
+}
+
+// SetUserPINHash stores the user's transaction PIN hash.
+func (r *PostgresRepository) SetUserPINHash(ctx context.Context, id, hash string) error {
+	const q = `UPDATE users SET transaction_pin_hash = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	ct, err := r.pool.Exec(ctx, q, id, hash)
+	if err != nil {
+		return fmt.Errorf("set user pin: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// GetUserPINLock returns the user's wrong transaction PIN count and lockout.
+func (r *PostgresRepository) GetUserPINLock(ctx context.Context, id string) (PINLock, error) {
+	var lock PINLock
+	err := r.pool.QueryRow(ctx, `SELECT pin_failed_attempts, pin_locked_until FROM users WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&lock.FailedAttempts, &lock.LockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PINLock{}, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return PINLock{}, fmt.Errorf("get user pin lock: %w", err)
+	}
+	return lock, nil
+}
+
+// SetUserPINLock stores the user's wrong transaction PIN count and lockout.
+func (r *PostgresRepository) SetUserPINLock(ctx context.Context, id string, lock PINLock) error {
+	const q = `UPDATE users SET pin_failed_attempts = $2, pin_locked_until = $3, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	ct, err := r.pool.Exec(ctx, q, id, lock.FailedAttempts, lock.LockedUntil)
+	if err != nil {
+		return fmt.Errorf("set user pin lock: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// anonymizedWAID replaces a WhatsApp ID once its user is deleted. It is
+// random, so it cannot be matched back to the number by hashing candidates,
+// and the prefix keeps it from ever matching a real ID, so the number can
+// sign up afresh.
+func anonymizedWAID() string {
+	return "deleted:" + rand.Text()
+}
+
+// orderPersonalKeys are the order metadata keys that identify the customer
+// or their purchase target; they are removed when the user is deleted. The
+// amounts and supplier price stay for accounting.
+var orderPersonalKeys = []string{
+	"customer_id", "customer_id_raw", "customer_zone", "customer_ref", "target_candidates",
+	"pln", "pln_meter", "pln_customer_name", "pln_tariff", "sn", "note",
+	"payload", "headers", "transaction_raw",
+}
+
+// jsonRemovePaths returns "?" placeholders and '$.key' arguments for
+// JSON_REMOVE/json_remove over keys.
+func jsonRemovePaths(keys []string) (string, []any) {
+	marks := make([]string, len(keys))
+	args := make([]any, len(keys))
+	for i, key := range keys {
+		marks[i] = "?"
+		args[i] = "$." + key
+	}
+	return strings.Join(marks, ", "), args
+}
+
+// AnonymizeUser clears a user's personal data while keeping the row for accounting.
+func (r *PostgresRepository) AnonymizeUser(ctx context.Context, id string, at time.Time) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		var waID string
+		var waJID *string
+		err := tx.QueryRow(ctx, `SELECT wa_id, wa_jid FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&waID, &waJID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user %s: %w", id, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("lock user for anonymization: %w", err)
+		}
+		const q = `
+UPDATE users
+SET wa_id = $2, wa_jid = NULL, display_name = NULL, phone_number = NULL, transaction_pin_hash = NULL, pin_failed_attempts = 0, pin_locked_until = NULL, deleted_at = $3, updated_at = NOW()
+WHERE id = $1;
+`
+		if _, err := tx.Exec(ctx, q, id, anonymizedWAID(), at); err != nil {
+			return fmt.Errorf("anonymize user: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM messages WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user messages: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM user_memories WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user memory: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM operator_handoffs WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user handoff: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM conversation_states WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user conversation state: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM scheduled_orders WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user scheduled orders: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM product_alerts WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user product alerts: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM notification_journal WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("delete user notifications: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM outbound_messages WHERE chat_jid = $1 OR chat_jid LIKE $2`, waJID, waID+"@%"); err != nil {
+			return fmt.Errorf("delete user outbound messages: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE admin_approvals SET user_jid = '', summary = '' WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("scrub user approvals: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE withdrawals SET account_no = '', account_name = NULL, metadata = NULL WHERE user_id = $1`, id); err != nil {
+			return fmt.Errorf("scrub user withdrawals: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE orders SET metadata = metadata - $2::text[] WHERE user_id = $1 AND metadata IS NOT NULL`, id, orderPersonalKeys); err != nil {
+			return fmt.Errorf("scrub user orders: %w", err)
+		}
+		return nil
+	})
+}
+
+// userOwnedTables hold rows that follow a user into a merge unchanged.
+// campaign_deliveries, user_memories and operator_handoffs have uniqueness
+// on user_id and are handled separately.
+var userOwnedTables = []string{"messages", "orders", "deposits", "withdrawals", "admin_approvals", "scheduled_orders", "product_alerts", "notification_journal"}
+
+// MigrateUserWAID moves the user known as fromWAID to toWAID, merging in a
+// duplicate already registered under toWAID.
+func (r *PostgresRepository) MigrateUserWAID(ctx context.Context, fromWAID, toWAID string) (*User, error) {
+	var user User
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		const lookup = `SELECT id, created_at FROM users WHERE wa_id = $1 AND deleted_at IS NULL FOR UPDATE`
+		var fromID string
+		var fromCreated time.Time
+		err := tx.QueryRow(ctx, lookup, fromWAID).Scan(&fromID, &fromCreated)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user %s: %w", fromWAID, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("lock user for migration: %w", err)
+		}
+
+		keep := fromID
+		var toID string
+		var toCreated time.Time
+		err = tx.QueryRow(ctx, lookup, toWAID).Scan(&toID, &toCreated)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return fmt.Errorf("lock duplicate user: %w", err)
+		default:
+			drop := toID
+			if toCreated.Before(fromCreated) {
+				keep, drop = toID, fromID
+			}
+			if err := mergeUserPostgres(ctx, tx, keep, drop); err != nil {
+				return err
+			}
+		}
+
+		const q = `
+UPDATE users
+SET wa_id = $2, wa_jid = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, wa_id, wa_jid, display_name, phone_number, language_preference, timezone, currency_locale, created_at, updated_at;
+`
+		if err := tx.QueryRow(ctx, q, keep, toWAID).Scan(&user.ID, &user.WAID, &user.WAJID, &user.DisplayName, &user.PhoneNumber, &user.LanguagePreference, &user.Timezone, &user.CurrencyLocale, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return fmt.Errorf("migrate user wa id: %w", err)
+		}
+		// Journaled notifications, the merged user's included, go to the new JID.
+		if _, err := tx.Exec(ctx, `UPDATE notification_journal SET chat_jid = $2 WHERE user_id = $1`, keep, toWAID); err != nil {
+			return fmt.Errorf("move notifications to new jid: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE operator_handoffs SET chat_jid = $2 WHERE user_id = $1`, keep, toWAID); err != nil {
+			return fmt.Errorf("move handoff to new jid: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func mergeUserPostgres(ctx context.Context, tx pgx.Tx, keep, drop string) error {
+	for _, table := range userOwnedTables {
+		if _, err := tx.Exec(ctx, `UPDATE `+table+` SET user_id = $1 WHERE user_id = $2`, keep, drop); err != nil {
+			return fmt.Errorf("move %s to merged user: %w", table, err)
+		}
+	}
+	const deliveries = `
+UPDATE campaign_deliveries SET user_id = $1
+WHERE user_id = $2
+  AND campaign_id NOT IN (SELECT campaign_id FROM campaign_deliveries WHERE user_id = $1);
+`
+	if _, err := tx.Exec(ctx, deliveries, keep, drop); err != nil {
+		return fmt.Errorf("move campaign deliveries to merged user: %w", err)
+	}
+	const memory = `
+UPDATE user_memories SET user_id = $1
+WHERE user_id = $2 AND NOT EXISTS (SELECT 1 FROM user_memories WHERE user_id = $1);
+`
+	if _, err := tx.Exec(ctx, memory, keep, drop); err != nil {
+		return fmt.Errorf("move user memory to merged user: %w", err)
+	}
+	// A handoff on either user keeps the bot quiet for the merged one.
+	const handoff = `
+UPDATE operator_handoffs SET user_id = $1
+WHERE user_id = $2 AND NOT EXISTS (SELECT 1 FROM operator_handoffs WHERE user_id = $1);
+`
+	if _, err := tx.Exec(ctx, handoff, keep, drop); err != nil {
+		return fmt.Errorf("move handoff to merged user: %w", err)
+	}
+	// The kept user's PIN wins, else the merged one's is taken over; wrong
+	// attempts and lockouts keep the stricter of the two, so merging an
+	// account cannot reset a PIN lockout.
+	const profile = `
+UPDATE users AS k
+SET display_name = COALESCE(k.display_name, d.display_name),
+    phone_number = COALESCE(k.phone_number, d.phone_number),
+    currency_locale = COALESCE(k.currency_locale, d.currency_locale),
+    acquisition_source = COALESCE(k.acquisition_source, d.acquisition_source),
+    acquisition_detail = COALESCE(k.acquisition_detail, d.acquisition_detail),
+    transaction_pin_hash = COALESCE(k.transaction_pin_hash, d.transaction_pin_hash),
+    pin_failed_attempts = GREATEST(k.pin_failed_attempts, d.pin_failed_attempts),
+    pin_locked_until = GREATEST(k.pin_locked_until, d.pin_locked_until)
+FROM users AS d
+WHERE k.id = $1 AND d.id = $2;
+`
+	if _, err := tx.Exec(ctx, profile, keep, drop); err != nil {
+		return fmt.Errorf("merge user profile: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, drop); err != nil {
+		return fmt.Errorf("delete merged user: %w", err)
+	}
+	return nil
+}
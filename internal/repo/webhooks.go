@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const deadLetterColumns = `id, source, event_type, headers, payload, error, attempts, status, replayed_at, created_at, updated_at`
+
+type deadLetterScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDeadLetter(row deadLetterScanner) (*WebhookDeadLetter, error) {
+	var dl WebhookDeadLetter
+	var headersJSON []byte
+	var payload string
+	if err := row.Scan(&dl.ID, &dl.Source, &dl.EventType, &headersJSON, &payload, &dl.Error, &dl.Attempts, &dl.Status, &dl.ReplayedAt, &dl.CreatedAt, &dl.UpdatedAt); err != nil {
+		return nil, err
+	}
+	dl.Payload = []byte(payload)
+	if len(headersJSON) > 0 {
+		if err := json.Unmarshal(headersJSON, &dl.Headers); err != nil {
+			return nil, fmt.Errorf("decode dead letter headers: %w", err)
+		}
+	}
+	return &dl, nil
+}
+
+func headersParam(headers map[string]string) (any, error) {
+	if headers == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("marshal headers: %w", err)
+	}
+	return string(data), nil
+}
+
+// InsertWebhookDeadLetter stores a failed webhook event.
+func (r *PostgresRepository) InsertWebhookDeadLetter(ctx context.Context, dl WebhookDeadLetter) (*WebhookDeadLetter, error) {
+	headers, err := headersParam(dl.Headers)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+INSERT INTO webhook_dead_letters (source, event_type, headers, payload, error)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + deadLetterColumns + `;`
+	saved, err := scanDeadLetter(r.pool.QueryRow(ctx, q, dl.Source, dl.EventType, headers, string(dl.Payload), dl.Error))
+	if err != nil {
+		return nil, fmt.Errorf("insert webhook dead letter: %w", err)
+	}
+	return saved, nil
+}
+
+// ListWebhookDeadLetters returns the newest dead letters, optionally filtered by status.
+func (r *PostgresRepository) ListWebhookDeadLetters(ctx context.Context, status string, limit int) ([]WebhookDeadLetter, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	q := `
+SELECT ` + deadLetterColumns + `
+FROM webhook_dead_letters
+WHERE ($1 = '' OR status = $1)
+ORDER BY created_at DESC
+LIMIT $2;`
+	rows, err := r.pool.Query(ctx, q, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var res []WebhookDeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook dead letter: %w", err)
+		}
+		res = append(res, *dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook dead letters: %w", err)
+	}
+	return res, nil
+}
+
+// GetWebhookDeadLetter loads a single dead letter.
+func (r *PostgresRepository) GetWebhookDeadLetter(ctx context.Context, id string) (*WebhookDeadLetter, error) {
+	q := `SELECT ` + deadLetterColumns + ` FROM webhook_dead_letters WHERE id = $1;`
+	dl, err := scanDeadLetter(r.pool.QueryRow(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("webhook dead letter %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("get webhook dead letter: %w", err)
+	}
+	return dl, nil
+}
+
+// UpdateWebhookDeadLetter records a replay attempt and its outcome.
+func (r *PostgresRepository) UpdateWebhookDeadLetter(ctx context.Context, id, status, lastError string) error {
+	const q = `
+UPDATE webhook_dead_letters
+SET status = $2,
+    error = CASE WHEN $3 = '' THEN error ELSE $3 END,
+    attempts = attempts + 1,
+    replayed_at = CASE WHEN $2 = 'replayed' THEN NOW() ELSE replayed_at END,
+    updated_at = NOW()
+WHERE id = $1;
+`
+	ct, err := r.pool.Exec(ctx, q, id, status, lastError)
+	if err != nil {
+		return fmt.Errorf("update webhook dead letter: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("webhook dead letter %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ClaimWebhookDeadLetter marks a dead letter as being replayed so concurrent
+// replays cannot both process it.
+func (r *PostgresRepository) ClaimWebhookDeadLetter(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	const q = `
+UPDATE webhook_dead_letters
+SET status = 'replaying', updated_at = NOW()
+WHERE id = $1 AND (status = 'pending' OR (status = 'replaying' AND updated_at < $2));
+`
+	ct, err := r.pool.Exec(ctx, q, id, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("claim webhook dead letter: %w", err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// ClaimWebhookEvent records that the event ref/type/status is being processed.
+// It returns false when the event was claimed before.
+func (r *PostgresRepository) ClaimWebhookEvent(ctx context.Context, ref, eventType, status string) (bool, error) {
+	const q = `
+INSERT INTO webhook_events (ref_id, event_type, status)
+VALUES ($1, $2, $3)
+ON CONFLICT (ref_id, event_type, status) DO NOTHING;
+`
+	ct, err := r.pool.Exec(ctx, q, ref, eventType, status)
+	if err != nil {
+		return false, fmt.Errorf("claim webhook event: %w", err)
+	}
+	return ct.RowsAffected() == 1, nil
+}
+
+// ReleaseWebhookEvent drops a claim whose processing failed.
+func (r *PostgresRepository) ReleaseWebhookEvent(ctx context.Context, ref, eventType, status string) error {
+	const q = `DELETE FROM webhook_events WHERE ref_id = $1 AND event_type = $2 AND status = $3`
+	if _, err := r.pool.Exec(ctx, q, ref, eventType, status); err != nil {
+		return fmt.Errorf("release webhook event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InsertWithdrawal stores a new withdrawal record.
+func (r *PostgresRepository) InsertWithdrawal(ctx context.Context, wd Withdrawal) (*Withdrawal, error) {
+	meta, err := toJSON(wd.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	metaParam := jsonParam(meta)
+
+	const q = `
+INSERT INTO withdrawals (user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata, created_at, updated_at;
+`
+	row := r.pool.QueryRow(ctx, q,
+		wd.UserID,
+		wd.WithdrawalRef,
+		wd.BankCode,
+		wd.AccountNo,
+		wd.AccountName,
+		wd.Amount,
+		wd.Fee,
+		wd.Status,
+		metaParam,
+	)
+
+	var inserted Withdrawal
+	var metaJSON []byte
+	if err := row.Scan(&inserted.ID, &inserted.UserID, &inserted.WithdrawalRef, &inserted.BankCode, &inserted.AccountNo, &inserted.AccountName, &inserted.Amount, &inserted.Fee, &inserted.Status, &metaJSON, &inserted.CreatedAt, &inserted.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("insert withdrawal: %w", err)
+	}
+	inserted.Metadata = fromJSON(metaJSON)
+	return &inserted, nil
+}
+
+// GetWithdrawalByRef retrieves a withdrawal by reference.
+func (r *PostgresRepository) GetWithdrawalByRef(ctx context.Context, ref string) (*Withdrawal, error) {
+	const q = `
+SELECT id, user_id, withdrawal_ref, bank_code, account_no, account_name, amount, fee, status, metadata, created_at, updated_at
+FROM withdrawals
+WHERE withdrawal_ref = $1
+LIMIT 1;
+`
+	row := r.pool.QueryRow(ctx, q, ref)
+	var wd Withdrawal
+	var metaJSON []byte
+	if err := row.Scan(&wd.ID, &wd.UserID, &wd.WithdrawalRef, &wd.BankCode, &wd.AccountNo, &wd.AccountName, &wd.Amount, &wd.Fee, &wd.Status, &metaJSON, &wd.CreatedAt, &wd.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("get withdrawal by ref: %w", err)
+	}
+	wd.Metadata = fromJSON(metaJSON)
+	return &wd, nil
+}
+
+// UpdateWithdrawalStatus updates withdrawal metadata/status.
+func (r *PostgresRepository) UpdateWithdrawalStatus(ctx context.Context, ref, status string, metadata map[string]any) error {
+	meta, err := toJSON(metadata)
+	if err != nil {
+		return err
+	}
+	metaParam := jsonParam(meta)
+	const q = `
+UPDATE withdrawals
+SET status = $2,
+    metadata = COALESCE($3, metadata),
+    updated_at = NOW()
+WHERE withdrawal_ref = $1;
+`
+	_, err = r.pool.Exec(ctx, q, ref, status, metaParam)
+	if err != nil {
+		return fmt.Errorf("update withdrawal status: %w", err)
+	}
+	return nil
+}
+
+// SumWithdrawalsSince totals non-failed withdrawal amounts requested by a user since the given time.
+func (r *PostgresRepository) SumWithdrawalsSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	const q = `
+SELECT COALESCE(SUM(amount), 0)
+FROM withdrawals
+WHERE user_id = $1
+  AND status <> 'failed'
+  AND created_at >= $2;
+`
+	var total int64
+	if err := r.pool.QueryRow(ctx, q, userID, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("sum withdrawals: %w", err)
+	}
+	return total, nil
+}
+
+// withdrawnTotal returns amount+fee of withdrawals that are settled or still in flight.
+func (r *PostgresRepository) withdrawnTotal(ctx context.Context, userID string) (int64, error) {
+	const q = `
+SELECT COALESCE(SUM(amount + fee), 0)
+FROM withdrawals
+WHERE user_id = $1
+  AND status <> 'failed';
+`
+	var total int64
+	if err := r.pool.QueryRow(ctx, q, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("sum withdrawn: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,158 @@
+// Package reports sends scheduled business summaries to the admins over
+// WhatsApp.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+	"bot-jual/internal/wa"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Store totals the day's sales; repo.Repository fits.
+type Store interface {
+	SalesSummary(ctx context.Context, from, to time.Time) (*repo.SalesSummary, error)
+}
+
+// Notifier sends the summary to admins over WhatsApp.
+type Notifier interface {
+	SendText(ctx context.Context, to types.JID, text string) error
+}
+
+// Config groups knobs for the daily summary.
+type Config struct {
+	// At is the time of day the summary is sent, as an offset from
+	// midnight in Location. A negative value disables it.
+	At       time.Duration
+	Location *time.Location
+}
+
+// DailySummary sends the admins a sales summary once a day.
+type DailySummary struct {
+	store     Store
+	notifier  Notifier
+	adminJIDs []string
+	logger    *slog.Logger
+	cfg       Config
+	now       func() time.Time
+}
+
+// NewDailySummary constructs the daily sales summary job.
+func NewDailySummary(store Store, notifier Notifier, adminJIDs []string, logger *slog.Logger, cfg Config) *DailySummary {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &DailySummary{
+		store:     store,
+		notifier:  notifier,
+		adminJIDs: adminJIDs,
+		logger:    logger.With("component", "sales_summary"),
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// Run sends the summary every day at the configured time until ctx is
+// cancelled.
+func (d *DailySummary) Run(ctx context.Context) {
+	if d.cfg.At < 0 || len(d.adminJIDs) == 0 {
+		return
+	}
+	for {
+		next := nextRun(d.now(), d.cfg.At, d.cfg.Location)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := d.Send(ctx, d.now()); err != nil && ctx.Err() == nil {
+			d.logger.Error("daily sales summary failed", "error", err)
+		}
+	}
+}
+
+// Send totals the day at falls in and sends it to every admin. A run
+// before noon reports the previous day in full, so a summary scheduled
+// just after midnight still covers a whole day; a later run reports the
+// current day up to at.
+func (d *DailySummary) Send(ctx context.Context, at time.Time) error {
+	from, to := period(at, d.cfg.Location)
+	summary, err := d.store.SalesSummary(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	text := formatSummary(from, to, *summary)
+	sent := 0
+	for _, raw := range d.adminJIDs {
+		jid, err := wa.ParseAdminJID(raw)
+		if err != nil {
+			d.logger.Warn("invalid admin jid", "error", err, "jid", raw)
+			continue
+		}
+		if err := d.notifier.SendText(ctx, jid, text); err != nil {
+			d.logger.Warn("failed sending sales summary", "error", err, "jid", raw)
+			continue
+		}
+		sent++
+	}
+	d.logger.Info("daily sales summary sent", "from", from, "to", to, "orders", summary.Orders, "admins", sent)
+	return nil
+}
+
+// period returns the span the summary sent at reports on.
+func period(at time.Time, loc *time.Location) (from, to time.Time) {
+	local := at.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if local.Hour() < 12 {
+		return midnight.AddDate(0, 0, -1), midnight
+	}
+	return midnight, local
+}
+
+// nextRun returns the first time after now that falls at offset at past
+// midnight in loc.
+func nextRun(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	next := midnight.Add(at)
+	if !next.After(now) {
+		next = midnight.AddDate(0, 0, 1).Add(at)
+	}
+	return next
+}
+
+func formatSummary(from, to time.Time, s repo.SalesSummary) string {
+	rp := func(amount int64) string { return money.FromRupiah(amount).Format(money.Indonesian) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ringkasan penjualan %s", from.Format("02-01-2006"))
+	if to.Sub(from) < 24*time.Hour {
+		fmt.Fprintf(&b, " (s.d. %s)", to.Format("15:04"))
+	}
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "Order: %d (%d sukses, %d gagal", s.Orders, s.OrdersSuccess, s.OrdersFailed)
+	if pending := s.Orders - s.OrdersSuccess - s.OrdersFailed; pending > 0 {
+		fmt.Fprintf(&b, ", %d belum selesai", pending)
+	}
+	b.WriteString(")\n")
+	fmt.Fprintf(&b, "Omzet: %s\n", rp(s.Revenue))
+	switch {
+	case s.CostedOrders == 0:
+		b.WriteString("Profit: belum ada harga supplier tercatat\n")
+	case s.CostedOrders < s.OrdersSuccess:
+		fmt.Fprintf(&b, "Profit: %s (dari %d/%d order sukses dengan harga supplier tercatat)\n", rp(s.Profit()), s.CostedOrders, s.OrdersSuccess)
+	default:
+		fmt.Fprintf(&b, "Profit: %s\n", rp(s.Profit()))
+	}
+	fmt.Fprintf(&b, "Deposit: %d (%d lunas, total %s)", s.Deposits, s.DepositsPaid, rp(s.DepositAmount))
+	return b.String()
+}
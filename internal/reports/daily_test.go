@@ -0,0 +1,75 @@
+package reports
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+type fakeStore struct {
+	from, to time.Time
+	summary  repo.SalesSummary
+}
+
+func (f *fakeStore) SalesSummary(_ context.Context, from, to time.Time) (*repo.SalesSummary, error) {
+	f.from, f.to = from, to
+	return &f.summary, nil
+}
+
+type fakeNotifier struct {
+	to    []string
+	texts []string
+}
+
+func (f *fakeNotifier) SendText(_ context.Context, to types.JID, text string) error {
+	f.to = append(f.to, to.User)
+	f.texts = append(f.texts, text)
+	return nil
+}
+
+func TestSendReportsTheDay(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	store := &fakeStore{summary: repo.SalesSummary{
+		Orders: 12, OrdersSuccess: 9, OrdersFailed: 2, Revenue: 450000,
+		CostedOrders: 8, CostedRevenue: 400000, Cost: 372500,
+		Deposits: 4, DepositsPaid: 3, DepositAmount: 300000,
+	}}
+	notifier := &fakeNotifier{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := NewDailySummary(store, notifier, []string{"+6281234"}, logger, Config{At: 22 * time.Hour, Location: wib})
+
+	at := time.Date(2026, 3, 10, 22, 0, 0, 0, wib)
+	if err := d.Send(context.Background(), at); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 10, 0, 0, 0, 0, wib); !store.from.Equal(want) || !store.to.Equal(at) {
+		t.Fatalf("period = %s..%s, want %s..%s", store.from, store.to, want, at)
+	}
+	if len(notifier.texts) != 1 || notifier.to[0] != "6281234" {
+		t.Fatalf("sent to %v", notifier.to)
+	}
+	text := notifier.texts[0]
+	for _, want := range []string{"10-03-2026 (s.d. 22:00)", "12 (9 sukses, 2 gagal, 1 belum selesai)", "Omzet: Rp450.000", "Profit: Rp27.500 (dari 8/9", "Deposit: 4 (3 lunas, total Rp300.000)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("summary missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestPeriodBeforeNoonIsPreviousDay(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	from, to := period(time.Date(2026, 3, 10, 0, 5, 0, 0, wib), wib)
+	if !from.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, wib)) || !to.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, wib)) {
+		t.Fatalf("period = %s..%s", from, to)
+	}
+	if text := formatSummary(from, to, repo.SalesSummary{}); !strings.HasPrefix(text, "Ringkasan penjualan 09-03-2026\n") || !strings.Contains(text, "belum ada harga supplier") {
+		t.Fatalf("unexpected summary:\n%s", text)
+	}
+}
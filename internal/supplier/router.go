@@ -0,0 +1,134 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+// Router places each purchase with the cheapest available supplier that
+// sells the product and fails over down the list while FailOver allows.
+// The first supplier is the default for products no supplier quotes and
+// for orders that did not record one.
+type Router struct {
+	logger    *slog.Logger
+	suppliers []Supplier
+}
+
+// NewRouter creates a router over suppliers, the default first.
+func NewRouter(logger *slog.Logger, suppliers ...Supplier) *Router {
+	return &Router{
+		logger:    logger.With("component", "supplier_router"),
+		suppliers: suppliers,
+	}
+}
+
+type candidate struct {
+	supplier Supplier
+	price    money.Money
+	priced   bool
+	up       bool
+}
+
+// route orders the suppliers to try for productCode: available ones by
+// price, those whose price could not be fetched after them, and
+// unavailable ones last so their error is reported if nothing else works.
+// Suppliers that do not sell the product are left out.
+func (r *Router) route(ctx context.Context, productCode string) []Supplier {
+	candidates := make([]candidate, 0, len(r.suppliers))
+	for _, s := range r.suppliers {
+		c := candidate{supplier: s, up: s.Available()}
+		price, sells, err := s.Quote(ctx, productCode)
+		switch {
+		case err != nil:
+			r.logger.Debug("supplier quote failed", "supplier", s.Name(), "product_code", productCode, "error", err)
+		case !sells:
+			continue
+		default:
+			c.price, c.priced = price, true
+		}
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		return r.suppliers[:1]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.up != b.up {
+			return a.up
+		}
+		if a.priced != b.priced {
+			return a.priced
+		}
+		return a.priced && a.price < b.price
+	})
+	res := make([]Supplier, len(candidates))
+	for i, c := range candidates {
+		res[i] = c.supplier
+	}
+	return res
+}
+
+// CreatePrepaidTransaction places req with the first supplier on the route
+// that takes it and records that supplier on the response.
+func (r *Router) CreatePrepaidTransaction(ctx context.Context, req atl.CreatePrepaidRequest) (*atl.TransactionResponse, error) {
+	if len(r.suppliers) == 0 {
+		return nil, fmt.Errorf("no supplier configured")
+	}
+	var lastErr error
+	for i, s := range r.route(ctx, req.ProductCode) {
+		resp, err := s.CreatePrepaidTransaction(ctx, req)
+		if err == nil {
+			resp.Supplier = s.Name()
+			if i > 0 {
+				r.logger.Info("purchase placed with fallback supplier", "supplier", s.Name(), "product_code", req.ProductCode, "ref_id", req.RefID)
+			}
+			return resp, nil
+		}
+		lastErr = err
+		if !FailOver(err) {
+			return nil, err
+		}
+		r.logger.Warn("supplier refused purchase, failing over", "supplier", s.Name(), "product_code", req.ProductCode, "ref_id", req.RefID, "error", err)
+	}
+	return nil, lastErr
+}
+
+// TransactionStatus asks the supplier named in req, or the default one.
+func (r *Router) TransactionStatus(ctx context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error) {
+	s := r.supplier(req.Supplier)
+	if s == nil {
+		return nil, fmt.Errorf("unknown supplier %q", req.Supplier)
+	}
+	return s.TransactionStatus(ctx, req)
+}
+
+// HasFallback reports whether a supplier other than name can take
+// purchases now.
+func (r *Router) HasFallback(name string) bool {
+	for _, s := range r.suppliers {
+		if s.Name() != name && s.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) supplier(name string) Supplier {
+	if len(r.suppliers) == 0 {
+		return nil
+	}
+	if name == "" {
+		return r.suppliers[0]
+	}
+	for _, s := range r.suppliers {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
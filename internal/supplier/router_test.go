@@ -0,0 +1,114 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+type fakeSupplier struct {
+	name     string
+	prices   map[string]int64
+	down     bool
+	err      error
+	placed   []string
+	statuses []atl.TransactionStatusRequest
+}
+
+func (s *fakeSupplier) Name() string    { return s.name }
+func (s *fakeSupplier) Available() bool { return !s.down }
+
+func (s *fakeSupplier) Quote(_ context.Context, productCode string) (money.Money, bool, error) {
+	price, ok := s.prices[productCode]
+	return money.FromRupiah(price), ok, nil
+}
+
+func (s *fakeSupplier) CreatePrepaidTransaction(_ context.Context, req atl.CreatePrepaidRequest) (*atl.TransactionResponse, error) {
+	s.placed = append(s.placed, req.RefID)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &atl.TransactionResponse{RefID: req.RefID, Status: "pending"}, nil
+}
+
+func (s *fakeSupplier) TransactionStatus(_ context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error) {
+	s.statuses = append(s.statuses, req)
+	return &atl.TransactionStatusResponse{RefID: req.RefID, Status: "success"}, nil
+}
+
+func newTestRouter(suppliers ...Supplier) *Router {
+	return NewRouter(slog.New(slog.NewTextHandler(io.Discard, nil)), suppliers...)
+}
+
+func TestRouterPicksCheapestAvailableSupplier(t *testing.T) {
+	primary := &fakeSupplier{name: Atlantic, prices: map[string]int64{"TSEL10": 10500, "XL5": 5200}}
+	other := &fakeSupplier{name: "digiflazz", prices: map[string]int64{"TSEL10": 10300}}
+	r := newTestRouter(primary, other)
+	ctx := context.Background()
+
+	resp, err := r.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", RefID: "trx-1"})
+	if err != nil || resp.Supplier != "digiflazz" {
+		t.Fatalf("cheapest = %+v, %v", resp, err)
+	}
+	resp, err = r.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "XL5", RefID: "trx-2"})
+	if err != nil || resp.Supplier != Atlantic {
+		t.Fatalf("only seller = %+v, %v", resp, err)
+	}
+	other.down = true
+	resp, err = r.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", RefID: "trx-3"})
+	if err != nil || resp.Supplier != Atlantic {
+		t.Fatalf("unavailable supplier chosen: %+v, %v", resp, err)
+	}
+	if r.HasFallback(Atlantic) {
+		t.Fatalf("fallback reported while the other supplier is down")
+	}
+}
+
+func TestRouterFailsOverOnlyWhenPurchaseWasRefused(t *testing.T) {
+	primary := &fakeSupplier{name: Atlantic, prices: map[string]int64{"TSEL10": 10000}}
+	other := &fakeSupplier{name: "digiflazz", prices: map[string]int64{"TSEL10": 10300}}
+	r := newTestRouter(primary, other)
+	ctx := context.Background()
+
+	primary.err = &atl.CircuitOpenError{Endpoint: "/transaksi/create", RetryAt: time.Now()}
+	resp, err := r.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", RefID: "trx-1"})
+	if err != nil || resp.Supplier != "digiflazz" {
+		t.Fatalf("circuit open = %+v, %v", resp, err)
+	}
+
+	primary.err = fmt.Errorf("atlantic request: %w", context.DeadlineExceeded)
+	if _, err := r.CreatePrepaidTransaction(ctx, atl.CreatePrepaidRequest{ProductCode: "TSEL10", RefID: "trx-2"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout error = %v", err)
+	}
+	if len(other.placed) != 1 {
+		t.Fatalf("timed-out purchase failed over: %v", other.placed)
+	}
+}
+
+func TestRouterChecksStatusWithSupplierThatTookOrder(t *testing.T) {
+	primary := &fakeSupplier{name: Atlantic}
+	other := &fakeSupplier{name: "digiflazz"}
+	r := newTestRouter(primary, other)
+	order := &repo.Order{OrderRef: "trx-1", ProductCode: "TSEL10", Metadata: map[string]any{"supplier": "digiflazz", "customer_id": "0812"}}
+
+	if _, err := r.TransactionStatus(context.Background(), StatusRequest(order)); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if len(other.statuses) != 1 || other.statuses[0].CustomerID != "0812" || other.statuses[0].ProductCode != "TSEL10" || other.statuses[0].Type != "prabayar" {
+		t.Fatalf("status requests = %+v", other.statuses)
+	}
+	if _, err := r.TransactionStatus(context.Background(), StatusRequest(&repo.Order{OrderRef: "trx-old"})); err != nil || len(primary.statuses) != 1 {
+		t.Fatalf("order without supplier not sent to default: %v", err)
+	}
+	if _, err := r.TransactionStatus(context.Background(), atl.TransactionStatusRequest{RefID: "x", Supplier: "gone"}); err == nil {
+		t.Fatalf("expected error for an unknown supplier")
+	}
+}
@@ -0,0 +1,118 @@
+// Package supplier spreads prepaid purchases over the suppliers the shop
+// buys from: each purchase goes to the cheapest one that sells the product
+// and is up, failing over to the next when it is refused.
+package supplier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+	"bot-jual/internal/repo"
+)
+
+// Atlantic is the name of the Atlantic supplier, also assumed for orders
+// placed before suppliers were recorded.
+const Atlantic = "atlantic"
+
+// atlanticTransactionEndpoint is the Atlantic endpoint whose breaker marks
+// it unable to take purchases.
+const atlanticTransactionEndpoint = "/transaksi/create"
+
+// Fulfiller places prepaid transactions and checks on them. The Atlantic
+// client and the Router are both one.
+type Fulfiller interface {
+	CreatePrepaidTransaction(ctx context.Context, req atl.CreatePrepaidRequest) (*atl.TransactionResponse, error)
+	TransactionStatus(ctx context.Context, req atl.TransactionStatusRequest) (*atl.TransactionStatusResponse, error)
+}
+
+// Supplier is a Fulfiller the Router can choose between.
+type Supplier interface {
+	Fulfiller
+	// Name is recorded on the orders the supplier takes.
+	Name() string
+	// Quote returns what productCode costs, or false when the supplier
+	// does not sell it right now.
+	Quote(ctx context.Context, productCode string) (money.Money, bool, error)
+	// Available reports whether the supplier can take purchases now.
+	Available() bool
+}
+
+type atlanticSupplier struct {
+	*atl.Client
+	probe *atl.HealthProbe
+}
+
+// NewAtlantic wraps the Atlantic client as a Supplier. It is unavailable
+// while the transaction endpoint's breaker is open or probe, when set,
+// last found Atlantic down.
+func NewAtlantic(client *atl.Client, probe *atl.HealthProbe) Supplier {
+	return &atlanticSupplier{Client: client, probe: probe}
+}
+
+func (a *atlanticSupplier) Name() string { return Atlantic }
+
+func (a *atlanticSupplier) Quote(ctx context.Context, productCode string) (money.Money, bool, error) {
+	items, err := a.PriceList(ctx, "prabayar", false)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, item := range items {
+		if strings.EqualFold(item.Code, productCode) {
+			return item.Price, item.Status == "" || strings.EqualFold(item.Status, "available"), nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (a *atlanticSupplier) Available() bool {
+	if a.BreakerOpen(atlanticTransactionEndpoint) {
+		return false
+	}
+	if a.probe == nil {
+		return true
+	}
+	st := a.probe.Status()
+	return st.CheckedAt.IsZero() || st.Up
+}
+
+// FailOver reports whether a failed purchase can go to another supplier
+// because the supplier refused it without taking it: its breaker was
+// open, the connection never got through, or it rejected the shop's
+// credentials or balance. Errors after which the purchase may still have
+// gone through, such as timeouts, are not, so an order is never filled
+// twice.
+func FailOver(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, atl.ErrCircuitOpen) || errors.Is(err, atl.ErrInvalidCredential) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	lower := strings.ToLower(err.Error())
+	return strings.Contains(lower, "insufficient balance") || strings.Contains(lower, "saldo tidak cukup")
+}
+
+// StatusRequest asks about order with the supplier that took it.
+func StatusRequest(order *repo.Order) atl.TransactionStatusRequest {
+	productType, _ := order.Metadata["product_type"].(string)
+	if productType == "" {
+		productType = "prabayar"
+	}
+	supplierName, _ := order.Metadata["supplier"].(string)
+	customerID, _ := order.Metadata["customer_id"].(string)
+	return atl.TransactionStatusRequest{
+		RefID:       order.OrderRef,
+		Type:        productType,
+		Supplier:    supplierName,
+		ProductCode: order.ProductCode,
+		CustomerID:  customerID,
+	}
+}
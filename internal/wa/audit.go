@@ -0,0 +1,168 @@
+package wa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"bot-jual/internal/repo"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Triggers name what caused an outbound message.
+const (
+	TriggerConvo     = "convo"
+	TriggerWebhook   = "webhook"
+	TriggerBroadcast = "broadcast"
+	TriggerResend    = "resend"
+)
+
+// Origin describes why a message is sent, for the outbound audit log.
+// Category becomes the logged message type and defaults to the kind of
+// message (text, image, document, list, buttons). UserID is the recipient's
+// user when the sender knows it; otherwise the user is looked up by JID.
+// Transcript, when set, is logged as the content instead of what was sent,
+// such as the text version of a list.
+type Origin struct {
+	Trigger       string
+	CorrelationID string
+	UserID        string
+	Category      string
+	Transcript    string
+}
+
+type originContextKey struct{}
+
+// WithOrigin attaches o to ctx for messages sent with it. Empty fields keep
+// what an outer WithOrigin set, so a webhook can name the trigger once and
+// the code replying only adds the user or category.
+func WithOrigin(ctx context.Context, o Origin) context.Context {
+	parent := OriginFromContext(ctx)
+	if o.Trigger == "" {
+		o.Trigger = parent.Trigger
+	}
+	if o.CorrelationID == "" {
+		o.CorrelationID = parent.CorrelationID
+	}
+	if o.UserID == "" {
+		o.UserID = parent.UserID
+	}
+	if o.Category == "" {
+		o.Category = parent.Category
+	}
+	if o.Transcript == "" {
+		o.Transcript = parent.Transcript
+	}
+	return context.WithValue(ctx, originContextKey{}, o)
+}
+
+// OriginFromContext returns the origin attached with WithOrigin, if any.
+func OriginFromContext(ctx context.Context) Origin {
+	if ctx == nil {
+		return Origin{}
+	}
+	o, _ := ctx.Value(originContextKey{}).(Origin)
+	return o
+}
+
+// MessageLog is where sent messages are audited, next to the incoming ones.
+type MessageLog interface {
+	InsertMessage(ctx context.Context, msg repo.MessageRecord) error
+	GetUserByWAJID(ctx context.Context, jid string) (*repo.User, error)
+}
+
+// SetMessageLog records every sent message in the message log.
+func (c *Client) SetMessageLog(log MessageLog) {
+	c.messageLog = log
+}
+
+// AuditsOutbound reports whether sent messages are logged by the client, so
+// callers need not log their replies themselves.
+func (c *Client) AuditsOutbound() bool {
+	return c.messageLog != nil
+}
+
+// sentMessage is one message as it went out.
+type sentMessage struct {
+	kind     string
+	id       types.MessageID
+	to       types.JID
+	content  string
+	mediaURL string
+	message  *waProto.Message
+}
+
+// outboundPayload is stored as the raw payload of an audited message.
+type outboundPayload struct {
+	To            string          `json:"to"`
+	MessageID     string          `json:"message_id,omitempty"`
+	Kind          string          `json:"kind"`
+	Trigger       string          `json:"trigger,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Message       json.RawMessage `json:"message,omitempty"`
+}
+
+func (c *Client) auditSent(ctx context.Context, sent sentMessage) {
+	if c.messageLog == nil {
+		return
+	}
+	origin := OriginFromContext(ctx)
+	userID := origin.UserID
+	if userID == "" {
+		user, err := c.messageLog.GetUserByWAJID(ctx, sent.to.ToNonAD().String())
+		if err != nil {
+			// Chats that are not users, such as admin numbers, have no
+			// conversation to log into.
+			if !errors.Is(err, repo.ErrNotFound) {
+				c.logger.Warn("failed resolving user for outbound message", "error", err, "to", sent.to.String())
+			}
+			return
+		}
+		userID = user.ID
+	}
+
+	msgType := origin.Category
+	if msgType == "" {
+		msgType = sent.kind
+	}
+	payload := outboundPayload{
+		To:            sent.to.String(),
+		MessageID:     string(sent.id),
+		Kind:          sent.kind,
+		Trigger:       origin.Trigger,
+		CorrelationID: origin.CorrelationID,
+	}
+	if sent.message != nil {
+		if data, err := protojson.Marshal(sent.message); err == nil {
+			payload.Message = data
+		}
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Warn("failed encoding outbound message", "error", err, "message_id", sent.id)
+		return
+	}
+
+	record := repo.MessageRecord{
+		UserID:     userID,
+		Direction:  "outgoing",
+		Type:       msgType,
+		RawPayload: string(raw),
+	}
+	content := sent.content
+	if origin.Transcript != "" {
+		content = origin.Transcript
+	}
+	if content != "" {
+		record.Content = &content
+	}
+	if sent.mediaURL != "" {
+		record.MediaURL = &sent.mediaURL
+	}
+	if err := c.messageLog.InsertMessage(ctx, record); err != nil {
+		c.logger.Warn("failed logging outbound message", "error", err, "message_id", sent.id)
+	}
+}
@@ -0,0 +1,120 @@
+package wa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"bot-jual/internal/repo"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type fakeMessageLog struct {
+	users   map[string]string
+	records []repo.MessageRecord
+}
+
+func (f *fakeMessageLog) InsertMessage(ctx context.Context, msg repo.MessageRecord) error {
+	f.records = append(f.records, msg)
+	return nil
+}
+
+func (f *fakeMessageLog) GetUserByWAJID(ctx context.Context, jid string) (*repo.User, error) {
+	id, ok := f.users[jid]
+	if !ok {
+		return nil, fmt.Errorf("user with jid %s: %w", jid, repo.ErrNotFound)
+	}
+	return &repo.User{ID: id}, nil
+}
+
+func TestWithOriginKeepsOuterFields(t *testing.T) {
+	ctx := WithOrigin(context.Background(), Origin{Trigger: TriggerWebhook, CorrelationID: "ORD-1"})
+	ctx = WithOrigin(ctx, Origin{UserID: "u1", Category: "deposit_status"})
+
+	got := OriginFromContext(ctx)
+	want := Origin{Trigger: TriggerWebhook, CorrelationID: "ORD-1", UserID: "u1", Category: "deposit_status"}
+	if got != want {
+		t.Fatalf("origin = %+v, want %+v", got, want)
+	}
+}
+
+func TestAuditSent(t *testing.T) {
+	to := types.NewJID("62812", types.DefaultUserServer)
+	message := &waProto.Message{Conversation: proto.String("Halo kak")}
+
+	t.Run("origin user and trigger", func(t *testing.T) {
+		log := &fakeMessageLog{}
+		c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), messageLog: log}
+		ctx := WithOrigin(context.Background(), Origin{Trigger: TriggerConvo, CorrelationID: "IN1", UserID: "u1", Category: "greeting"})
+
+		c.auditSent(ctx, sentMessage{kind: "text", id: "OUT1", to: to, content: "Halo kak", message: message})
+
+		if len(log.records) != 1 {
+			t.Fatalf("records = %d, want 1", len(log.records))
+		}
+		rec := log.records[0]
+		if rec.UserID != "u1" || rec.Direction != "outgoing" || rec.Type != "greeting" || rec.Content == nil || *rec.Content != "Halo kak" {
+			t.Fatalf("record = %+v", rec)
+		}
+		var payload struct {
+			To            string         `json:"to"`
+			MessageID     string         `json:"message_id"`
+			Kind          string         `json:"kind"`
+			Trigger       string         `json:"trigger"`
+			CorrelationID string         `json:"correlation_id"`
+			Message       map[string]any `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(rec.RawPayload.(string)), &payload); err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		if payload.To != to.String() || payload.MessageID != "OUT1" || payload.Kind != "text" || payload.Trigger != TriggerConvo || payload.CorrelationID != "IN1" {
+			t.Fatalf("payload = %+v", payload)
+		}
+		if payload.Message["conversation"] != "Halo kak" {
+			t.Fatalf("payload message = %v", payload.Message)
+		}
+	})
+
+	t.Run("user looked up by jid", func(t *testing.T) {
+		log := &fakeMessageLog{users: map[string]string{to.String(): "u2"}}
+		c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), messageLog: log}
+
+		c.auditSent(context.Background(), sentMessage{kind: "image", id: "OUT2", to: to, mediaURL: "https://mmg.whatsapp.net/x"})
+
+		if len(log.records) != 1 || log.records[0].UserID != "u2" || log.records[0].Type != "image" {
+			t.Fatalf("records = %+v", log.records)
+		}
+		if log.records[0].Content != nil || log.records[0].MediaURL == nil {
+			t.Fatalf("content = %v, media = %v", log.records[0].Content, log.records[0].MediaURL)
+		}
+	})
+
+	t.Run("transcript replaces content", func(t *testing.T) {
+		log := &fakeMessageLog{}
+		c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), messageLog: log}
+		ctx := WithOrigin(context.Background(), Origin{UserID: "u1", Transcript: "1. TSEL10\n2. TSEL25"})
+
+		c.auditSent(ctx, sentMessage{kind: "list", id: "OUT3", to: to, content: "Pilih produk"})
+
+		if len(log.records) != 1 || *log.records[0].Content != "1. TSEL10\n2. TSEL25" {
+			t.Fatalf("records = %+v", log.records)
+		}
+	})
+
+	t.Run("unknown recipient skipped", func(t *testing.T) {
+		log := &fakeMessageLog{}
+		c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), messageLog: log}
+
+		c.auditSent(context.Background(), sentMessage{kind: "text", id: "OUT4", to: to, content: "admin alert"})
+
+		if len(log.records) != 0 {
+			t.Fatalf("records = %+v, want none", log.records)
+		}
+	})
+}
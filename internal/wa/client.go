@@ -8,7 +8,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"bot-jual/internal/activity"
 	"bot-jual/internal/metrics"
 
 	"go.mau.fi/whatsmeow"
@@ -26,14 +30,45 @@ type Config struct {
 	StorePath string
 	LogLevel  string
 	Metrics   *metrics.Metrics
+	// TypingDelayPerChar enables a "composing" pause before each text reply,
+	// proportional to its length and capped at TypingDelayMax. Zero disables it.
+	TypingDelayPerChar time.Duration
+	TypingDelayMax     time.Duration
+	// ResendAfter resends text messages without a delivery receipt after this
+	// long, at most MaxResends times. Zero disables resending.
+	ResendAfter time.Duration
+	MaxResends  int
+	// PairPhone switches pairing from QR codes to a code entered on the
+	// phone with this number (international form, digits only).
+	PairPhone string
+	// Events receives session alerts such as a logout, which admins cannot
+	// be told about over WhatsApp; nil drops them.
+	Events *activity.Bus
 }
 
 // Client wraps the WhatsMeow client and associated dependencies.
 type Client struct {
+	mu        sync.RWMutex
 	client    *whatsmeow.Client
+	container *sqlstore.Container
+	waLogger  waLog.Logger
+	runCtx    context.Context
+	pairing   pairing
+	pairPhone string
+	events    *activity.Bus
 	logger    *slog.Logger
 	metrics   *metrics.Metrics
 	processor MessageProcessor
+	inbound   senderQueues
+
+	typingPerChar time.Duration
+	typingMax     time.Duration
+
+	outbound    OutboundStore
+	resendAfter time.Duration
+	maxResends  int
+	messageLog  MessageLog
+	sendFault   func() error
 }
 
 // MessageProcessor handles inbound WhatsApp messages.
@@ -98,36 +133,42 @@ func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Client, error)
 	client := whatsmeow.NewClient(deviceStore, waLogger)
 
 	wc := &Client{
-		client:  client,
-		logger:  logger.With("component", "wa"),
-		metrics: cfg.Metrics,
+		client:    client,
+		container: container,
+		waLogger:  waLogger,
+		pairPhone: cfg.PairPhone,
+		events:    cfg.Events,
+		logger:    logger.With("component", "wa"),
+		metrics:   cfg.Metrics,
+
+		typingPerChar: cfg.TypingDelayPerChar,
+		typingMax:     cfg.TypingDelayMax,
+
+		resendAfter: cfg.ResendAfter,
+		maxResends:  cfg.MaxResends,
 	}
 	client.AddEventHandler(wc.handleEvent)
 
 	return wc, nil
 }
 
-// Start connects the client and handles login/QR pairing flow.
+// Start connects the client and handles login/QR pairing flow. An unpaired
+// device keeps offering QR codes until one is scanned or ctx ends.
 func (c *Client) Start(ctx context.Context) error {
-	if c.client.Store.ID == nil {
-		c.logger.Info("pairing required, waiting for QR scan")
-		qrChan, err := c.client.GetQRChannel(ctx)
-		if err != nil {
-			return fmt.Errorf("get qr channel: %w", err)
+	c.runCtx = ctx
+	cli := c.current()
+	if cli.Store.ID == nil {
+		if c.pairPhone != "" {
+			c.logger.Info("pairing required, requesting a pairing code", "phone", c.pairPhone)
+		} else {
+			c.logger.Info("pairing required, waiting for QR scan")
 		}
-
-		go func() {
-			for evt := range qrChan {
-				if evt.Event == "code" {
-					c.logger.Info("scan the QR code with WhatsApp", "qr", evt.Code)
-				} else {
-					c.logger.Info("pairing event received", "event", evt.Event)
-				}
-			}
-		}()
+		go c.pairLoop(ctx, cli)
+		return nil
 	}
 
-	if err := c.client.Connect(); err != nil {
+	c.setState(StatePaired)
+	if err := cli.Connect(); err != nil {
 		return fmt.Errorf("connect wa client: %w", err)
 	}
 
@@ -137,8 +178,8 @@ func (c *Client) Start(ctx context.Context) error {
 
 // Close disconnects the WhatsApp client.
 func (c *Client) Close() {
-	if c.client != nil {
-		c.client.Disconnect()
+	if cli := c.current(); cli != nil {
+		cli.Disconnect()
 	}
 }
 
@@ -146,10 +187,14 @@ func (c *Client) handleEvent(evt interface{}) {
 	switch v := evt.(type) {
 	case *events.Message:
 		c.handleMessage(v)
+	case *events.Receipt:
+		c.handleReceipt(v)
 	case *events.Connected:
 		c.logger.Info("device connected")
 	case *events.Disconnected:
 		c.logger.Warn("device disconnected")
+	case *events.LoggedOut:
+		go c.handleLoggedOut(v)
 	}
 }
 
@@ -161,23 +206,31 @@ func (c *Client) handleMessage(evt *events.Message) {
 
 	sender := evt.Info.Sender.String()
 
+	// Bodies stay out of the log: they carry transaction PINs, target
+	// numbers and account numbers, and the log ring is served to admins.
 	switch {
 	case msg.GetConversation() != "":
-		c.logger.Info("received text message", "from", sender, "text", msg.GetConversation())
+		c.logger.Info("received text message", "from", sender, "length", utf8.RuneCountInString(msg.GetConversation()))
 	case msg.ExtendedTextMessage != nil:
-		c.logger.Info("received extended text message", "from", sender, "text", msg.GetExtendedTextMessage().GetText())
+		c.logger.Info("received extended text message", "from", sender, "length", utf8.RuneCountInString(msg.GetExtendedTextMessage().GetText()))
 	case msg.ImageMessage != nil:
-		c.logger.Info("received image message", "from", sender, "caption", msg.GetImageMessage().GetCaption())
+		c.logger.Info("received image message", "from", sender, "caption_length", utf8.RuneCountInString(msg.GetImageMessage().GetCaption()))
 	case msg.VideoMessage != nil:
-		c.logger.Info("received video message", "from", sender, "caption", msg.GetVideoMessage().GetCaption())
+		c.logger.Info("received video message", "from", sender, "caption_length", utf8.RuneCountInString(msg.GetVideoMessage().GetCaption()))
 	case msg.AudioMessage != nil:
 		c.logger.Info("received audio message", "from", sender, "ptt", msg.GetAudioMessage().GetPTT())
+	case InteractiveSelection(msg) != "":
+		c.logger.Info("received interactive reply", "from", sender, "selection", InteractiveSelection(msg))
 	default:
 		c.logger.Info("received unsupported message type", "from", sender)
 	}
 
 	if c.processor != nil {
-		go c.processor.ProcessMessage(context.Background(), evt)
+		// Messages from one sender share a session, so they are handled in
+		// order; different senders proceed in parallel.
+		c.inbound.run(evt.Info.Sender.ToNonAD().String(), func() {
+			c.processor.ProcessMessage(context.Background(), evt)
+		})
 	}
 }
 
@@ -196,6 +249,31 @@ func (c *Client) SetMessageProcessor(processor MessageProcessor) {
 	c.processor = processor
 }
 
+// IsContact reports whether jid is saved in the device's address book, as
+// opposed to only being known by the push name it chats with.
+func (c *Client) IsContact(ctx context.Context, jid types.JID) (bool, error) {
+	info, err := c.current().Store.Contacts.GetContact(ctx, jid)
+	if err != nil {
+		return false, fmt.Errorf("get contact: %w", err)
+	}
+	return info.Found && (info.FullName != "" || info.FirstName != ""), nil
+}
+
+// SetSendFault makes every send first call fault and fail with its error,
+// if any, before reaching WhatsApp. It exists for failure injection.
+func (c *Client) SetSendFault(fault func() error) {
+	c.sendFault = fault
+}
+
+func (c *Client) sendMessage(ctx context.Context, to types.JID, message *waProto.Message) (whatsmeow.SendResponse, error) {
+	if c.sendFault != nil {
+		if err := c.sendFault(); err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+	return c.current().SendMessage(ctx, to, message)
+}
+
 // SendText sends a text message to the specified JID.
 func (c *Client) SendText(ctx context.Context, to types.JID, text string) error {
 	reply := replyFromContext(ctx)
@@ -219,13 +297,18 @@ func (c *Client) SendText(ctx context.Context, to types.JID, text string) error
 			Conversation: proto.String(text),
 		}
 	}
-	_, err := c.client.SendMessage(ctx, to, message)
+	if err := c.simulateTyping(ctx, to, text); err != nil {
+		return err
+	}
+	resp, err := c.sendMessage(ctx, to, message)
 	if err != nil {
 		return fmt.Errorf("send text: %w", err)
 	}
 	if c.metrics != nil {
 		c.metrics.WAOutgoingMessages.WithLabelValues("text").Inc()
 	}
+	c.trackSent(ctx, resp.ID, to, text, 0)
+	c.auditSent(ctx, sentMessage{kind: "text", id: resp.ID, to: to, content: text, message: message})
 	return nil
 }
 
@@ -240,7 +323,7 @@ func (c *Client) SendImage(ctx context.Context, to types.JID, data []byte, mimeT
 			mimeType = "image/png"
 		}
 	}
-	uploadResp, err := c.client.Upload(ctx, data, whatsmeow.MediaImage)
+	uploadResp, err := c.current().Upload(ctx, data, whatsmeow.MediaImage)
 	if err != nil {
 		return fmt.Errorf("upload image: %w", err)
 	}
@@ -261,18 +344,65 @@ func (c *Client) SendImage(ctx context.Context, to types.JID, data []byte, mimeT
 	message := &waProto.Message{
 		ImageMessage: imageMsg,
 	}
-	if _, err := c.client.SendMessage(ctx, to, message); err != nil {
+	resp, err := c.sendMessage(ctx, to, message)
+	if err != nil {
 		return fmt.Errorf("send image: %w", err)
 	}
 	if c.metrics != nil {
 		c.metrics.WAOutgoingMessages.WithLabelValues("image").Inc()
 	}
+	c.auditSent(ctx, sentMessage{kind: "image", id: resp.ID, to: to, content: caption, mediaURL: uploadResp.URL, message: message})
+	return nil
+}
+
+// SendDocument uploads and sends a file, such as a PDF receipt, to the
+// specified JID under fileName.
+func (c *Client) SendDocument(ctx context.Context, to types.JID, data []byte, mimeType, fileName, caption string) error {
+	if len(data) == 0 {
+		return errors.New("send document: empty data")
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	uploadResp, err := c.current().Upload(ctx, data, whatsmeow.MediaDocument)
+	if err != nil {
+		return fmt.Errorf("upload document: %w", err)
+	}
+
+	docMsg := &waProto.DocumentMessage{
+		URL:           proto.String(uploadResp.URL),
+		DirectPath:    proto.String(uploadResp.DirectPath),
+		MediaKey:      uploadResp.MediaKey,
+		FileEncSHA256: uploadResp.FileEncSHA256,
+		FileSHA256:    uploadResp.FileSHA256,
+		FileLength:    proto.Uint64(uploadResp.FileLength),
+		Mimetype:      proto.String(mimeType),
+	}
+	if fileName != "" {
+		docMsg.FileName = proto.String(fileName)
+		docMsg.Title = proto.String(fileName)
+	}
+	if caption != "" {
+		docMsg.Caption = proto.String(caption)
+	}
+
+	message := &waProto.Message{
+		DocumentMessage: docMsg,
+	}
+	resp, err := c.sendMessage(ctx, to, message)
+	if err != nil {
+		return fmt.Errorf("send document: %w", err)
+	}
+	if c.metrics != nil {
+		c.metrics.WAOutgoingMessages.WithLabelValues("document").Inc()
+	}
+	c.auditSent(ctx, sentMessage{kind: "document", id: resp.ID, to: to, content: caption, mediaURL: uploadResp.URL, message: message})
 	return nil
 }
 
 // DownloadMedia downloads the media content from a message and returns bytes and mime type.
 func (c *Client) DownloadMedia(ctx context.Context, msg *waProto.Message) ([]byte, string, error) {
-	data, err := c.client.DownloadAny(ctx, msg)
+	data, err := c.current().DownloadAny(ctx, msg)
 	if err != nil {
 		return nil, "", fmt.Errorf("download media: %w", err)
 	}
@@ -298,3 +428,36 @@ func (c *Client) DownloadMedia(ctx context.Context, msg *waProto.Message) ([]byt
 	}
 	return data, mime, nil
 }
+
+// simulateTyping shows "composing" for a length-proportional delay before a reply.
+func (c *Client) simulateTyping(ctx context.Context, to types.JID, text string) error {
+	delay := typingDelay(text, c.typingPerChar, c.typingMax)
+	if delay <= 0 {
+		return nil
+	}
+	if err := c.current().SendChatPresence(ctx, to, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil {
+		c.logger.Debug("failed sending composing presence", "error", err)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	if err := c.current().SendChatPresence(ctx, to, types.ChatPresencePaused, types.ChatPresenceMediaText); err != nil {
+		c.logger.Debug("failed sending paused presence", "error", err)
+	}
+	return nil
+}
+
+func typingDelay(text string, perChar, maxDelay time.Duration) time.Duration {
+	if perChar <= 0 {
+		return 0
+	}
+	delay := time.Duration(utf8.RuneCountInString(text)) * perChar
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,45 @@
+package wa
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTypingDelay(t *testing.T) {
+	if got := typingDelay("halo", 0, time.Second); got != 0 {
+		t.Fatalf("disabled delay = %v, want 0", got)
+	}
+	if got := typingDelay("halo kak", 50*time.Millisecond, time.Second); got != 400*time.Millisecond {
+		t.Fatalf("delay = %v, want 400ms", got)
+	}
+	if got := typingDelay("pesan yang cukup panjang sekali", 100*time.Millisecond, time.Second); got != time.Second {
+		t.Fatalf("capped delay = %v, want 1s", got)
+	}
+}
+
+func TestHandleMessageKeepsBodiesOutOfLog(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Client{logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	sender := types.MessageSource{Sender: types.NewJID("6281111", types.DefaultUserServer)}
+	for _, msg := range []*waProto.Message{
+		{Conversation: proto.String("493817")},
+		{ExtendedTextMessage: &waProto.ExtendedTextMessage{Text: proto.String("493817")}},
+		{ImageMessage: &waProto.ImageMessage{Caption: proto.String("493817")}},
+	} {
+		c.handleMessage(&events.Message{Info: types.MessageInfo{MessageSource: sender}, Message: msg})
+	}
+	if strings.Contains(buf.String(), "493817") {
+		t.Fatalf("message body logged:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "length=6") {
+		t.Fatalf("message not logged:\n%s", buf.String())
+	}
+}
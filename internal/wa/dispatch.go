@@ -0,0 +1,43 @@
+package wa
+
+import "sync"
+
+// senderQueues runs work for the same key one at a time, in arrival order,
+// while different keys run in parallel. A key's worker exits as soon as its
+// queue drains, so idle senders hold no goroutine.
+type senderQueues struct {
+	mu sync.Mutex
+	// queues holds the pending work of every key with a running worker.
+	queues map[string][]func()
+}
+
+// run queues fn behind any work already pending for key.
+func (q *senderQueues) run(key string, fn func()) {
+	q.mu.Lock()
+	if pending, busy := q.queues[key]; busy {
+		q.queues[key] = append(pending, fn)
+		q.mu.Unlock()
+		return
+	}
+	if q.queues == nil {
+		q.queues = make(map[string][]func())
+	}
+	q.queues[key] = nil
+	q.mu.Unlock()
+	go q.drain(key, fn)
+}
+
+func (q *senderQueues) drain(key string, fn func()) {
+	for fn != nil {
+		fn()
+		q.mu.Lock()
+		if pending := q.queues[key]; len(pending) > 0 {
+			fn = pending[0]
+			q.queues[key] = pending[1:]
+		} else {
+			delete(q.queues, key)
+			fn = nil
+		}
+		q.mu.Unlock()
+	}
+}
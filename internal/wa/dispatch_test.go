@@ -0,0 +1,62 @@
+package wa
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSenderQueuesSerialisePerKey(t *testing.T) {
+	var q senderQueues
+	var (
+		mu      sync.Mutex
+		order   []int
+		running int
+		overlap bool
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		q.run("a", func() {
+			defer wg.Done()
+			mu.Lock()
+			running++
+			overlap = overlap || running > 1
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	if overlap {
+		t.Fatalf("work for one key overlapped")
+	}
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("order = %v, want arrival order", order)
+		}
+	}
+	q.mu.Lock()
+	left := len(q.queues)
+	q.mu.Unlock()
+	if left != 0 {
+		t.Fatalf("%d queues left after draining", left)
+	}
+}
+
+func TestSenderQueuesParallelAcrossKeys(t *testing.T) {
+	var q senderQueues
+	release := make(chan struct{})
+	done := make(chan struct{})
+	q.run("a", func() { <-release })
+	q.run("b", func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("a blocked key stalled another key")
+	}
+	close(release)
+}
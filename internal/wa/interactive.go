@@ -0,0 +1,171 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// WhatsApp rejects interactive messages beyond these limits.
+const (
+	maxListRows          = 10
+	maxButtons           = 3
+	maxRowTitleLen       = 24
+	maxRowDescLen        = 72
+	maxSectionTitleLen   = 24
+	maxButtonTextLen     = 20
+	maxListButtonTextLen = 20
+)
+
+// ListRow is one tappable entry of a list message. ID comes back as the
+// user's selection.
+type ListRow struct {
+	ID          string
+	Title       string
+	Description string
+}
+
+// ListSection groups rows under a heading.
+type ListSection struct {
+	Title string
+	Rows  []ListRow
+}
+
+// Button is a quick-reply button. ID comes back as the user's selection.
+type Button struct {
+	ID   string
+	Text string
+}
+
+// SendListMessage sends a menu the user opens with buttonText and picks one
+// row from. At most 10 rows fit in a list.
+func (c *Client) SendListMessage(ctx context.Context, to types.JID, title, body, buttonText string, sections []ListSection) error {
+	msg, err := buildListMessage(title, body, buttonText, sections)
+	if err != nil {
+		return fmt.Errorf("send list: %w", err)
+	}
+	if err := c.simulateTyping(ctx, to, body); err != nil {
+		return err
+	}
+	// Interactive messages are not tracked for resending: a resend would
+	// arrive as plain text without the choices.
+	message := &waProto.Message{ListMessage: msg}
+	resp, err := c.sendMessage(ctx, to, message)
+	if err != nil {
+		return fmt.Errorf("send list: %w", err)
+	}
+	if c.metrics != nil {
+		c.metrics.WAOutgoingMessages.WithLabelValues("list").Inc()
+	}
+	c.auditSent(ctx, sentMessage{kind: "list", id: resp.ID, to: to, content: body, message: message})
+	return nil
+}
+
+// SendButtons sends body with up to three quick-reply buttons.
+func (c *Client) SendButtons(ctx context.Context, to types.JID, body, footer string, buttons []Button) error {
+	msg, err := buildButtonsMessage(body, footer, buttons)
+	if err != nil {
+		return fmt.Errorf("send buttons: %w", err)
+	}
+	if err := c.simulateTyping(ctx, to, body); err != nil {
+		return err
+	}
+	message := &waProto.Message{ButtonsMessage: msg}
+	resp, err := c.sendMessage(ctx, to, message)
+	if err != nil {
+		return fmt.Errorf("send buttons: %w", err)
+	}
+	if c.metrics != nil {
+		c.metrics.WAOutgoingMessages.WithLabelValues("buttons").Inc()
+	}
+	c.auditSent(ctx, sentMessage{kind: "buttons", id: resp.ID, to: to, content: body, message: message})
+	return nil
+}
+
+func buildListMessage(title, body, buttonText string, sections []ListSection) (*waProto.ListMessage, error) {
+	msg := &waProto.ListMessage{
+		Title:       proto.String(title),
+		Description: proto.String(body),
+		ButtonText:  proto.String(truncate(buttonText, maxListButtonTextLen)),
+		ListType:    waProto.ListMessage_SINGLE_SELECT.Enum(),
+	}
+	rows := 0
+	for _, section := range sections {
+		if len(section.Rows) == 0 {
+			continue
+		}
+		out := &waProto.ListMessage_Section{Title: proto.String(truncate(section.Title, maxSectionTitleLen))}
+		for _, row := range section.Rows {
+			if row.ID == "" {
+				return nil, errors.New("list row without id")
+			}
+			out.Rows = append(out.Rows, &waProto.ListMessage_Row{
+				RowID:       proto.String(row.ID),
+				Title:       proto.String(truncate(row.Title, maxRowTitleLen)),
+				Description: proto.String(truncate(row.Description, maxRowDescLen)),
+			})
+		}
+		rows += len(out.Rows)
+		msg.Sections = append(msg.Sections, out)
+	}
+	if rows == 0 {
+		return nil, errors.New("list has no rows")
+	}
+	if rows > maxListRows {
+		return nil, fmt.Errorf("list has %d rows, at most %d allowed", rows, maxListRows)
+	}
+	return msg, nil
+}
+
+func buildButtonsMessage(body, footer string, buttons []Button) (*waProto.ButtonsMessage, error) {
+	if len(buttons) == 0 || len(buttons) > maxButtons {
+		return nil, fmt.Errorf("need 1 to %d buttons, got %d", maxButtons, len(buttons))
+	}
+	msg := &waProto.ButtonsMessage{
+		ContentText: proto.String(body),
+		HeaderType:  waProto.ButtonsMessage_EMPTY.Enum(),
+	}
+	if footer != "" {
+		msg.FooterText = proto.String(footer)
+	}
+	for _, b := range buttons {
+		if b.ID == "" {
+			return nil, errors.New("button without id")
+		}
+		msg.Buttons = append(msg.Buttons, &waProto.ButtonsMessage_Button{
+			ButtonID:   proto.String(b.ID),
+			ButtonText: &waProto.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(truncate(b.Text, maxButtonTextLen))},
+			Type:       waProto.ButtonsMessage_Button_RESPONSE.Enum(),
+		})
+	}
+	return msg, nil
+}
+
+// InteractiveSelection returns the ID of the list row or button the user
+// tapped, or "" when msg is not a reply to an interactive message.
+func InteractiveSelection(msg *waProto.Message) string {
+	switch {
+	case msg.GetListResponseMessage() != nil:
+		return strings.TrimSpace(msg.GetListResponseMessage().GetSingleSelectReply().GetSelectedRowID())
+	case msg.GetButtonsResponseMessage() != nil:
+		return strings.TrimSpace(msg.GetButtonsResponseMessage().GetSelectedButtonID())
+	case msg.GetTemplateButtonReplyMessage() != nil:
+		return strings.TrimSpace(msg.GetTemplateButtonReplyMessage().GetSelectedID())
+	default:
+		return ""
+	}
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
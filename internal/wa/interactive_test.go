@@ -0,0 +1,65 @@
+package wa
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildListMessage(t *testing.T) {
+	msg, err := buildListMessage("", "Pilih produk", "Lihat daftar produk lengkap", []ListSection{
+		{Title: "Pulsa", Rows: []ListRow{{ID: "/beli TSEL25", Title: "#1 Telkomsel 25.000 Promo Spesial", Description: "TSEL25 · Rp25.500"}}},
+		{Title: "Kosong"},
+	})
+	if err != nil {
+		t.Fatalf("buildListMessage: %v", err)
+	}
+	if len(msg.GetSections()) != 1 {
+		t.Fatalf("empty section kept: %d sections", len(msg.GetSections()))
+	}
+	row := msg.GetSections()[0].GetRows()[0]
+	if row.GetRowID() != "/beli TSEL25" || row.GetTitle() != "#1 Telkomsel 25.000 Pro…" {
+		t.Fatalf("row = %q %q", row.GetRowID(), row.GetTitle())
+	}
+	if got := msg.GetButtonText(); got != "Lihat daftar produk…" {
+		t.Fatalf("button text = %q", got)
+	}
+
+	rows := make([]ListRow, maxListRows+1)
+	for i := range rows {
+		rows[i] = ListRow{ID: "x", Title: "x"}
+	}
+	if _, err := buildListMessage("", "body", "Pilih", []ListSection{{Rows: rows}}); err == nil {
+		t.Fatalf("list with %d rows accepted", len(rows))
+	}
+}
+
+func TestBuildButtonsMessage(t *testing.T) {
+	if _, err := buildButtonsMessage("body", "", make([]Button, 4)); err == nil {
+		t.Fatalf("four buttons accepted")
+	}
+	msg, err := buildButtonsMessage("Mau bayar pakai apa?", "batal untuk membatalkan", []Button{{ID: "qris", Text: "QRIS"}})
+	if err != nil {
+		t.Fatalf("buildButtonsMessage: %v", err)
+	}
+	if msg.GetButtons()[0].GetButtonID() != "qris" || msg.GetFooterText() != "batal untuk membatalkan" {
+		t.Fatalf("unexpected message %v", msg)
+	}
+}
+
+func TestInteractiveSelection(t *testing.T) {
+	list := &waProto.Message{ListResponseMessage: &waProto.ListResponseMessage{
+		SingleSelectReply: &waProto.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("/beli TSEL25")},
+	}}
+	if got := InteractiveSelection(list); got != "/beli TSEL25" {
+		t.Fatalf("list selection = %q", got)
+	}
+	buttons := &waProto.Message{ButtonsResponseMessage: &waProto.ButtonsResponseMessage{SelectedButtonID: proto.String("qris")}}
+	if got := InteractiveSelection(buttons); got != "qris" {
+		t.Fatalf("button selection = %q", got)
+	}
+	if got := InteractiveSelection(&waProto.Message{Conversation: proto.String("halo")}); got != "" {
+		t.Fatalf("text message selection = %q", got)
+	}
+}
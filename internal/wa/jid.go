@@ -0,0 +1,17 @@
+package wa
+
+import (
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ParseAdminJID accepts either a full JID or a bare phone number, as admins
+// are listed in ADMIN_JIDS.
+func ParseAdminJID(raw string) (types.JID, error) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "+")
+	if !strings.Contains(raw, "@") {
+		raw += "@" + types.DefaultUserServer
+	}
+	return types.ParseJID(raw)
+}
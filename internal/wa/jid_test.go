@@ -0,0 +1,25 @@
+package wa
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseAdminJID(t *testing.T) {
+	cases := map[string]types.JID{
+		"6281234567890":                types.NewJID("6281234567890", types.DefaultUserServer),
+		" +6281234567890 ":             types.NewJID("6281234567890", types.DefaultUserServer),
+		"6281234567890@s.whatsapp.net": types.NewJID("6281234567890", types.DefaultUserServer),
+		"123456789@lid":                types.NewJID("123456789", types.HiddenUserServer),
+	}
+	for raw, want := range cases {
+		got, err := ParseAdminJID(raw)
+		if err != nil {
+			t.Fatalf("ParseAdminJID(%q): %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("ParseAdminJID(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package wa
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"bot-jual/internal/activity"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Pairing states reported by Status.
+const (
+	StatePaired    = "paired"
+	StatePairing   = "pairing"
+	StateLoggedOut = "logged_out"
+)
+
+// pairRetryDelay spaces out pairing attempts after the QR codes run out or
+// connecting fails, so an unattended bot does not hammer WhatsApp.
+const pairRetryDelay = 10 * time.Second
+
+// pairClientName is how the bot shows up under linked devices. WhatsApp only
+// accepts common "Browser (OS)" names here.
+const pairClientName = "Chrome (Linux)"
+
+// PairingStatus describes the WhatsApp session for readiness and re-pairing.
+type PairingStatus struct {
+	State string
+	// QRCode is the latest pairing code while State is pairing.
+	QRCode      string
+	QRUpdatedAt time.Time
+	// PairCode is the code to enter on the phone when pairing by phone
+	// number; it stays valid until the QR codes of the same round run out.
+	PairCode string
+	// LoggedOutAt and Reason describe the last logout, if any.
+	LoggedOutAt time.Time
+	Reason      string
+	Connected   bool
+}
+
+type pairing struct {
+	mu     sync.Mutex
+	status PairingStatus
+}
+
+// Status reports whether the session is paired and, while pairing, the QR
+// code to scan.
+func (c *Client) Status() PairingStatus {
+	c.pairing.mu.Lock()
+	status := c.pairing.status
+	c.pairing.mu.Unlock()
+	status.Connected = c.current().IsConnected()
+	return status
+}
+
+func (c *Client) setState(state string) {
+	c.pairing.mu.Lock()
+	c.pairing.status.State = state
+	if state != StatePairing {
+		c.pairing.status.QRCode = ""
+		c.pairing.status.QRUpdatedAt = time.Time{}
+		c.pairing.status.PairCode = ""
+	}
+	c.pairing.mu.Unlock()
+	if c.metrics != nil {
+		paired := 0.0
+		if state == StatePaired {
+			paired = 1
+		}
+		c.metrics.WAPaired.Set(paired)
+	}
+}
+
+func (c *Client) setPairCode(code string) {
+	c.pairing.mu.Lock()
+	c.pairing.status.PairCode = code
+	c.pairing.mu.Unlock()
+}
+
+func (c *Client) setQRCode(code string) {
+	c.pairing.mu.Lock()
+	c.pairing.status.QRCode = code
+	c.pairing.status.QRUpdatedAt = time.Now()
+	c.pairing.mu.Unlock()
+}
+
+// markLoggedOut records the logout and reports false when it was already
+// handled, since both the old session and whatsmeow can report it.
+func (c *Client) markLoggedOut(reason string) bool {
+	c.pairing.mu.Lock()
+	defer c.pairing.mu.Unlock()
+	if c.pairing.status.State != StatePaired {
+		return false
+	}
+	c.pairing.status.LoggedOutAt = time.Now()
+	c.pairing.status.Reason = reason
+	return true
+}
+
+// current returns the active whatsmeow client, which is replaced on logout.
+func (c *Client) current() *whatsmeow.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+func (c *Client) newSession() *whatsmeow.Client {
+	cli := whatsmeow.NewClient(c.container.NewDevice(), c.waLogger)
+	cli.AddEventHandler(c.handleEvent)
+	c.mu.Lock()
+	c.client = cli
+	c.mu.Unlock()
+	return cli
+}
+
+// pairLoop offers pairing codes until the device is linked, starting over
+// whenever the codes run out. It returns once paired, or when ctx ends or cli is replaced.
+func (c *Client) pairLoop(ctx context.Context, cli *whatsmeow.Client) {
+	c.setState(StatePairing)
+	for {
+		qrChan, err := cli.GetQRChannel(ctx)
+		if err == nil {
+			err = cli.Connect()
+		}
+		if err != nil {
+			c.logger.Warn("failed starting whatsapp pairing", "error", err)
+		} else if c.watchQR(ctx, cli, qrChan) {
+			return
+		}
+		cli.Disconnect()
+
+		if c.current() != cli {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pairRetryDelay):
+		}
+	}
+}
+
+// watchQR publishes pairing codes and reports whether pairing succeeded.
+// With a pairing phone configured, a phone-number code is requested as soon
+// as the first QR code shows the connection is ready.
+func (c *Client) watchQR(ctx context.Context, cli *whatsmeow.Client, qrChan <-chan whatsmeow.QRChannelItem) bool {
+	c.setPairCode("")
+	requested := false
+	for evt := range qrChan {
+		switch evt.Event {
+		case whatsmeow.QRChannelEventCode:
+			c.setQRCode(evt.Code)
+			if c.pairPhone == "" {
+				c.logger.Info("scan the QR code with WhatsApp", "qr", evt.Code)
+				continue
+			}
+			if !requested {
+				requested = true
+				c.requestPairCode(ctx, cli)
+			}
+		case whatsmeow.QRChannelSuccess.Event:
+			c.setState(StatePaired)
+			c.logger.Info("whatsapp device paired")
+			return true
+		default:
+			c.logger.Info("pairing event received", "event", evt.Event, "error", evt.Error)
+		}
+	}
+	return false
+}
+
+func (c *Client) requestPairCode(ctx context.Context, cli *whatsmeow.Client) {
+	code, err := cli.PairPhone(ctx, c.pairPhone, true, whatsmeow.PairClientChrome, pairClientName)
+	if err != nil {
+		c.logger.Error("failed requesting whatsapp pairing code", "error", err, "phone", c.pairPhone)
+		return
+	}
+	c.setPairCode(code)
+	c.logger.Info("enter the pairing code in WhatsApp under Linked devices > Link with phone number", "code", code, "phone", c.pairPhone)
+}
+
+// handleLoggedOut alerts admins, drops the revoked session and starts pairing
+// a new device, so the bot can be linked again without restarting it.
+func (c *Client) handleLoggedOut(evt *events.LoggedOut) {
+	reason := "device removed"
+	if evt.OnConnect {
+		reason = evt.Reason.String()
+	}
+	if !c.markLoggedOut(reason) {
+		return
+	}
+	c.setState(StateLoggedOut)
+	c.logger.Error("whatsapp session logged out, waiting for re-pairing", "reason", reason)
+	if c.metrics != nil {
+		c.metrics.WALogouts.WithLabelValues(reason).Inc()
+	}
+	if c.events != nil {
+		c.events.Publish(activity.WhatsAppLoggedOut, activity.WhatsAppEvent{Reason: reason})
+	}
+
+	ctx := c.runCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	old := c.current()
+	old.RemoveEventHandlers()
+	old.Disconnect()
+	// whatsmeow usually deletes the device itself; this covers the cases
+	// where it did not, so a stale session is never reused after restart.
+	if old.Store.ID != nil {
+		if err := old.Store.Delete(ctx); err != nil && !errors.Is(err, sqlstore.ErrDeviceIDMustBeSet) {
+			c.logger.Warn("failed deleting logged out device", "error", err)
+		}
+	}
+
+	go c.pairLoop(ctx, c.newSession())
+}
@@ -0,0 +1,27 @@
+package wa
+
+import "testing"
+
+func TestMarkLoggedOutOnlyOnce(t *testing.T) {
+	c := &Client{}
+	c.setState(StatePairing)
+	c.setQRCode("2@abc")
+	if c.markLoggedOut("logged out") {
+		t.Fatal("logout while pairing should be ignored")
+	}
+
+	c.setState(StatePaired)
+	if c.pairing.status.QRCode != "" {
+		t.Fatalf("qr code kept after pairing: %q", c.pairing.status.QRCode)
+	}
+	if !c.markLoggedOut("logged out") {
+		t.Fatal("first logout not recorded")
+	}
+	c.setState(StateLoggedOut)
+	if c.markLoggedOut("device removed") {
+		t.Fatal("second logout should be ignored")
+	}
+	if got := c.pairing.status; got.Reason != "logged out" || got.LoggedOutAt.IsZero() {
+		t.Fatalf("status = %+v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package wa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	resendCheckInterval = time.Minute
+	resendBatchSize     = 50
+	receiptTimeout      = 10 * time.Second
+)
+
+// OutboundStore persists sent text messages and their delivery receipts.
+type OutboundStore interface {
+	InsertOutboundMessage(ctx context.Context, msg repo.OutboundMessage) error
+	MarkOutboundDelivered(ctx context.Context, ids []string, at time.Time) ([]repo.OutboundMessage, error)
+	MarkOutboundRead(ctx context.Context, ids []string, at time.Time) (int64, error)
+	ListUndeliveredOutbound(ctx context.Context, sentBefore time.Time, limit int) ([]repo.OutboundMessage, error)
+	SetOutboundStatus(ctx context.Context, id, status string) error
+}
+
+// SetOutboundStore enables receipt tracking of sent text messages.
+func (c *Client) SetOutboundStore(store OutboundStore) {
+	c.outbound = store
+}
+
+func (c *Client) trackSent(ctx context.Context, id types.MessageID, to types.JID, text string, attempts int) {
+	if c.outbound == nil || id == "" {
+		return
+	}
+	if err := c.outbound.InsertOutboundMessage(ctx, repo.OutboundMessage{
+		ID:       string(id),
+		ChatJID:  to.String(),
+		Body:     text,
+		Status:   repo.OutboundSent,
+		Attempts: attempts,
+	}); err != nil {
+		c.logger.Warn("failed recording outbound message", "error", err, "message_id", id)
+	}
+}
+
+func (c *Client) handleReceipt(evt *events.Receipt) {
+	// Receipts from our own devices describe messages we received, not sent.
+	if c.outbound == nil || evt.IsFromMe || len(evt.MessageIDs) == 0 {
+		return
+	}
+	switch evt.Type {
+	case types.ReceiptTypeDelivered, types.ReceiptTypeRead, types.ReceiptTypePlayed:
+	default:
+		return
+	}
+	ids := make([]string, 0, len(evt.MessageIDs))
+	for _, id := range evt.MessageIDs {
+		ids = append(ids, string(id))
+	}
+	at := evt.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+	// A read receipt may be the first one we see, so it counts as delivery too.
+	delivered, err := c.outbound.MarkOutboundDelivered(ctx, ids, at)
+	if err != nil {
+		c.logger.Warn("failed recording delivery receipt", "error", err, "chat", evt.Chat.String())
+		return
+	}
+	for _, msg := range delivered {
+		if c.metrics != nil {
+			c.metrics.WAReceipts.WithLabelValues(repo.OutboundDelivered).Inc()
+			c.metrics.WADeliveryLatency.Observe(at.Sub(msg.SentAt).Seconds())
+		}
+	}
+	if evt.Type == types.ReceiptTypeDelivered {
+		return
+	}
+	read, err := c.outbound.MarkOutboundRead(ctx, ids, at)
+	if err != nil {
+		c.logger.Warn("failed recording read receipt", "error", err, "chat", evt.Chat.String())
+		return
+	}
+	if c.metrics != nil {
+		c.metrics.WAReceipts.WithLabelValues(repo.OutboundRead).Add(float64(read))
+	}
+}
+
+// RunResends resends text messages that got no delivery receipt within
+// ResendAfter, up to MaxResends times each, until ctx is cancelled.
+func (c *Client) RunResends(ctx context.Context) {
+	if c.outbound == nil || c.resendAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(resendCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.resendUndelivered(ctx)
+		}
+	}
+}
+
+func (c *Client) resendUndelivered(ctx context.Context) {
+	pending, err := c.outbound.ListUndeliveredOutbound(ctx, time.Now().Add(-c.resendAfter), resendBatchSize)
+	if err != nil {
+		c.logger.Warn("failed listing undelivered messages", "error", err)
+		return
+	}
+	for _, msg := range pending {
+		outcome, err := c.resend(ctx, msg)
+		if err != nil {
+			c.logger.Warn("failed resending message", "error", err, "message_id", msg.ID, "chat", msg.ChatJID)
+		}
+		if c.metrics != nil {
+			c.metrics.WAResends.WithLabelValues(outcome).Inc()
+		}
+	}
+}
+
+// resend sends a fresh copy of msg and retires the original. It returns the
+// outcome label for metrics.
+func (c *Client) resend(ctx context.Context, msg repo.OutboundMessage) (string, error) {
+	if msg.Attempts >= c.maxResends {
+		return repo.OutboundUndelivered, c.outbound.SetOutboundStatus(ctx, msg.ID, repo.OutboundUndelivered)
+	}
+	to, err := types.ParseJID(msg.ChatJID)
+	if err != nil {
+		return "failed", fmt.Errorf("parse chat jid: %w", err)
+	}
+	// Retire the original first so a slow send is not picked up twice.
+	if err := c.outbound.SetOutboundStatus(ctx, msg.ID, repo.OutboundResent); err != nil {
+		return "failed", err
+	}
+	message := &waProto.Message{Conversation: proto.String(msg.Body)}
+	resp, err := c.sendMessage(ctx, to, message)
+	if err != nil {
+		return "failed", fmt.Errorf("send text: %w", err)
+	}
+	c.trackSent(ctx, resp.ID, to, msg.Body, msg.Attempts+1)
+	auditCtx := WithOrigin(ctx, Origin{Trigger: TriggerResend, CorrelationID: msg.ID})
+	c.auditSent(auditCtx, sentMessage{kind: "text", id: resp.ID, to: to, content: msg.Body, message: message})
+	c.logger.Info("resent undelivered message", "message_id", msg.ID, "new_message_id", resp.ID, "chat", msg.ChatJID)
+	return repo.OutboundResent, nil
+}
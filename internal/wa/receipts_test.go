@@ -0,0 +1,75 @@
+package wa
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"bot-jual/internal/repo"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type fakeOutbound struct {
+	delivered []string
+	read      []string
+	statuses  map[string]string
+}
+
+func (f *fakeOutbound) InsertOutboundMessage(ctx context.Context, msg repo.OutboundMessage) error {
+	return nil
+}
+
+func (f *fakeOutbound) MarkOutboundDelivered(ctx context.Context, ids []string, at time.Time) ([]repo.OutboundMessage, error) {
+	f.delivered = append(f.delivered, ids...)
+	return nil, nil
+}
+
+func (f *fakeOutbound) MarkOutboundRead(ctx context.Context, ids []string, at time.Time) (int64, error) {
+	f.read = append(f.read, ids...)
+	return int64(len(ids)), nil
+}
+
+func (f *fakeOutbound) ListUndeliveredOutbound(ctx context.Context, sentBefore time.Time, limit int) ([]repo.OutboundMessage, error) {
+	return nil, nil
+}
+
+func (f *fakeOutbound) SetOutboundStatus(ctx context.Context, id, status string) error {
+	f.statuses[id] = status
+	return nil
+}
+
+func TestHandleReceipt(t *testing.T) {
+	store := &fakeOutbound{statuses: map[string]string{}}
+	c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), outbound: store}
+
+	c.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"m1"}, Type: types.ReceiptTypeDelivered})
+	c.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"m2"}, Type: types.ReceiptTypeRead})
+	own := &events.Receipt{MessageIDs: []types.MessageID{"m3"}, Type: types.ReceiptTypeRead}
+	own.IsFromMe = true
+	c.handleReceipt(own)
+	c.handleReceipt(&events.Receipt{MessageIDs: []types.MessageID{"m4"}, Type: types.ReceiptTypeRetry})
+
+	if len(store.delivered) != 2 || store.delivered[0] != "m1" || store.delivered[1] != "m2" {
+		t.Fatalf("delivered = %v, want [m1 m2]", store.delivered)
+	}
+	if len(store.read) != 1 || store.read[0] != "m2" {
+		t.Fatalf("read = %v, want [m2]", store.read)
+	}
+}
+
+func TestResendGivesUpAfterMaxResends(t *testing.T) {
+	store := &fakeOutbound{statuses: map[string]string{}}
+	c := &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), outbound: store, maxResends: 1}
+
+	outcome, err := c.resend(context.Background(), repo.OutboundMessage{ID: "m1", ChatJID: "62812@s.whatsapp.net", Attempts: 1})
+	if err != nil {
+		t.Fatalf("resend: %v", err)
+	}
+	if outcome != repo.OutboundUndelivered || store.statuses["m1"] != repo.OutboundUndelivered {
+		t.Fatalf("outcome = %q, status = %q, want undelivered", outcome, store.statuses["m1"])
+	}
+}
@@ -0,0 +1,86 @@
+package wa
+
+import (
+	"context"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReplyRefKey is the order metadata key holding the ReplyRef of the message
+// that placed the order.
+const ReplyRefKey = "reply_to"
+
+// replyRefTextLimit caps the quoted preview kept alongside the reference.
+const replyRefTextLimit = 200
+
+// ReplyRef identifies an inbound message so a notification sent long after
+// the conversation moved on can still quote it. It survives a JSON round trip
+// through order metadata.
+type ReplyRef struct {
+	ID     string `json:"id"`
+	Chat   string `json:"chat"`
+	Sender string `json:"sender"`
+	Text   string `json:"text,omitempty"`
+}
+
+// NewReplyRef captures evt for later quoting; text is the preview shown in
+// the quote bubble.
+func NewReplyRef(evt *events.Message, text string) (ReplyRef, bool) {
+	if evt == nil || evt.Info.ID == "" {
+		return ReplyRef{}, false
+	}
+	return ReplyRef{
+		ID:     string(evt.Info.ID),
+		Chat:   evt.Info.Chat.String(),
+		Sender: evt.Info.Sender.ToNonAD().String(),
+		Text:   truncate(strings.TrimSpace(text), replyRefTextLimit),
+	}, true
+}
+
+// ReplyRefFromMetadata reads the reference stored under ReplyRefKey, either
+// as written or as decoded back from JSON.
+func ReplyRefFromMetadata(meta map[string]any) (ReplyRef, bool) {
+	var ref ReplyRef
+	switch v := meta[ReplyRefKey].(type) {
+	case ReplyRef:
+		ref = v
+	case map[string]any:
+		str := func(key string) string {
+			s, _ := v[key].(string)
+			return s
+		}
+		ref = ReplyRef{ID: str("id"), Chat: str("chat"), Sender: str("sender"), Text: str("text")}
+	default:
+		return ReplyRef{}, false
+	}
+	if ref.ID == "" || ref.Chat == "" {
+		return ReplyRef{}, false
+	}
+	return ref, true
+}
+
+// WithReplyRef is WithReply for a message known only by its stored reference.
+func WithReplyRef(ctx context.Context, ref ReplyRef) context.Context {
+	chat, err := types.ParseJID(ref.Chat)
+	if err != nil || ref.ID == "" {
+		return ctx
+	}
+	sender := chat
+	if ref.Sender != "" {
+		if parsed, err := types.ParseJID(ref.Sender); err == nil {
+			sender = parsed
+		}
+	}
+	meta := &ReplyMetadata{
+		Message: &waProto.Message{Conversation: proto.String(ref.Text)},
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: sender},
+			ID:            types.MessageID(ref.ID),
+		},
+	}
+	return context.WithValue(ctx, replyContextKey{}, meta)
+}
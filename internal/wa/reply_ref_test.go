@@ -0,0 +1,60 @@
+package wa
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplyRefSurvivesMetadataRoundTrip(t *testing.T) {
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:   types.NewJID("6281234567890", types.DefaultUserServer),
+				Sender: types.NewADJID("6281234567890", 0, 3),
+			},
+			ID: "3EB0ABCDEF",
+		},
+		Message: &waProto.Message{Conversation: proto.String("beli TSEL25 081234567890")},
+	}
+	ref, ok := NewReplyRef(evt, "beli TSEL25 081234567890")
+	if !ok {
+		t.Fatalf("NewReplyRef rejected a valid event")
+	}
+
+	raw, err := json.Marshal(map[string]any{ReplyRefKey: ref})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	got, ok := ReplyRefFromMetadata(meta)
+	if !ok || got != ref {
+		t.Fatalf("decoded = %+v, %v; want %+v", got, ok, ref)
+	}
+
+	reply := replyFromContext(WithReplyRef(context.Background(), got))
+	if reply == nil {
+		t.Fatalf("no reply metadata on context")
+	}
+	if reply.Info.ID != "3EB0ABCDEF" || reply.Info.Chat != evt.Info.Chat || reply.Info.Sender.String() != "6281234567890@s.whatsapp.net" {
+		t.Fatalf("reply info = %+v", reply.Info)
+	}
+	if reply.Message.GetConversation() != "beli TSEL25 081234567890" {
+		t.Fatalf("quoted text = %q", reply.Message.GetConversation())
+	}
+
+	if _, ok := ReplyRefFromMetadata(map[string]any{"note": "x"}); ok {
+		t.Fatalf("metadata without a reference reported one")
+	}
+	if ctx := WithReplyRef(context.Background(), ReplyRef{Chat: "6281234567890@s.whatsapp.net"}); replyFromContext(ctx) != nil {
+		t.Fatalf("reference without a message id produced reply metadata")
+	}
+}
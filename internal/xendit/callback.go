@@ -0,0 +1,161 @@
+package xendit
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/metrics"
+)
+
+// EventType marks deposit updates that came from a Xendit callback.
+const EventType = "xendit_payment"
+
+// callbackTokenHeader carries the verification token Xendit shows on the
+// dashboard's webhook settings.
+const callbackTokenHeader = "X-Callback-Token"
+
+// Callback is the body Xendit posts when an invoice changes or a QR code is
+// paid. Invoice callbacks are flat; QR payment callbacks nest the payment
+// under data.
+type Callback struct {
+	ID            string  `json:"id"`
+	ExternalID    string  `json:"external_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	PaidAmount    float64 `json:"paid_amount"`
+	PaymentMethod string  `json:"payment_method"`
+
+	Kind string `json:"event"`
+	Data *struct {
+		ID          string  `json:"id"`
+		QRID        string  `json:"qr_id"`
+		ReferenceID string  `json:"reference_id"`
+		Status      string  `json:"status"`
+		Amount      float64 `json:"amount"`
+	} `json:"data"`
+}
+
+// Ref is the deposit reference the callback is about.
+func (cb Callback) Ref() string {
+	if cb.Data != nil && cb.Data.ReferenceID != "" {
+		return cb.Data.ReferenceID
+	}
+	return cb.ExternalID
+}
+
+// Event maps the callback onto a deposit update for the Atlantic webhook
+// processor. A QR payment is always QRIS and carries the amount paid; an
+// invoice reports the channel the customer picked and, once paid, the paid
+// amount, which is what the deposit is credited with.
+func (cb Callback) Event(headers map[string]string, body []byte, receivedAt time.Time) atl.WebhookEvent {
+	var payload map[string]any
+	_ = json.Unmarshal(body, &payload)
+	status, method, amount := cb.Status, cb.PaymentMethod, cb.Amount
+	if cb.PaidAmount > 0 {
+		amount = cb.PaidAmount
+	}
+	if cb.Data != nil {
+		status, method, amount = cb.Data.Status, "qris", cb.Data.Amount
+	}
+	return atl.WebhookEvent{
+		Type:       EventType,
+		Headers:    headers,
+		Payload:    body,
+		ReceivedAt: receivedAt,
+		Deposit: &atl.DepositUpdate{
+			StatusUpdate: atl.StatusUpdate{
+				Ref:       cb.Ref(),
+				RawStatus: status,
+				Status:    NormalizeStatus(status),
+				Amount:    int64(amount),
+				Payload:   payload,
+			},
+			Method:    method,
+			NetAmount: int64(amount),
+		},
+	}
+}
+
+// WebhookHandler accepts callbacks whose X-Callback-Token matches the
+// dashboard token and hands them to the webhook processor. Xendit retries a
+// callback until it gets a 2xx, so processing errors answer 500.
+type WebhookHandler struct {
+	logger    *slog.Logger
+	metrics   *metrics.Metrics
+	token     string
+	processor atl.WebhookProcessor
+}
+
+// NewWebhookHandler creates a handler for callbacks carrying token.
+func NewWebhookHandler(logger *slog.Logger, metrics *metrics.Metrics, token string, processor atl.WebhookProcessor) *WebhookHandler {
+	return &WebhookHandler{
+		logger:    logger.With("component", "xendit_webhook"),
+		metrics:   metrics,
+		token:     token,
+		processor: processor,
+	}
+}
+
+// ServeHTTP satisfies http.Handler.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.verify(r.Header.Get(callbackTokenHeader)) {
+		h.countError("xendit_webhook_auth")
+		h.logger.Warn("rejected xendit callback with bad token")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.countError("xendit_webhook")
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var cb Callback
+	if err := json.Unmarshal(body, &cb); err != nil || cb.Ref() == "" {
+		h.countError("xendit_webhook_decode")
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	event := cb.Event(atl.WebhookHeaders(r.Header, callbackTokenHeader), body, time.Now())
+	// A PENDING invoice or ACTIVE QR code says nothing new: the deposit
+	// has been waiting for payment since CreateDeposit.
+	if event.Deposit.Status == "pending" {
+		atl.WriteWebhookOK(w)
+		return
+	}
+	if h.processor != nil {
+		if err := h.processor.HandleAtlanticEvent(r.Context(), event); err != nil {
+			h.logger.Error("failed processing xendit callback", "error", err, "ref", cb.Ref(), "status", event.Deposit.RawStatus)
+			h.countError("xendit_webhook_process")
+			http.Error(w, "failed to process", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.logger.Info("xendit callback processed", "ref", cb.Ref(), "status", event.Deposit.RawStatus)
+	atl.WriteWebhookOK(w)
+}
+
+func (h *WebhookHandler) verify(token string) bool {
+	if h.token == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *WebhookHandler) countError(label string) {
+	if h.metrics != nil {
+		h.metrics.Errors.WithLabelValues(label).Inc()
+	}
+}
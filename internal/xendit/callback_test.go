@@ -0,0 +1,61 @@
+package xendit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bot-jual/internal/atl"
+)
+
+type recordingProcessor struct {
+	events []atl.WebhookEvent
+}
+
+func (p *recordingProcessor) HandleAtlanticEvent(_ context.Context, event atl.WebhookEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestWebhookHandlerForwardsVerifiedCallbacks(t *testing.T) {
+	proc := &recordingProcessor{}
+	h := NewWebhookHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, "cb-token", proc)
+	post := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook/xendit", strings.NewReader(body))
+		req.Header.Set("X-Callback-Token", token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	invoicePaid := `{"id":"inv1","external_id":"dep-1","status":"PAID","amount":50000,"paid_amount":50000,"payment_method":"BANK_TRANSFER"}`
+
+	if code := post("wrong", invoicePaid); code != http.StatusUnauthorized {
+		t.Fatalf("bad token answered %d", code)
+	}
+	if code := post("cb-token", `{"id":"inv1","external_id":"dep-1","status":"PENDING","amount":50000}`); code != http.StatusOK {
+		t.Fatalf("pending answered %d", code)
+	}
+	if code := post("cb-token", invoicePaid); code != http.StatusOK {
+		t.Fatalf("invoice paid answered %d", code)
+	}
+	if code := post("cb-token", `{"event":"qr.payment","data":{"id":"qrpy_1","qr_id":"qr_1","reference_id":"dep-2","status":"SUCCEEDED","amount":10000}}`); code != http.StatusOK {
+		t.Fatalf("qr payment answered %d", code)
+	}
+	if len(proc.events) != 2 {
+		t.Fatalf("forwarded %d events, want 2", len(proc.events))
+	}
+	inv, qr := proc.events[0].Deposit, proc.events[1].Deposit
+	if inv.Ref != "dep-1" || inv.Status != "success" || inv.NetAmount != 50000 {
+		t.Fatalf("invoice update = %+v", inv)
+	}
+	if qr.Ref != "dep-2" || qr.Status != "success" || qr.Amount != 10000 || qr.Method != "qris" {
+		t.Fatalf("qr update = %+v", qr)
+	}
+	if _, leaked := proc.events[0].Headers["X-Callback-Token"]; leaked {
+		t.Fatalf("callback token kept in stored headers")
+	}
+}
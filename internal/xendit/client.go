@@ -0,0 +1,339 @@
+// Package xendit takes deposits through Xendit, as dynamic QRIS codes or
+// hosted invoices, in the same shapes the Atlantic client uses so the rest
+// of the bot treats both alike.
+package xendit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/httpx"
+	"bot-jual/internal/money"
+)
+
+const (
+	defaultBaseURL = "https://api.xendit.co"
+	// qrAPIVersion selects the QR codes API the requests below are written for.
+	qrAPIVersion = "2022-07-31"
+	qrIDPrefix   = "qr_"
+)
+
+// invoiceChannels maps deposit methods to the invoice payment method that
+// offers only that channel; other methods let the user choose.
+var invoiceChannels = map[string]string{
+	"bri": "BRI", "bni": "BNI", "bca": "BCA", "mandiri": "MANDIRI", "permata": "PERMATA",
+	"ovo": "OVO", "dana": "DANA", "shopeepay": "SHOPEEPAY", "linkaja": "LINKAJA",
+}
+
+// Config holds Xendit client configuration. Test and live mode follow the
+// secret key.
+type Config struct {
+	SecretKey string
+	// BaseURL overrides the API endpoint.
+	BaseURL string
+	// Expiry is how long a QR code or invoice accepts payment; zero keeps
+	// Xendit's default.
+	Expiry  time.Duration
+	Timeout time.Duration
+	// Transport carries Xendit calls; nil builds a dedicated pooled transport.
+	Transport http.RoundTripper
+}
+
+// Client talks to the Xendit QR codes and invoices APIs.
+type Client struct {
+	logger    *slog.Logger
+	secretKey string
+	baseURL   string
+	expiry    time.Duration
+	http      *http.Client
+	now       func() time.Time
+}
+
+// New creates a Xendit client.
+func New(cfg Config, logger *slog.Logger) *Client {
+	base := defaultBaseURL
+	if cfg.BaseURL != "" {
+		base = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = httpx.NewTransport(httpx.TransportConfig{})
+	}
+	return &Client{
+		logger:    logger.With("component", "xendit"),
+		secretKey: cfg.SecretKey,
+		baseURL:   base,
+		expiry:    cfg.Expiry,
+		http:      &http.Client{Timeout: timeout, Transport: transport},
+		now:       time.Now,
+	}
+}
+
+// qrCode is the QR codes API's code object.
+type qrCode struct {
+	ID          string  `json:"id"`
+	ReferenceID string  `json:"reference_id"`
+	Amount      float64 `json:"amount"`
+	Status      string  `json:"status"`
+	QRString    string  `json:"qr_string"`
+	ChannelCode string  `json:"channel_code"`
+	ExpiresAt   string  `json:"expires_at"`
+	Created     string  `json:"created"`
+}
+
+// invoice is the invoices API's invoice object.
+type invoice struct {
+	ID            string  `json:"id"`
+	ExternalID    string  `json:"external_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	PaidAmount    float64 `json:"paid_amount"`
+	InvoiceURL    string  `json:"invoice_url"`
+	ExpiryDate    string  `json:"expiry_date"`
+	PaymentMethod string  `json:"payment_method"`
+	Created       string  `json:"created"`
+}
+
+// NormalizeStatus maps Xendit invoice, QR code and QR payment statuses to
+// the statuses used for Atlantic deposits: success, pending or failed.
+// Other statuses are returned lowercased.
+func NormalizeStatus(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "PAID", "SETTLED", "SUCCEEDED", "COMPLETED":
+		return "success"
+	case "PENDING", "ACTIVE":
+		return "pending"
+	case "EXPIRED", "INACTIVE", "FAILED":
+		return "failed"
+	case "":
+		return "unknown"
+	default:
+		return strings.ToLower(strings.TrimSpace(status))
+	}
+}
+
+// CreateDeposit collects req.Amount under req.RefID as the Xendit reference.
+// QRIS gets a dynamic QR code so the reply can carry it; any other method
+// opens an invoice page, limited to that channel when Xendit offers it.
+func (c *Client) CreateDeposit(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, error) {
+	if strings.EqualFold(strings.TrimSpace(req.Method), "qris") {
+		return c.createQR(ctx, req)
+	}
+	return c.createInvoice(ctx, req)
+}
+
+func (c *Client) createQR(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, error) {
+	body := map[string]any{
+		"reference_id": req.RefID,
+		"type":         "DYNAMIC",
+		"currency":     "IDR",
+		"amount":       req.Amount.Rupiah(),
+	}
+	if c.expiry > 0 {
+		body["expires_at"] = c.now().Add(c.expiry).UTC().Format(time.RFC3339)
+	}
+	var qr qrCode
+	raw, err := c.do(ctx, http.MethodPost, "/qr_codes", body, &qr)
+	if err != nil {
+		return nil, err
+	}
+	return &atl.DepositResponse{
+		ID:        qr.ID,
+		RefID:     qr.ReferenceID,
+		Status:    NormalizeStatus(qr.Status),
+		QRString:  qr.QRString,
+		ExpiredAt: qr.ExpiresAt,
+		Checkout: map[string]any{
+			"nominal":    req.Amount.Rupiah(),
+			"qr_string":  qr.QRString,
+			"expired_at": qr.ExpiresAt,
+		},
+		Amount:    req.Amount,
+		NetAmount: req.Amount,
+		Raw:       raw,
+	}, nil
+}
+
+func (c *Client) createInvoice(ctx context.Context, req atl.DepositRequest) (*atl.DepositResponse, error) {
+	body := map[string]any{
+		"external_id": req.RefID,
+		"amount":      req.Amount.Rupiah(),
+		"currency":    "IDR",
+		"description": "Deposit " + req.RefID,
+	}
+	if c.expiry > 0 {
+		body["invoice_duration"] = int64(c.expiry / time.Second)
+	}
+	if channel, ok := invoiceChannels[strings.ToLower(strings.TrimSpace(req.Method))]; ok {
+		body["payment_methods"] = []string{channel}
+	}
+	var inv invoice
+	raw, err := c.do(ctx, http.MethodPost, "/v2/invoices", body, &inv)
+	if err != nil {
+		return nil, err
+	}
+	return &atl.DepositResponse{
+		ID:        inv.ID,
+		RefID:     inv.ExternalID,
+		Status:    NormalizeStatus(inv.Status),
+		ExpiredAt: inv.ExpiryDate,
+		Checkout: map[string]any{
+			"nominal":      req.Amount.Rupiah(),
+			"redirect_url": inv.InvoiceURL,
+			"expired_at":   inv.ExpiryDate,
+		},
+		Amount:    req.Amount,
+		NetAmount: req.Amount,
+		Raw:       raw,
+	}, nil
+}
+
+// DepositStatus looks a deposit up by the QR code or invoice ID CreateDeposit
+// returned.
+func (c *Client) DepositStatus(ctx context.Context, id string) (*atl.DepositStatusResponse, error) {
+	if strings.HasPrefix(id, qrIDPrefix) {
+		return c.qrStatus(ctx, id)
+	}
+	var inv invoice
+	raw, err := c.do(ctx, http.MethodGet, "/v2/invoices/"+url.PathEscape(id), nil, &inv)
+	if err != nil {
+		return nil, err
+	}
+	amount := money.FromRupiah(int64(inv.Amount))
+	return &atl.DepositStatusResponse{
+		ID:        inv.ID,
+		RefID:     inv.ExternalID,
+		Status:    NormalizeStatus(inv.Status),
+		Method:    strings.ToLower(inv.PaymentMethod),
+		Amount:    amount,
+		NetAmount: amount,
+		CreatedAt: inv.Created,
+		Raw:       raw,
+	}, nil
+}
+
+// qrStatus reports a QR code paid once any payment on it succeeded, and
+// otherwise by whether the code still accepts payment.
+func (c *Client) qrStatus(ctx context.Context, id string) (*atl.DepositStatusResponse, error) {
+	var payments struct {
+		Data []struct {
+			ID     string  `json:"id"`
+			Amount float64 `json:"amount"`
+			Status string  `json:"status"`
+		} `json:"data"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/qr_codes/"+url.PathEscape(id)+"/payments", nil, &payments); err != nil {
+		return nil, err
+	}
+	var qr qrCode
+	raw, err := c.do(ctx, http.MethodGet, "/qr_codes/"+url.PathEscape(id), nil, &qr)
+	if err != nil {
+		return nil, err
+	}
+	status := NormalizeStatus(qr.Status)
+	for _, payment := range payments.Data {
+		if NormalizeStatus(payment.Status) == "success" {
+			status = "success"
+			break
+		}
+	}
+	amount := money.FromRupiah(int64(qr.Amount))
+	return &atl.DepositStatusResponse{
+		ID:        qr.ID,
+		RefID:     qr.ReferenceID,
+		Status:    status,
+		Method:    "qris",
+		Amount:    amount,
+		NetAmount: amount,
+		CreatedAt: qr.Created,
+		Raw:       raw,
+	}, nil
+}
+
+// CancelDeposit expires a pending invoice so it can no longer be paid.
+// Dynamic QR codes cannot be closed early; they stop accepting payment at
+// the expiry set when they were made, so their status is reported instead.
+func (c *Client) CancelDeposit(ctx context.Context, id string) (*atl.DepositCancelResponse, error) {
+	if strings.HasPrefix(id, qrIDPrefix) {
+		status, err := c.qrStatus(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &atl.DepositCancelResponse{ID: status.ID, Status: status.Status, CreatedAt: status.CreatedAt, Raw: status.Raw}, nil
+	}
+	var inv invoice
+	raw, err := c.do(ctx, http.MethodPost, "/invoices/"+url.PathEscape(id)+"/expire!", nil, &inv)
+	if err != nil {
+		return nil, err
+	}
+	return &atl.DepositCancelResponse{
+		ID:        inv.ID,
+		Status:    NormalizeStatus(inv.Status),
+		CreatedAt: inv.Created,
+		Raw:       raw,
+	}, nil
+}
+
+// do sends body as JSON to path and decodes the response into dest,
+// returning it as a map too for auditing.
+func (c *Client) do(ctx context.Context, method, path string, body any, dest any) (map[string]any, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.SetBasicAuth(c.secretKey, "")
+	req.Header.Set("Accept", "application/json")
+	if strings.HasPrefix(path, "/qr_codes") {
+		req.Header.Set("api-version", qrAPIVersion)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("xendit request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			ErrorCode string `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.ErrorCode != "" {
+			return nil, fmt.Errorf("xendit error: %s (code=%s, http %d)", apiErr.Message, apiErr.ErrorCode, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("xendit http %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return nil, fmt.Errorf("decode response (http %d): %w", resp.StatusCode, err)
+	}
+	var raw map[string]any
+	_ = json.Unmarshal(data, &raw)
+	return raw, nil
+}
@@ -0,0 +1,106 @@
+package xendit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bot-jual/internal/atl"
+	"bot-jual/internal/money"
+)
+
+func newTestClient(t *testing.T, expiry time.Duration, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := New(Config{SecretKey: "xnd_test", BaseURL: srv.URL, Expiry: expiry}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return c
+}
+
+func TestCreateDepositQRCode(t *testing.T) {
+	var body map[string]any
+	c := newTestClient(t, time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != "xnd_test" || r.URL.Path != "/qr_codes" || r.Header.Get("api-version") != qrAPIVersion {
+			t.Errorf("request %s with auth %q, api-version %q", r.URL.Path, user, r.Header.Get("api-version"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"id":"qr_1","reference_id":"dep-1","amount":10000,"status":"ACTIVE","qr_string":"000201","expires_at":"2024-05-01T13:00:00Z"}`)
+	})
+
+	resp, err := c.CreateDeposit(context.Background(), atl.DepositRequest{Method: "qris", Amount: money.FromRupiah(10000), RefID: "dep-1"})
+	if err != nil {
+		t.Fatalf("create deposit: %v", err)
+	}
+	if body["type"] != "DYNAMIC" || body["reference_id"] != "dep-1" || body["expires_at"] != "2024-05-01T13:00:00Z" {
+		t.Fatalf("request body = %v", body)
+	}
+	if resp.ID != "qr_1" || resp.Status != "pending" || resp.Checkout["qr_string"] != "000201" {
+		t.Fatalf("response = %+v", resp)
+	}
+}
+
+func TestCreateDepositInvoice(t *testing.T) {
+	var body map[string]any
+	c := newTestClient(t, 30*time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/invoices" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"id":"inv1","external_id":"dep-2","status":"PENDING","amount":50000,"invoice_url":"https://checkout.example/inv1","expiry_date":"2024-05-01T12:30:00Z"}`)
+	})
+
+	resp, err := c.CreateDeposit(context.Background(), atl.DepositRequest{Method: "bri", Amount: money.FromRupiah(50000), RefID: "dep-2"})
+	if err != nil {
+		t.Fatalf("create deposit: %v", err)
+	}
+	methods, _ := body["payment_methods"].([]any)
+	if len(methods) != 1 || methods[0] != "BRI" || body["invoice_duration"] != float64(1800) {
+		t.Fatalf("request body = %v", body)
+	}
+	if resp.ID != "inv1" || resp.Checkout["redirect_url"] != "https://checkout.example/inv1" {
+		t.Fatalf("response = %+v", resp)
+	}
+}
+
+func TestDepositStatusAndCancel(t *testing.T) {
+	var expired bool
+	c := newTestClient(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/qr_codes/qr_1/payments":
+			_, _ = io.WriteString(w, `{"data":[{"id":"qrpy_1","amount":10000,"status":"SUCCEEDED"}]}`)
+		case "/qr_codes/qr_1":
+			_, _ = io.WriteString(w, `{"id":"qr_1","reference_id":"dep-1","amount":10000,"status":"ACTIVE"}`)
+		case "/v2/invoices/inv1":
+			_, _ = io.WriteString(w, `{"id":"inv1","external_id":"dep-2","status":"SETTLED","amount":50000,"payment_method":"BANK_TRANSFER"}`)
+		case "/invoices/inv2/expire!":
+			expired = true
+			_, _ = io.WriteString(w, `{"id":"inv2","external_id":"dep-3","status":"EXPIRED","amount":50000}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error_code":"INVOICE_NOT_FOUND_ERROR","message":"Could not find invoice"}`)
+		}
+	})
+	ctx := context.Background()
+
+	qr, err := c.DepositStatus(ctx, "qr_1")
+	if err != nil || qr.Status != "success" || qr.RefID != "dep-1" {
+		t.Fatalf("qr status = %+v, %v", qr, err)
+	}
+	inv, err := c.DepositStatus(ctx, "inv1")
+	if err != nil || inv.Status != "success" || inv.Amount.Rupiah() != 50000 {
+		t.Fatalf("invoice status = %+v, %v", inv, err)
+	}
+	if _, err := c.DepositStatus(ctx, "missing"); err == nil {
+		t.Fatalf("expected error for an unknown invoice")
+	}
+	cancelled, err := c.CancelDeposit(ctx, "inv2")
+	if err != nil || !expired || cancelled.Status != "failed" {
+		t.Fatalf("cancel = %+v, %v (expired %v)", cancelled, err, expired)
+	}
+}
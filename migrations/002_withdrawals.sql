@@ -0,0 +1,18 @@
+-- Wallet withdrawals requested by resellers
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    withdrawal_ref TEXT NOT NULL,
+    bank_code TEXT NOT NULL,
+    account_no TEXT NOT NULL,
+    account_name TEXT,
+    amount BIGINT NOT NULL,
+    fee BIGINT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(withdrawal_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_id_created_at ON withdrawals(user_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Operator-managed synonyms consulted before product matching
+CREATE TABLE IF NOT EXISTS catalog_aliases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alias TEXT NOT NULL,
+    replacement TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(alias)
+);
@@ -0,0 +1,11 @@
+-- Operator curation of supplier products (scope: product code or whole category)
+CREATE TABLE IF NOT EXISTS curated_products (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope TEXT NOT NULL CHECK (scope IN ('product', 'category')),
+    key TEXT NOT NULL,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(scope, key)
+);
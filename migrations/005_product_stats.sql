@@ -0,0 +1,10 @@
+-- Per-product supplier outcomes collected from transaction webhooks
+CREATE TABLE IF NOT EXISTS product_stats (
+    product_code TEXT PRIMARY KEY,
+    success_count BIGINT NOT NULL DEFAULT 0,
+    failure_count BIGINT NOT NULL DEFAULT 0,
+    last_status TEXT,
+    last_outcome_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+-- Webhook events whose processing failed, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    headers JSONB,
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'pending',
+    replayed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_status_created_at ON webhook_dead_letters(status, created_at DESC);
@@ -0,0 +1,31 @@
+-- Scheduled promotional campaigns sent through the broadcast subsystem
+CREATE TABLE IF NOT EXISTS campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    template TEXT NOT NULL,
+    segment JSONB,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    throttle_per_minute INT NOT NULL DEFAULT 30,
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'running', 'completed', 'cancelled')),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_status_scheduled_at ON campaigns(status, scheduled_at);
+
+-- One row per campaign recipient, with conversion attribution
+CREATE TABLE IF NOT EXISTS campaign_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id UUID NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    converted_at TIMESTAMPTZ,
+    order_ref TEXT,
+    UNIQUE(campaign_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_deliveries_user_sent_at ON campaign_deliveries(user_id, sent_at DESC);
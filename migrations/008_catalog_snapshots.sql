@@ -0,0 +1,21 @@
+-- Versioned supplier price list snapshots; a pinned snapshot is served instead of live prices
+CREATE TABLE IF NOT EXISTS catalog_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_type TEXT NOT NULL,
+    version INT NOT NULL,
+    checksum TEXT NOT NULL,
+    item_count INT NOT NULL,
+    items JSONB NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(product_type, version)
+);
+
+-- Catalog version each order was quoted against
+CREATE TABLE IF NOT EXISTS order_catalog_versions (
+    order_ref TEXT PRIMARY KEY,
+    product_type TEXT NOT NULL,
+    catalog_version INT NOT NULL,
+    quoted_price BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
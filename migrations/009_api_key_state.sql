@@ -0,0 +1,4 @@
+-- Where a key came from and why it was disabled; disabled keys are never handed to the NLU client
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'config';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
@@ -0,0 +1,3 @@
+-- Daily request/token budgets per key; zero means unlimited
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_request_quota INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_token_quota INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Compact per-user profile summarised from conversation history and injected into prompts
+CREATE TABLE IF NOT EXISTS user_memories (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    message_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
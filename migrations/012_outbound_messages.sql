@@ -0,0 +1,13 @@
+-- Outbound WhatsApp text messages with their delivery and read receipts
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    wa_message_id TEXT PRIMARY KEY,
+    chat_jid TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'sent',
+    attempts INT NOT NULL DEFAULT 0,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    read_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbound_messages_status_sent_at ON outbound_messages(status, sent_at);
@@ -0,0 +1,2 @@
+-- Operator-written purchase instructions appended to success messages
+ALTER TABLE curated_products ADD COLUMN IF NOT EXISTS instructions TEXT;
@@ -0,0 +1,4 @@
+-- When a stuck order was escalated past its SLA; NULL until then
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS sla_escalated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at);
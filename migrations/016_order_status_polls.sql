@@ -0,0 +1,2 @@
+-- When the pending-order poller last asked Atlantic about an order; NULL until then
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_polled_at TIMESTAMPTZ;
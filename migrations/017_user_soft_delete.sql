@@ -0,0 +1,2 @@
+-- Set when a user is anonymized; the row stays so order history still adds up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- How a user first reached the bot (referral, channel, organic); NULL for
+-- users who predate tracking
+ALTER TABLE users ADD COLUMN IF NOT EXISTS acquisition_source TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS acquisition_detail TEXT;
@@ -0,0 +1,10 @@
+-- Who may talk to the bot: a single row edited from the admin API
+CREATE TABLE IF NOT EXISTS sender_policy (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    mode TEXT NOT NULL DEFAULT 'off' CHECK (mode IN ('off', 'allowlist', 'denylist')),
+    numbers JSONB NOT NULL DEFAULT '[]',
+    country_codes JSONB NOT NULL DEFAULT '[]',
+    contacts_only BOOLEAN NOT NULL DEFAULT FALSE,
+    block_business BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
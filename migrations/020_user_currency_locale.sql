@@ -0,0 +1,3 @@
+-- Per-user choice of how amounts are written ("id" Rp10.000, "en" Rp10,000);
+-- NULL follows language_preference
+ALTER TABLE users ADD COLUMN IF NOT EXISTS currency_locale TEXT;
@@ -0,0 +1,13 @@
+-- Time-limited price overrides applied on top of the supplier price list
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_code TEXT NOT NULL,
+    price BIGINT NOT NULL CHECK (price > 0),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    campaign_id UUID REFERENCES campaigns(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sales_ends_at ON flash_sales(ends_at);
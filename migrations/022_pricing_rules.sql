@@ -0,0 +1,12 @@
+-- Markup rules applied to supplier prices (scope: default, category or product code)
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope TEXT NOT NULL CHECK (scope IN ('default', 'category', 'product')),
+    key TEXT NOT NULL DEFAULT '',
+    margin_fixed BIGINT NOT NULL DEFAULT 0 CHECK (margin_fixed >= 0),
+    margin_percent DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (margin_percent >= 0),
+    round_to BIGINT NOT NULL DEFAULT 0 CHECK (round_to >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(scope, key)
+);
@@ -0,0 +1,2 @@
+-- Outbound messages are logged against the user chatting from the recipient JID
+CREATE INDEX IF NOT EXISTS idx_users_wa_jid ON users(wa_jid);
@@ -0,0 +1,8 @@
+-- Atlantic webhook events already processed, so re-deliveries are skipped
+CREATE TABLE IF NOT EXISTS webhook_events (
+    ref_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ref_id, event_type, status)
+);
@@ -0,0 +1,29 @@
+-- Merchant URLs that receive signed order and deposit events
+CREATE TABLE IF NOT EXISTS merchant_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One row per event and merchant URL, with the outcome of the last attempt
+CREATE TABLE IF NOT EXISTS merchant_webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES merchant_webhooks(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT,
+    error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_webhook_deliveries_due ON merchant_webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_merchant_webhook_deliveries_webhook ON merchant_webhook_deliveries(webhook_id, created_at DESC);
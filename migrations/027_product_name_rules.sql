@@ -0,0 +1,10 @@
+-- Display names for supplier products (scope: product code or regex pattern)
+CREATE TABLE IF NOT EXISTS product_name_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope TEXT NOT NULL CHECK (scope IN ('product', 'pattern')),
+    target TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(scope, target)
+);
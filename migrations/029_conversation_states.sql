@@ -0,0 +1,8 @@
+-- Multi-step conversation flows (product list, target, confirmation) kept
+-- when Redis is unavailable, so they survive restarts
+CREATE TABLE IF NOT EXISTS conversation_states (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    state JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Wrong transaction PINs in a row, and when the lockout they caused ends
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_locked_until TIMESTAMPTZ;
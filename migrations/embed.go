@@ -4,5 +4,5 @@ import "embed"
 
 // Files exposes embedded SQL migration files ordered lexicographically.
 //
-//go:embed *.sql sqlite
+//go:embed *.sql sqlite mysql
 var Files embed.FS
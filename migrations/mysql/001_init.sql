@@ -0,0 +1,354 @@
+-- MySQL 8.0.16+ / MariaDB 10.5+ schema. Text compares case-sensitively, as
+-- on Postgres and SQLite, and timestamps are stored in UTC.
+
+-- Users interacting via WhatsApp
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    wa_id VARCHAR(191) NOT NULL UNIQUE,
+    wa_jid VARCHAR(191),
+    display_name TEXT,
+    phone_number VARCHAR(64),
+    language_preference VARCHAR(32) DEFAULT 'id-ID',
+    timezone VARCHAR(64) DEFAULT 'Asia/Jakarta',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    deleted_at DATETIME(6),
+    acquisition_source VARCHAR(64),
+    acquisition_detail TEXT,
+    currency_locale VARCHAR(32),
+    INDEX idx_users_wa_jid (wa_jid)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Message log for auditing conversation context
+CREATE TABLE IF NOT EXISTS messages (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    direction VARCHAR(16) NOT NULL CHECK (direction IN ('incoming', 'outgoing')),
+    message_type VARCHAR(32) NOT NULL,
+    content MEDIUMTEXT,
+    media_url TEXT,
+    raw_payload JSON,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_messages_user_id_created_at (user_id, created_at DESC),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Orders (top up, bill payment, etc)
+CREATE TABLE IF NOT EXISTS orders (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    order_ref VARCHAR(191) NOT NULL UNIQUE,
+    product_code VARCHAR(191) NOT NULL,
+    amount BIGINT NOT NULL,
+    fee BIGINT DEFAULT 0,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    metadata JSON,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    sla_escalated_at DATETIME(6),
+    status_polled_at DATETIME(6),
+    INDEX idx_orders_user_id_status (user_id, status),
+    INDEX idx_orders_status_created_at (status, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Deposit tracking
+CREATE TABLE IF NOT EXISTS deposits (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    deposit_ref VARCHAR(191) NOT NULL UNIQUE,
+    method VARCHAR(64) NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    metadata JSON,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_deposits_user_id_status (user_id, status),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- API keys for Gemini (and other providers, future proof)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    provider VARCHAR(32) NOT NULL,
+    value VARCHAR(191) NOT NULL,
+    priority INT NOT NULL DEFAULT 100,
+    cooldown_until DATETIME(6),
+    source VARCHAR(16) NOT NULL DEFAULT 'config',
+    disabled_at DATETIME(6),
+    disabled_reason VARCHAR(64),
+    daily_request_quota BIGINT NOT NULL DEFAULT 0,
+    daily_token_quota BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (provider, value),
+    INDEX idx_api_keys_provider_priority (provider, priority)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Rate limit buckets (per user or global)
+CREATE TABLE IF NOT EXISTS rate_limits (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    scope VARCHAR(64) NOT NULL,
+    `key` VARCHAR(191) NOT NULL,
+    counter BIGINT NOT NULL DEFAULT 0,
+    reset_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (scope, `key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Wallet withdrawals requested by resellers
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    withdrawal_ref VARCHAR(191) NOT NULL UNIQUE,
+    bank_code VARCHAR(64) NOT NULL,
+    account_no VARCHAR(64) NOT NULL,
+    account_name VARCHAR(191),
+    amount BIGINT NOT NULL,
+    fee BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    metadata JSON,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_withdrawals_user_id_created_at (user_id, created_at DESC),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Operator-managed synonyms consulted before product matching
+CREATE TABLE IF NOT EXISTS catalog_aliases (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    alias VARCHAR(191) NOT NULL UNIQUE,
+    replacement VARCHAR(191) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Operator curation of supplier products (scope: product code or whole category)
+CREATE TABLE IF NOT EXISTS curated_products (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('product', 'category')),
+    `key` VARCHAR(191) NOT NULL,
+    hidden TINYINT(1) NOT NULL DEFAULT 0,
+    reason TEXT,
+    instructions TEXT,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (scope, `key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Per-product supplier outcomes collected from transaction webhooks
+CREATE TABLE IF NOT EXISTS product_stats (
+    product_code VARCHAR(191) NOT NULL PRIMARY KEY,
+    success_count BIGINT NOT NULL DEFAULT 0,
+    failure_count BIGINT NOT NULL DEFAULT 0,
+    last_status VARCHAR(64),
+    last_outcome_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Webhook events whose processing failed, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    headers JSON,
+    payload LONGTEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    replayed_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_webhook_dead_letters_status_created_at (status, created_at DESC)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Scheduled promotional campaigns sent through the broadcast subsystem
+CREATE TABLE IF NOT EXISTS campaigns (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(191) NOT NULL,
+    template TEXT NOT NULL,
+    segment JSON,
+    scheduled_at DATETIME(6) NOT NULL,
+    throttle_per_minute INT NOT NULL DEFAULT 30,
+    status VARCHAR(16) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'running', 'completed', 'cancelled')),
+    started_at DATETIME(6),
+    completed_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_campaigns_status_scheduled_at (status, scheduled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- One row per campaign recipient, with conversion attribution
+CREATE TABLE IF NOT EXISTS campaign_deliveries (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    campaign_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    sent_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    converted_at DATETIME(6),
+    order_ref VARCHAR(191),
+    UNIQUE (campaign_id, user_id),
+    INDEX idx_campaign_deliveries_user_sent_at (user_id, sent_at DESC),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Versioned supplier price list snapshots; a pinned snapshot is served instead of live prices
+CREATE TABLE IF NOT EXISTS catalog_snapshots (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    product_type VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    item_count INT NOT NULL,
+    items LONGTEXT NOT NULL,
+    pinned TINYINT(1) NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (product_type, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Catalog version each order was quoted against
+CREATE TABLE IF NOT EXISTS order_catalog_versions (
+    order_ref VARCHAR(191) NOT NULL PRIMARY KEY,
+    product_type VARCHAR(64) NOT NULL,
+    catalog_version INT NOT NULL,
+    quoted_price BIGINT NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Compact per-user profile summarised from conversation history and injected into prompts
+CREATE TABLE IF NOT EXISTS user_memories (
+    user_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    summary TEXT NOT NULL,
+    message_count INT NOT NULL DEFAULT 0,
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Outbound WhatsApp text messages with their delivery and read receipts
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    wa_message_id VARCHAR(191) NOT NULL PRIMARY KEY,
+    chat_jid VARCHAR(191) NOT NULL,
+    body MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'sent',
+    attempts INT NOT NULL DEFAULT 0,
+    sent_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    delivered_at DATETIME(6),
+    read_at DATETIME(6),
+    INDEX idx_outbound_messages_status_sent_at (status, sent_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Risky actions held until an admin approves or rejects them
+CREATE TABLE IF NOT EXISTS admin_approvals (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    kind VARCHAR(32) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    user_jid VARCHAR(191) NOT NULL,
+    subject_ref VARCHAR(191) NOT NULL,
+    summary TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    decided_by VARCHAR(191),
+    decided_at DATETIME(6),
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_admin_approvals_status_expires_at (status, expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Who may talk to the bot: a single row edited from the admin API
+CREATE TABLE IF NOT EXISTS sender_policy (
+    id INT NOT NULL DEFAULT 1 PRIMARY KEY CHECK (id = 1),
+    mode VARCHAR(16) NOT NULL DEFAULT 'off' CHECK (mode IN ('off', 'allowlist', 'denylist')),
+    numbers JSON NOT NULL,
+    country_codes JSON NOT NULL,
+    contacts_only TINYINT(1) NOT NULL DEFAULT 0,
+    block_business TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Time-limited price overrides applied on top of the supplier price list
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    product_code VARCHAR(191) NOT NULL,
+    price BIGINT NOT NULL CHECK (price > 0),
+    starts_at DATETIME(6) NOT NULL,
+    ends_at DATETIME(6) NOT NULL,
+    campaign_id VARCHAR(36),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CHECK (ends_at > starts_at),
+    INDEX idx_flash_sales_ends_at (ends_at),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Markup rules applied to supplier prices (scope: default, category or product code)
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('default', 'category', 'product')),
+    `key` VARCHAR(191) NOT NULL DEFAULT '',
+    margin_fixed BIGINT NOT NULL DEFAULT 0 CHECK (margin_fixed >= 0),
+    margin_percent DOUBLE NOT NULL DEFAULT 0 CHECK (margin_percent >= 0),
+    round_to BIGINT NOT NULL DEFAULT 0 CHECK (round_to >= 0),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (scope, `key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Atlantic webhook events already processed, so re-deliveries are skipped
+CREATE TABLE IF NOT EXISTS webhook_events (
+    ref_id VARCHAR(191) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    status VARCHAR(64) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (ref_id, event_type, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Merchant URLs that receive signed order and deposit events
+CREATE TABLE IF NOT EXISTS merchant_webhooks (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(191) NOT NULL,
+    events JSON,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- One row per event and merchant URL, with the outcome of the last attempt
+CREATE TABLE IF NOT EXISTS merchant_webhook_deliveries (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    webhook_id VARCHAR(36) NOT NULL,
+    event_id VARCHAR(191) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload LONGTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT,
+    error TEXT,
+    next_attempt_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    delivered_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_merchant_webhook_deliveries_due (status, next_attempt_at),
+    INDEX idx_merchant_webhook_deliveries_webhook (webhook_id, created_at DESC),
+    FOREIGN KEY (webhook_id) REFERENCES merchant_webhooks(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+-- Payment notifications journaled before sending, retried until delivered or dead
+CREATE TABLE IF NOT EXISTS notification_journal (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    chat_jid VARCHAR(191) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    ref_id VARCHAR(191) NOT NULL,
+    body MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    delivered_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_notification_journal_due (status, next_attempt_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
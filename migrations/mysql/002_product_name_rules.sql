@@ -0,0 +1,10 @@
+-- Display names for supplier products (scope: product code or regex pattern)
+CREATE TABLE IF NOT EXISTS product_name_rules (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('product', 'pattern')),
+    target VARCHAR(191) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (scope, target)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
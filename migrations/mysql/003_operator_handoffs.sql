@@ -0,0 +1,9 @@
+-- Conversations handed to a human operator; the bot stays quiet while a row exists
+CREATE TABLE IF NOT EXISTS operator_handoffs (
+    user_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    chat_jid VARCHAR(191) NOT NULL,
+    phone VARCHAR(32),
+    reason TEXT,
+    started_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
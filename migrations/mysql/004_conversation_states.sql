@@ -0,0 +1,9 @@
+-- Multi-step conversation flows (product list, target, confirmation) kept
+-- when Redis is unavailable, so they survive restarts
+CREATE TABLE IF NOT EXISTS conversation_states (
+    user_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    state JSON NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
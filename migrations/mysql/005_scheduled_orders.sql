@@ -0,0 +1,23 @@
+-- Recurring purchases ("isi pulsa 25k tiap tanggal 1") paid from the user's balance
+CREATE TABLE IF NOT EXISTS scheduled_orders (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    chat_jid VARCHAR(191) NOT NULL,
+    product_code VARCHAR(64) NOT NULL,
+    product_name VARCHAR(191) NOT NULL,
+    product_type VARCHAR(32) NOT NULL,
+    customer_id VARCHAR(191) NOT NULL,
+    customer_zone VARCHAR(64) NOT NULL DEFAULT '',
+    frequency VARCHAR(16) NOT NULL,
+    day INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    next_run_at DATETIME(6) NOT NULL,
+    last_run_at DATETIME(6),
+    last_order_ref VARCHAR(191),
+    reminded_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_scheduled_orders_status_next_run_at (status, next_run_at),
+    INDEX idx_scheduled_orders_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
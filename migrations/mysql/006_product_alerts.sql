@@ -0,0 +1,18 @@
+-- Users waiting to hear that a product is back in stock or cheaper
+CREATE TABLE IF NOT EXISTS product_alerts (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    chat_jid VARCHAR(191) NOT NULL,
+    product_code VARCHAR(64) NOT NULL,
+    product_name VARCHAR(191) NOT NULL,
+    product_type VARCHAR(32) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    baseline_price BIGINT NOT NULL,
+    target_price BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    notified_at DATETIME(6),
+    INDEX idx_product_alerts_status (status),
+    INDEX idx_product_alerts_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,2 @@
+-- Salted hash of the PIN users confirm balance transfers with; NULL until set
+ALTER TABLE users ADD COLUMN transaction_pin_hash VARCHAR(255) NULL;
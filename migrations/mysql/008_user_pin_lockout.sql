@@ -0,0 +1,3 @@
+-- Wrong transaction PINs in a row, and when the lockout they caused ends
+ALTER TABLE users ADD COLUMN pin_failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN pin_locked_until DATETIME(6) NULL;
@@ -8,10 +8,15 @@ CREATE TABLE IF NOT EXISTS users (
     language_preference TEXT DEFAULT 'id-ID',
     timezone TEXT DEFAULT 'Asia/Jakarta',
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
+    acquisition_source TEXT,
+    acquisition_detail TEXT,
+    currency_locale TEXT
 );
 
 CREATE INDEX IF NOT EXISTS idx_users_wa_id ON users(wa_id);
+CREATE INDEX IF NOT EXISTS idx_users_wa_jid ON users(wa_jid);
 
 -- Message log for auditing conversation context
 CREATE TABLE IF NOT EXISTS messages (
@@ -39,10 +44,13 @@ CREATE TABLE IF NOT EXISTS orders (
     metadata TEXT, -- JSONB stored as TEXT
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sla_escalated_at DATETIME,
+    status_polled_at DATETIME,
     UNIQUE(order_ref)
 );
 
 CREATE INDEX IF NOT EXISTS idx_orders_user_id_status ON orders(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(status, created_at);
 
 -- Deposit tracking
 CREATE TABLE IF NOT EXISTS deposits (
@@ -67,6 +75,11 @@ CREATE TABLE IF NOT EXISTS api_keys (
     value TEXT NOT NULL,
     priority INTEGER NOT NULL DEFAULT 100,
     cooldown_until DATETIME,
+    source TEXT NOT NULL DEFAULT 'config',
+    disabled_at DATETIME,
+    disabled_reason TEXT,
+    daily_request_quota INTEGER NOT NULL DEFAULT 0,
+    daily_token_quota INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(provider, value)
@@ -84,3 +97,263 @@ CREATE TABLE IF NOT EXISTS rate_limits (
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(scope, key)
 );
+
+-- Wallet withdrawals requested by resellers
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    withdrawal_ref TEXT NOT NULL,
+    bank_code TEXT NOT NULL,
+    account_no TEXT NOT NULL,
+    account_name TEXT,
+    amount BIGINT NOT NULL,
+    fee BIGINT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    metadata TEXT, -- JSONB stored as TEXT
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(withdrawal_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_id_created_at ON withdrawals(user_id, created_at DESC);
+
+-- Operator-managed synonyms consulted before product matching
+CREATE TABLE IF NOT EXISTS catalog_aliases (
+    id TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    replacement TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(alias)
+);
+
+-- Operator curation of supplier products (scope: product code or whole category)
+CREATE TABLE IF NOT EXISTS curated_products (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('product', 'category')),
+    key TEXT NOT NULL,
+    hidden BOOLEAN NOT NULL DEFAULT 0,
+    reason TEXT,
+    instructions TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(scope, key)
+);
+
+-- Per-product supplier outcomes collected from transaction webhooks
+CREATE TABLE IF NOT EXISTS product_stats (
+    product_code TEXT PRIMARY KEY,
+    success_count INTEGER NOT NULL DEFAULT 0,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    last_status TEXT,
+    last_outcome_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Webhook events whose processing failed, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id TEXT PRIMARY KEY,
+    source TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    headers TEXT, -- JSONB stored as TEXT
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'pending',
+    replayed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_status_created_at ON webhook_dead_letters(status, created_at DESC);
+
+-- Scheduled promotional campaigns sent through the broadcast subsystem
+CREATE TABLE IF NOT EXISTS campaigns (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    template TEXT NOT NULL,
+    segment TEXT, -- JSONB stored as TEXT
+    scheduled_at DATETIME NOT NULL,
+    throttle_per_minute INTEGER NOT NULL DEFAULT 30,
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'running', 'completed', 'cancelled')),
+    started_at DATETIME,
+    completed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_status_scheduled_at ON campaigns(status, scheduled_at);
+
+-- One row per campaign recipient, with conversion attribution
+CREATE TABLE IF NOT EXISTS campaign_deliveries (
+    id TEXT PRIMARY KEY,
+    campaign_id TEXT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    converted_at DATETIME,
+    order_ref TEXT,
+    UNIQUE(campaign_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_deliveries_user_sent_at ON campaign_deliveries(user_id, sent_at DESC);
+
+-- Versioned supplier price list snapshots; a pinned snapshot is served instead of live prices
+CREATE TABLE IF NOT EXISTS catalog_snapshots (
+    id TEXT PRIMARY KEY,
+    product_type TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    checksum TEXT NOT NULL,
+    item_count INTEGER NOT NULL,
+    items TEXT NOT NULL, -- JSONB stored as TEXT
+    pinned BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(product_type, version)
+);
+
+-- Catalog version each order was quoted against
+CREATE TABLE IF NOT EXISTS order_catalog_versions (
+    order_ref TEXT PRIMARY KEY,
+    product_type TEXT NOT NULL,
+    catalog_version INTEGER NOT NULL,
+    quoted_price INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Compact per-user profile summarised from conversation history and injected into prompts
+CREATE TABLE IF NOT EXISTS user_memories (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Outbound WhatsApp text messages with their delivery and read receipts
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    wa_message_id TEXT PRIMARY KEY,
+    chat_jid TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'sent',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME,
+    read_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbound_messages_status_sent_at ON outbound_messages(status, sent_at);
+
+-- Risky actions held until an admin approves or rejects them
+CREATE TABLE IF NOT EXISTS admin_approvals (
+    id TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_jid TEXT NOT NULL,
+    subject_ref TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    decided_by TEXT,
+    decided_at DATETIME,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_approvals_status_expires_at ON admin_approvals(status, expires_at);
+
+-- Who may talk to the bot: a single row edited from the admin API
+CREATE TABLE IF NOT EXISTS sender_policy (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    mode TEXT NOT NULL DEFAULT 'off' CHECK (mode IN ('off', 'allowlist', 'denylist')),
+    numbers TEXT NOT NULL DEFAULT '[]',
+    country_codes TEXT NOT NULL DEFAULT '[]',
+    contacts_only BOOLEAN NOT NULL DEFAULT 0,
+    block_business BOOLEAN NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Time-limited price overrides applied on top of the supplier price list
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id TEXT PRIMARY KEY,
+    product_code TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    campaign_id TEXT REFERENCES campaigns(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sales_ends_at ON flash_sales(ends_at);
+
+-- Markup rules applied to supplier prices (scope: default, category or product code)
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('default', 'category', 'product')),
+    key TEXT NOT NULL DEFAULT '',
+    margin_fixed INTEGER NOT NULL DEFAULT 0 CHECK (margin_fixed >= 0),
+    margin_percent REAL NOT NULL DEFAULT 0 CHECK (margin_percent >= 0),
+    round_to INTEGER NOT NULL DEFAULT 0 CHECK (round_to >= 0),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(scope, key)
+);
+
+-- Atlantic webhook events already processed, so re-deliveries are skipped
+CREATE TABLE IF NOT EXISTS webhook_events (
+    ref_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ref_id, event_type, status)
+);
+
+-- Merchant URLs that receive signed order and deposit events
+CREATE TABLE IF NOT EXISTS merchant_webhooks (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT, -- JSONB stored as TEXT
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per event and merchant URL, with the outcome of the last attempt
+CREATE TABLE IF NOT EXISTS merchant_webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES merchant_webhooks(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    error TEXT,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_webhook_deliveries_due ON merchant_webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_merchant_webhook_deliveries_webhook ON merchant_webhook_deliveries(webhook_id, created_at DESC);
+
+-- Payment notifications journaled before sending, retried until delivered or dead
+CREATE TABLE IF NOT EXISTS notification_journal (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_jid TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    ref_id TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_journal_due ON notification_journal(status, next_attempt_at);
@@ -0,0 +1,10 @@
+-- Display names for supplier products (scope: product code or regex pattern)
+CREATE TABLE IF NOT EXISTS product_name_rules (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('product', 'pattern')),
+    target TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(scope, target)
+);
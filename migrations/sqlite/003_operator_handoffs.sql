@@ -0,0 +1,8 @@
+-- Conversations handed to a human operator; the bot stays quiet while a row exists
+CREATE TABLE IF NOT EXISTS operator_handoffs (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    chat_jid TEXT NOT NULL,
+    phone TEXT,
+    reason TEXT,
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Multi-step conversation flows (product list, target, confirmation) kept
+-- when Redis is unavailable, so they survive restarts
+CREATE TABLE IF NOT EXISTS conversation_states (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    state TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
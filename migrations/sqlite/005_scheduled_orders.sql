@@ -0,0 +1,23 @@
+-- Recurring purchases ("isi pulsa 25k tiap tanggal 1") paid from the user's balance
+CREATE TABLE IF NOT EXISTS scheduled_orders (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_jid TEXT NOT NULL,
+    product_code TEXT NOT NULL,
+    product_name TEXT NOT NULL,
+    product_type TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    customer_zone TEXT NOT NULL DEFAULT '',
+    frequency TEXT NOT NULL,
+    day INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active',
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME,
+    last_order_ref TEXT,
+    reminded_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_orders_status_next_run_at ON scheduled_orders(status, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_orders_user_id ON scheduled_orders(user_id);
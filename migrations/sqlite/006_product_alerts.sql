@@ -0,0 +1,18 @@
+-- Users waiting to hear that a product is back in stock or cheaper
+CREATE TABLE IF NOT EXISTS product_alerts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_jid TEXT NOT NULL,
+    product_code TEXT NOT NULL,
+    product_name TEXT NOT NULL,
+    product_type TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    baseline_price INTEGER NOT NULL,
+    target_price INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    notified_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_alerts_status ON product_alerts(status);
+CREATE INDEX IF NOT EXISTS idx_product_alerts_user_id ON product_alerts(user_id);